@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveFile string
+	fromBackup  string
+)
+
+// NewExportCmd produces an ExportCmd which writes the store of a stopped
+// node, or a backup of a running one, to an archive
+func NewExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the store of a stopped node, or a backup of a running one, to an archive",
+		RunE:  exportStore,
+	}
+	AddArchiveFlags(cmd)
+	AddBackupFlag(cmd)
+	return cmd
+}
+
+// NewImportCmd produces an ImportCmd which creates the store of a node from
+// an archive
+func NewImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Create the store of a node from an archive",
+		RunE:  importStore,
+	}
+	AddArchiveFlags(cmd)
+	return cmd
+}
+
+//AddArchiveFlags adds flags to the export and import commands
+func AddArchiveFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&config.Lachesis.DataDir, "datadir", config.Lachesis.DataDir, "Top-level directory for configuration and data")
+	cmd.Flags().StringVar(&config.Lachesis.StoreType, "store-type", config.Lachesis.StoreType, "Database backend: badger or bolt")
+	cmd.Flags().IntVar(&config.Lachesis.NodeConfig.CacheSize, "cache-size", config.Lachesis.NodeConfig.CacheSize, "Number of items in LRU caches")
+	cmd.Flags().StringVar(&archiveFile, "file", "lachesis.archive", "Archive file")
+}
+
+// AddBackupFlag adds the flag of the commands which read a backup of a
+// running node instead of the database of datadir
+func AddBackupFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&fromBackup, "backup", "", "Read this backup of a running node, taken with /admin/backup (badger) or copied (bolt), instead of the database of datadir")
+}
+
+// openBadgerBackup opens the badger backup at path read-only
+func openBadgerBackup(path string, o poset.BadgerOptions) (*poset.BadgerStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return poset.LoadBadgerBackup(f, config.Lachesis.NodeConfig.CacheSize, o)
+}
+
+func storePath() (string, error) {
+	switch config.Lachesis.StoreType {
+	case "badger", "":
+		return config.Lachesis.BadgerDir(), nil
+	case "bolt":
+		return config.Lachesis.BoltPath(), nil
+	}
+	return "", fmt.Errorf("unsupported store type: %s", config.Lachesis.StoreType)
+}
+
+func exportStore(cmd *cobra.Command, args []string) error {
+	path, err := storePath()
+	if err != nil {
+		return err
+	}
+	var store poset.Store
+	switch {
+	case config.Lachesis.StoreType == "bolt":
+		// a bolt backup is a copy of the database file
+		if fromBackup != "" {
+			path = fromBackup
+		}
+		store, err = poset.LoadBoltStore(config.Lachesis.NodeConfig.CacheSize, path)
+	case fromBackup != "":
+		store, err = openBadgerBackup(fromBackup, poset.DefaultBadgerOptions())
+	default:
+		store, err = poset.LoadBadgerStoreReadOnly(config.Lachesis.NodeConfig.CacheSize, path)
+	}
+	if err != nil {
+		return fmt.Errorf("loading store: %s", err)
+	}
+	defer store.Close()
+
+	f, err := os.Create(archiveFile)
+	if err != nil {
+		return fmt.Errorf("creating archive: %s", err)
+	}
+	if err := poset.ExportStore(store, f); err != nil {
+		f.Close()
+		return fmt.Errorf("exporting store: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing archive: %s", err)
+	}
+	fmt.Printf("The store has been exported to: %s\n", archiveFile)
+	return nil
+}
+
+func importStore(cmd *cobra.Command, args []string) error {
+	path, err := storePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("A store already lives under: %s", path)
+	}
+
+	f, err := os.Open(archiveFile)
+	if err != nil {
+		return fmt.Errorf("opening archive: %s", err)
+	}
+	defer f.Close()
+
+	store, err := poset.ImportStore(f, func(participants *peers.Peers) (poset.Store, error) {
+		if config.Lachesis.StoreType == "bolt" {
+			return poset.NewBoltStore(participants, config.Lachesis.NodeConfig.CacheSize, path, &config.Lachesis.PoSConfig)
+		}
+		return poset.NewBadgerStore(participants, config.Lachesis.NodeConfig.CacheSize, path, &config.Lachesis.PoSConfig)
+	})
+	if err != nil {
+		os.RemoveAll(path)
+		return fmt.Errorf("importing archive: %s", err)
+	}
+	if err := store.Close(); err != nil {
+		return fmt.Errorf("closing store: %s", err)
+	}
+	fmt.Printf("The archive has been imported to: %s\n", path)
+	return nil
+}
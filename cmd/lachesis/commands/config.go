@@ -10,6 +10,7 @@ import (
 type CLIConfig struct {
 	Lachesis   lachesis.LachesisConfig `mapstructure:",squash"`
 	ProxyAddr  string                  `mapstructure:"proxy-listen"`
+	ProxyType  string                  `mapstructure:"proxy-type"`
 	ClientAddr string                  `mapstructure:"client-connect"`
 	Standalone bool                    `mapstructure:"standalone"`
 	Log2file   bool                    `mapstructure:"log2file"`
@@ -22,6 +23,7 @@ func NewDefaultCLIConfig() *CLIConfig {
 	return &CLIConfig{
 		Lachesis:   *lachesis.NewDefaultConfig(),
 		ProxyAddr:  "127.0.0.1:1338",
+		ProxyType:  "grpc",
 		ClientAddr: "127.0.0.1:1339",
 		Standalone: false,
 		Log2file:   false,
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/spf13/cobra"
+)
+
+var dryRun bool
+
+// NewDBCmd produces a DBCmd which groups the maintenance commands of the
+// badger database of a stopped node
+func NewDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain the badger database of a stopped node",
+	}
+	cmd.PersistentFlags().StringVar(&config.Lachesis.DataDir, "datadir", config.Lachesis.DataDir, "Top-level directory for configuration and data")
+	cmd.PersistentFlags().IntVar(&config.Lachesis.NodeConfig.CacheSize, "cache-size", config.Lachesis.NodeConfig.CacheSize, "Number of items in LRU caches")
+	cmd.PersistentFlags().StringVar(&config.Lachesis.BadgerOptions.EncryptionKeyFile, "badger-encryption-key", config.Lachesis.BadgerOptions.EncryptionKeyFile, "File holding the hex key the badger store is encrypted with")
+	cmd.PersistentFlags().StringSliceVar(&config.Lachesis.BadgerOptions.OldEncryptionKeyFiles, "badger-old-encryption-keys", config.Lachesis.BadgerOptions.OldEncryptionKeyFiles, "Files holding the keys of earlier rotations of badger-encryption-key")
+	cmd.AddCommand(
+		newMigrateCmd(),
+		newVerifyCmd(),
+		newRepairCmd(),
+		newRotateKeyCmd())
+	return cmd
+}
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the database to the current schema version",
+		RunE:  migrateDB,
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Check the migrations without saving them")
+	return cmd
+}
+
+func migrateDB(cmd *cobra.Command, args []string) error {
+	path := config.Lachesis.BadgerDir()
+	applied, err := poset.MigrateBadgerStore(path, dryRun)
+	if err != nil {
+		return fmt.Errorf("migrating %s: %s", path, err)
+	}
+	if len(applied) == 0 {
+		fmt.Printf("The database is at schema version %d\n", poset.SchemaVersion())
+		return nil
+	}
+	for _, m := range applied {
+		fmt.Printf("%d: %s\n", m.Version, m.Description)
+	}
+	if dryRun {
+		fmt.Printf("%d migrations would be applied\n", len(applied))
+	} else {
+		fmt.Printf("The database has been migrated to schema version %d\n", poset.SchemaVersion())
+	}
+	return nil
+}
+
+func newVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Look for corrupted records and broken indexes in the database, or in a backup of a running node",
+		RunE:  verifyDB,
+	}
+	AddBackupFlag(cmd)
+	return cmd
+}
+
+func newRepairCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repair",
+		Short: "Truncate the database back to its last consistent block",
+		RunE:  repairDB,
+	}
+}
+
+func verifyDB(cmd *cobra.Command, args []string) error {
+	var (
+		store *poset.BadgerStore
+		err   error
+	)
+	if fromBackup != "" {
+		store, err = openBadgerBackup(fromBackup, config.Lachesis.BadgerOptions)
+	} else {
+		store, err = poset.LoadBadgerStoreReadOnlyWithOptions(config.Lachesis.NodeConfig.CacheSize, config.Lachesis.BadgerDir(), config.Lachesis.BadgerOptions)
+	}
+	if err != nil {
+		return fmt.Errorf("loading store: %s", err)
+	}
+	defer store.Close()
+
+	report, err := poset.VerifyBadgerStore(store)
+	if err != nil {
+		return fmt.Errorf("verifying store: %s", err)
+	}
+	printReport(report)
+	if !report.OK() {
+		return fmt.Errorf("the database is corrupted, see lachesis db repair")
+	}
+	return nil
+}
+
+func repairDB(cmd *cobra.Command, args []string) error {
+	store, err := poset.LoadBadgerStoreWithOptions(config.Lachesis.NodeConfig.CacheSize, config.Lachesis.BadgerDir(), config.Lachesis.BadgerOptions)
+	if err != nil {
+		return fmt.Errorf("loading store: %s", err)
+	}
+	defer store.Close()
+
+	report, err := poset.VerifyBadgerStore(store)
+	if err != nil {
+		return fmt.Errorf("verifying store: %s", err)
+	}
+	printReport(report)
+	if report.OK() {
+		return nil
+	}
+	if err := poset.RepairBadgerStore(store, report); err != nil {
+		return fmt.Errorf("repairing store: %s", err)
+	}
+	fmt.Printf("Kept %d events and %d blocks\n", report.ConsistentEvents, report.LastConsistentBlock+1)
+	return nil
+}
+
+func newRotateKeyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Encrypt the whole database with badger-encryption-key, so that the old keys can be dropped",
+		RunE:  rotateKeyDB,
+	}
+}
+
+func rotateKeyDB(cmd *cobra.Command, args []string) error {
+	store, err := poset.LoadBadgerStoreWithOptions(config.Lachesis.NodeConfig.CacheSize, config.Lachesis.BadgerDir(), config.Lachesis.BadgerOptions)
+	if err != nil {
+		return fmt.Errorf("loading store: %s", err)
+	}
+	defer store.Close()
+
+	rotated, err := poset.RotateBadgerStoreKey(store)
+	if err != nil {
+		return fmt.Errorf("rotating the store key: %s", err)
+	}
+	fmt.Printf("Encrypted %d values with the new key, the old keys are no longer needed\n", rotated)
+	return nil
+}
+
+func printReport(report *poset.DBReport) {
+	for _, issue := range report.Issues {
+		fmt.Println(issue)
+	}
+	fmt.Printf("Checked %d events and %d blocks, found %d issues\n", report.Events, report.Blocks, len(report.Issues))
+	if report.Pruned {
+		fmt.Println("The database was pruned, missing events and frames were not reported")
+	}
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Fantom-foundation/go-lachesis/src/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+	"github.com/spf13/viper"
+)
+
+// reloadOnHangup reloads the heartbeat, sync-limit, cache-size and log
+// settings of the running node from the config file, and the flags, on
+// every SIGHUP, until the node is done
+func reloadOnHangup(engine *lachesis.Lachesis) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	logger := engine.Config.Logger
+	for {
+		select {
+		case <-hup:
+		case <-engine.Node.Done():
+			return
+		}
+
+		if err := viper.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				logger.WithError(err).Error("Reading config file on SIGHUP")
+				continue
+			}
+		}
+		_, err := engine.Node.Reload(node.ReloadConfig{
+			HeartbeatTimeout: viper.GetDuration("heartbeat"),
+			SyncLimit:        viper.GetInt64("sync-limit"),
+			CacheSize:        viper.GetInt("cache-size"),
+			LogLevel:         viper.GetString("log"),
+		})
+		if err != nil {
+			logger.WithError(err).Error("Reloading config on SIGHUP")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/spf13/cobra"
+)
+
+var backupFile string
+
+// NewRestoreCmd produces a RestoreCmd which creates the badger database of
+// a node from a backup taken with the /admin/backup endpoint
+func NewRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the badger database of a node from a backup",
+		RunE:  restoreStore,
+	}
+	AddRestoreFlags(cmd)
+	return cmd
+}
+
+//AddRestoreFlags adds flags to the restore command
+func AddRestoreFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&config.Lachesis.DataDir, "datadir", config.Lachesis.DataDir, "Top-level directory for configuration and data")
+	cmd.Flags().IntVar(&config.Lachesis.NodeConfig.CacheSize, "cache-size", config.Lachesis.NodeConfig.CacheSize, "Number of items in LRU caches")
+	cmd.Flags().Float64Var(&config.Lachesis.NodeConfig.BlockQuorum, "block-quorum", config.Lachesis.NodeConfig.BlockQuorum, "Share of the weight of the validators whose signatures a block needs (0 for more than 1/3)")
+	cmd.Flags().StringVar(&backupFile, "file", "lachesis.backup", "Backup file")
+}
+
+func restoreStore(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(backupFile)
+	if err != nil {
+		return fmt.Errorf("opening backup: %s", err)
+	}
+	defer f.Close()
+
+	// the blocks are checked against our own peers, not those of the backup
+	participants, err := peers.NewJSONPeers(config.Lachesis.DataDir).Peers()
+	if err != nil {
+		return fmt.Errorf("loading peers: %s", err)
+	}
+
+	// and their weights are those of our genesis, if we have one
+	var weights map[string]uint64
+	genesis, err := poset.LoadGenesis(config.Lachesis.DataDir)
+	if err != nil {
+		return fmt.Errorf("loading genesis: %s", err)
+	}
+	if genesis != nil {
+		if err := genesis.Validate(participants); err != nil {
+			return err
+		}
+		weights = genesis.Weights()
+	}
+
+	path := config.Lachesis.BadgerDir()
+	store, err := poset.RestoreBadgerStore(f, config.Lachesis.NodeConfig.CacheSize, path,
+		config.Lachesis.BadgerOptions, participants, weights, config.Lachesis.NodeConfig.BlockQuorum)
+	if err != nil {
+		return fmt.Errorf("restoring backup: %s", err)
+	}
+	if err := store.Close(); err != nil {
+		return fmt.Errorf("closing store: %s", err)
+	}
+	fmt.Printf("The backup has been restored to: %s\n", path)
+	return nil
+}
@@ -54,31 +54,81 @@ func runSingleLachesis(config *CLIConfig) error {
 
 	config.Lachesis.Logger.WithFields(logrus.Fields{
 		"proxy-listen":   config.ProxyAddr,
+		"proxy-type":     config.ProxyType,
 		"client-connect": config.ClientAddr,
 		"standalone":     config.Standalone,
 		"service-only":   config.Lachesis.ServiceOnly,
 
-		"lachesis.datadir":        config.Lachesis.DataDir,
-		"lachesis.bindaddr":       config.Lachesis.BindAddr,
-		"lachesis.service-listen": config.Lachesis.ServiceAddr,
-		"lachesis.maxpool":        config.Lachesis.MaxPool,
-		"lachesis.store":          config.Lachesis.Store,
-		"lachesis.loadpeers":      config.Lachesis.LoadPeers,
-		"lachesis.log":            config.Lachesis.LogLevel,
-
-		"lachesis.node.heartbeat":  config.Lachesis.NodeConfig.HeartbeatTimeout,
-		"lachesis.node.tcptimeout": config.Lachesis.NodeConfig.TCPTimeout,
-		"lachesis.node.cachesize":  config.Lachesis.NodeConfig.CacheSize,
-		"lachesis.node.synclimit":  config.Lachesis.NodeConfig.SyncLimit,
+		"lachesis.datadir":           config.Lachesis.DataDir,
+		"lachesis.bindaddr":          config.Lachesis.BindAddr,
+		"lachesis.service-listen":    config.Lachesis.ServiceAddr,
+		"lachesis.admin":             config.Lachesis.Admin,
+		"lachesis.service-auth":      config.Lachesis.ServiceToken != "" || config.Lachesis.ServiceJWTSecret != "",
+		"lachesis.pprof":             config.Lachesis.Pprof,
+		"lachesis.cors-origins":      config.Lachesis.CORSOrigins,
+		"lachesis.cors-methods":      config.Lachesis.CORSMethods,
+		"lachesis.service-tls-cert":  config.Lachesis.ServiceTLSCert,
+		"lachesis.rate-limit":        config.Lachesis.RateLimit,
+		"lachesis.global-rate-limit": config.Lachesis.GlobalRateLimit,
+		"lachesis.maxpool":           config.Lachesis.MaxPool,
+		"lachesis.store":             config.Lachesis.Store,
+		"lachesis.store-type":        config.Lachesis.StoreType,
+		"lachesis.chain-id":          config.Lachesis.ChainID,
+		"lachesis.pg-mirror":         config.Lachesis.PgMirror != "",
+		"lachesis.prune-interval":    config.Lachesis.PruneInterval,
+		"lachesis.prune-retain":      config.Lachesis.PruneRetainRounds,
+		"lachesis.store-quota":       config.Lachesis.StoreQuota,
+		"lachesis.cold-storage-dir":  config.Lachesis.ColdStorageDir,
+		"lachesis.store-writes":      config.Lachesis.StoreWrites,
+		"lachesis.event-wal":         config.Lachesis.EventWAL,
+		"lachesis.store-write-queue": config.Lachesis.StoreWriteQueue,
+		"lachesis.badger":            config.Lachesis.BadgerOptions,
+		"lachesis.snapshot-interval": config.Lachesis.SnapshotInterval,
+		"lachesis.snapshot-blocks":   config.Lachesis.SnapshotBlocks,
+		"lachesis.loadpeers":         config.Lachesis.LoadPeers,
+		"lachesis.log":               config.Lachesis.LogLevel,
+
+		"lachesis.node.heartbeat":       config.Lachesis.NodeConfig.HeartbeatTimeout,
+		"lachesis.node.tcptimeout":      config.Lachesis.NodeConfig.TCPTimeout,
+		"lachesis.node.cachesize":       config.Lachesis.NodeConfig.CacheSize,
+		"lachesis.node.synclimit":       config.Lachesis.NodeConfig.SyncLimit,
+		"lachesis.node.syncpeers":       config.Lachesis.NodeConfig.SyncPeers,
+		"lachesis.node.gossipfanout":    config.Lachesis.NodeConfig.GossipFanout,
+		"lachesis.node.antientropy":     config.Lachesis.NodeConfig.AntiEntropyInterval,
+		"lachesis.node.syncpipeline":    config.Lachesis.NodeConfig.SyncPipeline,
+		"lachesis.node.syncchunks":      config.Lachesis.NodeConfig.SyncChunks,
+		"lachesis.node.observer":        config.Lachesis.NodeConfig.Observer,
+		"lachesis.node.follower":        config.Lachesis.NodeConfig.Follower,
+		"lachesis.node.noemptyevents":   config.Lachesis.NodeConfig.NoEmptyEvents,
+		"lachesis.node.maxsilence":      config.Lachesis.NodeConfig.MaxSilence,
+		"lachesis.node.maxeventtxs":     config.Lachesis.NodeConfig.MaxEventTxs,
+		"lachesis.node.maxeventpayload": config.Lachesis.NodeConfig.MaxEventPayload,
+		"lachesis.node.blockquorum":     config.Lachesis.NodeConfig.BlockQuorum,
+		"lachesis.node.commitonquorum":  config.Lachesis.NodeConfig.CommitOnQuorum,
+		"lachesis.node.appbuffer":       config.Lachesis.NodeConfig.AppBuffer,
+		"lachesis.node.apptimeout":      config.Lachesis.NodeConfig.AppTimeout,
+		"lachesis.node.apppolicy":       config.Lachesis.NodeConfig.AppTimeoutPolicy,
+		"lachesis.node.appbreaker":      config.Lachesis.NodeConfig.AppBreakerThreshold,
+		"lachesis.node.apperrors":       config.Lachesis.NodeConfig.AppErrorPolicy,
+		"lachesis.node.panicpolicy":     config.Lachesis.NodeConfig.PanicPolicy,
+		"lachesis.node.panicrestarts":   config.Lachesis.NodeConfig.PanicRestarts,
+		"lachesis.node.readymaxlag":     config.Lachesis.NodeConfig.ReadyMaxLag,
+		"lachesis.node.maxpooltxs":      config.Lachesis.NodeConfig.MaxPoolTxs,
+		"lachesis.node.maxpoolbytes":    config.Lachesis.NodeConfig.MaxPoolBytes,
+		"lachesis.node.poolpolicy":      config.Lachesis.NodeConfig.PoolPolicy,
+		"lachesis.node.maxinternaltxs":  config.Lachesis.NodeConfig.MaxInternalTxs,
+		"lachesis.node.txdedupwindow":   config.Lachesis.NodeConfig.TxDedupWindow,
+		"lachesis.node.txkeywindow":     config.Lachesis.NodeConfig.TxKeyWindow,
+		"lachesis.node.txttl":           config.Lachesis.NodeConfig.TxTTL,
+		"lachesis.node.txgossip":        config.Lachesis.NodeConfig.TxGossip,
+		"lachesis.node.dedupblocktxs":   config.Lachesis.NodeConfig.DedupBlockTxs,
+		"lachesis.node.backpressure":    config.Lachesis.NodeConfig.CommitBackpressure,
+		"lachesis.node.faultinjection":  config.Lachesis.NodeConfig.FaultInjection,
+		"lachesis.node.verifyworkers":   config.Lachesis.NodeConfig.VerifyWorkers,
 	}).Debug("RUN")
 
 	if !config.Standalone {
-		p, err := aproxy.NewGrpcAppProxy(
-			config.ProxyAddr,
-			config.Lachesis.NodeConfig.HeartbeatTimeout,
-			config.Lachesis.Logger,
-		)
-
+		p, err := newAppProxy(config)
 		if err != nil {
 			config.Lachesis.Logger.Error("Cannot initialize socket AppProxy:", err)
 			return nil
@@ -117,9 +167,41 @@ func runSingleLachesis(config *CLIConfig) error {
 	}
 
 	engine.Node.Register()
+	go reloadOnHangup(engine)
 	engine.Run()
 
-	return nil
+	return engine.Err()
+}
+
+// newAppProxy listens for the app on the proxy address with the configured
+// protocol
+func newAppProxy(config *CLIConfig) (aproxy.AppProxy, error) {
+	switch config.ProxyType {
+	case "grpc":
+		return aproxy.NewGrpcAppProxy(
+			config.ProxyAddr,
+			config.Lachesis.NodeConfig.HeartbeatTimeout,
+			config.Lachesis.Logger,
+		)
+	case "socket":
+		network, addr := aproxy.SplitSocketAddr(config.ProxyAddr)
+		return aproxy.NewSocketAppProxy(
+			network,
+			addr,
+			config.Lachesis.NodeConfig.HeartbeatTimeout,
+			aproxy.DefaultSocketHeartbeat,
+			config.Lachesis.Logger,
+		)
+	case "websocket":
+		return aproxy.NewWebSocketAppProxy(
+			config.ProxyAddr,
+			config.Lachesis.NodeConfig.HeartbeatTimeout,
+			aproxy.DefaultSocketHeartbeat,
+			config.Lachesis.Logger,
+		)
+	default:
+		return nil, fmt.Errorf("unknown proxy type %q", config.ProxyType)
+	}
 }
 
 //AddRunFlags adds flags to the Run command
@@ -129,6 +211,7 @@ func AddRunFlags(cmd *cobra.Command) {
 	config := NewDefaultCLIConfig()
 
 	cmd.Flags().String("datadir", config.Lachesis.DataDir, "Top-level directory for configuration and data")
+	cmd.Flags().String("chain-id", config.Lachesis.ChainID, "Chain ID of the network, required without a genesis.json in the datadir")
 	cmd.Flags().String("log", config.Lachesis.LogLevel, "debug, info, warn, error, fatal, panic")
 	cmd.Flags().Bool("log2file", config.Log2file, "duplicate log output into file lachesis_<BindAddr>.log")
 	switch runtime.GOOS {
@@ -149,23 +232,89 @@ func AddRunFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool("standalone", config.Standalone, "Do not create a proxy")
 	cmd.Flags().Bool("service-only", config.Lachesis.ServiceOnly, "Only host the http service")
 	cmd.Flags().StringP("proxy-listen", "p", config.ProxyAddr, "Listen IP:Port for lachesis proxy")
+	cmd.Flags().String("proxy-type", config.ProxyType, "Protocol of the lachesis proxy: grpc, socket (IP:Port or unix:///path/to/socket) or websocket")
 	cmd.Flags().StringP("client-connect", "c", config.ClientAddr, "IP:Port to connect to client")
 
 	// Service
-	cmd.Flags().StringP("service-listen", "s", config.Lachesis.ServiceAddr, "Listen IP:Port for HTTP service")
+	cmd.Flags().StringP("service-listen", "s", config.Lachesis.ServiceAddr, "Listen IP:Port, or unix:///path/to/socket, for HTTP service")
+	cmd.Flags().Bool("admin", config.Lachesis.Admin, "Serve the /admin/ endpoints on the HTTP service")
+	cmd.Flags().Bool("pprof", config.Lachesis.Pprof, "Serve the /debug/pprof/ profiles on the HTTP service")
+	cmd.Flags().StringSlice("cors-origins", config.Lachesis.CORSOrigins, "Origins browsers may call the HTTP service from, * for any (empty to disable CORS)")
+	cmd.Flags().StringSlice("cors-methods", config.Lachesis.CORSMethods, "Methods browsers may call the HTTP service with")
+	cmd.Flags().String("service-tls-cert", config.Lachesis.ServiceTLSCert, "PEM certificate file of the HTTP service, which serves HTTPS with service-tls-key")
+	cmd.Flags().String("service-tls-key", config.Lachesis.ServiceTLSKey, "PEM key file of the HTTP service certificate")
+	cmd.Flags().Float64("rate-limit", config.Lachesis.RateLimit, "Requests per second every client IP may make to the HTTP service without credentials (0 for no limit)")
+	cmd.Flags().Int("rate-burst", config.Lachesis.RateBurst, "Requests a client IP may make at once over rate-limit")
+	cmd.Flags().Float64("global-rate-limit", config.Lachesis.GlobalRateLimit, "Requests per second all the clients may make to the HTTP service without credentials (0 for no limit)")
+	cmd.Flags().Int("global-rate-burst", config.Lachesis.GlobalRateBurst, "Requests all the clients may make at once over global-rate-limit")
+	cmd.Flags().String("service-token", config.Lachesis.ServiceToken, "Bearer token required by the admin and transaction submission endpoints of the HTTP service")
+	cmd.Flags().String("service-jwt-secret", config.Lachesis.ServiceJWTSecret, "HS256 secret of the JWTs accepted by the admin and transaction submission endpoints of the HTTP service")
 
 	// Store
 	cmd.Flags().Bool("store", config.Lachesis.Store, "Use badgerDB instead of in-mem DB")
+	cmd.Flags().String("store-type", config.Lachesis.StoreType, "Database used with --store: badger, bolt or a registered store")
+	cmd.Flags().Duration("prune-interval", config.Lachesis.PruneInterval, "Time between badger store maintenance runs (0 to disable)")
+	cmd.Flags().Int64("prune-retain-rounds", config.Lachesis.PruneRetainRounds, "Number of recent rounds kept by the badger store maintenance (0 to keep all)")
+	cmd.Flags().String("cold-storage-dir", config.Lachesis.ColdStorageDir, "Directory, or mounted bucket, which receives the rounds pruned from the badger store")
+	cmd.Flags().Int64("store-quota", config.Lachesis.StoreQuota, "Size in bytes of the badger store past which old rounds are pruned, then the node stops (0 for no quota)")
+	cmd.Flags().String("store-writes", config.Lachesis.StoreWrites, "Badger store writes: direct, strict (sync every commit) or async (journaled write-behind)")
+	cmd.Flags().Int("store-write-queue", config.Lachesis.StoreWriteQueue, "Number of batches queued to the disk with --store-writes=async")
+	cmd.Flags().Bool("event-wal", config.Lachesis.EventWAL, "Journal the created events and the pooled transactions with --store, replayed after a crash")
+	cmd.Flags().Int64("badger-value-log-size", config.Lachesis.BadgerOptions.ValueLogFileSize, "Size in bytes of the badger value log files")
+	cmd.Flags().Int("badger-memtables", config.Lachesis.BadgerOptions.NumMemtables, "Number of badger tables kept in memory")
+	cmd.Flags().Int64("badger-table-size", config.Lachesis.BadgerOptions.MaxTableSize, "Size in bytes of the badger memtables and tables")
+	cmd.Flags().String("badger-table-loading", config.Lachesis.BadgerOptions.TableLoadingMode, "How badger loads its tables: mmap, ram or fileio")
+	cmd.Flags().String("badger-value-log-loading", config.Lachesis.BadgerOptions.ValueLogLoadingMode, "How badger loads its value log: mmap, ram or fileio")
+	cmd.Flags().Bool("badger-sync-writes", config.Lachesis.BadgerOptions.SyncWrites, "Make badger sync every write to disk")
+	cmd.Flags().String("badger-compression", config.Lachesis.BadgerOptions.Compression, "Badger table compression (only none is supported)")
+	cmd.Flags().String("badger-encryption-key", config.Lachesis.BadgerOptions.EncryptionKeyFile, "File holding the hex key a new badger store is encrypted with, and an encrypted one read with")
+	cmd.Flags().StringSlice("badger-old-encryption-keys", config.Lachesis.BadgerOptions.OldEncryptionKeyFiles, "Files holding the keys of earlier rotations of badger-encryption-key")
+	cmd.Flags().Duration("snapshot-interval", config.Lachesis.SnapshotInterval, "Time between snapshots of the in-mem store to datadir (0 to disable)")
+	cmd.Flags().Int64("snapshot-blocks", config.Lachesis.SnapshotBlocks, "Number of new blocks between snapshots of the in-mem store (0 to disable)")
+	cmd.Flags().String("pg-mirror", config.Lachesis.PgMirror, "PostgreSQL connection string to mirror finalized blocks to")
 	cmd.Flags().Int("cache-size", config.Lachesis.NodeConfig.CacheSize, "Number of items in LRU caches")
 
 	// Node configuration
 	cmd.Flags().Duration("heartbeat", config.Lachesis.NodeConfig.HeartbeatTimeout, "Time between gossips")
 	cmd.Flags().Int64("sync-limit", config.Lachesis.NodeConfig.SyncLimit, "Max number of events for sync")
+	cmd.Flags().Int("sync-peers", config.Lachesis.NodeConfig.SyncPeers, "Number of peers a gossip pulls from at once")
+	cmd.Flags().Int("gossip-fanout", config.Lachesis.NodeConfig.GossipFanout, "Number of peers a gossip pushes the events to, the synced ones plus random others")
+	cmd.Flags().Duration("anti-entropy-interval", config.Lachesis.NodeConfig.AntiEntropyInterval, "Time between reconciliations of the known events with a random peer (0 to disable)")
+	cmd.Flags().Int("sync-chunks", config.Lachesis.NodeConfig.SyncChunks, "Number of chunks of sync-limit events a node catches up by sync before it fast forwards (1 to fast forward past sync-limit)")
+	cmd.Flags().Int("sync-pipeline", config.Lachesis.NodeConfig.SyncPipeline, "Number of fetched sync responses queued for insertion while the next is fetched (0 to disable)")
+	cmd.Flags().Int("verify-workers", config.Lachesis.NodeConfig.VerifyWorkers, "Number of goroutines verifying the events of a sync (0 for GOMAXPROCS)")
+	cmd.Flags().Int("max-event-txs", config.Lachesis.NodeConfig.MaxEventTxs, "Max number of transactions per event (0 for no limit)")
+	cmd.Flags().Int("max-event-payload", config.Lachesis.NodeConfig.MaxEventPayload, "Max size in bytes of the transactions of an event")
+	cmd.Flags().Bool("observer", config.Lachesis.NodeConfig.Observer, "Follow the network without creating events (pubkey must not be in peers.json)")
+	cmd.Flags().Bool("follower", config.Lachesis.NodeConfig.Follower, "Follow the network as an observer which holds no key, for API and explorer nodes")
+	cmd.Flags().Bool("no-empty-events", config.Lachesis.NodeConfig.NoEmptyEvents, "Skip the events with no transaction which bring nothing new to the DAG")
+	cmd.Flags().Duration("max-silence", config.Lachesis.NodeConfig.MaxSilence, "Time after which no-empty-events creates an event anyway (0 for no bound)")
+	cmd.Flags().Float64("block-quorum", config.Lachesis.NodeConfig.BlockQuorum, "A block needs the signatures of validators of more than this share of the weight (0 for 1/3)")
+	cmd.Flags().Bool("commit-on-quorum", config.Lachesis.NodeConfig.CommitOnQuorum, "Commit blocks to the app only once they reach the block quorum")
+	cmd.Flags().Int("app-buffer", config.Lachesis.NodeConfig.AppBuffer, "Number of blocks kept in memory while the app is disconnected")
+	cmd.Flags().Duration("app-timeout", config.Lachesis.NodeConfig.AppTimeout, "Deadline of the commit, snapshot and restore calls to the app (0 for none)")
+	cmd.Flags().String("app-timeout-policy", config.Lachesis.NodeConfig.AppTimeoutPolicy, "What an expired commit does: retry, halt or degrade")
+	cmd.Flags().Int("app-breaker", config.Lachesis.NodeConfig.AppBreakerThreshold, "Number of consecutive expired app calls which pause the calls for one app-timeout")
+	cmd.Flags().String("app-error-policy", config.Lachesis.NodeConfig.AppErrorPolicy, "What a block the app fails does: retry, halt or skip")
+	cmd.Flags().String("panic-policy", config.Lachesis.NodeConfig.PanicPolicy, "What a panic of a background loop does: restart (up to panic-restarts times) or fail")
+	cmd.Flags().Int("panic-restarts", config.Lachesis.NodeConfig.PanicRestarts, "Number of panics of each background loop recovered under panic-policy=restart")
+	cmd.Flags().Int("max-pool-txs", config.Lachesis.NodeConfig.MaxPoolTxs, "Max number of transactions waiting for an event (0 for no limit)")
+	cmd.Flags().Int("max-pool-bytes", config.Lachesis.NodeConfig.MaxPoolBytes, "Max size in bytes of the transactions waiting for an event (0 for no limit)")
+	cmd.Flags().Int("max-internal-txs", config.Lachesis.NodeConfig.MaxInternalTxs, "Max number of internal transactions waiting for an event (0 for no limit)")
+	cmd.Flags().String("pool-policy", config.Lachesis.NodeConfig.PoolPolicy, "What a full transaction pool does: backpressure (refuse new transactions) or evict-oldest")
+	cmd.Flags().Int("tx-dedup-window", config.Lachesis.NodeConfig.TxDedupWindow, "Number of the last pooled or committed transactions whose duplicates are dropped (0 to only drop those in the pool)")
+	cmd.Flags().Int("tx-key-window", config.Lachesis.NodeConfig.TxKeyWindow, "Number of the last idempotency keys whose transaction submissions are dropped")
+	cmd.Flags().Duration("tx-ttl", config.Lachesis.NodeConfig.TxTTL, "Time a transaction waits in the pool for an event before it expires (0 to disable)")
+	cmd.Flags().Bool("tx-gossip", config.Lachesis.NodeConfig.TxGossip, "Push the submitted transactions to the pools of the peers")
+	cmd.Flags().Bool("dedup-block-txs", config.Lachesis.NodeConfig.DedupBlockTxs, "Keep a transaction once per block and drop those of the previous block (all validators must agree)")
+	cmd.Flags().Int("commit-backpressure", config.Lachesis.NodeConfig.CommitBackpressure, "Number of blocks waiting for the app past which the node pauses the intake of events (0 to disable)")
+	cmd.Flags().Int64("ready-max-lag", config.Lachesis.NodeConfig.ReadyMaxLag, "Number of rounds waiting for consensus past which /readyz fails (0 to disable)")
 
 	// Test
 	cmd.Flags().Bool("test", config.Lachesis.Test, "Enable testing (sends transactions to random nodes in the network)")
 	cmd.Flags().Uint64("test_n", config.Lachesis.TestN, "Number of transactions to send")
 	cmd.Flags().Uint64("test_delay", config.Lachesis.TestDelay, "Number of second to delay before sending transactions")
+	cmd.Flags().Bool("fault-injection", config.Lachesis.NodeConfig.FaultInjection, "Let /admin/faults inject faults in the RPCs and the store, for resilience tests only")
 }
 
 //Bind all flags and read the config into viper
@@ -13,6 +13,10 @@ func main() {
 	rootCmd.AddCommand(
 		cmd.VersionCmd,
 		cmd.NewKeygenCmd(),
+		cmd.NewExportCmd(),
+		cmd.NewImportCmd(),
+		cmd.NewRestoreCmd(),
+		cmd.NewDBCmd(),
 		cmd.NewRunCmd())
 
 	//Do not print usage when error occurs
@@ -33,6 +33,7 @@ do
     --client-connect="172.77.5.$(($N+$i)):1339" \
     --service-listen="172.77.5.$i:80" \
     --sync-limit=1000 \
+    --chain-id=lachesis-demo \
     --store \
     --log="debug"
 
@@ -31,7 +31,7 @@ for ip in $(jq -rc '.[].NetAddr' "$DATAL_DIR/lachesis_data_dir/peers.json"); do
 done
 
 # Run multi lachesis
-#GOMAXPROCS=$(($logicalCpuCount - 1)) "$BUILD_DIR/lachesis_$TARGET_OS" run --datadir "$DATAL_DIR/lachesis_data_dir" --store --listen="$node_addr":12000 --log=warn --heartbeat=5s -p "$node_addr":9000 --test --test_n=10 --test_delay=10
+#GOMAXPROCS=$(($logicalCpuCount - 1)) "$BUILD_DIR/lachesis_$TARGET_OS" run --datadir "$DATAL_DIR/lachesis_data_dir" --chain-id=lachesis-multi --store --listen="$node_addr":12000 --log=warn --heartbeat=5s -p "$node_addr":9000 --test --test_n=10 --test_delay=10
 
 declare -i debug=0
 while getopts "d" opt; do
@@ -46,9 +46,9 @@ shift $((OPTIND-1))
 [ "${1:-}" = "--" ] && shift
 
 if [ "$debug" == 0 ]; then
-  GOMAXPROCS=$(($logicalCpuCount - 1)) "$BUILD_DIR/lachesis_$TARGET_OS" run --datadir "$DATAL_DIR/lachesis_data_dir" --store --listen="$node_addr":12000 --log=warn --heartbeat=5s -p "$node_addr":9000 --test --test_n=10 --test_delay=10
+  GOMAXPROCS=$(($logicalCpuCount - 1)) "$BUILD_DIR/lachesis_$TARGET_OS" run --datadir "$DATAL_DIR/lachesis_data_dir" --chain-id=lachesis-multi --store --listen="$node_addr":12000 --log=warn --heartbeat=5s -p "$node_addr":9000 --test --test_n=10 --test_delay=10
 else
-  GOMAXPROCS=$(($logicalCpuCount - 1)) dlv --listen=localhost:37555 --headless=true --api-version=2 --backend=default exec "$BUILD_DIR/lachesis_$TARGET_OS" -- run --datadir "$DATAL_DIR/lachesis_data_dir" --store --listen="$node_addr":12000 --log=warn --heartbeat=5s -p "$node_addr":9000 --test --test_n=10 --test_delay=10
+  GOMAXPROCS=$(($logicalCpuCount - 1)) dlv --listen=localhost:37555 --headless=true --api-version=2 --backend=default exec "$BUILD_DIR/lachesis_$TARGET_OS" -- run --datadir "$DATAL_DIR/lachesis_data_dir" --chain-id=lachesis-multi --store --listen="$node_addr":12000 --log=warn --heartbeat=5s -p "$node_addr":9000 --test --test_n=10 --test_delay=10
 fi
 
 declare -i rc=$?
@@ -38,7 +38,7 @@ for host in $(jq -rc '.[].NetAddr' "$PEERS_DIR/peers.json"); do
 
   node_dir="$PEERS_DIR/nodes/$node_num_p"
   cp "$PEERS_DIR/peers.json" "$node_dir"
-  ./lachesis run  --log=info --listen="$host" --datadir "$PEERS_DIR/nodes/$node_num_p" --heartbeat=4s --store -p "$ip:$proxy_port" -s "$ip:$service_port" --test &
+  ./lachesis run  --log=info --chain-id=lachesis-local --listen="$host" --datadir "$PEERS_DIR/nodes/$node_num_p" --heartbeat=4s --store -p "$ip:$proxy_port" -s "$ip:$service_port" --test &
 
   #"$DIR/spin.bash" "$node_num_p" "$ip"
   ((node_num++))
@@ -27,7 +27,7 @@ func NewDummySocketClient(addr string, logger *logrus.Logger) (*DummyClient, err
 		return nil, err
 	}
 
-	return NewDummyClient(lachesisProxy, nil, logger)
+	return NewDummyClient(lachesisProxy, NewState(logger), logger)
 }
 
 // NewDummyClient instantiates an implementation of the dummy app
@@ -8,7 +8,6 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Fantom-foundation/go-lachesis/src/common"
-	"github.com/Fantom-foundation/go-lachesis/src/crypto"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy"
 	"github.com/Fantom-foundation/go-lachesis/src/utils"
@@ -81,27 +80,26 @@ func TestDummySocketClient(t *testing.T) {
 	_, err = NewDummyClient(lachesisProxy, state, logger)
 	assertO.NoError(err)
 
-	initialStateHash := state.stateHash
 	//create a few blocks
 	blocks := [5]poset.Block{}
 	for i := int64(0); i < 5; i++ {
-		blocks[i] = poset.NewBlock(i, i+1, []byte{}, [][]byte{[]byte(fmt.Sprintf("block %d transaction", i))})
+		blocks[i] = poset.NewBlock(i, i+1, []byte{}, [][]byte{[]byte(fmt.Sprintf("set key%d block %d", i, i))})
 	}
 
 	<-time.After(timeout / 4)
 
 	//commit first block and check that the client's statehash is correct
-	stateHash, err := appProxy.CommitBlock(blocks[0])
+	res, err := appProxy.CommitBlock(blocks[0])
 	assertO.NoError(err)
 
-	expectedStateHash := crypto.Keccak256(append([][]byte{initialStateHash}, blocks[0].Transactions()...)...)
+	expectedStateHash := state.GetStateHash()
 
-	assertO.Equal(expectedStateHash, stateHash)
+	assertO.Equal(expectedStateHash, res.StateHash)
 
 	snapshot, err := appProxy.GetSnapshot(blocks[0].Index())
 	assertO.NoError(err)
 
-	assertO.Equal(expectedStateHash, snapshot)
+	assertO.Equal(`{"key0":"block 0"}`, string(snapshot))
 
 	//commit a few more blocks, then attempt to restore back to block 0 state
 	for i := 1; i < 5; i++ {
@@ -109,6 +107,7 @@ func TestDummySocketClient(t *testing.T) {
 		assertO.NoError(err)
 	}
 
-	err = appProxy.Restore(snapshot)
+	stateHash, err := appProxy.Restore(snapshot)
 	assertO.NoError(err)
+	assertO.Equal(expectedStateHash, stateHash)
 }
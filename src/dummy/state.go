@@ -1,28 +1,51 @@
 package dummy
 
 import (
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/Fantom-foundation/go-lachesis/src/crypto"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
 )
 
 /*
  * The dummy App is used for testing and as an example for building Lachesis
- * applications. Here, we define the dummy's state which doesn't really do
- * anything useful. It saves and logs block transactions. The state hash is
- * computed by cumulatively hashing transactions together as they come in.
- * Snapshots correspond to the state hash resulting from executing a the block's
- * transactions.
+ * applications. It is a key-value store whose transactions are text
+ * commands:
+ *
+ *   set <key> <value>
+ *   get <key>
+ *   delete <key>
+ *
+ * Other transactions stay in the blocks but fail, with an error receipt. The
+ * state hash is the hash of the sorted key-value pairs, so every node which
+ * commits the same blocks reports the same state hash. Snapshots are the
+ * key-value pairs in JSON, as of a block.
  */
 
-// State implements ProxyHandler
+// Receipt codes of the dummy transactions
+const (
+	// CodeInvalidTx is the code of the transactions which are not commands
+	CodeInvalidTx uint32 = 1
+	// CodeKeyNotFound is the code of a get of a key which is not set
+	CodeKeyNotFound uint32 = 2
+)
+
+// snapshotRetain is the number of recent blocks whose snapshot is kept
+const snapshotRetain = 100
+
+// State implements ProxyHandler, CommitReceiptsHandler and QueryHandler
 type State struct {
 	logger       *logrus.Logger
 	committedTxs [][]byte
+	kv           map[string]string
 	stateHash    []byte
 	snapshots    map[int64][]byte
 	locker       sync.Mutex
@@ -33,9 +56,10 @@ func NewState(logger *logrus.Logger) *State {
 	state := &State{
 		logger:       logger,
 		committedTxs: [][]byte{},
-		stateHash:    []byte{},
+		kv:           make(map[string]string),
 		snapshots:    make(map[int64][]byte),
 	}
+	state.stateHash = state.hash()
 	logger.Info("Init Dummy State")
 
 	return state
@@ -47,16 +71,23 @@ func NewState(logger *logrus.Logger) *State {
 
 // CommitHandler triggers on block received
 func (s *State) CommitHandler(block poset.Block) ([]byte, error) {
+	res, err := s.CommitReceiptsHandler(block)
+	return res.StateHash, err
+}
+
+// CommitReceiptsHandler applies the transactions of the block and returns
+// one receipt per transaction
+func (s *State) CommitReceiptsHandler(block poset.Block) (proxy.CommitResult, error) {
 	s.locker.Lock()
 	defer s.locker.Unlock()
 	s.logger.WithField("block", block).Debug("CommitBlock")
 
-	err := s.commit(block)
+	receipts, err := s.commit(block)
 	if err != nil {
-		return nil, err
+		return proxy.CommitResult{}, err
 	}
 	s.logger.WithField("stateHash", s.stateHash).Debug("CommitBlock Answer")
-	return s.stateHash, nil
+	return proxy.CommitResult{StateHash: s.stateHash, Receipts: receipts}, nil
 }
 
 // SnapshotHandler triggers on snapshot restore
@@ -73,15 +104,29 @@ func (s *State) SnapshotHandler(blockIndex int64) ([]byte, error) {
 	return snapshot, nil
 }
 
-// RestoreHandler triggers on snapshot for a restore
+// RestoreHandler replaces the state with a snapshot and returns its hash
 func (s *State) RestoreHandler(snapshot []byte) ([]byte, error) {
+	kv := make(map[string]string)
+	if err := json.Unmarshal(snapshot, &kv); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %v", err)
+	}
+
 	s.locker.Lock()
 	defer s.locker.Unlock()
-	// XXX do something smart here
-	s.stateHash = snapshot
+	s.kv = kv
+	s.stateHash = s.hash()
 	return s.stateHash, nil
 }
 
+// QueryHandler returns the value of the key in the query
+func (s *State) QueryHandler(query []byte) ([]byte, error) {
+	value, ok := s.Get(string(query))
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", query)
+	}
+	return []byte(value), nil
+}
+
 /*
  * staff:
  */
@@ -93,12 +138,76 @@ func (s *State) GetCommittedTransactions() [][]byte {
 	return s.committedTxs
 }
 
-func (s *State) commit(block poset.Block) error {
-	s.committedTxs = append(s.committedTxs, block.Transactions()...)
-	// log tx and update state hash
-	// TODO: fix idempotency
-	hash := crypto.Keccak256(append([][]byte{s.stateHash}, block.Transactions()...)...)
-	s.snapshots[block.Index()] = hash
-	s.stateHash = hash
-	return nil
+// Get returns the value of a key
+func (s *State) Get(key string) (string, bool) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	value, ok := s.kv[key]
+	return value, ok
+}
+
+// GetStateHash returns the hash of the current state
+func (s *State) GetStateHash() []byte {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return s.stateHash
+}
+
+func (s *State) commit(block poset.Block) ([]poset.Receipt, error) {
+	txs := block.Transactions()
+	s.committedTxs = append(s.committedTxs, txs...)
+	receipts := make([]poset.Receipt, len(txs))
+	for i, tx := range txs {
+		receipts[i] = s.apply(tx)
+	}
+	s.stateHash = s.hash()
+
+	snapshot, err := json.Marshal(s.kv)
+	if err != nil {
+		return nil, err
+	}
+	s.snapshots[block.Index()] = snapshot
+	delete(s.snapshots, block.Index()-snapshotRetain)
+	return receipts, nil
+}
+
+// apply executes a transaction on the state
+func (s *State) apply(tx []byte) poset.Receipt {
+	args := strings.SplitN(string(tx), " ", 3)
+	switch {
+	case len(args) == 3 && args[0] == "set":
+		s.kv[args[1]] = args[2]
+	case len(args) == 2 && args[0] == "get":
+		value, ok := s.kv[args[1]]
+		if !ok {
+			return poset.Receipt{Code: CodeKeyNotFound, Log: "key not found"}
+		}
+		return poset.Receipt{Code: proxy.CodeTypeOK, Data: []byte(value)}
+	case len(args) == 2 && args[0] == "delete":
+		delete(s.kv, args[1])
+	default:
+		return poset.Receipt{Code: CodeInvalidTx, Log: "not a set, get or delete command"}
+	}
+	return poset.Receipt{Code: proxy.CodeTypeOK}
+}
+
+// hash returns the hash of the key-value pairs in key order, each one
+// prefixed with their lengths
+func (s *State) hash() []byte {
+	keys := make([]string, 0, len(s.kv))
+	for key := range s.kv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	data := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		value := s.kv[key]
+		entry := make([]byte, 8, 8+len(key)+len(value))
+		binary.BigEndian.PutUint32(entry, uint32(len(key)))
+		binary.BigEndian.PutUint32(entry[4:], uint32(len(value)))
+		entry = append(entry, key...)
+		data = append(data, append(entry, value...))
+	}
+	return crypto.Keccak256(data...)
 }
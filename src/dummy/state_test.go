@@ -3,7 +3,10 @@ package dummy
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy"
 )
 
@@ -18,3 +21,58 @@ func TestProxyHandlerImplementation(t *testing.T) {
 		t.Fatal("State does not implement ProxyHandler interface!")
 	}
 }
+
+func TestKVState(t *testing.T) {
+	assertO := assert.New(t)
+	logger := common.NewTestLogger(t)
+
+	block := poset.NewBlock(0, 1, []byte{}, [][]byte{
+		[]byte("set a 1"),
+		[]byte("set b hello world"),
+		[]byte("get b"),
+		[]byte("delete a"),
+		[]byte("get a"),
+		[]byte("chat message"),
+	})
+
+	// two nodes committing the same block agree on the state
+	s1, s2 := NewState(logger), NewState(logger)
+	res1, err := s1.CommitReceiptsHandler(block)
+	if !assertO.NoError(err) {
+		return
+	}
+	res2, err := s2.CommitReceiptsHandler(block)
+	if !assertO.NoError(err) {
+		return
+	}
+	assertO.Equal(res1.StateHash, res2.StateHash)
+	assertO.NotEqual(NewState(logger).GetStateHash(), res1.StateHash)
+
+	if assertO.Len(res1.Receipts, 6) {
+		assertO.Equal(proxy.CodeTypeOK, res1.Receipts[0].Code)
+		assertO.Equal([]byte("hello world"), res1.Receipts[2].Data)
+		assertO.Equal(CodeKeyNotFound, res1.Receipts[4].Code)
+		assertO.Equal(CodeInvalidTx, res1.Receipts[5].Code)
+	}
+	value, err := s1.QueryHandler([]byte("b"))
+	if assertO.NoError(err) {
+		assertO.Equal([]byte("hello world"), value)
+	}
+	_, err = s1.QueryHandler([]byte("a"))
+	assertO.Error(err)
+
+	// a restored state has the state hash of the snapshot
+	snapshot, err := s1.SnapshotHandler(0)
+	if !assertO.NoError(err) {
+		return
+	}
+	s3 := NewState(logger)
+	stateHash, err := s3.RestoreHandler(snapshot)
+	if assertO.NoError(err) {
+		assertO.Equal(res1.StateHash, stateHash)
+	}
+	value, err = s3.QueryHandler([]byte("b"))
+	if assertO.NoError(err) {
+		assertO.Equal([]byte("hello world"), value)
+	}
+}
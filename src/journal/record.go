@@ -0,0 +1,84 @@
+// Package journal encodes the records of the append-only journals which the
+// node replays after a crash: the write-behind journal of the badger store
+// and the journal of the events the node creates.
+//
+// A journal is a sequence of records
+//
+//	length uint32 | crc32 of the payload uint32 | payload
+//
+// A record cut short by a crash fails its checksum and ends the journal.
+package journal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// MaxRecordSize bounds the payload of a record, so that a corrupt length
+// does not make a reader allocate gigabytes. Writers split what does not fit
+// in one record.
+const MaxRecordSize = 64 << 20
+
+const headerSize = 8
+
+var (
+	// ErrRecordTooBig is returned for a payload over MaxRecordSize
+	ErrRecordTooBig = fmt.Errorf("journal record over %d bytes", MaxRecordSize)
+	// ErrCorrupt is returned for a payload which does not decode
+	ErrCorrupt = errors.New("corrupt journal record")
+)
+
+// AppendRecord appends the record of payload to buf
+func AppendRecord(buf, payload []byte) ([]byte, error) {
+	if len(payload) > MaxRecordSize {
+		return buf, ErrRecordTooBig
+	}
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+	buf = append(buf, header[:]...)
+	return append(buf, payload...), nil
+}
+
+// ReadRecord reads the payload of the next record of r. The end of the
+// journal reads as io.EOF, and so does a record which is truncated, fails
+// its checksum or claims more than MaxRecordSize bytes: it is the tail of an
+// interrupted write.
+func ReadRecord(r io.Reader) ([]byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, io.EOF
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > MaxRecordSize {
+		return nil, io.EOF
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, io.EOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, io.EOF
+	}
+	return payload, nil
+}
+
+// AppendBytes appends b to buf, prefixed with its uvarint length
+func AppendBytes(buf, b []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	buf = append(buf, n[:binary.PutUvarint(n[:], uint64(len(b)))]...)
+	return append(buf, b...)
+}
+
+// ReadBytes reads the uvarint-prefixed bytes at the start of buf, and
+// returns them and the rest of buf
+func ReadBytes(buf []byte) ([]byte, []byte, error) {
+	n, size := binary.Uvarint(buf)
+	if size <= 0 || uint64(len(buf)-size) < n {
+		return nil, nil, ErrCorrupt
+	}
+	return buf[size : size+int(n)], buf[size+int(n):], nil
+}
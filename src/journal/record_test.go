@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestRecords(t *testing.T) {
+	var buf []byte
+	var err error
+	for _, payload := range []string{"first", "", "third"} {
+		if buf, err = AppendRecord(buf, []byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	whole := len(buf)
+	// a crash in the middle of a record
+	buf, _ = AppendRecord(buf, []byte("torn"))
+	buf = buf[:whole+6]
+
+	r := bytes.NewReader(buf)
+	for _, expected := range []string{"first", "", "third"} {
+		payload, err := ReadRecord(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(payload) != expected {
+			t.Fatalf("expected %q, got %q", expected, payload)
+		}
+	}
+	if _, err := ReadRecord(r); err != io.EOF {
+		t.Fatalf("a torn record should read as io.EOF, got %v", err)
+	}
+}
+
+func TestRecordBounds(t *testing.T) {
+	if _, err := AppendRecord(nil, make([]byte, MaxRecordSize+1)); err != ErrRecordTooBig {
+		t.Fatalf("expected %v, got %v", ErrRecordTooBig, err)
+	}
+
+	// a corrupt length is not allocated
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[:4], 0xffffffff)
+	if _, err := ReadRecord(bytes.NewReader(header[:])); err != io.EOF {
+		t.Fatalf("an oversized record should read as io.EOF, got %v", err)
+	}
+
+	buf := AppendBytes(AppendBytes(nil, []byte("key")), []byte("value"))
+	key, rest, err := ReadBytes(buf)
+	if err != nil || string(key) != "key" {
+		t.Fatalf("expected key, got %q: %v", key, err)
+	}
+	if _, _, err := ReadBytes(rest[:len(rest)-1]); err != ErrCorrupt {
+		t.Fatalf("expected %v, got %v", ErrCorrupt, err)
+	}
+}
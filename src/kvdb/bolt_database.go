@@ -0,0 +1,131 @@
+package kvdb
+
+import (
+	"errors"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+// ErrBoltKeyNotFound is returned by BoltDatabase.Get for missing keys
+var ErrBoltKeyNotFound = errors.New("key not found")
+
+// BoltDatabase is a kvbd.Database wrapper of a *bolt.DB bucket
+type BoltDatabase struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltDatabase wraps the bucket of *bolt.DB, which must exist
+func NewBoltDatabase(db *bolt.DB, bucket []byte) *BoltDatabase {
+	return &BoltDatabase{
+		db:     db,
+		bucket: bucket,
+	}
+}
+
+/*
+ * Database interface implementation
+ */
+
+// Put puts key-value pair into db.
+func (w *BoltDatabase) Put(key []byte, value []byte) error {
+	return w.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(w.bucket).Put(key, common.CopyBytes(value))
+	})
+}
+
+// Has checks if key is in the db.
+func (w *BoltDatabase) Has(key []byte) (bool, error) {
+	var found bool
+	err := w.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(w.bucket).Get(key) != nil
+		return nil
+	})
+	return found, err
+}
+
+// Get returns key-value pair by key.
+func (w *BoltDatabase) Get(key []byte) (res []byte, err error) {
+	err = w.db.View(func(tx *bolt.Tx) error {
+		val := tx.Bucket(w.bucket).Get(key)
+		if val == nil {
+			return ErrBoltKeyNotFound
+		}
+		// val is only valid during the transaction
+		res = common.CopyBytes(val)
+		return nil
+	})
+	return
+}
+
+// Delete removes key-value pair by key.
+func (w *BoltDatabase) Delete(key []byte) error {
+	return w.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(w.bucket).Delete(key)
+	})
+}
+
+// Close does nothing.
+func (w *BoltDatabase) Close() {}
+
+// NewBatch creates new batch.
+func (w *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: w}
+}
+
+/*
+ * Batch
+ */
+
+// boltBatch is a batch structure.
+type boltBatch struct {
+	db     *BoltDatabase
+	writes []kv
+	size   int
+}
+
+// Put puts key-value pair into batch.
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+// Delete removes key-value pair from batch by key.
+func (b *boltBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size++
+	return nil
+}
+
+// Write writes batch into db.
+func (b *boltBatch) Write() error {
+	return b.db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.db.bucket)
+		for _, kv := range b.writes {
+			var err error
+			if kv.del {
+				err = bucket.Delete(kv.k)
+			} else {
+				err = bucket.Put(kv.k, kv.v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ValueSize returns values sizes sum.
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
+
+// Reset cleans whole batch.
+func (b *boltBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
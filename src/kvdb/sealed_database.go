@@ -0,0 +1,59 @@
+package kvdb
+
+// Sealer encrypts and authenticates the values of a Database, bound to
+// their keys
+type Sealer interface {
+	Seal(key, value []byte) []byte
+	Open(key, value []byte) ([]byte, error)
+}
+
+type sealedDatabase struct {
+	db     Database
+	sealer Sealer
+}
+
+// NewSealedDatabase returns a Database which seals the values it writes to
+// db and opens the values it reads
+func NewSealedDatabase(db Database, sealer Sealer) Database {
+	return &sealedDatabase{
+		db:     db,
+		sealer: sealer,
+	}
+}
+
+func (w *sealedDatabase) Put(key []byte, value []byte) error {
+	return w.db.Put(key, w.sealer.Seal(key, value))
+}
+
+func (w *sealedDatabase) Has(key []byte) (bool, error) {
+	return w.db.Has(key)
+}
+
+func (w *sealedDatabase) Get(key []byte) ([]byte, error) {
+	value, err := w.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return w.sealer.Open(key, value)
+}
+
+func (w *sealedDatabase) Delete(key []byte) error {
+	return w.db.Delete(key)
+}
+
+func (w *sealedDatabase) Close() {
+	w.db.Close()
+}
+
+func (w *sealedDatabase) NewBatch() Batch {
+	return &sealedBatch{w.db.NewBatch(), w.sealer}
+}
+
+type sealedBatch struct {
+	Batch
+	sealer Sealer
+}
+
+func (b *sealedBatch) Put(key, value []byte) error {
+	return b.Batch.Put(key, b.sealer.Seal(key, value))
+}
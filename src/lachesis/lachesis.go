@@ -1,9 +1,13 @@
 package lachesis
 
 import (
+	"context"
 	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -13,7 +17,9 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/src/node"
 	"github.com/Fantom-foundation/go-lachesis/src/peer"
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/pgmirror"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
 	"github.com/Fantom-foundation/go-lachesis/src/service"
 )
 
@@ -25,6 +31,9 @@ type Lachesis struct {
 	Store     poset.Store
 	Peers     *peers.Peers
 	Service   *service.Service
+
+	errLock sync.Mutex
+	err     error
 }
 
 // NewLachesis constructor
@@ -86,17 +95,64 @@ func (l *Lachesis) initPeers() error {
 	return nil
 }
 
+func (l *Lachesis) initGenesis() error {
+	if l.Config.Genesis == nil {
+		genesis, err := poset.LoadGenesis(l.Config.DataDir)
+		if err != nil {
+			return err
+		}
+		if genesis == nil {
+			// two networks sharing the same peers.json only differ by it
+			if l.Config.ChainID == "" {
+				return fmt.Errorf("no genesis.json in %s, a chain ID is required to derive the genesis from peers.json", l.Config.DataDir)
+			}
+			l.Config.Logger.Debug("no genesis.json, deriving genesis from peers")
+			genesis = poset.NewGenesisFromPeers(l.Config.ChainID, l.Peers)
+		} else if l.Config.ChainID != "" && l.Config.ChainID != genesis.ChainID {
+			return fmt.Errorf("chain ID %s differs from %s in genesis.json", l.Config.ChainID, genesis.ChainID)
+		}
+		l.Config.Genesis = genesis
+	}
+
+	if err := l.Config.Genesis.Validate(l.Peers); err != nil {
+		return err
+	}
+	hash, err := l.Config.Genesis.Hash()
+	if err != nil {
+		return err
+	}
+
+	l.Config.NodeConfig.GenesisHash = hash
+	l.Config.NodeConfig.ValidatorWeights = l.Config.Genesis.Weights()
+	l.Config.PoSConfig.Weights = l.Config.Genesis.Weights()
+
+	l.Config.Logger.WithFields(logrus.Fields{
+		"chain_id": l.Config.Genesis.ChainID,
+		"hash":     fmt.Sprintf("0x%X", hash),
+	}).Debug("GENESIS")
+	return nil
+}
+
 func (l *Lachesis) initStore() (err error) {
+	storeType := l.Config.StoreType
 	if !l.Config.Store {
-		l.Store = poset.NewInmemStore(l.Peers, l.Config.NodeConfig.CacheSize, &l.Config.PoSConfig)
-		l.Config.Logger.Debug("created new in-mem store")
-	} else {
-		dbDir := l.Config.BadgerDir()
-		l.Config.Logger.WithField("path", dbDir).Debug("Attempting to load or create database")
-		l.Store, err = poset.LoadOrCreateBadgerStore(l.Peers, l.Config.NodeConfig.CacheSize, dbDir, &l.Config.PoSConfig)
+		storeType = "inmem"
+	} else if storeType == "" {
+		storeType = "badger"
+	}
+	l.Config.Logger.WithField("type", storeType).Debug("Attempting to load or create store")
+	l.Store, err = newStore(storeType, l.Config, l.Peers)
+	if err != nil {
+		return
+	}
+
+	if l.Config.PgMirror != "" {
+		mirror, err := pgmirror.New(l.Config.PgMirror, pgmirror.DefaultQueueSize, l.Config.Logger)
 		if err != nil {
-			return
+			return fmt.Errorf("failed to start PostgreSQL mirror: %s", err)
 		}
+		l.Store = pgmirror.Wrap(l.Store, mirror)
+		l.Config.Logger.Debug("mirroring blocks to PostgreSQL")
 	}
 
 	if l.Store.NeedBootstrap() {
@@ -109,6 +165,15 @@ func (l *Lachesis) initStore() (err error) {
 }
 
 func (l *Lachesis) initKey() error {
+	if l.Config.NodeConfig.Follower {
+		// a follower is an observer which holds no key, none is read or
+		// created
+		if l.Config.Key != nil {
+			return fmt.Errorf("follower must not be given a key")
+		}
+		l.Config.NodeConfig.Observer = true
+		return nil
+	}
 	if l.Config.Key == nil {
 		pemKey := crypto.NewPemKey(l.Config.DataDir)
 
@@ -139,20 +204,54 @@ func (l *Lachesis) initKey() error {
 func (l *Lachesis) initNode() error {
 	key := l.Config.Key
 
-	nodePub := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
-	n, ok := l.Peers.ReadByPubKey(nodePub)
-
-	if !ok {
+	var nodeID uint64
+	var nodePub string
+	var n peers.Peer
+	var ok bool
+	if key != nil {
+		nodePub = fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
+		n, ok = l.Peers.ReadByPubKey(nodePub)
+	}
+	switch {
+	case l.Config.NodeConfig.Follower:
+		// followers have no key to derive an ID from, the peers only
+		// need it to tell them apart
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return err
+		}
+		nodeID = binary.BigEndian.Uint64(buf[:])
+	case l.Config.NodeConfig.Observer:
+		if ok {
+			return fmt.Errorf("observer pubkey must not be listed in peers.json")
+		}
+		// observers are kept out of the participants, so their ID
+		// is derived from the key the same way it is for peers
+		nodeID = peers.NewPeer(nodePub, l.Config.BindAddr).ID
+	case !ok:
 		return fmt.Errorf("cannot find self pubkey in peers.json")
+	default:
+		nodeID = n.ID
 	}
 
-	nodeID := n.ID
-
 	l.Config.Logger.WithFields(logrus.Fields{
 		"participants": l.Peers,
 		"id":           nodeID,
+		"observer":     l.Config.NodeConfig.Observer,
+		"follower":     l.Config.NodeConfig.Follower,
 	}).Debug("PARTICIPANTS")
 
+	if l.Config.Store && l.Config.EventWAL {
+		l.Config.NodeConfig.WALPath = l.Config.EventWALPath()
+	}
+
+	l.Config.NodeConfig.TransportOpener = func() (peer.SyncPeer, error) {
+		if err := l.initTransport(); err != nil {
+			return nil, err
+		}
+		return l.Transport, nil
+	}
+
 	selectorArgs := node.SmartPeerSelectorCreationFnArgs{
 		LocalAddr:    l.Config.BindAddr,
 		GetFlagTable: nil,
@@ -164,7 +263,7 @@ func (l *Lachesis) initNode() error {
 		l.Peers,
 		l.Store,
 		l.Transport,
-		l.Config.Proxy,
+		proxy.Chain(l.Config.Proxy, l.Config.ProxyMiddleware...),
 		node.NewSmartPeerSelectorWrapper,
 		selectorArgs,
 		l.Config.BindAddr,
@@ -174,12 +273,89 @@ func (l *Lachesis) initNode() error {
 		return fmt.Errorf("failed to initialize node: %s", err)
 	}
 
+	l.startPruner()
+	l.startSnapshots()
+
 	return nil
 }
 
+func (l *Lachesis) startPruner() {
+	store := l.Store
+	if mirrored, ok := store.(*pgmirror.Store); ok {
+		store = mirrored.Store
+	}
+	badgerStore, ok := store.(*poset.BadgerStore)
+	if !ok || (l.Config.PruneInterval <= 0 && l.Config.StoreQuota <= 0) {
+		return
+	}
+	interval := l.Config.PruneInterval
+	if interval <= 0 {
+		// the quota alone still needs the store size to be checked
+		interval = time.Minute
+	}
+	badgerStore.StartPruner(poset.PrunerConfig{
+		Interval:      interval,
+		RetainRounds:  l.Config.PruneRetainRounds,
+		Quota:         l.Config.StoreQuota,
+		QuotaExceeded: l.quotaExceeded,
+	}, l.Node.IsCatchingUp, l.Config.Logger.WithField("component", "pruner"))
+}
+
+// quotaExceeded shuts the node down before the volume of the store fills
+func (l *Lachesis) quotaExceeded(err error) {
+	l.Config.Logger.WithError(err).Error("Store disk quota exceeded, shutting down")
+	l.errLock.Lock()
+	if l.err == nil {
+		l.err = err
+	}
+	l.errLock.Unlock()
+	// Shutdown closes the store, which waits for the pruner calling us
+	go l.Node.Shutdown()
+}
+
+// Err returns the error which made the node shut down on its own, if any
+func (l *Lachesis) Err() error {
+	l.errLock.Lock()
+	defer l.errLock.Unlock()
+	if l.err == nil && l.Node != nil {
+		return l.Node.Err()
+	}
+	return l.err
+}
+
+func (l *Lachesis) startSnapshots() {
+	store := l.Store
+	if mirrored, ok := store.(*pgmirror.Store); ok {
+		store = mirrored.Store
+	}
+	inmemStore, ok := store.(*poset.InmemStore)
+	if !ok || !l.Config.Snapshots() {
+		return
+	}
+	inmemStore.StartSnapshots(poset.SnapshotConfig{
+		Path:     l.Config.SnapshotPath(),
+		Interval: l.Config.SnapshotInterval,
+		Blocks:   l.Config.SnapshotBlocks,
+	}, l.Config.Logger.WithField("component", "snapshots"))
+}
+
 func (l *Lachesis) initService() error {
 	if l.Config.ServiceAddr != "" {
 		l.Service = service.NewService(l.Config.ServiceAddr, l.Node, l.Config.Logger)
+		if l.Config.Admin {
+			l.Service.EnableAdmin()
+		}
+		if l.Config.Pprof {
+			l.Service.EnablePprof()
+		}
+		l.Service.EnableAuth(l.Config.ServiceToken, l.Config.ServiceJWTSecret)
+		l.Service.SetCORS(l.Config.CORSOrigins, l.Config.CORSMethods)
+		l.Service.SetRateLimits(l.Config.RateLimit, l.Config.RateBurst, l.Config.GlobalRateLimit, l.Config.GlobalRateBurst)
+		if l.Config.ServiceTLSCert != "" || l.Config.ServiceTLSKey != "" {
+			if err := l.Service.EnableTLS(l.Config.ServiceTLSCert, l.Config.ServiceTLSKey); err != nil {
+				return fmt.Errorf("service TLS: %v", err)
+			}
+		}
 	}
 	return nil
 }
@@ -195,6 +371,10 @@ func (l *Lachesis) Init() error {
 		return err
 	}
 
+	if err := l.initGenesis(); err != nil {
+		return err
+	}
+
 	if err := l.initStore(); err != nil {
 		return err
 	}
@@ -218,12 +398,26 @@ func (l *Lachesis) Init() error {
 	return nil
 }
 
-// Run hosts the services for the lachesis node
+// serviceShutdownTimeout is the time the requests in flight have to end
+// once the node shut down
+const serviceShutdownTimeout = 5 * time.Second
+
+// Run hosts the services for the lachesis node, until the node shuts down
 func (l *Lachesis) Run() {
 	if l.Service != nil {
-		go l.Service.Serve()
+		if err := l.Service.Start(); err != nil {
+			l.Config.Logger.WithError(err).Error("Starting service")
+		}
 	}
 	l.Node.Run(true)
+
+	if l.Service != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), serviceShutdownTimeout)
+		defer cancel()
+		if err := l.Service.Shutdown(ctx); err != nil {
+			l.Config.Logger.WithError(err).Debug("Shutting down service")
+		}
+	}
 }
 
 // Keygen generates a new key pair
@@ -7,6 +7,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -14,7 +15,9 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/src/node"
 	"github.com/Fantom-foundation/go-lachesis/src/peer"
 	"github.com/Fantom-foundation/go-lachesis/src/pos"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+	"github.com/Fantom-foundation/go-lachesis/src/service"
 )
 
 type LachesisConfig struct {
@@ -22,20 +25,70 @@ type LachesisConfig struct {
 	BindAddr    string `mapstructure:"listen"`
 	ServiceAddr string `mapstructure:"service-listen"`
 	ServiceOnly bool   `mapstructure:"service-only"`
+	Admin       bool   `mapstructure:"admin"`
+	Pprof       bool   `mapstructure:"pprof"`
 	MaxPool     int    `mapstructure:"max-pool"`
 	Store       bool   `mapstructure:"store"`
-	LogLevel    string `mapstructure:"log"`
-
-	NodeConfig node.Config `mapstructure:",squash"`
-	PoSConfig  pos.Config  `mapstructure:",squash"`
+	StoreType   string `mapstructure:"store-type"`
+	PgMirror    string `mapstructure:"pg-mirror"`
+	// ChainID names the network whose genesis is derived from peers.json,
+	// when the datadir has no genesis.json
+	ChainID string `mapstructure:"chain-id"`
+	// Credentials of the admin and transaction submission endpoints of the
+	// service, see service.Service.EnableAuth
+	ServiceToken     string `mapstructure:"service-token"`
+	ServiceJWTSecret string `mapstructure:"service-jwt-secret"`
+	// Origins and methods browsers may call the service with, see
+	// service.Service.SetCORS
+	CORSOrigins []string `mapstructure:"cors-origins"`
+	CORSMethods []string `mapstructure:"cors-methods"`
+	// PEM files of the certificate and key of the service, which serves
+	// HTTPS when both are set
+	ServiceTLSCert string `mapstructure:"service-tls-cert"`
+	ServiceTLSKey  string `mapstructure:"service-tls-key"`
+	// Requests per second, and bursts, of every client IP and of all the
+	// clients of the public service endpoints, see
+	// service.Service.SetRateLimits
+	RateLimit       float64 `mapstructure:"rate-limit"`
+	RateBurst       int     `mapstructure:"rate-burst"`
+	GlobalRateLimit float64 `mapstructure:"global-rate-limit"`
+	GlobalRateBurst int     `mapstructure:"global-rate-burst"`
+	// Badger store maintenance, see poset.PrunerConfig
+	PruneInterval     time.Duration `mapstructure:"prune-interval"`
+	PruneRetainRounds int64         `mapstructure:"prune-retain-rounds"`
+	StoreQuota        int64         `mapstructure:"store-quota"`
+	ColdStorageDir    string        `mapstructure:"cold-storage-dir"`
+	// Badger store writes, see poset.WriteConfig
+	StoreWrites     string `mapstructure:"store-writes"`
+	StoreWriteQueue int    `mapstructure:"store-write-queue"`
+	// EventWAL journals the events the node creates and its pool, with
+	// Store, see node.Config.WALPath
+	EventWAL bool `mapstructure:"event-wal"`
+	// Inmem store snapshots, see poset.SnapshotConfig
+	SnapshotInterval time.Duration `mapstructure:"snapshot-interval"`
+	SnapshotBlocks   int64         `mapstructure:"snapshot-blocks"`
+	LogLevel         string        `mapstructure:"log"`
+
+	NodeConfig    node.Config         `mapstructure:",squash"`
+	PoSConfig     pos.Config          `mapstructure:",squash"`
+	BadgerOptions poset.BadgerOptions `mapstructure:",squash"`
 
 	LoadPeers bool
 	Proxy     proxy.AppProxy
 	Key       *ecdsa.PrivateKey
 	Logger    *logrus.Logger
+	Genesis   *poset.Genesis
 
 	ConnFunc peer.CreateNetConnFunc
 
+	// ColdStorage receives the rounds pruned from a badger store, e.g. an
+	// S3 or GCS client. It defaults to a directory if ColdStorageDir is set.
+	ColdStorage poset.ColdStorage
+
+	// ProxyMiddleware wraps Proxy, the first middleware being the outermost,
+	// see proxy.Chain
+	ProxyMiddleware []proxy.Middleware
+
 	Test      bool   `mapstructure:"test"`
 	TestN     uint64 `mapstructure:"test_n"`
 	TestDelay uint64 `mapstructure:"test_delay"`
@@ -43,23 +96,32 @@ type LachesisConfig struct {
 
 func NewDefaultConfig() *LachesisConfig {
 	config := &LachesisConfig{
-		DataDir:     DefaultDataDir(),
-		BindAddr:    ":1337",
-		ServiceAddr: ":8000",
-		ServiceOnly: false,
-		ConnFunc:    net.DialTimeout,
-		MaxPool:     2,
-		NodeConfig:  *node.DefaultConfig(),
-		PoSConfig:   *pos.DefaultConfig(),
-		Store:       false,
-		LogLevel:    "info",
-		Proxy:       nil,
-		Logger:      logrus.New(),
-		LoadPeers:   true,
-		Key:         nil,
-		Test:        false,
-		TestN:       ^uint64(0),
-		TestDelay:   1,
+		DataDir:         DefaultDataDir(),
+		BindAddr:        ":1337",
+		ServiceAddr:     ":8000",
+		ServiceOnly:     false,
+		CORSOrigins:     service.DefaultCORSOrigins,
+		CORSMethods:     service.DefaultCORSMethods,
+		RateBurst:       service.DefaultRateBurst,
+		GlobalRateBurst: service.DefaultGlobalRateBurst,
+		ConnFunc:        net.DialTimeout,
+		MaxPool:         2,
+		NodeConfig:      *node.DefaultConfig(),
+		PoSConfig:       *pos.DefaultConfig(),
+		BadgerOptions:   poset.DefaultBadgerOptions(),
+		Store:           false,
+		StoreType:       "badger",
+		StoreWrites:     poset.WriteDirect,
+		StoreWriteQueue: 64,
+		EventWAL:        true,
+		LogLevel:        "info",
+		Proxy:           nil,
+		Logger:          logrus.New(),
+		LoadPeers:       true,
+		Key:             nil,
+		Test:            false,
+		TestN:           ^uint64(0),
+		TestDelay:       1,
 	}
 
 	config.Logger.Level = LogLevel(config.LogLevel)
@@ -84,6 +146,30 @@ func (c *LachesisConfig) BadgerDir() string {
 	return filepath.Join(c.DataDir, "badger_db")
 }
 
+func (c *LachesisConfig) BoltPath() string {
+	return filepath.Join(c.DataDir, "lachesis.db")
+}
+
+// WriteJournalPath is the write-ahead journal of the badger store
+func (c *LachesisConfig) WriteJournalPath() string {
+	return filepath.Join(c.DataDir, "badger_db.journal")
+}
+
+// EventWALPath is the journal of the events the node creates
+func (c *LachesisConfig) EventWALPath() string {
+	return filepath.Join(c.DataDir, "events.wal")
+}
+
+// SnapshotPath is the file of the inmem store snapshots
+func (c *LachesisConfig) SnapshotPath() string {
+	return filepath.Join(c.DataDir, "inmem.snapshot")
+}
+
+// Snapshots tells if the inmem store is snapshotted
+func (c *LachesisConfig) Snapshots() bool {
+	return c.SnapshotInterval > 0 || c.SnapshotBlocks > 0
+}
+
 func DefaultDataDir() string {
 	// Try to place the data folder in the user's home dir
 	home := HomeDir()
@@ -0,0 +1,102 @@
+package lachesis
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// StoreConstructor creates the store of a node, or loads it if it exists
+type StoreConstructor func(config *LachesisConfig, participants *peers.Peers) (poset.Store, error)
+
+var (
+	storeRegistry     = make(map[string]StoreConstructor)
+	storeRegistryLock sync.RWMutex
+)
+
+func init() {
+	MustRegisterStore("inmem", func(c *LachesisConfig, participants *peers.Peers) (poset.Store, error) {
+		if c.Snapshots() {
+			store, err := poset.LoadInmemSnapshot(participants, c.NodeConfig.CacheSize, c.SnapshotPath(), &c.PoSConfig)
+			if !os.IsNotExist(err) {
+				return store, err
+			}
+		}
+		return poset.NewInmemStore(participants, c.NodeConfig.CacheSize, &c.PoSConfig), nil
+	})
+	MustRegisterStore("badger", func(c *LachesisConfig, participants *peers.Peers) (poset.Store, error) {
+		writes := poset.WriteConfig{
+			Mode:        c.StoreWrites,
+			QueueSize:   c.StoreWriteQueue,
+			JournalPath: c.WriteJournalPath(),
+		}
+		store, err := poset.LoadOrCreateBadgerStoreWithOptions(participants, c.NodeConfig.CacheSize, c.BadgerDir(), &c.PoSConfig, writes.Options(c.BadgerOptions))
+		if err != nil {
+			return nil, err
+		}
+		cold := c.ColdStorage
+		if cold == nil && c.ColdStorageDir != "" {
+			if cold, err = poset.NewDirColdStorage(c.ColdStorageDir); err != nil {
+				store.Close()
+				return nil, err
+			}
+		}
+		if cold != nil {
+			store.SetColdStorage(cold)
+		}
+		err = store.SetWriteMode(writes, c.Logger.WithField("component", "store"))
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		return store, nil
+	})
+	MustRegisterStore("bolt", func(c *LachesisConfig, participants *peers.Peers) (poset.Store, error) {
+		return poset.LoadOrCreateBoltStore(participants, c.NodeConfig.CacheSize, c.BoltPath(), &c.PoSConfig)
+	})
+}
+
+// RegisterStore makes a store available under the given name, which can then
+// be used as StoreType
+func RegisterStore(name string, constructor StoreConstructor) error {
+	storeRegistryLock.Lock()
+	defer storeRegistryLock.Unlock()
+	if _, ok := storeRegistry[name]; ok {
+		return fmt.Errorf("store %q is already registered", name)
+	}
+	storeRegistry[name] = constructor
+	return nil
+}
+
+// MustRegisterStore is like RegisterStore but panics on error
+func MustRegisterStore(name string, constructor StoreConstructor) {
+	if err := RegisterStore(name, constructor); err != nil {
+		panic(err)
+	}
+}
+
+// StoreTypes returns the names of the registered stores, sorted
+func StoreTypes() []string {
+	storeRegistryLock.RLock()
+	defer storeRegistryLock.RUnlock()
+	names := make([]string, 0, len(storeRegistry))
+	for name := range storeRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newStore(name string, config *LachesisConfig, participants *peers.Peers) (poset.Store, error) {
+	storeRegistryLock.RLock()
+	constructor, ok := storeRegistry[name]
+	storeRegistryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store type %q, registered: %v", name, StoreTypes())
+	}
+	return constructor(config, participants)
+}
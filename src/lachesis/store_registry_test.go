@@ -0,0 +1,39 @@
+package lachesis
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestStoreRegistry(t *testing.T) {
+	participants := peers.NewPeersFromSlice([]*peers.Peer{
+		peers.NewPeer("0xAA", ""),
+		peers.NewPeer("0xBB", ""),
+	})
+	config := NewDefaultConfig()
+
+	var created bool
+	err := RegisterStore("test", func(c *LachesisConfig, p *peers.Peers) (poset.Store, error) {
+		created = true
+		return poset.NewInmemStore(p, c.NodeConfig.CacheSize, nil), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterStore("badger", nil); err == nil {
+		t.Fatal("registering a store twice should fail")
+	}
+
+	store, err := newStore("test", config, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || store == nil {
+		t.Fatal("registered constructor was not used")
+	}
+	if _, err := newStore("unknown", config, participants); err == nil {
+		t.Fatal("unknown store type should fail")
+	}
+}
@@ -57,14 +57,14 @@ func (m *mobileAppProxy) RestoreHandler(snapshot []byte) ([]byte, error) {
 // gomobile cannot export a Block object because it doesn't support arrays of
 // arrays of bytes; so we have to serialize the block.
 // Overrides  InappProxy::CommitBlock
-func (m *mobileAppProxy) CommitBlock(block poset.Block) ([]byte, error) {
+func (m *mobileAppProxy) CommitBlock(block poset.Block) (proxy.CommitResult, error) {
 	blockBytes, err := block.ProtoMarshal()
 	if err != nil {
 		m.logger.Debug("mobileAppProxy error marhsalling Block")
-		return nil, err
+		return proxy.CommitResult{}, err
 	}
 	stateHash := m.commitHandler.OnCommit(blockBytes)
-	return stateHash, nil
+	return proxy.CommitResult{StateHash: stateHash}, nil
 }
 
 //TODO - Implement these two functions
@@ -72,6 +72,6 @@ func (m *mobileAppProxy) GetSnapshot(blockIndex int64) ([]byte, error) {
 	return []byte{}, nil
 }
 
-func (m *mobileAppProxy) Restore(snapshot []byte) error {
-	return nil
+func (m *mobileAppProxy) Restore(snapshot []byte) ([]byte, error) {
+	return []byte{}, nil
 }
@@ -9,6 +9,7 @@ type MobileConfig struct {
 	SyncLimit  int    //Max Events per sync
 	StoreType  string //inmem or badger
 	StorePath  string //File containing the Store DB
+	ChainID    string //Chain ID of the network, required without genesis.json
 }
 
 // NewMobileConfig creates a new mobile config
@@ -57,6 +57,7 @@ func New(privKey string,
 
 	lachesisConfig.Proxy = newMobileAppProxy(commitHandler, exceptionHandler, lachesisConfig.Logger)
 	lachesisConfig.LoadPeers = false
+	lachesisConfig.ChainID = config.ChainID
 
 	engine := lachesis.NewLachesis(lachesisConfig)
 
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// ErrNotGossiping is returned when the node is asked to catch up while it
+// is not gossiping
+var ErrNotGossiping = errors.New("node is not gossiping")
+
+// PauseGossip stops the node from initiating gossips. It still answers the
+// syncs of its peers, and goes on committing the blocks it decides.
+func (n *Node) PauseGossip() {
+	atomic.StoreInt32(&n.gossipPaused, 1)
+	n.logger.Info("Gossip paused")
+}
+
+// ResumeGossip undoes PauseGossip
+func (n *Node) ResumeGossip() {
+	atomic.StoreInt32(&n.gossipPaused, 0)
+	n.logger.Info("Gossip resumed")
+}
+
+// GossipPaused tells whether the gossip is paused, see PauseGossip
+func (n *Node) GossipPaused() bool {
+	return atomic.LoadInt32(&n.gossipPaused) == 1
+}
+
+// Suspend quiesces the node for maintenance: it neither creates events nor
+// initiates gossips, transactions included, but still answers the syncs of
+// its peers, commits the blocks it decides and serves its API.
+func (n *Node) Suspend() {
+	n.core.SetSuspended(true)
+	n.logger.Info("Node suspended")
+}
+
+// Resume undoes Suspend
+func (n *Node) Resume() {
+	n.core.SetSuspended(false)
+	n.logger.Info("Node resumed")
+}
+
+// Suspended tells whether the node is suspended, see Suspend
+func (n *Node) Suspended() bool {
+	return n.core.IsSuspended()
+}
+
+// FastForward makes a gossiping node catch up with a peer from its last
+// anchor block, as it does when it falls behind by more than SyncLimit
+func (n *Node) FastForward() error {
+	if n.getState() != Gossiping {
+		return ErrNotGossiping
+	}
+	select {
+	case n.fastForwardCh <- struct{}{}:
+	default:
+		// a fast forward is already requested
+	}
+	return nil
+}
+
+// CompactStore compacts the database of the store, if it supports it
+func (n *Node) CompactStore() error {
+	store, ok := n.core.poset.Store.(poset.CompactStore)
+	if !ok {
+		return fmt.Errorf("store does not support compaction")
+	}
+	return store.Compact()
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdminControls(t *testing.T) {
+	data := InitTestData(t, 2, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	node.PauseGossip()
+	if !node.GossipPaused() || node.GetStats()["gossip_paused"] != "true" {
+		t.Fatal("expected a paused gossip")
+	}
+	node.ResumeGossip()
+	if node.GossipPaused() {
+		t.Fatal("expected a resumed gossip")
+	}
+
+	node.Suspend()
+	if !node.Suspended() || !node.core.IsSuspended() || node.GetStats()["suspended"] != "true" {
+		t.Fatal("expected a suspended node")
+	}
+	node.Resume()
+	if node.Suspended() {
+		t.Fatal("expected a resumed node")
+	}
+
+	if err := node.WaitState(Gossiping, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.FastForward(); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.WaitState(CatchingUp, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.FastForward(); err != ErrNotGossiping {
+		t.Fatalf("expected ErrNotGossiping, got %v", err)
+	}
+
+	if err := node.CompactStore(); err == nil {
+		t.Fatal("expected the inmem store not to support compaction")
+	}
+}
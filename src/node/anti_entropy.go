@@ -0,0 +1,111 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAntiEntropyInterval is the default Config.AntiEntropyInterval
+	DefaultAntiEntropyInterval = time.Minute
+	// antiEntropyMaxChunks bounds the SyncResponses of a reconciliation, the
+	// gossips get the rest
+	antiEntropyMaxChunks = 16
+)
+
+// doAntiEntropy reconciles the node with a random peer every
+// Config.AntiEntropyInterval, until the node shuts down
+func (n *Node) doAntiEntropy(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-n.clock.After(n.conf.AntiEntropyInterval):
+			if n.getState() != Gossiping || n.GossipPaused() || n.Suspended() || n.appSlow() {
+				continue
+			}
+			if err := n.antiEntropy(); err != nil {
+				n.logger.WithError(err).Debug("Anti-entropy")
+			}
+		case <-stopCh:
+			return
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
+
+// antiEntropy reconciles the known events of the node with those of a peer
+// drawn at random, rather than by the peer selector, so that over time it
+// meets every peer. It pulls every chunk of what it misses, then pushes what
+// the peer misses. Regular gossip pushes only to the peers it syncs with, and
+// a dropped push or response leaves the other side behind until a later
+// gossip happens to pair them; this recovers from it within an interval.
+func (n *Node) antiEntropy() error {
+	p := n.randomPeer()
+	if p == nil {
+		return fmt.Errorf("no peer to reconcile with")
+	}
+
+	var (
+		known  map[uint64]int64
+		pulled int
+	)
+	for i := 0; i < antiEntropyMaxChunks; i++ {
+		knownEvents := n.knownEvents()
+
+		resp, err := n.requestSync(p.NetAddr, knownEvents)
+		if err != nil {
+			n.health.failure(p.ID, err)
+			return err
+		}
+		if resp.SyncLimit {
+			// too far behind for a sync, the gossip fast forwards
+			n.health.success(p.ID)
+			return nil
+		}
+		if err := n.sync(p, resp.Events); err != nil {
+			n.health.failure(p.ID, err)
+			return err
+		}
+		n.health.success(p.ID)
+		n.recordKnown(p.ID, resp.Known)
+		known = resp.Known
+		pulled += len(resp.Events)
+		if !resp.More {
+			break
+		}
+	}
+
+	// observers are unknown to the other participants and have nothing of
+	// their own to send
+	if !n.conf.Observer {
+		if err := n.push(p.NetAddr, known); err != nil {
+			n.health.failure(p.ID, err)
+			return err
+		}
+	}
+
+	n.antiEntropySyncs.increment()
+	n.antiEntropyEvents.add(int64(pulled))
+	n.logger.WithFields(logrus.Fields{
+		"peer":   p.NetAddr,
+		"events": pulled,
+	}).Debug("Anti-entropy reconciliation")
+	return nil
+}
+
+// randomPeer draws one of the peers, but the node, nil if there is none
+func (n *Node) randomPeer() *peers.Peer {
+	var others []*peers.Peer
+	for _, p := range n.peerSelector.Peers().ToPeerSlice() {
+		if p.ID != n.id && p.NetAddr != n.localAddr {
+			others = append(others, p)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	return others[n.rand.Intn(len(others))]
+}
@@ -0,0 +1,38 @@
+package node
+
+import (
+	"testing"
+)
+
+func TestRandomPeer(t *testing.T) {
+	fp := fakePeers(4)
+	fps := fp.ToPeerSlice()
+	n := &Node{
+		id:        fps[0].ID,
+		localAddr: fps[0].NetAddr,
+		rand:      NewSeededRand(1),
+		peerSelector: NewRandomPeerSelector(fp, RandomPeerSelectorCreationFnArgs{
+			LocalAddr: fps[0].NetAddr,
+		}),
+	}
+
+	drawn := make(map[uint64]int)
+	for i := 0; i < 300; i++ {
+		p := n.randomPeer()
+		if p == nil || p.ID == n.id {
+			t.Fatalf("expected another peer, got %v", p)
+		}
+		drawn[p.ID]++
+	}
+	// every other peer comes up, whatever the selector favours
+	if len(drawn) != 3 {
+		t.Fatalf("expected the 3 other peers drawn, got %v", drawn)
+	}
+
+	alone := fakePeers(1)
+	n.peerSelector = NewRandomPeerSelector(alone, RandomPeerSelectorCreationFnArgs{})
+	n.localAddr = alone.ToPeerSlice()[0].NetAddr
+	if p := n.randomPeer(); p != nil {
+		t.Fatalf("expected no peer, got %v", p)
+	}
+}
@@ -0,0 +1,161 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// DefaultAppBuffer is the number of blocks kept in memory for an app which
+// is disconnected
+const DefaultAppBuffer = 100
+
+// appBuffer holds the blocks committed while the app is disconnected, to
+// replay them in order once it is back. The first ones are kept in memory,
+// up to a bound; the next ones are read back from the store.
+type appBuffer struct {
+	lock      sync.Mutex
+	pending   []int64
+	blocks    map[int64]poset.Block
+	limit     int
+	replaying bool
+}
+
+func newAppBuffer(limit int) *appBuffer {
+	return &appBuffer{
+		blocks: make(map[int64]poset.Block),
+		limit:  limit,
+	}
+}
+
+// push buffers block and reports whether a replay must be started
+func (b *appBuffer) push(block poset.Block) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pending = append(b.pending, block.Index())
+	if len(b.blocks) < b.limit {
+		b.blocks[block.Index()] = block
+	}
+	start := !b.replaying
+	b.replaying = true
+	return start
+}
+
+// buffering reports whether blocks wait for the app, in which case the next
+// ones must wait as well to keep the order
+func (b *appBuffer) buffering() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.replaying
+}
+
+// head returns the index of the oldest buffered block, and the block if it
+// is in memory. The replay stops when there is none.
+func (b *appBuffer) head() (index int64, block poset.Block, inMemory, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if len(b.pending) == 0 {
+		b.replaying = false
+		return 0, poset.Block{}, false, false
+	}
+	index = b.pending[0]
+	block, inMemory = b.blocks[index]
+	return index, block, inMemory, true
+}
+
+// pop removes the oldest buffered block
+func (b *appBuffer) pop() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.blocks, b.pending[0])
+	b.pending = b.pending[1:]
+}
+
+// Len returns the number of buffered blocks
+func (b *appBuffer) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.pending)
+}
+
+// commitToApp commits block to the app, or buffers it while the app is
+// unavailable, fails it under AppErrorRetry, or older blocks wait for it
+func (n *Node) commitToApp(block poset.Block) {
+	if !n.appBuffer.buffering() {
+		res, err := n.commitBlock(block)
+		if err == nil {
+			n.appCommitted(block, res)
+			return
+		}
+		if !n.appRetries(err) {
+			n.commitFailed(block, err)
+			return
+		}
+		n.logger.WithError(err).Warn("App unavailable or failed the block, buffering blocks")
+	}
+	// once the node shuts down, ShutdownContext flushes the buffer itself
+	if n.appBuffer.push(block) {
+		n.goWorker(n.replayToApp)
+	}
+}
+
+func (n *Node) appCommitted(block poset.Block, res proxy.CommitResult) {
+	n.storeReceipts(block, res.Receipts)
+	n.submitValidatorUpdates(block, res.ValidatorUpdates)
+}
+
+// replayToApp commits the buffered blocks, in order, retrying while the app
+// is unavailable or fails them under AppErrorRetry. The delay between two
+// attempts doubles from one heartbeat.
+func (n *Node) replayToApp() {
+	var delay time.Duration
+	for {
+		index, block, inMemory, ok := n.appBuffer.head()
+		if !ok {
+			n.logger.Info("App blocks replayed")
+			return
+		}
+		if !inMemory {
+			var err error
+			if block, err = n.core.GetBlock(index); err != nil {
+				n.logger.WithError(err).WithField("block", index).Error("Reading buffered block")
+				n.appBuffer.pop()
+				continue
+			}
+		}
+		res, err := n.commitBlock(block)
+		if n.appRetries(err) {
+			delay = n.appRetryDelay(delay)
+			logger := n.logger.WithFields(logrus.Fields{
+				"block":    index,
+				"buffered": n.appBuffer.Len(),
+				"retry_in": delay,
+			}).WithError(err)
+			if isAppError(err) {
+				logger.Warn("App failed the block, retrying")
+			} else {
+				logger.Debug("App still unavailable")
+			}
+			select {
+			case <-time.After(delay):
+				continue
+			case <-n.shutdownCh:
+				return
+			}
+		}
+		delay = 0
+		if err != nil {
+			n.commitFailed(block, err)
+			if n.getState() == Stop {
+				return
+			}
+		} else {
+			n.appCommitted(block, res)
+		}
+		n.appBuffer.pop()
+	}
+}
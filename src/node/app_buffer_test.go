@@ -0,0 +1,89 @@
+package node
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// flakyProxy fails the commits while the app is disconnected
+type flakyProxy struct {
+	proxy.Wrapper
+	lock         sync.Mutex
+	disconnected bool
+	committed    []int64
+}
+
+func (p *flakyProxy) setDisconnected(disconnected bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.disconnected = disconnected
+}
+
+func (p *flakyProxy) getCommitted() []int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]int64{}, p.committed...)
+}
+
+func (p *flakyProxy) CommitBlock(block poset.Block) (proxy.CommitResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.disconnected {
+		return proxy.CommitResult{}, proxy.ErrSocketDisconnected
+	}
+	p.committed = append(p.committed, block.Index())
+	return p.AppProxy.CommitBlock(block)
+}
+
+func TestAppBuffer(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	data.Config.HeartbeatTimeout = 10 * time.Millisecond
+
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	app := &flakyProxy{Wrapper: proxy.Wrapper{AppProxy: node.proxy}, disconnected: true}
+	node.proxy = app
+	// the second block spills to the store
+	node.appBuffer = newAppBuffer(1)
+
+	for i := int64(0); i < 2; i++ {
+		block := poset.NewBlock(i, i+1, []byte("framehash"), [][]byte{[]byte("tx")})
+		if err := node.core.poset.Store.SetBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		node.commitToApp(block)
+	}
+	if l := node.appBuffer.Len(); l != 2 {
+		t.Fatalf("expected 2 buffered blocks, got %d", l)
+	}
+
+	app.setDisconnected(false)
+	// the next block waits for the buffered ones
+	block := poset.NewBlock(2, 3, []byte("framehash"), [][]byte{[]byte("tx")})
+	if err := node.core.poset.Store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	node.commitToApp(block)
+
+	expected := []int64{0, 1, 2}
+	deadline := time.Now().Add(5 * time.Second)
+	for !reflect.DeepEqual(expected, app.getCommitted()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected blocks %v to be replayed, got %v", expected, app.getCommitted())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if l := node.appBuffer.Len(); l != 0 {
+		t.Fatalf("expected an empty buffer, got %d blocks", l)
+	}
+}
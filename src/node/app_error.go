@@ -0,0 +1,71 @@
+package node
+
+import (
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// What the node does when the app returns an error from CommitBlock, see
+// Config.AppErrorPolicy
+const (
+	// AppErrorRetry buffers the block and commits it again, with a backoff,
+	// until the app accepts it. The next blocks wait for it.
+	AppErrorRetry = "retry"
+	// AppErrorHalt stops the node, which creates no more blocks
+	AppErrorHalt = "halt"
+	// AppErrorSkip goes on with the next block, storing a CodeCommitFailed
+	// receipt for every transaction of the failed one
+	AppErrorSkip = "skip"
+)
+
+// CodeCommitFailed is the code of the receipts the node stores for the
+// transactions of a block the app failed, with the error as log. The apps
+// must not use it.
+const CodeCommitFailed uint32 = math.MaxUint32
+
+// maxAppRetryDelay caps the delay between two commits of a buffered block,
+// which doubles from one heartbeat after each failure
+const maxAppRetryDelay = 30 * time.Second
+
+// isAppError tells whether the commit failed because of the app, as opposed
+// to a disconnection or a timeout
+func isAppError(err error) bool {
+	return err != nil && !proxy.IsDisconnected(err) && !isAppTimeout(err)
+}
+
+// appRetryDelay returns the delay before the next commit of a buffered
+// block which failed delay ago
+func (n *Node) appRetryDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return n.heartbeat()
+	}
+	if delay *= 2; delay > maxAppRetryDelay {
+		delay = maxAppRetryDelay
+	}
+	return delay
+}
+
+// appErrorFailed applies the error policy to a commit the app failed
+func (n *Node) appErrorFailed(block poset.Block, err error) {
+	logger := n.logger.WithError(err).WithFields(logrus.Fields{
+		"block":        block.Index(),
+		"transactions": len(block.Transactions()),
+	})
+	if n.conf.AppErrorPolicy == AppErrorHalt {
+		logger.Error("App failed the block, halting the node")
+		n.setState(Stop)
+		return
+	}
+	logger.Error("App failed the block, skipped")
+	n.appSkipped.increment()
+	receipts := make([]poset.Receipt, len(block.Transactions()))
+	for i := range receipts {
+		receipts[i] = poset.Receipt{Code: CodeCommitFailed, Log: err.Error()}
+	}
+	n.storeReceipts(block, receipts)
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// failingProxy fails the first commits
+type failingProxy struct {
+	proxy.Wrapper
+	lock     sync.Mutex
+	failures int
+	commits  int
+}
+
+func (p *failingProxy) getCommits() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.commits
+}
+
+func (p *failingProxy) CommitBlock(block poset.Block) (proxy.CommitResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.failures > 0 {
+		p.failures--
+		return proxy.CommitResult{}, errors.New("invalid block")
+	}
+	p.commits++
+	return p.AppProxy.CommitBlock(block)
+}
+
+func TestAppErrorPolicy(t *testing.T) {
+	for _, policy := range []string{AppErrorRetry, AppErrorHalt, AppErrorSkip} {
+		t.Run(policy, func(t *testing.T) {
+			data := InitTestData(t, 1, 2)
+			data.Config.HeartbeatTimeout = 10 * time.Millisecond
+			data.Config.AppErrorPolicy = policy
+
+			trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+				data.PoolSize, data.CreateFu, data.Network.CreateListener)
+			defer transportClose(t, trans)
+
+			node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+			defer node.Shutdown()
+
+			app := &failingProxy{Wrapper: proxy.Wrapper{AppProxy: node.proxy}, failures: 2}
+			node.proxy = app
+
+			block := poset.NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+			if err := node.core.poset.Store.SetBlock(block); err != nil {
+				t.Fatal(err)
+			}
+			node.commitToApp(block)
+
+			switch policy {
+			case AppErrorRetry:
+				deadline := time.Now().Add(5 * time.Second)
+				for app.getCommits() != 1 {
+					if time.Now().After(deadline) {
+						t.Fatal("expected the block to be committed again")
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			case AppErrorHalt:
+				if state := node.getState(); state != Stop {
+					t.Fatalf("expected a stopped node, got %s", state)
+				}
+			case AppErrorSkip:
+				if skipped := node.GetStats()["app_skipped_blocks"]; skipped != "1" {
+					t.Fatalf("expected 1 skipped block, got %s", skipped)
+				}
+				store, ok := node.core.poset.Store.(poset.ReceiptStore)
+				if !ok {
+					return
+				}
+				receipts, err := store.GetReceipts(0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(receipts) != 1 || receipts[0].Code != CodeCommitFailed || receipts[0].Log != "invalid block" {
+					t.Fatalf("expected a failure receipt, got %v", receipts)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// What the node does when a CommitBlock call to the app expires, see
+// Config.AppTimeoutPolicy
+const (
+	// AppTimeoutRetry buffers the block and commits it again, as for a
+	// disconnected app. The app may see the block twice.
+	AppTimeoutRetry = "retry"
+	// AppTimeoutHalt stops the node, which creates no more blocks
+	AppTimeoutHalt = "halt"
+	// AppTimeoutDegrade drops the block and reports the node degraded until
+	// the app answers again
+	AppTimeoutDegrade = "degrade"
+)
+
+// DefaultAppBreakerThreshold is the number of consecutive expired calls
+// which open the circuit breaker
+const DefaultAppBreakerThreshold = 3
+
+var (
+	// ErrAppTimeout is returned by the app calls which did not answer within
+	// Config.AppTimeout
+	ErrAppTimeout = errors.New("app call timed out")
+	// ErrAppCircuitOpen is returned without calling the app while the
+	// circuit breaker is open
+	ErrAppCircuitOpen = errors.New("app circuit breaker is open")
+)
+
+// appGuard puts a deadline on the calls to the app, and opens a circuit
+// breaker after threshold consecutive expired calls: the next calls fail at
+// once for one timeout, then one call is let through to probe the app.
+type appGuard struct {
+	timeout   time.Duration
+	threshold int
+
+	lock      sync.Mutex
+	expired   int
+	openUntil time.Time
+	degraded  bool
+}
+
+func newAppGuard(timeout time.Duration, threshold int) *appGuard {
+	if threshold <= 0 {
+		threshold = DefaultAppBreakerThreshold
+	}
+	return &appGuard{
+		timeout:   timeout,
+		threshold: threshold,
+	}
+}
+
+type appAnswer struct {
+	res interface{}
+	err error
+}
+
+// call runs fn, giving up after the timeout. An expired call goes on in
+// the background, its answer is lost.
+func (g *appGuard) call(fn func() (interface{}, error)) (interface{}, error) {
+	if g.timeout <= 0 {
+		return fn()
+	}
+	g.lock.Lock()
+	open := time.Now().Before(g.openUntil)
+	g.lock.Unlock()
+	if open {
+		return nil, ErrAppCircuitOpen
+	}
+
+	answer := make(chan appAnswer, 1)
+	go func() {
+		res, err := fn()
+		answer <- appAnswer{res, err}
+	}()
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case a := <-answer:
+		g.lock.Lock()
+		g.expired = 0
+		g.degraded = false
+		g.lock.Unlock()
+		return a.res, a.err
+	case <-timer.C:
+		g.lock.Lock()
+		if g.expired++; g.expired >= g.threshold {
+			g.openUntil = time.Now().Add(g.timeout)
+		}
+		g.lock.Unlock()
+		return nil, ErrAppTimeout
+	}
+}
+
+// degrade marks the app degraded, until a call answers in time
+func (g *appGuard) degrade() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.degraded = true
+}
+
+// state returns ok, degraded or open
+func (g *appGuard) state() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	switch {
+	case time.Now().Before(g.openUntil):
+		return "open"
+	case g.degraded:
+		return "degraded"
+	default:
+		return "ok"
+	}
+}
+
+func isAppTimeout(err error) bool {
+	return err == ErrAppTimeout || err == ErrAppCircuitOpen
+}
+
+// commitBlock commits block to the app within the deadline
+func (n *Node) commitBlock(block poset.Block) (proxy.CommitResult, error) {
+	res, err := n.appGuard.call(func() (interface{}, error) {
+		return n.proxy.CommitBlock(block)
+	})
+	if err != nil {
+		return proxy.CommitResult{}, err
+	}
+	return res.(proxy.CommitResult), nil
+}
+
+// getSnapshot asks the app for its snapshot at block within the deadline
+func (n *Node) getSnapshot(blockIndex int64) ([]byte, error) {
+	res, err := n.appGuard.call(func() (interface{}, error) {
+		return n.proxy.GetSnapshot(blockIndex)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]byte), nil
+}
+
+// restoreFrom restores the app from the snapshot read from r within the
+// deadline, streaming it when the proxy is a StreamRestorer
+func (n *Node) restoreFrom(r io.Reader) ([]byte, error) {
+	res, err := n.appGuard.call(func() (interface{}, error) {
+		if restorer, ok := n.proxy.(proxy.StreamRestorer); ok {
+			return restorer.RestoreFrom(r)
+		}
+		snapshot, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return n.proxy.Restore(snapshot)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]byte), nil
+}
+
+// appRetries tells whether the commit failed because the app is
+// unavailable, or failed it under AppErrorRetry, and the block should be
+// committed again later
+func (n *Node) appRetries(err error) bool {
+	if proxy.IsDisconnected(err) {
+		return true
+	}
+	if isAppError(err) {
+		return n.conf.AppErrorPolicy == AppErrorRetry
+	}
+	return isAppTimeout(err) && n.conf.AppTimeoutPolicy != AppTimeoutHalt &&
+		n.conf.AppTimeoutPolicy != AppTimeoutDegrade
+}
+
+// commitFailed applies the timeout or error policy to a commit which did
+// not make it
+func (n *Node) commitFailed(block poset.Block, err error) {
+	if !isAppTimeout(err) {
+		n.appErrorFailed(block, err)
+		return
+	}
+	logger := n.logger.WithError(err).WithField("block", block.Index())
+	switch n.conf.AppTimeoutPolicy {
+	case AppTimeoutHalt:
+		logger.Error("App does not answer, halting the node")
+		n.setState(Stop)
+	case AppTimeoutDegrade:
+		logger.Error("App does not answer, block dropped")
+		n.appGuard.degrade()
+	}
+}
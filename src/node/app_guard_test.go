@@ -0,0 +1,96 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+func TestAppGuard(t *testing.T) {
+	timeout := 20 * time.Millisecond
+	guard := newAppGuard(timeout, 2)
+	hang := make(chan struct{})
+	defer close(hang)
+	hung := func() (interface{}, error) {
+		<-hang
+		return nil, nil
+	}
+	answer := func() (interface{}, error) {
+		return "answer", nil
+	}
+
+	if res, err := guard.call(answer); err != nil || res != "answer" {
+		t.Fatalf("expected an answer, got %v, %v", res, err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := guard.call(hung); err != ErrAppTimeout {
+			t.Fatalf("expected ErrAppTimeout, got %v", err)
+		}
+	}
+	// the breaker is open, the app is not called
+	if _, err := guard.call(answer); err != ErrAppCircuitOpen {
+		t.Fatalf("expected ErrAppCircuitOpen, got %v", err)
+	}
+	if state := guard.state(); state != "open" {
+		t.Fatalf("expected an open breaker, got %s", state)
+	}
+
+	// after one timeout a call probes the app and closes the breaker
+	time.Sleep(timeout)
+	if _, err := guard.call(answer); err != nil {
+		t.Fatal(err)
+	}
+	if state := guard.state(); state != "ok" {
+		t.Fatalf("expected a closed breaker, got %s", state)
+	}
+}
+
+// hungProxy never answers the commits
+type hungProxy struct {
+	proxy.Wrapper
+	hang chan struct{}
+}
+
+func (p *hungProxy) CommitBlock(block poset.Block) (proxy.CommitResult, error) {
+	<-p.hang
+	return proxy.CommitResult{}, nil
+}
+
+func TestAppTimeoutPolicy(t *testing.T) {
+	for _, policy := range []string{AppTimeoutDegrade, AppTimeoutHalt} {
+		t.Run(policy, func(t *testing.T) {
+			data := InitTestData(t, 1, 2)
+			data.Config.AppTimeout = 20 * time.Millisecond
+			data.Config.AppTimeoutPolicy = policy
+
+			trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+				data.PoolSize, data.CreateFu, data.Network.CreateListener)
+			defer transportClose(t, trans)
+
+			node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+			defer node.Shutdown()
+
+			app := &hungProxy{Wrapper: proxy.Wrapper{AppProxy: node.proxy}, hang: make(chan struct{})}
+			defer close(app.hang)
+			node.proxy = app
+
+			node.commitToApp(poset.NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")}))
+			if l := node.appBuffer.Len(); l != 0 {
+				t.Fatalf("expected the block to be dropped, %d buffered", l)
+			}
+			stats := node.GetStats()
+			switch policy {
+			case AppTimeoutDegrade:
+				if stats["app_state"] != "degraded" || stats["state"] != Gossiping.String() {
+					t.Fatalf("expected a degraded gossiping node, got %s and %s", stats["app_state"], stats["state"])
+				}
+			case AppTimeoutHalt:
+				if stats["state"] != Stop.String() {
+					t.Fatalf("expected a stopped node, got %s", stats["state"])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+type blockCallback struct {
+	fn func(poset.Block)
+}
+
+// blockCallbacks are the functions registered with OnBlockCommitted, in the
+// order of their registration
+type blockCallbacks struct {
+	lock sync.RWMutex
+	cbs  []*blockCallback
+}
+
+func (c *blockCallbacks) add(fn func(poset.Block)) func() {
+	cb := &blockCallback{fn: fn}
+	c.lock.Lock()
+	c.cbs = append(c.cbs, cb)
+	c.lock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			for i, other := range c.cbs {
+				if other == cb {
+					// copy, call may be ranging over the previous slice
+					c.cbs = append(append([]*blockCallback{}, c.cbs[:i]...), c.cbs[i+1:]...)
+					return
+				}
+			}
+		})
+	}
+}
+
+func (c *blockCallbacks) get() []*blockCallback {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.cbs
+}
+
+// OnBlockCommitted registers fn to be called with every block the node
+// commits, once it is final, in order, so that an indexer or a bridge in the
+// same process observes the blocks without wrapping the app proxy or
+// polling the store. Unlike Subscribe, no block is missed. It returns the
+// function which unregisters fn.
+//
+// fn is called from the commit of the node, which waits for it: it must
+// return quickly, hand the block over to a goroutine of its own for longer
+// work, and must not shut the node down. A panic of fn is logged and
+// recovered.
+func (n *Node) OnBlockCommitted(fn func(poset.Block)) func() {
+	return n.blockCallbacks.add(fn)
+}
+
+func (n *Node) callBlockCallbacks(block poset.Block) {
+	for _, cb := range n.blockCallbacks.get() {
+		n.callBlockCallback(cb.fn, block)
+	}
+}
+
+func (n *Node) callBlockCallback(fn func(poset.Block), block poset.Block) {
+	defer func() {
+		if r := recover(); r != nil {
+			n.logger.WithField("block", block.Index()).Errorf("Block callback panicked: %v", r)
+		}
+	}()
+	fn(block)
+}
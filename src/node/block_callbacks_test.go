@@ -0,0 +1,38 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestOnBlockCommitted(t *testing.T) {
+	n := &Node{logger: common.NewTestLogger(t).WithField("id", 1)}
+
+	var calls []string
+	unregister := n.OnBlockCommitted(func(block poset.Block) {
+		calls = append(calls, "first")
+	})
+	n.OnBlockCommitted(func(block poset.Block) {
+		panic("indexer bug")
+	})
+	n.OnBlockCommitted(func(block poset.Block) {
+		calls = append(calls, "last")
+	})
+
+	block := poset.NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+	// the panic does not stop the next callbacks
+	n.callBlockCallbacks(block)
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "last" {
+		t.Fatalf("expected the callbacks in order, got %v", calls)
+	}
+
+	unregister()
+	unregister()
+	calls = nil
+	n.callBlockCallbacks(block)
+	if len(calls) != 1 || calls[0] != "last" {
+		t.Fatalf("expected the last callback only, got %v", calls)
+	}
+}
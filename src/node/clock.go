@@ -0,0 +1,159 @@
+package node
+
+import (
+	"container/heap"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and schedules the timers of the node: its
+// heartbeat, its background loops and the expiry of its transactions. The
+// node uses the wall clock unless Config.Clock is set.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// wallClock is the Clock of the time package
+type wallClock struct{}
+
+func (wallClock) Now() time.Time                         { return time.Now() }
+func (wallClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Rand draws the random choices of the node: the jitter of its heartbeat
+// and the peers it gossips with. It must be safe for concurrent use. The
+// node uses the global source of math/rand unless Config.Rand is set.
+type Rand interface {
+	Intn(n int) int
+	Int63() int64
+}
+
+// globalRand is the Rand of the global source of math/rand
+type globalRand struct{}
+
+func (globalRand) Intn(n int) int { return rand.Intn(n) }
+func (globalRand) Int63() int64   { return rand.Int63() }
+
+// lockedRand is a Rand of its own source, safe for concurrent use
+type lockedRand struct {
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+// NewSeededRand returns a Rand which draws the same choices for the same
+// seed
+func NewSeededRand(seed int64) Rand {
+	return &lockedRand{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Intn(n)
+}
+
+func (r *lockedRand) Int63() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Int63()
+}
+
+// simTimer is a timer of a SimClock
+type simTimer struct {
+	at  time.Time
+	seq uint64
+	ch  chan time.Time
+}
+
+// simTimers is a heap of simTimers, the earliest first, then in the order
+// they were set
+type simTimers []simTimer
+
+func (t simTimers) Len() int { return len(t) }
+func (t simTimers) Less(i, j int) bool {
+	if !t[i].at.Equal(t[j].at) {
+		return t[i].at.Before(t[j].at)
+	}
+	return t[i].seq < t[j].seq
+}
+func (t simTimers) Swap(i, j int)       { t[i], t[j] = t[j], t[i] }
+func (t *simTimers) Push(x interface{}) { *t = append(*t, x.(simTimer)) }
+func (t *simTimers) Pop() interface{} {
+	old := *t
+	last := old[len(old)-1]
+	*t = old[:len(old)-1]
+	return last
+}
+
+// SimClock is a virtual Clock whose time only moves when it is advanced,
+// so that the timers of a simulation fire in the same order in every run.
+// It is shared by the nodes of a simulated network, see nodekit.
+type SimClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	seq    uint64
+	timers simTimers
+}
+
+// NewSimClock returns a SimClock at start
+func NewSimClock(start time.Time) *SimClock {
+	return &SimClock{now: start}
+}
+
+// Now implements Clock
+func (c *SimClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// After implements Clock, the channel gets the time once the clock was
+// advanced by d
+func (c *SimClock) After(d time.Duration) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.seq++
+	heap.Push(&c.timers, simTimer{at: c.now.Add(d), seq: c.seq, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the timers due on the way
+// in the order of their deadlines
+func (c *SimClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	end := c.now.Add(d)
+	for len(c.timers) > 0 && !c.timers[0].at.After(end) {
+		t := heap.Pop(&c.timers).(simTimer)
+		c.now = t.at
+		t.ch <- t.at
+	}
+	c.now = end
+}
+
+// Next moves the clock to the deadline of the next timer and fires it. It
+// returns false when no timer is set.
+func (c *SimClock) Next() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.timers) == 0 {
+		return false
+	}
+	t := heap.Pop(&c.timers).(simTimer)
+	c.now = t.at
+	t.ch <- t.at
+	return true
+}
+
+// Pending returns the number of timers waiting to fire
+func (c *SimClock) Pending() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.timers)
+}
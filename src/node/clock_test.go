@@ -0,0 +1,76 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimClockAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewSimClock(start)
+
+	late := c.After(3 * time.Second)
+	early := c.After(time.Second)
+	if c.Pending() != 2 {
+		t.Fatalf("expected 2 pending timers, got %d", c.Pending())
+	}
+
+	c.Advance(2 * time.Second)
+	select {
+	case at := <-early:
+		if !at.Equal(start.Add(time.Second)) {
+			t.Fatalf("expected the timer to fire at 1s, got %v", at.Sub(start))
+		}
+	default:
+		t.Fatal("expected the 1s timer to fire")
+	}
+	select {
+	case <-late:
+		t.Fatal("expected the 3s timer to wait")
+	default:
+	}
+	if now := c.Now(); !now.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("expected the clock at 2s, got %v", now.Sub(start))
+	}
+
+	if !c.Next() {
+		t.Fatal("expected a pending timer")
+	}
+	<-late
+	if c.Next() {
+		t.Fatal("expected no pending timer")
+	}
+
+	select {
+	case <-c.After(0):
+	default:
+		t.Fatal("expected a timer of 0 to fire at once")
+	}
+}
+
+func TestSimClockOrder(t *testing.T) {
+	c := NewSimClock(time.Unix(0, 0))
+	var chs []<-chan time.Time
+	for _, d := range []time.Duration{3, 1, 2, 1} {
+		chs = append(chs, c.After(d*time.Second))
+	}
+
+	// the timers of the same deadline fire in the order they were set
+	for _, i := range []int{1, 3, 2, 0} {
+		c.Next()
+		select {
+		case <-chs[i]:
+		default:
+			t.Fatalf("expected timer %d to fire", i)
+		}
+	}
+}
+
+func TestSeededRand(t *testing.T) {
+	a, b := NewSeededRand(42), NewSeededRand(42)
+	for i := 0; i < 100; i++ {
+		if x, y := a.Intn(1000), b.Intn(1000); x != y {
+			t.Fatalf("draw %d: %d != %d", i, x, y)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package node
+
+import (
+	"sync/atomic"
+)
+
+const (
+	// commitChSize is the number of decided blocks which wait for the app
+	// in the commit channel
+	commitChSize = 400
+	// DefaultCommitBackpressure is the default Config.CommitBackpressure,
+	// three quarters of the commit channel
+	DefaultCommitBackpressure = commitChSize * 3 / 4
+)
+
+// appSlow tells whether the intake of events is paused for a slow app, see
+// checkCommitQueue
+func (n *Node) appSlow() bool {
+	return atomic.LoadInt32(&n.slowApp) == 1
+}
+
+// checkCommitQueue pauses the intake of events once Config.CommitBackpressure
+// blocks wait for the app, and resumes it once half of them were committed.
+// While it is paused the node neither creates events nor takes those of its
+// peers, and runs no consensus, so that the blocks do not pile up in the
+// commit channel until the consensus blocks on it. It returns appSlow.
+func (n *Node) checkCommitQueue() bool {
+	limit := n.conf.CommitBackpressure
+	if limit <= 0 {
+		return false
+	}
+	queued := len(n.commitCh)
+	if queued >= limit && atomic.CompareAndSwapInt32(&n.slowApp, 0, 1) {
+		n.appSlowPauses.increment()
+		n.logger.WithField("queued_blocks", queued).Warn("App slow, pausing the intake of events")
+	} else if queued <= limit/2 && atomic.CompareAndSwapInt32(&n.slowApp, 1, 0) {
+		n.logger.WithField("queued_blocks", queued).Info("App caught up, resuming the intake of events")
+		// run the consensus skipped in the meantime
+		n.scheduleConsensus()
+	}
+	return n.appSlow()
+}
+
+// appState is the state of the app in the stats: ok, slow while the intake
+// of events is paused for it, degraded or open, see appGuard.state
+func (n *Node) appState() string {
+	state := n.appGuard.state()
+	if state == "ok" && n.appSlow() {
+		return "slow"
+	}
+	return state
+}
@@ -0,0 +1,54 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestCommitBackpressure(t *testing.T) {
+	conf := TestConfig(t)
+	conf.CommitBackpressure = 4
+	n := &Node{
+		conf:        conf,
+		logger:      conf.Logger.WithField("this_id", 0),
+		commitCh:    make(chan poset.Block, commitChSize),
+		consensusCh: make(chan struct{}, 1),
+		appGuard:    newAppGuard(0, 0),
+	}
+
+	for i := 0; i < 3; i++ {
+		n.commitCh <- poset.Block{}
+	}
+	if n.checkCommitQueue() {
+		t.Fatal("expected the intake of events to go on below the limit")
+	}
+	n.commitCh <- poset.Block{}
+	if !n.checkCommitQueue() {
+		t.Fatal("expected the intake of events to pause at the limit")
+	}
+	if state := n.appState(); state != "slow" {
+		t.Fatalf("expected a slow app, got %s", state)
+	}
+
+	// it resumes once half of the blocks were committed
+	<-n.commitCh
+	if !n.checkCommitQueue() {
+		t.Fatal("expected the intake of events to stay paused above half the limit")
+	}
+	<-n.commitCh
+	if n.checkCommitQueue() {
+		t.Fatal("expected the intake of events to resume at half the limit")
+	}
+	select {
+	case <-n.consensusCh:
+	default:
+		t.Fatal("expected the consensus to be scheduled when the intake resumes")
+	}
+	if p := n.appSlowPauses.get(); p != 1 {
+		t.Fatalf("expected 1 pause, got %d", p)
+	}
+	if state := n.appState(); state != "ok" {
+		t.Fatalf("expected the app to be ok, got %s", state)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Fantom-foundation/go-lachesis/src/common"
 	"github.com/Fantom-foundation/go-lachesis/src/log"
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,6 +18,121 @@ type Config struct {
 	SyncLimit        int64         `mapstructure:"sync-limit"`
 	Logger           *logrus.Logger
 	TestDelay        uint64 `mapstructure:"test_delay"`
+	// SyncPeers is the number of peers a gossip pulls from at once, whose
+	// events are inserted together
+	SyncPeers int `mapstructure:"sync-peers"`
+	// GossipFanout is the number of peers a gossip pushes the events of the
+	// node to, those it synced with plus random others
+	GossipFanout int `mapstructure:"gossip-fanout"`
+	// AntiEntropyInterval is the time between two reconciliations of the
+	// known events with a random peer, which recover the events a dropped
+	// push or response left behind. 0 disables them.
+	AntiEntropyInterval time.Duration `mapstructure:"anti-entropy-interval"`
+	// SyncPipeline is the number of fetched SyncResponses waiting for
+	// insertion while the next one is fetched, 0 fetches and inserts in turn
+	SyncPipeline int `mapstructure:"sync-pipeline"`
+	// SyncChunks is the number of chunks of SyncLimit events a node catches
+	// up by sync before it fast forwards, the size of the chunks follows
+	// the rate of each peer. 1 fast forwards past SyncLimit events.
+	SyncChunks int `mapstructure:"sync-chunks"`
+	// Observer nodes sync and verify the DAG but never create events
+	Observer bool `mapstructure:"observer"`
+	// Follower nodes are observers which hold no key at all, so that API
+	// and explorer nodes keep no key on disk. NewNode takes a nil key, and
+	// Observer must be set.
+	Follower bool `mapstructure:"follower"`
+	// NoEmptyEvents skips the events with no transaction which would bring
+	// nothing new to the DAG, but one every MaxSilence, 0 for no bound
+	NoEmptyEvents bool          `mapstructure:"no-empty-events"`
+	MaxSilence    time.Duration `mapstructure:"max-silence"`
+	// Per-event budget, enforced on created and received events
+	MaxEventTxs     int `mapstructure:"max-event-txs"`
+	MaxEventPayload int `mapstructure:"max-event-payload"`
+	// GenesisHash identifies the network, requests from nodes of another
+	// network are rejected
+	GenesisHash []byte `mapstructure:"-"`
+	// BlockQuorum is a share of the weight of the validators: a Block is
+	// anchored and served to FastForward once validators of more than that
+	// share signed it, 0 means 1/3
+	BlockQuorum float64 `mapstructure:"block-quorum"`
+	// ValidatorWeights are the weights of the validators by public key, those
+	// of the genesis; without them every validator weighs 1
+	ValidatorWeights map[string]uint64 `mapstructure:"-"`
+	// CommitOnQuorum holds blocks back from the app until they reach the
+	// BlockQuorum, instead of committing them as soon as they are decided
+	CommitOnQuorum bool `mapstructure:"commit-on-quorum"`
+	// AppBuffer is the number of blocks kept in memory while the app is
+	// disconnected, the next ones are read back from the store
+	AppBuffer int `mapstructure:"app-buffer"`
+	// AppTimeout is the deadline of the CommitBlock, GetSnapshot and Restore
+	// calls to the app, 0 for none. AppTimeoutPolicy tells what happens to
+	// an expired commit: AppTimeoutRetry, AppTimeoutHalt or
+	// AppTimeoutDegrade. After AppBreakerThreshold consecutive expired calls
+	// the app is not called for one AppTimeout.
+	AppTimeout          time.Duration `mapstructure:"app-timeout"`
+	AppTimeoutPolicy    string        `mapstructure:"app-timeout-policy"`
+	AppBreakerThreshold int           `mapstructure:"app-breaker"`
+	// AppErrorPolicy tells what happens to a block the app returns an error
+	// for: AppErrorRetry, AppErrorHalt or AppErrorSkip
+	AppErrorPolicy string `mapstructure:"app-error-policy"`
+	// PanicPolicy tells what a panic of a goroutine of the node does:
+	// PanicRestart, up to PanicRestarts panics of each goroutine, or
+	// PanicFail
+	PanicPolicy   string `mapstructure:"panic-policy"`
+	PanicRestarts int    `mapstructure:"panic-restarts"`
+	// MaxPoolTxs and MaxPoolBytes bound the transactions waiting for an
+	// event, 0 for no limit. PoolPolicy tells what happens to the new
+	// transactions once the pool is full: PoolBackpressure or
+	// PoolEvictOldest.
+	MaxPoolTxs   int    `mapstructure:"max-pool-txs"`
+	MaxPoolBytes int    `mapstructure:"max-pool-bytes"`
+	PoolPolicy   string `mapstructure:"pool-policy"`
+	// MaxInternalTxs bounds the internal transactions waiting for an event,
+	// 0 for no limit, see Node.SubmitInternalTx
+	MaxInternalTxs int `mapstructure:"max-internal-txs"`
+	// TxDedupWindow is the number of the last transactions which left the
+	// pool, or were committed, whose duplicates the pool drops
+	TxDedupWindow int `mapstructure:"tx-dedup-window"`
+	// TxKeyWindow is the number of the last idempotency keys whose
+	// submissions the node drops, see Node.SubmitKeyedTx. 0 keeps none.
+	TxKeyWindow int `mapstructure:"tx-key-window"`
+	// TxTTL is the time a transaction waits in the pool for an event before
+	// it expires, and the app gets a proxy.TxExpired node event. 0 disables
+	// the expiry.
+	TxTTL time.Duration `mapstructure:"tx-ttl"`
+	// TxGossip pushes the transactions submitted to the node to the pools
+	// of its peers, so that any of them may put them in an event
+	TxGossip bool `mapstructure:"tx-gossip"`
+	// DedupBlockTxs makes the blocks keep a transaction once, and drop those
+	// of the previous block, see poset.Poset.SetDedupBlockTransactions. All
+	// the validators must agree on it.
+	DedupBlockTxs bool `mapstructure:"dedup-block-txs"`
+	// CommitBackpressure is the number of blocks waiting for the app past
+	// which the node pauses the intake of events, until half of them were
+	// committed. 0 disables the backpressure.
+	CommitBackpressure int `mapstructure:"commit-backpressure"`
+	// ReadyMaxLag is the number of rounds waiting for consensus past which
+	// the node is not ready, see Node.Ready. 0 disables the check.
+	ReadyMaxLag int64 `mapstructure:"ready-max-lag"`
+	// Clock drives the heartbeat, the background loops and the expiry of
+	// the pool, the wall clock when nil. Rand draws the heartbeat jitter and
+	// the gossip peers, the global source of math/rand when nil. A SimClock
+	// and a NewSeededRand make simulations reproducible, see nodekit.
+	Clock Clock `mapstructure:"-"`
+	Rand  Rand  `mapstructure:"-"`
+	// FaultInjection lets Node.SetFaults and /admin/faults inject faults in
+	// the RPCs and the store of the node, for resilience tests only
+	FaultInjection bool `mapstructure:"fault-injection"`
+	// VerifyWorkers is the number of goroutines hashing the events of a
+	// sync and verifying their signatures, runtime.GOMAXPROCS when 0
+	VerifyWorkers int `mapstructure:"verify-workers"`
+	// WALPath is the journal of the events the node creates and of the
+	// transactions of its pool, replayed by Init after a crash, see
+	// Core.ReplayWAL. Empty disables the journal.
+	WALPath string `mapstructure:"-"`
+	// TransportOpener opens a new transport for Node.Start after Node.Stop
+	// closed the previous one. Nil keeps the transport open while stopped.
+	TransportOpener func() (peer.SyncPeer, error) `mapstructure:"-"`
 }
 
 // NewConfig creates a new node config
@@ -42,12 +158,34 @@ func DefaultConfig() *Config {
 	lachesis_log.NewLocal(logger, logger.Level.String())
 
 	return &Config{
-		HeartbeatTimeout: 10 * time.Millisecond,
-		TCPTimeout:       180 * 1000 * time.Millisecond,
-		CacheSize:        500,
-		SyncLimit:        100,
-		Logger:           logger,
-		TestDelay:        1,
+		HeartbeatTimeout:    10 * time.Millisecond,
+		TCPTimeout:          180 * 1000 * time.Millisecond,
+		CacheSize:           500,
+		SyncLimit:           100,
+		SyncPeers:           DefaultSyncPeers,
+		GossipFanout:        DefaultGossipFanout,
+		AntiEntropyInterval: DefaultAntiEntropyInterval,
+		MaxSilence:          DefaultMaxSilence,
+		SyncChunks:          DefaultSyncChunks,
+		Logger:              logger,
+		TestDelay:           1,
+		MaxEventTxs:         0,
+		MaxEventPayload:     MaxEventsPayloadSize,
+		AppBuffer:           DefaultAppBuffer,
+		AppTimeoutPolicy:    AppTimeoutRetry,
+		AppBreakerThreshold: DefaultAppBreakerThreshold,
+		AppErrorPolicy:      AppErrorSkip,
+		PanicPolicy:         PanicRestart,
+		PanicRestarts:       DefaultPanicRestarts,
+		MaxPoolTxs:          DefaultMaxPoolTxs,
+		MaxPoolBytes:        DefaultMaxPoolBytes,
+		PoolPolicy:          PoolBackpressure,
+		MaxInternalTxs:      DefaultMaxInternalTxs,
+		TxDedupWindow:       DefaultTxDedupWindow,
+		TxKeyWindow:         DefaultTxKeyWindow,
+		TxTTL:               DefaultTxTTL,
+		CommitBackpressure:  DefaultCommitBackpressure,
+		ReadyMaxLag:         DefaultReadyMaxLag,
 	}
 }
 
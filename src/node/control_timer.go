@@ -1,7 +1,6 @@
 package node
 
 import (
-	"math/rand"
 	"sync"
 	"time"
 )
@@ -32,13 +31,19 @@ func NewControlTimer(timerFactory timerFactory) *ControlTimer {
 
 // NewRandomControlTimer creates a random time controller with no defaults set
 func NewRandomControlTimer() *ControlTimer {
+	return newClockControlTimer(wallClock{}, globalRand{})
+}
+
+// newClockControlTimer creates a random time controller on the clock, whose
+// jitter is drawn from rnd
+func newClockControlTimer(clock Clock, rnd Rand) *ControlTimer {
 
 	randomTimeout := func(min time.Duration) <-chan time.Time {
 		if min == 0 {
 			return nil
 		}
-		extra := time.Duration(rand.Int63()) % min
-		return time.After(min + extra)
+		extra := time.Duration(rnd.Int63()) % min
+		return clock.After(min + extra)
 	}
 	return NewControlTimer(randomTimeout)
 }
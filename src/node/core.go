@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -17,14 +18,30 @@ import (
 )
 
 const (
-	// MaxEventsPayloadSize is size limitation of txs in bytes.
+	// MaxEventsPayloadSize is the default size limitation of txs in bytes.
 	// TODO: collect the similar magic constants in protocol config.
 	MaxEventsPayloadSize = 100 * 1024 * 1024
+	// DefaultMaxSilence is the default time after which a core skipping the
+	// empty events creates one anyway, see Core.SetNoEmptyEvents
+	DefaultMaxSilence = 10 * time.Second
 )
 
 var (
-	// ErrTooBigTx is returned when transaction size > MaxEventsPayloadSize
+	// ErrTooBigTx is returned when transaction size > the event payload budget
 	ErrTooBigTx = fmt.Errorf("transaction too big")
+	// ErrObserver is returned when an observer core is asked to create events
+	ErrObserver = fmt.Errorf("observer node does not create events")
+	// ErrNoKey is returned by Node.Init for a node without key which is not
+	// an observer
+	ErrNoKey = fmt.Errorf("node without key must be an observer")
+	// ErrTooManyEventTxs is returned when a received event carries more
+	// transactions than the per-event budget allows
+	ErrTooManyEventTxs = fmt.Errorf("too many transactions in event")
+	// ErrTooBigEvent is returned when the transactions of a received event
+	// exceed the per-event payload budget
+	ErrTooBigEvent = fmt.Errorf("event payload too big")
+	// ErrGenesisMismatch is returned to nodes of another network
+	ErrGenesisMismatch = fmt.Errorf("genesis mismatch")
 )
 
 // Core struct that controls the consensus, transaction, and communication
@@ -38,16 +55,45 @@ type Core struct {
 	participants *peers.Peers // [PubKey] => id
 	head         poset.EventHash
 
-	transactionPool         [][]byte
-	internalTransactionPool []poset.InternalTransaction
-	blockSignaturePool      []poset.BlockSignature
+	// observer cores only follow the DAG: they never create events or sign
+	// blocks and are not counted among the participants
+	observer bool
+	// suspended is 1 while the core creates no events, see SetSuspended
+	suspended int32
+
+	// per-event transaction budget, 0 means no limit on the count
+	maxEventTxs     int
+	maxEventPayload int
+
+	// verifyWorkers is the number of goroutines verifying the events of a
+	// sync, GOMAXPROCS when 0
+	verifyWorkers int
+
+	// noEmptyEvents skips the events which would carry nothing new, but
+	// one every maxSilence, see SetNoEmptyEvents. lastEvent is the time of
+	// the last event created, skippedEvents counts the skipped ones.
+	noEmptyEvents bool
+	maxSilence    time.Duration
+	lastEvent     time.Time
+	skippedEvents uint64
+	clock         Clock
+
+	// eventHook is called with every inserted event
+	eventHook func(poset.Event)
+
+	// wal journals the created events and the pooled transactions, nil
+	// unless ReplayWAL opened it
+	wal *eventWAL
+
+	txPool             *txPool
+	internalTxPool     *internalTxPool
+	blockSignaturePool []poset.BlockSignature
 
 	logger *logrus.Entry
 
-	addSelfEventBlockLocker       sync.Mutex
-	transactionPoolLocker         sync.RWMutex
-	internalTransactionPoolLocker sync.RWMutex
-	blockSignaturePoolLocker      sync.RWMutex
+	addSelfEventBlockLocker  sync.Mutex
+	consensusLocker          sync.Mutex
+	blockSignaturePoolLocker sync.RWMutex
 }
 
 // NewCore creates a new core struct
@@ -63,15 +109,17 @@ func NewCore(id uint64, key *ecdsa.PrivateKey, participants *peers.Peers,
 
 	p2 := poset.NewPoset(participants, store, commitCh, logEntry)
 	core := &Core{
-		id:                      id,
-		key:                     key,
-		poset:                   p2,
-		participants:            participants,
-		transactionPool:         [][]byte{},
-		internalTransactionPool: []poset.InternalTransaction{},
-		blockSignaturePool:      []poset.BlockSignature{},
-		logger:                  logEntry,
-		head:                    poset.EventHash{},
+		id:                 id,
+		key:                key,
+		poset:              p2,
+		participants:       participants,
+		txPool:             newTxPool(),
+		internalTxPool:     newInternalTxPool(DefaultMaxInternalTxs),
+		blockSignaturePool: []poset.BlockSignature{},
+		logger:             logEntry,
+		head:               poset.EventHash{},
+		maxEventPayload:    MaxEventsPayloadSize,
+		clock:              wallClock{},
 	}
 
 	p2.SetCore(core)
@@ -84,17 +132,156 @@ func (c *Core) ID() uint64 {
 	return c.id
 }
 
-// PubKey returns the public key of this core
+// SetGenesisHash sets the hash of the genesis the first block references
+func (c *Core) SetGenesisHash(hash []byte) {
+	c.poset.SetGenesisHash(hash)
+}
+
+// SetBlockQuorum sets the share of the weight of the validators a Block
+// needs more than the signatures of, see Poset.SetBlockQuorum
+func (c *Core) SetBlockQuorum(quorum float64, weights map[string]uint64) {
+	c.poset.SetBlockQuorum(quorum)
+	c.poset.SetBlockWeights(weights)
+}
+
+// SetObserver switches the core in or out of observer mode
+func (c *Core) SetObserver(observer bool) {
+	c.observer = observer
+}
+
+// IsObserver returns true if the core only follows the DAG
+func (c *Core) IsObserver() bool {
+	return c.observer
+}
+
+// SetSuspended stops or restarts the creation of events, the core still
+// inserts those of the peers
+func (c *Core) SetSuspended(suspended bool) {
+	var v int32
+	if suspended {
+		v = 1
+	}
+	atomic.StoreInt32(&c.suspended, v)
+}
+
+// IsSuspended returns true while the core creates no events
+func (c *Core) IsSuspended() bool {
+	return atomic.LoadInt32(&c.suspended) == 1
+}
+
+// SetEventHook sets the function called with every event inserted in the
+// DAG, which must not block
+func (c *Core) SetEventHook(hook func(poset.Event)) {
+	c.eventHook = hook
+}
+
+// SetEventBudget sets the max number of transactions and the max payload size
+// in bytes of a single event. A non positive value keeps the default.
+func (c *Core) SetEventBudget(maxTxs, maxPayload int) {
+	if maxTxs > 0 {
+		c.maxEventTxs = maxTxs
+	}
+	if maxPayload > 0 {
+		c.maxEventPayload = maxPayload
+	}
+}
+
+// SetVerifyWorkers sets the number of goroutines hashing the events of a
+// sync and verifying their signatures, runtime.GOMAXPROCS when 0
+func (c *Core) SetVerifyWorkers(workers int) {
+	c.verifyWorkers = workers
+}
+
+// SetNoEmptyEvents makes a sync skip the event it would create when the
+// pools are empty and the peer sent nothing new, no event and the other
+// parent of the head, unless maxSilence passed since the last event, 0 for
+// no bound
+func (c *Core) SetNoEmptyEvents(on bool, maxSilence time.Duration) {
+	c.noEmptyEvents = on
+	c.maxSilence = maxSilence
+}
+
+// SkippedEvents returns the number of events skipped, see SetNoEmptyEvents
+func (c *Core) SkippedEvents() uint64 {
+	return atomic.LoadUint64(&c.skippedEvents)
+}
+
+// SetTxPoolLimits bounds the transaction pool to maxTxs transactions and
+// maxBytes bytes, 0 for no limit, and sets the policy of the full pool:
+// PoolBackpressure or PoolEvictOldest
+func (c *Core) SetTxPoolLimits(maxTxs, maxBytes int, policy string) {
+	c.txPool.setLimits(maxTxs, maxBytes, policy)
+}
+
+// SetTxDedupWindow sets the number of transactions which left the pool, for
+// an event or a block, whose duplicates the pool drops. Those it holds are
+// dropped in any case.
+func (c *Core) SetTxDedupWindow(window int) {
+	c.txPool.setDedupWindow(window)
+}
+
+// SetDedupBlockTransactions makes the blocks keep a transaction once, see
+// Poset.SetDedupBlockTransactions
+func (c *Core) SetDedupBlockTransactions(dedup bool) {
+	c.poset.SetDedupBlockTransactions(dedup)
+}
+
+// SetMaxInternalTxs bounds the internal transaction pool to max
+// transactions, 0 for no limit
+func (c *Core) SetMaxInternalTxs(max int) {
+	c.internalTxPool.setMax(max)
+}
+
+// SetClock sets the clock dating the transactions of the pool, for their
+// expiry, and the events, for SetNoEmptyEvents
+func (c *Core) SetClock(clock Clock) {
+	c.clock = clock
+	c.txPool.setClock(clock)
+}
+
+// TxPoolFull tells whether the transaction pool refuses new transactions,
+// until TxPoolSpace is signaled
+func (c *Core) TxPoolFull() bool {
+	return c.txPool.full()
+}
+
+// TxPoolSpace is signaled when transactions leave the pool
+func (c *Core) TxPoolSpace() <-chan struct{} {
+	return c.txPool.space()
+}
+
+// CheckEventBudget checks a received event against the per-event budget
+func (c *Core) CheckEventBudget(we poset.WireEvent) error {
+	txs := we.Body.Transactions
+	if c.maxEventTxs > 0 && len(txs) > c.maxEventTxs {
+		return ErrTooManyEventTxs
+	}
+	var payloadSize int
+	for _, tx := range txs {
+		payloadSize += len(tx)
+	}
+	if payloadSize > c.maxEventPayload {
+		return ErrTooBigEvent
+	}
+	return nil
+}
+
+// PubKey returns the public key of this core, nil for a follower, which
+// holds no key
 func (c *Core) PubKey() []byte {
+	if c.key == nil {
+		return nil
+	}
 	if c.pubKey == nil {
 		c.pubKey = crypto.FromECDSAPub(&c.key.PublicKey)
 	}
 	return c.pubKey
 }
 
-// HexID returns the Hex representation of the public key
+// HexID returns the Hex representation of the public key, empty for a
+// follower
 func (c *Core) HexID() string {
-	if c.hexID == "" {
+	if c.hexID == "" && c.key != nil {
 		pubKey := c.PubKey()
 		c.hexID = fmt.Sprintf("0x%X", pubKey)
 	}
@@ -135,6 +322,10 @@ func (c *Core) InDegrees() map[string]int64 {
 
 // SetHeadAndHeight calculates and sets the current head and height for the chain
 func (c *Core) SetHeadAndHeight() error {
+	if c.observer {
+		// observers are not participants and have no chain of their own
+		return nil
+	}
 
 	var head poset.EventHash
 	var height int64
@@ -218,6 +409,11 @@ func (c *Core) SignAndInsertSelfEvent(event poset.Event) error {
 	if err := c.poset.SetWireInfoAndSign(&event, c.key); err != nil {
 		return err
 	}
+	if c.wal != nil {
+		if err := c.wal.appendEvent(event); err != nil {
+			return fmt.Errorf("journaling event: %v", err)
+		}
+	}
 
 	return c.InsertEvent(event, true)
 }
@@ -241,12 +437,17 @@ func (c *Core) InsertEvent(event poset.Event, setWireInfo bool) error {
 		c.head = event.Hash()
 	} else {
 		c.participants.SetHeightByPubKeyHex(event.GetCreator(), event.Index())
+		// the transactions gossiped to the creator too are in the pool
+		c.txPool.seen(event.Transactions())
 	}
 	c.participants.SetInDegreeByPubKeyHex(event.GetCreator(), 0)
 
 	if otherEvent, err := c.poset.Store.GetEventBlock(event.OtherParent()); err == nil {
 		c.participants.IncInDegreeByPubKeyHex(otherEvent.GetCreator())
 	}
+	if c.eventHook != nil {
+		c.eventHook(event)
+	}
 	return nil
 }
 
@@ -306,7 +507,13 @@ func (c *Core) EventDiff(known map[uint64]int64) (events []poset.Event, err erro
 		if err != nil {
 			return []poset.Event{}, err
 		}
+		root := poset.GenRootSelfParent(peer.ID)
 		for _, e := range participantEvents {
+			// the leaf event of the root stands in for the first event
+			// until it is known, every node has its own
+			if e == root {
+				continue
+			}
 			ev, err := c.poset.Store.GetEventBlock(e)
 			if err != nil {
 				return []poset.Event{}, err
@@ -328,8 +535,62 @@ func (c *Core) EventDiff(known map[uint64]int64) (events []poset.Event, err erro
 	return unknown, nil
 }
 
-// Sync unknown events into our poset
+// LastEventHashFrom returns the hash of the last event known from a participant,
+// the root's self-parent if there is none yet
+func (c *Core) LastEventHashFrom(id uint64) (poset.EventHash, error) {
+	peer, ok := c.participants.ReadByID(id)
+	if !ok {
+		return poset.EventHash{}, fmt.Errorf("unknown participant %d", id)
+	}
+	last, _, err := c.poset.Store.LastEventFrom(peer.PubKeyHex)
+	return last, err
+}
+
+// SelfAncestors returns, oldest first, at most limit events of a participant
+// which follow head (one of its events) up to and including index
+func (c *Core) SelfAncestors(id uint64, head poset.EventHash, index, limit int64) ([]poset.WireEvent, error) {
+	peer, ok := c.participants.ReadByID(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown participant %d", id)
+	}
+	headEvent, err := c.poset.Store.GetEventBlock(head)
+	if err != nil {
+		return nil, err
+	}
+	if headEvent.GetCreator() != peer.PubKeyHex {
+		return nil, fmt.Errorf("head %v is not an event of participant %d", head, id)
+	}
+	hashes, err := c.poset.Store.ParticipantEvents(peer.PubKeyHex, headEvent.Index())
+	if err != nil {
+		return nil, err
+	}
+
+	var ancestors []poset.WireEvent
+	for _, h := range hashes {
+		if int64(len(ancestors)) >= limit {
+			break
+		}
+		ev, err := c.poset.Store.GetEventBlock(h)
+		if err != nil {
+			return nil, err
+		}
+		if ev.Index() > index {
+			break
+		}
+		ancestors = append(ancestors, ev.ToWire())
+	}
+	return ancestors, nil
+}
+
+// Sync unknown events into our poset. The store writes of a sync are
+// grouped into one transaction when the store supports it.
 func (c *Core) Sync(peer *peers.Peer, unknownEvents []poset.WireEvent) error {
+	return c.batch(func() error {
+		return c.sync(peer, unknownEvents)
+	})
+}
+
+func (c *Core) sync(peer *peers.Peer, unknownEvents []poset.WireEvent) error {
 
 	c.logger.WithFields(logrus.Fields{
 		"unknown_events":              len(unknownEvents),
@@ -345,46 +606,94 @@ func (c *Core) Sync(peer *peers.Peer, unknownEvents []poset.WireEvent) error {
 		c.logger.WithField("peer", peer).Errorf("c.poset.Store.LastEventFrom(peer.PubKeyHex)")
 		return err
 	}
-	// add unknown events
+	// read the unknown events up to the first bad one, whose error is
+	// returned once those before it are inserted
+	wireEvents := unknownEvents
+	var readErr error
 	for k, we := range unknownEvents {
 		c.logger.WithFields(logrus.Fields{
 			"unknown_events": we,
 		}).Debug("unknownEvents")
-		ev, err := c.poset.ReadWireInfo(we)
-		if err != nil {
-			c.logger.WithField("EventBlock", we).WithField("err", err).Errorf("c.poset.ReadWireInfo(we)")
-			return err
-
+		if err := c.CheckEventBudget(we); err != nil {
+			c.logger.WithField("EventBlock", we).WithField("err", err).Errorf("c.CheckEventBudget(we)")
+			wireEvents, readErr = unknownEvents[:k], err
+			break
 		}
+	}
+	events, err := c.poset.ReadWireInfos(wireEvents)
+	if err != nil {
+		c.logger.WithField("EventBlock", wireEvents[len(events)]).WithField("err", err).Errorf("c.poset.ReadWireInfos(wireEvents)")
+		readErr = err
+	}
+
+	// verify the new events at once, then add them in order
+	var fresh []*poset.Event
+	for _, ev := range events {
 		if ev.Index() > myKnownEvents[ev.CreatorID()] {
-			ev.SetLamportTimestamp(poset.LamportTimestampNIL)
-			ev.SetRound(poset.RoundNIL)
-			ev.SetRoundReceived(poset.RoundNIL)
-			if err := c.InsertEvent(*ev, false); err != nil {
-				c.logger.Error("SYNC: INSERT ERR:", err)
-				return err
-			}
+			fresh = append(fresh, ev)
 		}
-
-		// assume last event corresponds to other-head
-		if k == len(unknownEvents)-1 {
-			otherHead = ev.Hash()
+	}
+	poset.VerifyEvents(fresh, c.verifyWorkers)
+	for _, ev := range fresh {
+		ev.SetLamportTimestamp(poset.LamportTimestampNIL)
+		ev.SetRound(poset.RoundNIL)
+		ev.SetRoundReceived(poset.RoundNIL)
+		if err := c.InsertEvent(*ev, false); err != nil {
+			c.logger.Error("SYNC: INSERT ERR:", err)
+			return err
 		}
 	}
+	if readErr != nil {
+		return readErr
+	}
+
+	// assume last event corresponds to other-head
+	if len(events) > 0 {
+		otherHead = events[len(events)-1].Hash()
+	}
+
+	if c.observer || c.IsSuspended() {
+		return nil
+	}
 
 	// create new event with self head and other head only if there are pending
 	// loaded events or the pools are not empty
-	if c.poset.GetPendingLoadedEvents() > 0 ||
-		c.GetTransactionPoolCount() > 0 ||
-		c.GetInternalTransactionPoolCount() > 0 ||
-		c.GetBlockSignaturePoolCount() > 0 {
-		return c.AddSelfEventBlock(otherHead)
+	if c.GetTransactionPoolCount() == 0 &&
+		c.GetInternalTransactionPoolCount() == 0 &&
+		c.GetBlockSignaturePoolCount() == 0 {
+		if c.poset.GetPendingLoadedEvents() == 0 || c.skipEmptyEvent(otherHead, len(fresh) > 0) {
+			return nil
+		}
 	}
-	return nil
+	return c.AddSelfEventBlock(otherHead)
+}
+
+// skipEmptyEvent tells whether the sync skips the event it would create with
+// empty pools, see SetNoEmptyEvents. fresh tells whether the sync inserted
+// events.
+func (c *Core) skipEmptyEvent(otherHead poset.EventHash, fresh bool) bool {
+	if !c.noEmptyEvents || fresh {
+		return false
+	}
+	c.addSelfEventBlockLocker.Lock()
+	head, last := c.head, c.lastEvent
+	c.addSelfEventBlockLocker.Unlock()
+
+	if c.maxSilence > 0 && c.clock.Now().Sub(last) >= c.maxSilence {
+		return false
+	}
+	// the other head came in an EagerSync, it is news to the DAG
+	if headEvent, err := c.poset.Store.GetEventBlock(head); err != nil || headEvent.OtherParent() != otherHead {
+		return false
+	}
+	atomic.AddUint64(&c.skippedEvents, 1)
+	return true
 }
 
 // FastForward catch up to another peer if too far behind
 func (c *Core) FastForward(peer string, block poset.Block, frame poset.Frame) error {
+	c.consensusLocker.Lock()
+	defer c.consensusLocker.Unlock()
 
 	// Check Block Signatures
 	err := c.poset.CheckBlock(block)
@@ -401,6 +710,15 @@ func (c *Core) FastForward(peer string, block poset.Block, frame poset.Frame) er
 		return fmt.Errorf("invalid Frame Hash")
 	}
 
+	// Check the Frame's events are the ones the Block, signed, commits to
+	match, err := frame.MatchesEventsRoot(block)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return fmt.Errorf("invalid Frame events root")
+	}
+
 	err = c.poset.Reset(block, frame)
 	if err != nil {
 		return err
@@ -411,7 +729,7 @@ func (c *Core) FastForward(peer string, block poset.Block, frame poset.Frame) er
 		return err
 	}
 
-	err = c.RunConsensus()
+	err = c.runConsensus()
 	if err != nil {
 		return err
 	}
@@ -428,6 +746,9 @@ func min(a, b int) int {
 
 // AddSelfEventBlock adds an event block created by this node
 func (c *Core) AddSelfEventBlock(otherHead poset.EventHash) error {
+	if c.observer {
+		return ErrObserver
+	}
 
 	c.addSelfEventBlockLocker.Lock()
 	defer c.addSelfEventBlockLocker.Unlock()
@@ -463,44 +784,34 @@ func (c *Core) AddSelfEventBlock(otherHead poset.EventHash) error {
 		}
 	}
 
-	// get transactions batch for new Event
-	c.transactionPoolLocker.Lock()
-	var payloadSize, nTxs int
-	for nTxs = 0; nTxs < len(c.transactionPool); nTxs++ {
-		// NOTE: if len(tx)>MaxEventsPayloadSize it will be payloadSize>MaxEventsPayloadSize
-		txSize := len(c.transactionPool[nTxs])
-		if nTxs > 0 && payloadSize >= (MaxEventsPayloadSize-txSize) {
-			break
-		}
-		payloadSize += txSize
+	// get transactions batch for new Event, by priority
+	batch := c.txPool.take(c.maxEventTxs, c.maxEventPayload)
+	txs := make([][]byte, len(batch))
+	for i, t := range batch {
+		txs[i] = t.tx
 	}
-	batch := c.transactionPool[0:nTxs]
-	c.transactionPool = c.transactionPool[nTxs:]
-	c.transactionPoolLocker.Unlock()
+
+	internalTxs := c.internalTxPool.take()
 
 	// create new event with self head and empty other parent
-	newHead := poset.NewEvent(batch,
-		c.internalTransactionPool,
+	newHead := poset.NewEvent(txs,
+		internalTxs,
 		c.blockSignaturePool,
 		poset.EventHashes{c.head, otherHead}, c.PubKey(), c.participants.NextHeightByPubKeyHex(c.HexID()), flagTable)
 
 	if err := c.SignAndInsertSelfEvent(newHead); err != nil {
-		// put batch back to transactionPool
-		c.transactionPoolLocker.Lock()
-		c.transactionPool = append(batch, c.transactionPool...)
-		c.transactionPoolLocker.Unlock()
+		// put batch back to the transaction pool
+		c.txPool.putBack(batch)
+		c.internalTxPool.putBack(internalTxs)
 		return fmt.Errorf("newHead := poset.NewEventBlock: %s", err)
 	}
+	c.lastEvent = c.clock.Now()
 	c.logger.WithFields(logrus.Fields{
-		"transactions":          nTxs,
-		"internal_transactions": c.GetInternalTransactionPoolCount(),
+		"transactions":          len(batch),
+		"internal_transactions": len(internalTxs),
 		"block_signatures":      c.GetBlockSignaturePoolCount(),
 	}).Debug("newHead := poset.NewEventBlock")
 
-	c.internalTransactionPoolLocker.Lock()
-	c.internalTransactionPool = []poset.InternalTransaction{}
-	c.internalTransactionPoolLocker.Unlock()
-
 	// retain c.blockSignaturePool until c.transactionPool is empty
 	// FIXIT: is there any better strategy?
 	if c.GetTransactionPoolCount() == 0 {
@@ -516,6 +827,9 @@ func (c *Core) AddSelfEventBlock(otherHead poset.EventHash) error {
 func (c *Core) FromWire(wireEvents []poset.WireEvent) ([]poset.Event, error) {
 	events := make([]poset.Event, len(wireEvents))
 	for i, w := range wireEvents {
+		if err := c.CheckEventBudget(w); err != nil {
+			return nil, err
+		}
 		ev, err := c.poset.ReadWireInfo(w)
 		if err != nil {
 			return nil, err
@@ -535,7 +849,31 @@ func (c *Core) ToWire(events []poset.Event) ([]poset.WireEvent, error) {
 }
 
 // RunConsensus is the core consensus mechanism, this checks rounds/frames and creates blocks.
+// It only excludes other consensus runs, so events can keep being inserted
+// while the rounds are decided.
 func (c *Core) RunConsensus() error {
+	c.consensusLocker.Lock()
+	defer c.consensusLocker.Unlock()
+	return c.batch(c.runConsensus)
+}
+
+// batch runs fn in a store batch, see poset.BatchStore
+func (c *Core) batch(fn func() error) error {
+	store, ok := c.poset.Store.(poset.BatchStore)
+	if !ok {
+		return fn()
+	}
+	if err := store.StartBatch(); err != nil {
+		return err
+	}
+	err := fn()
+	if cerr := store.CommitBatch(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (c *Core) runConsensus() error {
 	start := time.Now()
 	err := c.poset.DivideRounds()
 	c.logger.WithField("Duration", time.Since(start).Nanoseconds()).Debug("c.poset.DivideRounds()")
@@ -585,24 +923,63 @@ func (c *Core) RunConsensus() error {
 	return nil
 }
 
-// AddTransactions add transactions to the pending pool
+// AddTransactions add transactions to the pending pool, with the
+// DefaultTxPriority
 func (c *Core) AddTransactions(txs [][]byte) error {
+	return c.AddPriorityTransactions(txs, DefaultTxPriority)
+}
+
+// AddPriorityTransactions add transactions to the pending pool, which
+// leave it for an event before those of a lower priority. The duplicates of
+// the transactions of the pool, of the last ones which left it, see
+// SetTxDedupWindow, and of the committed ones the store indexes are dropped.
+func (c *Core) AddPriorityTransactions(txs [][]byte, priority int) error {
+	if c.observer {
+		return ErrObserver
+	}
 	for _, tx := range txs {
-		if len(tx) > MaxEventsPayloadSize {
+		if len(tx) > c.maxEventPayload {
 			return ErrTooBigTx
 		}
 	}
-	c.transactionPoolLocker.Lock()
-	defer c.transactionPoolLocker.Unlock()
-	c.transactionPool = append(c.transactionPool, txs...)
-	return nil
+	if txs = c.dropCommitted(txs); len(txs) == 0 {
+		return nil
+	}
+	evicted, err := c.txPool.add(txs, priority)
+	if evicted > 0 {
+		c.logger.WithField("evicted", evicted).Warn("Transaction pool full, evicted the oldest transactions")
+	}
+	if err == nil && c.wal != nil {
+		// the transactions are pooled anyway, they only miss a restart
+		if jerr := c.wal.appendTxs(txs, priority); jerr != nil {
+			c.logger.WithError(jerr).Error("Journaling transactions")
+		}
+	}
+	return err
+}
+
+// dropCommitted returns the transactions of txs which are not in a committed
+// block, if the store indexes them
+func (c *Core) dropCommitted(txs [][]byte) [][]byte {
+	index, ok := c.poset.Store.(poset.TxIndexStore)
+	if !ok {
+		return txs
+	}
+	var fresh [][]byte
+	for _, tx := range txs {
+		if _, err := index.GetTxLocation(poset.TxHash(tx)); err == nil {
+			c.txPool.dropped(1)
+			continue
+		}
+		fresh = append(fresh, tx)
+	}
+	return fresh
 }
 
-// AddInternalTransactions add internal transactions to the pending pool
-func (c *Core) AddInternalTransactions(txs []poset.InternalTransaction) {
-	c.internalTransactionPoolLocker.Lock()
-	defer c.internalTransactionPoolLocker.Unlock()
-	c.internalTransactionPool = append(c.internalTransactionPool, txs...)
+// AddInternalTransactions add internal transactions to the pending pool, all
+// or none: it returns ErrDuplicateInternalTx or ErrInternalTxPoolFull
+func (c *Core) AddInternalTransactions(txs []poset.InternalTransaction) error {
+	return c.internalTxPool.add(txs...)
 }
 
 // AddBlockSignature add block signatures to the pending pool
@@ -671,6 +1048,31 @@ func (c *Core) GetLastConsensusRound() int64 {
 	return c.poset.GetLastConsensusRound()
 }
 
+// GetFirstConsensusRound returns the first consensus round known
+func (c *Core) GetFirstConsensusRound() int64 {
+	return c.poset.GetFirstConsensusRound()
+}
+
+// GetLastDecidedRound returns the last round with all clothos decided
+func (c *Core) GetLastDecidedRound() int64 {
+	return c.poset.GetLastDecidedRound()
+}
+
+// GetPendingRoundsCount returns the count of rounds waiting for a decision
+func (c *Core) GetPendingRoundsCount() int {
+	return c.poset.GetPendingRoundsCount()
+}
+
+// GetAnchorBlock returns the index of the last block with enough signatures
+func (c *Core) GetAnchorBlock() int64 {
+	return c.poset.GetAnchorBlock()
+}
+
+// GetBlock returns the block with the given index from the store
+func (c *Core) GetBlock(index int64) (poset.Block, error) {
+	return c.poset.Store.GetBlock(index)
+}
+
 // GetConsensusTransactionsCount returns the count of transactions that are final
 func (c *Core) GetConsensusTransactionsCount() uint64 {
 	return c.poset.GetConsensusTransactionsCount()
@@ -688,16 +1090,33 @@ func (c *Core) GetLastBlockIndex() int64 {
 
 // GetTransactionPoolCount returns the count of all pending transactions
 func (c *Core) GetTransactionPoolCount() int64 {
-	c.transactionPoolLocker.RLock()
-	defer c.transactionPoolLocker.RUnlock()
-	return int64(len(c.transactionPool))
+	return int64(c.txPool.Len())
+}
+
+// HasPendingTx tells whether the transaction of the hash is in the pool, or
+// in an event whose consensus order is not determined yet
+func (c *Core) HasPendingTx(hash string) bool {
+	if c.txPool.contains(hash) {
+		return true
+	}
+
+	for _, h := range c.poset.GetUndeterminedEvents() {
+		event, err := c.poset.Store.GetEventBlock(h)
+		if err != nil {
+			continue
+		}
+		for _, tx := range event.Transactions() {
+			if poset.TxHash(tx) == hash {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // GetInternalTransactionPoolCount returns the count of all pending internal transactions
 func (c *Core) GetInternalTransactionPoolCount() int64 {
-	c.internalTransactionPoolLocker.RLock()
-	defer c.internalTransactionPoolLocker.RUnlock()
-	return int64(len(c.internalTransactionPool))
+	return int64(c.internalTxPool.count())
 }
 
 // GetBlockSignaturePoolCount returns the count of all pending block signatures
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Fantom-foundation/go-lachesis/src/common"
 	"github.com/Fantom-foundation/go-lachesis/src/crypto"
@@ -783,6 +785,84 @@ func TestOverSyncLimit(t *testing.T) {
 
 }
 
+func TestEventBudget(t *testing.T) {
+	participants := peers.NewPeers()
+	key, _ := crypto.GenerateECDSAKey()
+	participants.AddPeer(peers.NewPeer(fmt.Sprintf("0x%X",
+		crypto.FromECDSAPub(&key.PublicKey)), ""))
+	core := NewCore(0,
+		key,
+		participants,
+		poset.NewInmemStore(participants, 1000, nil),
+		nil,
+		common.NewTestLogger(t))
+	core.SetEventBudget(2, 10)
+
+	if err := core.AddTransactions([][]byte{make([]byte, 11)}); err != ErrTooBigTx {
+		t.Fatalf("expected ErrTooBigTx, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		txs [][]byte
+		err error
+	}{
+		{[][]byte{[]byte("abc"), []byte("def")}, nil},
+		{[][]byte{make([]byte, 10)}, nil},
+		{[][]byte{make([]byte, 11)}, ErrTooBigEvent},
+		{[][]byte{[]byte("a"), []byte("b"), []byte("c")}, ErrTooManyEventTxs},
+		{[][]byte{make([]byte, 6), make([]byte, 6)}, ErrTooBigEvent},
+	} {
+		we := poset.WireEvent{Body: poset.WireBody{Transactions: tc.txs}}
+		if err := core.CheckEventBudget(we); err != tc.err {
+			t.Fatalf("expected %v for %d txs, got %v", tc.err, len(tc.txs), err)
+		}
+		if tc.err == nil {
+			continue
+		}
+		if _, err := core.FromWire([]poset.WireEvent{we}); err != tc.err {
+			t.Fatalf("FromWire: expected %v, got %v", tc.err, err)
+		}
+	}
+}
+
+func TestObserverCore(t *testing.T) {
+	participants := peers.NewPeers()
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		pubHex := fmt.Sprintf("0x%X",
+			crypto.FromECDSAPub(&key.PublicKey))
+		participants.AddPeer(peers.NewPeer(pubHex, ""))
+	}
+
+	key, _ := crypto.GenerateECDSAKey()
+	observer := NewCore(uint64(participants.Len()),
+		key,
+		participants,
+		poset.NewInmemStore(participants, 1000, nil),
+		nil,
+		common.NewTestLogger(t))
+	observer.SetObserver(true)
+
+	if !observer.IsObserver() {
+		t.Fatal("core should be in observer mode")
+	}
+	if err := observer.SetHeadAndHeight(); err != nil {
+		t.Fatal(err)
+	}
+	if err := observer.AddTransactions([][]byte{[]byte("tx")}); err != ErrObserver {
+		t.Fatalf("expected ErrObserver, got %v", err)
+	}
+	if err := observer.AddSelfEventBlock(poset.EventHash{}); err != ErrObserver {
+		t.Fatalf("expected ErrObserver, got %v", err)
+	}
+	if l := observer.GetTransactionPoolCount(); l != 0 {
+		t.Fatalf("transaction pool should be empty, not %d", l)
+	}
+	if l := len(observer.KnownEvents()); l != participants.Len() {
+		t.Fatalf("observer should track %d participants, not %d", participants.Len(), l)
+	}
+}
+
 /*
     |   |   |   |-----------------
 	|   w31 |   | R3
@@ -1054,3 +1134,244 @@ func getName(index map[string]poset.EventHash, hash poset.EventHash) string {
 	}
 	return fmt.Sprintf("%s not found", hash)
 }
+
+func TestSelfAncestors(t *testing.T) {
+	participants := peers.NewPeers()
+	key, _ := crypto.GenerateECDSAKey()
+	pubHex := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
+	participants.AddPeer(peers.NewPeer(pubHex, ""))
+	peer, _ := participants.ReadByPubKey(pubHex)
+	core := NewCore(peer.ID,
+		key,
+		participants,
+		poset.NewInmemStore(participants, 1000, nil),
+		nil,
+		common.NewTestLogger(t))
+	if err := core.SetHeadAndHeight(); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := core.LastEventHashFrom(peer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := core.AddSelfEventBlock(head); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ancestors, err := core.SelfAncestors(peer.ID, head, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ancestors) != 2 {
+		t.Fatalf("expected 2 ancestors, got %d", len(ancestors))
+	}
+	for i, a := range ancestors {
+		if a.Body.Index != int64(i+1) || a.Body.SelfParentIndex != int64(i) {
+			t.Fatalf("ancestor %d: bad index %d, self-parent %d",
+				i, a.Body.Index, a.Body.SelfParentIndex)
+		}
+	}
+
+	ancestors, err = core.SelfAncestors(peer.ID, head, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ancestors) != 1 {
+		t.Fatalf("expected the limit to cap ancestors to 1, got %d", len(ancestors))
+	}
+
+	if _, err := core.SelfAncestors(peer.ID+1, head, 4, 10); err == nil {
+		t.Fatal("expected an error for an unknown participant")
+	}
+}
+
+func TestInsertEventDropsPooledTxs(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make(map[uint64]*ecdsa.PrivateKey)
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		peer := peers.NewPeer(fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey)), "")
+		participants.AddPeer(peer)
+		keys[peer.ID] = key
+	}
+	ps := participants.ToPeerSlice()
+	core := NewCore(ps[0].ID,
+		keys[ps[0].ID],
+		participants,
+		poset.NewInmemStore(participants, 1000, nil),
+		nil,
+		common.NewTestLogger(t))
+
+	// the transaction was gossiped to both nodes, the peer puts it in its
+	// first event
+	tx := []byte("gossiped")
+	if err := core.AddTransactions([][]byte{tx}); err != nil {
+		t.Fatal(err)
+	}
+	event := poset.NewEvent([][]byte{tx},
+		[]poset.InternalTransaction{},
+		nil,
+		poset.EventHashes{poset.GenRootSelfParent(ps[1].ID), poset.EventHash{}},
+		crypto.FromECDSAPub(&keys[ps[1].ID].PublicKey), 0, poset.FlagTable{})
+	if err := event.Sign(keys[ps[1].ID]); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.InsertEvent(event, false); err != nil {
+		t.Fatal(err)
+	}
+	if l := core.txPool.Len(); l != 0 {
+		t.Fatalf("expected the transaction of the event of the peer to leave the pool, got %d", l)
+	}
+}
+
+func TestNoEmptyEvents(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make(map[uint64]*ecdsa.PrivateKey)
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		peer := peers.NewPeer(fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey)), "")
+		participants.AddPeer(peer)
+		keys[peer.ID] = key
+	}
+	var cores []*Core
+	for _, peer := range participants.ToPeerSlice() {
+		core := NewCore(peer.ID,
+			keys[peer.ID],
+			participants,
+			poset.NewInmemStore(participants, 1000, nil),
+			nil,
+			common.NewTestLogger(t))
+		if err := core.SetHeadAndHeight(); err != nil {
+			t.Fatal(err)
+		}
+		cores = append(cores, core)
+	}
+	// the event of the first core is loaded, with a transaction
+	if err := cores[0].AddTransactions([][]byte{[]byte("loaded")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cores[0].AddSelfEventBlock(poset.EventHash{}); err != nil {
+		t.Fatal(err)
+	}
+	clock := NewSimClock(time.Unix(0, 0))
+	cores[1].SetClock(clock)
+	cores[1].SetNoEmptyEvents(true, 10*time.Second)
+
+	// the event of the first core reaches the second one, which syncs
+	// nothing new from then on
+	event, err := cores[0].GetEventBlock(cores[0].head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cores[1].InsertEvent(event, false); err != nil {
+		t.Fatal(err)
+	}
+	peer, _ := participants.ReadByPubKey(cores[0].HexID())
+	sync := func(payload [][]byte) error {
+		if err := cores[1].AddTransactions(payload); err != nil {
+			return err
+		}
+		return cores[1].Sync(&peer, nil)
+	}
+
+	// the first event of the other core is news
+	head := cores[1].head
+	if err := sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	if cores[1].head == head || cores[1].SkippedEvents() != 0 {
+		t.Fatalf("expected an event, skipped %d", cores[1].SkippedEvents())
+	}
+	head = cores[1].head
+
+	// nothing new, no event
+	if err := sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	if cores[1].head != head || cores[1].SkippedEvents() != 1 {
+		t.Fatalf("expected the event skipped, skipped %d", cores[1].SkippedEvents())
+	}
+
+	// an event past the max silence
+	clock.Advance(10 * time.Second)
+	if err := sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	if cores[1].head == head {
+		t.Fatal("expected an event after the max silence")
+	}
+	head = cores[1].head
+
+	// and one for transactions
+	if err := sync([][]byte{[]byte("tx")}); err != nil {
+		t.Fatal(err)
+	}
+	if cores[1].head == head || cores[1].SkippedEvents() != 1 {
+		t.Fatalf("expected an event for the transactions, skipped %d", cores[1].SkippedEvents())
+	}
+}
+
+// The consensus pipeline runs apart from the event insertion and the stats,
+// run with -race
+func TestConsensusWhileInserting(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make(map[uint64]*ecdsa.PrivateKey)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		peer := peers.NewPeer(fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey)), "")
+		participants.AddPeer(peer)
+		keys[peer.ID] = key
+	}
+	var cores []*Core
+	for _, peer := range participants.ToPeerSlice() {
+		core := NewCore(peer.ID,
+			keys[peer.ID],
+			participants,
+			poset.NewInmemStore(participants, 1000, nil),
+			nil,
+			common.NewTestLogger(t))
+		if err := core.SetHeadAndHeight(); err != nil {
+			t.Fatal(err)
+		}
+		cores = append(cores, core)
+	}
+	core := cores[0]
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		// the cores gossip in a ring
+		for i := 0; i < 50; i++ {
+			from, to := i%len(cores), (i+1)%len(cores)
+			if err := synchronizeCores(cores, from, to, [][]byte{[]byte(strconv.Itoa(i))}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if err := core.RunConsensus(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			core.GetAnchorBlock()
+			core.GetFirstConsensusRound()
+			core.GetLastConsensusRound()
+			core.GetLastDecidedRound()
+			core.GetPendingRoundsCount()
+			core.GetUndeterminedEvents()
+		}
+	}()
+	wg.Wait()
+}
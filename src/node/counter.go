@@ -17,3 +17,7 @@ func (c *count64) decrement() int64 {
 func (c *count64) get() int64 {
 	return atomic.LoadInt64((*int64)(c))
 }
+
+func (c *count64) add(delta int64) int64 {
+	return atomic.AddInt64((*int64)(c), delta)
+}
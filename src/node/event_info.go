@@ -0,0 +1,86 @@
+package node
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/common/hexutil"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// EventInfo is an event with its place in the DAG and in the consensus, to
+// trace how its transactions moved through the poset. Hashes, keys and
+// transactions are 0x prefixed hex. Round and RoundReceived are
+// poset.RoundNIL while the node has not decided them.
+type EventInfo struct {
+	Hash                 string
+	Creator              string
+	CreatorID            uint64
+	Index                int64
+	TopologicalIndex     int64
+	SelfParent           string
+	OtherParent          string
+	Transactions         []string
+	InternalTransactions []*poset.InternalTransaction
+	BlockSignatures      []*poset.BlockSignature
+	Signature            string
+	Round                int64
+	RoundReceived        int64
+	// LamportTimestamp is the consensus timestamp of the event, which
+	// orders the events received in the same round
+	LamportTimestamp int64
+	Clotho           bool
+	Atropos          string
+	Consensus        bool
+}
+
+// GetEventInfo returns the event of the hash with its round, consensus
+// timestamp and fame decisions, as far as the node knows them
+func (n *Node) GetEventInfo(hash poset.EventHash) (EventInfo, error) {
+	event, err := n.core.poset.Store.GetEventBlock(hash)
+	if err != nil {
+		return EventInfo{}, err
+	}
+
+	txs := make([]string, len(event.Transactions()))
+	for i, tx := range event.Transactions() {
+		txs[i] = hexutil.Encode(tx)
+	}
+	selfParent, otherParent := event.SelfParent(), event.OtherParent()
+	info := EventInfo{
+		Hash:                 hash.String(),
+		Creator:              event.GetCreator(),
+		CreatorID:            event.CreatorID(),
+		Index:                event.Index(),
+		TopologicalIndex:     event.Message.TopologicalIndex,
+		SelfParent:           selfParent.String(),
+		OtherParent:          otherParent.String(),
+		Transactions:         txs,
+		InternalTransactions: event.InternalTransactions(),
+		BlockSignatures:      event.BlockSignatures(),
+		Signature:            event.Message.Signature,
+		Round:                event.GetRound(),
+		RoundReceived:        event.GetRoundReceived(),
+		LamportTimestamp:     event.GetLamportTimestamp(),
+		Atropos:              poset.Trilean_UNDEFINED.String(),
+	}
+	if info.Round == poset.RoundNIL {
+		return info, nil
+	}
+
+	// the fame decisions of the event are recorded by its round
+	round, err := n.core.poset.Store.GetRoundCreated(info.Round)
+	if err != nil {
+		if common.Is(err, common.KeyNotFound) {
+			return info, nil
+		}
+		return EventInfo{}, err
+	}
+	if roundEvent, ok := round.Message.Events[info.Hash]; ok {
+		info.Clotho = roundEvent.Clotho
+		info.Atropos = roundEvent.Atropos.String()
+		info.Consensus = roundEvent.Consensus
+		if info.RoundReceived == poset.RoundNIL && roundEvent.Consensus {
+			info.RoundReceived = roundEvent.RoundReceived
+		}
+	}
+	return info, nil
+}
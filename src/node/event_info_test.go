@@ -0,0 +1,46 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestGetEventInfo(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	root := node.core.Head()
+	if err := node.core.AddTransactions([][]byte{[]byte("tx")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.core.AddSelfEventBlock(root); err != nil {
+		t.Fatal(err)
+	}
+	head := node.core.Head()
+	info, err := node.GetEventInfo(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Hash != head.String() || info.Index != 1 || info.SelfParent != root.String() ||
+		len(info.Transactions) != 1 || info.Signature == "" {
+		t.Fatalf("expected the event %s on the root, got %+v", head.String(), info)
+	}
+	if info.Creator != node.core.HexID() {
+		t.Fatalf("expected creator %s, got %s", node.core.HexID(), info.Creator)
+	}
+	if info.Round != poset.RoundNIL || info.RoundReceived != poset.RoundNIL || info.Consensus {
+		t.Fatalf("expected an undecided event, got %+v", info)
+	}
+
+	var unknown poset.EventHash
+	if _, err := node.GetEventInfo(unknown); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("expected KeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// subscribeNodeEvents sends the node events to the app, if its proxy is a
+// proxy.NodeEventSubscriber
+func (n *Node) subscribeNodeEvents(participants *peers.Peers) {
+	subscriber, ok := n.proxy.(proxy.NodeEventSubscriber)
+	if !ok {
+		return
+	}
+	n.nodeEvents = subscriber.NodeEventCh()
+	if n.nodeEvents == nil {
+		return
+	}
+	participants.OnNewPeer(func(peer *peers.Peer) {
+		n.emitNodeEvent(proxy.NodeEvent{Type: proxy.PeerAdded, Peer: peer})
+	})
+	participants.OnRemovedPeer(func(peer *peers.Peer) {
+		n.emitNodeEvent(proxy.NodeEvent{Type: proxy.PeerRemoved, Peer: peer})
+	})
+}
+
+// emitNodeEvent never blocks: the event is dropped if the app is late
+func (n *Node) emitNodeEvent(event proxy.NodeEvent) {
+	if n.nodeEvents == nil {
+		return
+	}
+	event.Time = n.clock.Now()
+	select {
+	case n.nodeEvents <- event:
+	default:
+		n.logger.WithField("event", event.Type).Warn("node event dropped")
+	}
+}
+
+// setState makes the node go to state s and tells the app and the
+// subscribers of the state feed when it changes. It returns false if the
+// current state does not allow it, see transitions, or is not one of from,
+// when given.
+func (n *Node) setState(s state, from ...state) bool {
+	prev, changed, err := n.fsm.transition(s, from...)
+	if err != nil {
+		n.logger.WithError(err).Debug("State transition refused")
+		return false
+	}
+	if changed {
+		n.publishState(prev, s)
+		n.emitNodeEvent(proxy.NodeEvent{Type: proxy.NodeStateChanged, State: s.String()})
+	}
+	return true
+}
+
+// StateHistory returns the last state transitions of the node, oldest first
+func (n *Node) StateHistory() []StateTransition {
+	return n.fsm.transitionHistory()
+}
+
+// emitTxExpired tells the app about the transactions which expired in the
+// pool
+func (n *Node) emitTxExpired(hashes []string) {
+	n.emitNodeEvent(proxy.NodeEvent{Type: proxy.TxExpired, Txs: hashes})
+}
+
+// emitFork tells the app about a fork detected while syncing
+func (n *Node) emitFork(fork *poset.ForkError) {
+	n.emitNodeEvent(proxy.NodeEvent{
+		Type:    proxy.ForkDetected,
+		Creator: fork.Creator,
+		Index:   fork.Index,
+		Events:  []string{fork.Known.String(), fork.Fork.String()},
+	})
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// indexBlockEvents indexes the events received in the round of the
+// committed block, if the store supports it
+func (n *Node) indexBlockEvents(block poset.Block) {
+	store, ok := n.core.poset.Store.(poset.ExplorerStore)
+	if !ok {
+		return
+	}
+	received, err := store.GetRoundReceived(block.RoundReceived())
+	if err == nil {
+		events := make(poset.EventHashes, len(received.Rounds))
+		for i, raw := range received.Rounds {
+			events[i].Set(raw)
+		}
+		err = store.SetBlockEvents(block.Index(), events)
+	}
+	if err != nil {
+		n.logger.WithError(err).WithFields(logrus.Fields{
+			"block": block.Index(),
+		}).Error("Indexing block events")
+	}
+}
+
+// explorerStore returns the store if it has the explorer indexes
+func (n *Node) explorerStore() (poset.ExplorerStore, error) {
+	store, ok := n.core.poset.Store.(poset.ExplorerStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not index events for explorers")
+	}
+	return store, nil
+}
+
+// eventInfos returns the details of the events
+func (n *Node) eventInfos(hashes poset.EventHashes) ([]EventInfo, error) {
+	res := make([]EventInfo, 0, len(hashes))
+	for _, hash := range hashes {
+		info, err := n.GetEventInfo(hash)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
+
+// GetBlockEvents returns the details of the events the block is made of,
+// in consensus order
+func (n *Node) GetBlockEvents(blockIndex int64) ([]EventInfo, error) {
+	store, err := n.explorerStore()
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := store.GetBlockEvents(blockIndex)
+	if err != nil {
+		return nil, err
+	}
+	return n.eventInfos(hashes)
+}
+
+// GetCreatorEvents returns the details of the events of the creator, a hex
+// public key, from the index, at most limit of them
+func (n *Node) GetCreatorEvents(creator string, from int64, limit int) ([]EventInfo, error) {
+	store, err := n.explorerStore()
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := store.CreatorEvents(creator, from, limit)
+	if err != nil {
+		return nil, err
+	}
+	return n.eventInfos(hashes)
+}
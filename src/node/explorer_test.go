@@ -0,0 +1,45 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestExplorerEvents(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	if err := node.core.AddTransactions([][]byte{[]byte("tx")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.core.AddSelfEventBlock(node.core.Head()); err != nil {
+		t.Fatal(err)
+	}
+	head := node.core.Head()
+
+	events, err := node.GetCreatorEvents(node.core.HexID(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Hash != head.String() {
+		t.Fatalf("expected the event %s, got %+v", head.String(), events)
+	}
+
+	store := node.core.poset.Store.(poset.ExplorerStore)
+	if err := store.SetBlockEvents(0, poset.EventHashes{head}); err != nil {
+		t.Fatal(err)
+	}
+	events, err = node.GetBlockEvents(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Hash != head.String() || len(events[0].Transactions) != 1 {
+		t.Fatalf("expected the event %s in block 0, got %+v", head.String(), events)
+	}
+}
@@ -0,0 +1,427 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// faultReorderWindow is the longest time a reordered RPC is held back
+const faultReorderWindow = 100 * time.Millisecond
+
+var (
+	// ErrFaultsDisabled is returned when faults are set on a node which was
+	// not created with Config.FaultInjection
+	ErrFaultsDisabled = errors.New("fault injection is disabled")
+	// ErrFaultDropped is returned by the RPCs dropped by the injected faults
+	ErrFaultDropped = errors.New("rpc dropped by fault injection")
+	// ErrFaultStoreWrite is returned by the store writes failed by the
+	// injected faults
+	ErrFaultStoreWrite = errors.New("store write failed by fault injection")
+)
+
+// Faults are the faults a node injects in the RPCs it sends and in the
+// writes to its store, to test the resilience of a network without network
+// tooling. The rates are shares of the calls, between 0 and 1; the zero
+// value injects no fault.
+type Faults struct {
+	// Drop fails the RPCs with ErrFaultDropped, without sending them
+	Drop float64
+	// Delay holds every RPC back before sending it
+	Delay time.Duration
+	// Duplicate sends the RPCs twice, the answer to the first copy is
+	// dropped
+	Duplicate float64
+	// Reorder holds the RPCs back for up to 100ms more, so that the next
+	// RPCs overtake them
+	Reorder float64
+	// StoreWrite fails the writes to the store with ErrFaultStoreWrite
+	StoreWrite float64
+	// Peer restricts the RPC faults to the peer at this address, all the
+	// peers when empty
+	Peer string
+}
+
+// faults holds the Faults of a node, drawn with its Rand
+type faults struct {
+	lock   sync.RWMutex
+	faults Faults
+	rand   Rand
+}
+
+func newFaults(rnd Rand) *faults {
+	return &faults{rand: rnd}
+}
+
+func (f *faults) get() Faults {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.faults
+}
+
+func (f *faults) set(faults Faults) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.faults = faults
+}
+
+// hit draws whether a fault of the rate happens
+func (f *faults) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	return float64(f.rand.Int63())/math.MaxInt64 < rate
+}
+
+// SetFaults sets the faults the node injects, see Faults. It returns
+// ErrFaultsDisabled unless the node was created with Config.FaultInjection.
+func (n *Node) SetFaults(faults Faults) error {
+	if n.faults == nil {
+		return ErrFaultsDisabled
+	}
+	n.faults.set(faults)
+	n.logger.WithField("faults", faults).Warn("Injected faults changed")
+	return nil
+}
+
+// GetFaults returns the faults the node injects, see SetFaults
+func (n *Node) GetFaults() (Faults, error) {
+	if n.faults == nil {
+		return Faults{}, ErrFaultsDisabled
+	}
+	return n.faults.get(), nil
+}
+
+// faultyTransport injects the faults in the RPCs sent by the transport
+type faultyTransport struct {
+	peer.SyncPeer
+	faults *faults
+	clock  Clock
+}
+
+// call sends the RPC with rpc, after drawing its faults
+func (t *faultyTransport) call(ctx context.Context, target string, rpc func() error) error {
+	f := t.faults.get()
+	if f.Peer != "" && f.Peer != target {
+		return rpc()
+	}
+	if t.faults.hit(f.Drop) {
+		return ErrFaultDropped
+	}
+	delay := f.Delay
+	if t.faults.hit(f.Reorder) {
+		delay += time.Duration(t.faults.rand.Int63() % int64(faultReorderWindow))
+	}
+	if delay > 0 {
+		select {
+		case <-t.clock.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if t.faults.hit(f.Duplicate) {
+		rpc() // the answer to the first copy is dropped
+	}
+	return rpc()
+}
+
+// Sync implements peer.SyncPeer
+func (t *faultyTransport) Sync(ctx context.Context, target string,
+	req *peer.SyncRequest, resp *peer.SyncResponse) error {
+	return t.call(ctx, target, func() error {
+		return t.SyncPeer.Sync(ctx, target, req, resp)
+	})
+}
+
+// ForceSync implements peer.SyncPeer
+func (t *faultyTransport) ForceSync(ctx context.Context, target string,
+	req *peer.ForceSyncRequest, resp *peer.ForceSyncResponse) error {
+	return t.call(ctx, target, func() error {
+		return t.SyncPeer.ForceSync(ctx, target, req, resp)
+	})
+}
+
+// FastForward implements peer.SyncPeer
+func (t *faultyTransport) FastForward(ctx context.Context, target string,
+	req *peer.FastForwardRequest, resp *peer.FastForwardResponse) error {
+	return t.call(ctx, target, func() error {
+		return t.SyncPeer.FastForward(ctx, target, req, resp)
+	})
+}
+
+// Ancestors implements peer.SyncPeer
+func (t *faultyTransport) Ancestors(ctx context.Context, target string,
+	req *peer.AncestorsRequest, resp *peer.AncestorsResponse) error {
+	return t.call(ctx, target, func() error {
+		return t.SyncPeer.Ancestors(ctx, target, req, resp)
+	})
+}
+
+// StateChunk implements peer.SyncPeer
+func (t *faultyTransport) StateChunk(ctx context.Context, target string,
+	req *peer.StateChunkRequest, resp *peer.StateChunkResponse) error {
+	return t.call(ctx, target, func() error {
+		return t.SyncPeer.StateChunk(ctx, target, req, resp)
+	})
+}
+
+// TxGossip implements peer.SyncPeer
+func (t *faultyTransport) TxGossip(ctx context.Context, target string,
+	req *peer.TxGossipRequest, resp *peer.TxGossipResponse) error {
+	return t.call(ctx, target, func() error {
+		return t.SyncPeer.TxGossip(ctx, target, req, resp)
+	})
+}
+
+// faultyStore injects the faults in the writes to the store. Like
+// pgmirror.Store, it forwards the optional interfaces of the store, so that
+// the node and the service find them through it.
+type faultyStore struct {
+	poset.Store
+	faults *faults
+}
+
+func (s *faultyStore) fail() bool {
+	return s.faults.hit(s.faults.get().StoreWrite)
+}
+
+// SetEvent implements poset.Store
+func (s *faultyStore) SetEvent(event poset.Event) error {
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return s.Store.SetEvent(event)
+}
+
+// AddConsensusEvent implements poset.Store
+func (s *faultyStore) AddConsensusEvent(event poset.Event) error {
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return s.Store.AddConsensusEvent(event)
+}
+
+// SetRoundCreated implements poset.Store
+func (s *faultyStore) SetRoundCreated(r int64, round poset.RoundCreated) error {
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return s.Store.SetRoundCreated(r, round)
+}
+
+// SetRoundReceived implements poset.Store
+func (s *faultyStore) SetRoundReceived(r int64, round poset.RoundReceived) error {
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return s.Store.SetRoundReceived(r, round)
+}
+
+// SetBlock implements poset.Store
+func (s *faultyStore) SetBlock(block poset.Block) error {
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return s.Store.SetBlock(block)
+}
+
+// SetFrame implements poset.Store
+func (s *faultyStore) SetFrame(frame poset.Frame) error {
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return s.Store.SetFrame(frame)
+}
+
+// SetReceipts stores the receipts in the wrapped store if it supports it
+func (s *faultyStore) SetReceipts(blockIndex int64, receipts []poset.Receipt) error {
+	store, ok := s.Store.(poset.ReceiptStore)
+	if !ok {
+		return errors.New("store does not keep receipts")
+	}
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return store.SetReceipts(blockIndex, receipts)
+}
+
+// GetReceipts reads receipts of the wrapped store if it supports it
+func (s *faultyStore) GetReceipts(blockIndex int64) ([]poset.Receipt, error) {
+	store, ok := s.Store.(poset.ReceiptStore)
+	if !ok {
+		return nil, errors.New("store does not keep receipts")
+	}
+	return store.GetReceipts(blockIndex)
+}
+
+// SetStateSnapshot stores the snapshot in the wrapped store if it supports
+// it
+func (s *faultyStore) SetStateSnapshot(snapshot poset.StateSnapshot, chunks [][]byte) error {
+	store, ok := s.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return errors.New("store does not keep state snapshots")
+	}
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return store.SetStateSnapshot(snapshot, chunks)
+}
+
+// GetStateSnapshot reads a snapshot of the wrapped store if it supports it
+func (s *faultyStore) GetStateSnapshot(stateHash []byte) (poset.StateSnapshot, error) {
+	store, ok := s.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return poset.StateSnapshot{}, errors.New("store does not keep state snapshots")
+	}
+	return store.GetStateSnapshot(stateHash)
+}
+
+// GetStateSnapshotChunk reads a snapshot chunk of the wrapped store if it
+// supports it
+func (s *faultyStore) GetStateSnapshotChunk(stateHash []byte, index int) ([]byte, error) {
+	store, ok := s.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return nil, errors.New("store does not keep state snapshots")
+	}
+	return store.GetStateSnapshotChunk(stateHash, index)
+}
+
+// StartBatch starts a batch in the wrapped store if it supports it
+func (s *faultyStore) StartBatch() error {
+	if store, ok := s.Store.(poset.BatchStore); ok {
+		return store.StartBatch()
+	}
+	return nil
+}
+
+// CommitBatch commits a batch of the wrapped store if it supports it
+func (s *faultyStore) CommitBatch() error {
+	if store, ok := s.Store.(poset.BatchStore); ok {
+		return store.CommitBatch()
+	}
+	return nil
+}
+
+// Flush flushes the wrapped store if it supports it
+func (s *faultyStore) Flush() error {
+	if store, ok := s.Store.(interface{ Flush() error }); ok {
+		return store.Flush()
+	}
+	return nil
+}
+
+// IndexTransactions indexes the transactions of the block in the wrapped
+// store if it supports it
+func (s *faultyStore) IndexTransactions(block poset.Block) error {
+	store, ok := s.Store.(poset.TxIndexStore)
+	if !ok {
+		return errors.New("store does not index transactions")
+	}
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return store.IndexTransactions(block)
+}
+
+// GetTxLocation reads the location of a transaction in the wrapped store.
+// A store which does not index transactions knows none of them.
+func (s *faultyStore) GetTxLocation(hash string) (poset.TxLocation, error) {
+	store, ok := s.Store.(poset.TxIndexStore)
+	if !ok {
+		return poset.TxLocation{}, common.NewStoreErr("TxIndex", common.KeyNotFound, hash)
+	}
+	return store.GetTxLocation(hash)
+}
+
+// SetBlockEvents indexes the events of the block in the wrapped store if it
+// supports it
+func (s *faultyStore) SetBlockEvents(blockIndex int64, events poset.EventHashes) error {
+	store, ok := s.Store.(poset.ExplorerStore)
+	if !ok {
+		return errors.New("store does not index events for explorers")
+	}
+	if s.fail() {
+		return ErrFaultStoreWrite
+	}
+	return store.SetBlockEvents(blockIndex, events)
+}
+
+// GetBlockEvents reads the events of the block in the wrapped store if it
+// supports it
+func (s *faultyStore) GetBlockEvents(blockIndex int64) (poset.EventHashes, error) {
+	store, ok := s.Store.(poset.ExplorerStore)
+	if !ok {
+		return nil, errors.New("store does not index events for explorers")
+	}
+	return store.GetBlockEvents(blockIndex)
+}
+
+// CreatorEvents reads the events of the creator in the wrapped store if it
+// supports it
+func (s *faultyStore) CreatorEvents(creator string, from int64, limit int) (poset.EventHashes, error) {
+	store, ok := s.Store.(poset.ExplorerStore)
+	if !ok {
+		return nil, errors.New("store does not index events for explorers")
+	}
+	return store.CreatorEvents(creator, from, limit)
+}
+
+// Backup backs up the wrapped store if it supports it
+func (s *faultyStore) Backup(w io.Writer) (int64, error) {
+	store, ok := s.Store.(poset.BackupStore)
+	if !ok {
+		return 0, errors.New("store does not support backups")
+	}
+	return store.Backup(w)
+}
+
+// Compact compacts the wrapped store if it supports it
+func (s *faultyStore) Compact() error {
+	store, ok := s.Store.(poset.CompactStore)
+	if !ok {
+		return errors.New("store does not support compaction")
+	}
+	return store.Compact()
+}
+
+// DiskUsage returns the size of the wrapped store if it is kept on disk
+func (s *faultyStore) DiskUsage() (int64, error) {
+	store, ok := s.Store.(poset.DiskStore)
+	if !ok {
+		return 0, errors.New("store is not kept on disk")
+	}
+	return store.DiskUsage()
+}
+
+// PrunedRound returns the last round pruned from the wrapped store, -1 if it
+// cannot be pruned
+func (s *faultyStore) PrunedRound() (int64, error) {
+	store, ok := s.Store.(poset.PrunedStore)
+	if !ok {
+		return -1, nil
+	}
+	return store.PrunedRound()
+}
+
+// LastStoredBlock reads the last block of the wrapped store if it supports it
+func (s *faultyStore) LastStoredBlock() (poset.Block, error) {
+	store, ok := s.Store.(poset.PrunedStore)
+	if !ok {
+		return poset.Block{}, errors.New("store cannot be pruned")
+	}
+	return store.LastStoredBlock()
+}
+
+// ResizeCache resizes the LRU caches of the wrapped store if it has some
+func (s *faultyStore) ResizeCache(size int) {
+	if store, ok := s.Store.(poset.CacheResizer); ok {
+		store.ResizeCache(size)
+	}
+}
@@ -0,0 +1,139 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// countingPeer counts the Syncs it is sent
+type countingPeer struct {
+	peer.SyncPeer
+	syncs int
+}
+
+func (p *countingPeer) Sync(ctx context.Context, target string,
+	req *peer.SyncRequest, resp *peer.SyncResponse) error {
+	p.syncs++
+	return nil
+}
+
+func TestFaultyTransport(t *testing.T) {
+	inner := &countingPeer{}
+	faults := newFaults(NewSeededRand(1))
+	trans := &faultyTransport{SyncPeer: inner, faults: faults, clock: wallClock{}}
+	sync := func(target string) error {
+		return trans.Sync(context.Background(), target,
+			&peer.SyncRequest{}, &peer.SyncResponse{})
+	}
+
+	if err := sync("a"); err != nil || inner.syncs != 1 {
+		t.Fatalf("expected the Sync sent once, got %d, %v", inner.syncs, err)
+	}
+
+	faults.set(Faults{Drop: 1})
+	if err := sync("a"); err != ErrFaultDropped || inner.syncs != 1 {
+		t.Fatalf("expected the Sync dropped, got %d, %v", inner.syncs, err)
+	}
+
+	faults.set(Faults{Drop: 1, Peer: "b"})
+	if err := sync("a"); err != nil || inner.syncs != 2 {
+		t.Fatalf("expected the Sync to another peer sent, got %d, %v", inner.syncs, err)
+	}
+
+	faults.set(Faults{Duplicate: 1})
+	if err := sync("a"); err != nil || inner.syncs != 4 {
+		t.Fatalf("expected the Sync sent twice, got %d, %v", inner.syncs, err)
+	}
+
+	faults.set(Faults{Delay: 20 * time.Millisecond})
+	start := time.Now()
+	if err := sync("a"); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("expected the Sync delayed, took %v, %v", time.Since(start), err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := trans.Sync(ctx, "a", &peer.SyncRequest{}, &peer.SyncResponse{})
+	if err != context.Canceled || inner.syncs != 5 {
+		t.Fatalf("expected the delayed Sync canceled, got %d, %v", inner.syncs, err)
+	}
+}
+
+func TestFaultyStore(t *testing.T) {
+	faults := newFaults(NewSeededRand(1))
+	store := &faultyStore{
+		Store:  poset.NewInmemStore(peers.NewPeers(), 10, nil),
+		faults: faults,
+	}
+	block := poset.NewBlock(0, 1, []byte("frame"), [][]byte{[]byte("tx")})
+
+	faults.set(Faults{StoreWrite: 1})
+	if err := store.SetBlock(block); err != ErrFaultStoreWrite {
+		t.Fatalf("expected the write to fail, got %v", err)
+	}
+	if _, err := store.GetBlock(0); err == nil {
+		t.Fatal("expected the failed block not to be stored")
+	}
+
+	faults.set(Faults{})
+	if err := store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetBlock(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFaultyStoreInterfaces(t *testing.T) {
+	faults := newFaults(NewSeededRand(1))
+	store := &faultyStore{
+		Store:  poset.NewInmemStore(peers.NewPeers(), 10, nil),
+		faults: faults,
+	}
+	// the optional interfaces the node and the service assert are forwarded
+	var (
+		_ poset.TxIndexStore  = store
+		_ poset.ExplorerStore = store
+		_ poset.BackupStore   = store
+		_ poset.CompactStore  = store
+		_ poset.DiskStore     = store
+		_ poset.PrunedStore   = store
+		_ poset.CacheResizer  = store
+	)
+
+	block := poset.NewBlock(0, 1, []byte("frame"), [][]byte{[]byte("tx")})
+	faults.set(Faults{StoreWrite: 1})
+	if err := store.IndexTransactions(block); err != ErrFaultStoreWrite {
+		t.Fatalf("expected the index write to fail, got %v", err)
+	}
+	faults.set(Faults{})
+	if err := store.IndexTransactions(block); err != nil {
+		t.Fatal(err)
+	}
+	location, err := store.GetTxLocation(poset.TxHash([]byte("tx")))
+	if err != nil || location.Block != 0 {
+		t.Fatalf("expected the transaction in block 0, got %v, %v", location, err)
+	}
+
+	// the inmem store is not kept on disk
+	if _, err := store.DiskUsage(); err == nil {
+		t.Fatal("expected the inmem store to have no disk usage")
+	}
+	if round, err := store.PrunedRound(); err != nil || round != -1 {
+		t.Fatalf("expected no pruned round, got %d, %v", round, err)
+	}
+}
+
+func TestSetFaultsDisabled(t *testing.T) {
+	n := &Node{}
+	if err := n.SetFaults(Faults{Drop: 1}); err != ErrFaultsDisabled {
+		t.Fatalf("expected ErrFaultsDisabled, got %v", err)
+	}
+	if _, err := n.GetFaults(); err != ErrFaultsDisabled {
+		t.Fatalf("expected ErrFaultsDisabled, got %v", err)
+	}
+}
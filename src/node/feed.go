@@ -0,0 +1,81 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// Types of the notifications of the node feed
+const (
+	// FeedBlock is published when the node commits a block
+	FeedBlock = "newBlock"
+	// FeedEvent is published when the node inserts an event in its DAG,
+	// its own or one it received
+	FeedEvent = "newEvent"
+)
+
+// Notification is published to the subscribers of the node feed. Only the
+// field of its Type is set.
+type Notification struct {
+	Type      string
+	Block     *poset.Block `json:",omitempty"`
+	Event     *poset.Event `json:",omitempty"`
+	State     string       `json:",omitempty"`
+	PrevState string       `json:",omitempty"`
+}
+
+// feed publishes the notifications of the node to its subscribers, without
+// ever blocking: a subscriber which lags behind misses notifications
+type feed struct {
+	lock sync.Mutex
+	subs map[chan Notification]struct{}
+}
+
+func newFeed() *feed {
+	return &feed{subs: make(map[chan Notification]struct{})}
+}
+
+func (f *feed) subscribe(buffer int) (<-chan Notification, func()) {
+	ch := make(chan Notification, buffer)
+	f.lock.Lock()
+	f.subs[ch] = struct{}{}
+	f.lock.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.lock.Lock()
+			delete(f.subs, ch)
+			f.lock.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (f *feed) publish(note Notification) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- note:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of the blocks the node commits and the events
+// it inserts, holding up to buffer notifications, and the function which
+// ends the subscription and closes the channel. Notifications are dropped
+// while the channel is full.
+func (n *Node) Subscribe(buffer int) (<-chan Notification, func()) {
+	return n.feed.subscribe(buffer)
+}
+
+func (n *Node) publishBlock(block poset.Block) {
+	n.feed.publish(Notification{Type: FeedBlock, Block: &block})
+}
+
+func (n *Node) publishEvent(event poset.Event) {
+	n.feed.publish(Notification{Type: FeedEvent, Event: &event})
+}
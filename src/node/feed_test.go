@@ -0,0 +1,34 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestFeed(t *testing.T) {
+	f := newFeed()
+	notes, unsubscribe := f.subscribe(1)
+
+	block := poset.NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+	f.publish(Notification{Type: FeedBlock, Block: &block})
+	// the subscriber lags behind, the next one is dropped
+	f.publish(Notification{Type: FeedBlock, Block: &block})
+
+	note := <-notes
+	if note.Type != FeedBlock || note.Block.Index() != 0 {
+		t.Fatalf("expected block 0, got %v", note)
+	}
+	select {
+	case note := <-notes:
+		t.Fatalf("expected no more notifications, got %v", note)
+	default:
+	}
+
+	unsubscribe()
+	if _, ok := <-notes; ok {
+		t.Fatal("expected a closed channel")
+	}
+	f.publish(Notification{Type: FeedBlock, Block: &block})
+	unsubscribe()
+}
@@ -0,0 +1,45 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/dummy"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestFollower(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	selectorArgs := SmartPeerSelectorCreationFnArgs{LocalAddr: data.Adds[0]}
+	newNode := func(config *Config) *Node {
+		db := poset.NewInmemStore(data.Peers, config.CacheSize, nil)
+		app := dummy.NewInmemDummyApp(data.Logger)
+		return NewNode(config, 1, nil, data.Peers, db, trans, app,
+			NewSmartPeerSelectorWrapper, selectorArgs, data.Adds[0])
+	}
+
+	// a validator needs its key
+	if err := newNode(data.Config).Init(); err != ErrNoKey {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+
+	config := TestConfig(t)
+	config.Observer = true
+	config.Follower = true
+	node := newNode(config)
+	if err := node.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if node.core.PubKey() != nil || node.core.HexID() != "" {
+		t.Fatalf("expected no public key, got %s", node.core.HexID())
+	}
+	if err := node.core.AddSelfEventBlock(poset.EventHash{}); err != ErrObserver {
+		t.Fatalf("expected ErrObserver, got %v", err)
+	}
+	if stats := node.GetStats(); stats["follower"] != "true" {
+		t.Fatalf("expected a follower, got %s", stats["follower"])
+	}
+}
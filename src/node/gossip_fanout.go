@@ -0,0 +1,78 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+// DefaultGossipFanout is the default Config.GossipFanout, the events go to
+// the peers the gossip synced with only
+const DefaultGossipFanout = 1
+
+// peerKnown holds the known events each peer last told, in a SyncRequest or
+// a SyncResponse
+type peerKnown struct {
+	lock  sync.Mutex
+	known map[uint64]map[uint64]int64
+}
+
+func newPeerKnown() *peerKnown {
+	return &peerKnown{known: make(map[uint64]map[uint64]int64)}
+}
+
+func (p *peerKnown) set(id uint64, known map[uint64]int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.known[id] = known
+}
+
+func (p *peerKnown) get(id uint64) (map[uint64]int64, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	known, ok := p.known[id]
+	return known, ok
+}
+
+// recordKnown keeps the known events of the peer for the fanout, with
+// Config.GossipFanout above one
+func (n *Node) recordKnown(id uint64, known map[uint64]int64) {
+	if n.conf.GossipFanout > 1 && known != nil {
+		n.peerKnown.set(id, known)
+	}
+}
+
+// fanout pushes the events of the node to Config.GossipFanout peers in all,
+// the synced ones which were pushed to plus random others. The diff of an
+// other peer is against the known events it last told, or against those of
+// the synced peers, fallback, if it told none yet: it may then get events it
+// has, which it skips, or miss parents, and get them in the next gossips.
+func (n *Node) fanout(synced []*peers.Peer, fallback map[uint64]int64) {
+	k := n.conf.GossipFanout - len(synced)
+	if k <= 0 || n.conf.Observer {
+		return
+	}
+	seen := make(map[uint64]bool)
+	for _, p := range synced {
+		seen[p.ID] = true
+	}
+	targets := n.selectOtherPeers(k, seen)
+
+	var wg sync.WaitGroup
+	for _, p := range targets {
+		known, ok := n.peerKnown.get(p.ID)
+		if !ok {
+			known = fallback
+		}
+		wg.Add(1)
+		go func(p *peers.Peer, known map[uint64]int64) {
+			defer wg.Done()
+			n.runGuarded("push", func() {
+				if err := n.push(p.NetAddr, known); err != nil {
+					n.health.failure(p.ID, err)
+				}
+			})
+		}(p, known)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,47 @@
+package node
+
+import (
+	"testing"
+)
+
+func TestSelectOtherPeers(t *testing.T) {
+	fp := fakePeers(5)
+	fps := fp.ToPeerSlice()
+	n := &Node{
+		peerSelector: NewRandomPeerSelector(fp, RandomPeerSelectorCreationFnArgs{
+			LocalAddr: fps[0].NetAddr,
+			Rand:      NewSeededRand(1),
+		}),
+	}
+
+	// the node and a synced peer are left out, 3 others remain
+	for i := 0; i < 10; i++ {
+		selected := n.selectOtherPeers(3, map[uint64]bool{fps[1].ID: true})
+		for _, p := range selected {
+			if p.ID == fps[0].ID || p.ID == fps[1].ID {
+				t.Fatalf("expected neither the node nor the synced peer, got %s", p.NetAddr)
+			}
+		}
+	}
+	seen := map[uint64]bool{fps[1].ID: true}
+	selected := n.selectOtherPeers(1, seen)
+	if len(selected) != 1 || !seen[selected[0].ID] {
+		t.Fatalf("expected 1 peer added to seen, got %v", selected)
+	}
+}
+
+func TestRecordKnown(t *testing.T) {
+	n := &Node{conf: &Config{GossipFanout: DefaultGossipFanout}, peerKnown: newPeerKnown()}
+	n.recordKnown(1, map[uint64]int64{1: 5})
+	if _, ok := n.peerKnown.get(1); ok {
+		t.Fatal("expected no known events kept without fanout")
+	}
+
+	n.conf.GossipFanout = 3
+	n.recordKnown(1, map[uint64]int64{1: 5})
+	n.recordKnown(1, map[uint64]int64{1: 7})
+	known, ok := n.peerKnown.get(1)
+	if !ok || known[1] != 7 {
+		t.Fatalf("expected the last known events, got %v", known)
+	}
+}
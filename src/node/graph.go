@@ -26,14 +26,10 @@ func (g *Graph) GetBlocks() []poset.Block {
 		blockIdx = 0
 	}
 
-	for blockIdx <= store.LastBlockIndex() {
-		r, err := store.GetBlock(blockIdx)
-		if err != nil {
-			break
-		}
-		res = append(res, r)
-		blockIdx++
-	}
+	_ = store.BlocksRange(blockIdx, store.LastBlockIndex()+1, func(b poset.Block) error {
+		res = append(res, b)
+		return nil
+	})
 	return res
 }
 
@@ -108,17 +104,10 @@ func (g *Graph) GetRounds() []poset.RoundCreated {
 		round = 0
 	}
 
-	for round <= store.LastRound() {
-		r, err := store.GetRoundCreated(round)
-
-		if err != nil {
-			break
-		}
-
+	_ = store.RoundsRange(round, store.LastRound()+1, func(_ int64, r poset.RoundCreated) error {
 		res = append(res, r)
-
-		round++
-	}
+		return nil
+	})
 
 	return res
 }
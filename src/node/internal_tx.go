@@ -0,0 +1,174 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// DefaultMaxInternalTxs is the default number of internal transactions the
+// internal pool holds
+const DefaultMaxInternalTxs = 1000
+
+var (
+	// ErrInternalTxPoolFull is returned for the internal transactions a full
+	// internal pool refuses
+	ErrInternalTxPoolFull = fmt.Errorf("internal transaction pool full")
+	// ErrDuplicateInternalTx is returned for an internal transaction the
+	// internal pool holds already
+	ErrDuplicateInternalTx = fmt.Errorf("internal transaction already pending")
+)
+
+// internalTxPool holds the internal transactions waiting for an event, apart
+// from the transactions of the clients, whose limits, priorities and
+// expiry do not apply to them
+type internalTxPool struct {
+	lock sync.Mutex
+	txs  []poset.InternalTransaction
+	// keys are the encoded transactions of txs
+	keys map[string]bool
+	// max is the number of transactions the pool holds, 0 for no limit
+	max int
+}
+
+func newInternalTxPool(max int) *internalTxPool {
+	return &internalTxPool{keys: make(map[string]bool), max: max}
+}
+
+func internalTxKey(tx poset.InternalTransaction) (string, error) {
+	bf, err := tx.ProtoMarshal()
+	if err != nil {
+		return "", err
+	}
+	return string(bf), nil
+}
+
+func (p *internalTxPool) setMax(max int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.max = max
+}
+
+// add pools all the transactions, or none of them if one is pending already
+// or there is not enough room for them
+func (p *internalTxPool) add(txs ...poset.InternalTransaction) error {
+	keys := make([]string, len(txs))
+	for i, tx := range txs {
+		key, err := internalTxKey(tx)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if p.keys[key] || seen[key] {
+			return ErrDuplicateInternalTx
+		}
+		seen[key] = true
+	}
+	if p.max > 0 && len(p.txs)+len(txs) > p.max {
+		return ErrInternalTxPoolFull
+	}
+	for i, tx := range txs {
+		p.txs = append(p.txs, tx)
+		p.keys[keys[i]] = true
+	}
+	return nil
+}
+
+// take empties the pool into an event
+func (p *internalTxPool) take() []poset.InternalTransaction {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	txs := p.txs
+	p.txs = nil
+	p.keys = make(map[string]bool)
+	return txs
+}
+
+// putBack returns the transactions of an event which could not be created
+// to the head of the pool, beyond its limit
+func (p *internalTxPool) putBack(txs []poset.InternalTransaction) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, tx := range txs {
+		if key, err := internalTxKey(tx); err == nil {
+			p.keys[key] = true
+		}
+	}
+	p.txs = append(append([]poset.InternalTransaction{}, txs...), p.txs...)
+}
+
+func (p *internalTxPool) count() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.txs)
+}
+
+// SubmitInternalTx adds a consensus-level transaction, a peer joining or
+// leaving the participants, or a PoS transfer, to the internal transaction
+// pool, for the next event of the node. Unlike SubmitTx it is not checked
+// by the app but against the current participants, and returns why it does
+// not apply to them, ErrDuplicateInternalTx or ErrInternalTxPoolFull.
+// Observers create no events and get ErrObserver.
+func (n *Node) SubmitInternalTx(tx poset.InternalTransaction) error {
+	if n.conf.Observer {
+		return ErrObserver
+	}
+	if err := n.checkInternalTx(tx); err != nil {
+		n.rejectedInternalTxs.increment()
+		return err
+	}
+	if err := n.core.AddInternalTransactions([]poset.InternalTransaction{tx}); err != nil {
+		return err
+	}
+	n.resetTimer()
+	return nil
+}
+
+func invalidInternalTx(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid internal transaction: "+format, args...)
+}
+
+// checkInternalTx tells whether tx applies to the current participants
+func (n *Node) checkInternalTx(tx poset.InternalTransaction) error {
+	if tx.Peer == nil {
+		return invalidInternalTx("no peer")
+	}
+	if len(tx.Peer.PubKeyHex) < 2 {
+		return invalidInternalTx("no public key")
+	}
+	if _, err := tx.Peer.PubKeyBytes(); err != nil {
+		return invalidInternalTx("public key: %v", err)
+	}
+	_, participant := n.core.participants.ReadByPubKey(tx.Peer.PubKeyHex)
+
+	switch tx.Type {
+	case poset.TransactionType_PEER_ADD:
+		if participant {
+			return invalidInternalTx("%s is a participant already", tx.Peer.PubKeyHex)
+		}
+		if tx.Peer.NetAddr == "" {
+			return invalidInternalTx("no address for %s", tx.Peer.PubKeyHex)
+		}
+	case poset.TransactionType_PEER_REMOVE:
+		if !participant {
+			return invalidInternalTx("%s is not a participant", tx.Peer.PubKeyHex)
+		}
+		if n.core.participants.Len() == 1 {
+			return invalidInternalTx("%s is the last participant", tx.Peer.PubKeyHex)
+		}
+	case poset.TransactionType_POS_TRANSFER:
+		if tx.Amount == 0 {
+			return invalidInternalTx("no amount")
+		}
+	default:
+		return invalidInternalTx("unknown type %d", tx.Type)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestInternalTxPool(t *testing.T) {
+	p := newInternalTxPool(2)
+	tx := func(amount uint64) poset.InternalTransaction {
+		return poset.InternalTransaction{Type: poset.TransactionType_POS_TRANSFER, Amount: amount}
+	}
+
+	if err := p.add(tx(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.add(tx(1)); err != ErrDuplicateInternalTx {
+		t.Fatalf("expected ErrDuplicateInternalTx, got %v", err)
+	}
+	if err := p.add(tx(2), tx(3)); err != ErrInternalTxPoolFull {
+		t.Fatalf("expected ErrInternalTxPoolFull, got %v", err)
+	}
+	if err := p.add(tx(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	txs := p.take()
+	if len(txs) != 2 || p.count() != 0 {
+		t.Fatalf("expected 2 transactions taken, got %d, %d left", len(txs), p.count())
+	}
+	if err := p.add(tx(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// an event which could not be created gives its transactions back
+	p.putBack(txs)
+	if p.count() != 3 {
+		t.Fatalf("expected 3 transactions, got %d", p.count())
+	}
+	if err := p.add(tx(1)); err != ErrDuplicateInternalTx {
+		t.Fatalf("expected ErrDuplicateInternalTx, got %v", err)
+	}
+	if txs := p.take(); txs[0].Amount != 1 || txs[2].Amount != 3 {
+		t.Fatalf("unexpected order %v", txs)
+	}
+}
+
+func TestCheckInternalTx(t *testing.T) {
+	newPeer := func() *peers.Peer {
+		key, _ := crypto.GenerateECDSAKey()
+		return peers.NewPeer(fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey)), "addr")
+	}
+	participant, other := newPeer(), newPeer()
+	participants := peers.NewPeers()
+	participants.AddPeer(participant)
+	n := &Node{conf: &Config{}, core: &Core{participants: participants}}
+
+	for _, c := range []struct {
+		tx    poset.InternalTransaction
+		valid bool
+	}{
+		{poset.NewInternalTransaction(poset.TransactionType_PEER_ADD, *other), true},
+		{poset.NewInternalTransaction(poset.TransactionType_PEER_ADD, *participant), false},
+		{poset.NewInternalTransaction(poset.TransactionType_PEER_ADD, peers.Peer{PubKeyHex: other.PubKeyHex}), false},
+		{poset.NewInternalTransaction(poset.TransactionType_PEER_REMOVE, *other), false},
+		// the last participant stays
+		{poset.NewInternalTransaction(poset.TransactionType_PEER_REMOVE, *participant), false},
+		{poset.InternalTransaction{Type: poset.TransactionType_POS_TRANSFER, Peer: other, Amount: 5}, true},
+		{poset.InternalTransaction{Type: poset.TransactionType_POS_TRANSFER, Peer: other}, false},
+		{poset.InternalTransaction{Type: poset.TransactionType_PEER_ADD}, false},
+		{poset.InternalTransaction{Type: 7, Peer: other}, false},
+	} {
+		if err := n.checkInternalTx(c.tx); (err == nil) != c.valid {
+			t.Fatalf("expected %v valid %v, got %v", c.tx, c.valid, err)
+		}
+	}
+
+	participants.AddPeer(other)
+	if err := n.checkInternalTx(poset.NewInternalTransaction(poset.TransactionType_PEER_REMOVE, *other)); err != nil {
+		t.Fatal(err)
+	}
+}
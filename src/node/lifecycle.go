@@ -0,0 +1,75 @@
+package node
+
+import (
+	"fmt"
+)
+
+// Stop stops the node from gossiping and answering RPCs, until Start. It
+// waits for the syncs in flight and the background loops which use the
+// transport, then, with Config.TransportOpener, flushes the store and closes
+// the transport, so that the peers see the node down rather than unanswered.
+// The store, the pools and the other background work are kept.
+func (n *Node) Stop() error {
+	n.lifecycleLock.Lock()
+	defer n.lifecycleLock.Unlock()
+	if !n.setState(Stop, Gossiping, CatchingUp) {
+		return fmt.Errorf("node is %s", n.getState())
+	}
+	n.waitRoutines()
+	n.transLock.Lock()
+	close(n.transStopCh)
+	n.transLock.Unlock()
+	n.transWorkers.Wait()
+	if n.conf.TransportOpener == nil {
+		return nil
+	}
+
+	if flusher, ok := n.core.poset.Store.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			n.logger.WithError(err).Error("Flushing store writes")
+		}
+	}
+	n.transClosed = true
+	return n.transport().Close()
+}
+
+// Start resumes a stopped node from its store, with a new transport from
+// Config.TransportOpener if Stop closed it. It fails if the node is not
+// stopped.
+func (n *Node) Start() error {
+	n.lifecycleLock.Lock()
+	defer n.lifecycleLock.Unlock()
+	if state := n.getState(); state != Stop {
+		return fmt.Errorf("node is %s, not stopped", state)
+	}
+
+	// nothing uses the transport while the node is stopped
+	if n.transClosed {
+		trans, err := n.conf.TransportOpener()
+		if err != nil {
+			return fmt.Errorf("opening transport: %v", err)
+		}
+		if n.faults != nil {
+			trans = &faultyTransport{SyncPeer: trans, faults: n.faults, clock: n.clock}
+		}
+		n.transLock.Lock()
+		n.trans = trans
+		n.transLock.Unlock()
+		n.transClosed = false
+	}
+
+	if !n.setState(Gossiping, Stop) {
+		// shut down meanwhile
+		n.transClosed = true
+		n.transport().Close()
+		return fmt.Errorf("node is %s, not stopped", n.getState())
+	}
+	n.transLock.Lock()
+	n.transStopCh = make(chan struct{})
+	for _, fn := range n.transLoops {
+		n.startTransWorker(fn)
+	}
+	n.transLock.Unlock()
+	n.resetTimer()
+	return nil
+}
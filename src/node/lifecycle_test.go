@@ -0,0 +1,64 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+)
+
+func TestStopStart(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+
+	newTransport := func() peer.SyncPeer {
+		return createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+			data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	}
+	// the loops which use the transport run across Stop and Start
+	data.Config.TxGossip = true
+	data.Config.AntiEntropyInterval = 10 * time.Millisecond
+	opened := 0
+	data.Config.TransportOpener = func() (peer.SyncPeer, error) {
+		opened++
+		return newTransport(), nil
+	}
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID,
+		data.Keys[0], data.Peers, newTransport(), data.Adds[0], false)
+	defer node.Shutdown()
+
+	if err := node.Start(); err == nil {
+		t.Fatal("expected Start to fail on a gossiping node")
+	}
+
+	if err := node.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if state := node.getState(); state != Stop {
+		t.Fatal(state)
+	}
+	if err := node.Stop(); err == nil {
+		t.Fatal("expected Stop to fail on a stopped node")
+	}
+
+	// Stop closed the transport, so that the address is free for the new one
+	if err := node.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if state := node.getState(); state != Gossiping {
+		t.Fatal(state)
+	}
+	if opened != 1 {
+		t.Fatalf("expected 1 transport opened, got %d", opened)
+	}
+
+	if err := node.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if opened != 2 {
+		t.Fatalf("expected 2 transports opened, got %d", opened)
+	}
+}
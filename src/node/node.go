@@ -1,9 +1,11 @@
 package node
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strconv"
@@ -25,6 +27,8 @@ type Node struct {
 
 	conf   *Config
 	logger *logrus.Entry
+	// confLock guards the fields of conf which Reload changes
+	confLock sync.RWMutex
 
 	id       uint64
 	core     *Core
@@ -36,22 +40,128 @@ type Node struct {
 
 	trans peer.SyncPeer
 	proxy proxy.AppProxy
+	// lifecycleLock serializes Stop and Start, transClosed tells that Stop
+	// closed the transport
+	lifecycleLock sync.Mutex
+	transClosed   bool
+	// transLock guards trans, which Start replaces, see transport
+	transLock sync.RWMutex
+	// transWorkers are the background loops which use the transport. They
+	// end with transStopCh, which Stop closes before the transport, see
+	// goTransWorker.
+	transWorkers sync.WaitGroup
+	transStopCh  chan struct{}
+	transLoops   []func(stopCh <-chan struct{})
 
 	submitCh         chan []byte
 	submitInternalCh chan poset.InternalTransaction
-	commitCh         chan poset.Block
-	shutdownCh       chan struct{}
-	signalTERMch     chan os.Signal
+	// keyedSubmitCh is the KeyedSubmitCh of the app, nil if it is not a
+	// proxy.KeyedSubmitter
+	keyedSubmitCh chan proxy.KeyedTx
+	commitCh      chan poset.Block
+	consensusCh   chan struct{}
+	shutdownCh    chan struct{}
+	signalTERMch  chan os.Signal
+
+	// workers are the background loops, which end with shutdownCh
+	workers     sync.WaitGroup
+	workersLock sync.Mutex
+	// shutdownOnce runs the shutdown, which closes doneCh once it is over
+	shutdownOnce sync.Once
+	doneCh       chan struct{}
 
 	controlTimer *ControlTimer
+	clock        Clock
+	// rand draws the peers of the anti-entropy
+	rand Rand
+	// faults are injected in the RPCs and the store, nil unless
+	// Config.FaultInjection
+	faults *faults
 
 	start        time.Time
 	syncRequests int
 	syncErrors   int
 
+	// misbehavior counts protocol violations per peer ID
+	misbehavior     map[uint64]int64
+	misbehaviorLock sync.RWMutex
+
+	// pendingBlocks are decided but wait for the BlockQuorum before they are
+	// committed to the app, see Config.CommitOnQuorum
+	pendingBlocks     []int64
+	pendingBlocksLock sync.Mutex
+
+	// appBuffer holds the blocks committed while the app is disconnected
+	appBuffer *appBuffer
+	// appGuard puts deadlines on the calls to the app
+	appGuard *appGuard
+
+	// nodeEvents is where the app subscribes to the node events, nil if it
+	// does not
+	nodeEvents chan<- proxy.NodeEvent
+	// feed publishes the committed blocks and inserted events
+	feed *feed
+	// stateFeed publishes the state changes, see SubscribeState
+	stateFeed *feed
+	// blockCallbacks are called with the committed blocks, see
+	// OnBlockCommitted
+	blockCallbacks blockCallbacks
+	// panics are those the goroutines recovered from, see
+	// Config.PanicPolicy
+	panics panics
+	// health tracks the syncs with the peers
+	health *peerHealth
+	// peerKnown holds the known events of the peers, see Config.GossipFanout
+	peerKnown *peerKnown
+	// traffic counts the events and syncs exchanged with each peer
+	traffic *peerTraffic
+	// diffs shares the EventDiffs of the concurrent SyncRequests
+	diffs *diffCoalescer
+	// statsHistory keeps the samples of the key stats
+	statsHistory *statsHistory
+
+	// gossipPaused is 1 while the node initiates no gossip, see PauseGossip
+	gossipPaused int32
+	// slowApp is 1 while the intake of events is paused for the app, see
+	// checkCommitQueue
+	slowApp int32
+	// fastForwardCh requests a fast forward, see FastForward
+	fastForwardCh chan struct{}
+	// txGossip holds the submitted transactions until they are pushed to
+	// the peers, see Config.TxGossip
+	txGossip txGossip
+	// syncQueue holds the fetched SyncResponses until they are inserted,
+	// with Config.SyncPipeline, see fetch
+	syncQueue chan syncBatch
+	syncAhead *syncAhead
+	// syncChunks adapts the events asked of each peer, see Config.SyncChunks
+	syncChunks *syncChunks
+	// txKeys drops the submissions of the idempotency keys it holds, see
+	// SubmitKeyedTx
+	txKeys *txKeys
+
 	needBoostrap bool
 	gossipJobs   count64
 	rpcJobs      count64
+	rejectedTxs  count64
+	appSkipped   count64
+	gossipedTxs  count64
+	receivedTxs  count64
+	// rejectedInternalTxs counts the internal transactions which did not
+	// apply to the participants, see SubmitInternalTx
+	rejectedInternalTxs count64
+	// chunkedSyncs counts the SyncResponses which were a chunk of the diff
+	chunkedSyncs count64
+	// coalescedSyncs counts the SyncRequests served from the diff of another
+	coalescedSyncs count64
+	// antiEntropySyncs counts the reconciliations, antiEntropyEvents the
+	// events they pulled, see Config.AntiEntropyInterval
+	antiEntropySyncs  count64
+	antiEntropyEvents count64
+	// appSlowPauses counts the pauses of the intake of events for the app
+	appSlowPauses count64
+	// keyedDuplicates counts the submissions dropped for their idempotency key
+	keyedDuplicates count64
 }
 
 // NewNode create a new node struct
@@ -66,14 +176,47 @@ func NewNode(conf *Config,
 	selectorInitArgs SelectorCreationFnArgs,
 	localAddr string) *Node {
 
-	commitCh := make(chan poset.Block, 400)
+	clock, rnd := conf.Clock, conf.Rand
+	if clock == nil {
+		clock = wallClock{}
+	}
+	if rnd == nil {
+		rnd = globalRand{}
+	}
+	var faults *faults
+	if conf.FaultInjection {
+		faults = newFaults(rnd)
+		store = &faultyStore{Store: store, faults: faults}
+		trans = &faultyTransport{SyncPeer: trans, faults: faults, clock: clock}
+	}
+
+	commitCh := make(chan poset.Block, commitChSize)
 	core := NewCore(id, key, participants, store, commitCh, conf.Logger)
+	core.SetObserver(conf.Observer)
+	core.SetGenesisHash(conf.GenesisHash)
+	core.SetBlockQuorum(conf.BlockQuorum, conf.ValidatorWeights)
+	core.SetEventBudget(conf.MaxEventTxs, conf.MaxEventPayload)
+	core.SetTxPoolLimits(conf.MaxPoolTxs, conf.MaxPoolBytes, conf.PoolPolicy)
+	core.SetTxDedupWindow(conf.TxDedupWindow)
+	core.SetDedupBlockTransactions(conf.DedupBlockTxs)
+	core.SetMaxInternalTxs(conf.MaxInternalTxs)
+	core.SetClock(clock)
+	core.SetVerifyWorkers(conf.VerifyWorkers)
+	core.SetNoEmptyEvents(conf.NoEmptyEvents, conf.MaxSilence)
 
 	pubKey := core.HexID()
 
-	if args, ok := selectorInitArgs.(SmartPeerSelectorCreationFnArgs); ok {
+	switch args := selectorInitArgs.(type) {
+	case SmartPeerSelectorCreationFnArgs:
 		args.GetFlagTable = core.poset.GetPeerFlagTableOfRandomUndeterminedEvent
 		args.LocalAddr = localAddr
+		args.Rand = rnd
+		selectorInitArgs = args
+	case RandomPeerSelectorCreationFnArgs:
+		args.Rand = rnd
+		selectorInitArgs = args
+	case FairPeerSelectorCreationFnArgs:
+		args.Rand = rnd
 		selectorInitArgs = args
 	}
 
@@ -90,21 +233,48 @@ func NewNode(conf *Config,
 		submitCh:         proxy.SubmitCh(),
 		submitInternalCh: proxy.SubmitInternalCh(),
 		commitCh:         commitCh,
+		consensusCh:      make(chan struct{}, 1),
 		shutdownCh:       make(chan struct{}),
-		controlTimer:     NewRandomControlTimer(),
-		start:            time.Now(),
+		transStopCh:      make(chan struct{}),
+		doneCh:           make(chan struct{}),
+		controlTimer:     newClockControlTimer(clock, rnd),
+		clock:            clock,
+		rand:             rnd,
+		faults:           faults,
+		start:            clock.Now(),
 		gossipJobs:       0,
 		rpcJobs:          0,
+		misbehavior:      make(map[uint64]int64),
+		appBuffer:        newAppBuffer(conf.AppBuffer),
+		appGuard:         newAppGuard(conf.AppTimeout, conf.AppBreakerThreshold),
+		feed:             newFeed(),
+		stateFeed:        newFeed(),
+		health:           newPeerHealth(),
+		peerKnown:        newPeerKnown(),
+		traffic:          newPeerTraffic(),
+		diffs:            &diffCoalescer{},
+		statsHistory:     newStatsHistory(statsHistorySize),
+		fastForwardCh:    make(chan struct{}, 1),
 		nodeState2:       newNodeState2(),
+		syncChunks:       newSyncChunks(),
+		txKeys:           newTxKeys(conf.TxKeyWindow),
 		signalTERMch:     make(chan os.Signal, 1),
 	}
+	if conf.SyncPipeline > 0 {
+		node.syncQueue = make(chan syncBatch, conf.SyncPipeline)
+		node.syncAhead = newSyncAhead()
+	}
 
 	signal.Notify(node.signalTERMch, syscall.SIGTERM, os.Kill)
 
 	node.logger.WithField("participants", participants).Debug("participants")
 	node.logger.WithField("pubKey", pubKey).Debug("pubKey")
+	node.logger.WithField("observer", conf.Observer).Debug("observer")
 
 	node.needBoostrap = store.NeedBootstrap()
+	node.subscribeNodeEvents(participants)
+	node.subscribeKeyedTxs()
+	core.SetEventHook(node.publishEvent)
 
 	// Initialize
 	node.setState(Gossiping)
@@ -114,6 +284,10 @@ func NewNode(conf *Config,
 
 // Init initializes all the node processes
 func (n *Node) Init() error {
+	if n.core.key == nil && !n.conf.Observer {
+		return ErrNoKey
+	}
+
 	var peerAddresses []string
 	for _, p := range n.peerSelector.Peers().ToPeerSlice() {
 		peerAddresses = append(peerAddresses, p.NetAddr)
@@ -128,6 +302,19 @@ func (n *Node) Init() error {
 	}
 	n.Register()
 
+	if n.conf.WALPath != "" {
+		events, txs, err := n.core.ReplayWAL(n.conf.WALPath)
+		if err != nil {
+			return err
+		}
+		if events > 0 {
+			n.logger.WithField("events", events).Warn("Replayed the events journaled before an unclean shutdown")
+		}
+		if txs > 0 {
+			n.logger.WithField("transactions", txs).Info("Replayed the journaled transactions of the pool")
+		}
+	}
+
 	return n.core.SetHeadAndHeight()
 }
 
@@ -142,14 +329,49 @@ func (n *Node) Run(gossip bool) {
 	// The ControlTimer allows the background routines to control the
 	// heartbeat timer when the node is in the Gossiping state. The timer should
 	// only be running when there are uncommitted transactions in the system.
-	go n.controlTimer.Run(n.conf.HeartbeatTimeout)
+	go n.controlTimer.Run(n.heartbeat())
 
 	// Execute some background work regardless of the state of the node.
 	// Process SubmitTx and CommitBlock requests
-	go n.doBackgroundWork()
+	n.goWorker(n.doBackgroundWork)
+
+	// Decide rounds and produce blocks apart from the event insertion
+	n.goWorker(n.doConsensusWork)
+
+	n.goWorker(n.doStatsHistory)
+
+	if n.conf.TxTTL > 0 {
+		n.goWorker(n.doTxExpiry)
+	}
+
+	if n.conf.TxGossip {
+		n.goTransWorker(n.doTxGossip)
+	}
+
+	if n.syncQueue != nil {
+		n.goWorker(n.doSyncInsert)
+	}
+
+	if n.core.wal != nil {
+		n.goWorker(n.doWALCheckpoint)
+	}
+
+	if gossip && n.conf.AntiEntropyInterval > 0 {
+		n.goTransWorker(n.doAntiEntropy)
+	}
+
+	// the shutdown waits for the state machine, so that a fast forward
+	// does not outlive the store
+	if !n.addWorker() {
+		return
+	}
+	defer n.workers.Done()
 
 	// pause before gossiping test transactions to allow all nodes come up
-	time.Sleep(time.Duration(n.conf.TestDelay) * time.Second)
+	select {
+	case <-n.clock.After(time.Duration(n.conf.TestDelay) * time.Second):
+	case <-n.shutdownCh:
+	}
 
 	// Execute Node State Machine
 	for {
@@ -157,24 +379,29 @@ func (n *Node) Run(gossip bool) {
 		state := n.getState()
 		n.logger.WithField("state", state.String()).Debug("Run(gossip bool)")
 
-		switch state {
-		case Gossiping:
-			n.lachesis(gossip)
-		case CatchingUp:
-			if err := n.fastForward(); err != nil {
-				n.logger.WithField("state", "fastForward").WithError(err).Debug("Run(gossip bool)")
-			}
-		case Stop:
-			// do nothing in Stop state
-		case Shutdown:
+		if state == Shutdown {
 			return
 		}
+		// a panic fails the node, or the state runs again
+		n.runGuarded("Run", func() {
+			switch state {
+			case Gossiping:
+				n.lachesis(gossip)
+			case CatchingUp:
+				if err := n.fastForward(); err != nil {
+					n.logger.WithField("state", "fastForward").WithError(err).Debug("Run(gossip bool)")
+				}
+			case Stop:
+				// until the node is started again or shut down
+				n.fsm.await(Stop)
+			}
+		})
 	}
 }
 
 func (n *Node) resetTimer() {
 	if !n.controlTimer.GetSet() {
-		ts := n.conf.HeartbeatTimeout
+		ts := n.heartbeat()
 		// Slow gossip if nothing interesting to say
 		if n.core.poset.GetPendingLoadedEvents() == 0 &&
 			n.core.GetTransactionPoolCount() == 0 &&
@@ -187,18 +414,32 @@ func (n *Node) resetTimer() {
 
 func (n *Node) doBackgroundWork() {
 	for {
+		// a full pool leaves the submissions of the app waiting
+		submitCh, keyedSubmitCh := n.submitCh, n.keyedSubmitCh
+		if n.core.TxPoolFull() {
+			submitCh, keyedSubmitCh = nil, nil
+		}
 		select {
-		case t := <-n.submitCh:
+		case t := <-submitCh:
 			n.logger.Debug("Adding Transactions to Transaction Pool")
 			err := n.addTransaction(t)
 			if err != nil {
 				n.logger.Errorf("Adding Transactions to Transaction Pool: %s", err)
 			}
 			n.resetTimer()
+		case t := <-keyedSubmitCh:
+			n.logger.WithField("key", t.Key).Debug("Adding Keyed Transaction to Transaction Pool")
+			if err := n.addKeyedTransaction(t); err != nil {
+				n.logger.Errorf("Adding Keyed Transaction to Transaction Pool: %s", err)
+			}
+			n.resetTimer()
 		case t := <-n.submitInternalCh:
 			n.logger.Debug("Adding Internal Transaction")
-			n.addInternalTransaction(t)
-			n.resetTimer()
+			if err := n.SubmitInternalTx(t); err != nil {
+				n.logger.WithError(err).Error("Adding Internal Transaction")
+			}
+		case <-n.core.TxPoolSpace():
+			// the pool has room again for the submissions
 		case block := <-n.commitCh:
 			n.logger.WithFields(logrus.Fields{
 				"index":          block.Index(),
@@ -208,14 +449,50 @@ func (n *Node) doBackgroundWork() {
 			if err := n.commit(block); err != nil {
 				n.logger.WithField("error", err).Error("Adding EventBlock")
 			}
+			n.checkCommitQueue()
 		case <-n.shutdownCh:
 			return
 		case <-n.signalTERMch:
-			n.Shutdown()
+			// the shutdown waits for this loop
+			go n.Shutdown()
+		}
+	}
+}
+
+// doConsensusWork runs the consensus pipeline each time new events were
+// synced. Requests arriving while a run is in progress are coalesced into the
+// next run, so block production doesn't lag behind the insert throughput.
+func (n *Node) doConsensusWork() {
+	for {
+		select {
+		case <-n.consensusCh:
+			if n.checkCommitQueue() {
+				// scheduled again once the app caught up
+				continue
+			}
+			start := time.Now()
+			err := n.core.RunConsensus()
+			elapsed := time.Since(start)
+			n.logger.WithField("Duration", elapsed.Nanoseconds()).Debug("n.core.RunConsensus()")
+			if err != nil {
+				n.logger.WithError(err).Error("n.core.RunConsensus()")
+			}
+			n.publishBlocks()
+		case <-n.shutdownCh:
+			return
 		}
 	}
 }
 
+// scheduleConsensus requests a consensus run without waiting for it
+func (n *Node) scheduleConsensus() {
+	select {
+	case n.consensusCh <- struct{}{}:
+	default:
+		// a run is already pending and will see the new events
+	}
+}
+
 // lachesis is interrupted when a gossip function, launched asynchronously, changes
 // the state from Gossiping to CatchingUp, or when the node is shutdown.
 // Otherwise, it processes RPC requests, periodicaly initiates gossip while there
@@ -223,33 +500,47 @@ func (n *Node) doBackgroundWork() {
 func (n *Node) lachesis(gossip bool) {
 	returnCh := make(chan struct{}, 100)
 	for {
+		changed := n.fsm.changes()
+		if n.getState() != Gossiping {
+			return
+		}
 		select {
-		case rpc, ok := <-n.trans.ReceiverChannel():
+		case rpc, ok := <-n.transport().ReceiverChannel():
 			if !ok {
 				return
 			}
 			n.goFunc(func() {
 				n.rpcJobs.increment()
-				n.logger.Debug("Processing RPC")
-				n.processRPC(rpc)
+				defer n.rpcJobs.decrement()
+				n.runGuarded("processRPC", func() {
+					n.logger.Debug("Processing RPC")
+					n.processRPC(rpc)
+				})
 				n.resetTimer()
-				n.rpcJobs.decrement()
 			})
 		case <-n.controlTimer.tickCh:
 			n.logStats()
-			if gossip && n.gossipJobs.get() < 1 {
+			if gossip && !n.GossipPaused() && !n.Suspended() && !n.checkCommitQueue() && n.gossipJobs.get() < 1 {
 				n.goFunc(func() {
 					n.gossipJobs.increment()
-					if err := n.gossip(returnCh); err != nil {
-						n.logger.WithError(err).Debug("node::lachesis(bool)::n.controlTimer.tickCh")
-					}
-					n.gossipJobs.decrement()
+					defer n.gossipJobs.decrement()
+					n.runGuarded("gossip", func() {
+						if err := n.gossip(returnCh); err != nil {
+							n.logger.WithError(err).Debug("node::lachesis(bool)::n.controlTimer.tickCh")
+						}
+					})
 				})
 				n.logger.Debug("Gossip")
 			}
 			n.resetTimer()
 		case <-returnCh:
 			return
+		case <-changed:
+			// stopped, or shut down
+		case <-n.fastForwardCh:
+			n.logger.Info("Fast forward requested")
+			n.setState(CatchingUp)
+			return
 		case <-n.shutdownCh:
 			return
 		}
@@ -266,6 +557,12 @@ func (n *Node) processRPC(rpc *peer.RPC) {
 		n.processEagerSyncRequest(rpc, cmd)
 	case *peer.FastForwardRequest:
 		n.processFastForwardRequest(rpc, cmd)
+	case *peer.AncestorsRequest:
+		n.processAncestorsRequest(rpc, cmd)
+	case *peer.StateChunkRequest:
+		n.processStateChunkRequest(rpc, cmd)
+	case *peer.TxGossipRequest:
+		n.processTxGossipRequest(rpc, cmd)
 	default:
 		logger.Warn("unexpected RPC command")
 		// TODO: context.Background
@@ -284,20 +581,22 @@ func (n *Node) processSyncRequest(rpc *peer.RPC, cmd *peer.SyncRequest) {
 		FromID: n.id,
 	}
 	var respErr error
+	n.recordKnown(cmd.FromID, cmd.Known)
 
 	// Check sync limit
-	n.coreLock.Lock()
-	overSyncLimit := n.core.OverSyncLimit(cmd.Known, n.conf.SyncLimit)
-	n.coreLock.Unlock()
-	if overSyncLimit {
+	var overSyncLimit bool
+	n.withCoreLock(func() {
+		overSyncLimit = n.core.OverSyncLimit(cmd.Known, n.catchUpLimit(cmd.Limit))
+	})
+	if err := n.checkGenesis(cmd.FromID, cmd.Genesis); err != nil {
+		respErr = err
+	} else if overSyncLimit {
 		n.logger.Debug("n.core.OverSyncLimit(cmd.Known, n.conf.SyncLimit)")
 		resp.SyncLimit = true
 	} else {
-		// Compute Diff
+		// Compute Diff, with the concurrent requests
 		start := time.Now()
-		n.coreLock.Lock()
-		eventDiff, err := n.core.EventDiff(cmd.Known)
-		n.coreLock.Unlock()
+		eventDiff, err := n.eventDiff(cmd.Known)
 		elapsed := time.Since(start)
 		n.logger.WithField("Duration", elapsed.Nanoseconds()).Debug("n.core.EventBlockDiff(cmd.Known)")
 		if err != nil {
@@ -305,6 +604,13 @@ func (n *Node) processSyncRequest(rpc *peer.RPC, cmd *peer.SyncRequest) {
 			respErr = err
 		}
 
+		// a requester which takes chunks gets the first events of the
+		// diff, their parents come before them in topological order
+		if limit := n.chunkLimit(cmd.Limit); limit > 0 && int64(len(eventDiff)) > limit {
+			eventDiff = eventDiff[:limit]
+			resp.More = true
+		}
+
 		// Convert to WireEvents
 		wireEvents, err := n.core.ToWire(eventDiff)
 		if err != nil {
@@ -316,18 +622,23 @@ func (n *Node) processSyncRequest(rpc *peer.RPC, cmd *peer.SyncRequest) {
 	}
 
 	// Get Self Known
-	n.coreLock.Lock()
-	knownEvents := n.core.KnownEvents()
-	n.coreLock.Unlock()
+	knownEvents := n.knownEvents()
 	resp.Known = knownEvents
 
 	n.logger.WithFields(logrus.Fields{
 		"events":     len(resp.Events),
 		"known":      resp.Known,
 		"sync_limit": resp.SyncLimit,
+		"more":       resp.More,
 		"error":      respErr,
 	}).Debug("SyncRequest Received")
 
+	n.traffic.sync(cmd.FromID, false)
+	if respErr == nil {
+		n.health.success(cmd.FromID)
+		n.traffic.sent(cmd.FromID, resp.Events)
+	}
+
 	// TODO: context.Background
 	rpc.SendResult(context.Background(), n.logger, resp, respErr)
 }
@@ -339,18 +650,27 @@ func (n *Node) processEagerSyncRequest(rpc *peer.RPC, cmd *peer.ForceSyncRequest
 		n.logger.WithField("error", err).Error("n.sync(cmd.Events)")
 		success = false
 	}
-	p, ok := participants.ReadByID(cmd.FromID)
-	if !ok {
-		n.logger.WithField("error", err).Error("n.sync(cmd.Events)")
+	if err := n.checkGenesis(cmd.FromID, cmd.Genesis); err != nil {
 		success = false
 	}
+	if success && n.checkCommitQueue() {
+		n.logger.WithField("from_id", cmd.FromID).Debug("App slow, events refused")
+		success = false
+	}
+	var p peers.Peer
+	if success {
+		var ok bool
+		if p, ok = participants.ReadByID(cmd.FromID); !ok {
+			n.logger.WithField("from_id", cmd.FromID).Error("unknown sender")
+			success = false
+		}
+	}
 	n.logger.WithFields(logrus.Fields{
 		"from":    p.NetAddr,
 		"from_id": cmd.FromID,
 		"events":  len(cmd.Events),
 	}).Debug("processEagerSyncRequest(rpc net.RPC, cmd *net.ForceSyncRequest)")
 
-
 	resp := &peer.ForceSyncResponse{
 		FromID:  n.id,
 		Success: success,
@@ -358,9 +678,13 @@ func (n *Node) processEagerSyncRequest(rpc *peer.RPC, cmd *peer.ForceSyncRequest
 	// TODO: context.Background
 	rpc.SendResult(context.Background(), n.logger, resp, nil)
 
-	n.coreLock.Lock()
+	// events from an unknown sender cannot be checked against its history
+	if !success {
+		return
+	}
+	n.traffic.received(cmd.FromID, cmd.Events)
+
 	err = n.sync(&p, cmd.Events)
-	n.coreLock.Unlock()
 
 	if err != nil {
 		n.logger.WithField("error", err).Error("n.sync(cmd.Events)")
@@ -379,9 +703,17 @@ func (n *Node) processFastForwardRequest(rpc *peer.RPC, cmd *peer.FastForwardReq
 	var respErr error
 
 	// Get latest Frame
-	n.coreLock.Lock()
-	block, frame, err := n.core.GetAnchorBlockWithFrame()
-	n.coreLock.Unlock()
+	var (
+		block poset.Block
+		frame poset.Frame
+		err   error
+	)
+	n.withCoreLock(func() {
+		block, frame, err = n.core.GetAnchorBlockWithFrame()
+	})
+	if err == nil {
+		err = n.checkGenesis(cmd.FromID, cmd.Genesis)
+	}
 	if err != nil {
 		n.logger.WithField("error", err).Error("n.core.GetAnchorBlockWithFrame()")
 		respErr = err
@@ -389,13 +721,28 @@ func (n *Node) processFastForwardRequest(rpc *peer.RPC, cmd *peer.FastForwardReq
 		resp.Block = block
 		resp.Frame = frame
 
+		// chunked requesters fetch the snapshot with StateChunkRequests
+		chunked := false
+		if cmd.Chunked {
+			manifest, ok, err := n.stateSnapshotManifest(block)
+			if err != nil {
+				n.logger.WithField("error", err).Error("n.stateSnapshotManifest(block)")
+				respErr = err
+			} else if ok {
+				resp.Manifest = &manifest
+				chunked = true
+			}
+		}
+
 		// Get snapshot
-		snapshot, err := n.proxy.GetSnapshot(block.Index())
-		if err != nil {
-			n.logger.WithField("error", err).Error("n.proxy.GetSnapshot(block.Index())")
-			respErr = err
+		if !chunked && respErr == nil {
+			snapshot, err := n.stateSnapshot(block)
+			if err != nil {
+				n.logger.WithField("error", err).Error("n.stateSnapshot(block)")
+				respErr = err
+			}
+			resp.Snapshot = snapshot
 		}
-		resp.Snapshot = snapshot
 	}
 
 	n.logger.WithFields(logrus.Fields{
@@ -406,10 +753,76 @@ func (n *Node) processFastForwardRequest(rpc *peer.RPC, cmd *peer.FastForwardReq
 	rpc.SendResult(context.Background(), n.logger, resp, respErr)
 }
 
+// checkGenesis rejects requests from nodes of another network
+func (n *Node) checkGenesis(from uint64, genesis []byte) error {
+	if bytes.Equal(genesis, n.conf.GenesisHash) {
+		return nil
+	}
+	n.logger.WithFields(logrus.Fields{
+		"from_id": from,
+		"genesis": fmt.Sprintf("0x%X", genesis),
+	}).Warn("request from another network")
+	return ErrGenesisMismatch
+}
+
+func (n *Node) processStateChunkRequest(rpc *peer.RPC, cmd *peer.StateChunkRequest) {
+	n.logger.WithFields(logrus.Fields{
+		"from":       cmd.FromID,
+		"state_hash": fmt.Sprintf("%X", cmd.StateHash),
+		"index":      cmd.Index,
+	}).Debug("processStateChunkRequest(rpc net.RPC, cmd *net.StateChunkRequest)")
+
+	resp := &peer.StateChunkResponse{
+		FromID: n.id,
+	}
+	respErr := n.checkGenesis(cmd.FromID, cmd.Genesis)
+	if respErr == nil {
+		resp.Chunk, respErr = n.stateSnapshotChunk(cmd.StateHash, cmd.Index)
+	}
+	// TODO: context.Background
+	rpc.SendResult(context.Background(), n.logger, resp, respErr)
+}
+
+func (n *Node) processAncestorsRequest(rpc *peer.RPC, cmd *peer.AncestorsRequest) {
+	n.logger.WithFields(logrus.Fields{
+		"from":       cmd.FromID,
+		"creator_id": cmd.CreatorID,
+		"index":      cmd.Index,
+	}).Debug("processAncestorsRequest(rpc net.RPC, cmd *net.AncestorsRequest)")
+
+	resp := &peer.AncestorsResponse{
+		FromID: n.id,
+	}
+
+	var head poset.EventHash
+	head.Set(cmd.Head)
+	var (
+		events []poset.WireEvent
+		err    error
+	)
+	n.withCoreLock(func() {
+		events, err = n.core.SelfAncestors(cmd.CreatorID, head, cmd.Index, n.syncLimit())
+	})
+	if err != nil {
+		n.logger.WithField("error", err).Error("n.core.SelfAncestors()")
+	}
+	resp.Events = events
+	n.traffic.sent(cmd.FromID, events)
+
+	// TODO: context.Background
+	rpc.SendResult(context.Background(), n.logger, resp, err)
+}
+
 // This function is usually called in a go-routine and needs to inform the
 // calling routine (usually the lachesis routine) when it is time to exit the
 // Gossiping state and return.
 func (n *Node) gossip(parentReturnCh chan struct{}) error {
+	if n.conf.SyncPeers > 1 {
+		return n.gossipMany(parentReturnCh)
+	}
+	if n.syncQueue != nil {
+		return n.fetch(parentReturnCh)
+	}
 
 	peer := n.peerSelector.Next()
 	if peer == nil {
@@ -419,8 +832,10 @@ func (n *Node) gossip(parentReturnCh chan struct{}) error {
 	// pull
 	syncLimit, otherKnownEvents, err := n.pull(peer)
 	if err != nil {
+		n.health.failure(peer.ID, err)
 		return err
 	}
+	n.health.success(peer.ID)
 
 	// check and handle syncLimit
 	if syncLimit {
@@ -430,10 +845,15 @@ func (n *Node) gossip(parentReturnCh chan struct{}) error {
 		return nil
 	}
 
-	// push
-	err = n.push(peer.NetAddr, otherKnownEvents)
-	if err != nil {
-		return err
+	// push, observers are unknown to the other participants and have
+	// nothing of their own to send
+	if !n.conf.Observer {
+		err = n.push(peer.NetAddr, otherKnownEvents)
+		if err != nil {
+			n.health.failure(peer.ID, err)
+			return err
+		}
+		n.fanout([]*peers.Peer{peer}, otherKnownEvents)
 	}
 
 	// update peer selector
@@ -444,9 +864,7 @@ func (n *Node) gossip(parentReturnCh chan struct{}) error {
 
 func (n *Node) pull(peer *peers.Peer) (syncLimit bool, otherKnownEvents map[uint64]int64, err error) {
 	// Compute Known
-	n.coreLock.Lock()
-	knownEvents := n.core.KnownEvents()
-	n.coreLock.Unlock()
+	knownEvents := n.knownEvents()
 
 	// Send SyncRequest
 	start := time.Now()
@@ -472,11 +890,10 @@ func (n *Node) pull(peer *peers.Peer) (syncLimit bool, otherKnownEvents map[uint
 	if resp.SyncLimit {
 		return true, nil, nil
 	}
+	n.recordKnown(peer.ID, resp.Known)
 
 	// Add Events to poset and create new Head if necessary
-	n.coreLock.Lock()
 	err = n.sync(peer, resp.Events)
-	n.coreLock.Unlock()
 	if err != nil {
 		n.logger.WithField("error", err).Error("n.sync(peer, resp.Events)")
 		return false, nil, err
@@ -488,9 +905,10 @@ func (n *Node) pull(peer *peers.Peer) (syncLimit bool, otherKnownEvents map[uint
 func (n *Node) push(peerAddr string, knownEvents map[uint64]int64) error {
 
 	// Check SyncLimit
-	n.coreLock.Lock()
-	overSyncLimit := n.core.OverSyncLimit(knownEvents, n.conf.SyncLimit)
-	n.coreLock.Unlock()
+	var overSyncLimit bool
+	n.withCoreLock(func() {
+		overSyncLimit = n.core.OverSyncLimit(knownEvents, n.syncLimit())
+	})
 	if overSyncLimit {
 		n.logger.Debug("n.core.OverSyncLimit(knownEvents, n.conf.SyncLimit)")
 		return nil
@@ -498,9 +916,13 @@ func (n *Node) push(peerAddr string, knownEvents map[uint64]int64) error {
 
 	// Compute Diff
 	start := time.Now()
-	n.coreLock.Lock()
-	eventDiff, err := n.core.EventDiff(knownEvents)
-	n.coreLock.Unlock()
+	var (
+		eventDiff []poset.Event
+		err       error
+	)
+	n.withCoreLock(func() {
+		eventDiff, err = n.core.EventDiff(knownEvents)
+	})
 	elapsed := time.Since(start)
 	n.logger.WithField("Duration", elapsed.Nanoseconds()).Debug("n.core.EventDiff(knownEvents)")
 	if err != nil {
@@ -557,80 +979,224 @@ func (n *Node) fastForward() error {
 		"block_round_received": resp.Block.RoundReceived(),
 		"frame_events":         len(resp.Frame.Events),
 		"frame_roots":          resp.Frame.Roots,
-		"snapshot":             resp.Snapshot,
+		"snapshot":             len(resp.Snapshot),
+		"chunked":              resp.Manifest != nil,
 	}).Debug("FastForwardResponse")
 
+	// the manifest must describe the state the block was signed with. The
+	// chunks it lists are streamed to the app from a temporary file.
+	var snapshot io.Reader = bytes.NewReader(resp.Snapshot)
+	if resp.Manifest != nil {
+		if !bytes.Equal(resp.Manifest.StateHash, resp.Block.GetStateHash()) {
+			err := fmt.Errorf("snapshot manifest %X does not match the state hash %X of block %d",
+				resp.Manifest.StateHash, resp.Block.GetStateHash(), resp.Block.Index())
+			n.logger.WithField("Error", err).Error("fastForward()")
+			return err
+		}
+		file, err := n.fetchStateSnapshot(peer, resp.Manifest)
+		if err != nil {
+			n.logger.WithField("Error", err).Error("n.fetchStateSnapshot(peer, resp.Manifest)")
+			return err
+		}
+		defer removeStateSnapshot(file)
+		snapshot = file
+	}
+
 	// prepare core. ie: fresh poset
-	n.coreLock.Lock()
-	err = n.core.FastForward(peer.PubKeyHex, resp.Block, resp.Frame)
-	n.coreLock.Unlock()
+	n.withCoreLock(func() {
+		err = n.core.FastForward(peer.PubKeyHex, resp.Block, resp.Frame)
+	})
 	if err != nil {
 		n.logger.WithField("Error", err).Error("n.core.FastForward(peer.PubKeyHex, resp.Block, resp.Frame)")
 		return err
 	}
 
 	// update app from snapshot
-	err = n.proxy.Restore(resp.Snapshot)
+	stateHash, err := n.restoreFrom(snapshot)
 	if err != nil {
-		n.logger.WithField("Error", err).Error("n.proxy.Restore(resp.Snapshot)")
+		n.logger.WithField("Error", err).Error("n.restoreFrom(snapshot)")
+		return err
+	}
+	if err := n.checkRestoredState(resp.Block, stateHash); err != nil {
+		n.logger.WithField("Error", err).Error("n.checkRestoredState(resp.Block, stateHash)")
 		return err
 	}
+	// a chunked snapshot is not kept, the store asks the app for it when a
+	// peer fast forwards from this block
+	if resp.Manifest == nil {
+		n.storeStateSnapshot(resp.Block, resp.Snapshot)
+	}
 
-	n.setState(Gossiping)
+	// unless the node was stopped or shut down meanwhile
+	n.setState(Gossiping, CatchingUp)
 
 	return nil
 }
 
 func (n *Node) requestSync(target string, known map[uint64]int64) (*peer.SyncResponse, error) {
-	args := &peer.SyncRequest{FromID: n.id, Known: known}
+	args := &peer.SyncRequest{FromID: n.id, Known: known, Genesis: n.conf.GenesisHash, Limit: n.syncChunk(target)}
 	out := &peer.SyncResponse{}
-	err := n.trans.Sync(context.Background(), target, args, out)
+	start := time.Now()
+	err := n.transport().Sync(context.Background(), target, args, out)
+	if id, ok := n.peerID(target); ok {
+		n.traffic.sync(id, true)
+		if err == nil {
+			n.traffic.received(id, out.Events)
+		}
+	}
+	if args.Limit > 0 {
+		n.syncChunks.observe(target, n.syncLimit(), len(out.Events), out.More, time.Since(start), n.syncChunkTarget(), err)
+		if err == nil && out.More {
+			n.chunkedSyncs.increment()
+		}
+	}
 
 	return out, err
 }
 
 func (n *Node) requestEagerSync(target string, events []poset.WireEvent) (*peer.ForceSyncResponse, error) {
-	args := &peer.ForceSyncRequest{FromID: n.id, Events: events}
+	args := &peer.ForceSyncRequest{FromID: n.id, Events: events, Genesis: n.conf.GenesisHash}
 	out := &peer.ForceSyncResponse{}
-	err := n.trans.ForceSync(context.Background(), target, args, out)
+	err := n.transport().ForceSync(context.Background(), target, args, out)
+	if id, ok := n.peerID(target); ok && err == nil {
+		n.traffic.sent(id, events)
+	}
 
 	return out, err
 }
 
 func (n *Node) requestFastForward(target string) (*peer.FastForwardResponse, error) {
-	args := &peer.FastForwardRequest{FromID: n.id}
+	args := &peer.FastForwardRequest{FromID: n.id, Genesis: n.conf.GenesisHash, Chunked: true}
 	out := &peer.FastForwardResponse{}
-	err := n.trans.FastForward(context.Background(), target, args, out)
+	err := n.transport().FastForward(context.Background(), target, args, out)
+
+	return out, err
+}
+
+func (n *Node) requestStateChunk(target string, stateHash []byte, index int) ([]byte, error) {
+	args := &peer.StateChunkRequest{FromID: n.id, Genesis: n.conf.GenesisHash, StateHash: stateHash, Index: index}
+	out := &peer.StateChunkResponse{}
+	err := n.transport().StateChunk(context.Background(), target, args, out)
+
+	return out.Chunk, err
+}
+
+func (n *Node) requestAncestors(target string, creatorID uint64, head poset.EventHash, index int64) (*peer.AncestorsResponse, error) {
+	args := &peer.AncestorsRequest{FromID: n.id, CreatorID: creatorID, Head: head.Bytes(), Index: index}
+	out := &peer.AncestorsResponse{}
+	err := n.transport().Ancestors(context.Background(), target, args, out)
+	if id, ok := n.peerID(target); ok && err == nil {
+		n.traffic.received(id, out.Events)
+	}
 
 	return out, err
 }
 
+// fetchSelfAncestors asks the sender of an event whose self-parent is missing
+// for the events between our last event of that creator and the self-parent
+func (n *Node) fetchSelfAncestors(peer *peers.Peer, gap poset.WireErr) ([]poset.WireEvent, error) {
+	var (
+		head poset.EventHash
+		err  error
+	)
+	n.withCoreLock(func() {
+		head, err = n.core.LastEventHashFrom(gap.CreatorID())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := n.requestAncestors(peer.NetAddr, gap.CreatorID(), head, gap.Value())
+	if err != nil {
+		return nil, err
+	}
+	n.logger.WithFields(logrus.Fields{
+		"peer":       peer.NetAddr,
+		"creator_id": gap.CreatorID(),
+		"index":      gap.Value(),
+		"events":     len(resp.Events),
+	}).Debug("fetched missing self-ancestors")
+	return resp.Events, nil
+}
+
 func (n *Node) sync(peer *peers.Peer, events []poset.WireEvent) error {
 	// Insert Events in Poset and create new Head if necessary
 	start := time.Now()
-	err := n.core.Sync(peer, events)
+	err := n.syncEvents(peer, events)
+	if poset.IsWireErrType(err, poset.WireMissingSelfParent) {
+		// the events arrived out of order, fetch the gap from the sender
+		// and try again once rather than dropping the whole sync
+		var ancestors []poset.WireEvent
+		if ancestors, err = n.fetchSelfAncestors(peer, err.(poset.WireErr)); err == nil {
+			err = n.syncEvents(peer, append(ancestors, events...))
+		}
+	}
 	elapsed := time.Since(start)
 	n.logger.WithField("Duration", elapsed.Nanoseconds()).Debug("n.core.Sync(events)")
 	if err != nil {
+		if fork, ok := err.(*poset.ForkError); ok {
+			n.logger.WithError(err).WithField("peer", peer.NetAddr).Warn("fork detected")
+			n.emitFork(fork)
+		}
+		if isProtocolErr(err) {
+			score := n.misbehave(peer.ID)
+			n.logger.WithError(err).WithFields(logrus.Fields{
+				"peer":  peer.NetAddr,
+				"score": score,
+			}).Warn("peer sent malformed events")
+		}
 		return fmt.Errorf("n.core.Sync(peer, events): %v", err)
 	}
 
-	if err := n.core.RunConsensus(); err != nil {
-		return err
-	}
+	n.scheduleConsensus()
 
 	return nil
 }
 
+func (n *Node) syncEvents(peer *peers.Peer, events []poset.WireEvent) error {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.Sync(peer, events)
+}
+
+// withCoreLock runs fn under coreLock, which is released even if fn panics,
+// so that a goroutine restarted under PanicRestart finds it free
+func (n *Node) withCoreLock(fn func()) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	fn()
+}
+
+// knownEvents returns the last event index of every participant, under
+// coreLock
+func (n *Node) knownEvents() map[uint64]int64 {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.KnownEvents()
+}
+
 func (n *Node) commit(block poset.Block) error {
 
 	n.coreLock.Lock()
 	defer n.coreLock.Unlock()
 
 	stateHash := []byte{0, 1, 2}
-	_, err := n.proxy.CommitBlock(block)
-	if err != nil {
-		n.logger.WithError(err).Debug("commit(block poset.Block)")
+	if n.conf.CommitOnQuorum {
+		n.pendingBlocksLock.Lock()
+		n.pendingBlocks = append(n.pendingBlocks, block.Index())
+		n.pendingBlocksLock.Unlock()
+	} else {
+		n.commitToApp(block)
+	}
+	n.indexTransactions(block)
+	n.core.txPool.seen(block.Transactions())
+	n.indexBlockEvents(block)
+	n.publishBlock(block)
+	n.callBlockCallbacks(block)
+
+	// observers are not validators, their signatures would not count
+	if n.conf.Observer {
+		return nil
 	}
 
 	n.logger.WithFields(logrus.Fields{
@@ -666,41 +1232,126 @@ func (n *Node) commit(block poset.Block) error {
 	return nil
 }
 
-func (n *Node) addTransaction(tx []byte) error {
-	// we do not need coreLock here as n.core.AddTransactions has TransactionPoolLocker
-	return n.core.AddTransactions([][]byte{tx})
+// submitValidatorUpdates turns the validator set changes the app decided
+// while committing block into internal transactions. Every validator does,
+// observers create no events.
+func (n *Node) submitValidatorUpdates(block poset.Block, updates []proxy.ValidatorUpdate) {
+	if len(updates) == 0 || n.conf.Observer {
+		return
+	}
+	txs := make([]poset.InternalTransaction, len(updates))
+	for i, update := range updates {
+		txs[i] = update.InternalTransaction()
+	}
+	n.logger.WithFields(logrus.Fields{
+		"block":   block.Index(),
+		"updates": len(updates),
+	}).Debug("Submitting validator updates")
+	// the internal transaction pool has its own lock, commit may hold
+	// coreLock
+	if err := n.core.AddInternalTransactions(txs); err != nil {
+		n.logger.WithError(err).Error("Submitting validator updates")
+	}
 }
 
-func (n *Node) addInternalTransaction(tx poset.InternalTransaction) {
-	n.coreLock.Lock()
-	defer n.coreLock.Unlock()
-	n.core.AddInternalTransactions([]poset.InternalTransaction{tx})
+// storeReceipts keeps the transaction results the app reported for block,
+// if it did and the store supports it
+func (n *Node) storeReceipts(block poset.Block, receipts []poset.Receipt) {
+	store, ok := n.core.poset.Store.(poset.ReceiptStore)
+	if !ok || receipts == nil {
+		return
+	}
+	if len(receipts) != len(block.Transactions()) {
+		n.logger.WithFields(logrus.Fields{
+			"block":        block.Index(),
+			"transactions": len(block.Transactions()),
+			"receipts":     len(receipts),
+		}).Warn("App returned a receipt count which does not match the block")
+		return
+	}
+	if err := store.SetReceipts(block.Index(), receipts); err != nil {
+		n.logger.WithError(err).Error("Storing receipts")
+	}
 }
 
-// Shutdown the node
-func (n *Node) Shutdown() {
-	if n.getState() != Shutdown {
-		// n.mqtt.FireEvent("Shutdown()", "/mq/lachesis/node")
-		n.logger.Debug("Shutdown()")
+// publishBlocks commits to the app, in order, the pending blocks which have
+// reached the BlockQuorum
+func (n *Node) publishBlocks() {
+	if !n.conf.CommitOnQuorum {
+		return
+	}
+	anchor := n.core.GetAnchorBlock()
 
-		// Exit any non-shutdown state immediately
-		n.setState(Shutdown)
+	n.pendingBlocksLock.Lock()
+	defer n.pendingBlocksLock.Unlock()
+	for len(n.pendingBlocks) > 0 && n.pendingBlocks[0] <= anchor {
+		block, err := n.core.GetBlock(n.pendingBlocks[0])
+		if err != nil {
+			n.logger.WithError(err).Error("publishBlocks()")
+			return
+		}
+		n.commitToApp(block)
+		n.pendingBlocks = n.pendingBlocks[1:]
+	}
+}
 
-		// Stop and wait for concurrent operations
-		close(n.shutdownCh)
-		n.waitRoutines()
+func (n *Node) addTransaction(tx []byte) error {
+	if err := n.checkTx(tx); err != nil {
+		return nil
+	}
+	// we do not need coreLock here as the transaction pool has its own lock
+	if err := n.core.AddPriorityTransactions([][]byte{tx}, n.txPriority(tx)); err != nil {
+		return err
+	}
+	n.queueTxGossip(tx)
+	return nil
+}
 
-		// For some reason this needs to be called after closing the shutdownCh
-		// Not entirely sure why...
-		n.controlTimer.Shutdown()
+// SubmitTx adds a transaction of a client of the service to the
+// transaction pool, and returns the error of the app if it rejects it, or
+// ErrPoolFull
+func (n *Node) SubmitTx(tx []byte) error {
+	return n.SubmitPriorityTx(tx, n.txPriority(tx))
+}
 
-		// transport and store should only be closed once all concurrent operations
-		// are finished otherwise they will panic trying to use close objects
-		n.trans.Close()
-		if err := n.core.poset.Store.Close(); err != nil {
-			n.logger.WithError(err).Debug("node::Shutdown::n.core.poset.Store.Close()")
-		}
+// SubmitPriorityTx is SubmitTx with a priority hint, which the app does not
+// override: the transactions of a higher priority are put in events first
+func (n *Node) SubmitPriorityTx(tx []byte, priority int) error {
+	if err := n.checkTx(tx); err != nil {
+		return err
+	}
+	if err := n.core.AddPriorityTransactions([][]byte{tx}, priority); err != nil {
+		return err
+	}
+	n.queueTxGossip(tx)
+	return nil
+}
+
+// txPriority asks the app for the priority of tx, if it ranks transactions
+func (n *Node) txPriority(tx []byte) int {
+	if prioritizer, ok := n.proxy.(proxy.TxPrioritizer); ok {
+		return prioritizer.TxPriority(tx)
+	}
+	return DefaultTxPriority
+}
+
+// checkTx asks the app whether it accepts tx, if it checks transactions
+func (n *Node) checkTx(tx []byte) error {
+	checker, ok := n.proxy.(proxy.TxChecker)
+	if !ok {
+		return nil
+	}
+	err := checker.CheckTx(tx)
+	if err != nil {
+		// rejections are up to the app and can be spam, do not flood the log
+		n.rejectedTxs.increment()
+		n.logger.WithError(err).Debug("Transaction rejected by the app")
 	}
+	return err
+}
+
+func (n *Node) addInternalTransaction(tx poset.InternalTransaction) error {
+	return n.core.AddInternalTransactions([]poset.InternalTransaction{tx})
 }
 
 // GetStats returns processing stats for the node
@@ -711,6 +1362,13 @@ func (n *Node) GetStats() map[string]string {
 		}
 		return strconv.FormatInt(i, 10)
 	}
+	// rounds and block indexes start at 0
+	indexToString := func(i int64) string {
+		if i < 0 {
+			return "nil"
+		}
+		return strconv.FormatInt(i, 10)
+	}
 
 	timeElapsed := time.Since(n.start)
 
@@ -726,25 +1384,63 @@ func (n *Node) GetStats() map[string]string {
 	}
 
 	s := map[string]string{
+		"first_consensus_round":   indexToString(n.core.GetFirstConsensusRound()),
 		"last_consensus_round":    toString(lastConsensusRound),
+		"last_decided_round":      indexToString(n.core.GetLastDecidedRound()),
+		"pending_rounds":          strconv.Itoa(n.core.GetPendingRoundsCount()),
+		"anchor_block":            indexToString(n.core.GetAnchorBlock()),
 		"time_elapsed":            strconv.FormatFloat(timeElapsed.Seconds(), 'f', 2, 64),
-		"heartbeat":               strconv.FormatFloat(n.conf.HeartbeatTimeout.Seconds(), 'f', 2, 64),
+		"heartbeat":               strconv.FormatFloat(n.heartbeat().Seconds(), 'f', 2, 64),
 		"node_current":            strconv.FormatInt(time.Now().Unix(), 10),
 		"node_start":              strconv.FormatInt(n.start.Unix(), 10),
 		"last_block_index":        strconv.FormatInt(n.core.GetLastBlockIndex(), 10),
 		"consensus_events":        strconv.FormatInt(consensusEvents, 10),
-		"sync_limit":              strconv.FormatInt(n.conf.SyncLimit, 10),
+		"sync_limit":              strconv.FormatInt(n.syncLimit(), 10),
 		"consensus_transactions":  strconv.FormatUint(consensusTransactions, 10),
 		"undetermined_events":     strconv.Itoa(len(n.core.GetUndeterminedEvents())),
 		"transaction_pool":        strconv.FormatInt(n.core.GetTransactionPoolCount(), 10),
+		"transaction_pool_bytes":  strconv.Itoa(n.core.txPool.Size()),
+		"evicted_transactions":    strconv.FormatUint(n.core.txPool.Evicted(), 10),
+		"duplicate_transactions":  strconv.FormatUint(n.core.txPool.Duplicates(), 10),
+		"keyed_duplicates":        strconv.FormatInt(n.keyedDuplicates.get(), 10),
+		"expired_transactions":    strconv.FormatUint(n.core.txPool.Expired(), 10),
+		"gossiped_transactions":   strconv.FormatInt(n.gossipedTxs.get(), 10),
+		"received_transactions":   strconv.FormatInt(n.receivedTxs.get(), 10),
 		"num_peers":               strconv.Itoa(n.peerSelector.Peers().Len()),
 		"sync_rate":               strconv.FormatFloat(n.SyncRate(), 'f', 2, 64),
+		"sync_queue":              strconv.Itoa(len(n.syncQueue)),
+		"chunked_syncs":           strconv.FormatInt(n.chunkedSyncs.get(), 10),
+		"coalesced_syncs":         strconv.FormatInt(n.coalescedSyncs.get(), 10),
+		"anti_entropy_syncs":      strconv.FormatInt(n.antiEntropySyncs.get(), 10),
+		"anti_entropy_events":     strconv.FormatInt(n.antiEntropyEvents.get(), 10),
+		"skipped_events":          strconv.FormatUint(n.core.SkippedEvents(), 10),
 		"transactions_per_second": strconv.FormatFloat(transactionsPerSecond, 'f', 2, 64),
 		"events_per_second":       strconv.FormatFloat(consensusEventsPerSecond, 'f', 2, 64),
 		"rounds_per_second":       strconv.FormatFloat(consensusRoundsPerSecond, 'f', 2, 64),
 		"round_events":            strconv.Itoa(n.core.GetLastCommittedRoundEventsCount()),
 		"id":                      fmt.Sprint(n.id),
 		"state":                   n.getState().String(),
+		"gossip_paused":           strconv.FormatBool(n.GossipPaused()),
+		"suspended":               strconv.FormatBool(n.Suspended()),
+		"observer":                strconv.FormatBool(n.conf.Observer),
+		"follower":                strconv.FormatBool(n.core.key == nil),
+		"misbehavior":             strconv.FormatInt(n.getMisbehaviorTotal(), 10),
+		"rejected_transactions":   strconv.FormatInt(n.rejectedTxs.get(), 10),
+		"rejected_internal_txs":   strconv.FormatInt(n.rejectedInternalTxs.get(), 10),
+		"recovered_panics":        n.panicCount(),
+		"app_state":               n.appState(),
+		"commit_queue":            strconv.Itoa(len(n.commitCh)),
+		"app_slow_pauses":         strconv.FormatInt(n.appSlowPauses.get(), 10),
+		"app_buffered_blocks":     strconv.Itoa(n.appBuffer.Len()),
+		"app_skipped_blocks":      strconv.FormatInt(n.appSkipped.get(), 10),
+	}
+	if store, ok := n.core.poset.Store.(poset.DiskStore); ok {
+		if size, err := store.DiskUsage(); err == nil {
+			s["store_disk_bytes"] = strconv.FormatInt(size, 10)
+		}
+	}
+	for k, v := range n.traffic.stats() {
+		s[k] = v
 	}
 	// n.mqtt.FireEvent(s, "/mq/lachesis/stats")
 	return s
@@ -754,7 +1450,10 @@ func (n *Node) logStats() {
 	stats := n.GetStats()
 	n.logger.WithFields(logrus.Fields{
 		"last_consensus_round":   stats["last_consensus_round"],
+		"last_decided_round":     stats["last_decided_round"],
+		"pending_rounds":         stats["pending_rounds"],
 		"last_block_index":       stats["last_block_index"],
+		"anchor_block":           stats["anchor_block"],
 		"consensus_events":       stats["consensus_events"],
 		"consensus_transactions": stats["consensus_transactions"],
 		"undetermined_events":    stats["undetermined_events"],
@@ -779,6 +1478,40 @@ func (n *Node) logStats() {
 	}).Warn("logStats()")
 }
 
+// isProtocolErr tells whether a sync error is the sender's fault. Events
+// received out of order are not.
+func isProtocolErr(err error) bool {
+	if poset.IsWireErrType(err, poset.WireMissingSelfParent) {
+		return false
+	}
+	return poset.IsWireErr(err) || err == ErrTooManyEventTxs || err == ErrTooBigEvent
+}
+
+// misbehave increments the misbehavior score of a peer and returns the new score
+func (n *Node) misbehave(id uint64) int64 {
+	n.misbehaviorLock.Lock()
+	defer n.misbehaviorLock.Unlock()
+	n.misbehavior[id]++
+	return n.misbehavior[id]
+}
+
+// GetMisbehavior returns the misbehavior score of a peer
+func (n *Node) GetMisbehavior(id uint64) int64 {
+	n.misbehaviorLock.RLock()
+	defer n.misbehaviorLock.RUnlock()
+	return n.misbehavior[id]
+}
+
+func (n *Node) getMisbehaviorTotal() int64 {
+	n.misbehaviorLock.RLock()
+	defer n.misbehaviorLock.RUnlock()
+	var total int64
+	for _, score := range n.misbehavior {
+		total += score
+	}
+	return total
+}
+
 // SyncRate returns the current synchronization (talking to over nodes) rate in ms
 func (n *Node) SyncRate() float64 {
 	var syncErrorRate float64
@@ -834,6 +1567,11 @@ func (n *Node) GetRound(roundIndex int64) (poset.RoundCreated, error) {
 	return n.core.poset.Store.GetRoundCreated(roundIndex)
 }
 
+// GetRoundWitnesses returns the clotho/atropos status for a given round index
+func (n *Node) GetRoundWitnesses(roundIndex int64) (poset.RoundStatus, error) {
+	return n.core.poset.RoundWitnesses(roundIndex)
+}
+
 // GetLastRound returns the last round
 func (n *Node) GetLastRound() int64 {
 	return n.core.poset.Store.LastRound()
@@ -859,12 +1597,52 @@ func (n *Node) GetBlock(blockIndex int64) (poset.Block, error) {
 	return n.core.poset.Store.GetBlock(blockIndex)
 }
 
+// GetBlocks returns up to limit blocks from the index from, in order, and
+// whether there are more
+func (n *Node) GetBlocks(from int64, limit int) ([]poset.Block, bool, error) {
+	var blocks []poset.Block
+	more := false
+	err := n.core.poset.Store.BlocksRange(from, -1, func(block poset.Block) error {
+		if len(blocks) == limit {
+			more = true
+			return poset.ErrStopRange
+		}
+		blocks = append(blocks, block)
+		return nil
+	})
+	return blocks, more, err
+}
+
+// Query forwards a query to the app and returns its response
+func (n *Node) Query(query []byte) ([]byte, error) {
+	return n.proxy.Query(query)
+}
+
+// GetReceipts returns the results of the transactions of a block, as the
+// app reported them
+func (n *Node) GetReceipts(blockIndex int64) ([]poset.Receipt, error) {
+	store, ok := n.core.poset.Store.(poset.ReceiptStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not keep receipts")
+	}
+	return store.GetReceipts(blockIndex)
+}
+
+// Backup writes a consistent backup of the store to w while the node runs
+func (n *Node) Backup(w io.Writer) (int64, error) {
+	store, ok := n.core.poset.Store.(poset.BackupStore)
+	if !ok {
+		return 0, fmt.Errorf("store does not support backups")
+	}
+	return store.Backup(w)
+}
+
 // ID shows the ID of the node
 func (n *Node) ID() uint64 {
 	return n.id
 }
 
-// Stop stops the node from gossiping
-func (n *Node) Stop() {
-	n.setState(Stop)
+// IsCatchingUp tells if the node is fast forwarding to the others
+func (n *Node) IsCatchingUp() bool {
+	return n.getState() == CatchingUp
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"reflect"
@@ -21,6 +22,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/src/peer/fakenet"
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
 )
 
 type TestData struct {
@@ -347,6 +349,35 @@ func TestCreateAndInitNode(t *testing.T) {
 	}
 }
 
+// rejectingProxy is an AppProxy whose app rejects every transaction
+type rejectingProxy struct {
+	proxy.AppProxy
+}
+
+func (p *rejectingProxy) CheckTx(tx []byte) error {
+	return fmt.Errorf("rejected")
+}
+
+func TestAddTransactionRejected(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	node.proxy = &rejectingProxy{node.proxy}
+	if err := node.addTransaction([]byte("spam")); err != nil {
+		t.Fatal(err)
+	}
+	if count := node.core.GetTransactionPoolCount(); count != 0 {
+		t.Fatalf("rejected transaction should not be pooled, got %d", count)
+	}
+	if stats := node.GetStats(); stats["rejected_transactions"] != "1" {
+		t.Fatalf("expected 1 rejected transaction, got %s", stats["rejected_transactions"])
+	}
+}
+
 func TestAddTransaction(t *testing.T) {
 	// Init data
 	data := InitTestData(t, 1, 2)
@@ -375,7 +406,9 @@ func TestAddTransaction(t *testing.T) {
 
 	// Add new Internal Tx
 	internalTx := poset.InternalTransaction{}
-	node.addInternalTransaction(internalTx)
+	if err := node.addInternalTransaction(internalTx); err != nil {
+		t.Fatal(err)
+	}
 
 	// Check internal tx pool
 	txPoolCount = node.core.GetInternalTransactionPoolCount()
@@ -451,7 +484,10 @@ func TestDoBackgroundWork(t *testing.T) {
 	}
 
 	// Check submitInternalCh case
-	internalTx := poset.InternalTransaction{}
+	// a valid one, the node drops the others
+	key, _ := crypto.GenerateECDSAKey()
+	internalTx := poset.NewInternalTransaction(poset.TransactionType_PEER_ADD,
+		*peers.NewPeer(fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey)), "addr"))
 	node.submitInternalCh <- internalTx
 
 	// Because of we need to wait to complete submitInternalCh.
@@ -490,8 +526,11 @@ func TestDoBackgroundWork(t *testing.T) {
 	// Check signalTERMch case
 	node.signalTERMch <- nil
 
-	// Because of we need to wait to complete Shutdown.
-	time.Sleep(3 * time.Second)
+	select {
+	case <-node.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the shutdown")
+	}
 
 	if node.getState() != Shutdown {
 		t.Fatal(node.getState())
@@ -537,7 +576,7 @@ func TestSyncAndRequestSync(t *testing.T) {
 	}
 
 	// Check pool
-	if l := len(node1.core.transactionPool); l > 0 {
+	if l := node1.core.txPool.Len(); l > 0 {
 		t.Fatalf("expected %d, got %d", 0, l)
 	}
 
@@ -709,18 +748,37 @@ func TestRequestFastForward(t *testing.T) {
 
 	// Create expected object
 	expected := peer.FastForwardResponse{
-		FromID:   node2.id,
-		Block:    block,
-		Frame:    frame,
-		Snapshot: snapshot,
+		FromID: node2.id,
+		Block:  block,
+		Frame:  frame,
 	}
 
 	// Check actual result
 	if !result.Block.Equals(&expected.Block) || !result.Frame.Equals(&expected.Frame) ||
-		result.FromID != expected.FromID || !bytes.Equal(result.Snapshot, expected.Snapshot) {
+		result.FromID != expected.FromID {
 		t.Fatalf("bad response, expected: %+v, got: %+v", expected, result)
 	}
 
+	// the snapshot is fetched in chunks, as described by the manifest
+	if result.Manifest == nil || len(result.Snapshot) != 0 {
+		t.Fatalf("expected a snapshot manifest only, got %+v", result)
+	}
+	if !bytes.Equal(result.Manifest.StateHash, block.StateHash) {
+		t.Fatalf("expected manifest of state %X, got %X", block.StateHash, result.Manifest.StateHash)
+	}
+	file, err := node1.fetchStateSnapshot(&peers.Peer{NetAddr: data.Adds[1]}, result.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeStateSnapshot(file)
+	fetched, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fetched, snapshot) {
+		t.Fatalf("expected snapshot %X, got %X", snapshot, fetched)
+	}
+
 	hash1, err := expected.Frame.Hash()
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,423 @@
+// Package nodekit runs in-process networks of lachesis nodes over a fake
+// network, so that the applications built on lachesis spin up N nodes in
+// their own tests.
+//
+// With a Seed, the network is simulated: the nodes run on a shared
+// node.SimClock and draw their random choices from the seed, see Options.
+package nodekit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/dummy"
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+	"github.com/Fantom-foundation/go-lachesis/src/peer/fakenet"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// DefaultPoolSize is the default number of connections of a transport to
+// each peer
+const DefaultPoolSize = 2
+
+// NewFakeNetwork returns a fake network and the function which connects
+// the transports to its peers
+func NewFakeNetwork() (*fakenet.Network, peer.CreateSyncClientFunc) {
+	network := fakenet.NewNetwork()
+	createFu := func(target string,
+		timeout time.Duration) (peer.SyncClient, error) {
+		rpcCli, err := peer.NewRPCClient(
+			peer.TCP, target, time.Second, network.CreateNetConn)
+		if err != nil {
+			return nil, err
+		}
+
+		return peer.NewClient(rpcCli)
+	}
+
+	return network, createFu
+}
+
+// InitPeers generates the keys of number participants at random addresses
+// of the network
+func InitPeers(
+	number int, network *fakenet.Network) ([]*ecdsa.PrivateKey, *peers.Peers, []string) {
+	var keys []*ecdsa.PrivateKey
+	var adds []string
+	ps := peers.NewPeers()
+
+	for i := 0; i < number; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		keys = append(keys, key)
+		addr := network.RandomAddress()
+		adds = append(adds, addr)
+
+		ps.AddPeer(peers.NewPeer(
+			fmt.Sprintf("0x%X", crypto.FromECDSAPub(&keys[i].PublicKey)),
+			addr,
+		))
+	}
+
+	return keys, ps, adds
+}
+
+// InitSeededPeers generates the keys of number participants from the seed,
+// at addresses following their index, so that the same seed gives the same
+// participants
+func InitSeededPeers(
+	number int, seed int64) ([]*ecdsa.PrivateKey, *peers.Peers, []string) {
+	var keys []*ecdsa.PrivateKey
+	var adds []string
+	ps := peers.NewPeers()
+	gen := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < number; i++ {
+		key := seededKey(gen)
+		keys = append(keys, key)
+		addr := fmt.Sprintf("10.0.%d.%d:1337", i/256, i%256)
+		adds = append(adds, addr)
+
+		ps.AddPeer(peers.NewPeer(
+			fmt.Sprintf("0x%X", crypto.FromECDSAPub(&keys[i].PublicKey)),
+			addr,
+		))
+	}
+
+	return keys, ps, adds
+}
+
+// seededKey derives a key from gen. ecdsa.GenerateKey is not used for it
+// mixes its reader with randomness of its own.
+func seededKey(gen *rand.Rand) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	b := make([]byte, curve.Params().BitSize/8+8)
+	gen.Read(b)
+	n := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	d := new(big.Int).SetBytes(b)
+	d.Mod(d, n)
+	d.Add(d, big.NewInt(1))
+
+	key := new(ecdsa.PrivateKey)
+	key.PublicKey.Curve = curve
+	key.D = d
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return key
+}
+
+// NewTransport listens on addr of the fake network of listenerFu, and
+// connects to the peers with clientFu
+func NewTransport(logger logrus.FieldLogger,
+	backConf *peer.BackendConfig, addr string, poolSize int,
+	clientFu peer.CreateSyncClientFunc,
+	listenerFu peer.CreateListenerFunc) (peer.SyncPeer, error) {
+	producer := peer.NewProducer(poolSize, time.Second, clientFu)
+	backend := peer.NewBackend(backConf, logger, listenerFu)
+	if err := backend.ListenAndServe(peer.TCP, addr); err != nil {
+		return nil, err
+	}
+	return peer.NewTransport(logger, producer, backend), nil
+}
+
+// NewNode creates and initializes a node of the participants with an
+// inmem store, which is not run yet. The node gets its own copy of the
+// participants, whose heights it counts.
+func NewNode(config *node.Config, id uint64, key *ecdsa.PrivateKey,
+	participants *peers.Peers, trans peer.SyncPeer, app proxy.AppProxy,
+	localAddr string) (*node.Node, error) {
+	participants = copyPeers(participants)
+	db := poset.NewInmemStore(participants, config.CacheSize, nil)
+	selectorArgs := node.SmartPeerSelectorCreationFnArgs{
+		LocalAddr:    localAddr,
+		GetFlagTable: nil,
+	}
+	n := node.NewNode(config, id, key, participants, db, trans, app,
+		node.NewSmartPeerSelectorWrapper, selectorArgs, localAddr)
+	if err := n.Init(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Options tell how NewNetwork sets up its nodes, the zero value is valid
+type Options struct {
+	// Config of every node, node.DefaultConfig with Logger by default
+	Config *node.Config
+	// Logger of the nodes and their transports, a new one by default
+	Logger *logrus.Logger
+	// PoolSize is the number of connections to each peer, DefaultPoolSize
+	// by default
+	PoolSize int
+	// NewApp returns the app of a node, the inmem dummy app by default
+	NewApp func(logger *logrus.Logger) proxy.AppProxy
+	// Seed simulates the network when it is not 0: the keys and the
+	// addresses of the nodes, and their random choices, are drawn from it,
+	// and the nodes run on a node.SimClock which Run advances timer by timer.
+	// The runs of a seed are reproducible as far as the scheduling of the
+	// goroutines allows, the signatures and the RPC timeouts keep using the
+	// system randomness and the wall clock.
+	Seed int64
+}
+
+// Network is a set of nodes connected over a fake network
+type Network struct {
+	Nodes []*node.Node
+	// Apps are the app proxies of the Nodes, in the same order
+	Apps  []proxy.AppProxy
+	Keys  []*ecdsa.PrivateKey
+	Addrs []string
+	Peers *peers.Peers
+	Net   *fakenet.Network
+	// Clock is the clock of the nodes of a simulated network, nil otherwise
+	Clock *node.SimClock
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// simStart is the time a simulated network starts at
+var simStart = time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// simYield is the wall time a simulated network leaves its nodes to react
+// to a timer before it fires the next one
+const simYield = time.Millisecond
+
+// NewNetwork creates and initializes count nodes, which are not run yet
+func NewNetwork(count int, opts Options) (*Network, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	config := opts.Config
+	if config == nil {
+		config = node.DefaultConfig()
+		config.Logger = logger
+	}
+	poolSize := opts.PoolSize
+	if poolSize <= 0 {
+		poolSize = DefaultPoolSize
+	}
+	newApp := opts.NewApp
+	if newApp == nil {
+		newApp = dummy.NewInmemDummyApp
+	}
+
+	network, createFu := NewFakeNetwork()
+	var (
+		keys []*ecdsa.PrivateKey
+		ps   *peers.Peers
+		adds []string
+	)
+	if opts.Seed != 0 {
+		keys, ps, adds = InitSeededPeers(count, opts.Seed)
+	} else {
+		keys, ps, adds = InitPeers(count, network)
+	}
+	nw := &Network{Keys: keys, Addrs: adds, Peers: ps, Net: network,
+		stopCh: make(chan struct{})}
+	if opts.Seed != 0 {
+		nw.Clock = node.NewSimClock(simStart)
+	}
+	backConfig := peer.NewBackendConfig()
+	for i, p := range ps.ToPeerSlice() {
+		nodeConfig := config
+		if nw.Clock != nil {
+			c := *config
+			c.Clock = nw.Clock
+			c.Rand = node.NewSeededRand(opts.Seed + int64(i))
+			nodeConfig = &c
+		}
+		trans, err := NewTransport(logger, backConfig, adds[i], poolSize,
+			createFu, network.CreateListener)
+		if err != nil {
+			nw.Close()
+			return nil, err
+		}
+		app := newApp(logger)
+		n, err := NewNode(nodeConfig, p.ID, keys[i], ps, trans, app, adds[i])
+		if err != nil {
+			trans.Close()
+			nw.Close()
+			return nil, err
+		}
+		nw.Nodes = append(nw.Nodes, n)
+		nw.Apps = append(nw.Apps, app)
+	}
+	return nw, nil
+}
+
+// Run runs the nodes in the background, gossiping or not. The clock of a
+// simulated network is advanced until Close.
+func (nw *Network) Run(gossip bool) {
+	for _, n := range nw.Nodes {
+		n.RunAsync(gossip)
+	}
+	if nw.Clock != nil {
+		go nw.runClock()
+	}
+}
+
+// runClock fires the timers of the simulated network one by one, in the
+// order of their deadlines
+func (nw *Network) runClock() {
+	for {
+		select {
+		case <-nw.stopCh:
+			return
+		case <-time.After(simYield):
+			nw.Clock.Next()
+		}
+	}
+}
+
+// Close shuts the nodes down, which closes their transports
+func (nw *Network) Close() {
+	nw.stopOnce.Do(func() { close(nw.stopCh) })
+	for _, n := range nw.Nodes {
+		n.Shutdown()
+	}
+}
+
+// BombardAndWait submits transactions to the network until every node
+// committed the target block, see BombardAndWait
+func (nw *Network) BombardAndWait(target int64, timeout time.Duration) error {
+	return BombardAndWait(nw.Nodes, target, timeout)
+}
+
+// CheckBlocks compares the blocks of the nodes of the network, see
+// CheckBlocks
+func (nw *Network) CheckBlocks(fromBlock int64) error {
+	return CheckBlocks(nw.Nodes, fromBlock)
+}
+
+// BombardAndWait submits transactions to the nodes at random until every
+// one of them committed the target block and got its state hash from the
+// app, or returns an error after timeout
+func BombardAndWait(nodes []*node.Node, target int64, timeout time.Duration) error {
+	quit := make(chan struct{})
+	defer close(quit)
+	failed := make(chan error, 1)
+	go func() {
+		seq := make(map[int]int)
+		for {
+			select {
+			case <-quit:
+				return
+			default:
+				n := rand.Intn(len(nodes))
+				err := nodes[n].SubmitTx([]byte(
+					fmt.Sprintf("node%d transaction %d", n, seq[n])))
+				if err != nil && err != node.ErrPoolFull {
+					failed <- err
+					return
+				}
+				seq[n] = seq[n] + 1
+				time.Sleep(3 * time.Millisecond)
+			}
+		}
+	}()
+	return waitForBlock(nodes, target, timeout, failed)
+}
+
+// WaitForBlock waits until every node committed the target block and got
+// its state hash from the app, or returns an error after timeout
+func WaitForBlock(nodes []*node.Node, target int64, timeout time.Duration) error {
+	return waitForBlock(nodes, target, timeout, nil)
+}
+
+func waitForBlock(nodes []*node.Node, target int64, timeout time.Duration,
+	failed <-chan error) error {
+	tag := "beginning"
+	stopper := time.After(timeout)
+	for {
+		select {
+		case <-stopper:
+			return fmt.Errorf("timeout in %v", tag)
+		case err := <-failed:
+			return err
+		default:
+		}
+		time.Sleep(10 * time.Millisecond)
+		if tag = blockTag(nodes, target); tag == "" {
+			return nil
+		}
+	}
+}
+
+// blockTag tells why the nodes did not reach the target block yet, "" once
+// they did
+func blockTag(nodes []*node.Node, target int64) string {
+	for _, n := range nodes {
+		ce := n.GetLastBlockIndex()
+		if ce < target {
+			return fmt.Sprintf("ce<target:%v<%v", ce, target)
+		}
+		// wait until the target block has retrieved a state hash from
+		// the app
+		targetBlock, _ := n.GetBlock(target)
+		if len(targetBlock.GetStateHash()) == 0 {
+			return "stateHash==0"
+		}
+	}
+	return ""
+}
+
+// CheckBlocks returns an error if two nodes committed different blocks from
+// fromBlock, up to the last block they all committed
+func CheckBlocks(nodes []*node.Node, fromBlock int64) error {
+	var nodeBlocks [][]poset.Block
+	for _, n := range nodes {
+		var blocks []poset.Block
+		lastIndex := n.GetLastBlockIndex()
+		for i := fromBlock; i < lastIndex; i++ {
+			block, err := n.GetBlock(i)
+			if err != nil {
+				return fmt.Errorf("node %d block %d: %v", n.ID(), i, err)
+			}
+			blocks = append(blocks, block)
+		}
+		nodeBlocks = append(nodeBlocks, blocks)
+	}
+	if len(nodeBlocks) == 0 {
+		return nil
+	}
+
+	minB := len(nodeBlocks[0])
+	for k := 1; k < len(nodeBlocks); k++ {
+		if len(nodeBlocks[k]) < minB {
+			minB = len(nodeBlocks[k])
+		}
+	}
+
+	for i, block := range nodeBlocks[0][:minB] {
+		for k := 1; k < len(nodeBlocks); k++ {
+			oBlock := nodeBlocks[k][i]
+			if !reflect.DeepEqual(block.Body, oBlock.Body) {
+				return fmt.Errorf("difference in block %d."+
+					" node 0: %v, node %d: %v",
+					block.Index(), block.Body, k, oBlock.Body)
+			}
+		}
+	}
+	return nil
+}
+
+// copyPeers returns new peers with the keys and addresses of ps
+func copyPeers(ps *peers.Peers) *peers.Peers {
+	cp := peers.NewPeers()
+	for _, p := range ps.ToPeerSlice() {
+		cp.AddPeer(peers.NewPeer(p.PubKeyHex, p.NetAddr))
+	}
+	return cp
+}
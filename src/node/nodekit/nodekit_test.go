@@ -0,0 +1,67 @@
+package nodekit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+)
+
+func TestNetwork(t *testing.T) {
+	logger := common.NewTestLogger(t)
+	nw, err := NewNetwork(4, Options{Config: node.TestConfig(t), Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nw.Close()
+	if len(nw.Nodes) != 4 || len(nw.Apps) != 4 {
+		t.Fatalf("expected 4 nodes and apps, got %d and %d", len(nw.Nodes), len(nw.Apps))
+	}
+
+	nw.Run(true)
+	if err := nw.BombardAndWait(1, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CheckBlocks(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimulatedNetwork(t *testing.T) {
+	logger := common.NewTestLogger(t)
+	nw, err := NewNetwork(4, Options{
+		Config: node.TestConfig(t), Logger: logger, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nw.Close()
+	if nw.Clock == nil {
+		t.Fatal("expected a simulated clock")
+	}
+
+	other, err := NewNetwork(4, Options{
+		Config: node.TestConfig(t), Logger: logger, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+	for i := range nw.Addrs {
+		if nw.Addrs[i] != other.Addrs[i] ||
+			nw.Keys[i].D.Cmp(other.Keys[i].D) != 0 {
+			t.Fatalf("expected the same participant %d for the same seed", i)
+		}
+	}
+
+	start := nw.Clock.Now()
+	nw.Run(true)
+	if err := nw.BombardAndWait(1, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CheckBlocks(0); err != nil {
+		t.Fatal(err)
+	}
+	if !nw.Clock.Now().After(start) {
+		t.Fatal("expected the simulated clock to move")
+	}
+}
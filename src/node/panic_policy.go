@@ -0,0 +1,114 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// What the node does when one of its goroutines panics, see
+// Config.PanicPolicy
+const (
+	// PanicRestart recovers the panic and runs the loop again, or lets the
+	// next gossip or sync run, up to Config.PanicRestarts panics of each
+	// goroutine, after which the node fails
+	PanicRestart = "restart"
+	// PanicFail shuts the node down at the first panic, Node.Err telling
+	// why, rather than leave it running without one of its loops
+	PanicFail = "fail"
+)
+
+// DefaultPanicRestarts is the default number of panics of each goroutine the
+// node recovers from under PanicRestart
+const DefaultPanicRestarts = 3
+
+// errRecovered is the error of a sync which panicked
+var errRecovered = fmt.Errorf("recovered a panic")
+
+// panics counts the panics the node recovered from, by goroutine, and holds
+// the error which made the node fail
+type panics struct {
+	lock   sync.Mutex
+	counts map[string]int
+	total  int64
+	err    error
+}
+
+// runGuarded runs fn, the goroutine name, recovering its panic. It returns
+// true if fn panicked and may run again under Config.PanicPolicy.
+func (n *Node) runGuarded(name string, fn func()) (restart bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			restart = n.panicked(name, r, debug.Stack())
+		}
+	}()
+	fn()
+	return false
+}
+
+// panicked logs and counts a recovered panic of the goroutine name, then
+// applies Config.PanicPolicy: it tells whether the goroutine may run again,
+// or else fails the node
+func (n *Node) panicked(name string, r interface{}, stack []byte) bool {
+	n.logger.WithFields(logrus.Fields{
+		"goroutine": name,
+		"panic":     r,
+	}).Errorf("Recovered a panic\n%s", stack)
+
+	n.panics.lock.Lock()
+	if n.panics.counts == nil {
+		n.panics.counts = make(map[string]int)
+	}
+	n.panics.counts[name]++
+	n.panics.total++
+	count := n.panics.counts[name]
+	n.panics.lock.Unlock()
+
+	if n.conf.PanicPolicy == PanicRestart && count <= n.conf.PanicRestarts {
+		n.logger.WithFields(logrus.Fields{
+			"goroutine": name,
+			"panics":    count,
+		}).Warn("Restarting after a panic")
+		return true
+	}
+	n.fail(fmt.Errorf("%s panicked: %v", name, r))
+	return false
+}
+
+// fail shuts the node down on its own, Err telling why
+func (n *Node) fail(err error) {
+	n.logger.WithError(err).Error("Node failed, shutting down")
+	n.panics.lock.Lock()
+	if n.panics.err == nil {
+		n.panics.err = err
+	}
+	n.panics.lock.Unlock()
+	// the shutdown waits for the goroutine calling us
+	go n.Shutdown()
+}
+
+// Err returns the error which made the node shut down on its own, if any
+func (n *Node) Err() error {
+	n.panics.lock.Lock()
+	defer n.panics.lock.Unlock()
+	return n.panics.err
+}
+
+func (n *Node) panicCount() string {
+	n.panics.lock.Lock()
+	defer n.panics.lock.Unlock()
+	return strconv.FormatInt(n.panics.total, 10)
+}
+
+// funcName returns the name of the function or method fn, for the logs
+func funcName(fn interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+func TestFuncName(t *testing.T) {
+	n := &Node{}
+	if name := funcName(n.doTxGossip); name != "doTxGossip" {
+		t.Fatalf("expected doTxGossip, got %s", name)
+	}
+}
+
+func TestPanicPolicy(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	data.Config.PanicPolicy = PanicRestart
+	data.Config.PanicRestarts = 1
+
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID,
+		data.Keys[0], data.Peers, trans, data.Adds[0], false)
+
+	panicking := func() { panic("bug") }
+	if !node.runGuarded("loop", panicking) {
+		t.Fatal("expected the loop to restart")
+	}
+	if node.runGuarded("other", func() {}) {
+		t.Fatal("expected no restart without a panic")
+	}
+	if node.Err() != nil {
+		t.Fatal(node.Err())
+	}
+	if stats := node.GetStats(); stats["recovered_panics"] != "1" {
+		t.Fatalf("expected 1 recovered panic, got %s", stats["recovered_panics"])
+	}
+
+	// the second panic of the loop fails the node
+	if node.runGuarded("loop", panicking) {
+		t.Fatal("expected the node to fail")
+	}
+	select {
+	case <-node.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the node to shut down")
+	}
+	if node.Err() == nil {
+		t.Fatal("expected the error of the panic")
+	}
+}
+
+func TestPanicReleasesCoreLock(t *testing.T) {
+	n := &Node{logger: common.NewTestLogger(t).WithField("id", 1), conf: &Config{
+		PanicPolicy:   PanicRestart,
+		PanicRestarts: 1,
+	}}
+	if !n.runGuarded("sync", func() {
+		n.withCoreLock(func() { panic("bug") })
+	}) {
+		t.Fatal("expected the sync to restart")
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		n.coreLock.Lock()
+		n.coreLock.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the panic to release coreLock")
+	}
+}
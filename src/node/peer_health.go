@@ -0,0 +1,114 @@
+package node
+
+import (
+	"sync"
+	"time"
+)
+
+// maxPeerFailures is the number of gossips in a row a peer may fail before
+// it is reported unhealthy
+const maxPeerFailures = 3
+
+// PeerInfo is a peer of the node, with its last-known height and the health
+// of the connection to it
+type PeerInfo struct {
+	ID        uint64
+	NetAddr   string
+	PubKeyHex string
+	// Height is the index of the last event of the peer this node knows
+	Height int64
+	Self   bool
+	// LastContact is the last time a sync with the peer succeeded, in
+	// either direction, nil if it never did
+	LastContact *time.Time `json:",omitempty"`
+	// Failures are the gossips in a row with the peer which failed
+	Failures  int
+	LastError string `json:",omitempty"`
+	// Misbehavior is the count of protocol violations of the peer
+	Misbehavior int64
+	Healthy     bool
+	// Traffic is what the node exchanged with the peer
+	Traffic PeerTraffic
+}
+
+type peerContact struct {
+	last      time.Time
+	failures  int
+	lastError string
+}
+
+// peerHealth tracks the syncs with the peers, by peer ID
+type peerHealth struct {
+	lock     sync.RWMutex
+	contacts map[uint64]*peerContact
+}
+
+func newPeerHealth() *peerHealth {
+	return &peerHealth{contacts: make(map[uint64]*peerContact)}
+}
+
+func (h *peerHealth) contact(id uint64) *peerContact {
+	c, ok := h.contacts[id]
+	if !ok {
+		c = &peerContact{}
+		h.contacts[id] = c
+	}
+	return c
+}
+
+// success records a sync with the peer
+func (h *peerHealth) success(id uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	c := h.contact(id)
+	c.last = time.Now()
+	c.failures = 0
+	c.lastError = ""
+}
+
+// failure records a gossip with the peer which failed with err
+func (h *peerHealth) failure(id uint64, err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	c := h.contact(id)
+	c.failures++
+	c.lastError = err.Error()
+}
+
+func (h *peerHealth) get(id uint64) peerContact {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if c, ok := h.contacts[id]; ok {
+		return *c
+	}
+	return peerContact{}
+}
+
+// GetPeers returns the peers the node gossips with, itself included, with
+// their last-known heights and the health of the connections to them
+func (n *Node) GetPeers() []PeerInfo {
+	known := n.knownEvents()
+
+	var res []PeerInfo
+	for _, p := range n.peerSelector.Peers().ToPeerSlice() {
+		info := PeerInfo{
+			ID:          p.ID,
+			NetAddr:     p.NetAddr,
+			PubKeyHex:   p.PubKeyHex,
+			Height:      known[p.ID],
+			Self:        p.ID == n.id,
+			Misbehavior: n.GetMisbehavior(p.ID),
+			Traffic:     n.traffic.get(p.ID),
+		}
+		c := n.health.get(p.ID)
+		if !c.last.IsZero() {
+			last := c.last
+			info.LastContact = &last
+		}
+		info.Failures = c.failures
+		info.LastError = c.lastError
+		info.Healthy = info.Self || c.failures < maxPeerFailures
+		res = append(res, info)
+	}
+	return res
+}
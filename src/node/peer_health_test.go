@@ -0,0 +1,59 @@
+package node
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPeerHealth(t *testing.T) {
+	h := newPeerHealth()
+	if c := h.get(1); !c.last.IsZero() || c.failures != 0 {
+		t.Fatalf("expected no contact, got %+v", c)
+	}
+
+	for i := 0; i < maxPeerFailures; i++ {
+		h.failure(1, errors.New("connection refused"))
+	}
+	if c := h.get(1); c.failures != maxPeerFailures || c.lastError != "connection refused" {
+		t.Fatalf("expected %d failures, got %+v", maxPeerFailures, c)
+	}
+
+	h.success(1)
+	if c := h.get(1); c.last.IsZero() || c.failures != 0 || c.lastError != "" {
+		t.Fatalf("expected a healthy contact, got %+v", c)
+	}
+}
+
+func TestGetPeers(t *testing.T) {
+	data := InitTestData(t, 2, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	other := data.PeersSlice[1].ID
+	for i := 0; i < maxPeerFailures; i++ {
+		node.health.failure(other, errors.New("timeout"))
+	}
+
+	peers := node.GetPeers()
+	if len(peers) != 2 {
+		t.Fatalf("expected 2 peers, got %d", len(peers))
+	}
+	for _, p := range peers {
+		switch p.ID {
+		case node.id:
+			if !p.Self || !p.Healthy {
+				t.Fatalf("expected a healthy self, got %+v", p)
+			}
+		case other:
+			if p.Self || p.Healthy || p.LastError != "timeout" || p.LastContact != nil {
+				t.Fatalf("expected an unhealthy peer, got %+v", p)
+			}
+		default:
+			t.Fatalf("unexpected peer %+v", p)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package node
 
 import (
-	"math/rand"
-
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 )
 
@@ -20,6 +18,7 @@ type RandomPeerSelector struct {
 	peers     *peers.Peers
 	localAddr string
 	last      string
+	rand      Rand
 }
 
 // SelectorCreationFnArgs specifies the union of possible arguments that can be extracted to create a variant of PeerSelector
@@ -31,6 +30,8 @@ type SelectorCreationFn func(*peers.Peers, interface{}) PeerSelector
 // RandomPeerSelectorCreationFnArgs arguments for RandomPeerSelector
 type RandomPeerSelectorCreationFnArgs struct {
 	LocalAddr string
+	// Rand draws the peers, the global source of math/rand when nil
+	Rand Rand
 }
 
 // NewRandomPeerSelector creates a new random peer selector
@@ -38,6 +39,7 @@ func NewRandomPeerSelector(participants *peers.Peers, args RandomPeerSelectorCre
 	return &RandomPeerSelector{
 		localAddr: args.LocalAddr,
 		peers:     participants,
+		rand:      selectorRand(args.Rand),
 	}
 }
 
@@ -65,9 +67,17 @@ func (ps *RandomPeerSelector) Next() *peers.Peer {
 		selectablePeers = slice
 	}
 
-	i := rand.Intn(len(selectablePeers))
+	i := ps.rand.Intn(len(selectablePeers))
 
 	peer := selectablePeers[i]
 
 	return peer
 }
+
+// selectorRand returns rnd, or the global source of math/rand when nil
+func selectorRand(rnd Rand) Rand {
+	if rnd == nil {
+		return globalRand{}
+	}
+	return rnd
+}
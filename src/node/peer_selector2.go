@@ -2,7 +2,6 @@ package node
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 )
@@ -15,6 +14,7 @@ type SmartPeerSelector struct {
 	peers        *peers.Peers
 	localAddr    string
 	last         string
+	rand         Rand
 	GetFlagTable GetFlagTableFn
 }
 
@@ -22,6 +22,8 @@ type SmartPeerSelector struct {
 type SmartPeerSelectorCreationFnArgs struct {
 	GetFlagTable GetFlagTableFn
 	LocalAddr    string
+	// Rand draws the peers, the global source of math/rand when nil
+	Rand Rand
 }
 
 // NewSmartPeerSelector creates a new smart peer selection struct
@@ -30,6 +32,7 @@ func NewSmartPeerSelector(participants *peers.Peers, args SmartPeerSelectorCreat
 	return &SmartPeerSelector{
 		localAddr:    args.LocalAddr,
 		peers:        participants,
+		rand:         selectorRand(args.Rand),
 		GetFlagTable: args.GetFlagTable,
 	}
 }
@@ -116,7 +119,7 @@ func (ps *SmartPeerSelector) Next() *peers.Peer {
 		return nil
 	}
 
-	i := rand.Intn(len(selected))
+	i := ps.rand.Intn(len(selected))
 	selected[i].Used++
 	return selected[i]
 }
@@ -2,7 +2,6 @@ package node
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 )
@@ -13,12 +12,15 @@ type FairPeerSelector struct {
 	last      string
 	localAddr string
 	peers     *peers.Peers
+	rand      Rand
 }
 
 // FairPeerSelectorCreationFnArgs specifies which additional arguments are require to create a FairPeerSelector
 type FairPeerSelectorCreationFnArgs struct {
 	KPeerSize uint64
 	LocalAddr string
+	// Rand draws the peers, the global source of math/rand when nil
+	Rand Rand
 }
 
 // NewFairPeerSelector creates a new fair peer selection struct
@@ -26,6 +28,7 @@ func NewFairPeerSelector(participants *peers.Peers, args FairPeerSelectorCreatio
 	return &FairPeerSelector{
 		localAddr: args.LocalAddr,
 		peers:     participants,
+		rand:      selectorRand(args.Rand),
 		// kPeerSize: args.KPeerSize,
 	}
 }
@@ -105,7 +108,7 @@ func (ps *FairPeerSelector) Next() *peers.Peer {
 		return nil
 	}
 
-	i := rand.Intn(len(selected))
+	i := ps.rand.Intn(len(selected))
 	selected[i].Used++
 	return selected[i]
 }
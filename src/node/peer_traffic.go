@@ -0,0 +1,131 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// wireEventHeaderSize is the size of the indexes and IDs of a WireBody
+const wireEventHeaderSize = 40
+
+// PeerTraffic is the traffic of the node with a peer since it started
+type PeerTraffic struct {
+	EventsSent     int64
+	EventsReceived int64
+	// SyncsInitiated are the SyncRequests the node sent to the peer,
+	// SyncsServed those of the peer it answered
+	SyncsInitiated int64
+	SyncsServed    int64
+	// BytesSent and BytesReceived are the size of the events, without the
+	// encoding and the other fields of the RPCs
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// peerTraffic counts the traffic with the peers, by peer ID
+type peerTraffic struct {
+	lock  sync.Mutex
+	peers map[uint64]*PeerTraffic
+}
+
+func newPeerTraffic() *peerTraffic {
+	return &peerTraffic{peers: make(map[uint64]*PeerTraffic)}
+}
+
+func (t *peerTraffic) peer(id uint64) *PeerTraffic {
+	p, ok := t.peers[id]
+	if !ok {
+		p = &PeerTraffic{}
+		t.peers[id] = p
+	}
+	return p
+}
+
+// sent counts the events sent to the peer
+func (t *peerTraffic) sent(id uint64, events []poset.WireEvent) {
+	size := wireEventsSize(events)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := t.peer(id)
+	p.EventsSent += int64(len(events))
+	p.BytesSent += size
+}
+
+// received counts the events received from the peer
+func (t *peerTraffic) received(id uint64, events []poset.WireEvent) {
+	size := wireEventsSize(events)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := t.peer(id)
+	p.EventsReceived += int64(len(events))
+	p.BytesReceived += size
+}
+
+// sync counts a SyncRequest to the peer, initiated, or from it
+func (t *peerTraffic) sync(id uint64, initiated bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := t.peer(id)
+	if initiated {
+		p.SyncsInitiated++
+	} else {
+		p.SyncsServed++
+	}
+}
+
+func (t *peerTraffic) get(id uint64) PeerTraffic {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if p, ok := t.peers[id]; ok {
+		return *p
+	}
+	return PeerTraffic{}
+}
+
+// stats returns the traffic with every peer as peer_<id>_<counter> stats
+func (t *peerTraffic) stats() map[string]string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s := make(map[string]string, 6*len(t.peers))
+	for id, p := range t.peers {
+		for name, v := range map[string]int64{
+			"events_sent":     p.EventsSent,
+			"events_received": p.EventsReceived,
+			"syncs_initiated": p.SyncsInitiated,
+			"syncs_served":    p.SyncsServed,
+			"bytes_sent":      p.BytesSent,
+			"bytes_received":  p.BytesReceived,
+		} {
+			s[fmt.Sprintf("peer_%d_%s", id, name)] = strconv.FormatInt(v, 10)
+		}
+	}
+	return s
+}
+
+// wireEventsSize returns the size of the events, their payload, signature,
+// flag table and proofs
+func wireEventsSize(events []poset.WireEvent) int64 {
+	var size int
+	for _, we := range events {
+		size += wireEventHeaderSize + len(we.Signature) + len(we.FlagTable)
+		for _, tx := range we.Body.Transactions {
+			size += len(tx)
+		}
+		for _, proof := range we.ClothoProof {
+			size += len(proof)
+		}
+		for _, bs := range we.Body.BlockSignatures {
+			size += len(bs.Signature)
+		}
+	}
+	return int64(size)
+}
+
+// peerID returns the ID of the peer at the address, false if it is unknown
+func (n *Node) peerID(addr string) (uint64, bool) {
+	p, ok := n.peerSelector.Peers().ReadByNetAddr(addr)
+	return p.ID, ok
+}
@@ -0,0 +1,44 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestPeerTraffic(t *testing.T) {
+	tr := newPeerTraffic()
+	events := []poset.WireEvent{
+		{Body: poset.WireBody{Transactions: [][]byte{[]byte("abc")}}, Signature: "sig"},
+		{Signature: "sig"},
+	}
+	size := wireEventsSize(events)
+	if size != 2*wireEventHeaderSize+3+2*3 {
+		t.Fatalf("unexpected size %d", size)
+	}
+
+	tr.sync(1, true)
+	tr.received(1, events)
+	tr.sync(1, false)
+	tr.sent(1, events[:1])
+
+	expected := PeerTraffic{
+		EventsSent:     1,
+		EventsReceived: 2,
+		SyncsInitiated: 1,
+		SyncsServed:    1,
+		BytesSent:      wireEventsSize(events[:1]),
+		BytesReceived:  size,
+	}
+	if got := tr.get(1); got != expected {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+	if got := tr.get(2); got != (PeerTraffic{}) {
+		t.Fatalf("expected no traffic, got %+v", got)
+	}
+
+	stats := tr.stats()
+	if len(stats) != 6 || stats["peer_1_events_received"] != "2" || stats["peer_1_syncs_served"] != "1" {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+}
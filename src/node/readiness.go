@@ -0,0 +1,44 @@
+package node
+
+import (
+	"fmt"
+)
+
+// DefaultReadyMaxLag is the default Config.ReadyMaxLag
+const DefaultReadyMaxLag = 10
+
+// Ready returns why the node cannot serve its clients yet, nil if it can: it
+// must be gossiping, with its store open, at least one of its peers
+// reachable, less than Config.ReadyMaxLag rounds waiting for consensus and
+// the intake of events not paused for a slow app
+func (n *Node) Ready() error {
+	if state := n.getState(); state != Gossiping {
+		return fmt.Errorf("node is %s", state)
+	}
+	if _, err := n.core.poset.Store.Participants(); err != nil {
+		return fmt.Errorf("store: %v", err)
+	}
+
+	var others, reachable int
+	for _, p := range n.GetPeers() {
+		if p.Self {
+			continue
+		}
+		others++
+		if p.LastContact != nil && p.Healthy {
+			reachable++
+		}
+	}
+	if others > 0 && reachable == 0 {
+		return fmt.Errorf("none of the %d peers is reachable", others)
+	}
+
+	lag := n.GetLastRound() - n.core.GetLastConsensusRound()
+	if n.conf.ReadyMaxLag > 0 && lag >= n.conf.ReadyMaxLag {
+		return fmt.Errorf("%d rounds wait for consensus", lag)
+	}
+	if n.appSlow() {
+		return fmt.Errorf("app slow, %d blocks wait for it", len(n.commitCh))
+	}
+	return nil
+}
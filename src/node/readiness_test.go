@@ -0,0 +1,30 @@
+package node
+
+import (
+	"testing"
+)
+
+func TestReady(t *testing.T) {
+	data := InitTestData(t, 2, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	node.setState(CatchingUp)
+	if err := node.Ready(); err == nil {
+		t.Fatal("expected a catching up node not to be ready")
+	}
+
+	node.setState(Gossiping)
+	if err := node.Ready(); err == nil {
+		t.Fatal("expected a node without reachable peers not to be ready")
+	}
+
+	node.health.success(data.PeersSlice[1].ID)
+	if err := node.Ready(); err != nil {
+		t.Fatalf("expected the node to be ready, got %v", err)
+	}
+}
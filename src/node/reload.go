@@ -0,0 +1,91 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReloadConfig is the part of the Config which can change while the node
+// runs, see Node.Reload. The zero fields are left as they are.
+type ReloadConfig struct {
+	HeartbeatTimeout time.Duration
+	SyncLimit        int64
+	// CacheSize resizes the LRU caches of the poset and the store, the
+	// rolling indexes keep their size until they are reset
+	CacheSize int
+	// LogLevel is the level of the Config.Logger
+	LogLevel string
+}
+
+// Reload applies the non-zero fields of c to the running node and returns
+// the resulting config. Nothing is applied if a field is invalid.
+func (n *Node) Reload(c ReloadConfig) (ReloadConfig, error) {
+	var level logrus.Level
+	if c.LogLevel != "" {
+		var err error
+		if level, err = logrus.ParseLevel(c.LogLevel); err != nil {
+			return n.ReloadableConfig(), err
+		}
+	}
+	if c.HeartbeatTimeout < 0 || c.SyncLimit < 0 || c.CacheSize < 0 {
+		return n.ReloadableConfig(), fmt.Errorf("negative config value")
+	}
+
+	n.confLock.Lock()
+	if c.HeartbeatTimeout > 0 {
+		n.conf.HeartbeatTimeout = c.HeartbeatTimeout
+	}
+	if c.SyncLimit > 0 {
+		n.conf.SyncLimit = c.SyncLimit
+	}
+	if c.CacheSize > 0 {
+		n.conf.CacheSize = c.CacheSize
+	}
+	n.confLock.Unlock()
+
+	if c.CacheSize > 0 {
+		n.withCoreLock(func() {
+			n.core.poset.ResizeCache(c.CacheSize)
+		})
+	}
+	if c.LogLevel != "" {
+		n.conf.Logger.SetLevel(level)
+	}
+
+	res := n.ReloadableConfig()
+	n.logger.WithFields(logrus.Fields{
+		"heartbeat":  res.HeartbeatTimeout,
+		"sync_limit": res.SyncLimit,
+		"cache_size": res.CacheSize,
+		"log_level":  res.LogLevel,
+	}).Info("Config reloaded")
+	return res, nil
+}
+
+// ReloadableConfig returns the current values of the ReloadConfig fields
+func (n *Node) ReloadableConfig() ReloadConfig {
+	n.confLock.RLock()
+	defer n.confLock.RUnlock()
+	return ReloadConfig{
+		HeartbeatTimeout: n.conf.HeartbeatTimeout,
+		SyncLimit:        n.conf.SyncLimit,
+		CacheSize:        n.conf.CacheSize,
+		LogLevel:         n.conf.Logger.GetLevel().String(),
+	}
+}
+
+// heartbeat is the Config.HeartbeatTimeout, which Reload may change
+func (n *Node) heartbeat() time.Duration {
+	n.confLock.RLock()
+	defer n.confLock.RUnlock()
+	return n.conf.HeartbeatTimeout
+}
+
+// syncLimit is the Config.SyncLimit, which Reload may change
+func (n *Node) syncLimit() int64 {
+	n.confLock.RLock()
+	defer n.confLock.RUnlock()
+	return n.conf.SyncLimit
+}
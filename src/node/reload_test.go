@@ -0,0 +1,58 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	before := node.ReloadableConfig()
+	if _, err := node.Reload(ReloadConfig{SyncLimit: 7, LogLevel: "loud"}); err == nil {
+		t.Fatal("expected an invalid log level to be rejected")
+	}
+	if c := node.ReloadableConfig(); c != before {
+		t.Fatalf("expected nothing reloaded, got %+v", c)
+	}
+
+	c, err := node.Reload(ReloadConfig{
+		HeartbeatTimeout: 50 * time.Millisecond,
+		SyncLimit:        7,
+		CacheSize:        20,
+		LogLevel:         "warning",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ReloadConfig{
+		HeartbeatTimeout: 50 * time.Millisecond,
+		SyncLimit:        7,
+		CacheSize:        20,
+		LogLevel:         "warning",
+	}
+	if c != expected {
+		t.Fatalf("expected %+v, got %+v", expected, c)
+	}
+	if h := node.heartbeat(); h != expected.HeartbeatTimeout {
+		t.Fatalf("expected heartbeat %v, got %v", expected.HeartbeatTimeout, h)
+	}
+	if s := node.core.poset.Store.CacheSize(); s != 20 {
+		t.Fatalf("expected store cache size 20, got %d", s)
+	}
+
+	// zero fields are left as they are
+	if c, err = node.Reload(ReloadConfig{SyncLimit: 9}); err != nil {
+		t.Fatal(err)
+	}
+	expected.SyncLimit = 9
+	if c != expected {
+		t.Fatalf("expected %+v, got %+v", expected, c)
+	}
+}
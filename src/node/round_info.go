@@ -0,0 +1,100 @@
+package node
+
+import (
+	"sort"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// RoundInfo is a created round with the fame decisions of its witnesses,
+// its events, the events it received and the block made of them
+type RoundInfo struct {
+	poset.RoundStatus
+	// Events are the events created in the round, in hash order
+	Events []RoundEventInfo
+	// Received are the hashes of the events which reached consensus in the
+	// round
+	Received []string
+	// Block is the index of the block made of the received events, -1 if
+	// there is none (yet)
+	Block int64
+}
+
+// RoundEventInfo is an event created in a round, with its fame decision
+type RoundEventInfo struct {
+	Hash          string
+	Clotho        bool
+	Atropos       string
+	Consensus     bool
+	RoundReceived int64
+}
+
+// GetRoundInfo returns the round of the index, its witnesses, fame
+// decisions, events and block, to observe the consensus from the API
+func (n *Node) GetRoundInfo(index int64) (RoundInfo, error) {
+	status, err := n.core.poset.RoundWitnesses(index)
+	if err != nil {
+		return RoundInfo{}, err
+	}
+	round, err := n.core.poset.Store.GetRoundCreated(index)
+	if err != nil {
+		return RoundInfo{}, err
+	}
+
+	info := RoundInfo{RoundStatus: status, Block: -1}
+	for hash, e := range round.Message.Events {
+		event := RoundEventInfo{
+			Hash:          hash,
+			Clotho:        e.Clotho,
+			Atropos:       e.Atropos.String(),
+			Consensus:     e.Consensus,
+			RoundReceived: poset.RoundNIL,
+		}
+		if e.Consensus {
+			event.RoundReceived = e.RoundReceived
+		}
+		info.Events = append(info.Events, event)
+	}
+	sort.Slice(info.Events, func(i, j int) bool {
+		return info.Events[i].Hash < info.Events[j].Hash
+	})
+
+	received, err := n.core.poset.Store.GetRoundReceived(index)
+	if err != nil && !common.Is(err, common.KeyNotFound) {
+		return RoundInfo{}, err
+	}
+	for _, raw := range received.Rounds {
+		var hash poset.EventHash
+		hash.Set(raw)
+		info.Received = append(info.Received, hash.String())
+	}
+	if len(info.Received) > 0 {
+		info.Block = n.roundBlock(index)
+	}
+	return info, nil
+}
+
+// roundBlock returns the index of the block made of the events received in
+// the round, -1 if there is none. The blocks are ordered by the round which
+// received their events, at most one block per round.
+func (n *Node) roundBlock(roundReceived int64) int64 {
+	store := n.core.poset.Store
+	last := store.LastBlockIndex()
+	var failed bool
+	i := sort.Search(int(last+1), func(i int) bool {
+		block, err := store.GetBlock(int64(i))
+		if err != nil {
+			failed = true
+			return true
+		}
+		return block.RoundReceived() >= roundReceived
+	})
+	if failed || int64(i) > last {
+		return -1
+	}
+	if block, err := store.GetBlock(int64(i)); err != nil || block.RoundReceived() != roundReceived {
+		return -1
+	}
+	return int64(i)
+}
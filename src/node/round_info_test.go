@@ -0,0 +1,72 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestGetRoundInfo(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+	store := node.core.poset.Store
+
+	var x, y poset.EventHash
+	x.Set([]byte("x"))
+	y.Set([]byte("y"))
+	created := poset.NewRoundCreated()
+	created.AddEvent(y, false)
+	created.AddEvent(x, true)
+	created.SetConsensusEvent(x)
+	created.SetRoundReceived(x.String(), 5)
+	if err := store.SetRoundCreated(5, *created); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetRoundCreated(6, *poset.NewRoundCreated()); err != nil {
+		t.Fatal(err)
+	}
+	received := poset.NewRoundReceived()
+	received.Rounds = append(received.Rounds, x.Bytes())
+	if err := store.SetRoundReceived(5, *received); err != nil {
+		t.Fatal(err)
+	}
+	for i, roundReceived := range []int64{3, 5, 7} {
+		block := poset.NewBlock(int64(i), roundReceived, []byte("framehash"), [][]byte{[]byte("tx")})
+		if err := store.SetBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := node.GetRoundInfo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Round != 5 || info.Clothos != 1 || info.Block != 1 {
+		t.Fatalf("expected round 5 with 1 clotho and block 1, got %+v", info)
+	}
+	if len(info.Events) != 2 || info.Events[0].Hash != x.String() || !info.Events[0].Consensus ||
+		info.Events[0].RoundReceived != 5 || info.Events[1].RoundReceived != poset.RoundNIL {
+		t.Fatalf("expected the events x and y, got %+v", info.Events)
+	}
+	if len(info.Received) != 1 || info.Received[0] != x.String() {
+		t.Fatalf("expected x received, got %v", info.Received)
+	}
+
+	info, err = node.GetRoundInfo(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Block != -1 || len(info.Received) != 0 {
+		t.Fatalf("expected no block for round 6, got %+v", info)
+	}
+
+	if _, err := node.GetRoundInfo(42); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("expected KeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package node
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+)
+
+// goWorker runs fn, a loop which ends with shutdownCh, in the background.
+// The shutdown waits for it. Once the node shuts down, fn is not run. A
+// panic of fn is recovered, and fn run again, under Config.PanicPolicy.
+func (n *Node) goWorker(fn func()) {
+	if !n.addWorker() {
+		return
+	}
+	name := funcName(fn)
+	go func() {
+		defer n.workers.Done()
+		for {
+			if !n.runGuarded(name, fn) {
+				return
+			}
+		}
+	}()
+}
+
+// goTransWorker runs fn like goWorker, a loop which uses the transport and
+// also ends with the stopCh it is given. Stop closes it, and waits for fn,
+// before it closes the transport; Start runs fn again.
+func (n *Node) goTransWorker(fn func(stopCh <-chan struct{})) {
+	n.transLock.Lock()
+	defer n.transLock.Unlock()
+	n.transLoops = append(n.transLoops, fn)
+	n.startTransWorker(fn)
+}
+
+// startTransWorker runs fn, unless the node is stopped. n.transLock must be
+// held, so that Stop does not wait for the workers while one is added.
+func (n *Node) startTransWorker(fn func(stopCh <-chan struct{})) {
+	stopCh := n.transStopCh
+	select {
+	case <-stopCh:
+		// Start runs it again
+		return
+	default:
+	}
+	if !n.addWorker() {
+		return
+	}
+	n.transWorkers.Add(1)
+	name := funcName(fn)
+	go func() {
+		defer n.workers.Done()
+		defer n.transWorkers.Done()
+		for {
+			if !n.runGuarded(name, func() { fn(stopCh) }) {
+				return
+			}
+		}
+	}()
+}
+
+// transport returns the transport of the node, which Start may replace
+func (n *Node) transport() peer.SyncPeer {
+	n.transLock.RLock()
+	defer n.transLock.RUnlock()
+	return n.trans
+}
+
+// addWorker counts a worker the shutdown waits for, which must call
+// n.workers.Done when it ends. It returns false once the node shuts down.
+func (n *Node) addWorker() bool {
+	n.workersLock.Lock()
+	defer n.workersLock.Unlock()
+	select {
+	case <-n.shutdownCh:
+		return false
+	default:
+	}
+	n.workers.Add(1)
+	return true
+}
+
+// Shutdown the node, waiting as long as it takes, see ShutdownContext
+func (n *Node) Shutdown() {
+	if err := n.ShutdownContext(context.Background()); err != nil {
+		n.logger.WithError(err).Debug("Shutdown()")
+	}
+}
+
+// ShutdownContext shuts the node down gracefully. It stops processing RPCs
+// and gossiping, waits for the syncs in flight and the background work,
+// commits the pending blocks to the app, those the app buffer holds
+// included, flushes the store writes, then closes the transport and the
+// store.
+//
+// Once ctx is done, it closes the transport at once, to end the syncs in
+// flight, skips the blocks left and returns ctx.Err() once the node is
+// closed. Concurrent calls wait for the first one.
+func (n *Node) ShutdownContext(ctx context.Context) error {
+	first := false
+	n.shutdownOnce.Do(func() { first = true })
+	if !first {
+		select {
+		case <-n.doneCh:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer close(n.doneCh)
+	n.logger.Debug("Shutdown()")
+
+	// Exit any non-shutdown state immediately, which stops the processing
+	// of RPCs and the gossips
+	n.setState(Shutdown)
+	n.workersLock.Lock()
+	close(n.shutdownCh)
+	n.workersLock.Unlock()
+
+	// Stop may have closed the transport already, a Start in progress
+	// fails now
+	n.lifecycleLock.Lock()
+	transClosed := n.transClosed
+	n.lifecycleLock.Unlock()
+
+	// Wait for the syncs in flight and the background loops
+	waited := make(chan struct{})
+	go func() {
+		n.waitRoutines()
+		n.workers.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-ctx.Done():
+		n.logger.WithError(ctx.Err()).Warn("Interrupting the syncs in flight")
+		if !transClosed {
+			n.transport().Close()
+			transClosed = true
+		}
+		<-waited
+	}
+
+	// Wait for a running consensus pipeline, it must not outlive the store
+	n.core.consensusLocker.Lock()
+	defer n.core.consensusLocker.Unlock()
+
+	n.commitPending(ctx)
+	n.flushAppBuffer(ctx)
+
+	// For some reason this needs to be called after closing the shutdownCh
+	// Not entirely sure why...
+	n.controlTimer.Shutdown()
+
+	if flusher, ok := n.core.poset.Store.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			n.logger.WithError(err).Error("Flushing store writes")
+		}
+	}
+	if err := n.core.CloseWAL(); err != nil {
+		n.logger.WithError(err).Error("Closing the event journal")
+	}
+
+	// transport and store should only be closed once all concurrent operations
+	// are finished otherwise they will panic trying to use close objects
+	if !transClosed {
+		n.transport().Close()
+	}
+	if err := n.core.poset.Store.Close(); err != nil {
+		n.logger.WithError(err).Debug("node::Shutdown::n.core.poset.Store.Close()")
+	}
+	return ctx.Err()
+}
+
+// Done is closed once the node is shut down
+func (n *Node) Done() <-chan struct{} {
+	return n.doneCh
+}
+
+// commitPending commits the blocks the consensus produced but the
+// background loop did not take yet
+func (n *Node) commitPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case block := <-n.commitCh:
+			if err := n.commit(block); err != nil {
+				n.logger.WithError(err).WithField("block", block.Index()).Error("Committing block before shutdown")
+			}
+		default:
+			return
+		}
+	}
+}
+
+// flushAppBuffer commits the blocks the app buffer holds, in order, once
+// each, until the app fails one
+func (n *Node) flushAppBuffer(ctx context.Context) {
+	for ctx.Err() == nil {
+		index, block, inMemory, ok := n.appBuffer.head()
+		if !ok {
+			return
+		}
+		if !inMemory {
+			var err error
+			if block, err = n.core.GetBlock(index); err != nil {
+				n.logger.WithError(err).WithField("block", index).Error("Reading buffered block")
+				n.appBuffer.pop()
+				continue
+			}
+		}
+		res, err := n.commitBlock(block)
+		if err != nil {
+			n.logger.WithError(err).WithFields(logrus.Fields{
+				"block":    index,
+				"buffered": n.appBuffer.Len(),
+			}).Warn("App did not take the buffered blocks before shutdown")
+			return
+		}
+		n.appCommitted(block, res)
+		n.appBuffer.pop()
+	}
+}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+func TestShutdownContext(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	data.Config.HeartbeatTimeout = 10 * time.Millisecond
+
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+
+	app := &flakyProxy{Wrapper: proxy.Wrapper{AppProxy: node.proxy}, disconnected: true}
+	node.proxy = app
+
+	for i := int64(0); i < 2; i++ {
+		block := poset.NewBlock(i, i+1, []byte("framehash"), [][]byte{[]byte("tx")})
+		if err := node.core.poset.Store.SetBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		node.commitToApp(block)
+	}
+	app.setDisconnected(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := node.ShutdownContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-node.Done():
+	default:
+		t.Fatal("expected the node to be done")
+	}
+	if state := node.getState(); state != Shutdown {
+		t.Fatalf("expected Shutdown, got %v", state)
+	}
+	// the buffered blocks reached the app before the shutdown returned
+	if committed := app.getCommitted(); !reflect.DeepEqual([]int64{0, 1}, committed) {
+		t.Fatalf("expected blocks [0 1] committed, got %v", committed)
+	}
+	if l := node.appBuffer.Len(); l != 0 {
+		t.Fatalf("expected an empty app buffer, got %d blocks", l)
+	}
+
+	// later calls return at once
+	if err := node.ShutdownContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,178 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// stateChunkAttempts is the number of times a chunk of an application
+// snapshot is requested before fast forward gives up
+const stateChunkAttempts = 3
+
+// stateSnapshot returns the application snapshot taken at block. A store
+// which keeps snapshots serves it, and keeps the ones it gets from the
+// application, so that FastForward requests do not each ask the application
+// for a new snapshot.
+func (n *Node) stateSnapshot(block poset.Block) ([]byte, error) {
+	store, ok := n.core.poset.Store.(poset.StateSnapshotStore)
+	if !ok || len(block.GetStateHash()) == 0 {
+		return n.getSnapshot(block.Index())
+	}
+
+	snapshot, err := poset.ReadStateSnapshot(store, block.GetStateHash())
+	if err == nil {
+		return snapshot, nil
+	}
+	if !common.Is(err, common.KeyNotFound) {
+		n.logger.WithError(err).Warn("Reading stored state snapshot, asking the app again")
+	}
+
+	snapshot, err = n.getSnapshot(block.Index())
+	if err != nil {
+		return nil, err
+	}
+	n.storeStateSnapshot(block, snapshot)
+	return snapshot, nil
+}
+
+// stateSnapshotManifest returns the manifest of the application snapshot
+// taken at block, asking the application for it if the store does not have
+// it yet. ok is false if the store does not keep snapshots, peers then get
+// the whole snapshot.
+func (n *Node) stateSnapshotManifest(block poset.Block) (manifest poset.StateSnapshot, ok bool, err error) {
+	store, ok := n.core.poset.Store.(poset.StateSnapshotStore)
+	if !ok || len(block.GetStateHash()) == 0 {
+		return manifest, false, nil
+	}
+	manifest, err = store.GetStateSnapshot(block.GetStateHash())
+	if !common.Is(err, common.KeyNotFound) {
+		return manifest, true, err
+	}
+
+	snapshot, err := n.getSnapshot(block.Index())
+	if err != nil {
+		return manifest, true, err
+	}
+	manifest, chunks := poset.SplitStateSnapshot(block, snapshot)
+	return manifest, true, store.SetStateSnapshot(manifest, chunks)
+}
+
+// stateSnapshotChunk returns a chunk of a stored application snapshot
+func (n *Node) stateSnapshotChunk(stateHash []byte, index int) ([]byte, error) {
+	store, ok := n.core.poset.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not keep state snapshots")
+	}
+	return store.GetStateSnapshotChunk(stateHash, index)
+}
+
+// fetchStateSnapshot downloads the chunks of the snapshot described by
+// manifest one at a time, checking each against its hash, from peer first
+// and from other peers when a chunk cannot be had from it. The chunks are
+// written to a temporary file, read from the start, which the caller closes
+// and removes with removeStateSnapshot.
+func (n *Node) fetchStateSnapshot(peer *peers.Peer, manifest *poset.StateSnapshot) (*os.File, error) {
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	file, err := ioutil.TempFile("", "lachesis-snapshot")
+	if err != nil {
+		return nil, err
+	}
+	if err := n.fetchStateChunks(peer, manifest, file); err != nil {
+		removeStateSnapshot(file)
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		removeStateSnapshot(file)
+		return nil, err
+	}
+	return file, nil
+}
+
+// fetchStateChunks writes the chunks of the snapshot described by manifest
+// to w, in order
+func (n *Node) fetchStateChunks(peer *peers.Peer, manifest *poset.StateSnapshot, w io.Writer) error {
+	var size int64
+	for i := range manifest.ChunkHashes {
+		var chunk []byte
+		var err error
+		for attempt := 0; attempt < stateChunkAttempts; attempt++ {
+			if attempt > 0 {
+				peer = n.peerSelector.Next()
+			}
+			chunk, err = n.requestStateChunk(peer.NetAddr, manifest.StateHash, i)
+			if err == nil {
+				err = manifest.VerifyChunk(i, chunk)
+			}
+			if err == nil {
+				break
+			}
+			n.logger.WithFields(logrus.Fields{
+				"peer":  peer.NetAddr,
+				"chunk": i,
+			}).WithError(err).Warn("Fetching state snapshot chunk")
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		size += int64(len(chunk))
+		n.logger.WithFields(logrus.Fields{
+			"chunk":  i + 1,
+			"chunks": len(manifest.ChunkHashes),
+		}).Debug("Fetched state snapshot chunk")
+	}
+	if size != manifest.Size {
+		return fmt.Errorf("state snapshot %X has %d bytes, expected %d",
+			manifest.StateHash, size, manifest.Size)
+	}
+	return nil
+}
+
+// removeStateSnapshot closes and removes a snapshot file of fetchStateSnapshot
+func removeStateSnapshot(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// storeStateSnapshot keeps the snapshot of block if the store supports it
+func (n *Node) storeStateSnapshot(block poset.Block, snapshot []byte) {
+	store, ok := n.core.poset.Store.(poset.StateSnapshotStore)
+	if !ok || len(block.GetStateHash()) == 0 {
+		return
+	}
+	manifest, chunks := poset.SplitStateSnapshot(block, snapshot)
+	if err := store.SetStateSnapshot(manifest, chunks); err != nil {
+		n.logger.WithError(err).Warn("Storing state snapshot")
+	}
+}
+
+// checkRestoredState compares the state hash the application reports after
+// restoring a snapshot with the state hash of the block it was taken at
+func (n *Node) checkRestoredState(block poset.Block, stateHash []byte) error {
+	if len(block.GetStateHash()) == 0 || len(stateHash) == 0 {
+		n.logger.WithField("block", block.Index()).Warn("Cannot verify the restored app state, no state hash")
+		return nil
+	}
+	if !bytes.Equal(block.GetStateHash(), stateHash) {
+		return fmt.Errorf("restored app state %X does not match the state hash %X of block %d",
+			stateHash, block.GetStateHash(), block.Index())
+	}
+	n.logger.WithFields(logrus.Fields{
+		"block":      block.Index(),
+		"state_hash": fmt.Sprintf("%X", stateHash),
+	}).Debug("Restored app state verified")
+	return nil
+}
@@ -1,7 +1,9 @@
 package node
 
 import (
+	"fmt"
 	"sync"
+	"time"
 )
 
 const (
@@ -15,26 +17,123 @@ const (
 	Stop
 )
 
+// stateHistorySize is the number of the last transitions the node keeps,
+// see StateHistory
+const stateHistorySize = 64
+
 type state int
 
+// transitions are the states the node may go to from each state. Shutdown is
+// final, so that a gossip or a fast forward ending after the shutdown does
+// not bring the node back to life.
+var transitions = map[state][]state{
+	Gossiping:  {CatchingUp, Stop, Shutdown},
+	CatchingUp: {Gossiping, Stop, Shutdown},
+	Stop:       {Gossiping, Shutdown},
+}
+
+// StateTransition is a change of the state of the node
+type StateTransition struct {
+	From string
+	To   string
+	Time time.Time
+}
+
+// nodeFSM is the state machine of the lifecycle of the node
+type nodeFSM struct {
+	lock  sync.RWMutex
+	state state
+	// changed is closed at each transition
+	changed chan struct{}
+	history []StateTransition
+}
+
+func newNodeFSM() *nodeFSM {
+	return &nodeFSM{changed: make(chan struct{})}
+}
+
+func (f *nodeFSM) get() state {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.state
+}
+
+// transition goes to state s, if the current state allows it and is one of
+// from, when given. It returns the previous state and whether the state
+// changed, which it does not when the node is in s already and from, if
+// given, lists s.
+func (f *nodeFSM) transition(s state, from ...state) (state, bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	prev := f.state
+	if len(from) > 0 && !hasState(from, prev) {
+		return prev, false, fmt.Errorf("no transition to %s, node is %s", s, prev)
+	}
+	if prev == s {
+		return prev, false, nil
+	}
+	if !hasState(transitions[prev], s) {
+		return prev, false, fmt.Errorf("no transition from %s to %s", prev, s)
+	}
+
+	f.state = s
+	f.history = append(f.history, StateTransition{From: prev.String(), To: s.String(), Time: time.Now()})
+	if len(f.history) > stateHistorySize {
+		f.history = f.history[len(f.history)-stateHistorySize:]
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return prev, true, nil
+}
+
+func hasState(states []state, s state) bool {
+	for _, st := range states {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+// changes returns a channel closed at the next transition
+func (f *nodeFSM) changes() <-chan struct{} {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.changed
+}
+
+// await blocks while the node is in state s
+func (f *nodeFSM) await(s state) {
+	for {
+		f.lock.RLock()
+		cur, changed := f.state, f.changed
+		f.lock.RUnlock()
+		if cur != s {
+			return
+		}
+		<-changed
+	}
+}
+
+func (f *nodeFSM) transitionHistory() []StateTransition {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return append([]StateTransition(nil), f.history...)
+}
+
 type nodeState2 struct {
 	cond *sync.Cond
 	lock sync.RWMutex
 	wip  int
 
-	state        state
-	getStateChan chan state
-	setStateChan chan state
+	fsm *nodeFSM
 }
 
 func newNodeState2() *nodeState2 {
-	ns := &nodeState2{
-		cond:         sync.NewCond(&sync.Mutex{}),
-		getStateChan: make(chan state),
-		setStateChan: make(chan state),
+	return &nodeState2{
+		cond: sync.NewCond(&sync.Mutex{}),
+		fsm:  newNodeFSM(),
 	}
-	go ns.mtx()
-	return ns
 }
 
 func (s state) String() string {
@@ -52,15 +151,6 @@ func (s state) String() string {
 	}
 }
 
-func (s *nodeState2) mtx() {
-	for {
-		select {
-		case s.state = <-s.setStateChan:
-		case s.getStateChan <- s.state:
-		}
-	}
-}
-
 func (s *nodeState2) goFunc(fu func()) {
 	go func() {
 		s.lock.Lock()
@@ -97,9 +187,10 @@ func (s *nodeState2) waitRoutines() {
 }
 
 func (s *nodeState2) getState() state {
-	return <-s.getStateChan
+	return s.fsm.get()
 }
 
-func (s *nodeState2) setState(state state) {
-	s.setStateChan <- state
+func (s *nodeState2) setState(state state) error {
+	_, _, err := s.fsm.transition(state)
+	return err
 }
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+// FeedState is published on the state feed when the node changes state,
+// e.g. from Gossiping to CatchingUp
+const FeedState = "stateChanged"
+
+// SubscribeState returns a channel of the state changes of the node, holding
+// up to buffer notifications, and the function which ends the subscription
+// and closes the channel. The State and PrevState of the notifications are
+// set. Unlike Subscribe, the channel only ever gets state changes, so a
+// small buffer does not miss them among blocks and events.
+func (n *Node) SubscribeState(buffer int) (<-chan Notification, func()) {
+	return n.stateFeed.subscribe(buffer)
+}
+
+// WaitState blocks until the node is in state s, or returns an error after
+// timeout
+func (n *Node) WaitState(s state, timeout time.Duration) error {
+	notes, unsubscribe := n.SubscribeState(4)
+	defer unsubscribe()
+
+	// subscribed first, so that a change in between is not missed
+	if n.getState() == s {
+		return nil
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case note := <-notes:
+			if note.State == s.String() {
+				return nil
+			}
+		case <-timer.C:
+			// a notification dropped while the channel was full
+			if cur := n.getState(); cur != s {
+				return fmt.Errorf("timeout waiting for state %s, node is %s", s, cur)
+			}
+			return nil
+		}
+	}
+}
+
+func (n *Node) publishState(prev, s state) {
+	n.stateFeed.publish(Notification{Type: FeedState, State: s.String(), PrevState: prev.String()})
+}
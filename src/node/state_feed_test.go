@@ -0,0 +1,39 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateFeed(t *testing.T) {
+	n := &Node{nodeState2: newNodeState2(), stateFeed: newFeed()}
+	notes, unsubscribe := n.SubscribeState(2)
+	defer unsubscribe()
+
+	n.setState(Gossiping)
+	n.setState(CatchingUp)
+
+	note := <-notes
+	if note.Type != FeedState || note.State != "CatchingUp" || note.PrevState != "Gossiping" {
+		t.Fatalf("expected a change from Gossiping to CatchingUp, got %v", note)
+	}
+	select {
+	case note := <-notes:
+		t.Fatalf("expected no notification without a change, got %v", note)
+	default:
+	}
+
+	if err := n.WaitState(CatchingUp, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		n.setState(Shutdown)
+	}()
+	if err := n.WaitState(Shutdown, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.WaitState(Gossiping, 10*time.Millisecond); err == nil {
+		t.Fatal("expected a timeout")
+	}
+}
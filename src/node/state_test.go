@@ -74,3 +74,67 @@ func TestConcurrentGoFuncs(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestNodeFSM(t *testing.T) {
+	fsm := newNodeFSM()
+	if s := fsm.get(); s != Gossiping {
+		t.Fatalf("expected Gossiping first, got %s", s)
+	}
+
+	if prev, changed, err := fsm.transition(CatchingUp); err != nil || !changed || prev != Gossiping {
+		t.Fatalf("expected Gossiping to CatchingUp, got %s, %v, %v", prev, changed, err)
+	}
+	if _, changed, err := fsm.transition(CatchingUp); err != nil || changed {
+		t.Fatalf("expected no change, got %v, %v", changed, err)
+	}
+	if _, changed, err := fsm.transition(CatchingUp, CatchingUp); err != nil || changed {
+		t.Fatalf("expected the node in CatchingUp already, got %v, %v", changed, err)
+	}
+	// from applies even when the node is in s already
+	if _, _, err := fsm.transition(CatchingUp, Stop); err == nil {
+		t.Fatal("expected no transition from CatchingUp, which from does not list")
+	}
+	if _, _, err := fsm.transition(Stop); err != nil {
+		t.Fatal(err)
+	}
+
+	// a fast forward which ends after the node was stopped
+	if _, _, err := fsm.transition(Gossiping, CatchingUp); err == nil || fsm.get() != Stop {
+		t.Fatalf("expected the node to stay stopped, got %s", fsm.get())
+	}
+	if _, _, err := fsm.transition(CatchingUp); err == nil {
+		t.Fatal("expected no transition from Stop to CatchingUp")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		fsm.await(Stop)
+		close(done)
+	}()
+	if _, _, err := fsm.transition(Shutdown); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected await to return once the node left Stop")
+	}
+
+	// Shutdown is final
+	for _, s := range []state{Gossiping, CatchingUp, Stop} {
+		if _, _, err := fsm.transition(s); err == nil {
+			t.Fatalf("expected no transition from Shutdown to %s", s)
+		}
+	}
+
+	history := fsm.transitionHistory()
+	expected := []string{"Gossiping", "CatchingUp", "Stop", "Shutdown"}
+	if len(history) != len(expected)-1 {
+		t.Fatalf("expected %d transitions, got %v", len(expected)-1, history)
+	}
+	for i, tr := range history {
+		if tr.From != expected[i] || tr.To != expected[i+1] || tr.Time.IsZero() {
+			t.Fatalf("unexpected transition %d: %+v", i, tr)
+		}
+	}
+}
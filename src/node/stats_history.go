@@ -0,0 +1,94 @@
+package node
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// statsSampleInterval is the time between the samples of the stats
+	// history
+	statsSampleInterval = 5 * time.Second
+	// statsHistorySize is the number of samples the history keeps, an hour
+	// of them
+	statsHistorySize = 720
+)
+
+// StatsSample is a sample of the key stats of the node. The rates are over
+// the time since the previous sample.
+type StatsSample struct {
+	Time                  time.Time
+	EventsPerSecond       float64
+	TransactionsPerSecond float64
+	LastConsensusRound    int64
+	LastBlockIndex        int64
+	Peers                 int
+	TransactionPool       int64
+}
+
+// statsHistory is a ring buffer of the last samples
+type statsHistory struct {
+	lock    sync.RWMutex
+	samples []StatsSample
+	next    int
+	full    bool
+}
+
+func newStatsHistory(size int) *statsHistory {
+	return &statsHistory{samples: make([]StatsSample, size)}
+}
+
+func (h *statsHistory) add(s StatsSample) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the samples, oldest first
+func (h *statsHistory) list() []StatsSample {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	res := make([]StatsSample, 0, len(h.samples))
+	if h.full {
+		res = append(res, h.samples[h.next:]...)
+	}
+	return append(res, h.samples[:h.next]...)
+}
+
+// GetStatsHistory returns the samples of the key stats of the last hour,
+// oldest first, so that operators see their trends without a metrics stack
+func (n *Node) GetStatsHistory() []StatsSample {
+	return n.statsHistory.list()
+}
+
+// doStatsHistory samples the stats into the history until the node shuts
+// down
+func (n *Node) doStatsHistory() {
+	prevTime := n.clock.Now()
+	prevEvents := n.core.GetConsensusEventsCount()
+	prevTxs := n.core.GetConsensusTransactionsCount()
+	for {
+		select {
+		case now := <-n.clock.After(statsSampleInterval):
+			events := n.core.GetConsensusEventsCount()
+			txs := n.core.GetConsensusTransactionsCount()
+			elapsed := now.Sub(prevTime).Seconds()
+			n.statsHistory.add(StatsSample{
+				Time:                  now,
+				EventsPerSecond:       (float64(events) - float64(prevEvents)) / elapsed,
+				TransactionsPerSecond: (float64(txs) - float64(prevTxs)) / elapsed,
+				LastConsensusRound:    n.core.GetLastConsensusRound(),
+				LastBlockIndex:        n.core.GetLastBlockIndex(),
+				Peers:                 n.peerSelector.Peers().Len(),
+				TransactionPool:       n.core.GetTransactionPoolCount(),
+			})
+			prevTime, prevEvents, prevTxs = now, events, txs
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package node
+
+import (
+	"testing"
+)
+
+func TestStatsHistory(t *testing.T) {
+	h := newStatsHistory(3)
+	if samples := h.list(); len(samples) != 0 {
+		t.Fatalf("expected no samples, got %v", samples)
+	}
+
+	for i := int64(0); i < 5; i++ {
+		h.add(StatsSample{LastBlockIndex: i})
+	}
+	samples := h.list()
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	for i, s := range samples {
+		if s.LastBlockIndex != int64(i+2) {
+			t.Fatalf("expected the last 3 samples oldest first, got %v", samples)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSyncChunks is the default Config.SyncChunks
+	DefaultSyncChunks = 10
+	// minSyncChunk is the least number of events a node asks for in a
+	// SyncRequest
+	minSyncChunk = 10
+)
+
+// syncChunks holds the number of events the node asks of each peer in a
+// SyncRequest. It follows the rate the events of the peer came at, so that
+// a response takes about the target time: a slow link gets smaller chunks
+// rather than timeouts.
+type syncChunks struct {
+	lock  sync.Mutex
+	sizes map[string]int64
+}
+
+func newSyncChunks() *syncChunks {
+	return &syncChunks{sizes: make(map[string]int64)}
+}
+
+// clampChunk bounds a chunk size to [minSyncChunk, max], or max below
+// minSyncChunk
+func clampChunk(size, max int64) int64 {
+	if size < minSyncChunk {
+		size = minSyncChunk
+	}
+	if size > max {
+		size = max
+	}
+	return size
+}
+
+// size returns the chunk size to ask of the peer, max until the first
+// response of the peer
+func (c *syncChunks) size(addr string, max int64) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if size, ok := c.sizes[addr]; ok {
+		return clampChunk(size, max)
+	}
+	return max
+}
+
+// observe adapts the chunk size of the peer to a response of n events which
+// took elapsed. A failed request halves it, a response which took longer
+// than target shrinks it, and a full chunk faster than target grows it.
+func (c *syncChunks) observe(addr string, max int64, n int, more bool, elapsed, target time.Duration, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	size, ok := c.sizes[addr]
+	if !ok {
+		size = max
+	}
+	switch {
+	case err != nil:
+		size /= 2
+	case n > 0 && elapsed > 0:
+		// the events the peer sends in target at the observed rate, half
+		// way there to smooth out the noise of a single response
+		ideal := int64(float64(n) * float64(target) / float64(elapsed))
+		if ideal < size || more {
+			size = (size + ideal) / 2
+		}
+	}
+	c.sizes[addr] = clampChunk(size, max)
+}
+
+// syncChunkTarget is the time a SyncResponse should take, a quarter of the
+// TCPTimeout
+func (n *Node) syncChunkTarget() time.Duration {
+	return n.conf.TCPTimeout / 4
+}
+
+// syncChunk returns the number of events to ask of the peer, 0 for the
+// whole diff without Config.SyncChunks
+func (n *Node) syncChunk(addr string) int64 {
+	if n.conf.SyncChunks <= 1 {
+		return 0
+	}
+	return n.syncChunks.size(addr, n.syncLimit())
+}
+
+// catchUpLimit is the number of unknown events past which a requester is
+// sent to fast forward: SyncLimit, or Config.SyncChunks chunks of SyncLimit
+// events for a requester which takes chunks
+func (n *Node) catchUpLimit(limit int64) int64 {
+	if limit <= 0 || n.conf.SyncChunks <= 1 {
+		return n.syncLimit()
+	}
+	return n.syncLimit() * int64(n.conf.SyncChunks)
+}
+
+// chunkLimit is the number of events sent in reply to a SyncRequest with
+// the given Limit, which never exceeds the SyncLimit of the node, 0 for
+// the whole diff
+func (n *Node) chunkLimit(limit int64) int64 {
+	if limit <= 0 || n.conf.SyncChunks <= 1 {
+		return 0
+	}
+	if syncLimit := n.syncLimit(); limit > syncLimit {
+		return syncLimit
+	}
+	return limit
+}
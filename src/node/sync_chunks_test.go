@@ -0,0 +1,77 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSyncChunks(t *testing.T) {
+	c := newSyncChunks()
+	const max = 100
+	target := time.Second
+
+	if size := c.size("a", max); size != max {
+		t.Fatalf("expected the sync limit before any response, got %d", size)
+	}
+
+	// 100 events in 4s, 25 in the target: half way there
+	c.observe("a", max, 100, true, 4*time.Second, target, nil)
+	if size := c.size("a", max); size != 62 {
+		t.Fatalf("expected the chunk to shrink on a slow link, got %d", size)
+	}
+
+	c.observe("a", max, 62, true, 10*time.Millisecond, target, nil)
+	if size := c.size("a", max); size != max {
+		t.Fatalf("expected the chunk to grow back to the sync limit, got %d", size)
+	}
+
+	// a fast diff smaller than the chunk tells nothing of larger ones
+	c.observe("a", max, 5, false, time.Millisecond, target, nil)
+	if size := c.size("a", max); size != max {
+		t.Fatalf("expected the chunk to stay, got %d", size)
+	}
+
+	for i := 0; i < 10; i++ {
+		c.observe("a", max, 0, false, target, target, errors.New("timeout"))
+	}
+	if size := c.size("a", max); size != minSyncChunk {
+		t.Fatalf("expected the chunk to stop at %d, got %d", minSyncChunk, size)
+	}
+
+	// the other peers are left as they are, and the chunk follows a lower
+	// sync limit
+	if size := c.size("b", max); size != max {
+		t.Fatalf("expected the sync limit for another peer, got %d", size)
+	}
+	if size := c.size("a", 5); size != 5 {
+		t.Fatalf("expected the chunk not to exceed the sync limit, got %d", size)
+	}
+}
+
+func TestSyncChunkLimits(t *testing.T) {
+	conf := TestConfig(t)
+	conf.SyncLimit = 50
+	n := &Node{conf: conf}
+
+	if l := n.catchUpLimit(0); l != 50 {
+		t.Fatalf("expected a requester without chunks to fast forward past %d, got %d", 50, l)
+	}
+	if l := n.catchUpLimit(20); l != 50*DefaultSyncChunks {
+		t.Fatalf("expected a requester with chunks to fast forward past %d, got %d", 50*DefaultSyncChunks, l)
+	}
+	if l := n.chunkLimit(0); l != 0 {
+		t.Fatalf("expected the whole diff without chunks, got %d", l)
+	}
+	if l := n.chunkLimit(20); l != 20 {
+		t.Fatalf("expected a chunk of %d, got %d", 20, l)
+	}
+	if l := n.chunkLimit(80); l != 50 {
+		t.Fatalf("expected the chunk not to exceed the sync limit, got %d", l)
+	}
+
+	conf.SyncChunks = 1
+	if l := n.catchUpLimit(20); l != 50 {
+		t.Fatalf("expected no chunks with SyncChunks 1, got %d", l)
+	}
+}
@@ -0,0 +1,90 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// diffFlight is an EventDiff in progress, whose events the SyncRequests
+// which arrive meanwhile share
+type diffFlight struct {
+	known  map[uint64]int64
+	done   chan struct{}
+	events []poset.Event
+	err    error
+}
+
+// diffCoalescer computes the EventDiffs of concurrent SyncRequests once
+type diffCoalescer struct {
+	lock    sync.Mutex
+	flights []*diffFlight
+}
+
+// covers tells whether a requester which knows known needs no event missing
+// from the diff against base: it knows at least base, of the same creators,
+// EventDiff leaving out the creators missing from known
+func covers(base, known map[uint64]int64) bool {
+	if len(known) != len(base) {
+		return false
+	}
+	for creator, index := range base {
+		if k, ok := known[creator]; !ok || k < index {
+			return false
+		}
+	}
+	return true
+}
+
+// filterDiff returns the events of the diff unknown to known, in their
+// topological order
+func filterDiff(events []poset.Event, known map[uint64]int64) []poset.Event {
+	var res []poset.Event
+	for _, ev := range events {
+		if ev.Index() > known[ev.CreatorID()] {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+// eventDiff returns the events the known events miss, as EventDiff. A
+// SyncRequest which arrives while the diff of one knowing no more than it is
+// computed waits for that diff and takes the events it misses from it, so
+// that a hub node synced by many peers at once walks its DAG once for them.
+func (n *Node) eventDiff(known map[uint64]int64) ([]poset.Event, error) {
+	c := n.diffs
+	c.lock.Lock()
+	for _, f := range c.flights {
+		if covers(f.known, known) {
+			c.lock.Unlock()
+			<-f.done
+			n.coalescedSyncs.increment()
+			if f.err != nil {
+				return nil, f.err
+			}
+			return filterDiff(f.events, known), nil
+		}
+	}
+	f := &diffFlight{known: known, done: make(chan struct{})}
+	c.flights = append(c.flights, f)
+	c.lock.Unlock()
+
+	// the waiters are released even if EventDiff panics
+	defer func() {
+		c.lock.Lock()
+		for i, other := range c.flights {
+			if other == f {
+				c.flights = append(c.flights[:i], c.flights[i+1:]...)
+				break
+			}
+		}
+		c.lock.Unlock()
+		close(f.done)
+	}()
+	f.err = errRecovered
+	n.withCoreLock(func() {
+		f.events, f.err = n.core.EventDiff(known)
+	})
+	return f.events, f.err
+}
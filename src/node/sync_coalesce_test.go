@@ -0,0 +1,62 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestCovers(t *testing.T) {
+	base := map[uint64]int64{1: 3, 2: -1}
+	for _, c := range []struct {
+		known  map[uint64]int64
+		covers bool
+	}{
+		{map[uint64]int64{1: 3, 2: -1}, true},
+		{map[uint64]int64{1: 5, 2: 0}, true},
+		{map[uint64]int64{1: 2, 2: 0}, false},
+		// the diff of base leaves out the events of 3
+		{map[uint64]int64{1: 3, 2: -1, 3: 0}, false},
+		{map[uint64]int64{1: 3}, false},
+	} {
+		if covers(base, c.known) != c.covers {
+			t.Fatalf("expected covers(%v, %v) %v", base, c.known, c.covers)
+		}
+	}
+}
+
+func TestEventDiffCoalesced(t *testing.T) {
+	event := func(creator uint64, index int64) poset.Event {
+		body := poset.EventBody{Index: index}
+		return poset.Event{Message: &poset.EventMessage{Body: &body, CreatorID: creator}}
+	}
+	n := &Node{diffs: &diffCoalescer{}}
+
+	// a diff in progress
+	flight := &diffFlight{known: map[uint64]int64{1: 0, 2: -1}, done: make(chan struct{})}
+	n.diffs.flights = append(n.diffs.flights, flight)
+
+	type result struct {
+		events []poset.Event
+		err    error
+	}
+	resCh := make(chan result)
+	go func() {
+		events, err := n.eventDiff(map[uint64]int64{1: 1, 2: -1})
+		resCh <- result{events, err}
+	}()
+
+	flight.events = []poset.Event{event(1, 1), event(2, 0), event(1, 2)}
+	close(flight.done)
+
+	res := <-resCh
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if len(res.events) != 2 || res.events[0].Index() != 0 || res.events[1].Index() != 2 {
+		t.Fatalf("expected the events 2/0 and 1/2, got %v", res.events)
+	}
+	if n.coalescedSyncs.get() != 1 {
+		t.Fatalf("expected 1 coalesced sync, got %d", n.coalescedSyncs.get())
+	}
+}
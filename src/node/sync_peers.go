@@ -0,0 +1,175 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSyncPeers is the default Config.SyncPeers, one peer per gossip
+const DefaultSyncPeers = 1
+
+// selectSyncPeers returns up to k distinct peers of the peer selector
+func (n *Node) selectSyncPeers(k int) []*peers.Peer {
+	return n.selectOtherPeers(k, make(map[uint64]bool))
+}
+
+// selectOtherPeers returns up to k distinct peers of the peer selector, but
+// those of seen, which it adds to seen
+func (n *Node) selectOtherPeers(k int, seen map[uint64]bool) []*peers.Peer {
+	var res []*peers.Peer
+	// the selector favours the peers it returned the least, a few more
+	// tries than k usually find k of them
+	for i := 0; i < 3*k && len(res) < k; i++ {
+		p := n.peerSelector.Next()
+		if p == nil {
+			break
+		}
+		if seen[p.ID] {
+			continue
+		}
+		seen[p.ID] = true
+		res = append(res, p)
+	}
+	return res
+}
+
+// mergeEvents returns the union of the event lists, without the duplicates
+// of the events of the previous lists. The lists of the peers are diffs
+// against the same known events, so that the union keeps the parents of the
+// events before them.
+func mergeEvents(lists ...[]poset.WireEvent) []poset.WireEvent {
+	type eventKey struct {
+		creator uint64
+		index   int64
+	}
+	seen := make(map[eventKey]bool)
+	var res []poset.WireEvent
+	for _, events := range lists {
+		for _, we := range events {
+			key := eventKey{we.Body.CreatorID, we.Body.Index}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			res = append(res, we)
+		}
+	}
+	return res
+}
+
+// gossipMany is gossip with Config.SyncPeers peers at once: it pulls from
+// all of them concurrently, inserts the union of their events with a single
+// new event of its own, then pushes to each of them what it misses.
+func (n *Node) gossipMany(parentReturnCh chan struct{}) error {
+	targets := n.selectSyncPeers(n.conf.SyncPeers)
+	if len(targets) == 0 {
+		return fmt.Errorf("can't select next peer")
+	}
+
+	knownEvents := n.knownEvents()
+
+	resps := make([]*peer.SyncResponse, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, p := range targets {
+		wg.Add(1)
+		go func(i int, p *peers.Peer) {
+			defer wg.Done()
+			errs[i] = errRecovered
+			n.runGuarded("requestSync", func() {
+				resps[i], errs[i] = n.requestSync(p.NetAddr, knownEvents)
+			})
+		}(i, p)
+	}
+	wg.Wait()
+
+	// the peers which answered, in the order of the selector
+	var synced []int
+	for i, p := range targets {
+		if errs[i] != nil {
+			n.logger.WithError(errs[i]).WithField("peer", p.NetAddr).Error("n.requestSync(peer.NetAddr, knownEvents)")
+			n.health.failure(p.ID, errs[i])
+			continue
+		}
+		if resps[i].SyncLimit {
+			n.health.success(p.ID)
+			n.logger.WithField("from", p.NetAddr).Debug("SyncLimit")
+			n.setState(CatchingUp)
+			parentReturnCh <- struct{}{}
+			return nil
+		}
+		n.recordKnown(p.ID, resps[i].Known)
+		synced = append(synced, i)
+	}
+	if len(synced) == 0 {
+		return errs[0]
+	}
+
+	synced = n.syncMany(targets, resps, synced)
+	if len(synced) == 0 {
+		return fmt.Errorf("none of the %d peers synced", len(targets))
+	}
+
+	// push, observers are unknown to the other participants and have
+	// nothing of their own to send
+	if !n.conf.Observer {
+		pushed := make([]*peers.Peer, len(synced))
+		for j, i := range synced {
+			pushed[j] = targets[i]
+			wg.Add(1)
+			go func(p *peers.Peer, known map[uint64]int64) {
+				defer wg.Done()
+				n.runGuarded("push", func() {
+					if err := n.push(p.NetAddr, known); err != nil {
+						n.health.failure(p.ID, err)
+					}
+				})
+			}(targets[i], resps[i].Known)
+		}
+		wg.Wait()
+		n.fanout(pushed, resps[synced[0]].Known)
+	}
+
+	n.peerSelector.UpdateLast(targets[synced[0]].NetAddr)
+	return nil
+}
+
+// syncMany inserts the union of the events of the synced responses at once.
+// If that fails, it inserts the events of each peer on its own, so that the
+// faulty peer is the one blamed. It returns the peers which synced.
+func (n *Node) syncMany(targets []*peers.Peer, resps []*peer.SyncResponse, synced []int) []int {
+	lists := make([][]poset.WireEvent, len(synced))
+	for j, i := range synced {
+		lists[j] = resps[i].Events
+	}
+	events := mergeEvents(lists...)
+	n.logger.WithFields(logrus.Fields{
+		"peers":  len(synced),
+		"events": len(events),
+	}).Debug("SyncResponses merged")
+
+	if err := n.syncEvents(targets[synced[0]], events); err == nil {
+		n.scheduleConsensus()
+		for _, i := range synced {
+			n.health.success(targets[i].ID)
+		}
+		return synced
+	}
+
+	var ok []int
+	for _, i := range synced {
+		if err := n.sync(targets[i], resps[i].Events); err != nil {
+			n.logger.WithField("error", err).Error("n.sync(peer, resp.Events)")
+			n.health.failure(targets[i].ID, err)
+			continue
+		}
+		n.health.success(targets[i].ID)
+		ok = append(ok, i)
+	}
+	return ok
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestSelectSyncPeers(t *testing.T) {
+	fp := fakePeers(4)
+	fps := fp.ToPeerSlice()
+	n := &Node{
+		peerSelector: NewSmartPeerSelector(
+			fp,
+			SmartPeerSelectorCreationFnArgs{
+				LocalAddr: fps[0].NetAddr,
+				GetFlagTable: func() (map[string]int64, error) {
+					return nil, nil
+				},
+			},
+		),
+	}
+
+	selected := n.selectSyncPeers(2)
+	if len(selected) != 2 {
+		t.Fatalf("expected 2 peers, got %d", len(selected))
+	}
+	if selected[0].ID == selected[1].ID {
+		t.Fatal("expected distinct peers")
+	}
+
+	// there are only 3 peers besides the node
+	selected = n.selectSyncPeers(10)
+	if len(selected) != 3 {
+		t.Fatalf("expected 3 peers, got %d", len(selected))
+	}
+	for _, p := range selected {
+		if p.NetAddr == fps[0].NetAddr {
+			t.Fatal("expected the node not to sync with itself")
+		}
+	}
+}
+
+func TestMergeEvents(t *testing.T) {
+	event := func(creator uint64, index int64) poset.WireEvent {
+		return poset.WireEvent{Body: poset.WireBody{CreatorID: creator, Index: index}}
+	}
+	merged := mergeEvents(
+		[]poset.WireEvent{event(1, 0), event(1, 1), event(2, 0)},
+		[]poset.WireEvent{event(1, 0), event(2, 0), event(2, 1), event(3, 0)},
+	)
+
+	expected := []poset.WireEvent{event(1, 0), event(1, 1), event(2, 0), event(2, 1), event(3, 0)}
+	if len(merged) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(merged))
+	}
+	for i, we := range merged {
+		if we.Body.CreatorID != expected[i].Body.CreatorID || we.Body.Index != expected[i].Body.Index {
+			t.Fatalf("expected event %d of creator %d at %d, got %d of creator %d",
+				expected[i].Body.Index, expected[i].Body.CreatorID, i, we.Body.Index, we.Body.CreatorID)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/sirupsen/logrus"
+)
+
+// syncBatch is a SyncResponse fetched from a peer, waiting for insertion
+type syncBatch struct {
+	peer *peers.Peer
+	resp *peer.SyncResponse
+}
+
+// syncAhead holds the last index per creator of the events fetched for the
+// pipeline, which may not be inserted yet
+type syncAhead struct {
+	lock  sync.Mutex
+	known map[uint64]int64
+}
+
+func newSyncAhead() *syncAhead {
+	return &syncAhead{known: make(map[uint64]int64)}
+}
+
+// add records the events of a fetched batch
+func (a *syncAhead) add(events []poset.WireEvent) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, we := range events {
+		if index, ok := a.known[we.Body.CreatorID]; !ok || we.Body.Index > index {
+			a.known[we.Body.CreatorID] = we.Body.Index
+		}
+	}
+}
+
+// merge returns the known events of the poset, raised to the last indexes of
+// the fetched batches
+func (a *syncAhead) merge(known map[uint64]int64) map[uint64]int64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	res := make(map[uint64]int64, len(known))
+	for id, index := range known {
+		res[id] = index
+	}
+	for id, index := range a.known {
+		if cur, ok := res[id]; !ok || index > cur {
+			res[id] = index
+		}
+	}
+	return res
+}
+
+// reset forgets the fetched batches, once one of them was not inserted the
+// next requests start again from the events of the poset
+func (a *syncAhead) reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.known = make(map[uint64]int64)
+}
+
+// fetch is the first stage of the pipelined gossip, with Config.SyncPipeline.
+// It pulls from a peer the events the node misses, counting those of the
+// batches waiting for insertion as known, checks their budget and queues
+// them for doSyncInsert. The queue is bounded: once it is full, fetch blocks
+// and the next gossip waits for it.
+func (n *Node) fetch(parentReturnCh chan struct{}) error {
+	peer := n.peerSelector.Next()
+	if peer == nil {
+		return fmt.Errorf("can't select next peer")
+	}
+
+	knownEvents := n.knownEvents()
+	knownEvents = n.syncAhead.merge(knownEvents)
+
+	resp, err := n.requestSync(peer.NetAddr, knownEvents)
+	if err != nil {
+		n.logger.WithField("Error", err).Error("n.requestSync(peer.NetAddr, knownEvents)")
+		n.health.failure(peer.ID, err)
+		return err
+	}
+	n.logger.WithFields(logrus.Fields{
+		"from_id":    resp.FromID,
+		"sync_limit": resp.SyncLimit,
+		"events":     len(resp.Events),
+		"queued":     len(n.syncQueue),
+	}).Debug("SyncResponse fetched")
+
+	if resp.SyncLimit {
+		n.health.success(peer.ID)
+		n.logger.WithField("from", peer.NetAddr).Debug("SyncLimit")
+		n.setState(CatchingUp)
+		parentReturnCh <- struct{}{}
+		return nil
+	}
+
+	// the checks which need no parent run here, while the previous batch
+	// is inserted
+	for _, we := range resp.Events {
+		if err := n.core.CheckEventBudget(we); err != nil {
+			score := n.misbehave(peer.ID)
+			n.logger.WithError(err).WithFields(logrus.Fields{
+				"peer":  peer.NetAddr,
+				"score": score,
+			}).Warn("peer sent malformed events")
+			n.health.failure(peer.ID, err)
+			return err
+		}
+	}
+	n.recordKnown(peer.ID, resp.Known)
+	if len(resp.Events) > 0 {
+		n.syncAhead.add(resp.Events)
+	}
+
+	select {
+	case n.syncQueue <- syncBatch{peer: peer, resp: resp}:
+	case <-n.shutdownCh:
+		return nil
+	}
+	n.peerSelector.UpdateLast(peer.NetAddr)
+	return nil
+}
+
+// doSyncInsert is the second stage of the pipelined gossip: it inserts the
+// fetched batches in their order, then pushes to each peer what it misses
+func (n *Node) doSyncInsert() {
+	for {
+		select {
+		case b := <-n.syncQueue:
+			n.insertBatch(b)
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
+
+func (n *Node) insertBatch(b syncBatch) {
+	// the batches fetched before a fast forward or a pause for the app are
+	// dropped, and fetched again
+	if n.getState() != Gossiping || n.appSlow() {
+		n.syncAhead.reset()
+		return
+	}
+
+	if err := n.sync(b.peer, b.resp.Events); err != nil {
+		n.logger.WithField("error", err).Error("n.sync(peer, resp.Events)")
+		n.health.failure(b.peer.ID, err)
+		n.syncAhead.reset()
+		return
+	}
+	n.health.success(b.peer.ID)
+
+	// push, observers are unknown to the other participants and have
+	// nothing of their own to send
+	if !n.conf.Observer {
+		if err := n.push(b.peer.NetAddr, b.resp.Known); err != nil {
+			n.health.failure(b.peer.ID, err)
+		}
+		n.fanout([]*peers.Peer{b.peer}, b.resp.Known)
+	}
+}
@@ -0,0 +1,33 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestSyncAhead(t *testing.T) {
+	event := func(creator uint64, index int64) poset.WireEvent {
+		return poset.WireEvent{Body: poset.WireBody{CreatorID: creator, Index: index}}
+	}
+	a := newSyncAhead()
+	a.add([]poset.WireEvent{event(1, 3), event(1, 4), event(2, 0)})
+
+	known := map[uint64]int64{1: 2, 2: 5, 3: 1}
+	merged := a.merge(known)
+	expected := map[uint64]int64{1: 4, 2: 5, 3: 1}
+	for id, index := range expected {
+		if merged[id] != index {
+			t.Fatalf("expected index %d of creator %d, got %d", index, id, merged[id])
+		}
+	}
+	if known[1] != 2 {
+		t.Fatal("expected the known events of the poset to be left as they are")
+	}
+
+	a.reset()
+	merged = a.merge(known)
+	if merged[1] != 2 {
+		t.Fatalf("expected the known events of the poset after a reset, got %d", merged[1])
+	}
+}
@@ -0,0 +1,35 @@
+package node
+
+import (
+	"time"
+)
+
+// txExpiryInterval is the time between two expiries of the transaction pool
+const txExpiryInterval = time.Second
+
+// expireTxs drops the transactions which waited in the pool for longer than
+// the TxTTL at now, and tells the app about them
+func (n *Node) expireTxs(now time.Time) {
+	expired := n.core.txPool.expire(now.Add(-n.conf.TxTTL))
+	if len(expired) == 0 {
+		return
+	}
+	hashes := make([]string, len(expired))
+	for i, t := range expired {
+		hashes[i] = t.hash
+	}
+	n.logger.WithField("transactions", len(expired)).Warn("Transactions expired in the pool")
+	n.emitTxExpired(hashes)
+}
+
+// doTxExpiry expires the transactions of the pool every txExpiryInterval
+func (n *Node) doTxExpiry() {
+	for {
+		select {
+		case now := <-n.clock.After(txExpiryInterval):
+			n.expireTxs(now)
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
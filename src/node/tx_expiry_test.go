@@ -0,0 +1,51 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+func TestExpireTxs(t *testing.T) {
+	data := InitTestData(t, 1, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+	events := make(chan proxy.NodeEvent, 1)
+	node.nodeEvents = events
+
+	stuck := []byte("stuck")
+	if err := node.core.AddTransactions([][]byte{stuck}); err != nil {
+		t.Fatal(err)
+	}
+	node.expireTxs(time.Now())
+	if l := node.core.txPool.Len(); l != 1 {
+		t.Fatalf("expected the transaction to wait for its TTL, got %d in the pool", l)
+	}
+
+	node.expireTxs(time.Now().Add(node.conf.TxTTL + time.Second))
+	if l := node.core.txPool.Len(); l != 0 {
+		t.Fatalf("expected the transaction to expire, got %d in the pool", l)
+	}
+	select {
+	case event := <-events:
+		if event.Type != proxy.TxExpired || len(event.Txs) != 1 || event.Txs[0] != poset.TxHash(stuck) {
+			t.Fatalf("expected a tx_expired event for %s, got %+v", poset.TxHash(stuck), event)
+		}
+	default:
+		t.Fatal("expected a tx_expired event")
+	}
+
+	// the expired transaction may be submitted again
+	if err := node.core.AddTransactions([][]byte{stuck}); err != nil {
+		t.Fatal(err)
+	}
+	if l := node.core.txPool.Len(); l != 1 {
+		t.Fatalf("expected the transaction back in the pool, got %d", l)
+	}
+}
@@ -0,0 +1,167 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peer"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// txGossipInterval is the time between two pushes of the submitted
+	// transactions to the peers
+	txGossipInterval = 50 * time.Millisecond
+	// maxTxGossipBytes bounds the transactions of a TxGossipRequest, but a
+	// larger transaction goes alone
+	maxTxGossipBytes = 1 << 20
+)
+
+// txGossip holds the transactions submitted to the node until they are
+// pushed to the peers
+type txGossip struct {
+	lock sync.Mutex
+	txs  [][]byte
+}
+
+func (g *txGossip) queue(tx []byte) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.txs = append(g.txs, tx)
+}
+
+// flush returns the queued transactions, in batches of at most
+// maxTxGossipBytes, and empties the queue
+func (g *txGossip) flush() [][][]byte {
+	g.lock.Lock()
+	txs := g.txs
+	g.txs = nil
+	g.lock.Unlock()
+
+	var (
+		batches [][][]byte
+		batch   [][]byte
+		size    int
+	)
+	for _, tx := range txs {
+		if len(batch) > 0 && size+len(tx) > maxTxGossipBytes {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, tx)
+		size += len(tx)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// queueTxGossip queues a transaction submitted to the node for the peers,
+// with Config.TxGossip
+func (n *Node) queueTxGossip(tx []byte) {
+	if n.conf.TxGossip {
+		n.txGossip.queue(tx)
+	}
+}
+
+// doTxGossip pushes the submitted transactions to the peers every
+// txGossipInterval, until stopCh is closed
+func (n *Node) doTxGossip(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-n.clock.After(txGossipInterval):
+			// a suspended or stopped node keeps the transactions for when
+			// it resumes
+			if n.Suspended() || n.getState() != Gossiping {
+				continue
+			}
+			if batches := n.txGossip.flush(); len(batches) > 0 {
+				n.pushTxs(batches)
+			}
+		case <-stopCh:
+			return
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
+
+// pushTxs sends the batches of transactions to all the peers at once. The
+// gossip is best effort: a peer which misses them gets them in the events.
+func (n *Node) pushTxs(batches [][][]byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.conf.TCPTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-n.shutdownCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	trans := n.transport()
+
+	var wg sync.WaitGroup
+	for _, p := range n.peerSelector.Peers().ToPeerSlice() {
+		if p.ID == n.id || p.NetAddr == n.localAddr {
+			continue
+		}
+		wg.Add(1)
+		go func(p *peers.Peer) {
+			defer wg.Done()
+			for _, txs := range batches {
+				args := &peer.TxGossipRequest{FromID: n.id, Genesis: n.conf.GenesisHash, Txs: txs}
+				out := &peer.TxGossipResponse{}
+				if err := trans.TxGossip(ctx, p.NetAddr, args, out); err != nil {
+					n.logger.WithError(err).WithField("peer", p.NetAddr).Debug("Transaction gossip failed")
+					return
+				}
+				n.gossipedTxs.add(int64(len(txs)))
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (n *Node) processTxGossipRequest(rpc *peer.RPC, cmd *peer.TxGossipRequest) {
+	n.logger.WithFields(logrus.Fields{
+		"from_id":      cmd.FromID,
+		"transactions": len(cmd.Txs),
+	}).Debug("processTxGossipRequest(rpc net.RPC, cmd *net.TxGossipRequest)")
+
+	resp := &peer.TxGossipResponse{
+		FromID: n.id,
+	}
+	respErr := n.checkGenesis(cmd.FromID, cmd.Genesis)
+	if respErr == nil {
+		resp.Accepted = n.addGossipedTxs(cmd.Txs)
+	}
+	// TODO: context.Background
+	rpc.SendResult(context.Background(), n.logger, resp, respErr)
+}
+
+// addGossipedTxs adds the transactions a peer pushed to the pool, but those
+// the app rejects, and returns how many it accepted. They are not pushed
+// again: the peer pushed them to every node. Those already in the pool, in
+// an event of a peer or committed are dropped as duplicates, see
+// Core.AddPriorityTransactions.
+func (n *Node) addGossipedTxs(txs [][]byte) int {
+	var accepted int
+	for _, tx := range txs {
+		if err := n.checkTx(tx); err != nil {
+			continue
+		}
+		if err := n.core.AddPriorityTransactions([][]byte{tx}, n.txPriority(tx)); err != nil {
+			n.logger.WithError(err).Debug("Gossiped transaction refused")
+			if err == ErrPoolFull || err == ErrObserver {
+				break
+			}
+			continue
+		}
+		accepted++
+	}
+	n.receivedTxs.add(int64(accepted))
+	return accepted
+}
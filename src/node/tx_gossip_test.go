@@ -0,0 +1,84 @@
+package node
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestTxGossipFlush(t *testing.T) {
+	var g txGossip
+	g.queue([]byte("a"))
+	g.queue(bytes.Repeat([]byte("b"), maxTxGossipBytes))
+	g.queue([]byte("c"))
+
+	batches := g.flush()
+	if len(batches) != 3 {
+		t.Fatalf("expected the large transaction in a batch of its own, got %d batches", len(batches))
+	}
+	if len(g.flush()) != 0 {
+		t.Fatal("expected the queue to be empty after a flush")
+	}
+}
+
+func TestTxGossip(t *testing.T) {
+	data := InitTestData(t, 2, 2)
+
+	trans1 := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans1)
+
+	trans2 := createTransport(t, data.Logger, data.BackConfig, data.Adds[1],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans2)
+
+	peer1, _ := data.Peers.ReadByNetAddr(data.Adds[0])
+	peer2, _ := data.Peers.ReadByNetAddr(data.Adds[1])
+
+	conf := *data.Config
+	conf.TxGossip = true
+	node1 := createNode(t, data.Logger, &conf, peer1.ID, data.Keys[0], data.Peers, trans1, data.Adds[0], false)
+	defer node1.Shutdown()
+
+	node2 := createNode(t, data.Logger, data.Config, peer2.ID, data.Keys[1], data.Peers, trans2, data.Adds[1], false)
+	defer node2.Shutdown()
+
+	tx := []byte("gossiped")
+	if err := node1.SubmitTx(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !node2.core.txPool.contains(poset.TxHash(tx)) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the transaction in the pool of the peer")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r := node2.receivedTxs.get(); r != 1 {
+		t.Fatalf("expected 1 received transaction, got %d", r)
+	}
+}
+
+func TestAddCommittedTransactions(t *testing.T) {
+	cores, _, _ := initCores(1, t)
+	core := cores[0]
+	committed := []byte("committed")
+	block := poset.NewBlock(0, 1, []byte("framehash"), [][]byte{committed})
+	if err := core.poset.Store.(poset.TxIndexStore).IndexTransactions(block); err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction pushed again once committed is a duplicate
+	if err := core.AddTransactions([][]byte{committed, []byte("fresh")}); err != nil {
+		t.Fatal(err)
+	}
+	if core.txPool.contains(poset.TxHash(committed)) || !core.txPool.contains(poset.TxHash([]byte("fresh"))) {
+		t.Fatal("expected only the transaction which is not committed in the pool")
+	}
+	if d := core.txPool.Duplicates(); d != 1 {
+		t.Fatalf("expected 1 duplicate, got %d", d)
+	}
+}
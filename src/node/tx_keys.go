@@ -0,0 +1,111 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
+)
+
+// DefaultTxKeyWindow is the default number of the last idempotency keys
+// whose submissions the node drops, see Config.TxKeyWindow
+const DefaultTxKeyWindow = 100000
+
+// txKeys holds the hashes of the transactions submitted with the last
+// window idempotency keys, so that a client which retries a submission
+// after a timeout does not get a transfer committed twice, even when the
+// retried transaction differs from the first one, e.g. re-signed.
+type txKeys struct {
+	lock   sync.Mutex
+	hashes map[string]string
+	order  []string
+	window int
+}
+
+func newTxKeys(window int) *txKeys {
+	return &txKeys{hashes: make(map[string]string), window: window}
+}
+
+// claim records key for the transaction of hash, unless it is already
+// recorded: it then returns the hash of the transaction first submitted
+// with it, and false
+func (k *txKeys) claim(key, hash string) (string, bool) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if first, ok := k.hashes[key]; ok {
+		return first, false
+	}
+	if k.window <= 0 {
+		return hash, true
+	}
+	k.hashes[key] = hash
+	k.order = append(k.order, key)
+	for len(k.order) > k.window {
+		delete(k.hashes, k.order[0])
+		k.order = k.order[1:]
+	}
+	return hash, true
+}
+
+// release forgets key after the submission it was claimed for failed, so
+// that the client may retry it
+func (k *txKeys) release(key, hash string) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.hashes[key] != hash {
+		return
+	}
+	delete(k.hashes, key)
+	for i, o := range k.order {
+		if o == key {
+			k.order = append(k.order[:i], k.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// SubmitKeyedTx is SubmitTx with an idempotency key: the submissions of a
+// key among the last Config.TxKeyWindow ones are dropped. It returns the
+// hash of the transaction submitted with the key, and whether it was this
+// one rather than an earlier submission.
+func (n *Node) SubmitKeyedTx(key string, tx []byte) (string, bool, error) {
+	return n.SubmitKeyedPriorityTx(key, tx, n.txPriority(tx))
+}
+
+// SubmitKeyedPriorityTx is SubmitKeyedTx with a priority hint, see
+// SubmitPriorityTx
+func (n *Node) SubmitKeyedPriorityTx(key string, tx []byte, priority int) (string, bool, error) {
+	hash, ok := n.txKeys.claim(key, poset.TxHash(tx))
+	if !ok {
+		n.keyedDuplicates.increment()
+		return hash, false, nil
+	}
+	if err := n.SubmitPriorityTx(tx, priority); err != nil {
+		n.txKeys.release(key, hash)
+		return hash, false, err
+	}
+	return hash, true, nil
+}
+
+// subscribeKeyedTxs takes the keyed submissions of the app, if its proxy is
+// a proxy.KeyedSubmitter
+func (n *Node) subscribeKeyedTxs() {
+	if submitter, ok := n.proxy.(proxy.KeyedSubmitter); ok {
+		n.keyedSubmitCh = submitter.KeyedSubmitCh()
+	}
+}
+
+// addKeyedTransaction adds a transaction the app submitted with an
+// idempotency key, see proxy.KeyedSubmitter
+func (n *Node) addKeyedTransaction(t proxy.KeyedTx) error {
+	hash, ok := n.txKeys.claim(t.Key, poset.TxHash(t.Tx))
+	if !ok {
+		n.keyedDuplicates.increment()
+		return nil
+	}
+	if err := n.addTransaction(t.Tx); err != nil {
+		n.txKeys.release(t.Key, hash)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package node
+
+import "testing"
+
+func TestTxKeys(t *testing.T) {
+	k := newTxKeys(2)
+
+	if hash, ok := k.claim("a", "0x1"); !ok || hash != "0x1" {
+		t.Fatalf("expected a new key, got %s %v", hash, ok)
+	}
+	// a retry, even of another transaction, gets the first hash
+	if hash, ok := k.claim("a", "0x2"); ok || hash != "0x1" {
+		t.Fatalf("expected the first hash of the key, got %s %v", hash, ok)
+	}
+
+	// a failed submission frees the key, unless it was claimed by another
+	k.release("a", "0x2")
+	if _, ok := k.claim("a", "0x3"); ok {
+		t.Fatal("expected the key to stay claimed by its first transaction")
+	}
+	k.release("a", "0x1")
+	if _, ok := k.claim("a", "0x3"); !ok {
+		t.Fatal("expected a released key to be claimed again")
+	}
+
+	// the window keeps the last keys only
+	k.claim("b", "0x4")
+	k.claim("c", "0x5")
+	if _, ok := k.claim("a", "0x6"); !ok {
+		t.Fatal("expected the oldest key to leave the window")
+	}
+	if _, ok := k.claim("c", "0x7"); ok {
+		t.Fatal("expected the last key to stay in the window")
+	}
+}
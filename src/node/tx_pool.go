@@ -0,0 +1,415 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+const (
+	// DefaultMaxPoolTxs is the default number of transactions the pool holds
+	DefaultMaxPoolTxs = 100000
+	// DefaultMaxPoolBytes is the default size in bytes of the transactions
+	// the pool holds, room for two transactions of the largest size
+	DefaultMaxPoolBytes = 2 * MaxEventsPayloadSize
+	// DefaultTxDedupWindow is the default number of transactions which left
+	// the pool, or were committed, whose duplicates the pool drops
+	DefaultTxDedupWindow = 100000
+	// DefaultTxTTL is the default time a transaction waits in the pool for
+	// an event before it expires
+	DefaultTxTTL = 10 * time.Minute
+	// DefaultTxPriority is the priority of the transactions submitted
+	// without one, when the app does not rank them, see proxy.TxPrioritizer
+	DefaultTxPriority = 0
+)
+
+// Policies of a full transaction pool, see Config.PoolPolicy
+const (
+	// PoolBackpressure refuses the new transactions: the node stops reading
+	// the SubmitCh of the app, whose submissions wait, and the clients of the
+	// service get ErrPoolFull
+	PoolBackpressure = "backpressure"
+	// PoolEvictOldest drops the oldest transactions of the lowest priority,
+	// first in first out, to make room for the new ones
+	PoolEvictOldest = "evict-oldest"
+)
+
+// ErrPoolFull is returned for the transactions a full pool refuses
+var ErrPoolFull = fmt.Errorf("transaction pool full")
+
+// pooledTx is a transaction of the pool
+type pooledTx struct {
+	tx       []byte
+	hash     string
+	priority int
+	// seq is the order of arrival of the transaction
+	seq   uint64
+	added time.Time
+}
+
+// before tells whether t leaves the pool before o: the higher priorities
+// first, in their order of arrival
+func (t pooledTx) before(o pooledTx) bool {
+	if t.priority != o.priority {
+		return t.priority > o.priority
+	}
+	return t.seq < o.seq
+}
+
+// txPool holds the transactions waiting for an event, by priority then in
+// their order of arrival, within a bound on their count and their size. It
+// drops the duplicates of the transactions it holds, and of the last ones
+// which left it for an event, were seen in an event of a peer or were
+// committed.
+type txPool struct {
+	lock     sync.RWMutex
+	txs      []pooledTx // in the order they leave the pool
+	seq      uint64
+	pending  map[string]struct{}
+	bytes    int
+	maxTxs   int
+	maxBytes int
+	evict    bool
+	evicted  uint64
+	expired  uint64
+	// recent counts the hashes of recentOrder, the last window transactions
+	// which left the pool or were committed
+	recent      map[string]int
+	recentOrder []string
+	window      int
+	duplicates  uint64
+	// spaceCh wakes the node waiting for room in the pool
+	spaceCh chan struct{}
+	// clock dates the transactions added, for their expiry
+	clock Clock
+}
+
+func newTxPool() *txPool {
+	return &txPool{
+		pending: make(map[string]struct{}),
+		recent:  make(map[string]int),
+		window:  DefaultTxDedupWindow,
+		spaceCh: make(chan struct{}, 1),
+		clock:   wallClock{},
+	}
+}
+
+// setClock sets the clock dating the transactions added
+func (p *txPool) setClock(clock Clock) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.clock = clock
+}
+
+// setDedupWindow sets the number of transactions which left the pool whose
+// duplicates are dropped, 0 to only drop the duplicates of the pool
+func (p *txPool) setDedupWindow(window int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.window = window
+	p.trimRecent()
+}
+
+// remember adds hash to the recent transactions
+func (p *txPool) remember(hash string) {
+	if p.window <= 0 {
+		return
+	}
+	p.recent[hash]++
+	p.recentOrder = append(p.recentOrder, hash)
+	p.trimRecent()
+}
+
+func (p *txPool) trimRecent() {
+	for len(p.recentOrder) > p.window {
+		hash := p.recentOrder[0]
+		p.recentOrder = p.recentOrder[1:]
+		if p.recent[hash]--; p.recent[hash] <= 0 {
+			delete(p.recent, hash)
+		}
+	}
+}
+
+// known tells whether the transaction of the hash is in the pool or among
+// the recent ones
+func (p *txPool) known(hash string) bool {
+	if _, ok := p.pending[hash]; ok {
+		return true
+	}
+	_, ok := p.recent[hash]
+	return ok
+}
+
+// setLimits bounds the pool, 0 means no limit, and sets the policy once it
+// is full
+func (p *txPool) setLimits(maxTxs, maxBytes int, policy string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.maxTxs = maxTxs
+	p.maxBytes = maxBytes
+	p.evict = policy == PoolEvictOldest
+}
+
+// fits tells whether count more transactions of size bytes fit in the pool
+func (p *txPool) fits(count, size int) bool {
+	return (p.maxTxs <= 0 || len(p.txs)+count <= p.maxTxs) &&
+		(p.maxBytes <= 0 || p.bytes+size <= p.maxBytes)
+}
+
+// add inserts txs in the pool with the priority, but the duplicates,
+// evicting the oldest transactions of the lowest priority to make room for
+// them with PoolEvictOldest. It returns the number of evicted transactions,
+// and ErrPoolFull if txs do not fit; then none is added.
+func (p *txPool) add(txs [][]byte, priority int) (int, error) {
+	hashes := make([]string, len(txs))
+	for i, tx := range txs {
+		hashes[i] = poset.TxHash(tx)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := p.clock.Now()
+	var (
+		fresh []pooledTx
+		size  int
+		seen  = make(map[string]bool, len(txs))
+	)
+	for i, tx := range txs {
+		if p.known(hashes[i]) || seen[hashes[i]] {
+			p.duplicates++
+			continue
+		}
+		seen[hashes[i]] = true
+		fresh = append(fresh, pooledTx{tx: tx, hash: hashes[i], priority: priority, added: now})
+		size += len(tx)
+	}
+
+	if (p.maxTxs > 0 && len(fresh) > p.maxTxs) || (p.maxBytes > 0 && size > p.maxBytes) {
+		return 0, ErrPoolFull
+	}
+	var evicted int
+	for !p.fits(len(fresh), size) {
+		if !p.evict {
+			return 0, ErrPoolFull
+		}
+		p.evictOne()
+		evicted++
+	}
+	p.evicted += uint64(evicted)
+	for _, t := range fresh {
+		p.seq++
+		t.seq = p.seq
+		p.insert(t)
+	}
+	return evicted, nil
+}
+
+// insert adds t to the pool, in its place
+func (p *txPool) insert(t pooledTx) {
+	i := sort.Search(len(p.txs), func(i int) bool {
+		return t.before(p.txs[i])
+	})
+	p.txs = append(p.txs, pooledTx{})
+	copy(p.txs[i+1:], p.txs[i:])
+	p.txs[i] = t
+	p.pending[t.hash] = struct{}{}
+	p.bytes += len(t.tx)
+}
+
+// evictOne removes the oldest transaction of the lowest priority
+func (p *txPool) evictOne() {
+	lowest := p.txs[len(p.txs)-1].priority
+	i := sort.Search(len(p.txs), func(i int) bool {
+		return p.txs[i].priority <= lowest
+	})
+	p.bytes -= len(p.txs[i].tx)
+	delete(p.pending, p.txs[i].hash)
+	p.txs = append(p.txs[:i], p.txs[i+1:]...)
+}
+
+// take removes and returns the first transactions of the pool, the ones of
+// the highest priority, at most maxTxs of them if it is positive, and as
+// many as fit in maxPayload bytes. The batch is empty if the first one does
+// not fit: Core never pools such a transaction, and creates its event
+// without any.
+func (p *txPool) take(maxTxs, maxPayload int) []pooledTx {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var payloadSize, nTxs int
+	for nTxs = 0; nTxs < len(p.txs); nTxs++ {
+		if maxTxs > 0 && nTxs >= maxTxs {
+			break
+		}
+		// a transaction bigger than maxPayload is refused before it is
+		// pooled, see Core.AddPriorityTransactions
+		txSize := len(p.txs[nTxs].tx)
+		if payloadSize+txSize > maxPayload {
+			break
+		}
+		payloadSize += txSize
+	}
+	batch := make([]pooledTx, nTxs)
+	copy(batch, p.txs)
+	for _, t := range batch {
+		p.remember(t.hash)
+		delete(p.pending, t.hash)
+	}
+	p.txs = p.txs[nTxs:]
+	p.bytes -= payloadSize
+	if nTxs > 0 {
+		select {
+		case p.spaceCh <- struct{}{}:
+		default:
+		}
+	}
+	return batch
+}
+
+// putBack returns a batch of take to the pool, in its place, whatever the
+// limits
+func (p *txPool) putBack(batch []pooledTx) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, t := range batch {
+		p.insert(t)
+	}
+}
+
+// seen remembers the transactions of a committed block, or of an event of a
+// peer, and removes them from the pool, where they would be duplicates
+func (p *txPool) seen(txs [][]byte) {
+	if len(txs) == 0 {
+		return
+	}
+	hashes := make(map[string]bool, len(txs))
+	for _, tx := range txs {
+		hashes[poset.TxHash(tx)] = true
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var drop bool
+	for hash := range hashes {
+		p.remember(hash)
+		if _, ok := p.pending[hash]; ok {
+			drop = true
+		}
+	}
+	if !drop {
+		return
+	}
+
+	var kept []pooledTx
+	for _, t := range p.txs {
+		if hashes[t.hash] {
+			p.bytes -= len(t.tx)
+			delete(p.pending, t.hash)
+			p.duplicates++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	p.txs = kept
+	select {
+	case p.spaceCh <- struct{}{}:
+	default:
+	}
+}
+
+// expire removes the transactions which entered the pool before deadline
+// and returns them. They are not duplicates of the next transactions.
+func (p *txPool) expire(deadline time.Time) []pooledTx {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var kept, expired []pooledTx
+	for _, t := range p.txs {
+		if t.added.Before(deadline) {
+			p.bytes -= len(t.tx)
+			delete(p.pending, t.hash)
+			expired = append(expired, t)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	p.txs = kept
+	p.expired += uint64(len(expired))
+	select {
+	case p.spaceCh <- struct{}{}:
+	default:
+	}
+	return expired
+}
+
+// full tells whether the pool refuses one more transaction
+func (p *txPool) full() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return !p.evict && !p.fits(1, 1)
+}
+
+// space is signaled when transactions leave the pool
+func (p *txPool) space() <-chan struct{} {
+	return p.spaceCh
+}
+
+// contains tells whether the transaction of the hash is in the pool
+func (p *txPool) contains(hash string) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	_, ok := p.pending[hash]
+	return ok
+}
+
+// list returns the transactions of the pool, in the order they leave it
+func (p *txPool) list() []pooledTx {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return append([]pooledTx(nil), p.txs...)
+}
+
+// Len returns the number of transactions in the pool
+func (p *txPool) Len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.txs)
+}
+
+// Size returns the size in bytes of the transactions in the pool
+func (p *txPool) Size() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.bytes
+}
+
+// Evicted returns the number of transactions evicted from the pool
+func (p *txPool) Evicted() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.evicted
+}
+
+// Duplicates returns the number of duplicate transactions the pool dropped
+func (p *txPool) Duplicates() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.duplicates
+}
+
+// dropped counts n duplicates dropped before they reached the pool
+func (p *txPool) dropped(n int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.duplicates += uint64(n)
+}
+
+// Expired returns the number of transactions which expired in the pool
+func (p *txPool) Expired() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.expired
+}
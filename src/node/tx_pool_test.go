@@ -0,0 +1,188 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func pooledTxs(batch []pooledTx) [][]byte {
+	txs := make([][]byte, len(batch))
+	for i, t := range batch {
+		txs[i] = t.tx
+	}
+	return txs
+}
+
+func TestTxPoolTakePayload(t *testing.T) {
+	pool := newTxPool()
+	if _, err := pool.add([][]byte{[]byte("aaaa"), []byte("bb"), []byte("c")}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first transaction does not fit: none is taken
+	if batch := pool.take(0, 3); len(batch) != 0 {
+		t.Fatalf("expected an empty batch, got %q", pooledTxs(batch))
+	}
+	if batch := pool.take(0, 0); len(batch) != 0 {
+		t.Fatalf("expected an empty batch, got %q", pooledTxs(batch))
+	}
+	batch := pooledTxs(pool.take(0, 6))
+	if !reflect.DeepEqual([][]byte{[]byte("aaaa"), []byte("bb")}, batch) {
+		t.Fatalf("expected the transactions which fit, got %q", batch)
+	}
+	batch = pooledTxs(pool.take(0, 6))
+	if !reflect.DeepEqual([][]byte{[]byte("c")}, batch) {
+		t.Fatalf("expected the last transaction, got %q", batch)
+	}
+}
+
+func TestTxPoolBackpressure(t *testing.T) {
+	pool := newTxPool()
+	pool.setLimits(2, 10, PoolBackpressure)
+
+	if _, err := pool.add([][]byte{[]byte("aaaa"), []byte("bbbb")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !pool.full() {
+		t.Fatal("expected a pool of 2 transactions to be full")
+	}
+	if _, err := pool.add([][]byte{[]byte("c")}, 0); err != ErrPoolFull {
+		t.Fatalf("expected ErrPoolFull, got %v", err)
+	}
+
+	batch := pooledTxs(pool.take(1, MaxEventsPayloadSize))
+	if !reflect.DeepEqual([][]byte{[]byte("aaaa")}, batch) {
+		t.Fatalf("expected the oldest transaction, got %q", batch)
+	}
+	select {
+	case <-pool.space():
+	default:
+		t.Fatal("expected the pool to signal room")
+	}
+	if pool.full() {
+		t.Fatal("expected room in the pool")
+	}
+	// 4+7 bytes is over the limit
+	if _, err := pool.add([][]byte{[]byte("ccccccc")}, 0); err != ErrPoolFull {
+		t.Fatalf("expected ErrPoolFull, got %v", err)
+	}
+	if _, err := pool.add([][]byte{[]byte("cccccc")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if size := pool.Size(); size != 10 {
+		t.Fatalf("expected 10 bytes, got %d", size)
+	}
+}
+
+func TestTxPoolEvictOldest(t *testing.T) {
+	pool := newTxPool()
+	pool.setLimits(3, 0, PoolEvictOldest)
+
+	for _, tx := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := pool.add([][]byte{[]byte(tx)}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pool.full() {
+		t.Fatal("expected an evicting pool never to be full")
+	}
+	if evicted := pool.Evicted(); evicted != 2 {
+		t.Fatalf("expected 2 evicted transactions, got %d", evicted)
+	}
+	batch := pooledTxs(pool.take(0, MaxEventsPayloadSize))
+	expected := [][]byte{[]byte("c"), []byte("d"), []byte("e")}
+	if !reflect.DeepEqual(expected, batch) {
+		t.Fatalf("expected %q, got %q", expected, batch)
+	}
+
+	// a batch larger than the pool is refused
+	if _, err := pool.add([][]byte{[]byte("f"), []byte("g"), []byte("h"), []byte("i")}, 0); err != ErrPoolFull {
+		t.Fatalf("expected ErrPoolFull, got %v", err)
+	}
+}
+
+func TestTxPoolDedup(t *testing.T) {
+	pool := newTxPool()
+	pool.setDedupWindow(2)
+
+	if _, err := pool.add([][]byte{[]byte("a"), []byte("b"), []byte("a")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.add([][]byte{[]byte("b")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if l := pool.Len(); l != 2 {
+		t.Fatalf("expected 2 transactions, got %d", l)
+	}
+
+	// a transaction which left the pool for an event is still a duplicate
+	pool.take(1, MaxEventsPayloadSize)
+	if _, err := pool.add([][]byte{[]byte("a")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if l := pool.Len(); l != 1 {
+		t.Fatalf("expected 1 transaction, got %d", l)
+	}
+
+	// the committed transactions leave the pool
+	pool.seen([][]byte{[]byte("b"), []byte("c")})
+	if l := pool.Len(); l != 0 {
+		t.Fatalf("expected an empty pool, got %d transactions", l)
+	}
+	if _, err := pool.add([][]byte{[]byte("c")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if l := pool.Len(); l != 0 {
+		t.Fatalf("expected a committed transaction to be dropped, got %d transactions", l)
+	}
+	if d := pool.Duplicates(); d != 5 {
+		t.Fatalf("expected 5 duplicates, got %d", d)
+	}
+
+	// a is out of the window of 2, it is accepted again
+	if _, err := pool.add([][]byte{[]byte("a")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !pool.contains(poset.TxHash([]byte("a"))) {
+		t.Fatal("expected the transaction out of the window in the pool")
+	}
+}
+
+func TestTxPoolPriority(t *testing.T) {
+	pool := newTxPool()
+	pool.setLimits(4, 0, PoolEvictOldest)
+
+	for _, tx := range []struct {
+		tx       string
+		priority int
+	}{
+		{"bulk1", 0},
+		{"urgent1", 10},
+		{"bulk2", 0},
+		{"low", -1},
+		{"urgent2", 10},
+	} {
+		if _, err := pool.add([][]byte{[]byte(tx.tx)}, tx.priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// low was evicted for urgent2, then the urgent ones leave first
+	batch := pool.take(2, MaxEventsPayloadSize)
+	expected := [][]byte{[]byte("urgent1"), []byte("urgent2")}
+	if !reflect.DeepEqual(expected, pooledTxs(batch)) {
+		t.Fatalf("expected %q, got %q", expected, pooledTxs(batch))
+	}
+
+	// a batch put back takes its place again
+	if _, err := pool.add([][]byte{[]byte("medium")}, 5); err != nil {
+		t.Fatal(err)
+	}
+	pool.putBack(batch)
+	expected = [][]byte{[]byte("urgent1"), []byte("urgent2"), []byte("medium"), []byte("bulk1"), []byte("bulk2")}
+	if got := pooledTxs(pool.take(0, MaxEventsPayloadSize)); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// The statuses of a transaction
+const (
+	// TxPending transactions are in the pool, or in events which have not
+	// reached consensus yet
+	TxPending = "pending"
+	// TxCommitted transactions are in a block
+	TxCommitted = "committed"
+)
+
+// TxStatus is the status of a transaction, with its location once it is
+// committed
+type TxStatus struct {
+	Hash   string
+	Status string
+	// Block and Position locate the committed transaction in
+	// Block.Transactions, -1 while it is pending
+	Block    int64
+	Position int
+}
+
+// indexTransactions indexes the transactions of the committed block by
+// hash, if the store supports it
+func (n *Node) indexTransactions(block poset.Block) {
+	store, ok := n.core.poset.Store.(poset.TxIndexStore)
+	if !ok || len(block.Transactions()) == 0 {
+		return
+	}
+	if err := store.IndexTransactions(block); err != nil {
+		n.logger.WithError(err).WithFields(logrus.Fields{
+			"block": block.Index(),
+		}).Error("Indexing transactions")
+	}
+}
+
+// GetTxStatus returns the status of the transaction of the hash, a
+// KeyNotFound error if the node does not know it
+func (n *Node) GetTxStatus(hash string) (TxStatus, error) {
+	if store, ok := n.core.poset.Store.(poset.TxIndexStore); ok {
+		loc, err := store.GetTxLocation(hash)
+		if err == nil {
+			return TxStatus{Hash: hash, Status: TxCommitted, Block: loc.Block, Position: loc.Position}, nil
+		}
+		if !common.Is(err, common.KeyNotFound) {
+			return TxStatus{}, err
+		}
+	}
+
+	var pending bool
+	n.withCoreLock(func() {
+		pending = n.core.HasPendingTx(hash)
+	})
+	if pending {
+		return TxStatus{Hash: hash, Status: TxPending, Block: -1, Position: -1}, nil
+	}
+	return TxStatus{}, common.NewStoreErr("Tx", common.KeyNotFound, hash)
+}
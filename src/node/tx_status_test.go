@@ -0,0 +1,44 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestGetTxStatus(t *testing.T) {
+	data := InitTestData(t, 2, 2)
+	trans := createTransport(t, data.Logger, data.BackConfig, data.Adds[0],
+		data.PoolSize, data.CreateFu, data.Network.CreateListener)
+	defer transportClose(t, trans)
+
+	node := createNode(t, data.Logger, data.Config, data.PeersSlice[0].ID, data.Keys[0], data.Peers, trans, data.Adds[0], false)
+	defer node.Shutdown()
+
+	tx := []byte("alice pays bob 10")
+	hash := poset.TxHash(tx)
+	if _, err := node.GetTxStatus(hash); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("expected an unknown transaction, got %v", err)
+	}
+
+	if err := node.SubmitTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	status, err := node.GetTxStatus(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != TxPending || status.Block != -1 {
+		t.Fatalf("expected a pending transaction, got %+v", status)
+	}
+
+	node.indexTransactions(poset.NewBlock(3, 1, []byte("framehash"), [][]byte{[]byte("other"), tx}))
+	status, err = node.GetTxStatus(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != TxCommitted || status.Block != 3 || status.Position != 1 {
+		t.Fatalf("expected a transaction committed in block 3, got %+v", status)
+	}
+}
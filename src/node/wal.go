@@ -0,0 +1,336 @@
+package node
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/journal"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+const (
+	// walCheckpointSize is the size past which the journal is checkpointed
+	walCheckpointSize = 16 << 20
+	// walCheckpointInterval is the time between the checks of the size of
+	// the journal
+	walCheckpointInterval = 10 * time.Second
+)
+
+// Kinds of the records of the journal
+const (
+	walEvent byte = iota + 1
+	walTxs
+)
+
+// eventWAL is the write-ahead journal of the events the node creates and of
+// the transactions submitted to its pool. The events are journaled before
+// they are inserted and gossiped, so that a node which crashes before its
+// store is flushed inserts them again on restart, instead of creating a
+// second event at the same height and forking its own chain.
+//
+// Its records are those of package journal, whose payload is the kind of the
+// record followed by the protobuf of an event, or by a varint priority and
+// uvarint-prefixed transactions. A record cut short by a crash fails its
+// checksum and ends the replay.
+type eventWAL struct {
+	lock sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+// walTx are transactions submitted to the pool at once
+type walTx struct {
+	priority int
+	txs      [][]byte
+}
+
+// openEventWAL opens the journal at path for appending
+func openEventWAL(path string) (*eventWAL, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &eventWAL{path: path, file: file, size: info.Size()}, nil
+}
+
+// appendEvent journals the event and syncs the journal
+func (w *eventWAL) appendEvent(event poset.Event) error {
+	data, err := event.ProtoMarshal()
+	if err != nil {
+		return err
+	}
+	record, err := journal.AppendRecord(nil, append([]byte{walEvent}, data...))
+	if err != nil {
+		return err
+	}
+	return w.write(record)
+}
+
+// appendTxs journals the transactions and syncs the journal
+func (w *eventWAL) appendTxs(txs [][]byte, priority int) error {
+	records, err := walTxsRecords(nil, txs, priority)
+	if err != nil {
+		return err
+	}
+	return w.write(records)
+}
+
+func (w *eventWAL) write(record []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, err := w.file.Write(record); err != nil {
+		return err
+	}
+	w.size += int64(len(record))
+	return w.file.Sync()
+}
+
+// full tells whether the journal outgrew walCheckpointSize
+func (w *eventWAL) full() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.size > walCheckpointSize
+}
+
+// checkpoint replaces the journal by one of the transactions of the pool.
+// The events it drops must be in the store, flushed.
+func (w *eventWAL) checkpoint(pool []pooledTx) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	tmp := w.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	for _, t := range pool {
+		if buf, err = walTxsRecords(buf, [][]byte{t.tx}, t.priority); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if _, err := file.Write(buf); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return err
+	}
+
+	next, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	w.file.Close()
+	w.file = next
+	w.size = int64(len(buf))
+	return nil
+}
+
+func (w *eventWAL) close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.file.Close()
+}
+
+// walTxsRecords appends the records of the transactions to buf, split over
+// several records if they do not fit in one
+func walTxsRecords(buf []byte, txs [][]byte, priority int) ([]byte, error) {
+	var n [binary.MaxVarintLen64]byte
+	header := append([]byte{walTxs}, n[:binary.PutVarint(n[:], int64(priority))]...)
+	payload := append([]byte(nil), header...)
+	var err error
+	for _, tx := range txs {
+		if len(payload) > len(header) && len(payload)+len(tx)+binary.MaxVarintLen64 > journal.MaxRecordSize {
+			if buf, err = journal.AppendRecord(buf, payload); err != nil {
+				return buf, err
+			}
+			payload = append([]byte(nil), header...)
+		}
+		payload = journal.AppendBytes(payload, tx)
+	}
+	return journal.AppendRecord(buf, payload)
+}
+
+// readEventWAL reads the events and the transactions journaled at path, in
+// their order, up to the first torn record
+func readEventWAL(path string) ([]poset.Event, []walTx, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var (
+		events []poset.Event
+		txs    []walTx
+	)
+	r := bufio.NewReader(f)
+	for {
+		payload, err := journal.ReadRecord(r)
+		if err != nil || len(payload) == 0 {
+			break
+		}
+
+		switch payload[0] {
+		case walEvent:
+			var event poset.Event
+			if err := event.ProtoUnmarshal(payload[1:]); err != nil {
+				return nil, nil, err
+			}
+			events = append(events, event)
+		case walTxs:
+			priority, size := binary.Varint(payload[1:])
+			if size <= 0 {
+				return nil, nil, journal.ErrCorrupt
+			}
+			t := walTx{priority: int(priority)}
+			rest := payload[1+size:]
+			for len(rest) > 0 {
+				tx, next, err := journal.ReadBytes(rest)
+				if err != nil {
+					return nil, nil, err
+				}
+				t.txs = append(t.txs, tx)
+				rest = next
+			}
+			txs = append(txs, t)
+		default:
+			return nil, nil, fmt.Errorf("unknown journal record %d", payload[0])
+		}
+	}
+	return events, txs, nil
+}
+
+// ReplayWAL inserts the events journaled at path which are missing from the
+// store, and puts the journaled transactions which are not in an event or a
+// block back in the pool. It then journals the next events and transactions
+// at path. It returns the number of events inserted and of transactions
+// pooled.
+func (c *Core) ReplayWAL(path string) (int, int, error) {
+	if c.observer {
+		return 0, 0, nil
+	}
+	events, txs, err := readEventWAL(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading journal %s: %v", path, err)
+	}
+
+	inserted := 0
+	inEvents := make(map[string]bool)
+	for _, event := range events {
+		for _, tx := range event.Transactions() {
+			inEvents[poset.TxHash(tx)] = true
+		}
+		if _, err := c.poset.Store.GetEventBlock(event.Hash()); err == nil {
+			continue
+		}
+		if err := c.InsertEvent(event, true); err != nil {
+			// its other parent was lost too, the node is bound to fork
+			c.logger.WithError(err).WithField("index", event.Index()).Error("Replaying a journaled event")
+			break
+		}
+		inserted++
+	}
+
+	index, _ := c.poset.Store.(poset.TxIndexStore)
+	pooled := 0
+	for _, t := range txs {
+		var fresh [][]byte
+		for _, tx := range t.txs {
+			hash := poset.TxHash(tx)
+			// the journal may predate a lower max-event-payload
+			if inEvents[hash] || len(tx) > c.maxEventPayload {
+				continue
+			}
+			if index != nil {
+				if _, err := index.GetTxLocation(hash); err == nil {
+					continue
+				}
+			}
+			fresh = append(fresh, tx)
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		if _, err := c.txPool.add(fresh, t.priority); err != nil {
+			c.logger.WithError(err).WithField("transactions", len(fresh)).Warn("Replaying journaled transactions")
+			continue
+		}
+		pooled += len(fresh)
+	}
+
+	wal, err := openEventWAL(path)
+	if err != nil {
+		return inserted, pooled, err
+	}
+	c.wal = wal
+	return inserted, pooled, c.CheckpointWAL()
+}
+
+// CheckpointWAL flushes the store and replaces the journal by the
+// transactions of the pool, the events being in the store
+func (c *Core) CheckpointWAL() error {
+	if c.wal == nil {
+		return nil
+	}
+	c.addSelfEventBlockLocker.Lock()
+	defer c.addSelfEventBlockLocker.Unlock()
+	if flusher, ok := c.poset.Store.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	return c.wal.checkpoint(c.txPool.list())
+}
+
+// CloseWAL checkpoints then closes the journal
+func (c *Core) CloseWAL() error {
+	if c.wal == nil {
+		return nil
+	}
+	err := c.CheckpointWAL()
+	if cerr := c.wal.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// doWALCheckpoint checkpoints the journal once it outgrows
+// walCheckpointSize, until the node shuts down
+func (n *Node) doWALCheckpoint() {
+	for {
+		select {
+		case <-n.clock.After(walCheckpointInterval):
+			if !n.core.wal.full() {
+				continue
+			}
+			if err := n.core.CheckpointWAL(); err != nil {
+				n.logger.WithError(err).Error("Checkpointing the event journal")
+			}
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestEventWALTornRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.wal")
+
+	wal, err := openEventWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.appendTxs([][]byte{[]byte("a"), []byte("b")}, -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.appendTxs([][]byte{[]byte("c")}, 2); err != nil {
+		t.Fatal(err)
+	}
+	// a crash in the middle of a record
+	record, err := walTxsRecords(nil, [][]byte{[]byte("d")}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wal.file.Write(record[:6])
+	wal.close()
+
+	events, txs, err := readEventWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 || len(txs) != 2 {
+		t.Fatalf("expected 2 transaction records, got %d events and %d records", len(events), len(txs))
+	}
+	if txs[0].priority != -1 || len(txs[0].txs) != 2 || string(txs[0].txs[1]) != "b" {
+		t.Fatalf("unexpected first record %v", txs[0])
+	}
+	if txs[1].priority != 2 || string(txs[1].txs[0]) != "c" {
+		t.Fatalf("unexpected second record %v", txs[1])
+	}
+}
+
+func TestReplayWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.wal")
+
+	cores, keys, _ := initCores(1, t)
+	core := cores[0]
+	first, err := core.GetEventBlock(core.head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := core.ReplayWAL(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := core.AddTransactions([][]byte{[]byte("in event")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.AddSelfEventBlock(poset.EventHash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.AddTransactions([][]byte{[]byte("pooled")}); err != nil {
+		t.Fatal(err)
+	}
+	head := core.head
+
+	// the node crashes before its store got the last event
+	participants := core.participants
+	restarted := NewCore(core.id, keys[participants.ToPeerSlice()[0].ID],
+		participants, poset.NewInmemStore(participants, 1000, nil), nil,
+		common.NewTestLogger(t))
+	if err := restarted.InsertEvent(first, true); err != nil {
+		t.Fatal(err)
+	}
+
+	events, txs, err := restarted.ReplayWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.CloseWAL()
+	if events != 1 || txs != 1 {
+		t.Fatalf("expected 1 event and 1 transaction replayed, got %d and %d", events, txs)
+	}
+	if err := restarted.SetHeadAndHeight(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.head != head {
+		t.Fatalf("expected the head %v, got %v", head, restarted.head)
+	}
+	if !restarted.HasPendingTx(poset.TxHash([]byte("pooled"))) {
+		t.Fatal("expected the pooled transaction back in the pool")
+	}
+
+	// the checkpoint left only the pool in the journal
+	walEvents, walTxs, err := readEventWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(walEvents) != 0 || len(walTxs) != 1 {
+		t.Fatalf("expected the pool only in the journal, got %d events and %d records", len(walEvents), len(walTxs))
+	}
+}
@@ -30,6 +30,12 @@ type SyncClient interface {
 		req *ForceSyncRequest, resp *ForceSyncResponse) error
 	FastForward(ctx context.Context,
 		req *FastForwardRequest, resp *FastForwardResponse) error
+	Ancestors(ctx context.Context,
+		req *AncestorsRequest, resp *AncestorsResponse) error
+	StateChunk(ctx context.Context,
+		req *StateChunkRequest, resp *StateChunkResponse) error
+	TxGossip(ctx context.Context,
+		req *TxGossipRequest, resp *TxGossipResponse) error
 	Close() error
 }
 
@@ -73,6 +79,24 @@ func (c *Client) FastForward(ctx context.Context,
 	return c.call(ctx, MethodFastForward, req, resp, nil)
 }
 
+// Ancestors sends a missing self-ancestors request.
+func (c *Client) Ancestors(ctx context.Context,
+	req *AncestorsRequest, resp *AncestorsResponse) error {
+	return c.call(ctx, MethodAncestors, req, resp, nil)
+}
+
+// StateChunk sends an application snapshot chunk request.
+func (c *Client) StateChunk(ctx context.Context,
+	req *StateChunkRequest, resp *StateChunkResponse) error {
+	return c.call(ctx, MethodStateChunk, req, resp, nil)
+}
+
+// TxGossip sends a pending transaction gossip request.
+func (c *Client) TxGossip(ctx context.Context,
+	req *TxGossipRequest, resp *TxGossipResponse) error {
+	return c.call(ctx, MethodTxGossip, req, resp, nil)
+}
+
 // Close closes a sync client.
 func (c *Client) Close() error {
 	return c.connect.Close()
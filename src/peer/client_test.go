@@ -35,6 +35,27 @@ var (
 	}
 	expEagerSyncResponse  = &peer.ForceSyncResponse{FromID: 1, Success: true}
 	expFastForwardRequest = &peer.FastForwardRequest{FromID: 0}
+	expAncestorsRequest   = &peer.AncestorsRequest{
+		FromID:    0,
+		CreatorID: 9,
+		Head:      []byte("head"),
+		Index:     1,
+	}
+	expAncestorsResponse = &peer.AncestorsResponse{
+		FromID: 1,
+		Events: []poset.WireEvent{
+			{
+				Body: poset.WireBody{
+					Transactions:         [][]byte(nil),
+					SelfParentIndex:      0,
+					OtherParentCreatorID: 10,
+					OtherParentIndex:     0,
+					CreatorID:            9,
+					Index:                1,
+				},
+			},
+		},
+	}
 	expSyncRequest        = &peer.SyncRequest{
 		FromID: 0,
 		Known:  map[uint64]int64{0: 1, 1: 2, 2: 3},
@@ -55,6 +76,23 @@ var (
 		},
 		Known: map[uint64]int64{0: 5, 1: 5, 2: 6},
 	}
+	expStateChunkRequest = &peer.StateChunkRequest{
+		FromID:    0,
+		StateHash: []byte("statehash"),
+		Index:     2,
+	}
+	expStateChunkResponse = &peer.StateChunkResponse{
+		FromID: 1,
+		Chunk:  []byte("chunk"),
+	}
+	expTxGossipRequest = &peer.TxGossipRequest{
+		FromID: 0,
+		Txs:    [][]byte{[]byte("tx1"), []byte("tx2")},
+	}
+	expTxGossipResponse = &peer.TxGossipResponse{
+		FromID:   1,
+		Accepted: 2,
+	}
 	testError = errors.New("error")
 )
 
@@ -182,6 +220,93 @@ func TestClientFastForward(t *testing.T) {
 	checkFastForwardResponse(t, expResponse, resp)
 }
 
+func TestClientAncestors(t *testing.T) {
+	ctx := context.Background()
+	m := newRPCClient(t, testError, expAncestorsResponse)
+	cli := newClient(t, m)
+	defer func() {
+		if err := cli.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	resp := &peer.AncestorsResponse{}
+	if err := cli.Ancestors(
+		ctx, expAncestorsRequest, resp); err != testError {
+		t.Fatalf("expected error: %s, got: %s", testError, err)
+	}
+
+	m.err = nil
+
+	if err := cli.Ancestors(
+		ctx, expAncestorsRequest, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resp, expAncestorsResponse) {
+		t.Fatalf("failed to get response, expected: %+v, got: %+v",
+			expAncestorsResponse, resp)
+	}
+}
+
+func TestClientStateChunk(t *testing.T) {
+	ctx := context.Background()
+	m := newRPCClient(t, testError, expStateChunkResponse)
+	cli := newClient(t, m)
+	defer func() {
+		if err := cli.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	resp := &peer.StateChunkResponse{}
+	if err := cli.StateChunk(
+		ctx, expStateChunkRequest, resp); err != testError {
+		t.Fatalf("expected error: %s, got: %s", testError, err)
+	}
+
+	m.err = nil
+
+	if err := cli.StateChunk(
+		ctx, expStateChunkRequest, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resp, expStateChunkResponse) {
+		t.Fatalf("failed to get response, expected: %+v, got: %+v",
+			expStateChunkResponse, resp)
+	}
+}
+
+func TestClientTxGossip(t *testing.T) {
+	ctx := context.Background()
+	m := newRPCClient(t, testError, expTxGossipResponse)
+	cli := newClient(t, m)
+	defer func() {
+		if err := cli.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	resp := &peer.TxGossipResponse{}
+	if err := cli.TxGossip(
+		ctx, expTxGossipRequest, resp); err != testError {
+		t.Fatalf("expected error: %s, got: %s", testError, err)
+	}
+
+	m.err = nil
+
+	if err := cli.TxGossip(
+		ctx, expTxGossipRequest, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resp, expTxGossipResponse) {
+		t.Fatalf("failed to get response, expected: %+v, got: %+v",
+			expTxGossipResponse, resp)
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	timeout := time.Second
 	conf := &peer.BackendConfig{
@@ -6,24 +6,30 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// SyncRequest initiates a synchronization request.
+// SyncRequest initiates a synchronization request. A requester which sets
+// Limit takes the events in chunks of at most Limit events.
 type SyncRequest struct {
-	FromID uint64
-	Known  map[uint64]int64
+	FromID  uint64
+	Known   map[uint64]int64
+	Genesis []byte
+	Limit   int64
 }
 
-// SyncResponse is a response to a SyncRequest request.
+// SyncResponse is a response to a SyncRequest request. More tells that the
+// Events are the first chunk of a larger diff.
 type SyncResponse struct {
 	FromID    uint64
 	SyncLimit bool
 	Events    []poset.WireEvent
 	Known     map[uint64]int64
+	More      bool
 }
 
 // ForceSyncRequest after an initial sync to quickly catch up.
 type ForceSyncRequest struct {
-	FromID uint64
-	Events []poset.WireEvent
+	FromID  uint64
+	Events  []poset.WireEvent
+	Genesis []byte
 }
 
 // ForceSyncResponse response to an ForceSyncRequest.
@@ -32,18 +38,68 @@ type ForceSyncResponse struct {
 	Success bool
 }
 
-// FastForwardRequest request to start a fast forward catch up.
+// FastForwardRequest request to start a fast forward catch up. Chunked
+// requesters fetch the application snapshot with StateChunkRequests.
 type FastForwardRequest struct {
-	FromID uint64
+	FromID  uint64
+	Genesis []byte
+	Chunked bool
 }
 
 // FastForwardResponse response with the snapshot data for fast forward
-// request.
+// request. Chunked requests get the Manifest of the snapshot instead of the
+// Snapshot, unless the store of the responder does not keep snapshots.
 type FastForwardResponse struct {
 	FromID   uint64
 	Block    poset.Block
 	Frame    poset.Frame
 	Snapshot []byte
+	Manifest *poset.StateSnapshot
+}
+
+// StateChunkRequest asks for a chunk of the application snapshot with the
+// given state hash.
+type StateChunkRequest struct {
+	FromID    uint64
+	Genesis   []byte
+	StateHash []byte
+	Index     int
+}
+
+// StateChunkResponse response with a chunk of an application snapshot.
+type StateChunkResponse struct {
+	FromID uint64
+	Chunk  []byte
+}
+
+// AncestorsRequest asks for the self-ancestors of an event that are missing
+// after the last event of that creator the requester knows about (Head), up
+// to and including Index.
+type AncestorsRequest struct {
+	FromID    uint64
+	CreatorID uint64
+	Head      []byte
+	Index     int64
+}
+
+// AncestorsResponse response with the requested self-ancestors, oldest first.
+type AncestorsResponse struct {
+	FromID uint64
+	Events []poset.WireEvent
+}
+
+// TxGossipRequest pushes the transactions submitted to the requester to
+// the pool of another node.
+type TxGossipRequest struct {
+	FromID  uint64
+	Genesis []byte
+	Txs     [][]byte
+}
+
+// TxGossipResponse response to a TxGossipRequest.
+type TxGossipResponse struct {
+	FromID   uint64
+	Accepted int
 }
 
 // RPCResponse captures both a response and a potential error.
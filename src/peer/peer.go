@@ -18,6 +18,12 @@ type SyncPeer interface {
 		req *ForceSyncRequest, resp *ForceSyncResponse) error
 	FastForward(ctx context.Context, target string,
 		req *FastForwardRequest, resp *FastForwardResponse) error
+	Ancestors(ctx context.Context, target string,
+		req *AncestorsRequest, resp *AncestorsResponse) error
+	StateChunk(ctx context.Context, target string,
+		req *StateChunkRequest, resp *StateChunkResponse) error
+	TxGossip(ctx context.Context, target string,
+		req *TxGossipRequest, resp *TxGossipResponse) error
 	ReceiverChannel() <-chan *RPC
 	Close() error
 }
@@ -146,6 +152,106 @@ func (tr *Peer) fastForward(ctx context.Context, target string,
 	return nil
 }
 
+// Ancestors requests missing self-ancestors from a specific node.
+func (tr *Peer) Ancestors(ctx context.Context, target string,
+	req *AncestorsRequest, resp *AncestorsResponse) error {
+	if tr.isShutdown() {
+		return ErrTransportStopped
+	}
+
+	tr.wg.Add(1)
+	defer tr.wg.Done()
+
+	return tr.ancestors(ctx, target, req, resp)
+}
+
+func (tr *Peer) ancestors(ctx context.Context, target string,
+	req *AncestorsRequest, resp *AncestorsResponse) error {
+	logger := tr.logger.WithFields(logrus.Fields{"method": "ancestors",
+		"target": target})
+
+	cli, err := tr.clientProducer.Pop(target)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if err := cli.Ancestors(ctx, req, resp); err != nil {
+		logger.Error(err)
+		return err
+	}
+	tr.clientProducer.Push(target, cli)
+
+	return nil
+}
+
+// StateChunk requests a chunk of an application snapshot from a specific
+// node.
+func (tr *Peer) StateChunk(ctx context.Context, target string,
+	req *StateChunkRequest, resp *StateChunkResponse) error {
+	if tr.isShutdown() {
+		return ErrTransportStopped
+	}
+
+	tr.wg.Add(1)
+	defer tr.wg.Done()
+
+	return tr.stateChunk(ctx, target, req, resp)
+}
+
+func (tr *Peer) stateChunk(ctx context.Context, target string,
+	req *StateChunkRequest, resp *StateChunkResponse) error {
+	logger := tr.logger.WithFields(logrus.Fields{"method": "stateChunk",
+		"target": target})
+
+	cli, err := tr.clientProducer.Pop(target)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if err := cli.StateChunk(ctx, req, resp); err != nil {
+		logger.Error(err)
+		return err
+	}
+	tr.clientProducer.Push(target, cli)
+
+	return nil
+}
+
+// TxGossip pushes pending transactions to a specific node.
+func (tr *Peer) TxGossip(ctx context.Context, target string,
+	req *TxGossipRequest, resp *TxGossipResponse) error {
+	if tr.isShutdown() {
+		return ErrTransportStopped
+	}
+
+	tr.wg.Add(1)
+	defer tr.wg.Done()
+
+	return tr.txGossip(ctx, target, req, resp)
+}
+
+func (tr *Peer) txGossip(ctx context.Context, target string,
+	req *TxGossipRequest, resp *TxGossipResponse) error {
+	logger := tr.logger.WithFields(logrus.Fields{"method": "txGossip",
+		"target": target})
+
+	cli, err := tr.clientProducer.Pop(target)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if err := cli.TxGossip(ctx, req, resp); err != nil {
+		logger.Error(err)
+		return err
+	}
+	tr.clientProducer.Push(target, cli)
+
+	return nil
+}
+
 // ReceiverChannel returns a sync server receiver channel.
 func (tr *Peer) ReceiverChannel() <-chan *RPC {
 	tr.mtx.Lock()
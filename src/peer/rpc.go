@@ -9,6 +9,9 @@ const (
 	MethodSync        = "Lachesis.Sync"
 	MethodForceSync   = "Lachesis.ForceSync"
 	MethodFastForward = "Lachesis.FastForward"
+	MethodAncestors   = "Lachesis.Ancestors"
+	MethodStateChunk  = "Lachesis.StateChunk"
+	MethodTxGossip    = "Lachesis.TxGossip"
 )
 
 // Lachesis implements Lachesis synchronization methods.
@@ -78,6 +81,54 @@ func (r *Lachesis) FastForward(
 	return nil
 }
 
+// Ancestors handles missing self-ancestors requests.
+func (r *Lachesis) Ancestors(
+	req *AncestorsRequest, resp *AncestorsResponse) error {
+	result, err := r.process(req)
+	if err != nil {
+		return err
+	}
+
+	item, ok := result.(*AncestorsResponse)
+	if !ok {
+		return ErrBadResult
+	}
+	*resp = *item
+	return nil
+}
+
+// StateChunk handles application snapshot chunk requests.
+func (r *Lachesis) StateChunk(
+	req *StateChunkRequest, resp *StateChunkResponse) error {
+	result, err := r.process(req)
+	if err != nil {
+		return err
+	}
+
+	item, ok := result.(*StateChunkResponse)
+	if !ok {
+		return ErrBadResult
+	}
+	*resp = *item
+	return nil
+}
+
+// TxGossip handles pending transaction gossip requests.
+func (r *Lachesis) TxGossip(
+	req *TxGossipRequest, resp *TxGossipResponse) error {
+	result, err := r.process(req)
+	if err != nil {
+		return err
+	}
+
+	item, ok := result.(*TxGossipResponse)
+	if !ok {
+		return ErrBadResult
+	}
+	*resp = *item
+	return nil
+}
+
 func (r *Lachesis) send(req interface{}) *RPCResponse {
 	reply := make(chan *RPCResponse, 1) // Buffered.
 	ticket := &RPC{
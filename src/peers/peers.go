@@ -30,6 +30,8 @@ type Peers struct {
 	ByAddress AddressPeers
 	ByNetAddr NetAddrPeers
 	Listeners []Listener
+	// RemovedListeners are notified of the peers which leave
+	RemovedListeners []Listener
 }
 
 /* Constructors */
@@ -102,9 +104,8 @@ func (p *Peers) internalSort() {
 // RemovePeer removes a peer from the peers struct
 func (p *Peers) RemovePeer(peer *Peer) {
 	p.Lock()
-	defer p.Unlock()
-
 	if _, ok := p.ByPubKey[peer.PubKeyHex]; !ok {
+		p.Unlock()
 		return
 	}
 
@@ -114,6 +115,8 @@ func (p *Peers) RemovePeer(peer *Peer) {
 	delete(p.ByNetAddr, peer.NetAddr)
 
 	p.internalSort()
+	p.Unlock()
+	p.EmitRemovedPeer(peer)
 }
 
 // RemovePeerByPubKey removes a peer by their public key
@@ -187,6 +190,18 @@ func (p *Peers) EmitNewPeer(peer *Peer) {
 	}
 }
 
+// OnRemovedPeer registers a listener of the peers which leave
+func (p *Peers) OnRemovedPeer(cb func(*Peer)) {
+	p.RemovedListeners = append(p.RemovedListeners, cb)
+}
+
+// EmitRemovedPeer emits an event for all listeners as soon as a peer leaves
+func (p *Peers) EmitRemovedPeer(peer *Peer) {
+	for _, listener := range p.RemovedListeners {
+		listener(peer)
+	}
+}
+
 /* Utilities */
 
 // Len returns the length of peers
@@ -201,28 +216,40 @@ func (p *Peers) ReadByPubKey(key string) (Peer, bool) {
 	p.RLock()
 	defer p.RUnlock()
 	peer, ok := p.ByPubKey[key]
-	return *peer, ok
+	if !ok {
+		return Peer{}, false
+	}
+	return *peer, true
 }
 
 func (p *Peers) ReadByID(key uint64) (Peer, bool) {
 	p.RLock()
 	defer p.RUnlock()
 	peer, ok := p.ByID[key]
-	return *peer, ok
+	if !ok {
+		return Peer{}, false
+	}
+	return *peer, true
 }
 
 func (p *Peers) ReadByAddress(key common.Address) (Peer, bool) {
 	p.RLock()
 	defer p.RUnlock()
 	peer, ok := p.ByAddress[key]
-	return *peer, ok
+	if !ok {
+		return Peer{}, false
+	}
+	return *peer, true
 }
 
 func (p *Peers) ReadByNetAddr(key string) (Peer, bool) {
 	p.RLock()
 	defer p.RUnlock()
 	peer, ok := p.ByNetAddr[key]
-	return *peer, ok
+	if !ok {
+		return Peer{}, false
+	}
+	return *peer, true
 }
 
 func (p *Peers) SetHeightByPubKeyHex(key string, height int64) {
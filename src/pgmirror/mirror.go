@@ -0,0 +1,179 @@
+// Package pgmirror copies finalized blocks, their events and transactions to
+// PostgreSQL, so that explorers and BI tools can query them with SQL. Writes
+// are asynchronous and never hold consensus back: when PostgreSQL lags too
+// much, blocks are dropped from the mirror, not from the node.
+package pgmirror
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// DefaultQueueSize is the number of blocks waiting to be mirrored above which
+// new ones are dropped
+const DefaultQueueSize = 1000
+
+type job struct {
+	block poset.Block
+	frame *poset.Frame // nil when only the signatures changed
+}
+
+// sink writes the rows of the mirrored blocks: PostgreSQL, see New
+type sink interface {
+	// writeBlock writes the block, or only its signatures if it was written
+	// already, with the events and transactions which were not, all of them
+	// or none
+	writeBlock(block blockRow, events []eventRow, txs []txRow) error
+	close() error
+}
+
+// blockRow, eventRow and txRow are the rows of the tables of the schema
+type blockRow struct {
+	index         int64
+	roundReceived int64
+	hash          []byte
+	frameHash     []byte
+	eventsRoot    []byte
+	createdTime   int64
+	txCount       int
+	signatures    int
+}
+
+type eventRow struct {
+	hash             []byte
+	position         int
+	creator          []byte
+	creatorIndex     int64
+	topologicalIndex int64
+	txCount          int
+}
+
+type txRow struct {
+	position  int
+	eventHash []byte
+	data      []byte
+}
+
+// Mirror writes blocks to PostgreSQL in the background
+type Mirror struct {
+	sink   sink
+	queue  chan job
+	logger *logrus.Entry
+	wg     sync.WaitGroup
+}
+
+// New connects to PostgreSQL, creates the tables if needed and starts the
+// background writer
+func New(dsn string, queueSize int, logger *logrus.Logger) (*Mirror, error) {
+	s, err := newPostgresSink(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return newMirror(s, queueSize, logger), nil
+}
+
+func newMirror(s sink, queueSize int, logger *logrus.Logger) *Mirror {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	m := &Mirror{
+		sink:   s,
+		queue:  make(chan job, queueSize),
+		logger: logger.WithField("component", "pgmirror"),
+	}
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// Enqueue schedules the block, and the events of its frame if given, to be
+// written. It does not block.
+func (m *Mirror) Enqueue(block poset.Block, frame *poset.Frame) {
+	// the poset adds the next signatures to the map of the block it saved
+	signatures := make(map[string]string, len(block.Signatures))
+	for validator, sig := range block.Signatures {
+		signatures[validator] = sig
+	}
+	block.Signatures = signatures
+
+	select {
+	case m.queue <- job{block, frame}:
+	default:
+		m.logger.WithField("block", block.Index()).Warn("Mirror queue full, dropping block")
+	}
+}
+
+// Close writes the queued blocks and disconnects
+func (m *Mirror) Close() error {
+	close(m.queue)
+	m.wg.Wait()
+	return m.sink.close()
+}
+
+func (m *Mirror) run() {
+	defer m.wg.Done()
+	for j := range m.queue {
+		if err := m.write(j); err != nil {
+			m.logger.WithError(err).WithField("block", j.block.Index()).Error("Mirroring block")
+		}
+	}
+}
+
+func (m *Mirror) write(j job) error {
+	b := j.block
+	hash, err := b.Body.Hash()
+	if err != nil {
+		return err
+	}
+	block := blockRow{
+		index:         b.Index(),
+		roundReceived: b.RoundReceived(),
+		hash:          hash,
+		frameHash:     b.FrameHash,
+		eventsRoot:    b.Body.EventsRoot,
+		createdTime:   b.CreatedTime,
+		txCount:       len(b.Transactions()),
+		signatures:    len(b.Signatures),
+	}
+
+	var events []eventRow
+	var txs []txRow
+	if j.frame != nil {
+		// the transactions of the block are those of the events in order,
+		// but the copies SetDedupBlockTransactions dropped: the positions
+		// are those of the block the store saved, like the TxIndex ones
+		blockTxs := b.Transactions()
+		position := 0
+		for i, e := range j.frame.Events {
+			eventHash, err := e.Body.Hash()
+			if err != nil {
+				return err
+			}
+			events = append(events, eventRow{
+				hash:             eventHash.Bytes(),
+				position:         i,
+				creator:          e.Body.Creator,
+				creatorIndex:     e.Body.Index,
+				topologicalIndex: e.TopologicalIndex,
+				txCount:          len(e.Body.Transactions),
+			})
+			for _, data := range e.Body.Transactions {
+				if position >= len(blockTxs) || !bytes.Equal(data, blockTxs[position]) {
+					continue
+				}
+				txs = append(txs, txRow{
+					position:  position,
+					eventHash: eventHash.Bytes(),
+					data:      data,
+				})
+				position++
+			}
+		}
+	}
+
+	return m.sink.writeBlock(block, events, txs)
+}
@@ -0,0 +1,148 @@
+package pgmirror
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// fakeSink keeps the rows in memory
+type fakeSink struct {
+	lock   sync.Mutex
+	blocks []blockRow
+	events map[int64][]eventRow
+	txs    map[int64][]txRow
+	closed bool
+
+	// started, if not nil, receives every block index before it is written,
+	// and gate, if not nil, holds the writes back until it is closed
+	started chan int64
+	gate    chan struct{}
+}
+
+func newFakeSink() *fakeSink {
+	return &fakeSink{
+		events: make(map[int64][]eventRow),
+		txs:    make(map[int64][]txRow),
+	}
+}
+
+// writeBlock implements sink
+func (s *fakeSink) writeBlock(b blockRow, events []eventRow, txs []txRow) error {
+	if s.started != nil {
+		s.started <- b.index
+	}
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.blocks = append(s.blocks, b)
+	s.events[b.index] = append(s.events[b.index], events...)
+	s.txs[b.index] = append(s.txs[b.index], txs...)
+	return nil
+}
+
+// close implements sink
+func (s *fakeSink) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSink) blockIndexes() []int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var indexes []int64
+	for _, b := range s.blocks {
+		indexes = append(indexes, b.index)
+	}
+	return indexes
+}
+
+func testFrame(round int64, txs ...[][]byte) poset.Frame {
+	frame := poset.Frame{Round: round}
+	for i, eventTxs := range txs {
+		frame.Events = append(frame.Events, &poset.EventMessage{
+			Body: &poset.EventBody{
+				Transactions: eventTxs,
+				Creator:      []byte("creator"),
+				Index:        int64(i),
+			},
+			TopologicalIndex: int64(i),
+		})
+	}
+	return frame
+}
+
+func TestMirrorPositions(t *testing.T) {
+	sink := newFakeSink()
+	m := newMirror(sink, 0, logrus.New())
+
+	// the second copy of a was dropped from the block
+	frame := testFrame(1,
+		[][]byte{[]byte("a"), []byte("b")},
+		[][]byte{[]byte("a"), []byte("c")})
+	block := poset.NewBlock(0, 1, []byte("framehash"),
+		[][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	m.Enqueue(block, &frame)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.blocks) != 1 || sink.blocks[0].txCount != 3 {
+		t.Fatalf("expected block 0 with 3 transactions, got %+v", sink.blocks)
+	}
+	events := sink.events[0]
+	if len(events) != 2 || events[0].position != 0 || events[1].position != 1 {
+		t.Fatalf("expected the 2 events in order, got %+v", events)
+	}
+	txs := sink.txs[0]
+	expected := []string{"a", "b", "c"}
+	if len(txs) != len(expected) {
+		t.Fatalf("expected %d transactions, got %d", len(expected), len(txs))
+	}
+	for i, tx := range txs {
+		if tx.position != i || string(tx.data) != expected[i] {
+			t.Fatalf("expected %s at position %d, got %s at %d", expected[i], i, tx.data, tx.position)
+		}
+	}
+	if string(txs[2].eventHash) != string(events[1].hash) {
+		t.Fatal("expected c to be mirrored with the second event")
+	}
+}
+
+func TestMirrorQueue(t *testing.T) {
+	sink := newFakeSink()
+	sink.started = make(chan int64, 3)
+	sink.gate = make(chan struct{})
+	m := newMirror(sink, 1, logrus.New())
+
+	block := func(index int64) poset.Block {
+		return poset.NewBlock(index, index+1, []byte("framehash"), nil)
+	}
+	m.Enqueue(block(0), nil)
+	if index := <-sink.started; index != 0 {
+		t.Fatalf("expected block 0 to be written first, got %d", index)
+	}
+	// block 0 is being written, block 1 waits in the queue and block 2
+	// does not fit
+	m.Enqueue(block(1), nil)
+	m.Enqueue(block(2), nil)
+	close(sink.gate)
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	indexes := sink.blockIndexes()
+	if len(indexes) != 2 || indexes[0] != 0 || indexes[1] != 1 {
+		t.Fatalf("expected the queued blocks 0 and 1 to be written, got %v", indexes)
+	}
+	if !sink.closed {
+		t.Fatal("expected Close to close the sink")
+	}
+}
@@ -0,0 +1,99 @@
+package pgmirror
+
+import (
+	"database/sql"
+
+	// registers the "postgres" driver
+	_ "github.com/lib/pq"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS blocks (
+	index          BIGINT PRIMARY KEY,
+	round_received BIGINT NOT NULL,
+	hash           BYTEA NOT NULL,
+	frame_hash     BYTEA,
+	events_root    BYTEA,
+	created_time   BIGINT NOT NULL,
+	tx_count       INTEGER NOT NULL,
+	signatures     INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS events (
+	hash              BYTEA PRIMARY KEY,
+	block_index       BIGINT NOT NULL REFERENCES blocks (index),
+	position          INTEGER NOT NULL,
+	creator           BYTEA NOT NULL,
+	creator_index     BIGINT NOT NULL,
+	topological_index BIGINT NOT NULL,
+	tx_count          INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS transactions (
+	block_index BIGINT NOT NULL REFERENCES blocks (index),
+	position    INTEGER NOT NULL,
+	event_hash  BYTEA NOT NULL,
+	data        BYTEA NOT NULL,
+	PRIMARY KEY (block_index, position)
+);`
+
+// postgresSink writes the rows to PostgreSQL, those of a block in one
+// transaction
+type postgresSink struct {
+	db *sql.DB
+}
+
+// newPostgresSink connects to PostgreSQL and creates the tables if needed
+func newPostgresSink(dsn string) (*postgresSink, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &postgresSink{db: db}, nil
+}
+
+// writeBlock implements sink
+func (s *postgresSink) writeBlock(b blockRow, events []eventRow, txs []txRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO blocks
+		(index, round_received, hash, frame_hash, events_root, created_time, tx_count, signatures)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (index) DO UPDATE SET signatures = EXCLUDED.signatures`,
+		b.index, b.roundReceived, b.hash, b.frameHash, b.eventsRoot,
+		b.createdTime, b.txCount, b.signatures)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		_, err = tx.Exec(`INSERT INTO events
+			(hash, block_index, position, creator, creator_index, topological_index, tx_count)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING`,
+			e.hash, b.index, e.position, e.creator, e.creatorIndex,
+			e.topologicalIndex, e.txCount)
+		if err != nil {
+			return err
+		}
+	}
+	for _, t := range txs {
+		_, err = tx.Exec(`INSERT INTO transactions
+			(block_index, position, event_hash, data)
+			VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
+			b.index, t.position, t.eventHash, t.data)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// close implements sink
+func (s *postgresSink) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,236 @@
+package pgmirror
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// Store is a poset.Store which also sends the blocks it saves to a Mirror
+type Store struct {
+	poset.Store
+	mirror *Mirror
+
+	lastIndex     int64
+	lastIndexLock sync.Mutex
+}
+
+// Wrap mirrors the blocks saved to store
+func Wrap(store poset.Store, mirror *Mirror) *Store {
+	return &Store{
+		Store:     store,
+		mirror:    mirror,
+		lastIndex: -1,
+	}
+}
+
+// SetBlock saves the block then mirrors it. Blocks are saved again each time
+// they get a signature, only the first time are their events mirrored.
+func (s *Store) SetBlock(block poset.Block) error {
+	if err := s.Store.SetBlock(block); err != nil {
+		return err
+	}
+
+	s.lastIndexLock.Lock()
+	isNew := block.Index() > s.lastIndex
+	if isNew {
+		s.lastIndex = block.Index()
+	}
+	s.lastIndexLock.Unlock()
+
+	var frame *poset.Frame
+	if isNew {
+		f, err := s.Store.GetFrame(block.RoundReceived())
+		if err != nil {
+			s.mirror.logger.WithError(err).WithField("block", block.Index()).Warn("Frame of mirrored block")
+		} else {
+			frame = &f
+		}
+	}
+	s.mirror.Enqueue(block, frame)
+	return nil
+}
+
+// Close stops the mirror, once it has written its queue, then the store
+func (s *Store) Close() error {
+	if err := s.mirror.Close(); err != nil {
+		return err
+	}
+	return s.Store.Close()
+}
+
+// Backup backs up the wrapped store if it supports it
+func (s *Store) Backup(w io.Writer) (int64, error) {
+	store, ok := s.Store.(poset.BackupStore)
+	if !ok {
+		return 0, fmt.Errorf("store does not support backups")
+	}
+	return store.Backup(w)
+}
+
+// DiskUsage returns the size of the wrapped store if it is kept on disk
+func (s *Store) DiskUsage() (int64, error) {
+	store, ok := s.Store.(poset.DiskStore)
+	if !ok {
+		return 0, fmt.Errorf("store is not kept on disk")
+	}
+	return store.DiskUsage()
+}
+
+// SetReceipts stores the receipts in the wrapped store if it supports it
+func (s *Store) SetReceipts(blockIndex int64, receipts []poset.Receipt) error {
+	store, ok := s.Store.(poset.ReceiptStore)
+	if !ok {
+		return fmt.Errorf("store does not keep receipts")
+	}
+	return store.SetReceipts(blockIndex, receipts)
+}
+
+// GetReceipts reads receipts of the wrapped store if it supports it
+func (s *Store) GetReceipts(blockIndex int64) ([]poset.Receipt, error) {
+	store, ok := s.Store.(poset.ReceiptStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not keep receipts")
+	}
+	return store.GetReceipts(blockIndex)
+}
+
+// IndexTransactions indexes the transactions of the block in the wrapped
+// store if it supports it
+func (s *Store) IndexTransactions(block poset.Block) error {
+	store, ok := s.Store.(poset.TxIndexStore)
+	if !ok {
+		return fmt.Errorf("store does not index transactions")
+	}
+	return store.IndexTransactions(block)
+}
+
+// GetTxLocation reads the location of a transaction in the wrapped store.
+// A store which does not index transactions knows none of them.
+func (s *Store) GetTxLocation(hash string) (poset.TxLocation, error) {
+	store, ok := s.Store.(poset.TxIndexStore)
+	if !ok {
+		return poset.TxLocation{}, common.NewStoreErr("TxIndex", common.KeyNotFound, hash)
+	}
+	return store.GetTxLocation(hash)
+}
+
+// SetBlockEvents indexes the events of the block in the wrapped store if it
+// supports it
+func (s *Store) SetBlockEvents(blockIndex int64, events poset.EventHashes) error {
+	store, ok := s.Store.(poset.ExplorerStore)
+	if !ok {
+		return fmt.Errorf("store does not index events for explorers")
+	}
+	return store.SetBlockEvents(blockIndex, events)
+}
+
+// GetBlockEvents reads the events of the block in the wrapped store if it
+// supports it
+func (s *Store) GetBlockEvents(blockIndex int64) (poset.EventHashes, error) {
+	store, ok := s.Store.(poset.ExplorerStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not index events for explorers")
+	}
+	return store.GetBlockEvents(blockIndex)
+}
+
+// CreatorEvents reads the events of the creator in the wrapped store if it
+// supports it
+func (s *Store) CreatorEvents(creator string, from int64, limit int) (poset.EventHashes, error) {
+	store, ok := s.Store.(poset.ExplorerStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not index events for explorers")
+	}
+	return store.CreatorEvents(creator, from, limit)
+}
+
+// SetStateSnapshot stores the snapshot in the wrapped store if it supports it
+func (s *Store) SetStateSnapshot(snapshot poset.StateSnapshot, chunks [][]byte) error {
+	store, ok := s.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return fmt.Errorf("store does not keep state snapshots")
+	}
+	return store.SetStateSnapshot(snapshot, chunks)
+}
+
+// GetStateSnapshot reads a snapshot of the wrapped store if it supports it
+func (s *Store) GetStateSnapshot(stateHash []byte) (poset.StateSnapshot, error) {
+	store, ok := s.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return poset.StateSnapshot{}, fmt.Errorf("store does not keep state snapshots")
+	}
+	return store.GetStateSnapshot(stateHash)
+}
+
+// GetStateSnapshotChunk reads a snapshot chunk of the wrapped store if it
+// supports it
+func (s *Store) GetStateSnapshotChunk(stateHash []byte, index int) ([]byte, error) {
+	store, ok := s.Store.(poset.StateSnapshotStore)
+	if !ok {
+		return nil, fmt.Errorf("store does not keep state snapshots")
+	}
+	return store.GetStateSnapshotChunk(stateHash, index)
+}
+
+// StartBatch starts a batch in the wrapped store if it supports it
+func (s *Store) StartBatch() error {
+	if store, ok := s.Store.(poset.BatchStore); ok {
+		return store.StartBatch()
+	}
+	return nil
+}
+
+// CommitBatch commits a batch of the wrapped store if it supports it
+func (s *Store) CommitBatch() error {
+	if store, ok := s.Store.(poset.BatchStore); ok {
+		return store.CommitBatch()
+	}
+	return nil
+}
+
+// Flush waits for the queued writes of the wrapped store if it supports it
+func (s *Store) Flush() error {
+	if store, ok := s.Store.(interface{ Flush() error }); ok {
+		return store.Flush()
+	}
+	return nil
+}
+
+// Compact compacts the wrapped store if it supports it
+func (s *Store) Compact() error {
+	store, ok := s.Store.(poset.CompactStore)
+	if !ok {
+		return fmt.Errorf("store does not support compaction")
+	}
+	return store.Compact()
+}
+
+// PrunedRound returns the last round pruned from the wrapped store, -1 if it
+// cannot be pruned
+func (s *Store) PrunedRound() (int64, error) {
+	store, ok := s.Store.(poset.PrunedStore)
+	if !ok {
+		return -1, nil
+	}
+	return store.PrunedRound()
+}
+
+// LastStoredBlock reads the last block of the wrapped store if it supports it
+func (s *Store) LastStoredBlock() (poset.Block, error) {
+	store, ok := s.Store.(poset.PrunedStore)
+	if !ok {
+		return poset.Block{}, fmt.Errorf("store cannot be pruned")
+	}
+	return store.LastStoredBlock()
+}
+
+// ResizeCache resizes the LRU caches of the wrapped store if it has some
+func (s *Store) ResizeCache(size int) {
+	if store, ok := s.Store.(poset.CacheResizer); ok {
+		store.ResizeCache(size)
+	}
+}
@@ -0,0 +1,109 @@
+package pgmirror
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func newTestStore(t *testing.T) (*Store, *fakeSink) {
+	sink := newFakeSink()
+	inmem := poset.NewInmemStore(peers.NewPeers(), 100, nil)
+	return Wrap(inmem, newMirror(sink, 0, logrus.New())), sink
+}
+
+func TestStoreMirrorsBlocksOnce(t *testing.T) {
+	store, sink := newTestStore(t)
+
+	var blocks []poset.Block
+	for i := int64(0); i < 2; i++ {
+		frame := testFrame(i+1, [][]byte{[]byte{byte(i)}})
+		if err := store.SetFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+		block, err := poset.NewBlockFromFrame(i, frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	// blocks are saved again each time they get a signature
+	key, _ := crypto.GenerateECDSAKey()
+	sig, err := blocks[0].Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks[0].SetSignature(sig)
+	if err := store.SetBlock(blocks[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	indexes := sink.blockIndexes()
+	if len(indexes) != 3 || indexes[0] != 0 || indexes[1] != 1 || indexes[2] != 0 {
+		t.Fatalf("expected blocks 0, 1 then the signatures of 0, got %v", indexes)
+	}
+	if sink.blocks[2].signatures != 1 {
+		t.Fatalf("expected the signature of block 0 to be mirrored, got %d", sink.blocks[2].signatures)
+	}
+	for i := int64(0); i < 2; i++ {
+		if len(sink.events[i]) != 1 || len(sink.txs[i]) != 1 {
+			t.Fatalf("expected the event and transaction of block %d mirrored once, got %d and %d",
+				i, len(sink.events[i]), len(sink.txs[i]))
+		}
+	}
+}
+
+func TestStoreForwards(t *testing.T) {
+	store, _ := newTestStore(t)
+	defer store.Close()
+
+	// the optional interfaces the node and the service assert are forwarded
+	var (
+		_ poset.TxIndexStore       = store
+		_ poset.ExplorerStore      = store
+		_ poset.ReceiptStore       = store
+		_ poset.StateSnapshotStore = store
+		_ poset.BatchStore         = store
+		_ poset.BackupStore        = store
+		_ poset.CompactStore       = store
+		_ poset.DiskStore          = store
+		_ poset.PrunedStore        = store
+		_ poset.CacheResizer       = store
+	)
+
+	block := poset.NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+	if err := store.IndexTransactions(block); err != nil {
+		t.Fatal(err)
+	}
+	location, err := store.GetTxLocation(poset.TxHash([]byte("tx")))
+	if err != nil || location.Block != 0 {
+		t.Fatalf("expected the transaction in block 0, got %v, %v", location, err)
+	}
+	events := poset.EventHashes{}
+	if err := store.SetBlockEvents(0, events); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetBlockEvents(0); err != nil {
+		t.Fatal(err)
+	}
+	store.ResizeCache(10)
+
+	// the inmem store is not kept on disk
+	if _, err := store.DiskUsage(); err == nil {
+		t.Fatal("expected the inmem store to have no disk usage")
+	}
+	if round, err := store.PrunedRound(); err != nil || round != -1 {
+		t.Fatalf("expected no pruned round, got %d, %v", round, err)
+	}
+}
@@ -3,6 +3,9 @@ package pos
 // Config for a PoS
 type Config struct {
 	TotalSupply uint64 `mapstructure:"total-supply"`
+	// Weights splits the total supply between participants by public key,
+	// it is split evenly when empty
+	Weights map[string]uint64 `mapstructure:"-"`
 }
 
 // NewConfig creates a new PoS config
@@ -1,6 +1,8 @@
 package pos
 
 import (
+	"math/big"
+
 	"github.com/Fantom-foundation/go-lachesis/src/common"
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 	"github.com/Fantom-foundation/go-lachesis/src/state"
@@ -12,12 +14,28 @@ func FakeGenesis(participants *peers.Peers, conf *Config, db state.Database) (co
 		conf = DefaultConfig()
 	}
 
-	balance := conf.TotalSupply / uint64(participants.Len())
+	var totalWeight uint64
+	for _, w := range conf.Weights {
+		totalWeight += w
+	}
 
 	statedb, _ := state.New(common.Hash{}, db)
 
 	for _, p := range participants.ToPeerSlice() {
+		balance := conf.TotalSupply / uint64(participants.Len())
+		if totalWeight > 0 {
+			balance = share(conf.TotalSupply, conf.Weights[p.PubKeyHex], totalWeight)
+		}
 		statedb.AddBalance(p.Address(), balance)
 	}
 	return statedb.Commit(true)
 }
+
+// share returns supply * weight / total, multiplied first so that small
+// weights are not truncated to nothing
+func share(supply, weight, total uint64) uint64 {
+	b := new(big.Int).SetUint64(supply)
+	b.Mul(b, new(big.Int).SetUint64(weight))
+	b.Div(b, new(big.Int).SetUint64(total))
+	return b.Uint64()
+}
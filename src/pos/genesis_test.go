@@ -0,0 +1,14 @@
+package pos
+
+import "testing"
+
+func TestShare(t *testing.T) {
+	// 10 / 6 * 3 would truncate to 3
+	if s := share(10, 3, 6); s != 5 {
+		t.Fatalf("expected 5, got %d", s)
+	}
+	// supply * weight overflows uint64
+	if s := share(1<<63, 4, 8); s != 1<<62 {
+		t.Fatalf("expected %d, got %d", uint64(1<<62), s)
+	}
+}
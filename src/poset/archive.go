@@ -0,0 +1,367 @@
+package poset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+// An archive is the magic string and a version, followed by records:
+//
+//	type (1 byte) | payload length (uvarint) | payload | CRC32 of type and payload
+//
+// The first record holds the participants, the last one the number of
+// records before it, so that a truncated archive is detected.
+const (
+	archiveMagic   = "LACHESIS"
+	archiveVersion = 1
+
+	// maxArchiveRecord bounds the size of a record read from an archive
+	maxArchiveRecord = 256 << 20
+)
+
+type archiveRecord byte
+
+const (
+	archivePeers archiveRecord = iota + 1
+	archiveRoot
+	archiveEvent
+	archiveRoundCreated
+	archiveRoundReceived
+	archiveBlock
+	archiveFrame
+	archiveEnd archiveRecord = 0xFF
+)
+
+type archiveWriter struct {
+	w     *bufio.Writer
+	count uint64
+}
+
+func (a *archiveWriter) write(t archiveRecord, payload []byte) error {
+	var header [1 + binary.MaxVarintLen64]byte
+	header[0] = byte(t)
+	n := binary.PutUvarint(header[1:], uint64(len(payload)))
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[:1])
+	crc.Write(payload)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+
+	for _, b := range [][]byte{header[:1+n], payload, sum[:]} {
+		if _, err := a.w.Write(b); err != nil {
+			return err
+		}
+	}
+	a.count++
+	return nil
+}
+
+func indexedPayload(index int64, data []byte) []byte {
+	payload := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(payload, uint64(index))
+	return append(payload, data...)
+}
+
+// ExportStore writes the participants, roots, events, rounds, blocks and
+// frames of the store to w. Events are read with EventsRange, so the store
+// must not be pruned.
+func ExportStore(store Store, w io.Writer) error {
+	a := &archiveWriter{w: bufio.NewWriter(w)}
+	if _, err := a.w.WriteString(archiveMagic); err != nil {
+		return err
+	}
+	if err := a.w.WriteByte(archiveVersion); err != nil {
+		return err
+	}
+
+	participants, err := store.Participants()
+	if err != nil {
+		return err
+	}
+	peersJSON, err := json.Marshal(participants.ToPeerSlice())
+	if err != nil {
+		return err
+	}
+	if err := a.write(archivePeers, peersJSON); err != nil {
+		return err
+	}
+
+	for participant, root := range store.RootsByParticipant() {
+		data, err := root.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		payload := make([]byte, binary.MaxVarintLen64)
+		payload = append(payload[:binary.PutUvarint(payload, uint64(len(participant)))], participant...)
+		if err := a.write(archiveRoot, append(payload, data...)); err != nil {
+			return err
+		}
+	}
+
+	err = store.EventsRange(0, -1, func(event Event) error {
+		data, err := event.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		return a.write(archiveEvent, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = store.RoundsRange(0, -1, func(r int64, round RoundCreated) error {
+		data, err := round.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		return a.write(archiveRoundCreated, indexedPayload(r, data))
+	})
+	if err != nil {
+		return err
+	}
+
+	for r := int64(0); ; r++ {
+		round, err := store.GetRoundReceived(r)
+		if common.Is(err, common.KeyNotFound) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		data, err := round.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		if err := a.write(archiveRoundReceived, indexedPayload(r, data)); err != nil {
+			return err
+		}
+	}
+
+	// the frames are read after the range, which must not call the store
+	var frames [][2]int64 // block index, round received
+	err = store.BlocksRange(0, -1, func(block Block) error {
+		data, err := block.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		frames = append(frames, [2]int64{block.Index(), block.RoundReceived()})
+		return a.write(archiveBlock, data)
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range frames {
+		frame, err := store.GetFrame(f[1])
+		if err != nil {
+			return fmt.Errorf("frame of block %d: %v", f[0], err)
+		}
+		data, err := frame.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		if err := a.write(archiveFrame, data); err != nil {
+			return err
+		}
+	}
+
+	var end [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(end[:], a.count)
+	if err := a.write(archiveEnd, end[:n]); err != nil {
+		return err
+	}
+	return a.w.Flush()
+}
+
+type archiveReader struct {
+	r     *bufio.Reader
+	count uint64
+}
+
+func (a *archiveReader) read() (archiveRecord, []byte, error) {
+	t, err := a.r.ReadByte()
+	if err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	size, err := binary.ReadUvarint(a.r)
+	if err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	if size > maxArchiveRecord {
+		return 0, nil, fmt.Errorf("archive record %d is too big: %d bytes", a.count, size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(a.r, payload); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(a.r, sum[:]); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte{t})
+	crc.Write(payload)
+	if crc.Sum32() != binary.BigEndian.Uint32(sum[:]) {
+		return 0, nil, fmt.Errorf("archive record %d is corrupted", a.count)
+	}
+	a.count++
+	return archiveRecord(t), payload, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func indexFromPayload(payload []byte) (int64, []byte, error) {
+	if len(payload) < 8 {
+		return 0, nil, fmt.Errorf("archive record too short")
+	}
+	return int64(binary.BigEndian.Uint64(payload)), payload[8:], nil
+}
+
+// ImportStore reads an archive written by ExportStore. newStore is called
+// with the participants of the archive and must return an empty store,
+// which is then filled and returned. On error, the store is closed and
+// should be discarded.
+func ImportStore(r io.Reader, newStore func(*peers.Peers) (Store, error)) (Store, error) {
+	a := &archiveReader{r: bufio.NewReader(r)}
+
+	header := make([]byte, len(archiveMagic)+1)
+	if _, err := io.ReadFull(a.r, header); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if !bytes.Equal(header[:len(archiveMagic)], []byte(archiveMagic)) {
+		return nil, fmt.Errorf("not a lachesis archive")
+	}
+	if v := header[len(archiveMagic)]; v > archiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d, expected at most %d", v, archiveVersion)
+	}
+
+	t, payload, err := a.read()
+	if err != nil {
+		return nil, err
+	}
+	if t != archivePeers {
+		return nil, fmt.Errorf("archive does not start with the participants")
+	}
+	var peerSlice []*peers.Peer
+	if err := json.Unmarshal(payload, &peerSlice); err != nil {
+		return nil, err
+	}
+	store, err := newStore(peers.NewPeersFromSlice(peerSlice))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := importRecords(a, store); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func importRecords(a *archiveReader, store Store) error {
+	roots := make(map[string]Root)
+	for {
+		count := a.count
+		t, payload, err := a.read()
+		if err != nil {
+			return err
+		}
+
+		switch t {
+		case archiveRoot:
+			size, n := binary.Uvarint(payload)
+			if n <= 0 || uint64(len(payload)-n) < size {
+				return fmt.Errorf("archive root record too short")
+			}
+			participant := string(payload[n : n+int(size)])
+			var root Root
+			if err := root.ProtoUnmarshal(payload[n+int(size):]); err != nil {
+				return err
+			}
+			roots[participant] = root
+		case archiveEvent:
+			if len(roots) > 0 {
+				if err := store.Reset(roots); err != nil {
+					return err
+				}
+				roots = make(map[string]Root)
+			}
+			event := Event{
+				roundReceived:    RoundNIL,
+				round:            RoundNIL,
+				lamportTimestamp: LamportTimestampNIL,
+			}
+			if err := event.ProtoUnmarshal(payload); err != nil {
+				return err
+			}
+			if err := store.SetEvent(event); err != nil {
+				return err
+			}
+		case archiveRoundCreated:
+			index, data, err := indexFromPayload(payload)
+			if err != nil {
+				return err
+			}
+			round := NewRoundCreated()
+			if err := round.ProtoUnmarshal(data); err != nil {
+				return err
+			}
+			if err := store.SetRoundCreated(index, *round); err != nil {
+				return err
+			}
+		case archiveRoundReceived:
+			index, data, err := indexFromPayload(payload)
+			if err != nil {
+				return err
+			}
+			round := NewRoundReceived()
+			if err := round.ProtoUnmarshal(data); err != nil {
+				return err
+			}
+			if err := store.SetRoundReceived(index, *round); err != nil {
+				return err
+			}
+		case archiveBlock:
+			var block Block
+			if err := block.ProtoUnmarshal(payload); err != nil {
+				return err
+			}
+			if err := store.SetBlock(block); err != nil {
+				return err
+			}
+		case archiveFrame:
+			var frame Frame
+			if err := frame.ProtoUnmarshal(payload); err != nil {
+				return err
+			}
+			if err := store.SetFrame(frame); err != nil {
+				return err
+			}
+		case archiveEnd:
+			expected, n := binary.Uvarint(payload)
+			if n <= 0 || expected != count {
+				return fmt.Errorf("archive should have %d records, read %d", expected, count)
+			}
+			if len(roots) > 0 {
+				return store.Reset(roots)
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown archive record type %d", t)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package poset
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+func TestArchive(t *testing.T) {
+	cacheSize := 100
+	store, participants := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+
+	// one event per participant and round
+	rounds := int64(3)
+	var events []Event
+	for r := int64(0); r < rounds; r++ {
+		round := NewRoundCreated()
+		for _, p := range participants {
+			event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], r))},
+				nil, nil, make(EventHashes, 2), p.pubKey, r, nil)
+			event.Message.TopologicalIndex = int64(len(events))
+			if err := store.SetEvent(event); err != nil {
+				t.Fatal(err)
+			}
+			round.AddEvent(event.Hash(), true)
+			events = append(events, event)
+		}
+		if err := store.SetRoundCreated(r, *round); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	block := NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx1")})
+	if err := store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetFrame(Frame{Round: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := ExportStore(store, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "lachesis_archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	newStore := func(path string) func(*peers.Peers) (Store, error) {
+		return func(participants *peers.Peers) (Store, error) {
+			return NewBoltStore(participants, cacheSize, filepath.Join(dir, path), nil)
+		}
+	}
+
+	imported, err := ImportStore(bytes.NewReader(archive.Bytes()), newStore("imported.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imported.Close()
+
+	topo, err := imported.TopologicalEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topo) != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), len(topo))
+	}
+	for i, event := range topo {
+		if event.Hash() != events[i].Hash() {
+			t.Fatalf("event %d: expected %v, got %v", i, events[i].Hash(), event.Hash())
+		}
+	}
+	for r := int64(0); r < rounds; r++ {
+		round, err := imported.GetRoundCreated(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(round.Message.Events) != len(participants) {
+			t.Fatalf("round %d: expected %d events, got %d", r, len(participants), len(round.Message.Events))
+		}
+	}
+	storedBlock, err := imported.GetBlock(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !storedBlock.Equals(&block) {
+		t.Fatal("Block and StoredBlock do not match")
+	}
+	if _, err := imported.GetFrame(1); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupted := append([]byte{}, archive.Bytes()...)
+	corrupted[len(corrupted)/2] ^= 0xFF
+	if _, err := ImportStore(bytes.NewReader(corrupted), newStore("corrupted.db")); err == nil {
+		t.Fatal("importing a corrupted archive should fail")
+	}
+	truncated := archive.Bytes()[:archive.Len()-10]
+	if _, err := ImportStore(bytes.NewReader(truncated), newStore("truncated.db")); err == nil {
+		t.Fatal("importing a truncated archive should fail")
+	}
+}
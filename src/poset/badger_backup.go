@@ -0,0 +1,162 @@
+package poset
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/dgraph-io/badger"
+)
+
+// BackupStore is a Store which can write a consistent backup of itself while
+// in use, such as BadgerStore and BoltStore
+type BackupStore interface {
+	Store
+	Backup(w io.Writer) (int64, error)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Backup writes a consistent snapshot of the database to w and returns the
+// number of bytes written. It is safe to call while the node runs: writes
+// made after the snapshot is taken are not part of the backup.
+func (s *BadgerStore) Backup(w io.Writer) (int64, error) {
+	if err := s.Flush(); err != nil {
+		return 0, err
+	}
+	c := &countingWriter{w: w}
+	_, err := s.db.Backup(c, 0)
+	return c.n, err
+}
+
+// RestoreBadgerStore creates a database at path from a backup written by
+// BadgerStore.Backup and opened with o, then checks its blocks with
+// VerifyBlocks against participants and their weights, see
+// Poset.SetBlockWeights, which the caller trusts, such as the peers and the
+// genesis of the node: the participants recorded in the backup itself prove
+// nothing. path must not exist; it is removed again if the backup is
+// rejected.
+func RestoreBadgerStore(r io.Reader, cacheSize int, path string, o BadgerOptions,
+	participants *peers.Peers, weights map[string]uint64, quorum float64) (*BadgerStore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("a database already lives under %s", path)
+	}
+
+	store, err := restoreBadgerStore(r, cacheSize, path, o)
+	if err == nil {
+		quorumWeight := QuorumWeight(quorum, ParticipantsWeight(participants, weights))
+		_, err = VerifyBlocks(store, participants, weights, quorumWeight)
+		if err != nil {
+			store.Close()
+		}
+	}
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	return store, nil
+}
+
+// LoadBadgerBackup opens a backup written by BadgerStore.Backup read-only,
+// see LoadBadgerStoreReadOnly. This is how the database of a running node,
+// which locks its directory, is read: from a backup taken with the
+// /admin/backup endpoint. The backup is loaded to a temporary directory,
+// which Close removes.
+func LoadBadgerBackup(r io.Reader, cacheSize int, o BadgerOptions) (*BadgerStore, error) {
+	dir, err := ioutil.TempDir("", "lachesis-backup")
+	if err != nil {
+		return nil, err
+	}
+	store, err := loadBadgerBackup(r, cacheSize, dir, o)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	store.removeOnClose = true
+	return store, nil
+}
+
+func loadBadgerBackup(r io.Reader, cacheSize int, dir string, o BadgerOptions) (*BadgerStore, error) {
+	if err := loadBackup(r, dir, o); err != nil {
+		return nil, err
+	}
+	return LoadBadgerStoreReadOnlyWithOptions(cacheSize, dir, o)
+}
+
+// loadBackup writes the backup to a new database at path
+func loadBackup(r io.Reader, path string, o BadgerOptions) error {
+	opts, err := o.badgerOptions(path)
+	if err != nil {
+		return err
+	}
+	// the store is synced when closed below
+	opts.SyncWrites = false
+	handle, err := badger.Open(opts)
+	if err != nil {
+		return err
+	}
+	if err := handle.Load(r); err != nil {
+		handle.Close()
+		return err
+	}
+	return handle.Close()
+}
+
+func restoreBadgerStore(r io.Reader, cacheSize int, path string, o BadgerOptions) (*BadgerStore, error) {
+	if err := loadBackup(r, path, o); err != nil {
+		return nil, err
+	}
+	return LoadBadgerStoreWithOptions(cacheSize, path, o)
+}
+
+// VerifyBlocks checks the signatures of all the blocks of the store and
+// returns the index of the last block signed by participants of at least the
+// quorum weight, or -1 if there is none. Every signature must be valid and
+// come from one of participants, and every block up to the last one signed
+// by a quorum must have one too; the blocks after it may still be collecting
+// their signatures.
+func VerifyBlocks(store Store, participants *peers.Peers, weights map[string]uint64, quorum uint64) (int64, error) {
+	var signed []bool
+	for i := int64(0); ; i++ {
+		block, err := store.GetBlock(i)
+		if common.Is(err, common.KeyNotFound) {
+			break
+		}
+		if err != nil {
+			return -1, err
+		}
+
+		for _, s := range block.GetBlockSignatures() {
+			if _, ok := participants.ByPubKey[s.ValidatorHex()]; !ok {
+				return -1, fmt.Errorf("block %d is signed by %s, which is not a participant", i, s.ValidatorHex())
+			}
+			if ok, err := block.Verify(s); !ok {
+				return -1, fmt.Errorf("block %d has an invalid signature from %s: %v", i, s.ValidatorHex(), err)
+			}
+		}
+		signed = append(signed, SignersWeight(participants, weights, block.Signatures) >= quorum)
+	}
+
+	last := int64(len(signed)) - 1
+	for last >= 0 && !signed[last] {
+		last--
+	}
+	for i := int64(0); i < last; i++ {
+		if !signed[i] {
+			return -1, fmt.Errorf("block %d has signatures of less than weight %d", i, quorum)
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,177 @@
+package poset
+
+import (
+	"github.com/dgraph-io/badger"
+)
+
+// BatchStore is a Store which can group the writes made between StartBatch
+// and CommitBatch into one transaction, such as BadgerStore
+type BatchStore interface {
+	Store
+	StartBatch() error
+	CommitBatch() error
+}
+
+// StartBatch makes the following writes go to a single transaction, until
+// the matching CommitBatch. Batches can be nested, e.g. a consensus run
+// during a sync: the writes of both are committed by the outermost
+// CommitBatch. Reads made meanwhile see the pending writes.
+func (s *BadgerStore) StartBatch() error {
+	s.batchLock.Lock()
+	defer s.batchLock.Unlock()
+	if s.batchDepth == 0 {
+		if s.writer != nil {
+			s.pending = s.writer.newBatch()
+		} else {
+			s.batch = s.db.NewTransaction(true)
+		}
+	}
+	s.batchDepth++
+	return nil
+}
+
+// CommitBatch ends a batch started with StartBatch. The transaction is
+// committed when the outermost batch ends, or queued in WriteBehind mode.
+func (s *BadgerStore) CommitBatch() error {
+	s.batchLock.Lock()
+	defer s.batchLock.Unlock()
+	if s.batchDepth == 0 {
+		return nil
+	}
+	s.batchDepth--
+	if s.batchDepth > 0 {
+		return nil
+	}
+	return s.commitBatch()
+}
+
+func (s *BadgerStore) commitBatch() error {
+	s.batchDepth = 0
+	if s.writer != nil {
+		b := s.pending
+		s.pending = nil
+		return s.writer.enqueue(b)
+	}
+	tx := s.batch
+	s.batch = nil
+	defer tx.Discard()
+	return s.commit(tx)
+}
+
+// update runs fn in the current batch, or in its own transaction outside of
+// a batch. fn must be safe to run again: when the batch grows too big for a
+// transaction, the batch is committed as is and fn runs again in a new one.
+// In WriteBehind mode, fn records its writes for the committer instead.
+func (s *BadgerStore) update(fn func(tx dbWriter) error) error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	s.batchLock.Lock()
+	defer s.batchLock.Unlock()
+	if s.writer != nil {
+		if s.pending != nil {
+			return fn(s.sealed(s.pending))
+		}
+		b := s.writer.newBatch()
+		if err := fn(s.sealed(b)); err != nil {
+			return err
+		}
+		return s.writer.enqueue(b)
+	}
+	if s.batch == nil {
+		tx := s.db.NewTransaction(true)
+		defer tx.Discard()
+		if err := fn(s.sealed(txnWriter{tx})); err != nil {
+			return err
+		}
+		return s.commit(tx)
+	}
+
+	err := fn(s.sealed(txnWriter{s.batch}))
+	if err == badger.ErrTxnTooBig {
+		depth := s.batchDepth
+		if err := s.commitBatch(); err != nil {
+			return err
+		}
+		s.batch = s.db.NewTransaction(true)
+		s.batchDepth = depth
+		err = fn(s.sealed(txnWriter{s.batch}))
+	}
+	return err
+}
+
+// view runs fn in the current batch, so that it sees the pending writes, or
+// in a read-only transaction outside of a batch. In WriteBehind mode, it
+// first waits for the queued writes to reach the database; the BadgerStore
+// only reads the database when its caches miss.
+func (s *BadgerStore) view(fn func(tx *badger.Txn) error) error {
+	s.batchLock.Lock()
+	if s.writer != nil {
+		seq, err := s.queuePending()
+		s.batchLock.Unlock()
+		if err == nil {
+			err = s.writer.wait(seq)
+		}
+		if err != nil {
+			return err
+		}
+		return s.db.View(fn)
+	}
+	if s.batch == nil {
+		s.batchLock.Unlock()
+		return s.db.View(fn)
+	}
+	defer s.batchLock.Unlock()
+	return fn(s.batch)
+}
+
+// splitWrite writes to the database outside of the batches, in as many
+// transactions as it takes: when the current transaction grows too big, it
+// is committed and the write goes to a new one, as in update. The writes are
+// therefore not atomic.
+type splitWrite struct {
+	s  *BadgerStore
+	tx *badger.Txn
+}
+
+func (s *BadgerStore) newSplitWrite() *splitWrite {
+	return &splitWrite{s: s, tx: s.db.NewTransaction(true)}
+}
+
+func (w *splitWrite) Set(key, val []byte) error {
+	if w.s.cipher != nil && !isPlainKey(key) {
+		val = w.s.cipher.Seal(key, val)
+	}
+	return w.do(func(tx *badger.Txn) error {
+		return tx.Set(key, val)
+	})
+}
+
+func (w *splitWrite) Delete(key []byte) error {
+	return w.do(func(tx *badger.Txn) error {
+		return tx.Delete(key)
+	})
+}
+
+func (w *splitWrite) do(fn func(tx *badger.Txn) error) error {
+	err := fn(w.tx)
+	if err == badger.ErrTxnTooBig {
+		if err := w.s.commit(w.tx); err != nil {
+			return err
+		}
+		w.tx.Discard()
+		w.tx = w.s.db.NewTransaction(true)
+		err = fn(w.tx)
+	}
+	return err
+}
+
+// Flush commits the last transaction
+func (w *splitWrite) Flush() error {
+	return w.s.commit(w.tx)
+}
+
+// Cancel discards the writes not committed yet
+func (w *splitWrite) Cancel() {
+	w.tx.Discard()
+}
@@ -0,0 +1,125 @@
+package poset
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Objects of the cold storage are keyed by what they hold:
+//
+//	events/<hash in hex>
+//	rounds/<round>/created
+//	rounds/<round>/received
+//	frames/<round>
+func coldEventKey(hash EventHash) string {
+	return "events/" + hex.EncodeToString(hash.Bytes())
+}
+
+func coldRoundCreatedKey(r int64) string {
+	return fmt.Sprintf("rounds/%09d/created", r)
+}
+
+func coldRoundReceivedKey(r int64) string {
+	return fmt.Sprintf("rounds/%09d/received", r)
+}
+
+func coldFrameKey(r int64) string {
+	return fmt.Sprintf("frames/%09d", r)
+}
+
+// SetColdStorage makes Prune move the rounds it deletes to cold, from which
+// the getters read them back when they are no longer in the database. It
+// must be called before the store is used.
+func (s *BadgerStore) SetColdStorage(cold ColdStorage) {
+	s.cold = cold
+}
+
+// moveToColdStorage copies the events, round infos and frame of round r to
+// the cold storage before dbPruneRound deletes them. Objects are replaced,
+// so a round is moved again if pruning it failed. The values of an
+// encrypted store are moved as they are, sealed.
+func (s *BadgerStore) moveToColdStorage(tx *badger.Txn, r int64, hashes []EventHash) error {
+	objects := make(map[string][]byte, len(hashes)+3)
+	for _, hash := range hashes {
+		objects[coldEventKey(hash)] = eventKey(hash)
+	}
+	objects[coldRoundCreatedKey(r)] = roundCreatedKey(r)
+	objects[coldRoundReceivedKey(r)] = roundReceivedKey(r)
+	objects[coldFrameKey(r)] = frameKey(r)
+
+	for coldKey, key := range objects {
+		item, err := tx.Get(key)
+		if isDBKeyNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if err := s.cold.Put(coldKey, val); err != nil {
+			return fmt.Errorf("moving %s to cold storage: %v", coldKey, err)
+		}
+	}
+	return nil
+}
+
+// coldGet reads the object of the cold storage moved from the database key
+// dbKey into v. A missing object, or no cold storage, is reported as a
+// missing database key.
+func (s *BadgerStore) coldGet(key string, dbKey []byte, v interface{ ProtoUnmarshal([]byte) error }) error {
+	if s.cold == nil {
+		return badger.ErrKeyNotFound
+	}
+	data, err := s.cold.Get(key)
+	if err == ErrNotInColdStorage {
+		return badger.ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if s.cipher != nil {
+		if data, err = s.cipher.Open(dbKey, data); err != nil {
+			return err
+		}
+	}
+	return v.ProtoUnmarshal(data)
+}
+
+func (s *BadgerStore) coldGetEventBlock(hash EventHash) (Event, error) {
+	event := new(Event)
+	if err := s.coldGet(coldEventKey(hash), eventKey(hash), event); err != nil {
+		return Event{}, err
+	}
+	return *event, nil
+}
+
+func (s *BadgerStore) coldGetRoundCreated(r int64) (RoundCreated, error) {
+	roundInfo := new(RoundCreated)
+	if err := s.coldGet(coldRoundCreatedKey(r), roundCreatedKey(r), roundInfo); err != nil {
+		return *NewRoundCreated(), err
+	}
+	// see dbGetRoundCreated
+	roundInfo.Message.Queued = false
+	return *roundInfo, nil
+}
+
+func (s *BadgerStore) coldGetRoundReceived(r int64) (RoundReceived, error) {
+	roundInfo := new(RoundReceived)
+	if err := s.coldGet(coldRoundReceivedKey(r), roundReceivedKey(r), roundInfo); err != nil {
+		return *NewRoundReceived(), err
+	}
+	return *roundInfo, nil
+}
+
+func (s *BadgerStore) coldGetFrame(r int64) (Frame, error) {
+	frame := new(Frame)
+	if err := s.coldGet(coldFrameKey(r), frameKey(r), frame); err != nil {
+		return Frame{}, err
+	}
+	return *frame, nil
+}
@@ -0,0 +1,299 @@
+package poset
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/Fantom-foundation/go-lachesis/src/kvdb"
+)
+
+// StoreKeySize is the size of the AES-256 keys of an encrypted BadgerStore
+const StoreKeySize = 32
+
+// Errors of a BadgerStore opened with the wrong encryption settings
+var (
+	ErrStoreEncrypted    = errors.New("database is encrypted, it needs its key")
+	ErrStoreNotEncrypted = errors.New("database is not encrypted")
+	ErrStoreKey          = errors.New("no key given opens the database")
+)
+
+// encryptionCheckKey holds a known value sealed like the others, which
+// tells whether the database is encrypted and with which keys
+var encryptionCheckKey = []byte("encryption_check")
+
+var encryptionCheckValue = []byte("lachesis")
+
+// isPlainKey tells whether the value of key is stored in the clear: the
+// schema version and the pruned round are read before the store is set up.
+// Databases are encrypted from their creation, at the current schema
+// version, so the migrations only ever see plaintext values.
+func isPlainKey(key []byte) bool {
+	return bytes.Equal(key, schemaVersionKey) || bytes.Equal(key, prunedRoundKey)
+}
+
+// StoreCipher encrypts the values of a BadgerStore with AES-GCM, keys and
+// journal records included, bound to their database key. Values are sealed
+// with the active key; the keys of earlier rotations only open the values
+// sealed with them, until RotateBadgerStoreKey rewrites those. A sealed
+// value is
+//
+//	key id uint32 | nonce | ciphertext and tag
+//
+// where the key id is the start of the SHA-256 of the key. Database keys,
+// which are hashes, indexes and public keys, are not encrypted.
+type StoreCipher struct {
+	active uint32
+	aeads  map[uint32]cipher.AEAD
+}
+
+// NewStoreCipher returns a StoreCipher which seals with key and also opens
+// the values sealed with oldKeys
+func NewStoreCipher(key []byte, oldKeys ...[]byte) (*StoreCipher, error) {
+	c := &StoreCipher{aeads: make(map[uint32]cipher.AEAD)}
+	for i, k := range append([][]byte{key}, oldKeys...) {
+		if len(k) != StoreKeySize {
+			return nil, fmt.Errorf("store key %d has %d bytes, expected %d", i, len(k), StoreKeySize)
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		id := storeKeyID(k)
+		if _, ok := c.aeads[id]; ok {
+			return nil, fmt.Errorf("store key %d is given twice", i)
+		}
+		c.aeads[id] = aead
+		if i == 0 {
+			c.active = id
+		}
+	}
+	return c, nil
+}
+
+func storeKeyID(key []byte) uint32 {
+	sum := sha256.Sum256(key)
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// ReadStoreKey reads a key file, which holds the key in hex, as written by
+// `openssl rand -hex 32` or by the agent of a key management service
+func ReadStoreKey(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("store key %s: %v", path, err)
+	}
+	if len(key) != StoreKeySize {
+		return nil, fmt.Errorf("store key %s has %d bytes, expected %d", path, len(key), StoreKeySize)
+	}
+	return key, nil
+}
+
+// Seal encrypts the value of key with the active key
+func (c *StoreCipher) Seal(key, val []byte) []byte {
+	aead := c.aeads[c.active]
+	out := make([]byte, 4+aead.NonceSize(), 4+aead.NonceSize()+len(val)+aead.Overhead())
+	binary.BigEndian.PutUint32(out, c.active)
+	if _, err := rand.Read(out[4:]); err != nil {
+		panic(err)
+	}
+	return aead.Seal(out, out[4:], val, key)
+}
+
+// Open decrypts the value of key, sealed with any of the keys of c
+func (c *StoreCipher) Open(key, val []byte) ([]byte, error) {
+	if len(val) < 4 {
+		return nil, fmt.Errorf("value of %q is not sealed", key)
+	}
+	id := binary.BigEndian.Uint32(val)
+	aead, ok := c.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("value of %q is sealed with unknown key %08x", key, id)
+	}
+	if len(val) < 4+aead.NonceSize() {
+		return nil, fmt.Errorf("value of %q is not sealed", key)
+	}
+	nonce := val[4 : 4+aead.NonceSize()]
+	res, err := aead.Open(nil, nonce, val[4+aead.NonceSize():], key)
+	if err != nil {
+		return nil, fmt.Errorf("value of %q: %v", key, err)
+	}
+	return res, nil
+}
+
+// sealedWithActive tells whether val is sealed with the active key
+func (c *StoreCipher) sealedWithActive(val []byte) bool {
+	return len(val) >= 4 && binary.BigEndian.Uint32(val) == c.active
+}
+
+// cipher returns the StoreCipher of the key files of o, nil if the store is
+// not encrypted
+func (o BadgerOptions) cipher() (*StoreCipher, error) {
+	if o.EncryptionKeyFile == "" {
+		if len(o.OldEncryptionKeyFiles) > 0 {
+			return nil, fmt.Errorf("old encryption keys need the current one")
+		}
+		return nil, nil
+	}
+	key, err := ReadStoreKey(o.EncryptionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	var oldKeys [][]byte
+	for _, path := range o.OldEncryptionKeyFiles {
+		k, err := ReadStoreKey(path)
+		if err != nil {
+			return nil, err
+		}
+		oldKeys = append(oldKeys, k)
+	}
+	return NewStoreCipher(key, oldKeys...)
+}
+
+// sealingWriter is a dbWriter which encrypts the values it writes
+type sealingWriter struct {
+	dbWriter
+	cipher *StoreCipher
+}
+
+func (w sealingWriter) Set(key, val []byte) error {
+	if isPlainKey(key) {
+		return w.dbWriter.Set(key, val)
+	}
+	return w.dbWriter.Set(key, w.cipher.Seal(key, val))
+}
+
+// sealed returns w, which encrypts the values it writes if the store is
+// encrypted
+func (s *BadgerStore) sealed(w dbWriter) dbWriter {
+	if s.cipher == nil {
+		return w
+	}
+	return sealingWriter{w, s.cipher}
+}
+
+// value returns a copy of the value of item, decrypted if the store is
+// encrypted
+func (s *BadgerStore) value(item *badger.Item) ([]byte, error) {
+	val, err := item.ValueCopy(nil)
+	if err != nil || s.cipher == nil || isPlainKey(item.Key()) {
+		return val, err
+	}
+	return s.cipher.Open(item.Key(), val)
+}
+
+// stateDatabase returns the state database of the store, whose values are
+// encrypted like the others
+func stateDatabase(db *badger.DB, c *StoreCipher) kvdb.Database {
+	var states kvdb.Database = kvdb.NewBadgerDatabase(db)
+	if c != nil {
+		states = kvdb.NewSealedDatabase(states, c)
+	}
+	return kvdb.NewTable(states, statePrefix)
+}
+
+// checkEncryption checks that the database is encrypted if, and only if, c
+// is given, and that c opens it
+func checkEncryption(db *badger.DB, c *StoreCipher) error {
+	return db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(encryptionCheckKey)
+		if isDBKeyNotFound(err) {
+			if c != nil {
+				return ErrStoreNotEncrypted
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			return ErrStoreEncrypted
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		check, err := c.Open(encryptionCheckKey, val)
+		if err != nil || !bytes.Equal(check, encryptionCheckValue) {
+			return ErrStoreKey
+		}
+		return nil
+	})
+}
+
+// isEncryptionError tells whether err is returned by a store opened with
+// the wrong encryption settings
+func isEncryptionError(err error) bool {
+	return err == ErrStoreEncrypted || err == ErrStoreNotEncrypted || err == ErrStoreKey
+}
+
+// RotateBadgerStoreKey rewrites the values of the store sealed with one of
+// its old keys with its current key, and returns how many it rewrote. The
+// old keys are not needed afterwards, but to read the objects moved to a
+// cold storage before, which are not rewritten. The node must be stopped: a
+// value written meanwhile could be overwritten with the one read before.
+func RotateBadgerStoreKey(s *BadgerStore) (int64, error) {
+	if s.readOnly {
+		return 0, ErrReadOnlyStore
+	}
+	if s.cipher == nil {
+		return 0, ErrStoreNotEncrypted
+	}
+	if err := s.Flush(); err != nil {
+		return 0, err
+	}
+
+	wb := s.newSplitWrite()
+	defer wb.Cancel()
+
+	var rotated int64
+	err := s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if isPlainKey(item.Key()) {
+				continue
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if s.cipher.sealedWithActive(val) {
+				continue
+			}
+			key := item.KeyCopy(nil)
+			val, err = s.cipher.Open(key, val)
+			if err != nil {
+				return err
+			}
+			if err := wb.Set(key, val); err != nil {
+				return err
+			}
+			rotated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rotated, wb.Flush()
+}
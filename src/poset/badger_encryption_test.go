@@ -0,0 +1,227 @@
+package poset
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+// writeStoreKey writes a new key file to dir
+func writeStoreKey(dir, name string, t *testing.T) string {
+	key := make([]byte, StoreKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rawValuesContain tells whether a value of the database holds data in the
+// clear
+func rawValuesContain(db *badger.DB, data []byte, t *testing.T) bool {
+	found := false
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			found = found || bytes.Contains(val, data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func TestStoreCipher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lachesis-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldKey, err := ReadStoreKey(writeStoreKey(dir, "old.key", t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ReadStoreKey(writeStoreKey(dir, "new.key", t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := NewStoreCipher(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := old.Seal([]byte("key"), []byte("value"))
+	if bytes.Contains(sealed, []byte("value")) {
+		t.Fatal("the sealed value holds the value in the clear")
+	}
+
+	rotated, err := NewStoreCipher(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, err := rotated.Open([]byte("key"), sealed); err != nil || string(val) != "value" {
+		t.Fatalf("the old key should open the value, got %q: %v", val, err)
+	}
+	if rotated.sealedWithActive(sealed) || !rotated.sealedWithActive(rotated.Seal([]byte("key"), nil)) {
+		t.Fatal("values should be sealed with the new key only")
+	}
+
+	// a value is bound to its key, and needs the key it was sealed with
+	if _, err := old.Open([]byte("other key"), sealed); err == nil {
+		t.Fatal("a value moved to another key should not open")
+	}
+	fresh, err := NewStoreCipher(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fresh.Open([]byte("key"), sealed); err == nil {
+		t.Fatal("a value sealed with a dropped key should not open")
+	}
+	if _, err := NewStoreCipher(newKey, newKey); err == nil {
+		t.Fatal("a key given twice should be rejected")
+	}
+	if _, err := NewStoreCipher(newKey[:16]); err == nil {
+		t.Fatal("a short key should be rejected")
+	}
+}
+
+func TestBadgerEncryption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lachesis-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o := DefaultBadgerOptions()
+	o.EncryptionKeyFile = writeStoreKey(dir, "store.key", t)
+	store, _ := initBadgerStoreWithOptions(cacheSize, o, t)
+	defer os.RemoveAll(store.path)
+
+	secret := []byte("secret transaction")
+	block := NewBlock(0, 1, []byte("framehash"), [][]byte{secret})
+	if err := store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetReceipts(0, []Receipt{{Data: secret}}); err != nil {
+		t.Fatal(err)
+	}
+	if rawValuesContain(store.db, secret, t) {
+		t.Fatal("the database holds the transaction in the clear")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the store opens with its key only
+	if _, err := LoadBadgerStore(cacheSize, store.path); err != ErrStoreEncrypted {
+		t.Fatalf("expected %v without the key, got %v", ErrStoreEncrypted, err)
+	}
+	if _, err := LoadOrCreateBadgerStore(peers.NewPeers(), cacheSize, store.path, nil); err == nil {
+		t.Fatal("an encrypted store should not be created again without its key")
+	}
+	other := o
+	other.EncryptionKeyFile = writeStoreKey(dir, "other.key", t)
+	if _, err := LoadBadgerStoreWithOptions(cacheSize, store.path, other); err != ErrStoreKey {
+		t.Fatalf("expected %v with another key, got %v", ErrStoreKey, err)
+	}
+
+	loaded, err := LoadBadgerStoreWithOptions(cacheSize, store.path, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.dbGetBlock(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Transactions()[0], secret) {
+		t.Fatalf("expected transaction %q, got %q", secret, got.Transactions()[0])
+	}
+	if err := loaded.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a store created without a key is not encrypted
+	plain, _ := initBadgerStore(cacheSize, t)
+	defer os.RemoveAll(plain.path)
+	if err := plain.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBadgerStoreWithOptions(cacheSize, plain.path, o); err != ErrStoreNotEncrypted {
+		t.Fatalf("expected %v, got %v", ErrStoreNotEncrypted, err)
+	}
+}
+
+func TestBadgerKeyRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lachesis-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o := DefaultBadgerOptions()
+	o.EncryptionKeyFile = writeStoreKey(dir, "old.key", t)
+	store, _ := initBadgerStoreWithOptions(cacheSize, o, t)
+	defer os.RemoveAll(store.path)
+	if err := store.SetBlock(NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx0")})); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the new key seals the new values, the old one still opens the others
+	rotated := o
+	rotated.EncryptionKeyFile = writeStoreKey(dir, "new.key", t)
+	rotated.OldEncryptionKeyFiles = []string{o.EncryptionKeyFile}
+	store, err = LoadBadgerStoreWithOptions(cacheSize, store.path, rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetBlock(NewBlock(1, 2, []byte("framehash"), [][]byte{[]byte("tx1")})); err != nil {
+		t.Fatal(err)
+	}
+	count, err := RotateBadgerStoreKey(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Fatal("the values sealed with the old key should be rewritten")
+	}
+	if count, err = RotateBadgerStoreKey(store); err != nil || count != 0 {
+		t.Fatalf("a second rotation should rewrite nothing, got %d: %v", count, err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the old key can be dropped, loading reads the roots written with it
+	rotated.OldEncryptionKeyFiles = nil
+	store, err = LoadBadgerStoreWithOptions(cacheSize, store.path, rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for i := int64(0); i < 2; i++ {
+		if _, err := store.dbGetBlock(i); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package poset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dgraph-io/badger"
+)
+
+// schemaVersionKey holds the version of the key layout and record formats
+// of a badger database, i.e. the number of migrations applied to it
+var schemaVersionKey = []byte("schema_version")
+
+// Migration upgrades a badger database from one schema version to the next
+type Migration struct {
+	Description string
+	// Migrate rewrites the database in txn, which is committed together
+	// with the new schema version. When txn grows too big, it is committed
+	// as is and Migrate runs again in a new one, so Migrate must skip the
+	// records it already rewrote. A dry run needs a single transaction.
+	Migrate func(txn *badger.Txn) error
+}
+
+// badgerMigrations are the migrations in order: applying the first n of them
+// brings a database to version n. Append new steps, never change or remove
+// released ones.
+var badgerMigrations = []Migration{
+	{
+		Description: "record the schema version of databases created before it existed",
+		Migrate:     func(txn *badger.Txn) error { return nil },
+	},
+	{
+		Description: "prefix the event keys and index the events by creator and by round",
+		Migrate:     migrateEventKeys,
+	},
+}
+
+// SchemaVersion returns the schema version of the databases created by this
+// version of lachesis
+func SchemaVersion() int {
+	return len(badgerMigrations)
+}
+
+// PendingMigration is a migration a database needs
+type PendingMigration struct {
+	Version int // version after the migration
+	Migration
+}
+
+// MigrateBadgerStore brings the database at path to the current schema
+// version and returns the migrations it applied. With dryRun, the
+// migrations are run but discarded, which checks that they would succeed.
+// The database must not be in use.
+func MigrateBadgerStore(path string, dryRun bool) ([]PendingMigration, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	opts := badger.DefaultOptions
+	opts.Dir = path
+	opts.ValueDir = path
+	opts.SyncWrites = false
+	handle, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+	return migrateBadgerDB(handle, dryRun)
+}
+
+func migrateBadgerDB(db *badger.DB, dryRun bool) ([]PendingMigration, error) {
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// a dry run applies all the migrations to the same transaction, so that
+	// each one sees the changes of the previous ones, then discards it
+	var txn *badger.Txn
+	if dryRun {
+		txn = db.NewTransaction(true)
+		defer txn.Discard()
+	}
+	for _, m := range pending {
+		if err := applyMigration(db, txn, m); err != nil {
+			return nil, fmt.Errorf("migration to schema version %d (%s): %v", m.Version, m.Description, err)
+		}
+	}
+	return pending, nil
+}
+
+// checkSchemaVersion migrates the database opened by LoadBadgerStore. A
+// database opened read-only cannot be migrated and must be up to date.
+func checkSchemaVersion(db *badger.DB, readOnly bool) error {
+	if !readOnly {
+		_, err := migrateBadgerDB(db, false)
+		return err
+	}
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database needs %d migrations to schema version %d, see lachesis db migrate",
+			len(pending), SchemaVersion())
+	}
+	return nil
+}
+
+func pendingMigrations(db *badger.DB) ([]PendingMigration, error) {
+	version, err := dbGetSchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion() {
+		return nil, fmt.Errorf("database schema version %d is newer than the supported version %d", version, SchemaVersion())
+	}
+
+	var pending []PendingMigration
+	for i := version; i < SchemaVersion(); i++ {
+		pending = append(pending, PendingMigration{Version: i + 1, Migration: badgerMigrations[i]})
+	}
+	return pending, nil
+}
+
+// applyMigration runs m in txn, or in its own transactions if txn is nil
+func applyMigration(db *badger.DB, txn *badger.Txn, m PendingMigration) error {
+	if txn != nil {
+		if err := m.Migrate(txn); err != nil {
+			return err
+		}
+		return setSchemaVersion(txnWriter{txn}, m.Version)
+	}
+
+	for {
+		txn = db.NewTransaction(true)
+		err := m.Migrate(txn)
+		if err == badger.ErrTxnTooBig {
+			err = txn.Commit(nil)
+			txn.Discard()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err == nil {
+			err = setSchemaVersion(txnWriter{txn}, m.Version)
+		}
+		if err == nil {
+			err = txn.Commit(nil)
+		}
+		txn.Discard()
+		return err
+	}
+}
+
+func dbGetSchemaVersion(db *badger.DB) (int, error) {
+	version := 0
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(schemaVersionKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			version = int(binary.BigEndian.Uint64(val))
+			return nil
+		})
+	})
+	if isDBKeyNotFound(err) {
+		err = nil
+	}
+	return version, err
+}
+
+func setSchemaVersion(txn dbWriter, version int) error {
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, uint64(version))
+	return txn.Set(schemaVersionKey, val)
+}
+
+// migrateEventKeys moves the events from <hash> to event_<hash> and the
+// participant indexes from <participant>__event_<index> to
+// pevent_<participant>_<index>, then builds the round index from the
+// created rounds
+func migrateEventKeys(txn *badger.Txn) error {
+	_, hashes, err := collectPrefix(txn, []byte(topoPrefix+"_"))
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		var hash EventHash
+		hash.Set(h)
+		if err := moveKey(txn, hash.Bytes(), eventKey(hash)); err != nil {
+			return err
+		}
+	}
+
+	participants, _, err := collectPrefix(txn, []byte(participantPrefix+"_"))
+	if err != nil {
+		return err
+	}
+	for _, key := range participants {
+		participant := string(key[len(participantPrefix)+1:])
+		prefix := []byte(participant + "__event_")
+		keys, _, err := collectPrefix(txn, prefix)
+		if err != nil {
+			return err
+		}
+		for _, old := range keys {
+			index, err := strconv.ParseInt(string(old[len(prefix):]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("key %s: %v", old, err)
+			}
+			if err := moveKey(txn, old, participantEventKey(participant, index)); err != nil {
+				return err
+			}
+		}
+	}
+
+	prefix := []byte(roundCreatedPrefix + "_")
+	keys, rounds, err := collectPrefix(txn, prefix)
+	if err != nil {
+		return err
+	}
+	for i, data := range rounds {
+		r, err := strconv.ParseInt(string(keys[i][len(prefix):]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("key %s: %v", keys[i], err)
+		}
+		var round RoundCreated
+		if err := round.ProtoUnmarshal(data); err != nil {
+			return fmt.Errorf("round %d: %v", r, err)
+		}
+		for x := range round.Message.Events {
+			var hash EventHash
+			if err := hash.Parse(x); err != nil {
+				return err
+			}
+			item, err := txn.Get(eventKey(hash))
+			if isDBKeyNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			eventBytes, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			var event Event
+			if err := event.ProtoUnmarshal(eventBytes); err != nil {
+				return err
+			}
+			peKey := participantEventKey(event.GetCreator(), event.Index())
+			if err := txn.Set(roundEventKey(r, hash), peKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectPrefix returns copies of the keys and values which start with
+// prefix. The iterator is closed before they are rewritten.
+func collectPrefix(txn *badger.Txn, prefix []byte) (keys, vals [][]byte, err error) {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, item.KeyCopy(nil))
+		vals = append(vals, val)
+	}
+	return keys, vals, nil
+}
+
+// moveKey renames a key, if it still exists
+func moveKey(txn *badger.Txn, from, to []byte) error {
+	item, err := txn.Get(from)
+	if isDBKeyNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+	if err := txn.Set(to, val); err != nil {
+		return err
+	}
+	return txn.Delete(from)
+}
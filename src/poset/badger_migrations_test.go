@@ -0,0 +1,161 @@
+package poset
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+)
+
+func TestBadgerMigrations(t *testing.T) {
+	if err := os.RemoveAll("test_data"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("test_data", os.ModeDir|0777); err != nil {
+		t.Fatal(err)
+	}
+	store := createTestDB("test_data/badger", t)
+	defer os.RemoveAll(store.path)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a second step which adds a key, on top of the released ones
+	marker := []byte("test_migration")
+	released := badgerMigrations
+	defer func() { badgerMigrations = released }()
+	badgerMigrations = append(badgerMigrations[:len(released):len(released)], Migration{
+		Description: "add a marker",
+		Migrate: func(txn *badger.Txn) error {
+			return txn.Set(marker, []byte("done"))
+		},
+	})
+
+	// schemaVersion returns the version of the database and whether the
+	// marker was written
+	schemaVersion := func() (int, bool) {
+		opts := badger.DefaultOptions
+		opts.Dir = store.path
+		opts.ValueDir = store.path
+		db, err := badger.Open(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		version, err := dbGetSchemaVersion(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.View(func(txn *badger.Txn) error {
+			_, err := txn.Get(marker)
+			return err
+		})
+		return version, err == nil
+	}
+
+	pending, err := MigrateBadgerStore(store.path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Version != len(released)+1 {
+		t.Fatalf("expected migration to version %d only, got %v", len(released)+1, pending)
+	}
+	if v, migrated := schemaVersion(); v != len(released) || migrated {
+		t.Fatalf("a dry run should not change the database, got version %d", v)
+	}
+
+	if _, err := LoadBadgerStoreReadOnly(cacheSize, store.path); err == nil {
+		t.Fatal("a database needing migrations should not be opened read-only")
+	}
+	if _, err := MigrateBadgerStore(store.path, false); err != nil {
+		t.Fatal(err)
+	}
+	if v, migrated := schemaVersion(); v != SchemaVersion() || !migrated {
+		t.Fatalf("expected schema version %d with the marker, got %d", SchemaVersion(), v)
+	}
+
+	// a database from a newer lachesis is refused
+	badgerMigrations = released
+	if _, err := MigrateBadgerStore(store.path, false); err == nil {
+		t.Fatal("migrating a newer database should fail")
+	}
+}
+
+func TestMigrateEventKeys(t *testing.T) {
+	store, participants := initBadgerStore(1, t)
+	defer os.RemoveAll(store.path)
+
+	// write the events with the keys of schema version 1
+	round := NewRoundCreated()
+	var events []Event
+	err := store.db.Update(func(txn *badger.Txn) error {
+		for i, p := range participants {
+			event := NewEvent([][]byte{[]byte(p.hex[:5])}, nil, nil, make(EventHashes, 2), p.pubKey, 0, nil)
+			event.Message.TopologicalIndex = int64(i)
+			hash := event.Hash()
+			data, err := event.ProtoMarshal()
+			if err != nil {
+				return err
+			}
+			for key, val := range map[string][]byte{
+				string(hash.Bytes()):                                     data,
+				string(topologicalEventKey(int64(i))):                    hash.Bytes(),
+				string(legacyParticipantEventKey(event.GetCreator(), 0)): hash.Bytes(),
+			} {
+				if err := txn.Set([]byte(key), val); err != nil {
+					return err
+				}
+			}
+			round.AddEvent(hash, false)
+			events = append(events, event)
+		}
+		data, err := round.ProtoMarshal()
+		if err != nil {
+			return err
+		}
+		if err := txn.Set(roundCreatedKey(0), data); err != nil {
+			return err
+		}
+		return setSchemaVersion(txnWriter{txn}, 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateBadgerStore(store.path, false); err != nil {
+		t.Fatal(err)
+	}
+	store, err = LoadBadgerStore(1, store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, event := range events {
+		hash := event.Hash()
+		if _, err := store.dbGetEventBlock(hash); err != nil {
+			t.Fatalf("event %v should have been moved: %v", hash, err)
+		}
+		pe, err := store.dbParticipantEvents(event.GetCreator(), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pe) != 1 || pe[0] != hash {
+			t.Fatalf("participant index should hold %v, not %v", hash, pe)
+		}
+		err = store.db.View(func(txn *badger.Txn) error {
+			if _, err := txn.Get(hash.Bytes()); !isDBKeyNotFound(err) {
+				return fmt.Errorf("old key of event %v should be deleted", hash)
+			}
+			_, err := txn.Get(roundEventKey(0, hash))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
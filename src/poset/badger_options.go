@@ -0,0 +1,98 @@
+package poset
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/badger/options"
+)
+
+// BadgerOptions are the badger settings of a BadgerStore which operators
+// tune to their hardware. Memory use is roughly NumMemtables * MaxTableSize,
+// plus the files which are memory-mapped.
+type BadgerOptions struct {
+	// ValueLogFileSize is the size in bytes past which badger starts a new
+	// value log file. Value log GC works file by file, so smaller files free
+	// space sooner.
+	ValueLogFileSize int64 `mapstructure:"badger-value-log-size"`
+	// NumMemtables is the number of tables kept in memory before writes
+	// stall
+	NumMemtables int `mapstructure:"badger-memtables"`
+	// MaxTableSize is the size in bytes of each memtable and LSM table
+	MaxTableSize int64 `mapstructure:"badger-table-size"`
+	// TableLoadingMode and ValueLogLoadingMode are "mmap", "ram" or
+	// "fileio". fileio uses the least memory and is the slowest.
+	TableLoadingMode    string `mapstructure:"badger-table-loading"`
+	ValueLogLoadingMode string `mapstructure:"badger-value-log-loading"`
+	// SyncWrites makes badger sync every write to disk, which the
+	// WriteStrict and WriteBehind modes need
+	SyncWrites bool `mapstructure:"badger-sync-writes"`
+	// Compression of the tables. Only "none" is supported: the badger
+	// release the store is built against does not compress.
+	Compression string `mapstructure:"badger-compression"`
+	// EncryptionKeyFile holds the key the values of the store are encrypted
+	// with, see StoreCipher. A store is encrypted, or not, from its
+	// creation.
+	EncryptionKeyFile string `mapstructure:"badger-encryption-key"`
+	// OldEncryptionKeyFiles hold the keys of earlier rotations, which the
+	// values written before are still read with
+	OldEncryptionKeyFiles []string `mapstructure:"badger-old-encryption-keys"`
+}
+
+// DefaultBadgerOptions returns the badger defaults, without SyncWrites
+func DefaultBadgerOptions() BadgerOptions {
+	return BadgerOptions{
+		ValueLogFileSize:    badger.DefaultOptions.ValueLogFileSize,
+		NumMemtables:        badger.DefaultOptions.NumMemtables,
+		MaxTableSize:        badger.DefaultOptions.MaxTableSize,
+		TableLoadingMode:    "mmap",
+		ValueLogLoadingMode: "mmap",
+		SyncWrites:          false,
+		Compression:         "none",
+	}
+}
+
+// badgerOptions returns the options to open the database at path with
+func (o BadgerOptions) badgerOptions(path string) (badger.Options, error) {
+	opts := badger.DefaultOptions
+	opts.Dir = path
+	opts.ValueDir = path
+	opts.SyncWrites = o.SyncWrites
+	if o.ValueLogFileSize > 0 {
+		opts.ValueLogFileSize = o.ValueLogFileSize
+	}
+	if o.NumMemtables > 0 {
+		opts.NumMemtables = o.NumMemtables
+	}
+	if o.MaxTableSize > 0 {
+		opts.MaxTableSize = o.MaxTableSize
+	}
+
+	var err error
+	if opts.TableLoadingMode, err = loadingMode(o.TableLoadingMode, opts.TableLoadingMode); err != nil {
+		return opts, fmt.Errorf("table loading: %v", err)
+	}
+	if opts.ValueLogLoadingMode, err = loadingMode(o.ValueLogLoadingMode, opts.ValueLogLoadingMode); err != nil {
+		return opts, fmt.Errorf("value log loading: %v", err)
+	}
+	switch o.Compression {
+	case "", "none":
+	default:
+		return opts, fmt.Errorf("badger compression %q is not supported, only none", o.Compression)
+	}
+	return opts, nil
+}
+
+func loadingMode(mode string, def options.FileLoadingMode) (options.FileLoadingMode, error) {
+	switch mode {
+	case "":
+		return def, nil
+	case "mmap":
+		return options.MemoryMap, nil
+	case "ram":
+		return options.LoadToRAM, nil
+	case "fileio":
+		return options.FileIO, nil
+	}
+	return def, fmt.Errorf("unknown loading mode %q, use mmap, ram or fileio", mode)
+}
@@ -0,0 +1,317 @@
+package poset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/sirupsen/logrus"
+)
+
+// quotaRetainRounds is the number of rounds a database over its quota keeps
+const quotaRetainRounds = 20
+
+// quotaDiscardRatio is the value log GC discard ratio of a database over its
+// quota: files are rewritten as soon as a tenth of them is garbage
+const quotaDiscardRatio = 0.1
+
+// prunedRoundKey holds the last round whose events, round infos and frame
+// were deleted
+var prunedRoundKey = []byte("pruned_round")
+
+// PrunerConfig configures the maintenance of a BadgerStore
+type PrunerConfig struct {
+	// Interval between runs, 0 disables the pruner
+	Interval time.Duration
+	// RetainRounds is the number of most recent rounds whose events, round
+	// infos and frames are kept, 0 keeps everything. Blocks are never pruned.
+	RetainRounds int64
+	// DiscardRatio is passed to the value log GC, see badger.DB.RunValueLogGC
+	DiscardRatio float64
+	// Quota is the disk usage in bytes past which a run prunes all but the
+	// last quotaRetainRounds rounds and collects the value log eagerly, 0
+	// disables it. QuotaExceeded is called if the database is still over the
+	// quota then, which should stop the node before the volume fills.
+	Quota         int64
+	QuotaExceeded func(error)
+}
+
+type pruner struct {
+	store  *BadgerStore
+	conf   PrunerConfig
+	busy   func() bool
+	logger *logrus.Entry
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// StartPruner runs value log GC and, if RetainRounds is set, deletes old
+// rounds every Interval, then enforces the Quota. Runs are skipped while busy
+// returns true, e.g. while the node catches up. The pruner stops when the store is closed.
+func (s *BadgerStore) StartPruner(conf PrunerConfig, busy func() bool, logger *logrus.Entry) {
+	if conf.Interval <= 0 || s.pruner != nil || s.readOnly {
+		return
+	}
+	if conf.DiscardRatio <= 0 || conf.DiscardRatio >= 1 {
+		conf.DiscardRatio = 0.5
+	}
+	s.pruner = &pruner{
+		store:  s,
+		conf:   conf,
+		busy:   busy,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+	s.pruner.wg.Add(1)
+	go s.pruner.run()
+}
+
+func (p *pruner) stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+func (p *pruner) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if p.busy != nil && p.busy() {
+				p.logger.Debug("Node is busy, skipping store maintenance")
+				continue
+			}
+			if p.conf.RetainRounds > 0 {
+				p.prune(p.conf.RetainRounds)
+			}
+			p.collectValueLog(p.conf.DiscardRatio)
+			p.checkQuota()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *pruner) prune(retain int64) {
+	pruned, err := p.store.Prune(p.store.LastRound() - retain)
+	if err != nil {
+		p.logger.WithError(err).Error("Pruning store")
+	} else if pruned > 0 {
+		p.logger.WithField("rounds", pruned).Debug("Pruned store")
+	}
+}
+
+// checkQuota prunes aggressively when the database is over its quota
+func (p *pruner) checkQuota() {
+	if p.conf.Quota <= 0 {
+		return
+	}
+	used, err := p.store.DiskUsage()
+	if err != nil {
+		p.logger.WithError(err).Error("Measuring store size")
+		return
+	}
+	if used <= p.conf.Quota {
+		return
+	}
+	p.logger.WithFields(logrus.Fields{
+		"used":  used,
+		"quota": p.conf.Quota,
+	}).Warn("Store is over its disk quota, pruning old rounds")
+
+	p.prune(quotaRetainRounds)
+	p.collectValueLog(quotaDiscardRatio)
+	if used, err = p.store.DiskUsage(); err != nil {
+		p.logger.WithError(err).Error("Measuring store size")
+		return
+	}
+	if used > p.conf.Quota && p.conf.QuotaExceeded != nil {
+		p.conf.QuotaExceeded(fmt.Errorf("store uses %d bytes after pruning, over its quota of %d bytes",
+			used, p.conf.Quota))
+	}
+}
+
+func (p *pruner) collectValueLog(discardRatio float64) {
+	// every successful run rewrites one file, go on until there is
+	// nothing left worth rewriting
+	for {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		err := p.store.db.RunValueLogGC(discardRatio)
+		if err == badger.ErrNoRewrite {
+			return
+		}
+		if err != nil {
+			p.logger.WithError(err).Error("Value log GC")
+			return
+		}
+	}
+}
+
+// CompactStore is a Store whose database can be compacted on demand
+type CompactStore interface {
+	Store
+	Compact() error
+}
+
+// Compact collects the value log of the database eagerly, to reclaim at
+// once the disk space of the pruned and overwritten records, which the
+// pruner otherwise reclaims over its runs. The badger release the store is
+// built against cannot flatten its LSM tree on demand, which it compacts on
+// its own.
+func (s *BadgerStore) Compact() error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	for {
+		err := s.db.RunValueLogGC(quotaDiscardRatio)
+		if err == badger.ErrNoRewrite {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// PrunedStore is a Store whose first rounds may have been pruned. The poset
+// of such a store bootstraps from the frame of its last block, see
+// Poset.Bootstrap.
+type PrunedStore interface {
+	Store
+	// PrunedRound returns the last pruned round, -1 if none was
+	PrunedRound() (int64, error)
+	// LastStoredBlock returns the last block of the database, which the
+	// store may not have loaded yet
+	LastStoredBlock() (Block, error)
+}
+
+// Prune deletes the events, round infos and frames of the rounds before
+// the given one from the database, and returns the number of rounds pruned.
+// With a cold storage, see SetColdStorage, they are moved there first.
+// Topological keys are kept so that TopologicalEvents can step over the
+// missing events. The round of the last block is never pruned: the poset
+// restarts from its frame.
+func (s *BadgerStore) Prune(before int64) (int64, error) {
+	// queued writes could recreate the keys of the pruned rounds
+	if err := s.Flush(); err != nil {
+		return 0, err
+	}
+	if block, err := s.GetBlock(s.LastBlockIndex()); err == nil && block.RoundReceived() < before {
+		before = block.RoundReceived()
+	}
+	last, err := s.dbGetPrunedRound()
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned int64
+	for r := last + 1; r < before; r++ {
+		if err := s.dbPruneRound(r); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// PrunedRound returns the last pruned round, -1 if none was
+func (s *BadgerStore) PrunedRound() (int64, error) {
+	return s.dbGetPrunedRound()
+}
+
+// LastStoredBlock reads the last block of the database with a reverse scan
+func (s *BadgerStore) LastStoredBlock() (Block, error) {
+	var block Block
+	err := s.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		// the indexes are zero-padded, every block key sorts before 0xff
+		prefix := []byte(blockPrefix + "_")
+		it.Seek(append(prefix, 0xff))
+		if !it.ValidForPrefix(prefix) {
+			return badger.ErrKeyNotFound
+		}
+		val, err := s.value(it.Item())
+		if err != nil {
+			return err
+		}
+		return block.ProtoUnmarshal(val)
+	})
+	return block, mapError(err, "Block", "last")
+}
+
+func (s *BadgerStore) dbGetPrunedRound() (int64, error) {
+	round := int64(-1)
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(prunedRoundKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			round = int64(binary.BigEndian.Uint64(val))
+			return nil
+		})
+	})
+	if isDBKeyNotFound(err) {
+		err = nil
+	}
+	return round, err
+}
+
+// dbPruneRound deletes the events of round r, found with a prefix scan of
+// the round index, and its round infos and frame
+func (s *BadgerStore) dbPruneRound(r int64) error {
+	tx := s.db.NewTransaction(true)
+	defer tx.Discard()
+
+	// the iterator is closed before the keys are deleted
+	var keys [][]byte
+	var hashes []EventHash
+	prefix := roundEventPrefixKey(r)
+	it := tx.NewIterator(badger.DefaultIteratorOptions)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		peKey, err := s.value(item)
+		if err != nil {
+			it.Close()
+			return err
+		}
+		key := item.KeyCopy(nil)
+		var hash EventHash
+		hash.Set(key[len(prefix):])
+		keys = append(keys, key, peKey, eventKey(hash))
+		hashes = append(hashes, hash)
+	}
+	it.Close()
+
+	if s.cold != nil {
+		if err := s.moveToColdStorage(tx, r, hashes); err != nil {
+			return err
+		}
+	}
+
+	keys = append(keys, roundCreatedKey(r), roundReceivedKey(r), frameKey(r))
+	for _, key := range keys {
+		if err := tx.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, uint64(r))
+	if err := tx.Set(prunedRoundKey, val); err != nil {
+		return err
+	}
+
+	return tx.Commit(nil)
+}
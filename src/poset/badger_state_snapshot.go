@@ -0,0 +1,124 @@
+package poset
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/badger"
+)
+
+func stateSnapshotKey(stateHash []byte) []byte {
+	return []byte(fmt.Sprintf("%s_%s", stateSnapshotPrefix, hex.EncodeToString(stateHash)))
+}
+
+func stateChunkKey(stateHash []byte, index int) []byte {
+	return []byte(fmt.Sprintf("%s_%s_%06d", stateChunkPrefix, hex.EncodeToString(stateHash), index))
+}
+
+// SetStateSnapshot implements StateSnapshotStore. The chunks are written
+// outside of the batches of StartBatch, in as many transactions as they
+// need, as a snapshot may not fit in one.
+func (s *BadgerStore) SetStateSnapshot(snapshot StateSnapshot, chunks [][]byte) error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	if len(snapshot.StateHash) == 0 {
+		return fmt.Errorf("state snapshot of block %d has no state hash", snapshot.BlockIndex)
+	}
+	if len(chunks) != len(snapshot.ChunkHashes) {
+		return fmt.Errorf("state snapshot of block %d has %d chunks, expected %d",
+			snapshot.BlockIndex, len(chunks), len(snapshot.ChunkHashes))
+	}
+	snapshots, err := s.dbStateSnapshots()
+	if err != nil {
+		return err
+	}
+	for _, old := range snapshots {
+		if string(old.StateHash) == string(snapshot.StateHash) {
+			return nil
+		}
+	}
+	val, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	wb := s.newSplitWrite()
+	defer wb.Cancel()
+	for i, chunk := range chunks {
+		if err := wb.Set(stateChunkKey(snapshot.StateHash, i), chunk); err != nil {
+			return err
+		}
+	}
+	// the manifest goes last, a snapshot is only visible once complete
+	if err := wb.Set(stateSnapshotKey(snapshot.StateHash), val); err != nil {
+		return err
+	}
+	snapshots = append(snapshots, snapshot)
+	for i := 0; i < len(snapshots)-stateSnapshotsRetained; i++ {
+		old := snapshots[i]
+		if err := wb.Delete(stateSnapshotKey(old.StateHash)); err != nil {
+			return err
+		}
+		for c := range old.ChunkHashes {
+			if err := wb.Delete(stateChunkKey(old.StateHash, c)); err != nil {
+				return err
+			}
+		}
+	}
+	return wb.Flush()
+}
+
+// GetStateSnapshot implements StateSnapshotStore
+func (s *BadgerStore) GetStateSnapshot(stateHash []byte) (snapshot StateSnapshot, err error) {
+	err = s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(stateSnapshotKey(stateHash))
+		if err != nil {
+			return err
+		}
+		val, err := s.value(item)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(val, &snapshot)
+	})
+	if isDBKeyNotFound(err) {
+		err = stateSnapshotNotFound(stateHash, -1)
+	}
+	return
+}
+
+// GetStateSnapshotChunk implements StateSnapshotStore
+func (s *BadgerStore) GetStateSnapshotChunk(stateHash []byte, index int) (chunk []byte, err error) {
+	err = s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(stateChunkKey(stateHash, index))
+		if err != nil {
+			return err
+		}
+		chunk, err = s.value(item)
+		return err
+	})
+	if isDBKeyNotFound(err) {
+		err = stateSnapshotNotFound(stateHash, index)
+	}
+	return
+}
+
+// dbStateSnapshots returns the stored snapshots, oldest first
+func (s *BadgerStore) dbStateSnapshots() ([]StateSnapshot, error) {
+	var snapshots []StateSnapshot
+	err := s.dbRangeKeys(stateSnapshotPrefix, func(key, val []byte) error {
+		var snapshot StateSnapshot
+		if err := json.Unmarshal(val, &snapshot); err != nil {
+			return fmt.Errorf("key %s: %v", key, err)
+		}
+		snapshots = append(snapshots, snapshot)
+		return nil
+	})
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].BlockIndex < snapshots[j].BlockIndex
+	})
+	return snapshots, err
+}
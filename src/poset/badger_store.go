@@ -1,8 +1,13 @@
 package poset
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/dgraph-io/badger"
 
@@ -13,17 +18,42 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/src/state"
 )
 
+// The keys of a BadgerStore are grouped by prefix, so that each kind of
+// record can be read with a prefix scan:
+//
+//	event_<hash>                          => event
+//	pevent_<participant>_<index>          => event hash, by creator
+//	revent_<round created>_<hash>         => pevent key, by round
+//	topo_<index>                          => event hash, in topological order
+//	block_<index>, frame_<round>, roundCreated_<round>, roundReceived_<round>
+//	ssnap_<state hash>, schunk_<state hash>_<chunk>  => application snapshots
+//	receipts_<block index>                => transaction results of a block
+//	tx_<tx hash>                          => TxLocation of a committed transaction
+//	bevent_<block index>                  => hashes of the events of a block
+//
+// Indexes are zero-padded so that keys sort in index order.
 const (
-	participantPrefix   = "participant"
-	rootSuffix          = "root"
-	roundCreatedPrefix  = "roundCreated"
-	roundReceivedPrefix = "roundReceived"
-	topoPrefix          = "topo"
-	blockPrefix         = "block"
-	framePrefix         = "frame"
-	statePrefix         = "state"
+	eventPrefix            = "event"
+	participantEventPrefix = "pevent"
+	roundEventPrefix       = "revent"
+	participantPrefix      = "participant"
+	rootSuffix             = "root"
+	roundCreatedPrefix     = "roundCreated"
+	roundReceivedPrefix    = "roundReceived"
+	topoPrefix             = "topo"
+	blockPrefix            = "block"
+	framePrefix            = "frame"
+	statePrefix            = "state"
+	stateSnapshotPrefix    = "ssnap"
+	stateChunkPrefix       = "schunk"
+	receiptsPrefix         = "receipts"
+	txPrefix               = "tx"
+	blockEventsPrefix      = "bevent"
 )
 
+// ErrReadOnlyStore is returned when writing to a store opened read-only
+var ErrReadOnlyStore = errors.New("store is read-only")
+
 // BadgerStore struct for badger config data
 type BadgerStore struct {
 	participants  *peers.Peers
@@ -31,18 +61,44 @@ type BadgerStore struct {
 	db            *badger.DB
 	path          string
 	needBootstrap bool
+	readOnly      bool
+	cipher        *StoreCipher // nil if the store is not encrypted
+	removeOnClose bool         // see LoadBadgerBackup
 
 	states    state.Database
 	stateRoot common.Hash
+
+	pruner *pruner
+	cold   ColdStorage
+
+	// pending writes, see StartBatch
+	batch      *badger.Txn
+	batchDepth int
+	batchLock  sync.Mutex
+
+	// see SetWriteMode
+	syncWrites bool
+	writer     *writeBehind
+	pending    *writeBatch // batch recorded in WriteBehind mode
 }
 
 // NewBadgerStore creates a brand new Store with a new database
 func NewBadgerStore(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config) (*BadgerStore, error) {
+	return NewBadgerStoreWithOptions(participants, cacheSize, path, posConf, DefaultBadgerOptions())
+}
+
+// NewBadgerStoreWithOptions creates a brand new Store with a new database
+// opened with the given badger options
+func NewBadgerStoreWithOptions(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config, o BadgerOptions) (*BadgerStore, error) {
+	opts, err := o.badgerOptions(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := o.cipher()
+	if err != nil {
+		return nil, err
+	}
 	inmemStore := NewInmemStore(participants, cacheSize, posConf)
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	opts.SyncWrites = false
 	handle, err := badger.Open(opts)
 	if err != nil {
 		return nil, err
@@ -52,10 +108,16 @@ func NewBadgerStore(participants *peers.Peers, cacheSize int, path string, posCo
 		inmemStore:   inmemStore,
 		db:           handle,
 		path:         path,
-		states: state.NewDatabase(
-			kvdb.NewTable(
-				kvdb.NewBadgerDatabase(
-					handle), statePrefix)),
+		cipher:       c,
+		syncWrites:   opts.SyncWrites,
+		states:       state.NewDatabase(stateDatabase(handle, c)),
+	}
+	if c != nil {
+		if err := store.update(func(tx dbWriter) error {
+			return tx.Set(encryptionCheckKey, encryptionCheckValue)
+		}); err != nil {
+			return nil, err
+		}
 	}
 	if err := store.dbSetParticipants(participants); err != nil {
 		return nil, err
@@ -63,6 +125,11 @@ func NewBadgerStore(participants *peers.Peers, cacheSize int, path string, posCo
 	if err := store.dbSetRoots(inmemStore.rootsByParticipant); err != nil {
 		return nil, err
 	}
+	if err := store.update(func(tx dbWriter) error {
+		return setSchemaVersion(tx, SchemaVersion())
+	}); err != nil {
+		return nil, err
+	}
 
 	// TODO: replace with real genesis
 	store.stateRoot, err = pos.FakeGenesis(participants, posConf, store.states)
@@ -75,27 +142,65 @@ func NewBadgerStore(participants *peers.Peers, cacheSize int, path string, posCo
 
 // LoadBadgerStore creates a Store from an existing database
 func LoadBadgerStore(cacheSize int, path string) (*BadgerStore, error) {
+	return loadBadgerStore(cacheSize, path, false, DefaultBadgerOptions())
+}
+
+// LoadBadgerStoreWithOptions creates a Store from an existing database
+// opened with the given badger options
+func LoadBadgerStoreWithOptions(cacheSize int, path string, o BadgerOptions) (*BadgerStore, error) {
+	return loadBadgerStore(cacheSize, path, false, o)
+}
+
+// LoadBadgerStoreReadOnly opens an existing database without writing to it:
+// the Set methods return ErrReadOnlyStore. Several processes can open the
+// same database read-only at once, but not while a node has it open, badger
+// locks the directory: the database of a running node is read from a backup,
+// see LoadBadgerBackup. The database must have been closed properly.
+func LoadBadgerStoreReadOnly(cacheSize int, path string) (*BadgerStore, error) {
+	return loadBadgerStore(cacheSize, path, true, DefaultBadgerOptions())
+}
+
+// LoadBadgerStoreReadOnlyWithOptions opens an existing database read-only,
+// see LoadBadgerStoreReadOnly, with the given badger options
+func LoadBadgerStoreReadOnlyWithOptions(cacheSize int, path string, o BadgerOptions) (*BadgerStore, error) {
+	return loadBadgerStore(cacheSize, path, true, o)
+}
+
+func loadBadgerStore(cacheSize int, path string, readOnly bool, o BadgerOptions) (*BadgerStore, error) {
 
 	if _, err := os.Stat(path); err != nil {
 		return nil, err
 	}
 
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	opts.SyncWrites = false
+	opts, err := o.badgerOptions(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := o.cipher()
+	if err != nil {
+		return nil, err
+	}
+	opts.ReadOnly = readOnly
 	handle, err := badger.Open(opts)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkEncryption(handle, c); err != nil {
+		handle.Close()
+		return nil, err
+	}
+	if err := checkSchemaVersion(handle, readOnly); err != nil {
+		handle.Close()
+		return nil, err
+	}
 	store := &BadgerStore{
 		db:            handle,
 		path:          path,
 		needBootstrap: true,
-		states: state.NewDatabase(
-			kvdb.NewTable(
-				kvdb.NewBadgerDatabase(
-					handle), statePrefix)),
+		readOnly:      readOnly,
+		cipher:        c,
+		syncWrites:    opts.SyncWrites,
+		states:        state.NewDatabase(stateDatabase(handle, c)),
 	}
 
 	participants, err := store.dbGetParticipants()
@@ -127,11 +232,26 @@ func LoadBadgerStore(cacheSize int, path string) (*BadgerStore, error) {
 
 // LoadOrCreateBadgerStore load or create a new badger store
 func LoadOrCreateBadgerStore(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config) (*BadgerStore, error) {
-	store, err := LoadBadgerStore(cacheSize, path)
+	return LoadOrCreateBadgerStoreWithOptions(participants, cacheSize, path, posConf, DefaultBadgerOptions())
+}
+
+// LoadOrCreateBadgerStoreWithOptions load or create a new badger store
+// opened with the given badger options
+func LoadOrCreateBadgerStoreWithOptions(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config, o BadgerOptions) (*BadgerStore, error) {
+	if _, err := o.badgerOptions(path); err != nil {
+		return nil, err
+	}
+	if _, err := o.cipher(); err != nil {
+		return nil, err
+	}
+	store, err := LoadBadgerStoreWithOptions(cacheSize, path, o)
+	if isEncryptionError(err) {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
 
 	if err != nil {
 		fmt.Println("Could not load store - creating new")
-		store, err = NewBadgerStore(participants, cacheSize, path, posConf)
+		store, err = NewBadgerStoreWithOptions(participants, cacheSize, path, posConf, o)
 
 		if err != nil {
 			return nil, err
@@ -152,10 +272,28 @@ func participantKey(participant string) []byte {
 	return []byte(fmt.Sprintf("%s_%s", participantPrefix, participant))
 }
 
+func eventKey(hash EventHash) []byte {
+	return append([]byte(eventPrefix+"_"), hash.Bytes()...)
+}
+
 func participantEventKey(participant string, index int64) []byte {
+	return []byte(fmt.Sprintf("%s_%s_%09d", participantEventPrefix, participant, index))
+}
+
+// legacyParticipantEventKey is the participant event key of badger schema
+// version 1, which the BoltStore still uses
+func legacyParticipantEventKey(participant string, index int64) []byte {
 	return []byte(fmt.Sprintf("%s__event_%09d", participant, index))
 }
 
+func roundEventPrefixKey(round int64) []byte {
+	return []byte(fmt.Sprintf("%s_%09d_", roundEventPrefix, round))
+}
+
+func roundEventKey(round int64, hash EventHash) []byte {
+	return append(roundEventPrefixKey(round), hash.Bytes()...)
+}
+
 func participantRootKey(participant string) []byte {
 	return []byte(fmt.Sprintf("%s_%s", participant, rootSuffix))
 }
@@ -181,41 +319,49 @@ func frameKey(index int64) []byte {
 
 // TopologicalEvents returns event in topological order.
 func (s *BadgerStore) TopologicalEvents() ([]Event, error) {
+	defer observeOp("badger", "TopologicalEvents", time.Now())
 	var res []Event
 	var evKey string
 	t := int64(0)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		key := topologicalEventKey(t)
 		item, errr := txn.Get(key)
 		for errr == nil {
-			errrr := item.Value(func(v []byte) error {
-				evKey = string(v)
-				return nil
-			})
+			v, errrr := s.value(item)
 			if errrr != nil {
-				break
+				return errrr
+			}
+			evKey = string(v)
+
+			var hash EventHash
+			hash.Set([]byte(evKey))
+			eventItem, err := txn.Get(eventKey(hash))
+			if isDBKeyNotFound(err) {
+				// the event was pruned, see Prune
+				t++
+				key = topologicalEventKey(t)
+				item, errr = txn.Get(key)
+				continue
 			}
-
-			eventItem, err := txn.Get([]byte(evKey))
 			if err != nil {
 				return err
 			}
-			err = eventItem.Value(func(eventBytes []byte) error {
-				event := &Event{
-					roundReceived:    RoundNIL,
-					round:            RoundNIL,
-					lamportTimestamp: LamportTimestampNIL,
-				}
-
-				if err := event.ProtoUnmarshal(eventBytes); err != nil {
-					return err
-				}
-				res = append(res, *event)
-				return nil
-			})
+			eventBytes, err := s.value(eventItem)
 			if err != nil {
 				return err
 			}
+			event := &Event{
+				roundReceived:    RoundNIL,
+				round:            RoundNIL,
+				lamportTimestamp: LamportTimestampNIL,
+			}
+			if err := event.ProtoUnmarshal(eventBytes); err != nil {
+				return err
+			}
+			// the key holds the index, a poset reset from a frame
+			// rewrites the events of the frame with new ones
+			event.Message.TopologicalIndex = t
+			res = append(res, *event)
 
 			t++
 			key = topologicalEventKey(t)
@@ -254,17 +400,26 @@ func (s *BadgerStore) RootsByParticipant() map[string]Root {
 
 // GetEventBlock get specific event block by hash
 func (s *BadgerStore) GetEventBlock(hash EventHash) (event Event, err error) {
+	defer observeOp("badger", "GetEventBlock", time.Now())
 	// try to get it from cache
 	event, err = s.inmemStore.GetEventBlock(hash)
 	// if not in cache, try to get it from db
 	if err != nil {
 		event, err = s.dbGetEventBlock(hash)
 	}
+	// pruned events may be in cold storage
+	if isDBKeyNotFound(err) {
+		event, err = s.coldGetEventBlock(hash)
+	}
 	return event, mapError(err, "Event", hash.String())
 }
 
 // SetEvent set a specific event
 func (s *BadgerStore) SetEvent(event Event) error {
+	defer observeOp("badger", "SetEvent", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
 	// try to add it to the cache
 	if err := s.inmemStore.SetEvent(event); err != nil {
 		return err
@@ -275,6 +430,7 @@ func (s *BadgerStore) SetEvent(event Event) error {
 
 // ParticipantEvents return all participant events
 func (s *BadgerStore) ParticipantEvents(participant string, skip int64) (EventHashes, error) {
+	defer observeOp("badger", "ParticipantEvents", time.Now())
 	res, err := s.inmemStore.ParticipantEvents(participant, skip)
 	if err != nil {
 		res, err = s.dbParticipantEvents(participant, skip)
@@ -318,15 +474,23 @@ func (s *BadgerStore) AddConsensusEvent(event Event) error {
 
 // GetRoundCreated gets the created round info for a given index
 func (s *BadgerStore) GetRoundCreated(r int64) (RoundCreated, error) {
+	defer observeOp("badger", "GetRoundCreated", time.Now())
 	res, err := s.inmemStore.GetRoundCreated(r)
 	if err != nil {
 		res, err = s.dbGetRoundCreated(r)
 	}
+	if isDBKeyNotFound(err) {
+		res, err = s.coldGetRoundCreated(r)
+	}
 	return res, mapError(err, "RoundCreated", string(roundCreatedKey(r)))
 }
 
 // SetRoundCreated sets the created round info for a given index
 func (s *BadgerStore) SetRoundCreated(r int64, round RoundCreated) error {
+	defer observeOp("badger", "SetRoundCreated", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
 	if err := s.inmemStore.SetRoundCreated(r, round); err != nil {
 		return err
 	}
@@ -335,15 +499,23 @@ func (s *BadgerStore) SetRoundCreated(r int64, round RoundCreated) error {
 
 // GetRoundReceived gets the received round for a given index
 func (s *BadgerStore) GetRoundReceived(r int64) (RoundReceived, error) {
+	defer observeOp("badger", "GetRoundReceived", time.Now())
 	res, err := s.inmemStore.GetRoundReceived(r)
 	if err != nil {
 		res, err = s.dbGetRoundReceived(r)
 	}
+	if isDBKeyNotFound(err) {
+		res, err = s.coldGetRoundReceived(r)
+	}
 	return res, mapError(err, "RoundReceived", string(roundReceivedKey(r)))
 }
 
 // SetRoundReceived sets the received round info for a given index
 func (s *BadgerStore) SetRoundReceived(r int64, round RoundReceived) error {
+	defer observeOp("badger", "SetRoundReceived", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
 	if err := s.inmemStore.SetRoundReceived(r, round); err != nil {
 		return err
 	}
@@ -384,6 +556,7 @@ func (s *BadgerStore) GetRoot(participant string) (Root, error) {
 
 // GetBlock returns the block for a given index
 func (s *BadgerStore) GetBlock(rr int64) (Block, error) {
+	defer observeOp("badger", "GetBlock", time.Now())
 	res, err := s.inmemStore.GetBlock(rr)
 	if err != nil {
 		res, err = s.dbGetBlock(rr)
@@ -393,6 +566,10 @@ func (s *BadgerStore) GetBlock(rr int64) (Block, error) {
 
 // SetBlock add a block
 func (s *BadgerStore) SetBlock(block Block) error {
+	defer observeOp("badger", "SetBlock", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
 	if err := s.inmemStore.SetBlock(block); err != nil {
 		return err
 	}
@@ -406,15 +583,23 @@ func (s *BadgerStore) LastBlockIndex() int64 {
 
 // GetFrame returns a specific frame for the index
 func (s *BadgerStore) GetFrame(rr int64) (Frame, error) {
+	defer observeOp("badger", "GetFrame", time.Now())
 	res, err := s.inmemStore.GetFrame(rr)
 	if err != nil {
 		res, err = s.dbGetFrame(rr)
 	}
+	if isDBKeyNotFound(err) {
+		res, err = s.coldGetFrame(rr)
+	}
 	return res, mapError(err, "Frame", string(frameKey(rr)))
 }
 
 // SetFrame add a frame
 func (s *BadgerStore) SetFrame(frame Frame) error {
+	defer observeOp("badger", "SetFrame", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
 	if err := s.inmemStore.SetFrame(frame); err != nil {
 		return err
 	}
@@ -428,10 +613,32 @@ func (s *BadgerStore) Reset(roots map[string]Root) error {
 
 // Close badger
 func (s *BadgerStore) Close() error {
+	if s.pruner != nil {
+		s.pruner.stop()
+	}
+	s.batchLock.Lock()
+	if s.batch != nil || s.pending != nil {
+		if err := s.commitBatch(); err != nil {
+			s.batchLock.Unlock()
+			return err
+		}
+	}
+	s.batchLock.Unlock()
+	if s.writer != nil {
+		if err := s.writer.stop(); err != nil {
+			return err
+		}
+	}
 	if err := s.inmemStore.Close(); err != nil {
 		return err
 	}
-	return s.db.Close()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	if s.removeOnClose {
+		return os.RemoveAll(s.path)
+	}
+	return nil
 }
 
 // NeedBootstrap checks if bootstrapping is required
@@ -454,20 +661,75 @@ func (s *BadgerStore) StateRoot() common.Hash {
 	return s.stateRoot
 }
 
+// EventsRange reads the events in the range with a prefix scan of the
+// topological index, see Store. Pruned events are skipped.
+func (s *BadgerStore) EventsRange(from, to int64, fn func(Event) error) error {
+	defer observeOp("badger", "EventsRange", time.Now())
+	return s.dbRange(topoPrefix, topologicalEventKey, from, to, func(txn *badger.Txn, _ int64, val []byte) error {
+		var hash EventHash
+		hash.Set(val)
+		item, err := txn.Get(eventKey(hash))
+		if isDBKeyNotFound(err) {
+			// the event was pruned, see Prune
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		eventBytes, err := s.value(item)
+		if err != nil {
+			return err
+		}
+		event := Event{
+			roundReceived:    RoundNIL,
+			round:            RoundNIL,
+			lamportTimestamp: LamportTimestampNIL,
+		}
+		if err := event.ProtoUnmarshal(eventBytes); err != nil {
+			return err
+		}
+		return fn(event)
+	})
+}
+
+// BlocksRange reads the blocks in the range with a prefix scan, see Store
+func (s *BadgerStore) BlocksRange(from, to int64, fn func(Block) error) error {
+	defer observeOp("badger", "BlocksRange", time.Now())
+	return s.dbRange(blockPrefix, blockKey, from, to, func(_ *badger.Txn, _ int64, val []byte) error {
+		var block Block
+		if err := block.ProtoUnmarshal(val); err != nil {
+			return err
+		}
+		return fn(block)
+	})
+}
+
+// RoundsRange reads the created rounds in the range with a prefix scan, see
+// Store
+func (s *BadgerStore) RoundsRange(from, to int64, fn func(int64, RoundCreated) error) error {
+	defer observeOp("badger", "RoundsRange", time.Now())
+	return s.dbRange(roundCreatedPrefix, roundCreatedKey, from, to, func(txn *badger.Txn, index int64, val []byte) error {
+		round := new(RoundCreated)
+		if err := round.ProtoUnmarshal(val); err != nil {
+			return err
+		}
+		// see dbGetRoundCreated
+		round.Message.Queued = false
+		return fn(index, *round)
+	})
+}
+
 // ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 // DB Methods
 
 func (s *BadgerStore) dbGetEventBlock(hash EventHash) (Event, error) {
 	var eventBytes []byte
-	err := s.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(hash.Bytes())
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(eventKey(hash))
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			eventBytes = val
-			return nil
-		})
+		eventBytes, err = s.value(item)
 		return err
 	})
 
@@ -484,70 +746,58 @@ func (s *BadgerStore) dbGetEventBlock(hash EventHash) (Event, error) {
 }
 
 func (s *BadgerStore) dbSetEvents(events []Event) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
-
-	for _, event := range events {
-		eventHash := event.Hash()
-		val, err := event.ProtoMarshal()
-		if err != nil {
-			return err
-		}
-		// check if it already exists
-		notFound := true
-		_, err = tx.Get(eventHash.Bytes())
-		if err == nil {
-			notFound = false
-		} else if !isDBKeyNotFound(err) {
-			return err
-		}
-
-		// insert [event hash] => [event bytes]
-		if err := tx.Set(eventHash.Bytes(), val); err != nil {
-			return err
-		}
-
-		if notFound {
-			// insert [topo_index] => [event hash]
-			topoKey := topologicalEventKey(event.Message.TopologicalIndex)
-			if err := tx.Set(topoKey, eventHash.Bytes()); err != nil {
+	return s.update(func(tx dbWriter) error {
+		for _, event := range events {
+			eventHash := event.Hash()
+			val, err := event.ProtoMarshal()
+			if err != nil {
 				return err
 			}
-			// insert [participant_index] => [event hash]
-			peKey := participantEventKey(event.GetCreator(), event.Index())
-			if err := tx.Set(peKey, eventHash.Bytes()); err != nil {
+			// check if it already exists
+			found, err := tx.has(eventKey(eventHash))
+			if err != nil {
 				return err
 			}
-		}
-	}
-	return tx.Commit(nil)
-}
 
-func (s *BadgerStore) dbParticipantEvents(participant string, skip int64) (res EventHashes, err error) {
-	err = s.db.View(func(txn *badger.Txn) error {
-		i := skip + 1
-		key := participantEventKey(participant, i)
-		item, errr := txn.Get(key)
-		for errr == nil {
-			errrr := item.Value(func(v []byte) error {
-				var hash EventHash
-				hash.Set(v)
-				res = append(res, hash)
-				return nil
-			})
-			if errrr != nil {
-				break
+			peKey := participantEventKey(event.GetCreator(), event.Index())
+			if !found {
+				// insert [topo_index] => [event hash]
+				topoKey := topologicalEventKey(event.Message.TopologicalIndex)
+				if err := tx.Set(topoKey, eventHash.Bytes()); err != nil {
+					return err
+				}
+				// insert [participant_index] => [event hash]
+				if err := tx.Set(peKey, eventHash.Bytes()); err != nil {
+					return err
+				}
+			}
+			// insert [round_hash] => [participant_index] once the round of
+			// the event is known, see Poset.DivideRounds
+			if round := event.GetRound(); round != RoundNIL {
+				if err := tx.Set(roundEventKey(round, eventHash), peKey); err != nil {
+					return err
+				}
 			}
 
-			i++
-			key = participantEventKey(participant, i)
-			item, errr = txn.Get(key)
-		}
-
-		if !isDBKeyNotFound(errr) {
-			return errr
+			// insert [event hash] => [event bytes] last, so that an update
+			// retried after ErrTxnTooBig writes the keys above again
+			if err := tx.Set(eventKey(eventHash), val); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
+func (s *BadgerStore) dbParticipantEvents(participant string, skip int64) (res EventHashes, err error) {
+	key := func(index int64) []byte {
+		return participantEventKey(participant, index)
+	}
+	prefix := fmt.Sprintf("%s_%s", participantEventPrefix, participant)
+	err = s.dbRange(prefix, key, skip+1, -1, func(_ *badger.Txn, _ int64, val []byte) error {
+		var hash EventHash
+		hash.Set(val)
+		res = append(res, hash)
 		return nil
 	})
 	return
@@ -556,51 +806,49 @@ func (s *BadgerStore) dbParticipantEvents(participant string, skip int64) (res E
 func (s *BadgerStore) dbParticipantEvent(participant string, index int64) (hash EventHash, err error) {
 	key := participantEventKey(participant, index)
 
-	err = s.db.View(func(txn *badger.Txn) error {
+	err = s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			hash.Set(val)
-			return nil
-		})
-		return err
+		val, err := s.value(item)
+		if err != nil {
+			return err
+		}
+		hash.Set(val)
+		return nil
 	})
 
 	return
 }
 
 func (s *BadgerStore) dbSetRoots(roots map[string]Root) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
-	for participant, root := range roots {
-		val, err := root.ProtoMarshal()
-		if err != nil {
-			return err
-		}
-		key := participantRootKey(participant)
-		// fmt.Println("Setting root", participant, "->", key)
-		// insert [participant_root] => [root bytes]
-		if err := tx.Set(key, val); err != nil {
-			return err
+	return s.update(func(tx dbWriter) error {
+		for participant, root := range roots {
+			val, err := root.ProtoMarshal()
+			if err != nil {
+				return err
+			}
+			key := participantRootKey(participant)
+			// fmt.Println("Setting root", participant, "->", key)
+			// insert [participant_root] => [root bytes]
+			if err := tx.Set(key, val); err != nil {
+				return err
+			}
 		}
-	}
-	return tx.Commit(nil)
+		return nil
+	})
 }
 
 func (s *BadgerStore) dbGetRoot(participant string) (Root, error) {
 	var rootBytes []byte
 	key := participantRootKey(participant)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			rootBytes = val
-			return nil
-		})
+		rootBytes, err = s.value(item)
 		return err
 	})
 
@@ -619,15 +867,12 @@ func (s *BadgerStore) dbGetRoot(participant string) (Root, error) {
 func (s *BadgerStore) dbGetRoundCreated(index int64) (RoundCreated, error) {
 	var roundBytes []byte
 	key := roundCreatedKey(index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			roundBytes = val
-			return nil
-		})
+		roundBytes, err = s.value(item)
 		return err
 	})
 
@@ -647,36 +892,68 @@ func (s *BadgerStore) dbGetRoundCreated(index int64) (RoundCreated, error) {
 	return *roundInfo, nil
 }
 
+// dbRange calls fn with the indexes and values of the keys in
+// [key(from), key(to)) which start with prefix, in order. The keys are
+// zero-padded, so their order is the order of the indexes.
+func (s *BadgerStore) dbRange(prefix string, key func(int64) []byte, from, to int64,
+	fn func(txn *badger.Txn, index int64, val []byte) error) error {
+	valid := []byte(prefix + "_")
+	var end []byte
+	if to >= 0 {
+		end = key(to)
+	}
+	err := s.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Seek(key(from)); it.ValidForPrefix(valid); it.Next() {
+			item := it.Item()
+			if end != nil && bytes.Compare(item.Key(), end) >= 0 {
+				return nil
+			}
+			index, err := strconv.ParseInt(string(item.Key()[len(valid):]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("key %s: %v", item.Key(), err)
+			}
+			val, err := s.value(item)
+			if err != nil {
+				return err
+			}
+			if err := fn(txn, index, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return stopRange(err)
+}
+
 func (s *BadgerStore) dbSetRoundCreated(index int64, round RoundCreated) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
+	return s.update(func(tx dbWriter) error {
 
-	key := roundCreatedKey(index)
-	val, err := round.ProtoMarshal()
-	if err != nil {
-		return err
-	}
+		key := roundCreatedKey(index)
+		val, err := round.ProtoMarshal()
+		if err != nil {
+			return err
+		}
 
-	// insert [round_index] => [round bytes]
-	if err := tx.Set(key, val); err != nil {
-		return err
-	}
+		// insert [round_index] => [round bytes]
+		if err := tx.Set(key, val); err != nil {
+			return err
+		}
 
-	return tx.Commit(nil)
+		return nil
+	})
 }
 
 func (s *BadgerStore) dbGetRoundReceived(index int64) (RoundReceived, error) {
 	var roundBytes []byte
 	key := roundReceivedKey(index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			roundBytes = val
-			return nil
-		})
+		roundBytes, err = s.value(item)
 		return err
 	})
 
@@ -693,27 +970,27 @@ func (s *BadgerStore) dbGetRoundReceived(index int64) (RoundReceived, error) {
 }
 
 func (s *BadgerStore) dbSetRoundReceived(index int64, round RoundReceived) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
+	return s.update(func(tx dbWriter) error {
 
-	key := roundReceivedKey(index)
-	val, err := round.ProtoMarshal()
-	if err != nil {
-		return err
-	}
+		key := roundReceivedKey(index)
+		val, err := round.ProtoMarshal()
+		if err != nil {
+			return err
+		}
 
-	// insert [round_index] => [round bytes]
-	if err := tx.Set(key, val); err != nil {
-		return err
-	}
+		// insert [round_index] => [round bytes]
+		if err := tx.Set(key, val); err != nil {
+			return err
+		}
 
-	return tx.Commit(nil)
+		return nil
+	})
 }
 
 func (s *BadgerStore) dbGetParticipants() (*peers.Peers, error) {
 	res := peers.NewPeers()
 
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		it := txn.NewIterator(badger.DefaultIteratorOptions)
 		defer it.Close()
 		prefix := []byte(participantPrefix)
@@ -734,34 +1011,31 @@ func (s *BadgerStore) dbGetParticipants() (*peers.Peers, error) {
 }
 
 func (s *BadgerStore) dbSetParticipants(participants *peers.Peers) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
-
-	participants.RLock()
-	defer participants.RUnlock()
-	for participant, id := range participants.ByPubKey {
-		key := participantKey(participant)
-		val := []byte(fmt.Sprint(id.ID))
-		// insert [participant_participant] => [id]
-		if err := tx.Set(key, val); err != nil {
-			return err
+	return s.update(func(tx dbWriter) error {
+
+		participants.RLock()
+		defer participants.RUnlock()
+		for participant, id := range participants.ByPubKey {
+			key := participantKey(participant)
+			val := []byte(fmt.Sprint(id.ID))
+			// insert [participant_participant] => [id]
+			if err := tx.Set(key, val); err != nil {
+				return err
+			}
 		}
-	}
-	return tx.Commit(nil)
+		return nil
+	})
 }
 
 func (s *BadgerStore) dbGetBlock(index int64) (Block, error) {
 	var blockBytes []byte
 	key := blockKey(index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			blockBytes = val
-			return nil
-		})
+		blockBytes, err = s.value(item)
 		return err
 	})
 
@@ -778,35 +1052,32 @@ func (s *BadgerStore) dbGetBlock(index int64) (Block, error) {
 }
 
 func (s *BadgerStore) dbSetBlock(block Block) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
+	return s.update(func(tx dbWriter) error {
 
-	key := blockKey(block.Index())
-	val, err := block.ProtoMarshal()
-	if err != nil {
-		return err
-	}
+		key := blockKey(block.Index())
+		val, err := block.ProtoMarshal()
+		if err != nil {
+			return err
+		}
 
-	// insert [index] => [block bytes]
-	if err := tx.Set(key, val); err != nil {
-		return err
-	}
+		// insert [index] => [block bytes]
+		if err := tx.Set(key, val); err != nil {
+			return err
+		}
 
-	return tx.Commit(nil)
+		return nil
+	})
 }
 
 func (s *BadgerStore) dbGetFrame(index int64) (Frame, error) {
 	var frameBytes []byte
 	key := frameKey(index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			frameBytes = val
-			return nil
-		})
+		frameBytes, err = s.value(item)
 		return err
 	})
 
@@ -823,27 +1094,27 @@ func (s *BadgerStore) dbGetFrame(index int64) (Frame, error) {
 }
 
 func (s *BadgerStore) dbSetFrame(frame Frame) error {
-	tx := s.db.NewTransaction(true)
-	defer tx.Discard()
+	return s.update(func(tx dbWriter) error {
 
-	key := frameKey(frame.Round)
-	val, err := frame.ProtoMarshal()
-	if err != nil {
-		return err
-	}
+		key := frameKey(frame.Round)
+		val, err := frame.ProtoMarshal()
+		if err != nil {
+			return err
+		}
 
-	// insert [index] => [block bytes]
-	if err := tx.Set(key, val); err != nil {
-		return err
-	}
+		// insert [index] => [block bytes]
+		if err := tx.Set(key, val); err != nil {
+			return err
+		}
 
-	return tx.Commit(nil)
+		return nil
+	})
 }
 
 // ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func isDBKeyNotFound(err error) bool {
-	return err == badger.ErrKeyNotFound
+	return err == badger.ErrKeyNotFound || err == kvdb.ErrBoltKeyNotFound
 }
 
 func mapError(err error, name, key string) error {
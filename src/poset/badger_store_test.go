@@ -1,17 +1,25 @@
 package poset
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
 
+	"github.com/dgraph-io/badger"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
 	"github.com/Fantom-foundation/go-lachesis/src/crypto"
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 )
 
 func initBadgerStore(cacheSize int, t *testing.T) (*BadgerStore, []pub) {
+	return initBadgerStoreWithOptions(cacheSize, DefaultBadgerOptions(), t)
+}
+
+func initBadgerStoreWithOptions(cacheSize int, o BadgerOptions, t *testing.T) (*BadgerStore, []pub) {
 	n := 3
 	var participantPubs []pub
 	participants := peers.NewPeers()
@@ -35,7 +43,7 @@ func initBadgerStore(cacheSize int, t *testing.T) (*BadgerStore, []pub) {
 		t.Fatal(err)
 	}
 
-	store, err := NewBadgerStore(participants, cacheSize, dir, nil)
+	store, err := NewBadgerStoreWithOptions(participants, cacheSize, dir, nil, o)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -168,6 +176,60 @@ func TestLoadBadgerStore(t *testing.T) {
 
 }
 
+func TestLoadBadgerStoreReadOnly(t *testing.T) {
+	if err := os.RemoveAll("test_data"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("test_data", os.ModeDir|0777); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := "test_data/badger"
+
+	tempStore := createTestDB(dbPath, t)
+	defer func() {
+		if err := os.RemoveAll(tempStore.path); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	block := NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+	if err := tempStore.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if err := tempStore.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// two readers at once
+	var stores []*BadgerStore
+	for i := 0; i < 2; i++ {
+		store, err := LoadBadgerStoreReadOnly(cacheSize, tempStore.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, store)
+	}
+	for _, store := range stores {
+		storedBlock, err := store.GetBlock(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !storedBlock.Equals(&block) {
+			t.Fatal("Block and StoredBlock do not match")
+		}
+		if err := store.SetBlock(NewBlock(1, 2, nil, nil)); err != ErrReadOnlyStore {
+			t.Fatalf("expected ErrReadOnlyStore, got %v", err)
+		}
+		if _, err := store.GetBlock(1); !common.Is(err, common.KeyNotFound) {
+			t.Fatalf("a read-only store should not be written to, got %v", err)
+		}
+	}
+	for _, store := range stores {
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 // Call DB methods directly
 
@@ -726,3 +788,723 @@ func TestBadgerFrames(t *testing.T) {
 		}
 	})
 }
+
+func TestBadgerPrune(t *testing.T) {
+	cacheSize := 1 // Inmem_store's caches accept positive cacheSize only
+	store, participants := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+
+	// one event per participant and round
+	rounds := int64(3)
+	var events []Event
+	for r := int64(0); r < rounds; r++ {
+		round := NewRoundCreated()
+		for _, p := range participants {
+			event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], r))},
+				nil, nil, make(EventHashes, 2), p.pubKey, r+1, nil)
+			event.Message.TopologicalIndex = int64(len(events))
+			event.SetRound(r)
+			if err := store.dbSetEvents([]Event{event}); err != nil {
+				t.Fatal(err)
+			}
+			round.AddEvent(event.Hash(), false)
+			events = append(events, event)
+		}
+		if err := store.dbSetRoundCreated(r, *round); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := store.Prune(rounds - 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != rounds-1 {
+		t.Fatalf("expected %d pruned rounds, got %d", rounds-1, pruned)
+	}
+	// pruning again the same rounds does nothing
+	if pruned, _ := store.Prune(rounds - 1); pruned != 0 {
+		t.Fatalf("rounds should only be pruned once, got %d", pruned)
+	}
+
+	kept := len(participants)
+	for i, event := range events {
+		_, err := store.dbGetEventBlock(event.Hash())
+		if i < len(events)-kept && !isDBKeyNotFound(err) {
+			t.Fatalf("event %d should have been pruned, got %v", i, err)
+		}
+		if i >= len(events)-kept && err != nil {
+			t.Fatalf("event %d should have been kept, got %v", i, err)
+		}
+	}
+	if _, err := store.dbGetRoundCreated(0); !isDBKeyNotFound(err) {
+		t.Fatalf("round 0 should have been pruned, got %v", err)
+	}
+
+	topo, err := store.TopologicalEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topo) != kept {
+		t.Fatalf("expected %d topological events after pruning, got %d", kept, len(topo))
+	}
+
+	// compacting keeps the records left
+	if err := store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.dbGetEventBlock(events[len(events)-1].Hash()); err != nil {
+		t.Fatalf("the last event should be kept by the compaction, got %v", err)
+	}
+}
+
+// A pruned store lacks the self parents of its first events: its poset
+// bootstraps from the frame of the last block
+func TestBadgerPruneBootstrap(t *testing.T) {
+	cacheSize := 100
+	store, pubs := initBadgerStore(cacheSize, t)
+	path := store.path
+	defer os.RemoveAll(path)
+
+	// the roots of a frame are in the order of the peers
+	var participants []pub
+	for _, peer := range store.participants.ToPeerSlice() {
+		for _, p := range pubs {
+			if p.id == peer.ID {
+				participants = append(participants, p)
+			}
+		}
+	}
+
+	// rounds 0 and 1 of one event per participant, decided
+	n := len(participants)
+	created := make([][]Event, 2)
+	var topo int64
+	for r := int64(0); r < 2; r++ {
+		round := NewRoundCreated()
+		for i, p := range participants {
+			selfParent := GenRootSelfParent(p.id)
+			if r > 0 {
+				selfParent = created[r-1][i].Hash()
+			}
+			event := NewEvent(nil, nil, nil, EventHashes{selfParent, EventHash{}},
+				p.pubKey, r, nil)
+			event.Message.TopologicalIndex = topo
+			topo++
+			event.SetRound(r)
+			if err := store.dbSetEvents([]Event{event}); err != nil {
+				t.Fatal(err)
+			}
+			round.AddEvent(event.Hash(), false)
+			created[r] = append(created[r], event)
+		}
+		if err := store.dbSetRoundCreated(r, *round); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// round 1 is received by block 0, whose frame roots the next events
+	frame := Frame{Round: 1}
+	var next []Event
+	for i, p := range participants {
+		other := participants[(i+1)%n]
+		event := NewEvent(nil, nil, nil,
+			EventHashes{created[1][i].Hash(), created[1][(i+1)%n].Hash()},
+			p.pubKey, 2, nil)
+		if err := event.Sign(p.privKey); err != nil {
+			t.Fatal(err)
+		}
+		event.SetWireInfo(1, other.id, 1, p.id)
+		event.Message.TopologicalIndex = topo
+		topo++
+		if err := store.dbSetEvents([]Event{event}); err != nil {
+			t.Fatal(err)
+		}
+		next = append(next, event)
+
+		rootEvent := func(p pub, e Event) *RootEvent {
+			hash := e.Hash()
+			return &RootEvent{Hash: hash.Bytes(), CreatorID: p.id,
+				Index: 1, LamportTimestamp: 1, Round: 1}
+		}
+		hash := event.Hash()
+		frame.Roots = append(frame.Roots, &Root{
+			NextRound:  2,
+			SelfParent: rootEvent(p, created[1][i]),
+			Others: map[string]*RootEvent{
+				hash.String(): rootEvent(other, created[1][(i+1)%n]),
+			},
+		})
+	}
+	if err := store.SetFrame(frame); err != nil {
+		t.Fatal(err)
+	}
+	frameHash, err := frame.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetBlock(NewBlock(0, 1, frameHash, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the round of the last block is kept
+	if pruned, err := store.Prune(2); err != nil || pruned != 1 {
+		t.Fatalf("expected round 0 to be pruned, got %d, %v", pruned, err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBadgerStore(cacheSize, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Close()
+	p := NewPoset(loaded.participants, loaded, nil, testLogger(t))
+	if err := p.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	if lbi := p.Store.LastBlockIndex(); lbi != 0 {
+		t.Fatalf("LastBlockIndex should be 0, not %d", lbi)
+	}
+	for i, q := range participants {
+		last, _, err := p.Store.LastEventFrom(q.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last != next[i].Hash() {
+			t.Fatalf("the last event of participant %d should be its event of round 2", i)
+		}
+	}
+
+	// a new event takes the next topological index
+	event := NewEvent(nil, nil, nil, EventHashes{next[0].Hash(), next[1].Hash()},
+		participants[0].pubKey, 3, nil)
+	if err := event.Sign(participants[0].privKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.InsertEvent(event, true); err != nil {
+		t.Fatal(err)
+	}
+	events, err := p.Store.TopologicalEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := events[len(events)-1]; last.Hash() != event.Hash() ||
+		last.Message.TopologicalIndex != topo {
+		t.Fatalf("the new event should be the last one, at index %d", topo)
+	}
+}
+
+func TestBadgerColdStorage(t *testing.T) {
+	store, participants := initBadgerStore(1, t)
+	defer removeBadgerStore(store, t)
+
+	coldPath := store.path + "_cold"
+	defer os.RemoveAll(coldPath)
+	cold, err := NewDirColdStorage(coldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetColdStorage(cold)
+
+	var events []Event
+	for r := int64(0); r < 2; r++ {
+		round := NewRoundCreated()
+		for _, p := range participants {
+			event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], r))},
+				nil, nil, make(EventHashes, 2), p.pubKey, r+1, nil)
+			event.Message.TopologicalIndex = int64(len(events))
+			event.SetRound(r)
+			if err := store.dbSetEvents([]Event{event}); err != nil {
+				t.Fatal(err)
+			}
+			round.AddEvent(event.Hash(), false)
+			events = append(events, event)
+		}
+		if err := store.dbSetRoundCreated(r, *round); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if pruned, err := store.Prune(1); err != nil || pruned != 1 {
+		t.Fatalf("expected round 0 to be pruned, got %d: %v", pruned, err)
+	}
+	for i, event := range events[:len(participants)] {
+		if _, err := store.dbGetEventBlock(event.Hash()); !isDBKeyNotFound(err) {
+			t.Fatalf("event %d should have left the database, got %v", i, err)
+		}
+		got, err := store.GetEventBlock(event.Hash())
+		if err != nil {
+			t.Fatalf("event %d should be read from cold storage: %v", i, err)
+		}
+		if got.Hash() != event.Hash() {
+			t.Fatalf("event %d from cold storage does not match", i)
+		}
+	}
+	round, err := store.GetRoundCreated(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(round.Message.Events) != len(participants) {
+		t.Fatalf("round 0 from cold storage should have %d events, got %d",
+			len(participants), len(round.Message.Events))
+	}
+
+	// rounds which were never stored are still missing
+	if _, err := store.GetRoundCreated(5); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("round 5 should not be found, got %v", err)
+	}
+}
+
+func TestBadgerQuota(t *testing.T) {
+	store, _ := initBadgerStore(100, t)
+	defer removeBadgerStore(store, t)
+
+	used, err := store.DiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used <= 0 {
+		t.Fatalf("a badger store cannot be empty on disk, got %d bytes", used)
+	}
+
+	var exceeded error
+	p := &pruner{
+		store: store,
+		conf: PrunerConfig{
+			Quota:         used * 2,
+			QuotaExceeded: func(err error) { exceeded = err },
+		},
+		logger: common.NewTestLogger(t).WithField("test", t.Name()),
+	}
+	p.checkQuota()
+	if exceeded != nil {
+		t.Fatalf("store under its quota should be kept, got %v", exceeded)
+	}
+
+	// pruning cannot free the files badger preallocates
+	p.conf.Quota = 1
+	p.checkQuota()
+	if exceeded == nil {
+		t.Fatal("store over its quota should be reported")
+	}
+}
+
+func TestBadgerBackup(t *testing.T) {
+	cacheSize := 100
+	store, participants := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+
+	// block 0 has the default quorum of 2 signatures, block 1 is pending
+	var blocks []Block
+	for i, signers := range []int{2, 1} {
+		block := NewBlock(int64(i), int64(i+1), []byte("framehash"), [][]byte{[]byte("tx")})
+		for _, p := range participants[:signers] {
+			sig, err := block.Sign(p.privKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block.SetSignature(sig)
+		}
+		if err := store.SetBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	var backup bytes.Buffer
+	if n, err := store.Backup(&backup); err != nil || n != int64(backup.Len()) {
+		t.Fatalf("Backup wrote %d bytes out of %d: %v", n, backup.Len(), err)
+	}
+
+	path := store.path + "_restored"
+	defer os.RemoveAll(path)
+
+	// the participants recorded in the backup are not trusted
+	strangers := peers.NewPeers()
+	for range participants {
+		key, _ := crypto.GenerateECDSAKey()
+		strangers.AddPeer(peers.NewPeer(fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey)), ""))
+	}
+	if _, err := RestoreBadgerStore(bytes.NewReader(backup.Bytes()), cacheSize, path, DefaultBadgerOptions(), strangers, nil, 0); err == nil {
+		t.Fatal("a backup signed by strangers should be rejected")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("a rejected backup should leave no database, got %v", err)
+	}
+
+	trusted := store.participants
+	restored, err := RestoreBadgerStore(bytes.NewReader(backup.Bytes()), cacheSize, path, DefaultBadgerOptions(), trusted, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		storedBlock, err := restored.GetBlock(block.Index())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !storedBlock.Equals(&block) {
+			t.Fatalf("Block %d and StoredBlock do not match", block.Index())
+		}
+	}
+	if last, err := VerifyBlocks(restored, trusted, nil, 2); err != nil || last != 0 {
+		t.Fatalf("expected block 0 to be the last signed block, got %d: %v", last, err)
+	}
+	// the signer of block 1 weighs 3 out of 5
+	weights := map[string]uint64{participants[0].hex: 3}
+	quorum := QuorumWeight(0, ParticipantsWeight(trusted, weights))
+	if last, err := VerifyBlocks(restored, trusted, weights, quorum); err != nil || last != 1 {
+		t.Fatalf("expected block 1 to be the last signed block by weight, got %d: %v", last, err)
+	}
+
+	// a block signed by someone else than a participant is rejected
+	key, _ := crypto.GenerateECDSAKey()
+	sig, err := blocks[1].Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks[1].SetSignature(sig)
+	if err := restored.SetBlock(blocks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyBlocks(restored, trusted, nil, 2); err == nil {
+		t.Fatal("a block signed by a stranger should not be verified")
+	}
+	if err := restored.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestoreBadgerStore(bytes.NewReader(backup.Bytes()), cacheSize, path, DefaultBadgerOptions(), trusted, nil, 0); err == nil {
+		t.Fatal("restoring over an existing database should fail")
+	}
+}
+
+func TestLoadBadgerBackup(t *testing.T) {
+	store, _ := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+	block := NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+	if err := store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	// the running store locks its directory
+	if _, err := LoadBadgerStoreReadOnly(cacheSize, store.path); err == nil {
+		t.Fatal("the database of a running store should be locked")
+	}
+
+	var backup bytes.Buffer
+	if _, err := store.Backup(&backup); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBadgerBackup(&backup, cacheSize, DefaultBadgerOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedBlock, err := loaded.GetBlock(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !storedBlock.Equals(&block) {
+		t.Fatal("Block and StoredBlock do not match")
+	}
+	if err := loaded.SetBlock(NewBlock(1, 2, nil, nil)); err != ErrReadOnlyStore {
+		t.Fatalf("expected ErrReadOnlyStore, got %v", err)
+	}
+	if err := loaded.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(loaded.path); !os.IsNotExist(err) {
+		t.Fatalf("closing the backup should remove it, got %v", err)
+	}
+}
+
+func TestBadgerBatch(t *testing.T) {
+	cacheSize := 100
+	store, participants := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+
+	committed := func(event Event) bool {
+		hash := event.Hash()
+		err := store.db.View(func(txn *badger.Txn) error {
+			_, err := txn.Get(eventKey(hash))
+			return err
+		})
+		return err == nil
+	}
+
+	if err := store.StartBatch(); err != nil {
+		t.Fatal(err)
+	}
+	// a consensus run during a sync
+	if err := store.StartBatch(); err != nil {
+		t.Fatal(err)
+	}
+	event := NewEvent([][]byte{[]byte("tx")}, nil, nil, make(EventHashes, 2), participants[0].pubKey, 0, nil)
+	if err := store.SetEvent(event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.dbGetEventBlock(event.Hash()); err != nil {
+		t.Fatalf("pending writes should be visible in the batch: %v", err)
+	}
+	if err := store.CommitBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if committed(event) {
+		t.Fatal("the event should only be committed with the outermost batch")
+	}
+	if err := store.CommitBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if !committed(event) {
+		t.Fatal("the event should have been committed")
+	}
+	if store.batch != nil {
+		t.Fatal("no batch should be left open")
+	}
+}
+
+func TestBadgerRanges(t *testing.T) {
+	store, participants := initBadgerStore(1, t)
+	defer removeBadgerStore(store, t)
+
+	var events []Event
+	for k := int64(0); k < 2; k++ {
+		for _, p := range participants {
+			event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], k))},
+				nil, nil, make(EventHashes, 2), p.pubKey, k, nil)
+			event.Message.TopologicalIndex = int64(len(events))
+			if err := store.SetEvent(event); err != nil {
+				t.Fatal(err)
+			}
+			events = append(events, event)
+		}
+	}
+	for i := int64(0); i < 12; i++ {
+		if err := store.SetBlock(NewBlock(i, i, []byte("frame"), nil)); err != nil {
+			t.Fatal(err)
+		}
+		if i == 4 {
+			continue
+		}
+		if err := store.SetRoundCreated(i, *NewRoundCreated()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var hashes []EventHash
+	err := store.EventsRange(2, -1, func(event Event) error {
+		hashes = append(hashes, event.Hash())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != len(events)-2 {
+		t.Fatalf("EventsRange should return %d events, not %d", len(events)-2, len(hashes))
+	}
+	for i, hash := range hashes {
+		if hash != events[i+2].Hash() {
+			t.Fatalf("event %d should be %v, not %v", i+2, events[i+2].Hash(), hash)
+		}
+	}
+
+	blocks := func(from, to int64) []int64 {
+		var res []int64
+		err := store.BlocksRange(from, to, func(block Block) error {
+			res = append(res, block.Index())
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if got := blocks(3, 8); !reflect.DeepEqual(got, []int64{3, 4, 5, 6, 7}) {
+		t.Fatalf("BlocksRange(3, 8) returned %v", got)
+	}
+	if got := blocks(9, -1); !reflect.DeepEqual(got, []int64{9, 10, 11}) {
+		t.Fatalf("BlocksRange(9, -1) returned %v", got)
+	}
+
+	var rounds []int64
+	err = store.RoundsRange(2, 6, func(r int64, round RoundCreated) error {
+		rounds = append(rounds, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rounds, []int64{2, 3, 5}) {
+		t.Fatalf("RoundsRange(2, 6) returned %v", rounds)
+	}
+
+	count := 0
+	err = store.BlocksRange(0, -1, func(block Block) error {
+		count++
+		if count == 2 {
+			return ErrStopRange
+		}
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("ErrStopRange should stop the range without error, got %v after %d blocks", err, count)
+	}
+}
+
+func TestBadgerWriteBehind(t *testing.T) {
+	logger := common.NewTestLogger(t).WithField("test", t.Name())
+
+	// the committer needs a database which syncs its writes
+	conf := WriteConfig{Mode: WriteBehind, QueueSize: 2}
+	unsynced, _ := initBadgerStore(1, t)
+	if err := unsynced.SetWriteMode(conf, logger); err == nil {
+		t.Fatal("write-behind should need SyncWrites")
+	}
+	removeBadgerStore(unsynced, t)
+
+	// a cache of one event makes reads go to the database
+	store, participants := initBadgerStoreWithOptions(1, conf.Options(DefaultBadgerOptions()), t)
+	journal := store.path + ".journal"
+	conf.JournalPath = journal
+	if err := store.SetWriteMode(conf, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	if err := store.StartBatch(); err != nil {
+		t.Fatal(err)
+	}
+	for k := int64(0); k < 3; k++ {
+		for _, p := range participants {
+			event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], k))},
+				nil, nil, make(EventHashes, 2), p.pubKey, k, nil)
+			event.Message.TopologicalIndex = int64(len(events))
+			if err := store.SetEvent(event); err != nil {
+				t.Fatal(err)
+			}
+			events = append(events, event)
+		}
+	}
+	if err := store.CommitBatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reads wait for the queued writes
+	for _, event := range events {
+		if _, err := store.GetEventBlock(event.Hash()); err != nil {
+			t.Fatalf("GetEventBlock %v: %v", event.Hash(), err)
+		}
+	}
+	var count int
+	if err := store.EventsRange(0, -1, func(Event) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(events) {
+		t.Fatalf("database should have %d events, got %d", len(events), count)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(journal); !os.IsNotExist(err) {
+		t.Fatalf("a clean shutdown should remove the journal: %v", err)
+	}
+	if err := os.RemoveAll(store.path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBadgerJournalReplay(t *testing.T) {
+	store, _ := initBadgerStore(100, t)
+	defer removeBadgerStore(store, t)
+	journal := store.path + ".journal"
+
+	f, err := os.Create(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &writeBehind{journal: f}
+	b := &writeBatch{set: make(map[string]bool)}
+	b.Set([]byte("a"), []byte("1"))
+	b.Set([]byte("b"), []byte("2"))
+	if err := w.writeJournal([]*writeBatch{b}); err != nil {
+		t.Fatal(err)
+	}
+	// the tail of a write interrupted by a crash
+	if _, err := f.Write([]byte{0, 0, 1, 0, 7}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	replayed, err := replayJournal(store.db, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 1 {
+		t.Fatalf("1 batch should be replayed, got %d", replayed)
+	}
+	for key, val := range map[string]string{"a": "1", "b": "2"} {
+		err := store.db.View(func(txn *badger.Txn) error {
+			item, err := txn.Get([]byte(key))
+			if err != nil {
+				return err
+			}
+			v, err := item.ValueCopy(nil)
+			if err == nil && string(v) != val {
+				err = fmt.Errorf("value %q, want %q", v, val)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("key %s: %v", key, err)
+		}
+	}
+	if _, err := os.Stat(journal); !os.IsNotExist(err) {
+		t.Fatalf("the journal should be removed after the replay: %v", err)
+	}
+}
+
+func TestBadgerOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "badger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	participants := peers.NewPeersFromSlice([]*peers.Peer{peers.NewPeer("0xAA", "")})
+
+	o := DefaultBadgerOptions()
+	o.Compression = "snappy"
+	if _, err := NewBadgerStoreWithOptions(participants, 10, dir, nil, o); err == nil {
+		t.Fatal("unsupported compression should be rejected")
+	}
+	o = DefaultBadgerOptions()
+	o.TableLoadingMode = "disk"
+	if _, err := LoadOrCreateBadgerStoreWithOptions(participants, 10, dir, nil, o); err == nil {
+		t.Fatal("unknown loading mode should be rejected")
+	}
+
+	o = DefaultBadgerOptions()
+	o.TableLoadingMode = "fileio"
+	o.ValueLogLoadingMode = "fileio"
+	o.NumMemtables = 2
+	o.MaxTableSize = 8 << 20
+	o.ValueLogFileSize = 16 << 20
+	store, err := LoadOrCreateBadgerStoreWithOptions(participants, 10, dir, nil, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store, err = LoadBadgerStoreWithOptions(10, dir, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
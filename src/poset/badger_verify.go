@@ -0,0 +1,343 @@
+package poset
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+)
+
+// DBIssue is an inconsistency found in a database by VerifyBadgerStore
+type DBIssue struct {
+	Key     string
+	Problem string
+}
+
+func (i DBIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Key, i.Problem)
+}
+
+// DBReport is the result of VerifyBadgerStore
+type DBReport struct {
+	Events int64 // events checked, in topological order
+	Blocks int64 // blocks checked
+	Issues []DBIssue
+	// ConsistentEvents is the number of events before the first broken one
+	ConsistentEvents int64
+	// LastConsistentBlock is the index of the block before the first broken
+	// one, -1 if block 0 is broken
+	LastConsistentBlock int64
+	// Pruned tells that events and frames were deleted on purpose, see
+	// Prune, so missing ones are not reported
+	Pruned bool
+}
+
+// OK tells if no issue was found
+func (r *DBReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// VerifyBadgerStore scans the events, in topological order, and the blocks
+// of the database for unreadable records, missing parents, broken indexes
+// and blocks which do not match their frame. It only returns an error if the
+// database cannot be read at all.
+func VerifyBadgerStore(s *BadgerStore) (*DBReport, error) {
+	pruned, err := s.dbGetPrunedRound()
+	if err != nil {
+		return nil, err
+	}
+	report := &DBReport{
+		ConsistentEvents:    -1,
+		LastConsistentBlock: -1,
+		Pruned:              pruned >= 0,
+	}
+
+	if err := s.verifyEvents(report); err != nil {
+		return nil, err
+	}
+	if report.ConsistentEvents < 0 {
+		report.ConsistentEvents = report.Events
+	}
+
+	if err := s.verifyBlocks(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// knownParents returns the hashes of the events referenced by the roots,
+// which are parents of the first events of the database
+func (s *BadgerStore) knownParents() map[EventHash]bool {
+	known := map[EventHash]bool{EventHash{}: true}
+	for _, root := range s.inmemStore.RootsByParticipant() {
+		var hash EventHash
+		if root.SelfParent != nil {
+			hash.Set(root.SelfParent.Hash)
+			known[hash] = true
+		}
+		for _, other := range root.Others {
+			hash.Set(other.Hash)
+			known[hash] = true
+		}
+	}
+	return known
+}
+
+func (s *BadgerStore) verifyEvents(report *DBReport) error {
+	known := s.knownParents()
+	return s.view(func(txn *badger.Txn) error {
+		for t := int64(0); ; t++ {
+			key := topologicalEventKey(t)
+			item, err := txn.Get(key)
+			if isDBKeyNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			hashBytes, err := s.value(item)
+			if err != nil {
+				return err
+			}
+			report.Events++
+
+			var hash EventHash
+			hash.Set(hashBytes)
+			problem, err := s.verifyEvent(txn, t, hash, known, report.Pruned)
+			if err != nil {
+				return err
+			}
+			// later events may refer to a broken one, do not report them too
+			known[hash] = true
+			if problem == "" {
+				continue
+			}
+			report.Issues = append(report.Issues, DBIssue{Key: string(key), Problem: problem})
+			if report.ConsistentEvents < 0 {
+				report.ConsistentEvents = t
+			}
+		}
+	})
+}
+
+// verifyEvent returns what is wrong with the event at topological index t
+func (s *BadgerStore) verifyEvent(txn *badger.Txn, t int64, hash EventHash, known map[EventHash]bool, pruned bool) (string, error) {
+	item, err := txn.Get(eventKey(hash))
+	if isDBKeyNotFound(err) {
+		if pruned {
+			return "", nil
+		}
+		return fmt.Sprintf("event %v is missing", hash), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	data, err := s.value(item)
+	if err != nil {
+		return "", err
+	}
+	var event Event
+	if err := event.ProtoUnmarshal(data); err != nil {
+		return fmt.Sprintf("event %v is unreadable: %v", hash, err), nil
+	}
+	if event.Hash() != hash {
+		return fmt.Sprintf("event %v has hash %v", hash, event.Hash()), nil
+	}
+	if event.Message.TopologicalIndex != t {
+		return fmt.Sprintf("event %v has topological index %d", hash, event.Message.TopologicalIndex), nil
+	}
+	if len(event.Message.Body.Parents) != 2 {
+		return fmt.Sprintf("event %v has %d parents", hash, len(event.Message.Body.Parents)), nil
+	}
+	if !pruned {
+		if p := event.SelfParent(); !known[p] {
+			return fmt.Sprintf("self parent %v of event %v is missing", p, hash), nil
+		}
+		if p := event.OtherParent(); !known[p] {
+			return fmt.Sprintf("other parent %v of event %v is missing", p, hash), nil
+		}
+	}
+
+	peKey := participantEventKey(event.GetCreator(), event.Index())
+	item, err = txn.Get(peKey)
+	if isDBKeyNotFound(err) {
+		return fmt.Sprintf("participant index %s is missing", peKey), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	indexed, err := s.value(item)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(indexed, hash.Bytes()) {
+		return fmt.Sprintf("participant index %s points to another event", peKey), nil
+	}
+	return "", nil
+}
+
+func (s *BadgerStore) verifyBlocks(report *DBReport) error {
+	firstBroken := int64(-1)
+	defer func() {
+		if firstBroken < 0 {
+			report.LastConsistentBlock = report.Blocks - 1
+		} else {
+			report.LastConsistentBlock = firstBroken - 1
+		}
+	}()
+
+	for i := int64(0); ; i++ {
+		block, err := s.dbGetBlock(i)
+		if isDBKeyNotFound(err) {
+			return nil
+		}
+		report.Blocks++
+
+		var problem string
+		if err != nil {
+			problem = fmt.Sprintf("block is unreadable: %v", err)
+		} else if problem, err = s.verifyBlock(block, report.Pruned); err != nil {
+			return err
+		}
+		if problem == "" {
+			continue
+		}
+		report.Issues = append(report.Issues, DBIssue{Key: string(blockKey(i)), Problem: problem})
+		if firstBroken < 0 {
+			firstBroken = i
+		}
+	}
+}
+
+// verifyBlock returns what is wrong with the block and its frame
+func (s *BadgerStore) verifyBlock(block Block, pruned bool) (string, error) {
+	frame, err := s.dbGetFrame(block.RoundReceived())
+	if isDBKeyNotFound(err) {
+		if pruned {
+			return "", nil
+		}
+		return fmt.Sprintf("frame %d is missing", block.RoundReceived()), nil
+	}
+	if err != nil {
+		return fmt.Sprintf("frame %d is unreadable: %v", block.RoundReceived(), err), nil
+	}
+	frameHash, err := frame.Hash()
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(block.GetFrameHash(), frameHash) {
+		return fmt.Sprintf("frame %d does not match the block", block.RoundReceived()), nil
+	}
+	match, err := frame.MatchesEventsRoot(block)
+	if err != nil {
+		return "", err
+	}
+	if !match {
+		return fmt.Sprintf("events of frame %d do not match the block", block.RoundReceived()), nil
+	}
+	return "", nil
+}
+
+// RepairBadgerStore truncates the database back to the consistency point
+// found by VerifyBadgerStore: the events from the first broken one on, in
+// topological order, and the blocks after the last consistent one are
+// deleted, with their frames. Loading the database then bootstraps the node
+// from the remaining events, and it syncs the rest from its peers.
+func RepairBadgerStore(s *BadgerStore, report *DBReport) error {
+	if report.OK() {
+		return nil
+	}
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	wb := s.newSplitWrite()
+	defer wb.Cancel()
+
+	deleted := make(map[EventHash]bool)
+	err := s.view(func(txn *badger.Txn) error {
+		for t := report.ConsistentEvents; t < report.Events; t++ {
+			key := topologicalEventKey(t)
+			item, err := txn.Get(key)
+			if err != nil {
+				return err
+			}
+			val, err := s.value(item)
+			if err != nil {
+				return err
+			}
+			var hash EventHash
+			hash.Set(val)
+			deleted[hash] = true
+			if err := wb.Delete(eventKey(hash)); err != nil {
+				return err
+			}
+			if err := wb.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the creator and round of an unreadable event are unknown, so look for
+	// the deleted events in the whole indexes
+	err = s.dbRangeKeys(participantEventPrefix, func(key, val []byte) error {
+		var hash EventHash
+		hash.Set(val)
+		if deleted[hash] {
+			return wb.Delete(key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = s.dbRangeKeys(roundEventPrefix, func(key, _ []byte) error {
+		var hash EventHash
+		hash.Set(key[len(key)-len(hash):])
+		if deleted[hash] {
+			return wb.Delete(key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := report.LastConsistentBlock + 1; i < report.Blocks; i++ {
+		if block, err := s.dbGetBlock(i); err == nil {
+			if err := wb.Delete(frameKey(block.RoundReceived())); err != nil {
+				return err
+			}
+		}
+		if err := wb.Delete(blockKey(i)); err != nil {
+			return err
+		}
+	}
+
+	return wb.Flush()
+}
+
+// dbRangeKeys calls fn with copies of the keys and values which start with
+// prefix
+func (s *BadgerStore) dbRangeKeys(prefix string, fn func(key, val []byte) error) error {
+	valid := []byte(prefix + "_")
+	return s.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Seek(valid); it.ValidForPrefix(valid); it.Next() {
+			item := it.Item()
+			val, err := s.value(item)
+			if err != nil {
+				return err
+			}
+			if err := fn(item.KeyCopy(nil), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
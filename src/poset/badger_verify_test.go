@@ -0,0 +1,87 @@
+package poset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+)
+
+func TestBadgerVerifyAndRepair(t *testing.T) {
+	cacheSize := 100
+	store, participants := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+
+	// one event per participant and round, each round in a block
+	rounds := int64(3)
+	var events []Event
+	for r := int64(0); r < rounds; r++ {
+		frame := Frame{Round: r}
+		for _, p := range participants {
+			event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], r))},
+				nil, nil, make(EventHashes, 2), p.pubKey, r, nil)
+			event.Message.TopologicalIndex = int64(len(events))
+			if err := store.SetEvent(event); err != nil {
+				t.Fatal(err)
+			}
+			events = append(events, event)
+			frame.Events = append(frame.Events, event.Message)
+		}
+		block, err := NewBlockFromFrame(r, frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := VerifyBadgerStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Events != int64(len(events)) || report.Blocks != rounds {
+		t.Fatalf("expected a sound database with %d events and %d blocks, got %+v", len(events), rounds, report)
+	}
+
+	// corrupt the first event of round 1 and the frame of block 2
+	broken := int64(len(participants))
+	hash := events[broken].Hash()
+	if err := store.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(eventKey(hash), []byte("garbage")); err != nil {
+			return err
+		}
+		return txn.Set(frameKey(2), []byte("garbage"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = VerifyBadgerStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", report.Issues)
+	}
+	if report.ConsistentEvents != broken || report.LastConsistentBlock != 1 {
+		t.Fatalf("expected %d consistent events and block 1, got %d and %d",
+			broken, report.ConsistentEvents, report.LastConsistentBlock)
+	}
+
+	if err := RepairBadgerStore(store, report); err != nil {
+		t.Fatal(err)
+	}
+	report, err = VerifyBadgerStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Events != broken || report.Blocks != 2 {
+		t.Fatalf("expected a sound database with %d events and 2 blocks, got %+v", broken, report)
+	}
+	if _, err := store.dbParticipantEvent(participants[0].hex, 1); !isDBKeyNotFound(err) {
+		t.Fatalf("the index of the broken event should have been deleted, got %v", err)
+	}
+}
@@ -0,0 +1,481 @@
+package poset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/journal"
+)
+
+// Write modes of a BadgerStore, see SetWriteMode
+const (
+	// WriteDirect commits each batch before CommitBatch returns and leaves
+	// flushing the database files to the operating system. It is the default.
+	WriteDirect = "direct"
+	// WriteStrict has badger sync its value log on every write, so a batch
+	// survives a power loss once CommitBatch returns. The database must be
+	// opened with BadgerOptions.SyncWrites, see WriteConfig.Options.
+	WriteStrict = "strict"
+	// WriteBehind queues the batches to a background committer which writes
+	// them to a journal, then to the database, so that the node does not wait
+	// for the disk. Like WriteStrict, it needs BadgerOptions.SyncWrites, so
+	// that the journal can be truncated once a batch is committed.
+	WriteBehind = "async"
+)
+
+// journalCheckpointSize is the size past which the committer truncates the
+// journal
+const journalCheckpointSize = 32 << 20
+
+// WriteConfig configures how a BadgerStore writes to disk
+type WriteConfig struct {
+	// Mode is WriteDirect, WriteStrict or WriteBehind
+	Mode string
+	// QueueSize is the number of batches waiting for the committer in
+	// WriteBehind mode, past which writes block
+	QueueSize int
+	// JournalPath is the write-ahead journal of WriteBehind mode. A journal
+	// left by a crash is replayed by SetWriteMode whatever the mode.
+	JournalPath string
+}
+
+// Options returns o with SyncWrites set if the write mode, or the journal
+// left by a crash, needs it. The badger release the store is built against
+// cannot sync the database on demand: SyncWrites, which makes it sync its
+// value log on every write, is what puts a commit on disk.
+func (c WriteConfig) Options(o BadgerOptions) BadgerOptions {
+	switch c.Mode {
+	case WriteStrict, WriteBehind:
+		o.SyncWrites = true
+	}
+	if c.JournalPath != "" {
+		if _, err := os.Stat(c.JournalPath); err == nil {
+			o.SyncWrites = true
+		}
+	}
+	return o
+}
+
+// dbWriter is what update runs its function in: the transaction of the
+// current batch, or a batch of the write-behind pipeline
+type dbWriter interface {
+	Set(key, val []byte) error
+	// has tells if key exists, pending writes included
+	has(key []byte) (bool, error)
+}
+
+// txnWriter is a dbWriter over a badger transaction
+type txnWriter struct {
+	*badger.Txn
+}
+
+func (t txnWriter) has(key []byte) (bool, error) {
+	_, err := t.Get(key)
+	if isDBKeyNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetWriteMode replays the journal left by a crash, if any, then switches
+// the store to conf.Mode. It must be called before the store is used, which
+// must have been opened with conf.Options.
+func (s *BadgerStore) SetWriteMode(conf WriteConfig, logger *logrus.Entry) error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	if !s.syncWrites && conf.Options(BadgerOptions{}).SyncWrites {
+		return fmt.Errorf("write mode %s, or replaying the journal, needs badger SyncWrites", conf.Mode)
+	}
+	if conf.JournalPath != "" {
+		replayed, err := replayJournal(s.db, conf.JournalPath)
+		if err != nil {
+			return fmt.Errorf("replaying journal %s: %v", conf.JournalPath, err)
+		}
+		if replayed > 0 {
+			logger.WithField("batches", replayed).Warn("Replayed the write journal of an unclean shutdown")
+		}
+	}
+
+	switch conf.Mode {
+	case WriteDirect, "":
+	case WriteStrict:
+	case WriteBehind:
+		if conf.JournalPath == "" {
+			return fmt.Errorf("write mode %s needs a journal", conf.Mode)
+		}
+		if conf.QueueSize <= 0 {
+			conf.QueueSize = 64
+		}
+		w, err := newWriteBehind(s.db, conf, logger)
+		if err != nil {
+			return err
+		}
+		s.writer = w
+	default:
+		return fmt.Errorf("unknown write mode %q", conf.Mode)
+	}
+	return nil
+}
+
+// Flush waits until the writes made so far are in the database. Only
+// writes queued in WriteBehind mode can be pending, so it returns at once in
+// the other modes.
+func (s *BadgerStore) Flush() error {
+	s.batchLock.Lock()
+	if s.writer == nil {
+		s.batchLock.Unlock()
+		return nil
+	}
+	seq, err := s.queuePending()
+	s.batchLock.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.writer.wait(seq)
+}
+
+// queuePending sends the open batch, if any, to the committer and returns
+// the sequence number to wait for. The caller holds batchLock.
+func (s *BadgerStore) queuePending() (uint64, error) {
+	if s.pending != nil && len(s.pending.keys) > 0 {
+		if err := s.writer.enqueue(s.pending); err != nil {
+			return 0, err
+		}
+		s.pending = s.writer.newBatch()
+	}
+	return s.writer.lastQueued(), nil
+}
+
+// commit commits tx. In WriteStrict mode, the database syncs its writes, so
+// that tx is on disk when commit returns.
+func (s *BadgerStore) commit(tx *badger.Txn) error {
+	return tx.Commit(nil)
+}
+
+// writeBatch is a batch of writes recorded in WriteBehind mode
+type writeBatch struct {
+	w    *writeBehind
+	seq  uint64
+	keys [][]byte
+	vals [][]byte
+	set  map[string]bool
+}
+
+// Set implements dbWriter
+func (b *writeBatch) Set(key, val []byte) error {
+	b.keys = append(b.keys, key)
+	b.vals = append(b.vals, val)
+	b.set[string(key)] = true
+	return nil
+}
+
+func (b *writeBatch) has(key []byte) (bool, error) {
+	if b.set[string(key)] {
+		return true, nil
+	}
+	return b.w.has(key)
+}
+
+// writeBehind is the pipeline of WriteBehind mode. Batches are queued in
+// order; the committer appends them to the journal, syncs it, then commits
+// them to the database. The database syncs its writes, so the journal can be
+// truncated once the batches in it are committed.
+type writeBehind struct {
+	db      *badger.DB
+	logger  *logrus.Entry
+	queue   chan *writeBatch
+	journal *os.File
+	path    string
+	size    int64 // bytes in the journal
+	wg      sync.WaitGroup
+
+	lock      sync.Mutex
+	committed *sync.Cond
+	inflight  []*writeBatch // queued and not committed yet, in order
+	queued    uint64        // sequence number of the last queued batch
+	done      uint64        // sequence number of the last committed batch
+	err       error         // first commit error, the pipeline stops there
+}
+
+func newWriteBehind(db *badger.DB, conf WriteConfig, logger *logrus.Entry) (*writeBehind, error) {
+	journal, err := os.OpenFile(conf.JournalPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	w := &writeBehind{
+		db:      db,
+		logger:  logger,
+		queue:   make(chan *writeBatch, conf.QueueSize),
+		journal: journal,
+		path:    conf.JournalPath,
+	}
+	w.committed = sync.NewCond(&w.lock)
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+func (w *writeBehind) newBatch() *writeBatch {
+	return &writeBatch{w: w, set: make(map[string]bool)}
+}
+
+// enqueue hands b to the committer. It blocks while the queue is full.
+// Batches must be queued one at a time, which batchLock ensures.
+func (w *writeBehind) enqueue(b *writeBatch) error {
+	if len(b.keys) == 0 {
+		return nil
+	}
+	w.lock.Lock()
+	if w.err != nil {
+		w.lock.Unlock()
+		return w.err
+	}
+	w.queued++
+	b.seq = w.queued
+	w.inflight = append(w.inflight, b)
+	w.lock.Unlock()
+	w.queue <- b
+	return nil
+}
+
+func (w *writeBehind) lastQueued() uint64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.queued
+}
+
+// wait blocks until the batch seq is committed
+func (w *writeBehind) wait(seq uint64) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for w.done < seq && w.err == nil {
+		w.committed.Wait()
+	}
+	return w.err
+}
+
+// has looks for key in the queued batches, then in the database. A batch
+// leaves inflight only once committed, so the key cannot be missed.
+func (w *writeBehind) has(key []byte) (bool, error) {
+	w.lock.Lock()
+	for i := len(w.inflight) - 1; i >= 0; i-- {
+		if w.inflight[i].set[string(key)] {
+			w.lock.Unlock()
+			return true, nil
+		}
+	}
+	w.lock.Unlock()
+
+	found := false
+	err := w.db.View(func(txn *badger.Txn) error {
+		var err error
+		found, err = txnWriter{txn}.has(key)
+		return err
+	})
+	return found, err
+}
+
+// stop commits the queued batches and removes the journal
+func (w *writeBehind) stop() error {
+	close(w.queue)
+	w.wg.Wait()
+	if err := w.journal.Close(); err != nil {
+		return err
+	}
+	if w.err != nil {
+		// keep the journal, it is replayed on the next start
+		return w.err
+	}
+	return os.Remove(w.path)
+}
+
+func (w *writeBehind) run() {
+	defer w.wg.Done()
+	for b := range w.queue {
+		// commit the batches queued meanwhile together, with a single sync
+		// of the journal
+		group := []*writeBatch{b}
+	drain:
+		for len(group) < cap(w.queue) {
+			select {
+			case next, ok := <-w.queue:
+				if !ok {
+					break drain
+				}
+				group = append(group, next)
+			default:
+				break drain
+			}
+		}
+		w.commit(group)
+	}
+}
+
+func (w *writeBehind) commit(group []*writeBatch) {
+	w.lock.Lock()
+	failed := w.err != nil
+	w.lock.Unlock()
+	if failed {
+		// drain the queue so that writers do not block
+		return
+	}
+
+	err := w.writeJournal(group)
+	if err == nil {
+		err = w.apply(group)
+	}
+	if err == nil && w.size > journalCheckpointSize {
+		err = w.checkpoint()
+	}
+	if err != nil {
+		w.logger.WithError(err).Error("Committing queued writes, the journal will be replayed on restart")
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err != nil {
+		w.err = fmt.Errorf("write-behind commit: %v", err)
+	} else {
+		w.done = group[len(group)-1].seq
+		w.inflight = w.inflight[len(group):]
+	}
+	w.committed.Broadcast()
+}
+
+// apply commits the group to the database, in several transactions if it
+// is too big for one
+func (w *writeBehind) apply(group []*writeBatch) error {
+	defer observeOp("badger", "CommitQueued", time.Now())
+	tx := w.db.NewTransaction(true)
+	for _, b := range group {
+		for i, key := range b.keys {
+			err := tx.Set(key, b.vals[i])
+			if err == badger.ErrTxnTooBig {
+				if err = tx.Commit(nil); err != nil {
+					tx.Discard()
+					return err
+				}
+				tx.Discard()
+				tx = w.db.NewTransaction(true)
+				err = tx.Set(key, b.vals[i])
+			}
+			if err != nil {
+				tx.Discard()
+				return err
+			}
+		}
+	}
+	defer tx.Discard()
+	return tx.Commit(nil)
+}
+
+// checkpoint truncates the journal, whose batches are all committed to the
+// database, and so on disk
+func (w *writeBehind) checkpoint() error {
+	if err := w.journal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.journal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.size = 0
+	return nil
+}
+
+// writeJournal appends the group to the journal, in the records of package
+// journal, and syncs it. The payload of a record is a sequence of
+// uvarint-prefixed keys and values, those of a batch or, if it is too big,
+// of a part of it.
+func (w *writeBehind) writeJournal(group []*writeBatch) error {
+	buf := make([]byte, 0, 4096)
+	var err error
+	for _, b := range group {
+		var payload []byte
+		for i, key := range b.keys {
+			size := len(key) + len(b.vals[i]) + 2*binary.MaxVarintLen64
+			if len(payload) > 0 && len(payload)+size > journal.MaxRecordSize {
+				if buf, err = journal.AppendRecord(buf, payload); err != nil {
+					return err
+				}
+				payload = nil
+			}
+			payload = journal.AppendBytes(payload, key)
+			payload = journal.AppendBytes(payload, b.vals[i])
+		}
+		if buf, err = journal.AppendRecord(buf, payload); err != nil {
+			return err
+		}
+	}
+	if _, err := w.journal.Write(buf); err != nil {
+		return err
+	}
+	w.size += int64(len(buf))
+	return w.journal.Sync()
+}
+
+// replayJournal commits the batches of the journal at path to the database,
+// which syncs its writes, and removes the journal. It returns the number of
+// batches replayed; the writes are idempotent, so batches which were already
+// committed are harmless.
+func replayJournal(db *badger.DB, path string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := &writeBehind{db: db}
+	r := bufio.NewReader(f)
+	replayed := 0
+	for {
+		b, err := readJournalRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return replayed, err
+		}
+		b.w = w
+		if err := w.apply([]*writeBatch{b}); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, os.Remove(path)
+}
+
+// readJournalRecord reads the next batch of the journal. A truncated or
+// corrupt record is the tail of an interrupted write and reads as io.EOF.
+func readJournalRecord(r *bufio.Reader) (*writeBatch, error) {
+	payload, err := journal.ReadRecord(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &writeBatch{set: make(map[string]bool)}
+	for len(payload) > 0 {
+		key, rest, err := journal.ReadBytes(payload)
+		if err != nil {
+			return nil, err
+		}
+		val, rest, err := journal.ReadBytes(rest)
+		if err != nil {
+			return nil, err
+		}
+		b.Set(key, val)
+		payload = rest
+	}
+	return b, nil
+}
@@ -86,11 +86,17 @@ func NewBlockFromFrame(blockIndex int64, frame Frame) (Block, error) {
 	if err != nil {
 		return Block{}, err
 	}
+	eventsRoot, err := frame.EventsRoot()
+	if err != nil {
+		return Block{}, err
+	}
 	var transactions [][]byte
 	for _, e := range frame.Events {
 		transactions = append(transactions, e.Body.Transactions...)
 	}
-	return NewBlock(blockIndex, frame.Round, frameHash, transactions), nil
+	block := NewBlock(blockIndex, frame.Round, frameHash, transactions)
+	block.Body.EventsRoot = eventsRoot
+	return block, nil
 }
 
 // NewBlock creates a new empty block with current time
@@ -108,6 +114,23 @@ func NewBlock(blockIndex, roundReceived int64, frameHash []byte, txs [][]byte) B
 	}
 }
 
+// dropTransactions returns the first of each transaction of txs which is not
+// in drop
+func dropTransactions(txs, drop [][]byte) [][]byte {
+	seen := make(map[string]bool, len(txs)+len(drop))
+	for _, tx := range drop {
+		seen[TxHash(tx)] = true
+	}
+	var kept [][]byte
+	for _, tx := range txs {
+		if hash := TxHash(tx); !seen[hash] {
+			seen[hash] = true
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
+
 // Index returns the index (height) of the block
 func (b *Block) Index() int64 {
 	return b.Body.Index
@@ -118,11 +141,27 @@ func (b *Block) Transactions() [][]byte {
 	return b.Body.Transactions
 }
 
+// TxHash returns the hash identifying a transaction, the Keccak256 hash of
+// its bytes, as a 0x prefixed hex string
+func TxHash(tx []byte) string {
+	return crypto.Keccak256Hash(tx).Hex()
+}
+
 // RoundReceived returns the round in which the block was received
 func (b *Block) RoundReceived() int64 {
 	return b.Body.RoundReceived
 }
 
+// Random returns the random beacon value of the block
+func (b *Block) Random() []byte {
+	return b.Body.Random
+}
+
+// RandomHex returns the Hex of the random beacon value (used for API)
+func (b *Block) RandomHex() string {
+	return fmt.Sprintf("0x%X", b.Body.Random)
+}
+
 // BlockHash returns the Hash of the block (used for API)
 func (b *Block) BlockHash() ([]byte, error) {
 	hashBytes, err := b.ProtoMarshal()
@@ -22,6 +22,9 @@ type BlockBody struct {
 	Index                int64    `protobuf:"varint,1,opt,name=Index,proto3" json:"Index,omitempty"`
 	RoundReceived        int64    `protobuf:"varint,2,opt,name=RoundReceived,proto3" json:"RoundReceived,omitempty"`
 	Transactions         [][]byte `protobuf:"bytes,5,rep,name=Transactions,proto3" json:"Transactions,omitempty"`
+	Random               []byte   `protobuf:"bytes,6,opt,name=Random,proto3" json:"Random,omitempty"`
+	GenesisHash          []byte   `protobuf:"bytes,7,opt,name=GenesisHash,proto3" json:"GenesisHash,omitempty"`
+	EventsRoot           []byte   `protobuf:"bytes,8,opt,name=EventsRoot,proto3" json:"EventsRoot,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -31,7 +34,7 @@ func (m *BlockBody) Reset()         { *m = BlockBody{} }
 func (m *BlockBody) String() string { return proto.CompactTextString(m) }
 func (*BlockBody) ProtoMessage()    {}
 func (*BlockBody) Descriptor() ([]byte, []int) {
-	return fileDescriptor_block_508d5006735d6a13, []int{0}
+	return fileDescriptor_block_d66ca570276a532e, []int{0}
 }
 func (m *BlockBody) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BlockBody.Unmarshal(m, b)
@@ -72,6 +75,27 @@ func (m *BlockBody) GetTransactions() [][]byte {
 	return nil
 }
 
+func (m *BlockBody) GetRandom() []byte {
+	if m != nil {
+		return m.Random
+	}
+	return nil
+}
+
+func (m *BlockBody) GetGenesisHash() []byte {
+	if m != nil {
+		return m.GenesisHash
+	}
+	return nil
+}
+
+func (m *BlockBody) GetEventsRoot() []byte {
+	if m != nil {
+		return m.EventsRoot
+	}
+	return nil
+}
+
 type WireBlockSignature struct {
 	Index                int64    `protobuf:"varint,1,opt,name=Index,proto3" json:"Index,omitempty"`
 	Signature            string   `protobuf:"bytes,2,opt,name=Signature,proto3" json:"Signature,omitempty"`
@@ -84,7 +108,7 @@ func (m *WireBlockSignature) Reset()         { *m = WireBlockSignature{} }
 func (m *WireBlockSignature) String() string { return proto.CompactTextString(m) }
 func (*WireBlockSignature) ProtoMessage()    {}
 func (*WireBlockSignature) Descriptor() ([]byte, []int) {
-	return fileDescriptor_block_508d5006735d6a13, []int{1}
+	return fileDescriptor_block_d66ca570276a532e, []int{1}
 }
 func (m *WireBlockSignature) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_WireBlockSignature.Unmarshal(m, b)
@@ -135,7 +159,7 @@ func (m *Block) Reset()         { *m = Block{} }
 func (m *Block) String() string { return proto.CompactTextString(m) }
 func (*Block) ProtoMessage()    {}
 func (*Block) Descriptor() ([]byte, []int) {
-	return fileDescriptor_block_508d5006735d6a13, []int{2}
+	return fileDescriptor_block_d66ca570276a532e, []int{2}
 }
 func (m *Block) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Block.Unmarshal(m, b)
@@ -211,28 +235,30 @@ func init() {
 	proto.RegisterMapType((map[string]string)(nil), "poset.Block.SignaturesEntry")
 }
 
-func init() { proto.RegisterFile("block.proto", fileDescriptor_block_508d5006735d6a13) }
-
-var fileDescriptor_block_508d5006735d6a13 = []byte{
-	// 306 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0x41, 0x4b, 0xc3, 0x40,
-	0x10, 0x85, 0x49, 0xd2, 0x54, 0x32, 0xa9, 0x58, 0x16, 0x0f, 0x8b, 0xf4, 0x10, 0x42, 0x0f, 0x39,
-	0xe5, 0x50, 0x2f, 0x22, 0x7a, 0xa9, 0x28, 0xf5, 0xba, 0x16, 0x3c, 0x6f, 0x9b, 0xc1, 0x86, 0xb6,
-	0xbb, 0x65, 0xb3, 0x29, 0xed, 0x9f, 0xf2, 0x37, 0xca, 0x4e, 0x30, 0x49, 0x05, 0x6f, 0xb3, 0xdf,
-	0x7b, 0x93, 0x79, 0x33, 0x81, 0x78, 0xb5, 0xd3, 0xeb, 0x6d, 0x7e, 0x30, 0xda, 0x6a, 0x16, 0x1e,
-	0x74, 0x85, 0x36, 0xdd, 0x42, 0x34, 0x77, 0x74, 0xae, 0x8b, 0x33, 0xbb, 0x85, 0xf0, 0x5d, 0x15,
-	0x78, 0xe2, 0x5e, 0xe2, 0x65, 0x81, 0x68, 0x1e, 0x6c, 0x0a, 0xd7, 0x42, 0xd7, 0xaa, 0x10, 0xb8,
-	0xc6, 0xf2, 0x88, 0x05, 0xf7, 0x49, 0xbd, 0x84, 0x2c, 0x85, 0xd1, 0xd2, 0x48, 0x55, 0xc9, 0xb5,
-	0x2d, 0xb5, 0xaa, 0x78, 0x98, 0x04, 0xd9, 0x48, 0x5c, 0xb0, 0x74, 0x01, 0xec, 0xb3, 0x34, 0x48,
-	0x03, 0x3f, 0xca, 0x2f, 0x25, 0x6d, 0x6d, 0xf0, 0x9f, 0xa9, 0x13, 0x88, 0x5a, 0x0b, 0x4d, 0x8c,
-	0x44, 0x07, 0xd2, 0x6f, 0x1f, 0x42, 0xfa, 0x0c, 0x9b, 0xc2, 0xc0, 0x65, 0xa7, 0xe6, 0x78, 0x36,
-	0xce, 0x69, 0xad, 0xbc, 0xdd, 0x49, 0x90, 0xca, 0x9e, 0x00, 0xda, 0xe6, 0x8a, 0xfb, 0x49, 0x90,
-	0xc5, 0xb3, 0x49, 0xdf, 0x9b, 0x77, 0xf2, 0xab, 0xb2, 0xe6, 0x2c, 0x7a, 0x7e, 0xc6, 0x60, 0xb0,
-	0x91, 0xd5, 0x86, 0x07, 0x89, 0x97, 0x8d, 0x04, 0xd5, 0x6c, 0x0c, 0xc1, 0x06, 0x4f, 0x7c, 0x40,
-	0xc9, 0x5c, 0x49, 0x89, 0xad, 0xb4, 0xb8, 0x70, 0xd6, 0x90, 0xac, 0x1d, 0x70, 0xea, 0x9b, 0x91,
-	0xfb, 0x46, 0x1d, 0x36, 0x6a, 0x0b, 0x58, 0x02, 0xf1, 0x8b, 0x41, 0x69, 0xb1, 0x58, 0x96, 0x7b,
-	0xe4, 0x57, 0x74, 0x89, 0x3e, 0xba, 0x7b, 0x86, 0x9b, 0x3f, 0x11, 0x5d, 0x84, 0x2d, 0x36, 0x9b,
-	0x47, 0xc2, 0x95, 0xee, 0x94, 0x47, 0xb9, 0xab, 0x7f, 0x0f, 0xd6, 0x3c, 0x1e, 0xfd, 0x07, 0x6f,
-	0x35, 0xa4, 0xbf, 0x7e, 0xff, 0x13, 0x00, 0x00, 0xff, 0xff, 0x10, 0xb8, 0x9f, 0xf4, 0x04, 0x02,
-	0x00, 0x00,
+func init() { proto.RegisterFile("block.proto", fileDescriptor_block_d66ca570276a532e) }
+
+var fileDescriptor_block_d66ca570276a532e = []byte{
+	// 349 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0x41, 0x6b, 0xdb, 0x40,
+	0x10, 0x85, 0x91, 0x64, 0xb9, 0xd5, 0xc8, 0xa5, 0x66, 0x29, 0x65, 0x29, 0xa6, 0x08, 0xe1, 0x83,
+	0x4e, 0x3a, 0xb8, 0x97, 0x52, 0x9a, 0x8b, 0x83, 0x13, 0xe7, 0xba, 0x31, 0xe4, 0xbc, 0xb6, 0x86,
+	0x48, 0xd8, 0xde, 0x35, 0xda, 0xb5, 0xb1, 0xff, 0x54, 0xfe, 0x45, 0xfe, 0x57, 0xd8, 0x91, 0x63,
+	0xc9, 0x81, 0xdc, 0x76, 0xde, 0x7b, 0x23, 0xe6, 0x7d, 0x08, 0xe2, 0xe5, 0x46, 0xaf, 0xd6, 0xf9,
+	0xae, 0xd6, 0x56, 0xb3, 0x70, 0xa7, 0x0d, 0xda, 0xf4, 0xd5, 0x83, 0x68, 0xea, 0xe4, 0xa9, 0x2e,
+	0x4e, 0xec, 0x07, 0x84, 0x0f, 0xaa, 0xc0, 0x23, 0xf7, 0x12, 0x2f, 0x0b, 0x44, 0x33, 0xb0, 0x31,
+	0x7c, 0x13, 0x7a, 0xaf, 0x0a, 0x81, 0x2b, 0xac, 0x0e, 0x58, 0x70, 0x9f, 0xdc, 0x6b, 0x91, 0xa5,
+	0x30, 0x58, 0xd4, 0x52, 0x19, 0xb9, 0xb2, 0x95, 0x56, 0x86, 0x87, 0x49, 0x90, 0x0d, 0xc4, 0x95,
+	0xc6, 0x7e, 0x42, 0x5f, 0x48, 0x55, 0xe8, 0x2d, 0xef, 0x27, 0x5e, 0x36, 0x10, 0xe7, 0x89, 0x25,
+	0x10, 0xdf, 0xa3, 0x42, 0x53, 0x99, 0xb9, 0x34, 0x25, 0xff, 0x42, 0x66, 0x57, 0x62, 0xbf, 0x01,
+	0x66, 0x07, 0x54, 0xd6, 0x08, 0xad, 0x2d, 0xff, 0x4a, 0x81, 0x8e, 0x92, 0xce, 0x81, 0x3d, 0x55,
+	0x35, 0x52, 0x95, 0xc7, 0xea, 0x59, 0x49, 0xbb, 0xaf, 0xf1, 0x93, 0x3e, 0x23, 0x88, 0x2e, 0x11,
+	0xea, 0x12, 0x89, 0x56, 0x48, 0x5f, 0x7c, 0x08, 0xe9, 0x33, 0x6c, 0x0c, 0x3d, 0x47, 0x85, 0x96,
+	0xe3, 0xc9, 0x30, 0x27, 0x62, 0xf9, 0x85, 0x96, 0x20, 0x97, 0xfd, 0x07, 0xb8, 0x2c, 0x1b, 0xee,
+	0x27, 0x41, 0x16, 0x4f, 0x46, 0xdd, 0x6c, 0xde, 0xda, 0x33, 0x65, 0xeb, 0x93, 0xe8, 0xe4, 0x19,
+	0x83, 0x5e, 0xe9, 0x2a, 0x07, 0xd4, 0x88, 0xde, 0x6c, 0x08, 0x41, 0x89, 0x47, 0xde, 0xa3, 0xcb,
+	0x82, 0xf2, 0x7c, 0xb1, 0x95, 0x16, 0x89, 0x4e, 0x48, 0xd1, 0x56, 0x70, 0xee, 0x5d, 0x2d, 0xb7,
+	0x8d, 0xdb, 0x80, 0x6d, 0x05, 0xc7, 0xf6, 0xb6, 0x46, 0x69, 0xb1, 0x58, 0x54, 0x5b, 0x24, 0xb6,
+	0x81, 0xe8, 0x4a, 0xbf, 0x6e, 0xe0, 0xfb, 0x87, 0x13, 0xdd, 0x09, 0x6b, 0x6c, 0x9a, 0x47, 0xc2,
+	0x3d, 0x1d, 0xca, 0x83, 0xdc, 0xec, 0xdf, 0x81, 0x35, 0xc3, 0x3f, 0xff, 0xaf, 0xb7, 0xec, 0xd3,
+	0x0f, 0xf5, 0xe7, 0x6d, 0x00, 0x29, 0xb6, 0xc6, 0x93, 0x5f, 0x02, 0x00, 0x00,
 }
@@ -68,3 +68,36 @@ func TestAppendSignature(t *testing.T) {
 	}
 
 }
+
+func TestBlockDuplicateTransactions(t *testing.T) {
+	tx := func(s string) []byte { return []byte(s) }
+	event := func(creator string, txs ...[]byte) *EventMessage {
+		e := NewEvent(txs, nil, nil, EventHashes{GenRootSelfParent(1), EventHash{}},
+			[]byte(creator), 0, nil)
+		return e.Message
+	}
+	// two validators included the gossiped transaction b
+	frame := Frame{Round: 1, Events: []*EventMessage{
+		event("creator0", tx("a"), tx("b")),
+		event("creator1", tx("b"), tx("c")),
+	}}
+
+	block, err := NewBlockFromFrame(0, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txs := block.Transactions(); fmt.Sprintf("%s", txs) != "[a b b c]" {
+		t.Fatalf("expected the transactions of the events, got %s", txs)
+	}
+
+	// with SetDedupBlockTransactions
+	txs := dropTransactions(block.Transactions(), nil)
+	if fmt.Sprintf("%s", txs) != "[a b c]" {
+		t.Fatalf("expected the transactions once, in order, got %s", txs)
+	}
+	// and the previous block has c already
+	txs = dropTransactions(block.Transactions(), [][]byte{tx("c")})
+	if fmt.Sprintf("%s", txs) != "[a b]" {
+		t.Fatalf("expected the transactions of the previous block dropped, got %s", txs)
+	}
+}
@@ -0,0 +1,722 @@
+package poset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/pos"
+	"github.com/Fantom-foundation/go-lachesis/src/state"
+)
+
+var (
+	boltDataBucket  = []byte("lachesis")
+	boltStateBucket = []byte(statePrefix)
+)
+
+// BoltStore is a Store kept in a single bbolt file. It uses the keys of
+// version 1 of the BadgerStore schema, see badgerMigrations. Writes are
+// slower, but the file can be copied while the node runs, see Backup.
+type BoltStore struct {
+	participants  *peers.Peers
+	inmemStore    *InmemStore
+	db            *bolt.DB
+	path          string
+	needBootstrap bool
+
+	states    state.Database
+	stateRoot common.Hash
+}
+
+func openBolt(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltDataBucket, boltStateBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewBoltStore creates a brand new Store with a new database file
+func NewBoltStore(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config) (*BoltStore, error) {
+	inmemStore := NewInmemStore(participants, cacheSize, posConf)
+	handle, err := openBolt(path)
+	if err != nil {
+		return nil, err
+	}
+	store := &BoltStore{
+		participants: participants,
+		inmemStore:   inmemStore,
+		db:           handle,
+		path:         path,
+		states: state.NewDatabase(
+			kvdb.NewBoltDatabase(handle, boltStateBucket)),
+	}
+	if err := store.dbSetParticipants(participants); err != nil {
+		return nil, err
+	}
+	if err := store.dbSetRoots(inmemStore.rootsByParticipant); err != nil {
+		return nil, err
+	}
+
+	store.stateRoot, err = pos.FakeGenesis(participants, posConf, store.states)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// LoadBoltStore creates a Store from an existing database file
+func LoadBoltStore(cacheSize int, path string) (*BoltStore, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	handle, err := openBolt(path)
+	if err != nil {
+		return nil, err
+	}
+	store := &BoltStore{
+		db:            handle,
+		path:          path,
+		needBootstrap: true,
+		states: state.NewDatabase(
+			kvdb.NewBoltDatabase(handle, boltStateBucket)),
+	}
+
+	participants, err := store.dbGetParticipants()
+	if err != nil {
+		return nil, err
+	}
+
+	inmemStore := NewInmemStore(participants, cacheSize, nil)
+
+	// read roots from db and put them in InmemStore
+	roots := make(map[string]Root)
+	for p := range participants.ByPubKey {
+		root, err := store.dbGetRoot(p)
+		if err != nil {
+			return nil, err
+		}
+		roots[p] = root
+	}
+
+	if err := inmemStore.Reset(roots); err != nil {
+		return nil, err
+	}
+
+	store.participants = participants
+	store.inmemStore = inmemStore
+
+	return store, nil
+}
+
+// LoadOrCreateBoltStore load or create a new bolt store
+func LoadOrCreateBoltStore(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config) (*BoltStore, error) {
+	store, err := LoadBoltStore(cacheSize, path)
+
+	if err != nil {
+		fmt.Println("Could not load store - creating new")
+		store, err = NewBoltStore(participants, cacheSize, path, posConf)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// Backup writes a consistent copy of the database file to w, without
+// blocking writers. The copy can be opened with LoadBoltStore.
+func (s *BoltStore) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+/*
+ * Store interface implementation:
+ */
+
+// TopologicalEvents returns event in topological order.
+func (s *BoltStore) TopologicalEvents() ([]Event, error) {
+	var res []Event
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDataBucket)
+		for t := int64(0); ; t++ {
+			hash := b.Get(topologicalEventKey(t))
+			if hash == nil {
+				return nil
+			}
+			eventBytes := b.Get(hash)
+			if eventBytes == nil {
+				return kvdb.ErrBoltKeyNotFound
+			}
+			event := &Event{
+				roundReceived:    RoundNIL,
+				round:            RoundNIL,
+				lamportTimestamp: LamportTimestampNIL,
+			}
+			if err := event.ProtoUnmarshal(eventBytes); err != nil {
+				return err
+			}
+			res = append(res, *event)
+		}
+	})
+
+	return res, err
+}
+
+// CacheSize returns the cache size for the store
+func (s *BoltStore) CacheSize() int {
+	return s.inmemStore.CacheSize()
+}
+
+// Participants returns all participants in the store
+func (s *BoltStore) Participants() (*peers.Peers, error) {
+	return s.participants, nil
+}
+
+// RootsBySelfParent returns Self Parent's EventHash map of the roots
+func (s *BoltStore) RootsBySelfParent() map[EventHash]Root {
+	return s.inmemStore.RootsBySelfParent()
+}
+
+// RootsByParticipant returns PubKeyHex map of the roots
+func (s *BoltStore) RootsByParticipant() map[string]Root {
+	return s.inmemStore.RootsByParticipant()
+}
+
+// GetEventBlock get specific event block by hash
+func (s *BoltStore) GetEventBlock(hash EventHash) (event Event, err error) {
+	// try to get it from cache
+	event, err = s.inmemStore.GetEventBlock(hash)
+	// if not in cache, try to get it from db
+	if err != nil {
+		event, err = s.dbGetEventBlock(hash)
+	}
+	return event, mapError(err, "Event", hash.String())
+}
+
+// SetEvent set a specific event
+func (s *BoltStore) SetEvent(event Event) error {
+	// try to add it to the cache
+	if err := s.inmemStore.SetEvent(event); err != nil {
+		return err
+	}
+	// try to add it to the db
+	return s.dbSetEvents([]Event{event})
+}
+
+// ParticipantEvents return all participant events
+func (s *BoltStore) ParticipantEvents(participant string, skip int64) (EventHashes, error) {
+	res, err := s.inmemStore.ParticipantEvents(participant, skip)
+	if err != nil {
+		res, err = s.dbParticipantEvents(participant, skip)
+	}
+	return res, err
+}
+
+// ParticipantEvent get specific participant event
+func (s *BoltStore) ParticipantEvent(participant string, index int64) (EventHash, error) {
+	result, err := s.inmemStore.ParticipantEvent(participant, index)
+	if err != nil {
+		result, err = s.dbParticipantEvent(participant, index)
+	}
+	return result, mapError(err, "ParticipantEvent", string(legacyParticipantEventKey(participant, index)))
+}
+
+// LastEventFrom returns the last event for a participant
+func (s *BoltStore) LastEventFrom(participant string) (last EventHash, isRoot bool, err error) {
+	return s.inmemStore.LastEventFrom(participant)
+}
+
+// LastConsensusEventFrom returns the last consensus events for a participant
+func (s *BoltStore) LastConsensusEventFrom(participant string) (last EventHash, isRoot bool, err error) {
+	return s.inmemStore.LastConsensusEventFrom(participant)
+}
+
+// ConsensusEvents returns all consensus events
+func (s *BoltStore) ConsensusEvents() EventHashes {
+	return s.inmemStore.ConsensusEvents()
+}
+
+// ConsensusEventsCount returns the count for all known consensus events
+func (s *BoltStore) ConsensusEventsCount() int64 {
+	return s.inmemStore.ConsensusEventsCount()
+}
+
+// AddConsensusEvent adds a consensus event to the store
+func (s *BoltStore) AddConsensusEvent(event Event) error {
+	return s.inmemStore.AddConsensusEvent(event)
+}
+
+// GetRoundCreated gets the created round info for a given index
+func (s *BoltStore) GetRoundCreated(r int64) (RoundCreated, error) {
+	res, err := s.inmemStore.GetRoundCreated(r)
+	if err != nil {
+		res, err = s.dbGetRoundCreated(r)
+	}
+	return res, mapError(err, "RoundCreated", string(roundCreatedKey(r)))
+}
+
+// SetRoundCreated sets the created round info for a given index
+func (s *BoltStore) SetRoundCreated(r int64, round RoundCreated) error {
+	if err := s.inmemStore.SetRoundCreated(r, round); err != nil {
+		return err
+	}
+	val, err := round.ProtoMarshal()
+	if err != nil {
+		return err
+	}
+	return s.dbPut(roundCreatedKey(r), val)
+}
+
+// GetRoundReceived gets the received round for a given index
+func (s *BoltStore) GetRoundReceived(r int64) (RoundReceived, error) {
+	res, err := s.inmemStore.GetRoundReceived(r)
+	if err != nil {
+		res, err = s.dbGetRoundReceived(r)
+	}
+	return res, mapError(err, "RoundReceived", string(roundReceivedKey(r)))
+}
+
+// SetRoundReceived sets the received round info for a given index
+func (s *BoltStore) SetRoundReceived(r int64, round RoundReceived) error {
+	if err := s.inmemStore.SetRoundReceived(r, round); err != nil {
+		return err
+	}
+	val, err := round.ProtoMarshal()
+	if err != nil {
+		return err
+	}
+	return s.dbPut(roundReceivedKey(r), val)
+}
+
+// LastRound returns the last round for the store
+func (s *BoltStore) LastRound() int64 {
+	return s.inmemStore.LastRound()
+}
+
+// RoundClothos returns all clothos for a round
+func (s *BoltStore) RoundClothos(r int64) EventHashes {
+	round, err := s.GetRoundCreated(r)
+	if err != nil {
+		return EventHashes{}
+	}
+	return round.Clotho()
+}
+
+// RoundEvents returns all events for a round
+func (s *BoltStore) RoundEvents(r int64) int {
+	round, err := s.GetRoundCreated(r)
+	if err != nil {
+		return 0
+	}
+	return len(round.Message.Events)
+}
+
+// GetRoot returns the root for a participant
+func (s *BoltStore) GetRoot(participant string) (Root, error) {
+	root, err := s.inmemStore.GetRoot(participant)
+	if err != nil {
+		root, err = s.dbGetRoot(participant)
+	}
+	return root, mapError(err, "Root", string(participantRootKey(participant)))
+}
+
+// GetBlock returns the block for a given index
+func (s *BoltStore) GetBlock(rr int64) (Block, error) {
+	res, err := s.inmemStore.GetBlock(rr)
+	if err != nil {
+		res, err = s.dbGetBlock(rr)
+	}
+	return res, mapError(err, "Block", string(blockKey(rr)))
+}
+
+// SetBlock add a block
+func (s *BoltStore) SetBlock(block Block) error {
+	if err := s.inmemStore.SetBlock(block); err != nil {
+		return err
+	}
+	val, err := block.ProtoMarshal()
+	if err != nil {
+		return err
+	}
+	return s.dbPut(blockKey(block.Index()), val)
+}
+
+// LastBlockIndex returns the last block index (height)
+func (s *BoltStore) LastBlockIndex() int64 {
+	return s.inmemStore.LastBlockIndex()
+}
+
+// GetFrame returns a specific frame for the index
+func (s *BoltStore) GetFrame(rr int64) (Frame, error) {
+	res, err := s.inmemStore.GetFrame(rr)
+	if err != nil {
+		res, err = s.dbGetFrame(rr)
+	}
+	return res, mapError(err, "Frame", string(frameKey(rr)))
+}
+
+// SetFrame add a frame
+func (s *BoltStore) SetFrame(frame Frame) error {
+	if err := s.inmemStore.SetFrame(frame); err != nil {
+		return err
+	}
+	val, err := frame.ProtoMarshal()
+	if err != nil {
+		return err
+	}
+	return s.dbPut(frameKey(frame.Round), val)
+}
+
+// Reset all roots
+func (s *BoltStore) Reset(roots map[string]Root) error {
+	return s.inmemStore.Reset(roots)
+}
+
+// Close bolt
+func (s *BoltStore) Close() error {
+	if err := s.inmemStore.Close(); err != nil {
+		return err
+	}
+	return s.db.Close()
+}
+
+// NeedBootstrap checks if bootstrapping is required
+func (s *BoltStore) NeedBootstrap() bool {
+	return s.needBootstrap
+}
+
+// StorePath returns the path to the file on disk
+func (s *BoltStore) StorePath() string {
+	return s.path
+}
+
+// StateDB returns state database
+func (s *BoltStore) StateDB() state.Database {
+	return s.states
+}
+
+// StateRoot returns genesis state hash.
+func (s *BoltStore) StateRoot() common.Hash {
+	return s.stateRoot
+}
+
+// EventsRange reads the events in the range with a cursor over the
+// topological index, see Store
+func (s *BoltStore) EventsRange(from, to int64, fn func(Event) error) error {
+	return s.dbRange(topoPrefix, topologicalEventKey, from, to, func(b *bolt.Bucket, _ int64, hash []byte) error {
+		eventBytes := b.Get(hash)
+		if eventBytes == nil {
+			return kvdb.ErrBoltKeyNotFound
+		}
+		event := Event{
+			roundReceived:    RoundNIL,
+			round:            RoundNIL,
+			lamportTimestamp: LamportTimestampNIL,
+		}
+		if err := event.ProtoUnmarshal(common.CopyBytes(eventBytes)); err != nil {
+			return err
+		}
+		return fn(event)
+	})
+}
+
+// BlocksRange reads the blocks in the range with a cursor, see Store
+func (s *BoltStore) BlocksRange(from, to int64, fn func(Block) error) error {
+	return s.dbRange(blockPrefix, blockKey, from, to, func(_ *bolt.Bucket, _ int64, val []byte) error {
+		var block Block
+		if err := block.ProtoUnmarshal(val); err != nil {
+			return err
+		}
+		return fn(block)
+	})
+}
+
+// RoundsRange reads the created rounds in the range with a cursor, see Store
+func (s *BoltStore) RoundsRange(from, to int64, fn func(int64, RoundCreated) error) error {
+	return s.dbRange(roundCreatedPrefix, roundCreatedKey, from, to, func(_ *bolt.Bucket, index int64, val []byte) error {
+		round := new(RoundCreated)
+		if err := round.ProtoUnmarshal(val); err != nil {
+			return err
+		}
+		round.Message.Queued = false
+		return fn(index, *round)
+	})
+}
+
+// ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+// DB Methods
+
+// dbRange is the BoltStore version of BadgerStore.dbRange. val is a copy,
+// which fn may keep.
+func (s *BoltStore) dbRange(prefix string, key func(int64) []byte, from, to int64,
+	fn func(b *bolt.Bucket, index int64, val []byte) error) error {
+	valid := []byte(prefix + "_")
+	var end []byte
+	if to >= 0 {
+		end = key(to)
+	}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDataBucket)
+		c := b.Cursor()
+		for k, v := c.Seek(key(from)); k != nil && bytes.HasPrefix(k, valid); k, v = c.Next() {
+			if end != nil && bytes.Compare(k, end) >= 0 {
+				return nil
+			}
+			index, err := strconv.ParseInt(string(k[len(valid):]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("key %s: %v", k, err)
+			}
+			if err := fn(b, index, common.CopyBytes(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return stopRange(err)
+}
+
+func (s *BoltStore) dbGet(key []byte) (val []byte, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltDataBucket).Get(key)
+		if v == nil {
+			return kvdb.ErrBoltKeyNotFound
+		}
+		// v is only valid during the transaction
+		val = common.CopyBytes(v)
+		return nil
+	})
+	return
+}
+
+func (s *BoltStore) dbPut(key, val []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDataBucket).Put(key, val)
+	})
+}
+
+func (s *BoltStore) dbGetEventBlock(hash EventHash) (Event, error) {
+	eventBytes, err := s.dbGet(hash.Bytes())
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := new(Event)
+	if err := event.ProtoUnmarshal(eventBytes); err != nil {
+		return Event{}, err
+	}
+
+	return *event, nil
+}
+
+func (s *BoltStore) dbSetEvents(events []Event) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDataBucket)
+		for _, event := range events {
+			eventHash := event.Hash()
+			val, err := event.ProtoMarshal()
+			if err != nil {
+				return err
+			}
+			// check if it already exists
+			notFound := b.Get(eventHash.Bytes()) == nil
+
+			// insert [event hash] => [event bytes]
+			if err := b.Put(eventHash.Bytes(), val); err != nil {
+				return err
+			}
+
+			if notFound {
+				// insert [topo_index] => [event hash]
+				topoKey := topologicalEventKey(event.Message.TopologicalIndex)
+				if err := b.Put(topoKey, eventHash.Bytes()); err != nil {
+					return err
+				}
+				// insert [participant_index] => [event hash]
+				peKey := legacyParticipantEventKey(event.GetCreator(), event.Index())
+				if err := b.Put(peKey, eventHash.Bytes()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) dbParticipantEvents(participant string, skip int64) (res EventHashes, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDataBucket)
+		for i := skip + 1; ; i++ {
+			v := b.Get(legacyParticipantEventKey(participant, i))
+			if v == nil {
+				return nil
+			}
+			var hash EventHash
+			hash.Set(v)
+			res = append(res, hash)
+		}
+	})
+	return
+}
+
+func (s *BoltStore) dbParticipantEvent(participant string, index int64) (hash EventHash, err error) {
+	val, err := s.dbGet(legacyParticipantEventKey(participant, index))
+	if err != nil {
+		return
+	}
+	hash.Set(val)
+	return
+}
+
+func (s *BoltStore) dbSetRoots(roots map[string]Root) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDataBucket)
+		for participant, root := range roots {
+			val, err := root.ProtoMarshal()
+			if err != nil {
+				return err
+			}
+			// insert [participant_root] => [root bytes]
+			if err := b.Put(participantRootKey(participant), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) dbGetRoot(participant string) (Root, error) {
+	rootBytes, err := s.dbGet(participantRootKey(participant))
+	if err != nil {
+		return Root{}, err
+	}
+
+	root := new(Root)
+	if err := root.ProtoUnmarshal(rootBytes); err != nil {
+		return Root{}, err
+	}
+
+	return *root, nil
+}
+
+func (s *BoltStore) dbGetRoundCreated(index int64) (RoundCreated, error) {
+	roundBytes, err := s.dbGet(roundCreatedKey(index))
+	if err != nil {
+		return *NewRoundCreated(), err
+	}
+
+	roundInfo := new(RoundCreated)
+	if err := roundInfo.ProtoUnmarshal(roundBytes); err != nil {
+		return *NewRoundCreated(), err
+	}
+	// Queued is re-calculated for each round, see BadgerStore
+	roundInfo.Message.Queued = false
+
+	return *roundInfo, nil
+}
+
+func (s *BoltStore) dbGetRoundReceived(index int64) (RoundReceived, error) {
+	roundBytes, err := s.dbGet(roundReceivedKey(index))
+	if err != nil {
+		return *NewRoundReceived(), err
+	}
+
+	roundInfo := new(RoundReceived)
+	if err := roundInfo.ProtoUnmarshal(roundBytes); err != nil {
+		return *NewRoundReceived(), err
+	}
+
+	return *roundInfo, nil
+}
+
+func (s *BoltStore) dbGetParticipants() (*peers.Peers, error) {
+	res := peers.NewPeers()
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltDataBucket).Cursor()
+		prefix := []byte(participantPrefix)
+
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			pubKey := string(k[len(participantPrefix)+1:])
+
+			res.AddPeer(peers.NewPeer(pubKey, ""))
+		}
+
+		return nil
+	})
+
+	return res, err
+}
+
+func (s *BoltStore) dbSetParticipants(participants *peers.Peers) error {
+	participants.RLock()
+	defer participants.RUnlock()
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDataBucket)
+		for participant, id := range participants.ByPubKey {
+			// insert [participant_participant] => [id]
+			if err := b.Put(participantKey(participant), []byte(fmt.Sprint(id.ID))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) dbGetBlock(index int64) (Block, error) {
+	blockBytes, err := s.dbGet(blockKey(index))
+	if err != nil {
+		return Block{}, err
+	}
+
+	block := new(Block)
+	if err := block.ProtoUnmarshal(blockBytes); err != nil {
+		return Block{}, err
+	}
+
+	return *block, nil
+}
+
+func (s *BoltStore) dbGetFrame(index int64) (Frame, error) {
+	frameBytes, err := s.dbGet(frameKey(index))
+	if err != nil {
+		return Frame{}, err
+	}
+
+	frame := new(Frame)
+	if err := frame.ProtoUnmarshal(frameBytes); err != nil {
+		return Frame{}, err
+	}
+
+	return *frame, nil
+}
@@ -0,0 +1,86 @@
+package poset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+func TestBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lachesis_bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	participants := peers.NewPeersFromSlice([]*peers.Peer{
+		peers.NewPeer("0xAA", ""),
+		peers.NewPeer("0xBB", ""),
+		peers.NewPeer("0xCC", ""),
+	})
+	path := filepath.Join(dir, "lachesis.db")
+	store, err := NewBoltStore(participants, 1, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := NewBlock(0, 5, []byte("framehash"), [][]byte{[]byte("tx1"), []byte("tx2")})
+	if err := store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	frame := Frame{Round: 5}
+	if err := store.SetFrame(frame); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetBlock(1); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("expected KeyNotFound for a missing block, got %v", err)
+	}
+
+	backup, err := os.Create(filepath.Join(dir, "backup.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Backup(backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{path, backup.Name()} {
+		loaded, err := LoadBoltStore(1, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.NeedBootstrap() {
+			t.Fatalf("%s: loaded store should need bootstrap", p)
+		}
+		if loaded.participants.Len() != participants.Len() {
+			t.Fatalf("%s: expected %d participants, got %d", p, participants.Len(), loaded.participants.Len())
+		}
+		storedBlock, err := loaded.GetBlock(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !storedBlock.Equals(&block) {
+			t.Fatalf("%s: Block and StoredBlock do not match", p)
+		}
+		storedFrame, err := loaded.GetFrame(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if storedFrame.Round != frame.Round {
+			t.Fatalf("%s: expected frame of round %d, got %d", p, frame.Round, storedFrame.Round)
+		}
+		if err := loaded.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
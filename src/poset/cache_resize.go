@@ -0,0 +1,56 @@
+package poset
+
+// CacheResizer is a Store whose LRU caches can be resized while it is used,
+// such as InmemStore, BadgerStore and BoltStore
+type CacheResizer interface {
+	Store
+	ResizeCache(size int)
+}
+
+// ResizeCache resizes the LRU caches of the store, evicting the oldest
+// items if they shrink. The transaction index keeps txIndexCacheFactor
+// items per block.
+func (s *InmemStore) ResizeCache(size int) {
+	for _, cache := range []interface{ Resize(int) int }{
+		s.eventCache,
+		s.roundCreatedCache,
+		s.roundReceivedCache,
+		s.blockCache,
+		s.frameCache,
+		s.receiptCache,
+		s.blockEventsCache,
+	} {
+		cache.Resize(size)
+	}
+	s.txCache.Resize(size * txIndexCacheFactor)
+	s.cacheSizeLocker.Lock()
+	s.cacheSize = size
+	s.cacheSizeLocker.Unlock()
+}
+
+// ResizeCache resizes the LRU caches of the in-memory layer
+func (s *BadgerStore) ResizeCache(size int) {
+	s.inmemStore.ResizeCache(size)
+}
+
+// ResizeCache resizes the LRU caches of the in-memory layer
+func (s *BoltStore) ResizeCache(size int) {
+	s.inmemStore.ResizeCache(size)
+}
+
+// ResizeCache resizes the LRU caches of the poset, and those of the store
+// if it is a CacheResizer
+func (p *Poset) ResizeCache(size int) {
+	for _, cache := range []interface{ Resize(int) int }{
+		p.dominatorCache,
+		p.selfDominatorCache,
+		p.strictlyDominatedCache,
+		p.roundCache,
+		p.timestampCache,
+	} {
+		cache.Resize(size)
+	}
+	if store, ok := p.Store.(CacheResizer); ok {
+		store.ResizeCache(size)
+	}
+}
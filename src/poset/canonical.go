@@ -0,0 +1,216 @@
+package poset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+// Canonical JSON is a stable, language neutral view of events, blocks and
+// frames (see docs/canonical.rst). Objects have their keys sorted, there is no
+// insignificant whitespace, byte strings are "0x" prefixed upper case hex and
+// all integers are decimal strings, so that 64 bit values survive parsers
+// which only know doubles. Hashes are not computed over the JSON itself but
+// over the deterministic protobuf encoding, which the JSON carries field by
+// field so that it can be rebuilt.
+
+// MarshalCanonical returns the canonical JSON of an Event, Block or Frame
+func MarshalCanonical(v interface{}) ([]byte, error) {
+	var (
+		c   map[string]interface{}
+		err error
+	)
+	switch t := v.(type) {
+	case Event:
+		c, err = canonicalEvent(t.Message)
+	case *Event:
+		c, err = canonicalEvent(t.Message)
+	case EventMessage:
+		c, err = canonicalEvent(&t)
+	case *EventMessage:
+		c, err = canonicalEvent(t)
+	case Block:
+		c, err = canonicalBlock(&t)
+	case *Block:
+		c, err = canonicalBlock(t)
+	case Frame:
+		c, err = canonicalFrame(&t)
+	case *Frame:
+		c, err = canonicalFrame(t)
+	default:
+		return nil, fmt.Errorf("no canonical encoding for %T", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	// Encode terminates the value with a newline which is not part of it
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+func canonicalBytes(b []byte) string {
+	return fmt.Sprintf("0x%X", b)
+}
+
+func canonicalBytesList(list [][]byte) []string {
+	res := make([]string, len(list))
+	for i, b := range list {
+		res[i] = canonicalBytes(b)
+	}
+	return res
+}
+
+func canonicalInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func canonicalUint(i uint64) string {
+	return strconv.FormatUint(i, 10)
+}
+
+func canonicalPeer(p *peers.Peer) interface{} {
+	if p == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"id":          canonicalUint(p.ID),
+		"net_addr":    p.NetAddr,
+		"pub_key_hex": p.PubKeyHex,
+		"used":        canonicalInt(p.Used),
+		"height":      canonicalInt(p.Height),
+		"in_degree":   canonicalInt(p.InDegree),
+	}
+}
+
+func canonicalEventBody(b *EventBody) map[string]interface{} {
+	itxs := make([]interface{}, len(b.InternalTransactions))
+	for i, tx := range b.InternalTransactions {
+		itxs[i] = map[string]interface{}{
+			"type":   tx.Type.String(),
+			"peer":   canonicalPeer(tx.Peer),
+			"amount": canonicalUint(tx.Amount),
+		}
+	}
+	sigs := make([]interface{}, len(b.BlockSignatures))
+	for i, bs := range b.BlockSignatures {
+		sigs[i] = map[string]interface{}{
+			"validator": canonicalBytes(bs.Validator),
+			"index":     canonicalInt(bs.Index),
+			"signature": bs.Signature,
+		}
+	}
+	return map[string]interface{}{
+		"transactions":          canonicalBytesList(b.Transactions),
+		"internal_transactions": itxs,
+		"parents":               canonicalBytesList(b.Parents),
+		"creator":               canonicalBytes(b.Creator),
+		"index":                 canonicalInt(b.Index),
+		"block_signatures":      sigs,
+	}
+}
+
+func canonicalEvent(m *EventMessage) (map[string]interface{}, error) {
+	if m == nil || m.Body == nil {
+		return nil, fmt.Errorf("event has no body")
+	}
+	hash, err := m.Body.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"hash":                    canonicalBytes(hash.Bytes()),
+		"body":                    canonicalEventBody(m.Body),
+		"signature":               m.Signature,
+		"flag_table":              canonicalBytes(m.FlagTable),
+		"clotho_proof":            canonicalBytesList(m.ClothoProof),
+		"self_parent_index":       canonicalInt(m.SelfParentIndex),
+		"other_parent_creator_id": canonicalUint(m.OtherParentCreatorID),
+		"other_parent_index":      canonicalInt(m.OtherParentIndex),
+		"creator_id":              canonicalUint(m.CreatorID),
+		"topological_index":       canonicalInt(m.TopologicalIndex),
+		"message_hash":            canonicalBytes(m.Hash),
+	}, nil
+}
+
+func canonicalBlock(b *Block) (map[string]interface{}, error) {
+	if b.Body == nil {
+		return nil, fmt.Errorf("block has no body")
+	}
+	hash, err := b.Body.Hash()
+	if err != nil {
+		return nil, err
+	}
+	signatures := make(map[string]string, len(b.Signatures))
+	for validator, sig := range b.Signatures {
+		signatures[validator] = sig
+	}
+	return map[string]interface{}{
+		"hash": canonicalBytes(hash),
+		"body": map[string]interface{}{
+			"index":          canonicalInt(b.Body.Index),
+			"round_received": canonicalInt(b.Body.RoundReceived),
+			"transactions":   canonicalBytesList(b.Body.Transactions),
+			"random":         canonicalBytes(b.Body.Random),
+			"genesis_hash":   canonicalBytes(b.Body.GenesisHash),
+			"events_root":    canonicalBytes(b.Body.EventsRoot),
+		},
+		"signatures":   signatures,
+		"state_hash":   canonicalBytes(b.StateHash),
+		"frame_hash":   canonicalBytes(b.FrameHash),
+		"created_time": canonicalInt(b.CreatedTime),
+	}, nil
+}
+
+func canonicalRootEvent(re *RootEvent) interface{} {
+	if re == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"hash":              canonicalBytes(re.Hash),
+		"creator_id":        canonicalUint(re.CreatorID),
+		"index":             canonicalInt(re.Index),
+		"lamport_timestamp": canonicalInt(re.LamportTimestamp),
+		"round":             canonicalInt(re.Round),
+	}
+}
+
+func canonicalFrame(f *Frame) (map[string]interface{}, error) {
+	hash, err := f.Hash()
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]interface{}, len(f.Roots))
+	for i, r := range f.Roots {
+		others := make(map[string]interface{}, len(r.Others))
+		for k, re := range r.Others {
+			others[k] = canonicalRootEvent(re)
+		}
+		roots[i] = map[string]interface{}{
+			"next_round":  canonicalInt(r.NextRound),
+			"self_parent": canonicalRootEvent(r.SelfParent),
+			"others":      others,
+		}
+	}
+	events := make([]interface{}, len(f.Events))
+	for i, m := range f.Events {
+		if events[i], err = canonicalEvent(m); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		"hash":       canonicalBytes(hash),
+		"round":      canonicalInt(f.Round),
+		"roots":      roots,
+		"events":     events,
+		"state_hash": canonicalBytes(f.StateHash),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package poset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+)
+
+func TestMarshalCanonicalEvent(t *testing.T) {
+	privateKey, _ := crypto.GenerateECDSAKey()
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	event := NewEvent([][]byte{[]byte("abc")}, nil, nil,
+		EventHashes{GenRootSelfParent(1), EventHash{}}, publicKeyBytes, 0, nil)
+	if err := event.Sign(privateKey); err != nil {
+		t.Fatal(err)
+	}
+	event.SetWireInfo(-1, 0, -1, 1<<63)
+
+	b1, err := MarshalCanonical(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := MarshalCanonical(&event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b1, b2) {
+		t.Fatalf("canonical JSON is not stable:\n%s\n%s", b1, b2)
+	}
+	if bytes.ContainsAny(b1, " \n") {
+		t.Fatalf("canonical JSON should not contain whitespace: %s", b1)
+	}
+
+	var res map[string]interface{}
+	if err := json.Unmarshal(b1, &res); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := event.Message.Body.Hash()
+	if res["hash"] != fmt.Sprintf("0x%X", hash.Bytes()) {
+		t.Fatalf("expected hash 0x%X, got %v", hash.Bytes(), res["hash"])
+	}
+	if res["creator_id"] != "9223372036854775808" {
+		t.Fatalf("creator ID should be a decimal string, got %v", res["creator_id"])
+	}
+	body := res["body"].(map[string]interface{})
+	if body["creator"] != fmt.Sprintf("0x%X", publicKeyBytes) {
+		t.Fatalf("unexpected creator %v", body["creator"])
+	}
+	if _, ok := body["internal_transactions"].([]interface{}); !ok {
+		t.Fatalf("empty lists should not be null, got %v", body["internal_transactions"])
+	}
+}
+
+func TestMarshalCanonicalBlock(t *testing.T) {
+	privateKey, _ := crypto.GenerateECDSAKey()
+
+	block := NewBlock(1, 2, []byte("framehash"), [][]byte{[]byte("abc")})
+	sig, err := block.Sign(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := block.SetSignature(sig); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := MarshalCanonical(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res struct {
+		Hash       string            `json:"hash"`
+		Signatures map[string]string `json:"signatures"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := block.Body.Hash()
+	if res.Hash != fmt.Sprintf("0x%X", hash) {
+		t.Fatalf("expected hash 0x%X, got %s", hash, res.Hash)
+	}
+	if res.Signatures[sig.ValidatorHex()] != sig.Signature {
+		t.Fatalf("missing signature of %s", sig.ValidatorHex())
+	}
+
+	if _, err := MarshalCanonical(42); err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+}
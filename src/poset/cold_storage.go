@@ -0,0 +1,58 @@
+package poset
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotInColdStorage is returned by a ColdStorage which has no object with
+// the requested key
+var ErrNotInColdStorage = errors.New("not in cold storage")
+
+// ColdStorage is an object storage, such as an S3 or GCS bucket, which
+// keeps the rounds a BadgerStore prunes. Keys are slash separated paths.
+type ColdStorage interface {
+	// Put stores an object, replacing any object with the same key
+	Put(key string, data []byte) error
+	// Get returns an object, or ErrNotInColdStorage
+	Get(key string) ([]byte, error)
+}
+
+// DirColdStorage is a ColdStorage which keeps objects as files under a
+// directory, e.g. a bucket mounted with s3fs or gcsfuse
+type DirColdStorage struct {
+	path string
+}
+
+// NewDirColdStorage creates the directory if it does not exist
+func NewDirColdStorage(path string) (*DirColdStorage, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return &DirColdStorage{path: path}, nil
+}
+
+// Put implements ColdStorage. The object is written to a temporary file
+// first so that readers never see it partly written.
+func (c *DirColdStorage) Put(key string, data []byte) error {
+	path := filepath.Join(c.path, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get implements ColdStorage
+func (c *DirColdStorage) Get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(c.path, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrNotInColdStorage
+	}
+	return data, err
+}
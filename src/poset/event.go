@@ -181,6 +181,8 @@ type Event struct {
 	lamportTimestamp int64
 	round            int64
 	roundReceived    int64
+	// verified is set by VerifyEvents once the signature is checked
+	verified bool
 }
 
 // GetRound Round returns round of event.
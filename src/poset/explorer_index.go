@@ -0,0 +1,118 @@
+package poset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+// ExplorerStore is a Store with the indexes explorers page through, such as
+// InmemStore and BadgerStore: the events of the committed blocks, and the
+// events of every creator by index
+type ExplorerStore interface {
+	Store
+	SetBlockEvents(blockIndex int64, events EventHashes) error
+	GetBlockEvents(blockIndex int64) (EventHashes, error)
+	// CreatorEvents returns the events of the creator from the index, at
+	// most limit of them, in index order
+	CreatorEvents(creator string, from int64, limit int) (EventHashes, error)
+}
+
+// SetBlockEvents implements ExplorerStore. Like blocks, only the events of
+// the last blocks are kept.
+func (s *InmemStore) SetBlockEvents(blockIndex int64, events EventHashes) error {
+	defer observeOp("inmem", "SetBlockEvents", time.Now())
+	s.blockEventsCache.Add(blockIndex, events)
+	return nil
+}
+
+// GetBlockEvents implements ExplorerStore
+func (s *InmemStore) GetBlockEvents(blockIndex int64) (EventHashes, error) {
+	defer observeOp("inmem", "GetBlockEvents", time.Now())
+	res, ok := s.blockEventsCache.Get(blockIndex)
+	countLookup("block_events", ok)
+	if !ok {
+		return nil, common.NewStoreErr("BlockEventsCache", common.KeyNotFound, strconv.FormatInt(blockIndex, 10))
+	}
+	return res.(EventHashes), nil
+}
+
+// CreatorEvents implements ExplorerStore, over the cached events of the
+// creator
+func (s *InmemStore) CreatorEvents(creator string, from int64, limit int) (EventHashes, error) {
+	defer observeOp("inmem", "CreatorEvents", time.Now())
+	res, err := s.participantEventsCache.Get(creator, from-1)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func blockEventsKey(blockIndex int64) []byte {
+	return []byte(fmt.Sprintf("%s_%09d", blockEventsPrefix, blockIndex))
+}
+
+// SetBlockEvents implements ExplorerStore
+func (s *BadgerStore) SetBlockEvents(blockIndex int64, events EventHashes) error {
+	defer observeOp("badger", "SetBlockEvents", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	val, err := json.Marshal(events.Bytes())
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx dbWriter) error {
+		return tx.Set(blockEventsKey(blockIndex), val)
+	})
+}
+
+// GetBlockEvents implements ExplorerStore
+func (s *BadgerStore) GetBlockEvents(blockIndex int64) (EventHashes, error) {
+	defer observeOp("badger", "GetBlockEvents", time.Now())
+	var raw [][]byte
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(blockEventsKey(blockIndex))
+		if err != nil {
+			return err
+		}
+		val, err := s.value(item)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(val, &raw)
+	})
+	if err != nil {
+		return nil, mapError(err, "BlockEvents", string(blockEventsKey(blockIndex)))
+	}
+	res := make(EventHashes, len(raw))
+	for i, hash := range raw {
+		res[i].Set(hash)
+	}
+	return res, nil
+}
+
+// CreatorEvents implements ExplorerStore with a range scan of the events of
+// the creator
+func (s *BadgerStore) CreatorEvents(creator string, from int64, limit int) (res EventHashes, err error) {
+	defer observeOp("badger", "CreatorEvents", time.Now())
+	key := func(index int64) []byte {
+		return participantEventKey(creator, index)
+	}
+	prefix := fmt.Sprintf("%s_%s", participantEventPrefix, creator)
+	err = s.dbRange(prefix, key, from, from+int64(limit), func(_ *badger.Txn, _ int64, val []byte) error {
+		var hash EventHash
+		hash.Set(val)
+		res = append(res, hash)
+		return nil
+	})
+	return
+}
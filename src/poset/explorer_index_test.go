@@ -0,0 +1,61 @@
+package poset
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+func testExplorerStore(store ExplorerStore, p pub, t *testing.T) {
+	var hashes EventHashes
+	for k := int64(0); k < 5; k++ {
+		event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], k))},
+			nil, nil, make(EventHashes, 2), p.pubKey, k, nil)
+		if err := store.SetEvent(event); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, event.Hash())
+	}
+
+	if err := store.SetBlockEvents(4, hashes[1:3]); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetBlockEvents(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hashes[1:3], got) {
+		t.Fatalf("expected block events %v, got %v", hashes[1:3], got)
+	}
+	if _, err := store.GetBlockEvents(5); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("block 5 should have no events, got %v", err)
+	}
+
+	got, err = store.CreatorEvents(p.hex, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hashes[1:4], got) {
+		t.Fatalf("expected creator events %v, got %v", hashes[1:4], got)
+	}
+	got, err = store.CreatorEvents(p.hex, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hashes[3:], got) {
+		t.Fatalf("expected creator events %v, got %v", hashes[3:], got)
+	}
+}
+
+func TestInmemExplorerIndex(t *testing.T) {
+	store, participants := initInmemStore(10)
+	testExplorerStore(store, participants[0], t)
+}
+
+func TestBadgerExplorerIndex(t *testing.T) {
+	store, participants := initBadgerStore(10, t)
+	defer removeBadgerStore(store, t)
+	testExplorerStore(store, participants[0], t)
+}
@@ -0,0 +1,38 @@
+package poset
+
+import (
+	"fmt"
+)
+
+// ForkError is returned by InsertEvent for an event whose creator already
+// signed another event at the same index
+type ForkError struct {
+	Creator string
+	Index   int64
+	Known   EventHash
+	Fork    EventHash
+}
+
+func (e *ForkError) Error() string {
+	return fmt.Sprintf("fork of %s at index %d: %s and %s",
+		e.Creator, e.Index, e.Known.String(), e.Fork.String())
+}
+
+// checkFork returns a ForkError if the creator of event has another event
+// at its index
+func (p *Poset) checkFork(event Event) *ForkError {
+	known, err := p.Store.ParticipantEvent(event.GetCreator(), event.Index())
+	if err != nil {
+		return nil
+	}
+	hash := event.Hash()
+	if known == hash {
+		return nil
+	}
+	return &ForkError{
+		Creator: event.GetCreator(),
+		Index:   event.Index(),
+		Known:   known,
+		Fork:    hash,
+	}
+}
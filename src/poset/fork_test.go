@@ -0,0 +1,53 @@
+package poset
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/pos"
+)
+
+func TestInsertForkEvent(t *testing.T) {
+	var nodes []TestNode
+	participants := peers.NewPeers()
+	for i := 0; i < n; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		node := NewTestNode(key)
+		nodes = append(nodes, node)
+		participants.AddPeer(peers.NewPeer(node.PubHex, ""))
+	}
+
+	store := NewInmemStore(participants, cacheSize, pos.DefaultConfig())
+	poset := NewPoset(participants, store, nil, testLogger(t))
+
+	node := nodes[0]
+	selfParent, _, err := poset.Store.LastEventFrom(node.PubHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := NewEvent(nil, nil, nil, EventHashes{selfParent, EventHash{}}, node.Pub, 0, nil)
+	if err := known.Sign(node.Key); err != nil {
+		t.Fatal(err)
+	}
+	if err := poset.InsertEvent(known, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same creator signs another event at index 0
+	fork := NewEvent([][]byte{[]byte("fork")}, nil, nil, EventHashes{selfParent, EventHash{}}, node.Pub, 0, nil)
+	if err := fork.Sign(node.Key); err != nil {
+		t.Fatal(err)
+	}
+	err = poset.InsertEvent(fork, false)
+	forkErr, ok := err.(*ForkError)
+	if !ok {
+		t.Fatalf("expected a ForkError, got %v", err)
+	}
+	if forkErr.Creator != node.PubHex || forkErr.Index != 0 {
+		t.Errorf("wrong fork coordinates: %s", forkErr)
+	}
+	if forkErr.Known != known.Hash() || forkErr.Fork != fork.Hash() {
+		t.Errorf("wrong fork events: %s", forkErr)
+	}
+}
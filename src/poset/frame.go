@@ -1,6 +1,8 @@
 package poset
 
 import (
+	"bytes"
+
 	"github.com/Fantom-foundation/go-lachesis/src/crypto"
 	"github.com/golang/protobuf/proto"
 )
@@ -34,6 +36,43 @@ func (f *Frame) Hash() ([]byte, error) {
 	return crypto.Keccak256(hashBytes), nil
 }
 
+// EventHashes returns the hashes of the frame's events, in consensus order
+func (f *Frame) EventHashes() ([][]byte, error) {
+	hashes := make([][]byte, len(f.Events))
+	for i, e := range f.Events {
+		hash, err := e.Body.Hash()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash.Bytes()
+	}
+	return hashes, nil
+}
+
+// EventsRoot returns the Merkle root of the frame's event hashes, which
+// blocks commit to
+func (f *Frame) EventsRoot() ([]byte, error) {
+	hashes, err := f.EventHashes()
+	if err != nil {
+		return nil, err
+	}
+	return MerkleRoot(hashes), nil
+}
+
+// MatchesEventsRoot tells if the events of the frame are the ones the block
+// commits to. Blocks created before blocks committed to their events have no
+// events root, and match any frame.
+func (f *Frame) MatchesEventsRoot(block Block) (bool, error) {
+	if len(block.Body.GetEventsRoot()) == 0 {
+		return true, nil
+	}
+	eventsRoot, err := f.EventsRoot()
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(block.Body.GetEventsRoot(), eventsRoot), nil
+}
+
 // RootListEquals compares the equality of two root lists
 func RootListEquals(this []*Root, that []*Root) bool {
 	if len(this) != len(that) {
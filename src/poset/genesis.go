@@ -0,0 +1,120 @@
+package poset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+const genesisPath = "genesis.json"
+
+// GenesisValidator is a validator of the initial participant set
+type GenesisValidator struct {
+	PubKeyHex string `json:"pub_key_hex"`
+	NetAddr   string `json:"net_addr"`
+	Weight    uint64 `json:"weight"`
+}
+
+// Genesis describes the initial state of a network. Its hash is referenced
+// by the first block, so that networks sharing the same peers cannot be
+// mistaken for one another.
+type Genesis struct {
+	ChainID      string             `json:"chain_id"`
+	Validators   []GenesisValidator `json:"validators"`
+	AppStateHash string             `json:"app_state_hash"`
+}
+
+// NewGenesisFromPeers creates a genesis with equal weights for the given
+// participants, used when no genesis document is provided
+func NewGenesisFromPeers(chainID string, participants *peers.Peers) *Genesis {
+	g := &Genesis{
+		ChainID: chainID,
+	}
+	for _, p := range participants.ToPeerSlice() {
+		g.Validators = append(g.Validators, GenesisValidator{
+			PubKeyHex: p.PubKeyHex,
+			NetAddr:   p.NetAddr,
+			Weight:    1,
+		})
+	}
+	return g
+}
+
+// LoadGenesis reads genesis.json from the data directory. It returns
+// nil and no error if the file does not exist.
+func LoadGenesis(datadir string) (*Genesis, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(datadir, genesisPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Genesis{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(g); err != nil {
+		return nil, fmt.Errorf("%s: %v", genesisPath, err)
+	}
+	return g, nil
+}
+
+// Validate checks the genesis has a chain ID and its validators are exactly
+// the given participants
+func (g *Genesis) Validate(participants *peers.Peers) error {
+	if g.ChainID == "" {
+		return fmt.Errorf("genesis has no chain ID")
+	}
+	if len(g.Validators) != participants.Len() {
+		return fmt.Errorf("genesis defines %d validators, peers.json %d",
+			len(g.Validators), participants.Len())
+	}
+	seen := make(map[string]bool, len(g.Validators))
+	for _, v := range g.Validators {
+		if seen[v.PubKeyHex] {
+			return fmt.Errorf("genesis validator %s is defined twice", v.PubKeyHex)
+		}
+		seen[v.PubKeyHex] = true
+		if v.Weight == 0 {
+			return fmt.Errorf("genesis validator %s has no weight", v.PubKeyHex)
+		}
+		if _, ok := participants.ReadByPubKey(v.PubKeyHex); !ok {
+			return fmt.Errorf("genesis validator %s is not in peers.json", v.PubKeyHex)
+		}
+	}
+	return nil
+}
+
+// Weights returns the weight of every validator by public key
+func (g *Genesis) Weights() map[string]uint64 {
+	weights := make(map[string]uint64, len(g.Validators))
+	for _, v := range g.Validators {
+		weights[v.PubKeyHex] = v.Weight
+	}
+	return weights
+}
+
+// Hash returns the Keccak256 of the genesis JSON with validators sorted by
+// public key, so that it does not depend on the order they are listed in
+func (g *Genesis) Hash() ([]byte, error) {
+	sorted := *g
+	sorted.Validators = make([]GenesisValidator, len(g.Validators))
+	copy(sorted.Validators, g.Validators)
+	sort.Slice(sorted.Validators, func(i, j int) bool {
+		return sorted.Validators[i].PubKeyHex < sorted.Validators[j].PubKeyHex
+	})
+
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(data), nil
+}
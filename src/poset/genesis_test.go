@@ -0,0 +1,179 @@
+package poset
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+func TestGenesis(t *testing.T) {
+	participants := peers.NewPeers()
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		participants.AddPeer(peers.NewPeer(fmt.Sprintf("0x%X",
+			crypto.FromECDSAPub(&key.PublicKey)), fmt.Sprintf("127.0.0.1:%d", 1337+i)))
+	}
+
+	dir, err := ioutil.TempDir("", "lachesis_genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	genesis, err := LoadGenesis(dir)
+	if err != nil || genesis != nil {
+		t.Fatalf("expected no genesis without genesis.json, got %v, %v", genesis, err)
+	}
+
+	genesis = NewGenesisFromPeers("test", participants)
+	if err := genesis.Validate(participants); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewGenesisFromPeers("", participants).Validate(participants); err == nil {
+		t.Fatal("genesis without chain ID should not validate")
+	}
+	hash, err := genesis.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hash does not depend on the order of the validators
+	reversed := *genesis
+	reversed.Validators = nil
+	for i := len(genesis.Validators) - 1; i >= 0; i-- {
+		reversed.Validators = append(reversed.Validators, genesis.Validators[i])
+	}
+	reversedHash, _ := reversed.Hash()
+	if !bytes.Equal(hash, reversedHash) {
+		t.Fatal("genesis hash should not depend on the validators order")
+	}
+
+	// but it does on the chain ID
+	other := *genesis
+	other.ChainID = "other"
+	otherHash, _ := other.Hash()
+	if bytes.Equal(hash, otherHash) {
+		t.Fatal("genesis of different chains should have different hashes")
+	}
+
+	doc := fmt.Sprintf(`{"chain_id": "test", "app_state_hash": "0x00", "validators": [
+		{"pub_key_hex": "%s", "weight": 2}]}`, genesis.Validators[0].PubKeyHex)
+	if err := ioutil.WriteFile(filepath.Join(dir, genesisPath), []byte(doc), 0640); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadGenesis(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ChainID != "test" || loaded.Weights()[genesis.Validators[0].PubKeyHex] != 2 {
+		t.Fatalf("unexpected genesis %+v", loaded)
+	}
+	if err := loaded.Validate(participants); err == nil {
+		t.Fatal("genesis missing validators should not validate")
+	}
+}
+
+func TestCheckBlockGenesis(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateECDSAKey()
+		participants.AddPeer(peers.NewPeer(fmt.Sprintf("0x%X",
+			crypto.FromECDSAPub(&keys[i].PublicKey)), ""))
+	}
+	p := NewPoset(participants, NewInmemStore(participants, cacheSize, nil), nil, testLogger(t))
+	p.SetGenesisHash([]byte("genesis"))
+
+	signedBlock := func(genesisHash []byte) Block {
+		block := NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("abc")})
+		block.Body.GenesisHash = genesisHash
+		for _, key := range keys {
+			sig, err := block.Sign(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := block.SetSignature(sig); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return block
+	}
+
+	for _, genesisHash := range [][]byte{nil, []byte("other")} {
+		if err := p.CheckBlock(signedBlock(genesisHash)); err == nil {
+			t.Fatalf("block 0 referencing genesis %q should not be accepted", genesisHash)
+		}
+	}
+	if err := p.CheckBlock(signedBlock([]byte("genesis"))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBlockQuorum(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateECDSAKey()
+		participants.AddPeer(peers.NewPeer(fmt.Sprintf("0x%X",
+			crypto.FromECDSAPub(&keys[i].PublicKey)), ""))
+	}
+	p := NewPoset(participants, NewInmemStore(participants, cacheSize, nil), nil, testLogger(t))
+
+	signedBlock := func(signers ...*ecdsa.PrivateKey) Block {
+		block := NewBlock(1, 1, []byte("framehash"), [][]byte{[]byte("abc")})
+		for _, key := range signers {
+			sig, err := block.Sign(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := block.SetSignature(sig); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return block
+	}
+
+	for _, c := range []struct {
+		quorum float64
+		count  uint64
+	}{
+		{0, 3}, // more than ceil(4/3)
+		{1.0 / 3, 2},
+		{2.0 / 3, 3},
+		{1, 4},
+	} {
+		p.SetBlockQuorum(c.quorum)
+		if count := p.BlockQuorumWeight(); count != c.count {
+			t.Fatalf("quorum %v: expected %d signatures, got %d", c.quorum, c.count, count)
+		}
+		if err := p.CheckBlock(signedBlock(keys[:c.count-1]...)); err == nil {
+			t.Fatalf("quorum %v: block with %d signatures should not be accepted", c.quorum, c.count-1)
+		}
+		if err := p.CheckBlock(signedBlock(keys[:c.count]...)); err != nil {
+			t.Fatalf("quorum %v: %v", c.quorum, err)
+		}
+	}
+
+	// the signatures count for the weights of the validators: the first one
+	// weighs 4 out of 7
+	p.SetBlockQuorum(0)
+	p.SetBlockWeights(map[string]uint64{
+		fmt.Sprintf("0x%X", crypto.FromECDSAPub(&keys[0].PublicKey)): 4,
+	})
+	if weight := p.BlockQuorumWeight(); weight != 4 {
+		t.Fatalf("expected a quorum of weight 4, got %d", weight)
+	}
+	if err := p.CheckBlock(signedBlock(keys[0])); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CheckBlock(signedBlock(keys[1:]...)); err == nil {
+		t.Fatal("block signed by validators of weight 3 should not be accepted")
+	}
+}
@@ -0,0 +1,176 @@
+package poset
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/pos"
+)
+
+// SnapshotConfig configures the periodic snapshots of an InmemStore
+type SnapshotConfig struct {
+	// Path of the snapshot file
+	Path string
+	// Interval between snapshots, 0 disables the timer
+	Interval time.Duration
+	// Blocks is the number of new blocks which trigger a snapshot, 0
+	// disables the trigger
+	Blocks int64
+}
+
+type snapshotter struct {
+	store  *InmemStore
+	conf   SnapshotConfig
+	logger *logrus.Entry
+
+	lastBlock int64 // atomic
+	trigger   chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// StartSnapshots writes the store to conf.Path every Interval and every
+// Blocks new blocks, and once more when the store is closed. The snapshot is
+// an archive, see ExportStore, which LoadInmemSnapshot reads back on startup.
+func (s *InmemStore) StartSnapshots(conf SnapshotConfig, logger *logrus.Entry) {
+	if (conf.Interval <= 0 && conf.Blocks <= 0) || s.snapshotter != nil {
+		return
+	}
+	s.snapshotter = &snapshotter{
+		store:     s,
+		conf:      conf,
+		logger:    logger,
+		lastBlock: s.LastBlockIndex(),
+		trigger:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	s.snapshotter.wg.Add(1)
+	go s.snapshotter.run()
+}
+
+// blockAdded triggers a snapshot once enough blocks were added since the
+// last one
+func (sn *snapshotter) blockAdded(index int64) {
+	if sn.conf.Blocks <= 0 || index-atomic.LoadInt64(&sn.lastBlock) < sn.conf.Blocks {
+		return
+	}
+	select {
+	case sn.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (sn *snapshotter) stop() {
+	close(sn.done)
+	sn.wg.Wait()
+	sn.save()
+}
+
+func (sn *snapshotter) run() {
+	defer sn.wg.Done()
+	var tick <-chan time.Time
+	if sn.conf.Interval > 0 {
+		ticker := time.NewTicker(sn.conf.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+			sn.save()
+		case <-sn.trigger:
+			sn.save()
+		case <-sn.done:
+			return
+		}
+	}
+}
+
+func (sn *snapshotter) save() {
+	last := sn.store.LastBlockIndex()
+	if err := SaveInmemSnapshot(sn.store, sn.conf.Path); err != nil {
+		sn.logger.WithError(err).Error("Writing snapshot")
+		return
+	}
+	atomic.StoreInt64(&sn.lastBlock, last)
+	sn.logger.WithField("last_block", last).Debug("Wrote snapshot")
+}
+
+// SaveInmemSnapshot writes the store to path. The file is replaced
+// atomically, so a crash leaves the previous snapshot intact.
+func SaveInmemSnapshot(s *InmemStore, path string) error {
+	if _, err := s.cachedEvents(); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := ExportStore(s, f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadInmemSnapshot creates an InmemStore from the snapshot at path. Only
+// the roots and the events are restored: the store needs to be bootstrapped,
+// which runs the consensus on the events again and so rebuilds the rounds
+// and blocks. It fails with an error satisfying os.IsNotExist if there is
+// no snapshot.
+func LoadInmemSnapshot(participants *peers.Peers, cacheSize int, path string, posConf *pos.Config) (*InmemStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	snapshot, err := ImportStore(f, func(snapshotPeers *peers.Peers) (Store, error) {
+		return NewInmemStore(snapshotPeers, cacheSize, posConf), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %v", path, err)
+	}
+	restored := snapshot.(*InmemStore)
+	events, err := restored.TopologicalEvents()
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %v", path, err)
+	}
+
+	store := NewInmemStore(participants, cacheSize, posConf)
+	if err := store.Reset(restored.RootsByParticipant()); err != nil {
+		return nil, err
+	}
+	store.bootstrapEvents = events
+	return store, nil
+}
+
+// cachedEvents returns the events of the cache in topological order. It
+// fails if events were evicted, in which case the remaining ones cannot be
+// replayed.
+func (s *InmemStore) cachedEvents() ([]Event, error) {
+	events := s.sortedEvents()
+	for i, event := range events {
+		if event.Message.TopologicalIndex != int64(i) {
+			return nil, fmt.Errorf("events were evicted from the cache, cache-size %d is too small", s.cacheSize)
+		}
+	}
+	return events, nil
+}
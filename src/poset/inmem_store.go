@@ -3,8 +3,10 @@ package poset
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/golang-lru"
 
@@ -24,6 +26,9 @@ type InmemStore struct {
 	roundReceivedCache     *lru.Cache           // round received number => RoundReceived
 	blockCache             *lru.Cache           // index => Block
 	frameCache             *lru.Cache           // round received => Frame
+	receiptCache           *lru.Cache           // block index => []Receipt
+	txCache                *lru.Cache           // tx hash => TxLocation
+	blockEventsCache       *lru.Cache           // block index => EventHashes
 	consensusCache         *common.RollingIndex // consensus index => hash
 	totConsensusEvents     int64
 	participantEventsCache *ParticipantEventsCache // pubkey => Events
@@ -33,12 +38,22 @@ type InmemStore struct {
 	lastConsensusEvents    map[string]EventHash // [participant] => hex() of last consensus event
 	lastBlock              int64
 
+	cacheSizeLocker          sync.RWMutex
 	lastRoundLocker          sync.RWMutex
 	lastBlockLocker          sync.RWMutex
 	totConsensusEventsLocker sync.RWMutex
 
 	states    state.Database
 	stateRoot common.Hash
+
+	// bootstrapEvents are the events of a loaded snapshot, replayed by
+	// the first TopologicalEvents call
+	bootstrapEvents []Event
+	snapshotter     *snapshotter
+
+	// application state snapshots, see StateSnapshotStore
+	stateSnapshots       stateSnapshots
+	stateSnapshotsLocker sync.RWMutex
 }
 
 // NewInmemStore constructor
@@ -77,6 +92,21 @@ func NewInmemStore(participants *peers.Peers, cacheSize int, posConf *pos.Config
 		fmt.Println("Unable to init InmemStore.frameCache:", err)
 		os.Exit(34)
 	}
+	receiptCache, err := lru.New(cacheSize)
+	if err != nil {
+		fmt.Println("Unable to init InmemStore.receiptCache:", err)
+		os.Exit(37)
+	}
+	txCache, err := lru.New(cacheSize * txIndexCacheFactor)
+	if err != nil {
+		fmt.Println("Unable to init InmemStore.txCache:", err)
+		os.Exit(38)
+	}
+	blockEventsCache, err := lru.New(cacheSize)
+	if err != nil {
+		fmt.Println("Unable to init InmemStore.blockEventsCache:", err)
+		os.Exit(39)
+	}
 
 	store := &InmemStore{
 		cacheSize:              cacheSize,
@@ -86,6 +116,9 @@ func NewInmemStore(participants *peers.Peers, cacheSize int, posConf *pos.Config
 		roundReceivedCache:     roundReceivedCache,
 		blockCache:             blockCache,
 		frameCache:             frameCache,
+		receiptCache:           receiptCache,
+		txCache:                txCache,
+		blockEventsCache:       blockEventsCache,
 		consensusCache:         common.NewRollingIndex("ConsensusCache", cacheSize),
 		participantEventsCache: NewParticipantEventsCache(cacheSize, participants),
 		rootsByParticipant:     rootsByParticipant,
@@ -123,12 +156,19 @@ func NewInmemStore(participants *peers.Peers, cacheSize int, posConf *pos.Config
 
 // TopologicalEvents returns event in topological order.
 func (s *InmemStore) TopologicalEvents() ([]Event, error) {
-	// NOTE: it's used for bootstrap only, so is not implemented
-	return nil, nil
+	defer observeOp("inmem", "TopologicalEvents", time.Now())
+	if s.bootstrapEvents != nil {
+		events := s.bootstrapEvents
+		s.bootstrapEvents = nil
+		return events, nil
+	}
+	return s.cachedEvents()
 }
 
 // CacheSize size of cache
 func (s *InmemStore) CacheSize() int {
+	s.cacheSizeLocker.RLock()
+	defer s.cacheSizeLocker.RUnlock()
 	return s.cacheSize
 }
 
@@ -157,7 +197,9 @@ func (s *InmemStore) RootsByParticipant() map[string]Root {
 
 // GetEventBlock gets specific event block by hash
 func (s *InmemStore) GetEventBlock(hash EventHash) (Event, error) {
+	defer observeOp("inmem", "GetEventBlock", time.Now())
 	res, ok := s.eventCache.Get(hash)
+	countLookup("events", ok)
 	if !ok {
 		return Event{}, common.NewStoreErr("EventCache", common.KeyNotFound, hash.String())
 	}
@@ -167,6 +209,7 @@ func (s *InmemStore) GetEventBlock(hash EventHash) (Event, error) {
 
 // SetEvent set event for event block
 func (s *InmemStore) SetEvent(event Event) error {
+	defer observeOp("inmem", "SetEvent", time.Now())
 	eventHash := event.Hash()
 	_, err := s.GetEventBlock(eventHash)
 	if err != nil && !common.Is(err, common.KeyNotFound) {
@@ -190,6 +233,7 @@ func (s *InmemStore) addParticipantEvent(participant string, hash EventHash, ind
 
 // ParticipantEvents events for the participant
 func (s *InmemStore) ParticipantEvents(participant string, skip int64) (EventHashes, error) {
+	defer observeOp("inmem", "ParticipantEvents", time.Now())
 	return s.participantEventsCache.Get(participant, skip)
 }
 
@@ -282,7 +326,9 @@ func (s *InmemStore) AddConsensusEvent(event Event) error {
 
 // GetRoundCreated retrieves created round by ID
 func (s *InmemStore) GetRoundCreated(r int64) (RoundCreated, error) {
+	defer observeOp("inmem", "GetRoundCreated", time.Now())
 	res, ok := s.roundCreatedCache.Get(r)
+	countLookup("rounds_created", ok)
 	if !ok {
 		return *NewRoundCreated(), common.NewStoreErr("RoundCreatedCache", common.KeyNotFound, strconv.FormatInt(r, 10))
 	}
@@ -291,6 +337,7 @@ func (s *InmemStore) GetRoundCreated(r int64) (RoundCreated, error) {
 
 // SetRoundCreated stores created round by ID
 func (s *InmemStore) SetRoundCreated(r int64, round RoundCreated) error {
+	defer observeOp("inmem", "SetRoundCreated", time.Now())
 	s.lastRoundLocker.Lock()
 	defer s.lastRoundLocker.Unlock()
 	s.roundCreatedCache.Add(r, round)
@@ -302,7 +349,9 @@ func (s *InmemStore) SetRoundCreated(r int64, round RoundCreated) error {
 
 // GetRoundReceived gets received round by ID
 func (s *InmemStore) GetRoundReceived(r int64) (RoundReceived, error) {
+	defer observeOp("inmem", "GetRoundReceived", time.Now())
 	res, ok := s.roundReceivedCache.Get(r)
+	countLookup("rounds_received", ok)
 	if !ok {
 		return *NewRoundReceived(), common.NewStoreErr("RoundReceivedCache", common.KeyNotFound, strconv.FormatInt(r, 10))
 	}
@@ -311,6 +360,7 @@ func (s *InmemStore) GetRoundReceived(r int64) (RoundReceived, error) {
 
 // SetRoundReceived stores received round by ID
 func (s *InmemStore) SetRoundReceived(r int64, round RoundReceived) error {
+	defer observeOp("inmem", "SetRoundReceived", time.Now())
 	s.lastRoundLocker.Lock()
 	defer s.lastRoundLocker.Unlock()
 	s.roundReceivedCache.Add(r, round)
@@ -356,7 +406,9 @@ func (s *InmemStore) GetRoot(participant string) (Root, error) {
 
 // GetBlock for index
 func (s *InmemStore) GetBlock(index int64) (Block, error) {
+	defer observeOp("inmem", "GetBlock", time.Now())
 	res, ok := s.blockCache.Get(index)
+	countLookup("blocks", ok)
 	if !ok {
 		return Block{}, common.NewStoreErr("BlockCache", common.KeyNotFound, strconv.FormatInt(index, 10))
 	}
@@ -365,6 +417,7 @@ func (s *InmemStore) GetBlock(index int64) (Block, error) {
 
 // SetBlock TODO
 func (s *InmemStore) SetBlock(block Block) error {
+	defer observeOp("inmem", "SetBlock", time.Now())
 	s.lastBlockLocker.Lock()
 	defer s.lastBlockLocker.Unlock()
 	index := block.Index()
@@ -376,6 +429,9 @@ func (s *InmemStore) SetBlock(block Block) error {
 	if index > s.lastBlock {
 		s.lastBlock = index
 	}
+	if s.snapshotter != nil {
+		s.snapshotter.blockAdded(index)
+	}
 	return nil
 }
 
@@ -388,7 +444,9 @@ func (s *InmemStore) LastBlockIndex() int64 {
 
 // GetFrame by index
 func (s *InmemStore) GetFrame(index int64) (Frame, error) {
+	defer observeOp("inmem", "GetFrame", time.Now())
 	res, ok := s.frameCache.Get(index)
+	countLookup("frames", ok)
 	if !ok {
 		return Frame{}, common.NewStoreErr("FrameCache", common.KeyNotFound, strconv.FormatInt(index, 10))
 	}
@@ -397,6 +455,7 @@ func (s *InmemStore) GetFrame(index int64) (Frame, error) {
 
 // SetFrame in the store
 func (s *InmemStore) SetFrame(frame Frame) error {
+	defer observeOp("inmem", "SetFrame", time.Now())
 	index := frame.Round
 	_, err := s.GetFrame(index)
 	if err != nil && !common.Is(err, common.KeyNotFound) {
@@ -408,17 +467,18 @@ func (s *InmemStore) SetFrame(frame Frame) error {
 
 // Reset resets the store
 func (s *InmemStore) Reset(roots map[string]Root) error {
-	eventCache, errr := lru.New(s.cacheSize)
+	cacheSize := s.CacheSize()
+	eventCache, errr := lru.New(cacheSize)
 	if errr != nil {
 		fmt.Println("Unable to reset InmemStore.eventCache:", errr)
 		os.Exit(41)
 	}
-	roundCache, errr := lru.New(s.cacheSize)
+	roundCache, errr := lru.New(cacheSize)
 	if errr != nil {
 		fmt.Println("Unable to reset InmemStore.roundCreatedCache:", errr)
 		os.Exit(42)
 	}
-	roundReceivedCache, errr := lru.New(s.cacheSize)
+	roundReceivedCache, errr := lru.New(cacheSize)
 	if errr != nil {
 		fmt.Println("Unable to reset InmemStore.roundReceivedCache:", errr)
 		os.Exit(45)
@@ -431,7 +491,7 @@ func (s *InmemStore) Reset(roots map[string]Root) error {
 	s.eventCache = eventCache
 	s.roundCreatedCache = roundCache
 	s.roundReceivedCache = roundReceivedCache
-	s.consensusCache = common.NewRollingIndex("ConsensusCache", s.cacheSize)
+	s.consensusCache = common.NewRollingIndex("ConsensusCache", cacheSize)
 	err := s.participantEventsCache.Reset()
 	s.lastRoundLocker.Lock()
 	s.lastRound = -1
@@ -445,12 +505,16 @@ func (s *InmemStore) Reset(roots map[string]Root) error {
 
 // Close the store
 func (s *InmemStore) Close() error {
+	if s.snapshotter != nil {
+		s.snapshotter.stop()
+		s.snapshotter = nil
+	}
 	return nil
 }
 
-// NeedBootstrap for the store
+// NeedBootstrap for the store, true if it was loaded from a snapshot
 func (s *InmemStore) NeedBootstrap() bool {
-	return false
+	return s.bootstrapEvents != nil
 }
 
 // StorePath getter
@@ -467,3 +531,59 @@ func (s *InmemStore) StateDB() state.Database {
 func (s *InmemStore) StateRoot() common.Hash {
 	return s.stateRoot
 }
+
+// EventsRange calls fn for the cached events in the range, see Store
+func (s *InmemStore) EventsRange(from, to int64, fn func(Event) error) error {
+	defer observeOp("inmem", "EventsRange", time.Now())
+	for _, event := range s.sortedEvents() {
+		if !inRange(event.Message.TopologicalIndex, from, to) {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return stopRange(err)
+		}
+	}
+	return nil
+}
+
+// BlocksRange calls fn for the cached blocks in the range, see Store
+func (s *InmemStore) BlocksRange(from, to int64, fn func(Block) error) error {
+	defer observeOp("inmem", "BlocksRange", time.Now())
+	for i := from; i <= s.LastBlockIndex() && inRange(i, from, to); i++ {
+		block, ok := s.blockCache.Peek(i)
+		if !ok {
+			continue
+		}
+		if err := fn(block.(Block)); err != nil {
+			return stopRange(err)
+		}
+	}
+	return nil
+}
+
+// RoundsRange calls fn for the cached created rounds in the range, see Store
+func (s *InmemStore) RoundsRange(from, to int64, fn func(int64, RoundCreated) error) error {
+	defer observeOp("inmem", "RoundsRange", time.Now())
+	for i := from; i <= s.LastRound() && inRange(i, from, to); i++ {
+		round, ok := s.roundCreatedCache.Peek(i)
+		if !ok {
+			continue
+		}
+		if err := fn(i, round.(RoundCreated)); err != nil {
+			return stopRange(err)
+		}
+	}
+	return nil
+}
+
+// sortedEvents returns the cached events in topological order
+func (s *InmemStore) sortedEvents() []Event {
+	var events []Event
+	for _, key := range s.eventCache.Keys() {
+		if event, ok := s.eventCache.Peek(key); ok {
+			events = append(events, event.(Event))
+		}
+	}
+	sort.Sort(ByTopologicalOrder(events))
+	return events
+}
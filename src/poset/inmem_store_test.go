@@ -3,11 +3,15 @@ package poset
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/Fantom-foundation/go-lachesis/src/crypto"
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 type pub struct {
@@ -229,3 +233,84 @@ func TestInmemBlocks(t *testing.T) {
 		}
 	})
 }
+
+func TestInmemStoreMetrics(t *testing.T) {
+	store, _ := initInmemStore(10)
+	misses := storeCacheLookups.WithLabelValues("blocks", "miss")
+	before := testutil.ToFloat64(misses)
+
+	if _, err := store.GetBlock(5); err == nil {
+		t.Fatal("GetBlock should fail on an empty store")
+	}
+
+	if got := testutil.ToFloat64(misses) - before; got != 1 {
+		t.Fatalf("blocks cache misses should increase by 1, not %v", got)
+	}
+	if testutil.CollectAndCount(storeOpDuration) == 0 {
+		t.Fatal("GetBlock duration should be observed")
+	}
+}
+
+func TestInmemSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lachesis-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "inmem.snapshot")
+
+	fill := func(store *InmemStore, participants []pub) []Event {
+		var events []Event
+		for k := int64(0); k < 5; k++ {
+			for _, p := range participants {
+				event := NewEvent([][]byte{[]byte(fmt.Sprintf("%s_%d", p.hex[:5], k))},
+					nil, nil, make(EventHashes, 2), p.pubKey, k, nil)
+				event.Message.TopologicalIndex = int64(len(events))
+				if err := store.SetEvent(event); err != nil {
+					t.Fatal(err)
+				}
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	store, participants := initInmemStore(100)
+	events := fill(store, participants)
+	if err := SaveInmemSnapshot(store, path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := LoadInmemSnapshot(store.participants, 100, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.NeedBootstrap() {
+		t.Fatal("a store loaded from a snapshot should need a bootstrap")
+	}
+	replayed, err := restored.TopologicalEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != len(events) {
+		t.Fatalf("snapshot should replay %d events, not %d", len(events), len(replayed))
+	}
+	for i, event := range replayed {
+		if event.Hash() != events[i].Hash() {
+			t.Fatalf("replayed event %d should be %v, not %v", i, events[i].Hash(), event.Hash())
+		}
+	}
+	if restored.NeedBootstrap() {
+		t.Fatal("the events of a snapshot should be replayed once")
+	}
+
+	small, participants := initInmemStore(10)
+	fill(small, participants)
+	if err := SaveInmemSnapshot(small, path); err == nil {
+		t.Fatal("snapshot of a store which evicted events should fail")
+	}
+
+	if _, err := LoadInmemSnapshot(store.participants, 100, filepath.Join(dir, "none"), nil); !os.IsNotExist(err) {
+		t.Fatalf("loading a missing snapshot should fail with a not exist error, not %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package poset
+
+import (
+	"bytes"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+)
+
+// Leaves and inner nodes are hashed with different prefixes so that an inner
+// node can never be passed off as a leaf
+var (
+	merkleLeafPrefix = []byte{0}
+	merkleNodePrefix = []byte{1}
+)
+
+// MerkleRoot returns the Keccak256 Merkle root of the given leaves. A node
+// without sibling is promoted to the next level as is. The root of no leaves
+// is the hash of nothing.
+func MerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return crypto.Keccak256()
+	}
+	level := merkleLeaves(leaves)
+	for len(level) > 1 {
+		level = merkleParents(level)
+	}
+	return level[0]
+}
+
+func merkleLeaves(leaves [][]byte) [][]byte {
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = crypto.Keccak256(merkleLeafPrefix, leaf)
+	}
+	return level
+}
+
+func merkleParents(level [][]byte) [][]byte {
+	parents := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			parents = append(parents, level[i])
+			break
+		}
+		parents = append(parents, crypto.Keccak256(merkleNodePrefix, level[i], level[i+1]))
+	}
+	return parents
+}
+
+// MerkleProof returns the sibling hashes from the leaf at the given index up
+// to the root, with for each one whether it is on the left
+func MerkleProof(leaves [][]byte, index int) (siblings [][]byte, left []bool) {
+	level := merkleLeaves(leaves)
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			siblings = append(siblings, level[sibling])
+			left = append(left, sibling < index)
+		}
+		level = merkleParents(level)
+		index /= 2
+	}
+	return siblings, left
+}
+
+// VerifyMerkleProof checks that the leaf is part of the tree with the given
+// root, see MerkleProof
+func VerifyMerkleProof(root, leaf []byte, siblings [][]byte, left []bool) bool {
+	if len(siblings) != len(left) {
+		return false
+	}
+	hash := crypto.Keccak256(merkleLeafPrefix, leaf)
+	for i, sibling := range siblings {
+		if left[i] {
+			hash = crypto.Keccak256(merkleNodePrefix, sibling, hash)
+		} else {
+			hash = crypto.Keccak256(merkleNodePrefix, hash, sibling)
+		}
+	}
+	return bytes.Equal(hash, root)
+}
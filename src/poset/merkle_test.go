@@ -0,0 +1,66 @@
+package poset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		var leaves [][]byte
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, []byte(fmt.Sprintf("leaf%d", i)))
+		}
+		root := MerkleRoot(leaves)
+		for i, leaf := range leaves {
+			siblings, left := MerkleProof(leaves, i)
+			if !VerifyMerkleProof(root, leaf, siblings, left) {
+				t.Fatalf("%d leaves: proof of leaf %d does not verify", n, i)
+			}
+			if VerifyMerkleProof(root, []byte("other"), siblings, left) {
+				t.Fatalf("%d leaves: proof of leaf %d verifies another leaf", n, i)
+			}
+		}
+	}
+
+	// swapping two events changes the root
+	a := MerkleRoot([][]byte{[]byte("a"), []byte("b")})
+	b := MerkleRoot([][]byte{[]byte("b"), []byte("a")})
+	if bytes.Equal(a, b) {
+		t.Fatal("root should depend on the order of the leaves")
+	}
+}
+
+func TestBlockEventsRoot(t *testing.T) {
+	event := NewEvent([][]byte{[]byte("abc")}, nil, nil,
+		EventHashes{GenRootSelfParent(1), EventHash{}}, []byte("creator"), 0, nil)
+	frame := Frame{Round: 1, Events: []*EventMessage{event.Message}}
+
+	block, err := NewBlockFromFrame(0, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := frame.EventsRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(block.Body.EventsRoot, root) {
+		t.Fatalf("block events root 0x%X, expected 0x%X", block.Body.EventsRoot, root)
+	}
+
+	frame.Events = append(frame.Events, event.Message)
+	other, _ := frame.EventsRoot()
+	if bytes.Equal(other, root) {
+		t.Fatal("events root should change with the frame's events")
+	}
+	if match, err := frame.MatchesEventsRoot(block); err != nil || match {
+		t.Fatalf("block should not match another frame, got %v, %v", match, err)
+	}
+
+	// a block from before the events root matches any frame
+	block.Body.EventsRoot = nil
+	if match, err := frame.MatchesEventsRoot(block); err != nil || !match {
+		t.Fatalf("block without events root should match, got %v, %v", match, err)
+	}
+}
@@ -1,9 +1,9 @@
 package poset
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -14,6 +14,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
 	"github.com/Fantom-foundation/go-lachesis/src/log"
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
 	"github.com/Fantom-foundation/go-lachesis/src/state"
@@ -44,6 +45,10 @@ type Poset struct {
 	topologicalIndex         int64             // counter used to order events in topological order (only local)
 	superMajority            int
 	trustCount               int
+	genesisHash              []byte            // referenced by the first block
+	blockQuorum              float64           // share of the weight of the participants whose signatures make a Block valid
+	blockWeights             map[string]uint64 // see SetBlockWeights
+	dedupBlockTxs            bool              // see SetDedupBlockTransactions
 	core                     Core
 
 	dominatorCache         *lru.Cache
@@ -58,6 +63,9 @@ type Poset struct {
 	pendingLoadedEventsLocker     sync.RWMutex
 	firstLastConsensusRoundLocker sync.RWMutex
 	consensusTransactionsLocker   sync.RWMutex
+	sigPoolLocker                 sync.Mutex
+	pendingRoundsLocker           sync.RWMutex
+	anchorBlockLocker             sync.RWMutex
 }
 
 // NewPoset instantiates a Poset from a list of participants, underlying
@@ -823,24 +831,36 @@ func (p *Poset) setWireInfo(event *Event) error {
 		return err
 	}
 
-	otherParent, err := p.Store.GetEventBlock(event.OtherParent())
-	if err != nil {
-		return err
-	}
-	otherParentCreator, ok := p.Participants.ReadByPubKey(otherParent.GetCreator())
-	if !ok {
-		return fmt.Errorf("creator %s not found", otherParent.GetCreator())
+	// an event without other parent goes on the wire with no other parent
+	// creator and index -1, which readWireInfo reads back as the zero hash
+	var (
+		otherParentCreatorID uint64
+		otherParentIndex     int64 = -1
+	)
+	if op := event.OtherParent(); !op.Zero() {
+		otherParent, err := p.Store.GetEventBlock(op)
+		if err != nil {
+			return err
+		}
+		otherParentCreator, ok := p.Participants.ReadByPubKey(otherParent.GetCreator())
+		if !ok {
+			return fmt.Errorf("creator %s not found", otherParent.GetCreator())
+		}
+		otherParentCreatorID = otherParentCreator.ID
+		otherParentIndex = otherParent.Index()
 	}
 
 	event.SetWireInfo(selfParent.Index(),
-		otherParentCreator.ID,
-		otherParent.Index(),
+		otherParentCreatorID,
+		otherParentIndex,
 		creator.ID)
 
 	return nil
 }
 
 func (p *Poset) updatePendingRounds(decidedRounds map[int64]int64) {
+	p.pendingRoundsLocker.Lock()
+	defer p.pendingRoundsLocker.Unlock()
 	for _, ur := range p.PendingRounds {
 		if _, ok := decidedRounds[ur.Index]; ok {
 			ur.Decided = true
@@ -866,8 +886,8 @@ Public Methods
 // InsertEvent attempts to insert an Event in the DAG. It verifies the signature,
 // checks the dominators are known, and prevents the introduction of forks.
 func (p *Poset) InsertEvent(event Event, setWireInfo bool) error {
-	// verify signature
-	if ok, err := event.Verify(); !ok {
+	// verify signature, unless VerifyEvents did
+	if ok, err := event.verify(); !ok {
 		if err != nil {
 			return err
 		}
@@ -884,6 +904,9 @@ func (p *Poset) InsertEvent(event Event, setWireInfo bool) error {
 	}
 
 	if err := p.checkSelfParent(event); err != nil {
+		if fork := p.checkFork(event); fork != nil {
+			return fork
+		}
 		return fmt.Errorf("CheckSelfParent: %s", err)
 	}
 
@@ -918,7 +941,9 @@ func (p *Poset) InsertEvent(event Event, setWireInfo bool) error {
 	for i, v := range event.BlockSignatures() {
 		blockSignatures[i] = *v
 	}
+	p.sigPoolLocker.Lock()
 	p.SigPool = append(p.SigPool, blockSignatures...)
+	p.sigPoolLocker.Unlock()
 
 	return nil
 }
@@ -980,7 +1005,9 @@ func (p *Poset) DivideRounds() error {
 			*/
 			if !roundCreated.Message.Queued && roundNumber >= p.GetLastConsensusRound() {
 
+				p.pendingRoundsLocker.Lock()
 				p.PendingRounds = append(p.PendingRounds, &pendingRound{roundNumber, false})
+				p.pendingRoundsLocker.Unlock()
 				roundCreated.Message.Queued = true
 			}
 
@@ -1155,6 +1182,52 @@ func (p *Poset) DecideAtropos() error {
 	return nil
 }
 
+// RoundWitnesses returns the clotho/atropos status of a created round, which
+// tells why the round is or isn't decided yet
+func (p *Poset) RoundWitnesses(round int64) (RoundStatus, error) {
+	roundInfo, err := p.Store.GetRoundCreated(round)
+	if err != nil {
+		return RoundStatus{}, err
+	}
+
+	status := RoundStatus{
+		Round:         round,
+		Decided:       roundInfo.ClothoDecided(),
+		SuperMajority: p.superMajority,
+	}
+	p.pendingRoundsLocker.RLock()
+	for _, r := range p.PendingRounds {
+		if r.Index == round {
+			status.Pending = true
+			break
+		}
+	}
+	p.pendingRoundsLocker.RUnlock()
+
+	clothos := roundInfo.Clotho()
+	sort.Sort(clothos)
+	for _, x := range clothos {
+		w := WitnessStatus{
+			Hash:    x.String(),
+			Atropos: roundInfo.Message.Events[x.String()].Atropos,
+			Decided: roundInfo.IsDecided(x),
+		}
+		if ev, err := p.Store.GetEventBlock(x); err == nil {
+			w.Creator = ev.GetCreator()
+		}
+		status.Clothos++
+		if w.Atropos == Trilean_TRUE {
+			status.Atropos++
+		}
+		if !w.Decided {
+			status.Undecided++
+		}
+		status.Witnesses = append(status.Witnesses, w)
+	}
+
+	return status, nil
+}
+
 // DecideRoundReceived assigns a RoundReceived to undetermined events when they
 // reach consensus
 func (p *Poset) DecideRoundReceived() error {
@@ -1275,12 +1348,14 @@ func (p *Poset) ProcessDecidedRounds() error {
 			return
 		}
 		lastProcessedRound := p.PendingRoundReceived[processedIndex-1]
+		p.pendingRoundsLocker.Lock()
 		for i, round := range p.PendingRounds {
 			if round.Index == lastProcessedRound {
 				p.PendingRounds = p.PendingRounds[i+1:]
 				break
 			}
 		}
+		p.pendingRoundsLocker.Unlock()
 		p.PendingRoundReceived = p.PendingRoundReceived[processedIndex:]
 	}()
 
@@ -1344,6 +1419,18 @@ func (p *Poset) ProcessDecidedRounds() error {
 			if err != nil {
 				return err
 			}
+			if p.dedupBlockTxs {
+				if err := p.dedupTransactions(&block); err != nil {
+					return err
+				}
+			}
+			block.Body.Random, err = p.RandomBeacon(r)
+			if err != nil {
+				return fmt.Errorf("random beacon of round %d: %v", r, err)
+			}
+			if block.Index() == 0 {
+				block.Body.GenesisHash = p.genesisHash
+			}
 			if len(block.Transactions()) > 0 {
 				if err := p.Store.SetBlock(block); err != nil {
 					return err
@@ -1369,6 +1456,35 @@ func (p *Poset) ProcessDecidedRounds() error {
 	return nil
 }
 
+// RandomBeacon derives the pseudo-random value shared by the block of a
+// RoundReceived. It hashes the signed bodies of the round's Atropos, which are
+// only known once the round is decided, so no single creator can pick the
+// outcome in advance.
+func (p *Poset) RandomBeacon(roundReceived int64) ([]byte, error) {
+	round, err := p.Store.GetRoundCreated(roundReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	atropos := EventHashes(round.Atropos())
+	if len(atropos) == 0 {
+		return nil, fmt.Errorf("round %d has no Atropos", roundReceived)
+	}
+	sort.Sort(atropos)
+
+	var seed []byte
+	for _, hash := range atropos {
+		ev, err := p.Store.GetEventBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		seed = append(seed, hash.Bytes()...)
+		seed = append(seed, ev.Message.Signature...)
+	}
+
+	return crypto.Keccak256(seed), nil
+}
+
 // GetFrame returns the Frame corresponding to a RoundReceived.
 func (p *Poset) GetFrame(roundReceived int64) (Frame, error) {
 	// Try to get it from the Store first
@@ -1562,6 +1678,9 @@ func (p *Poset) ApplyInternalTransactions(round int64, orderedEvents []Event) (h
 // a known Block. If a Signature is found to be valid for a known Block, it is
 // appended to the block and removed from the SignaturePool
 func (p *Poset) ProcessSigPool() error {
+	p.sigPoolLocker.Lock()
+	defer p.sigPoolLocker.Unlock()
+
 	processedSignatures := map[int64]bool{} // index in SigPool => Processed?
 	defer p.removeProcessedSignatures(processedSignatures)
 
@@ -1576,8 +1695,7 @@ func (p *Poset) ProcessSigPool() error {
 			continue
 		}
 		// only check if bs is greater than AnchorBlock, otherwise simply remove
-		if p.AnchorBlock == nil ||
-			bs.Index > *p.AnchorBlock {
+		if bs.Index > p.GetAnchorBlock() {
 			block, err := p.Store.GetBlock(bs.Index)
 			if err != nil {
 				p.logger.WithFields(logrus.Fields{
@@ -1616,14 +1734,16 @@ func (p *Poset) ProcessSigPool() error {
 				}).Warning("Saving Block")
 			}
 
-			if len(block.Signatures) > p.trustCount &&
-				(p.AnchorBlock == nil ||
-					block.Index() > *p.AnchorBlock) {
+			// the signatures of the block were all verified when added
+			weight := p.SignersWeight(block.Signatures)
+			if weight >= p.BlockQuorumWeight() &&
+				block.Index() > p.GetAnchorBlock() {
 				p.setAnchorBlock(block.Index())
 				p.logger.WithFields(logrus.Fields{
 					"block_index": block.Index(),
 					"signatures":  len(block.Signatures),
-					"trustCount":  p.trustCount,
+					"weight":      weight,
+					"quorum":      p.BlockQuorumWeight(),
 				}).Debug("Setting AnchorBlock")
 			}
 		}
@@ -1638,11 +1758,12 @@ func (p *Poset) ProcessSigPool() error {
 // This can be used as a base to Reset a Poset
 func (p *Poset) GetAnchorBlockWithFrame() (Block, Frame, error) {
 
-	if p.AnchorBlock == nil {
+	anchorBlock := p.GetAnchorBlock()
+	if anchorBlock < 0 {
 		return Block{}, Frame{}, fmt.Errorf("no Anchor Block")
 	}
 
-	block, err := p.Store.GetBlock(*p.AnchorBlock)
+	block, err := p.Store.GetBlock(anchorBlock)
 	if err != nil {
 		return Block{}, Frame{}, err
 	}
@@ -1663,12 +1784,16 @@ func (p *Poset) Reset(block Block, frame Frame) error {
 	p.LastConsensusRound = nil
 	p.FirstConsensusRound = nil
 	p.firstLastConsensusRoundLocker.Unlock()
+	p.anchorBlockLocker.Lock()
 	p.AnchorBlock = nil
+	p.anchorBlockLocker.Unlock()
 
 	p.undeterminedEventsLocker.Lock()
 	p.UndeterminedEvents = EventHashes{}
 	p.undeterminedEventsLocker.Unlock()
+	p.pendingRoundsLocker.Lock()
 	p.PendingRounds = []*pendingRound{}
+	p.pendingRoundsLocker.Unlock()
 	p.pendingLoadedEventsLocker.Lock()
 	p.pendingLoadedEvents = 0
 	p.pendingLoadedEventsLocker.Unlock()
@@ -1728,8 +1853,15 @@ func (p *Poset) Reset(block Block, frame Frame) error {
 // Bootstrap loads all Events from the Store's DB (if there is one) and feeds
 // them to the Poset (in topological order) for consensus ordering. After this
 // method call, the Poset should be in a state coherent with the 'tip' of the
-// Poset
+// Poset. The first events of a pruned store are missing, so its poset is
+// reset from the frame of its last block, and only the events after the
+// frame are inserted.
 func (p *Poset) Bootstrap() error {
+	pruned, err := p.resetFromPrunedStore()
+	if err != nil {
+		return err
+	}
+
 	// Retreive the Events from the underlying DB. They come out in topological
 	// order
 	topologicalEvents, err := p.Store.TopologicalEvents()
@@ -1739,7 +1871,22 @@ func (p *Poset) Bootstrap() error {
 
 	// Insert the Events in the Poset
 	for _, e := range topologicalEvents {
-		if err := p.InsertEvent(e, true); err != nil {
+		if pruned {
+			// the events keep their topological indexes, which the new
+			// events must not take
+			p.topologicalIndex = e.Message.TopologicalIndex
+			last, err := p.lastIndexFrom(e.GetCreator())
+			if err != nil {
+				return err
+			}
+			if e.Index() <= last {
+				p.topologicalIndex++
+				continue
+			}
+		}
+		// the self parent of the first events of a pruned store is gone,
+		// their wire info was stored with them
+		if err := p.InsertEvent(e, !pruned); err != nil {
 			return err
 		}
 	}
@@ -1764,9 +1911,102 @@ func (p *Poset) Bootstrap() error {
 	return nil
 }
 
+// resetFromPrunedStore resets the poset from the frame of the last block of
+// a store whose first rounds were pruned, and tells if it did
+func (p *Poset) resetFromPrunedStore() (bool, error) {
+	store, ok := p.Store.(PrunedStore)
+	if !ok {
+		return false, nil
+	}
+	pruned, err := store.PrunedRound()
+	if err != nil || pruned < 0 {
+		return false, err
+	}
+	block, err := store.LastStoredBlock()
+	if err != nil {
+		return false, err
+	}
+	frame, err := p.Store.GetFrame(block.RoundReceived())
+	if err != nil {
+		return false, fmt.Errorf("frame of the last block %d: %v", block.Index(), err)
+	}
+	p.logger.WithFields(logrus.Fields{
+		"pruned_round": pruned,
+		"block":        block.Index(),
+	}).Debug("Bootstrapping pruned store from the last block")
+	return true, p.Reset(block, frame)
+}
+
+// lastIndexFrom returns the index of the last event of the creator, which
+// is the self parent of its root if the poset has no event of it
+func (p *Poset) lastIndexFrom(creator string) (int64, error) {
+	last, isRoot, err := p.Store.LastEventFrom(creator)
+	if err != nil {
+		return 0, err
+	}
+	if isRoot {
+		root, err := p.Store.GetRoot(creator)
+		if err != nil {
+			return 0, err
+		}
+		return root.SelfParent.Index, nil
+	}
+	event, err := p.Store.GetEventBlock(last)
+	if err != nil {
+		return 0, err
+	}
+	return event.Index(), nil
+}
+
 // ReadWireInfo converts a WireEvent to an Event by replacing int IDs with the
 // corresponding public keys.
 func (p *Poset) ReadWireInfo(wevent WireEvent) (*Event, error) {
+	return p.readWireInfo(wevent, nil)
+}
+
+// ReadWireInfos converts the wire events in order, as ReadWireInfo, but the
+// parents of an event may be among the events before it, not inserted yet.
+// It stops at the first event it cannot convert, and returns the events
+// before it with the error.
+func (p *Poset) ReadWireInfos(wevents []WireEvent) ([]*Event, error) {
+	events := make([]*Event, 0, len(wevents))
+	pending := make(wirePending)
+	for _, we := range wevents {
+		ev, err := p.readWireInfo(we, pending)
+		if err != nil {
+			return events, err
+		}
+		pending.add(ev)
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// wirePending are the hashes of the events read from the wire but not
+// inserted yet, by creator then index
+type wirePending map[string]map[int64]EventHash
+
+func (w wirePending) add(ev *Event) {
+	creator := ev.GetCreator()
+	if w[creator] == nil {
+		w[creator] = make(map[int64]EventHash)
+	}
+	w[creator][ev.Index()] = ev.Hash()
+}
+
+// participantEvent returns the event of the creator at index from the
+// store, or else from pending
+func (p *Poset) participantEvent(creator string, index int64, pending wirePending) (EventHash, error) {
+	hash, err := p.Store.ParticipantEvent(creator, index)
+	if err != nil {
+		if h, ok := pending[creator][index]; ok {
+			return h, nil
+		}
+	}
+	return hash, err
+}
+
+func (p *Poset) readWireInfo(wevent WireEvent, pending wirePending) (*Event, error) {
 	var (
 		selfParent  EventHash = GenRootSelfParent(wevent.Body.CreatorID)
 		otherParent EventHash
@@ -1776,10 +2016,13 @@ func (p *Poset) ReadWireInfo(wevent WireEvent) (*Event, error) {
 		otherParent = GenRootSelfParent(wevent.Body.OtherParentCreatorID)
 	}
 
+	if err := p.checkWireIndexes(wevent); err != nil {
+		return nil, err
+	}
+
 	creator, ok := p.Participants.ReadByID(wevent.Body.CreatorID)
-	// FIXIT: creator can be nil when wevent.Body.CreatorID == 0
 	if !ok {
-		return nil, fmt.Errorf("unknown wevent.Body.CreatorID=%v", wevent.Body.CreatorID)
+		return nil, NewWireErr(WireUnknownCreator, wevent.Body.CreatorID, "CreatorID", int64(wevent.Body.CreatorID))
 	}
 	creatorBytes, err := hex.DecodeString(creator.PubKeyHex[2:])
 	if err != nil {
@@ -1787,48 +2030,51 @@ func (p *Poset) ReadWireInfo(wevent WireEvent) (*Event, error) {
 	}
 
 	if wevent.Body.SelfParentIndex >= 0 {
-		selfParent, err = p.Store.ParticipantEvent(creator.PubKeyHex, wevent.Body.SelfParentIndex)
+		selfParent, err = p.participantEvent(creator.PubKeyHex, wevent.Body.SelfParentIndex, pending)
 		if err != nil {
-			return nil, fmt.Errorf("p.Store.ParticipantEvent(creator.PubKeyHex %v, wevent.Body.SelfParentIndex %v): %v",
-				creator.PubKeyHex, wevent.Body.SelfParentIndex, err)
+			p.logger.WithError(err).WithFields(logrus.Fields{
+				"creator":         creator.PubKeyHex,
+				"SelfParentIndex": wevent.Body.SelfParentIndex,
+			}).Debug("p.Store.ParticipantEvent()")
+			if common.Is(err, common.KeyNotFound) {
+				return nil, NewWireErr(WireMissingSelfParent, wevent.Body.CreatorID, "SelfParentIndex", wevent.Body.SelfParentIndex)
+			}
+			return nil, NewWireErr(WireBadSelfParent, wevent.Body.CreatorID, "SelfParentIndex", wevent.Body.SelfParentIndex)
 		}
 	}
 	if wevent.Body.OtherParentIndex >= 0 {
 		otherParentCreator, ok := p.Participants.ReadByID(wevent.Body.OtherParentCreatorID)
-		if ok {
-			otherParent, err = p.Store.ParticipantEvent(otherParentCreator.PubKeyHex, wevent.Body.OtherParentIndex)
+		if !ok {
+			return nil, NewWireErr(WireUnknownOtherParent, wevent.Body.CreatorID, "OtherParentCreatorID", int64(wevent.Body.OtherParentCreatorID))
+		}
+		otherParent, err = p.participantEvent(otherParentCreator.PubKeyHex, wevent.Body.OtherParentIndex, pending)
+		if err != nil {
+			// PROBLEM Check if other parent can be found in the root
+			// problem, we do not known the WireEvent's EventHash, and
+			// we do not know the creators of the roots RootEvents
+			root, err := p.Store.GetRoot(creator.PubKeyHex)
 			if err != nil {
-				// PROBLEM Check if other parent can be found in the root
-				// problem, we do not known the WireEvent's EventHash, and
-				// we do not know the creators of the roots RootEvents
-				root, err := p.Store.GetRoot(creator.PubKeyHex)
-				if err != nil {
-					return nil, fmt.Errorf("p.Store.GetRoot(creator.PubKeyHex %v): %v", creator.PubKeyHex, err)
-				}
-				// loop through others
-				found := false
-				for _, re := range root.Others {
-					if re.CreatorID == wevent.Body.OtherParentCreatorID &&
-						re.Index == wevent.Body.OtherParentIndex {
-						otherParent.Set(re.Hash)
-						found = true
-						break
-					}
+				return nil, fmt.Errorf("p.Store.GetRoot(creator.PubKeyHex %v): %v", creator.PubKeyHex, err)
+			}
+			// loop through others
+			found := false
+			for _, re := range root.Others {
+				if re.CreatorID == wevent.Body.OtherParentCreatorID &&
+					re.Index == wevent.Body.OtherParentIndex {
+					otherParent.Set(re.Hash)
+					found = true
+					break
 				}
+			}
 
-				if !found {
-					return nil, fmt.Errorf("OtherParent not found")
-				}
+			if !found {
+				return nil, NewWireErr(WireUnknownOtherParent, wevent.Body.CreatorID, "OtherParentIndex", wevent.Body.OtherParentIndex)
 			}
-		} else {
-			// unknown participant
-			// TODO: we should handle this nicely
-			return nil, errors.New("unknown participant")
 		}
 	}
 
 	if len(wevent.FlagTable) == 0 {
-		return nil, fmt.Errorf("flag table is null")
+		return nil, NewWireErr(WireEmptyFlagTable, wevent.Body.CreatorID, "Index", wevent.Body.Index)
 	}
 
 	transactions := make([]*InternalTransaction, len(wevent.Body.InternalTransactions))
@@ -1875,21 +2121,47 @@ func (p *Poset) ReadWireInfo(wevent WireEvent) (*Event, error) {
 	return event, nil
 }
 
-// CheckBlock returns an error if the Block does not contain valid signatures
-// from MORE than 1/3 of participants
+// checkWireIndexes rejects wire events whose indexes cannot be consistent
+// before any store lookup is made
+func (p *Poset) checkWireIndexes(wevent WireEvent) error {
+	if wevent.Body.Index < 0 {
+		return NewWireErr(WireBadIndex, wevent.Body.CreatorID, "Index", wevent.Body.Index)
+	}
+	// leaf events share index 0 with the first real event of a creator,
+	// so the self-parent index may equal the event index but never exceed it
+	if wevent.Body.SelfParentIndex < -1 || wevent.Body.SelfParentIndex > wevent.Body.Index {
+		return NewWireErr(WireBadSelfParent, wevent.Body.CreatorID, "SelfParentIndex", wevent.Body.SelfParentIndex)
+	}
+	if wevent.Body.OtherParentIndex < -1 {
+		return NewWireErr(WireUnknownOtherParent, wevent.Body.CreatorID, "OtherParentIndex", wevent.Body.OtherParentIndex)
+	}
+	return nil
+}
+
+// CheckBlock returns an error if the Block does not contain enough valid
+// signatures, see BlockQuorumWeight
 func (p *Poset) CheckBlock(block Block) error {
-	validSignatures := 0
+	if block.Index() == 0 && !bytes.Equal(block.Body.GenesisHash, p.genesisHash) {
+		return fmt.Errorf("block 0 references genesis 0x%X, expected 0x%X",
+			block.Body.GenesisHash, p.genesisHash)
+	}
+	valid := make(map[string]string)
 	for _, s := range block.GetBlockSignatures() {
 		ok, _ := block.Verify(s)
 		if ok {
-			validSignatures++
+			valid[s.ValidatorHex()] = s.Signature
 		}
 	}
-	if validSignatures <= p.trustCount {
-		return fmt.Errorf("not enough valid signatures: got %d, need %d", validSignatures, p.trustCount+1)
+	weight := p.SignersWeight(valid)
+	if quorum := p.BlockQuorumWeight(); weight < quorum {
+		return fmt.Errorf("not enough valid signatures: got %d of weight %d, need weight %d",
+			len(valid), weight, quorum)
 	}
 
-	p.logger.WithField("valid_signatures", validSignatures).Debug("CheckBlock")
+	p.logger.WithFields(logrus.Fields{
+		"valid_signatures": len(valid),
+		"weight":           weight,
+	}).Debug("CheckBlock")
 	return nil
 }
 
@@ -1897,6 +2169,108 @@ func (p *Poset) CheckBlock(block Block) error {
 Setters
 *******************************************************************************/
 
+// SetGenesisHash sets the hash of the genesis the first block references
+func (p *Poset) SetGenesisHash(hash []byte) {
+	p.genesisHash = hash
+}
+
+// SetBlockQuorum sets a share of the weight of the participants, between 0
+// and 1: a Block needs the signatures of strictly more than that share. 0
+// keeps the default of 1/3.
+func (p *Poset) SetBlockQuorum(quorum float64) {
+	p.blockQuorum = quorum
+}
+
+// SetBlockWeights sets the weights of the participants by public key, those
+// of the genesis, which their signatures of a Block count for. A participant
+// without a weight, and every participant without weights, counts for 1.
+func (p *Poset) SetBlockWeights(weights map[string]uint64) {
+	p.blockWeights = weights
+}
+
+// SetDedupBlockTransactions makes the blocks keep only the first copy of a
+// transaction, and drop those of the previous block. It changes the blocks
+// built from the same frames, so all the validators must agree on it.
+func (p *Poset) SetDedupBlockTransactions(dedup bool) {
+	p.dedupBlockTxs = dedup
+}
+
+// dedupTransactions drops the duplicates of the transactions of block, and
+// those of the previous block, which every node has, even one reset from its
+// frame. A transaction included in blocks further apart is kept.
+func (p *Poset) dedupTransactions(block *Block) error {
+	var previous [][]byte
+	if block.Index() > 0 {
+		prev, err := p.Store.GetBlock(block.Index() - 1)
+		if err != nil {
+			return err
+		}
+		previous = prev.Transactions()
+	}
+	block.Body.Transactions = dropTransactions(block.Transactions(), previous)
+	return nil
+}
+
+// BlockQuorumWeight returns the weight of the valid signatures a Block
+// needs to become the AnchorBlock or to be accepted by FastForward
+func (p *Poset) BlockQuorumWeight() uint64 {
+	return QuorumWeight(p.blockQuorum, ParticipantsWeight(p.Participants, p.blockWeights))
+}
+
+// SignersWeight returns the weight of the participants among the signers of
+// signatures, a Block.Signatures, see SetBlockWeights
+func (p *Poset) SignersWeight(signatures map[string]string) uint64 {
+	return SignersWeight(p.Participants, p.blockWeights, signatures)
+}
+
+// ParticipantsWeight returns the total weight of participants, a
+// participant without one in weights counting for 1
+func ParticipantsWeight(participants *peers.Peers, weights map[string]uint64) uint64 {
+	var total uint64
+	for _, pub := range participants.ToPubKeySlice() {
+		total += participantWeight(weights, pub)
+	}
+	return total
+}
+
+// SignersWeight returns the weight of the participants among the signers of
+// signatures, a participant without one in weights counting for 1
+func SignersWeight(participants *peers.Peers, weights map[string]uint64, signatures map[string]string) uint64 {
+	var total uint64
+	for pub := range signatures {
+		if _, ok := participants.ReadByPubKey(pub); ok {
+			total += participantWeight(weights, pub)
+		}
+	}
+	return total
+}
+
+func participantWeight(weights map[string]uint64, pub string) uint64 {
+	if w, ok := weights[pub]; ok {
+		return w
+	}
+	return 1
+}
+
+// QuorumWeight returns the weight of the valid signatures a Block needs
+// when quorum is the share of the total weight of the participants, see
+// SetBlockQuorum
+func QuorumWeight(quorum float64, total uint64) uint64 {
+	if quorum <= 0 {
+		return uint64(math.Ceil(float64(total)/float64(3))) + 1
+	}
+	weight := uint64(math.Floor(quorum*float64(total))) + 1
+	if weight > total {
+		weight = total
+	}
+	return weight
+}
+
+// GetGenesisHash returns the hash of the genesis of the network
+func (p *Poset) GetGenesisHash() []byte {
+	return p.genesisHash
+}
+
 func (p *Poset) setLastConsensusRound(i int64) {
 	p.firstLastConsensusRoundLocker.Lock()
 	defer p.firstLastConsensusRoundLocker.Unlock()
@@ -1912,6 +2286,8 @@ func (p *Poset) setLastConsensusRound(i int64) {
 }
 
 func (p *Poset) setAnchorBlock(i int64) {
+	p.anchorBlockLocker.Lock()
+	defer p.anchorBlockLocker.Unlock()
 	if p.AnchorBlock == nil {
 		p.AnchorBlock = new(int64)
 	}
@@ -1978,6 +2354,56 @@ func (p *Poset) GetLastConsensusRound() int64 {
 	return *p.LastConsensusRound
 }
 
+// GetFirstConsensusRound returns the first consensus round, -2 if none yet
+func (p *Poset) GetFirstConsensusRound() int64 {
+	p.firstLastConsensusRoundLocker.RLock()
+	defer p.firstLastConsensusRoundLocker.RUnlock()
+	if p.FirstConsensusRound == nil {
+		return -2
+	}
+	return *p.FirstConsensusRound
+}
+
+// GetLastDecidedRound returns the last round whose clothos are all decided,
+// it can run ahead of the last consensus round while frames are pending
+func (p *Poset) GetLastDecidedRound() int64 {
+	last := p.GetLastConsensusRound()
+	p.pendingRoundsLocker.RLock()
+	defer p.pendingRoundsLocker.RUnlock()
+	for _, r := range p.PendingRounds {
+		if !r.Decided {
+			break
+		}
+		if r.Index > last {
+			last = r.Index
+		}
+	}
+	return last
+}
+
+// GetPendingRoundsCount returns the number of rounds not decided yet
+func (p *Poset) GetPendingRoundsCount() int {
+	p.pendingRoundsLocker.RLock()
+	defer p.pendingRoundsLocker.RUnlock()
+	count := 0
+	for _, r := range p.PendingRounds {
+		if !r.Decided {
+			count++
+		}
+	}
+	return count
+}
+
+// GetAnchorBlock returns the index of the anchor block, -1 if none yet
+func (p *Poset) GetAnchorBlock() int64 {
+	p.anchorBlockLocker.RLock()
+	defer p.anchorBlockLocker.RUnlock()
+	if p.AnchorBlock == nil {
+		return -1
+	}
+	return *p.AnchorBlock
+}
+
 // GetConsensusTransactionsCount returns the count of finalized transactions
 func (p *Poset) GetConsensusTransactionsCount() uint64 {
 	p.consensusTransactionsLocker.RLock()
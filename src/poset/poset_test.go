@@ -3634,6 +3634,238 @@ func TestSparsePosetReset(t *testing.T) {
 
 }
 
+func TestRandomBeacon(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateECDSAKey()
+		pubHex := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&keys[i].PublicKey))
+		participants.AddPeer(peers.NewPeer(pubHex, ""))
+	}
+	store := NewInmemStore(participants, cacheSize, nil)
+	p := NewPoset(participants, store, nil, testLogger(t))
+
+	round := NewRoundCreated()
+	var atropos EventHashes
+	for i, key := range keys {
+		pub := crypto.FromECDSAPub(&key.PublicKey)
+		peer, _ := participants.ReadByPubKey(fmt.Sprintf("0x%X", pub))
+		selfParent := GenRootSelfParent(peer.ID)
+		event := NewEvent([][]byte{[]byte(fmt.Sprintf("tx%d", i))}, nil, nil,
+			EventHashes{selfParent, EventHash{}}, pub, 0,
+			FlagTable{selfParent: 1})
+		if err := event.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetEvent(event); err != nil {
+			t.Fatal(err)
+		}
+		round.AddEvent(event.Hash(), true)
+		atropos = append(atropos, event.Hash())
+	}
+
+	if _, err := p.RandomBeacon(0); err == nil {
+		t.Fatal("expected error for unknown round")
+	}
+
+	if err := store.SetRoundCreated(0, *round); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.RandomBeacon(0); err == nil {
+		t.Fatal("expected error for round without Atropos")
+	}
+
+	for _, hash := range atropos {
+		round.SetAtropos(hash, true)
+	}
+	if err := store.SetRoundCreated(0, *round); err != nil {
+		t.Fatal(err)
+	}
+	random, err := p.RandomBeacon(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(random) != 32 {
+		t.Fatalf("random beacon should be 32 bytes, not %d", len(random))
+	}
+	again, err := p.RandomBeacon(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(random, again) {
+		t.Fatalf("random beacon should be deterministic: %X != %X", random, again)
+	}
+
+	round.SetAtropos(atropos[0], false)
+	if err := store.SetRoundCreated(0, *round); err != nil {
+		t.Fatal(err)
+	}
+	other, err := p.RandomBeacon(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(random, other) {
+		t.Fatal("random beacon should depend on the Atropos set")
+	}
+}
+
+func TestRoundWitnesses(t *testing.T) {
+	participants := peers.NewPeers()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateECDSAKey()
+		pubHex := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&keys[i].PublicKey))
+		participants.AddPeer(peers.NewPeer(pubHex, ""))
+	}
+	store := NewInmemStore(participants, cacheSize, nil)
+	p := NewPoset(participants, store, nil, testLogger(t))
+
+	round := NewRoundCreated()
+	var clothos EventHashes
+	for _, key := range keys {
+		pub := crypto.FromECDSAPub(&key.PublicKey)
+		peer, _ := participants.ReadByPubKey(fmt.Sprintf("0x%X", pub))
+		selfParent := GenRootSelfParent(peer.ID)
+		event := NewEvent(nil, nil, nil,
+			EventHashes{selfParent, EventHash{}}, pub, 0,
+			FlagTable{selfParent: 1})
+		if err := event.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetEvent(event); err != nil {
+			t.Fatal(err)
+		}
+		round.AddEvent(event.Hash(), true)
+		clothos = append(clothos, event.Hash())
+	}
+	round.SetAtropos(clothos[0], true)
+	round.SetAtropos(clothos[1], false)
+	if err := store.SetRoundCreated(0, *round); err != nil {
+		t.Fatal(err)
+	}
+	p.PendingRounds = append(p.PendingRounds, &pendingRound{0, false})
+
+	status, err := p.RoundWitnesses(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Pending || status.Decided {
+		t.Fatalf("round 0 should be pending and undecided: %+v", status)
+	}
+	if status.Clothos != 3 || status.Atropos != 1 || status.Undecided != 1 {
+		t.Fatalf("unexpected round 0 counts: %+v", status)
+	}
+	if status.SuperMajority != p.superMajority {
+		t.Fatalf("super majority should be %d, not %d", p.superMajority, status.SuperMajority)
+	}
+	for _, w := range status.Witnesses {
+		var hash EventHash
+		if err := hash.Parse(w.Hash); err != nil {
+			t.Fatal(err)
+		}
+		if w.Decided != round.IsDecided(hash) {
+			t.Fatalf("witness %s decided should be %v", w.Hash, round.IsDecided(hash))
+		}
+		ev, _ := store.GetEventBlock(hash)
+		if w.Creator != ev.GetCreator() {
+			t.Fatalf("witness %s creator should be %s, not %s", w.Hash, ev.GetCreator(), w.Creator)
+		}
+	}
+
+	round.SetAtropos(clothos[2], true)
+	if err := store.SetRoundCreated(0, *round); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = p.RoundWitnesses(0); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Decided || status.Undecided != 0 || status.Atropos != 2 {
+		t.Fatalf("round 0 should be decided: %+v", status)
+	}
+
+	if _, err := p.RoundWitnesses(1); err == nil {
+		t.Fatal("expected error for unknown round")
+	}
+}
+
+func TestConsensusFrontier(t *testing.T) {
+	participants := peers.NewPeers()
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateECDSAKey()
+		pubHex := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
+		participants.AddPeer(peers.NewPeer(pubHex, ""))
+	}
+	p := NewPoset(participants, NewInmemStore(participants, cacheSize, nil), nil, testLogger(t))
+
+	if r := p.GetFirstConsensusRound(); r != -2 {
+		t.Fatalf("first consensus round should be -2, not %d", r)
+	}
+	if b := p.GetAnchorBlock(); b != -1 {
+		t.Fatalf("anchor block should be -1, not %d", b)
+	}
+
+	p.setLastConsensusRound(1)
+	p.setLastConsensusRound(2)
+	p.setAnchorBlock(0)
+	p.PendingRounds = []*pendingRound{{3, true}, {4, true}, {5, false}, {6, true}}
+
+	if r := p.GetFirstConsensusRound(); r != 1 {
+		t.Fatalf("first consensus round should be 1, not %d", r)
+	}
+	if r := p.GetLastDecidedRound(); r != 4 {
+		t.Fatalf("last decided round should be 4, not %d", r)
+	}
+	if c := p.GetPendingRoundsCount(); c != 1 {
+		t.Fatalf("pending rounds should be 1, not %d", c)
+	}
+	if b := p.GetAnchorBlock(); b != 0 {
+		t.Fatalf("anchor block should be 0, not %d", b)
+	}
+}
+
+func TestReadWireInfoErrors(t *testing.T) {
+	participants := peers.NewPeers()
+	key, _ := crypto.GenerateECDSAKey()
+	pubHex := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
+	participants.AddPeer(peers.NewPeer(pubHex, ""))
+	peer, _ := participants.ReadByPubKey(pubHex)
+	store := NewInmemStore(participants, cacheSize, nil)
+	p := NewPoset(participants, store, nil, testLogger(t))
+
+	flagTable := []byte{1}
+	cases := []struct {
+		name      string
+		body      WireBody
+		flagTable []byte
+		errType   WireErrType
+	}{
+		{"negative index",
+			WireBody{Index: -1, SelfParentIndex: -2, OtherParentIndex: -1, CreatorID: peer.ID},
+			flagTable, WireBadIndex},
+		{"self-parent gap",
+			WireBody{Index: 0, SelfParentIndex: 3, OtherParentIndex: -1, CreatorID: peer.ID},
+			flagTable, WireBadSelfParent},
+		{"unknown creator",
+			WireBody{Index: 0, SelfParentIndex: -1, OtherParentIndex: -1, CreatorID: peer.ID + 1},
+			flagTable, WireUnknownCreator},
+		{"missing self-parent",
+			WireBody{Index: 5, SelfParentIndex: 4, OtherParentIndex: -1, CreatorID: peer.ID},
+			flagTable, WireMissingSelfParent},
+		{"unknown other-parent creator",
+			WireBody{Index: 0, SelfParentIndex: -1, OtherParentCreatorID: peer.ID + 1, OtherParentIndex: 0, CreatorID: peer.ID},
+			flagTable, WireUnknownOtherParent},
+		{"empty flag table",
+			WireBody{Index: 0, SelfParentIndex: -1, OtherParentIndex: -1, CreatorID: peer.ID},
+			nil, WireEmptyFlagTable},
+	}
+	for _, c := range cases {
+		_, err := p.ReadWireInfo(WireEvent{Body: c.body, FlagTable: c.flagTable})
+		if !IsWireErrType(err, c.errType) {
+			t.Errorf("%s: expected wire error %d, got %v", c.name, c.errType, err)
+		}
+	}
+}
+
 func compareRoundClothos(p, p2 *Poset, index map[string]EventHash, round int64, check bool, t *testing.T) {
 	for i := round; i <= 5; i++ {
 		pRound, err := p.Store.GetRoundCreated(i)
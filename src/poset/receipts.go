@@ -0,0 +1,85 @@
+package poset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+// Receipt is the result the application reports for a transaction of a
+// committed block. Code 0 is success, anything else an application error.
+type Receipt struct {
+	Code uint32
+	Data []byte `json:",omitempty"`
+	Log  string `json:",omitempty"`
+}
+
+// ReceiptStore is a Store which keeps the receipts of the transactions of
+// committed blocks, in the order of Block.Transactions, such as InmemStore
+// and BadgerStore
+type ReceiptStore interface {
+	Store
+	SetReceipts(blockIndex int64, receipts []Receipt) error
+	GetReceipts(blockIndex int64) ([]Receipt, error)
+}
+
+// SetReceipts implements ReceiptStore. Like blocks, only the last cacheSize
+// blocks are kept.
+func (s *InmemStore) SetReceipts(blockIndex int64, receipts []Receipt) error {
+	defer observeOp("inmem", "SetReceipts", time.Now())
+	s.receiptCache.Add(blockIndex, receipts)
+	return nil
+}
+
+// GetReceipts implements ReceiptStore
+func (s *InmemStore) GetReceipts(blockIndex int64) ([]Receipt, error) {
+	defer observeOp("inmem", "GetReceipts", time.Now())
+	res, ok := s.receiptCache.Get(blockIndex)
+	countLookup("receipts", ok)
+	if !ok {
+		return nil, common.NewStoreErr("ReceiptCache", common.KeyNotFound, strconv.FormatInt(blockIndex, 10))
+	}
+	return res.([]Receipt), nil
+}
+
+func receiptsKey(blockIndex int64) []byte {
+	return []byte(fmt.Sprintf("%s_%09d", receiptsPrefix, blockIndex))
+}
+
+// SetReceipts implements ReceiptStore
+func (s *BadgerStore) SetReceipts(blockIndex int64, receipts []Receipt) error {
+	defer observeOp("badger", "SetReceipts", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	val, err := json.Marshal(receipts)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx dbWriter) error {
+		return tx.Set(receiptsKey(blockIndex), val)
+	})
+}
+
+// GetReceipts implements ReceiptStore
+func (s *BadgerStore) GetReceipts(blockIndex int64) ([]Receipt, error) {
+	defer observeOp("badger", "GetReceipts", time.Now())
+	var receipts []Receipt
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(receiptsKey(blockIndex))
+		if err != nil {
+			return err
+		}
+		val, err := s.value(item)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(val, &receipts)
+	})
+	return receipts, mapError(err, "Receipts", string(receiptsKey(blockIndex)))
+}
@@ -0,0 +1,39 @@
+package poset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+func testReceiptStore(store ReceiptStore, t *testing.T) {
+	receipts := []Receipt{
+		{Code: 0, Data: []byte("ok")},
+		{Code: 3, Log: "insufficient funds"},
+	}
+	if err := store.SetReceipts(4, receipts); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetReceipts(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(receipts, got) {
+		t.Fatalf("expected receipts %v, got %v", receipts, got)
+	}
+	if _, err := store.GetReceipts(5); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("block 5 should have no receipts, got %v", err)
+	}
+}
+
+func TestInmemReceipts(t *testing.T) {
+	store, _ := initInmemStore(10)
+	testReceiptStore(store, t)
+}
+
+func TestBadgerReceipts(t *testing.T) {
+	store, _ := initBadgerStore(10, t)
+	defer removeBadgerStore(store, t)
+	testReceiptStore(store, t)
+}
@@ -9,6 +9,26 @@ type pendingRound struct {
 	Decided bool
 }
 
+// WitnessStatus is the consensus status of a round clotho (witness)
+type WitnessStatus struct {
+	Hash    string
+	Creator string
+	Atropos Trilean
+	Decided bool
+}
+
+// RoundStatus describes how far a created round is from being decided
+type RoundStatus struct {
+	Round         int64
+	Pending       bool
+	Decided       bool
+	SuperMajority int
+	Clothos       int
+	Atropos       int
+	Undecided     int
+	Witnesses     []WitnessStatus
+}
+
 // RoundCreated wrapper for protobuf created round event messages
 type RoundCreated struct {
 	Message RoundCreatedMessage
@@ -0,0 +1,171 @@
+package poset
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+)
+
+// StateChunkSize is the size of the chunks application state snapshots are
+// split into
+const StateChunkSize = 1 << 20
+
+// stateSnapshotsRetained is the number of state snapshots a store keeps:
+// the latest one, and the previous one for the peers still reading it
+const stateSnapshotsRetained = 2
+
+// StateSnapshot describes an application state snapshot kept by a
+// StateSnapshotStore. It is keyed by the state hash of the block it was
+// taken at; the chunks are checked against their Keccak256 hashes when read.
+type StateSnapshot struct {
+	BlockIndex  int64
+	StateHash   []byte
+	Size        int64
+	ChunkHashes [][]byte
+}
+
+// StateSnapshotStore is a Store which keeps application state snapshots, so
+// that a node serves FastForward requests without asking the application for
+// a new snapshot each time, such as InmemStore and BadgerStore
+type StateSnapshotStore interface {
+	Store
+	// SetStateSnapshot stores a snapshot made by SplitStateSnapshot. Older
+	// snapshots are dropped.
+	SetStateSnapshot(snapshot StateSnapshot, chunks [][]byte) error
+	GetStateSnapshot(stateHash []byte) (StateSnapshot, error)
+	GetStateSnapshotChunk(stateHash []byte, index int) ([]byte, error)
+}
+
+// SplitStateSnapshot splits the application state snapshot taken at block
+// into chunks of StateChunkSize bytes
+func SplitStateSnapshot(block Block, data []byte) (StateSnapshot, [][]byte) {
+	snapshot := StateSnapshot{
+		BlockIndex: block.Index(),
+		StateHash:  block.GetStateHash(),
+		Size:       int64(len(data)),
+	}
+	var chunks [][]byte
+	for len(data) > 0 {
+		n := StateChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		chunks = append(chunks, data[:n])
+		snapshot.ChunkHashes = append(snapshot.ChunkHashes, crypto.Keccak256(data[:n]))
+		data = data[n:]
+	}
+	return snapshot, chunks
+}
+
+// Validate checks that the size of the snapshot is that of its chunks: all
+// of them but the last one hold StateChunkSize bytes. A node checks the
+// manifest of a peer before fetching the chunks it lists.
+func (s *StateSnapshot) Validate() error {
+	n := int64(len(s.ChunkHashes))
+	if s.Size < 0 || s.Size > n*StateChunkSize || (n > 0 && s.Size <= (n-1)*StateChunkSize) {
+		return fmt.Errorf("snapshot %X of %d bytes cannot have %d chunks", s.StateHash, s.Size, n)
+	}
+	return nil
+}
+
+// VerifyChunk checks a chunk against its hash
+func (s *StateSnapshot) VerifyChunk(index int, chunk []byte) error {
+	if index < 0 || index >= len(s.ChunkHashes) {
+		return fmt.Errorf("snapshot %X has no chunk %d", s.StateHash, index)
+	}
+	if !bytes.Equal(crypto.Keccak256(chunk), s.ChunkHashes[index]) {
+		return fmt.Errorf("chunk %d of snapshot %X does not match its hash", index, s.StateHash)
+	}
+	return nil
+}
+
+// ReadStateSnapshot reads and checks the chunks of the snapshot with the
+// given state hash and returns the application state
+func ReadStateSnapshot(store StateSnapshotStore, stateHash []byte) ([]byte, error) {
+	snapshot, err := store.GetStateSnapshot(stateHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := snapshot.Validate(); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, snapshot.Size)
+	for i := range snapshot.ChunkHashes {
+		chunk, err := store.GetStateSnapshotChunk(stateHash, i)
+		if err != nil {
+			return nil, err
+		}
+		if err := snapshot.VerifyChunk(i, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+	if int64(len(data)) != snapshot.Size {
+		return nil, fmt.Errorf("snapshot %X has %d bytes, expected %d", stateHash, len(data), snapshot.Size)
+	}
+	return data, nil
+}
+
+// stateSnapshotNotFound is the error of a missing snapshot or chunk
+func stateSnapshotNotFound(stateHash []byte, chunk int) error {
+	key := hex.EncodeToString(stateHash)
+	if chunk >= 0 {
+		key = fmt.Sprintf("%s/%d", key, chunk)
+	}
+	return common.NewStoreErr("StateSnapshot", common.KeyNotFound, key)
+}
+
+// stateSnapshots keeps the snapshots of an InmemStore
+type stateSnapshots struct {
+	snapshots []StateSnapshot // oldest first
+	chunks    map[string][][]byte
+}
+
+// SetStateSnapshot implements StateSnapshotStore
+func (s *InmemStore) SetStateSnapshot(snapshot StateSnapshot, chunks [][]byte) error {
+	if len(snapshot.StateHash) == 0 {
+		return fmt.Errorf("state snapshot of block %d has no state hash", snapshot.BlockIndex)
+	}
+	s.stateSnapshotsLocker.Lock()
+	defer s.stateSnapshotsLocker.Unlock()
+	if s.stateSnapshots.chunks == nil {
+		s.stateSnapshots.chunks = make(map[string][][]byte)
+	}
+	key := string(snapshot.StateHash)
+	if _, ok := s.stateSnapshots.chunks[key]; ok {
+		return nil
+	}
+	s.stateSnapshots.snapshots = append(s.stateSnapshots.snapshots, snapshot)
+	s.stateSnapshots.chunks[key] = chunks
+	for len(s.stateSnapshots.snapshots) > stateSnapshotsRetained {
+		delete(s.stateSnapshots.chunks, string(s.stateSnapshots.snapshots[0].StateHash))
+		s.stateSnapshots.snapshots = s.stateSnapshots.snapshots[1:]
+	}
+	return nil
+}
+
+// GetStateSnapshot implements StateSnapshotStore
+func (s *InmemStore) GetStateSnapshot(stateHash []byte) (StateSnapshot, error) {
+	s.stateSnapshotsLocker.RLock()
+	defer s.stateSnapshotsLocker.RUnlock()
+	for _, snapshot := range s.stateSnapshots.snapshots {
+		if bytes.Equal(snapshot.StateHash, stateHash) {
+			return snapshot, nil
+		}
+	}
+	return StateSnapshot{}, stateSnapshotNotFound(stateHash, -1)
+}
+
+// GetStateSnapshotChunk implements StateSnapshotStore
+func (s *InmemStore) GetStateSnapshotChunk(stateHash []byte, index int) ([]byte, error) {
+	s.stateSnapshotsLocker.RLock()
+	defer s.stateSnapshotsLocker.RUnlock()
+	chunks := s.stateSnapshots.chunks[string(stateHash)]
+	if index < 0 || index >= len(chunks) {
+		return nil, stateSnapshotNotFound(stateHash, index)
+	}
+	return chunks[index], nil
+}
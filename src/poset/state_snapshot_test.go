@@ -0,0 +1,82 @@
+package poset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+func testStateSnapshots(store StateSnapshotStore, t *testing.T) {
+	data := bytes.Repeat([]byte("state"), StateChunkSize/2)
+	var hashes [][]byte
+	for i := int64(0); i < 3; i++ {
+		block := NewBlock(i, i+1, []byte("framehash"), nil)
+		block.StateHash = []byte(fmt.Sprintf("statehash%d", i))
+		snapshot, chunks := SplitStateSnapshot(block, append(data, byte(i)))
+		if len(chunks) != 3 {
+			t.Fatalf("snapshot should have 3 chunks, got %d", len(chunks))
+		}
+		if err := store.SetStateSnapshot(snapshot, chunks); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, block.StateHash)
+	}
+
+	// only the two latest snapshots are kept
+	if _, err := store.GetStateSnapshot(hashes[0]); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("the oldest snapshot should be dropped, got %v", err)
+	}
+	for i, hash := range hashes[1:] {
+		got, err := ReadStateSnapshot(store, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, append(data, byte(i+1))) {
+			t.Fatalf("snapshot %s does not match", hash)
+		}
+	}
+
+	snapshot, err := store.GetStateSnapshot(hashes[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.BlockIndex != 2 || snapshot.Size != int64(len(data)+1) {
+		t.Fatalf("unexpected snapshot %d of %d bytes", snapshot.BlockIndex, snapshot.Size)
+	}
+	if err := snapshot.VerifyChunk(0, []byte("forged")); err == nil {
+		t.Fatal("a forged chunk should not verify")
+	}
+}
+
+func TestInmemStateSnapshots(t *testing.T) {
+	store, _ := initInmemStore(10)
+	testStateSnapshots(store, t)
+}
+
+func TestBadgerStateSnapshots(t *testing.T) {
+	store, _ := initBadgerStore(10, t)
+	defer removeBadgerStore(store, t)
+	testStateSnapshots(store, t)
+}
+
+func TestStateSnapshotValidate(t *testing.T) {
+	block := NewBlock(0, 1, []byte("framehash"), nil)
+	block.StateHash = []byte("statehash")
+	for _, size := range []int{0, 1, StateChunkSize, StateChunkSize + 1} {
+		snapshot, _ := SplitStateSnapshot(block, make([]byte, size))
+		if err := snapshot.Validate(); err != nil {
+			t.Fatalf("snapshot of %d bytes: %v", size, err)
+		}
+	}
+
+	snapshot, _ := SplitStateSnapshot(block, make([]byte, StateChunkSize+1))
+	for _, size := range []int64{-1, 0, StateChunkSize, 2*StateChunkSize + 1, 1 << 62} {
+		forged := snapshot
+		forged.Size = size
+		if err := forged.Validate(); err == nil {
+			t.Fatalf("a snapshot of 2 chunks should not have %d bytes", size)
+		}
+	}
+}
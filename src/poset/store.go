@@ -45,4 +45,15 @@ type Store interface {
 	// StateDB returns state database
 	StateDB() state.Database
 	StateRoot() common.Hash
+	// EventsRange calls fn for the events with a topological index in
+	// [from, to), in topological order. to < 0 means no upper bound.
+	// fn must not call the store, which may be locked during a range; it
+	// can stop the range with ErrStopRange.
+	EventsRange(from, to int64, fn func(Event) error) error
+	// BlocksRange calls fn for the blocks with an index in [from, to), in
+	// order. to < 0 means no upper bound.
+	BlocksRange(from, to int64, fn func(Block) error) error
+	// RoundsRange calls fn for the created rounds in [from, to), in order.
+	// to < 0 means no upper bound.
+	RoundsRange(from, to int64, fn func(int64, RoundCreated) error) error
 }
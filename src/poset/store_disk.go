@@ -0,0 +1,47 @@
+package poset
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DiskStore is a Store kept on disk which reports its size, such as
+// BadgerStore and BoltStore
+type DiskStore interface {
+	Store
+	// DiskUsage returns the size in bytes of the files of the store
+	DiskUsage() (int64, error)
+}
+
+// DiskUsage implements DiskStore. It counts the files of the database
+// directory, which badger itself only measures every minute.
+func (s *BadgerStore) DiskUsage() (int64, error) {
+	return dirSize(s.path)
+}
+
+// DiskUsage implements DiskStore
+func (s *BoltStore) DiskUsage() (int64, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			// files are deleted by compactions while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,46 @@
+package poset
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The metrics of all the stores of the process, labelled with the kind of
+// store. They are served by the /metrics endpoint of the service.
+var (
+	storeOpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "lachesis",
+		Subsystem: "store",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of store operations",
+		// 1µs to 4s
+		Buckets: prometheus.ExponentialBuckets(1e-6, 4, 12),
+	}, []string{"store", "op"})
+
+	storeCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lachesis",
+		Subsystem: "store",
+		Name:      "cache_lookups_total",
+		Help:      "Lookups in the LRU caches of the InmemStore, which the BadgerStore reads first",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(storeOpDuration, storeCacheLookups)
+}
+
+// observeOp records the duration of an operation, as in
+//   defer observeOp("badger", "GetBlock", time.Now())
+func observeOp(store, op string, start time.Time) {
+	storeOpDuration.WithLabelValues(store, op).Observe(time.Since(start).Seconds())
+}
+
+// countLookup records a hit or a miss in one of the InmemStore caches
+func countLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	storeCacheLookups.WithLabelValues(cache, result).Inc()
+}
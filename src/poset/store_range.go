@@ -0,0 +1,22 @@
+package poset
+
+import (
+	"errors"
+)
+
+// ErrStopRange can be returned by the function passed to the Range methods
+// of a Store to stop the iteration early. The Range method then returns nil.
+var ErrStopRange = errors.New("stop range")
+
+// inRange tells if i is in [from, to), to < 0 meaning no upper bound
+func inRange(i, from, to int64) bool {
+	return i >= from && (to < 0 || i < to)
+}
+
+// stopRange turns ErrStopRange into nil
+func stopRange(err error) error {
+	if err == ErrStopRange {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package poset
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+// txIndexCacheFactor is the number of transactions per block the InmemStore
+// index keeps room for
+const txIndexCacheFactor = 100
+
+// TxLocation is where a committed transaction is: the index of its block
+// and its position in Block.Transactions
+type TxLocation struct {
+	Block    int64
+	Position int
+}
+
+// TxIndexStore is a Store which indexes the transactions of the committed
+// blocks by TxHash, such as InmemStore and BadgerStore
+type TxIndexStore interface {
+	Store
+	IndexTransactions(block Block) error
+	GetTxLocation(hash string) (TxLocation, error)
+}
+
+// IndexTransactions implements TxIndexStore. Like blocks, only the
+// transactions of the last blocks are kept.
+func (s *InmemStore) IndexTransactions(block Block) error {
+	defer observeOp("inmem", "IndexTransactions", time.Now())
+	for i, tx := range block.Transactions() {
+		s.txCache.Add(TxHash(tx), TxLocation{Block: block.Index(), Position: i})
+	}
+	return nil
+}
+
+// GetTxLocation implements TxIndexStore
+func (s *InmemStore) GetTxLocation(hash string) (TxLocation, error) {
+	defer observeOp("inmem", "GetTxLocation", time.Now())
+	res, ok := s.txCache.Get(hash)
+	countLookup("txs", ok)
+	if !ok {
+		return TxLocation{}, common.NewStoreErr("TxCache", common.KeyNotFound, hash)
+	}
+	return res.(TxLocation), nil
+}
+
+func txKey(hash string) []byte {
+	return []byte(fmt.Sprintf("%s_%s", txPrefix, hash))
+}
+
+// IndexTransactions implements TxIndexStore
+func (s *BadgerStore) IndexTransactions(block Block) error {
+	defer observeOp("badger", "IndexTransactions", time.Now())
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	return s.update(func(tx dbWriter) error {
+		for i, t := range block.Transactions() {
+			val, err := json.Marshal(TxLocation{Block: block.Index(), Position: i})
+			if err != nil {
+				return err
+			}
+			if err := tx.Set(txKey(TxHash(t)), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetTxLocation implements TxIndexStore
+func (s *BadgerStore) GetTxLocation(hash string) (TxLocation, error) {
+	defer observeOp("badger", "GetTxLocation", time.Now())
+	var loc TxLocation
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(txKey(hash))
+		if err != nil {
+			return err
+		}
+		val, err := s.value(item)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(val, &loc)
+	})
+	return loc, mapError(err, "Tx", string(txKey(hash)))
+}
@@ -0,0 +1,38 @@
+package poset
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+)
+
+func testTxIndexStore(store TxIndexStore, t *testing.T) {
+	txs := [][]byte{[]byte("tx1"), []byte("tx2")}
+	block := NewBlock(4, 1, []byte("framehash"), txs)
+	if err := store.IndexTransactions(block); err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range txs {
+		loc, err := store.GetTxLocation(TxHash(tx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc.Block != 4 || loc.Position != i {
+			t.Fatalf("expected tx %d in block 4, got %+v", i, loc)
+		}
+	}
+	if _, err := store.GetTxLocation(TxHash([]byte("tx3"))); !common.Is(err, common.KeyNotFound) {
+		t.Fatalf("tx3 should not be indexed, got %v", err)
+	}
+}
+
+func TestInmemTxIndex(t *testing.T) {
+	store, _ := initInmemStore(10)
+	testTxIndexStore(store, t)
+}
+
+func TestBadgerTxIndex(t *testing.T) {
+	store, _ := initBadgerStore(10, t)
+	defer removeBadgerStore(store, t)
+	testTxIndexStore(store, t)
+}
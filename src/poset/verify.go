@@ -0,0 +1,59 @@
+package poset
+
+import (
+	"runtime"
+	"sync"
+)
+
+// VerifyEvents hashes the events and verifies their signatures with workers
+// goroutines at once, runtime.GOMAXPROCS when workers <= 0. InsertEvent
+// does not verify again the events whose signature is valid; the others fail
+// there.
+func VerifyEvents(events []*Event, workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(events) {
+		workers = len(events)
+	}
+	if workers <= 1 {
+		for _, ev := range events {
+			ev.verifyOnce()
+		}
+		return
+	}
+
+	next := make(chan *Event)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for ev := range next {
+				ev.verifyOnce()
+			}
+		}()
+	}
+	for _, ev := range events {
+		next <- ev
+	}
+	close(next)
+	wg.Wait()
+}
+
+// verifyOnce hashes the event and marks it verified if its signature is
+// valid
+func (e *Event) verifyOnce() {
+	e.Hash()
+	if ok, err := e.Verify(); ok && err == nil {
+		e.verified = true
+	}
+}
+
+// verify verifies the signature of the event, unless VerifyEvents did
+func (e *Event) verify() (bool, error) {
+	if e.verified {
+		return true, nil
+	}
+	return e.Verify()
+}
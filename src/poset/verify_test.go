@@ -0,0 +1,44 @@
+package poset
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+)
+
+func TestVerifyEvents(t *testing.T) {
+	privateKey, _ := crypto.GenerateECDSAKey()
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	var events []*Event
+	for i := 0; i < 10; i++ {
+		body := createDummyEventBody()
+		body.Creator = publicKeyBytes
+		body.Index = int64(i)
+		event := &Event{Message: &EventMessage{Body: &body}}
+		if err := event.Sign(privateKey); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	// a forged event, signed for another body
+	events[3].Message.Body.Index = 100
+
+	for _, workers := range []int{0, 1, 4} {
+		for _, ev := range events {
+			ev.verified = false
+		}
+		VerifyEvents(events, workers)
+		for i, ev := range events {
+			if ev.verified == (i == 3) {
+				t.Fatalf("workers %d: event %d verified %v", workers, i, ev.verified)
+			}
+			if len(ev.Message.Hash) == 0 {
+				t.Fatalf("workers %d: event %d not hashed", workers, i)
+			}
+		}
+		if ok, _ := events[3].verify(); ok {
+			t.Fatalf("workers %d: expected the forged event to fail", workers)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package poset
+
+import "fmt"
+
+// WireErrType wire event validation error type
+type WireErrType uint32
+
+const (
+	// WireBadIndex the event index is negative
+	WireBadIndex WireErrType = iota
+	// WireBadSelfParent the self-parent index is out of range or the
+	// self-parent is not known
+	WireBadSelfParent
+	// WireUnknownCreator the creator ID is not a known participant
+	WireUnknownCreator
+	// WireUnknownOtherParent the other-parent creator or event is not known
+	WireUnknownOtherParent
+	// WireEmptyFlagTable the flag table is missing
+	WireEmptyFlagTable
+	// WireMissingSelfParent the self-parent index is valid but the self-parent
+	// has not been received yet, it can be fetched from the sender
+	WireMissingSelfParent
+)
+
+// WireErr is returned when a WireEvent received from a peer is malformed.
+// It is a protocol violation, as opposed to a local failure.
+type WireErr struct {
+	errType   WireErrType
+	creatorID uint64
+	field     string
+	value     int64
+}
+
+// NewWireErr constructor
+func NewWireErr(errType WireErrType, creatorID uint64, field string, value int64) WireErr {
+	return WireErr{
+		errType:   errType,
+		creatorID: creatorID,
+		field:     field,
+		value:     value,
+	}
+}
+
+// Type returns the wire error type
+func (e WireErr) Type() WireErrType {
+	return e.errType
+}
+
+// CreatorID returns the creator of the rejected wire event
+func (e WireErr) CreatorID() uint64 {
+	return e.creatorID
+}
+
+// Value returns the value of the offending field
+func (e WireErr) Value() int64 {
+	return e.value
+}
+
+func (e WireErr) Error() string {
+	m := ""
+	switch e.errType {
+	case WireBadIndex:
+		m = "Bad Index"
+	case WireBadSelfParent:
+		m = "Bad Self-Parent"
+	case WireUnknownCreator:
+		m = "Unknown Creator"
+	case WireUnknownOtherParent:
+		m = "Unknown Other-Parent"
+	case WireEmptyFlagTable:
+		m = "Empty Flag Table"
+	case WireMissingSelfParent:
+		m = "Missing Self-Parent"
+	}
+
+	return fmt.Sprintf("wire event of %d, %s=%d, %s", e.creatorID, e.field, e.value, m)
+}
+
+// IsWireErr checks if the error is a wire error of any type
+func IsWireErr(err error) bool {
+	_, ok := err.(WireErr)
+	return ok
+}
+
+// IsWireErrType checks if the error is a wire error of the given type
+func IsWireErrType(err error, t WireErrType) bool {
+	wireErr, ok := err.(WireErr)
+	return ok && wireErr.errType == t
+}
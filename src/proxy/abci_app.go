@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// CodeTypeOK is the ResponseDeliverTx code of a successful transaction, as
+// in ABCI. Any other code is an application error.
+const CodeTypeOK uint32 = 0
+
+// RequestBeginBlock starts the execution of a block
+type RequestBeginBlock struct {
+	Hash          []byte
+	Height        int64
+	RoundReceived int64
+}
+
+// ResponseBeginBlock is the answer of the application to BeginBlock
+type ResponseBeginBlock struct{}
+
+// ResponseCheckTx is the verdict of the application on a submitted
+// transaction
+type ResponseCheckTx struct {
+	Code uint32
+	Log  string
+}
+
+// ResponseDeliverTx is the result of a transaction of a block
+type ResponseDeliverTx struct {
+	Code uint32
+	Data []byte
+	Log  string
+}
+
+// RequestEndBlock ends the execution of a block
+type RequestEndBlock struct {
+	Height int64
+}
+
+// ResponseEndBlock is the answer of the application to EndBlock
+type ResponseEndBlock struct {
+	ValidatorUpdates []ValidatorUpdate
+}
+
+// ResponseCommit holds the state hash, or app hash, once the block is
+// persisted
+type ResponseCommit struct {
+	Data []byte
+}
+
+// ABCIApplication is the block execution interface of Tendermint ABCI
+// applications. Transactions are delivered in consensus order, between
+// BeginBlock and EndBlock, then Commit persists the state.
+type ABCIApplication interface {
+	BeginBlock(req RequestBeginBlock) ResponseBeginBlock
+	DeliverTx(tx []byte) ResponseDeliverTx
+	EndBlock(req RequestEndBlock) ResponseEndBlock
+	Commit() ResponseCommit
+}
+
+// ABCITxChecker is implemented by ABCI applications which validate
+// transactions before they enter the transaction pool
+type ABCITxChecker interface {
+	CheckTx(tx []byte) ResponseCheckTx
+}
+
+// RequestQuery reads the state of an ABCI application
+type RequestQuery struct {
+	Data []byte
+}
+
+// ResponseQuery is the answer of the application to a query
+type ResponseQuery struct {
+	Code  uint32
+	Value []byte
+	Log   string
+}
+
+// ABCIQuerier is implemented by ABCI applications which answer queries
+type ABCIQuerier interface {
+	Query(req RequestQuery) ResponseQuery
+}
+
+// ABCISnapshotter is implemented by ABCI applications which support fast
+// sync: Snapshot returns the state as of the given height, Restore replaces
+// the state and returns its app hash.
+type ABCISnapshotter interface {
+	Snapshot(height int64) ([]byte, error)
+	Restore(snapshot []byte) (appHash []byte, err error)
+}
+
+// abciHandler adapts an ABCIApplication to ProxyHandler
+type abciHandler struct {
+	app    ABCIApplication
+	logger *logrus.Logger
+}
+
+// NewABCIAppProxy runs an ABCI application in process. Heights are block
+// indexes. Failed transactions, with a code other than CodeTypeOK, are
+// logged but stay in the block, as in Tendermint.
+func NewABCIAppProxy(app ABCIApplication, logger *logrus.Logger) *InmemAppProxy {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+	return NewInmemAppProxy(&abciHandler{app: app, logger: logger}, logger)
+}
+
+// CommitHandler implements ProxyHandler
+func (h *abciHandler) CommitHandler(block poset.Block) ([]byte, error) {
+	res, err := h.CommitReceiptsHandler(block)
+	return res.StateHash, err
+}
+
+// CommitReceiptsHandler implements CommitReceiptsHandler, the receipts are
+// the DeliverTx responses
+func (h *abciHandler) CommitReceiptsHandler(block poset.Block) (CommitResult, error) {
+	hash, err := block.BlockHash()
+	if err != nil {
+		return CommitResult{}, err
+	}
+	h.app.BeginBlock(RequestBeginBlock{
+		Hash:          hash,
+		Height:        block.Index(),
+		RoundReceived: block.RoundReceived(),
+	})
+	receipts := make([]poset.Receipt, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		res := h.app.DeliverTx(tx)
+		receipts[i] = poset.Receipt{Code: res.Code, Data: res.Data, Log: res.Log}
+		if res.Code != CodeTypeOK {
+			h.logger.WithFields(logrus.Fields{
+				"block": block.Index(),
+				"tx":    i,
+				"code":  res.Code,
+				"log":   res.Log,
+			}).Debug("ABCI transaction failed")
+		}
+	}
+	end := h.app.EndBlock(RequestEndBlock{Height: block.Index()})
+	return CommitResult{
+		StateHash:        h.app.Commit().Data,
+		Receipts:         receipts,
+		ValidatorUpdates: end.ValidatorUpdates,
+	}, nil
+}
+
+// CheckTxHandler implements CheckTxHandler
+func (h *abciHandler) CheckTxHandler(tx []byte) error {
+	checker, ok := h.app.(ABCITxChecker)
+	if !ok {
+		return nil
+	}
+	if res := checker.CheckTx(tx); res.Code != CodeTypeOK {
+		return fmt.Errorf("transaction rejected with code %d: %s", res.Code, res.Log)
+	}
+	return nil
+}
+
+// QueryHandler implements QueryHandler
+func (h *abciHandler) QueryHandler(query []byte) ([]byte, error) {
+	querier, ok := h.app.(ABCIQuerier)
+	if !ok {
+		return nil, ErrNoQuery
+	}
+	res := querier.Query(RequestQuery{Data: query})
+	if res.Code != CodeTypeOK {
+		return nil, fmt.Errorf("query failed with code %d: %s", res.Code, res.Log)
+	}
+	return res.Value, nil
+}
+
+// SnapshotHandler implements ProxyHandler
+func (h *abciHandler) SnapshotHandler(blockIndex int64) ([]byte, error) {
+	snapshotter, ok := h.app.(ABCISnapshotter)
+	if !ok {
+		return nil, fmt.Errorf("ABCI application does not support snapshots")
+	}
+	return snapshotter.Snapshot(blockIndex)
+}
+
+// RestoreHandler implements ProxyHandler
+func (h *abciHandler) RestoreHandler(snapshot []byte) ([]byte, error) {
+	snapshotter, ok := h.app.(ABCISnapshotter)
+	if !ok {
+		return nil, fmt.Errorf("ABCI application does not support snapshots")
+	}
+	return snapshotter.Restore(snapshot)
+}
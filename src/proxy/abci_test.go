@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestABCIAppProxy(t *testing.T) {
+	assertO := assert.New(t)
+
+	updates := []ValidatorUpdate{{PubKeyHex: "0xAA", NetAddr: "127.0.0.1:1337", Power: 10}}
+	app := &counterApp{updates: updates}
+	proxy := NewABCIAppProxy(app, common.NewTestLogger(t))
+
+	// the counter only accepts the next value
+	block := poset.NewBlock(0, 1, []byte{}, [][]byte{
+		counterTx(0), counterTx(1), counterTx(5), counterTx(2),
+	})
+	res, err := proxy.CommitBlock(block)
+	if assertO.NoError(err) {
+		assertO.Equal(counterTx(3), res.StateHash)
+		if assertO.Len(res.Receipts, 4) {
+			assertO.Equal(CodeTypeOK, res.Receipts[1].Code)
+			assertO.Equal("invalid nonce", res.Receipts[2].Log)
+		}
+		assertO.Equal(updates, res.ValidatorUpdates)
+		assertO.Equal([]string{"begin 0", "end 0", "commit"}, app.calls)
+		assertO.Equal(1, app.failed)
+	}
+
+	_, err = proxy.GetSnapshot(0)
+	assertO.Error(err, "counterApp does not support snapshots")
+
+	count, err := proxy.Query([]byte("count"))
+	if assertO.NoError(err) {
+		assertO.Equal(counterTx(3), count)
+	}
+	_, err = proxy.Query([]byte("balance"))
+	assertO.Error(err)
+
+	assertO.NoError(proxy.CheckTx(counterTx(3)))
+	assertO.Error(proxy.CheckTx([]byte("not a counter")))
+}
+
+func TestValidatorUpdate(t *testing.T) {
+	assertO := assert.New(t)
+
+	tx := ValidatorUpdate{PubKeyHex: "0xAA", NetAddr: "127.0.0.1:1337", Power: 10}.InternalTransaction()
+	assertO.Equal(poset.TransactionType_PEER_ADD, tx.Type)
+	assertO.Equal(uint64(10), tx.Amount)
+	assertO.Equal("0xAA", tx.Peer.PubKeyHex)
+	assertO.Equal("127.0.0.1:1337", tx.Peer.NetAddr)
+
+	tx = ValidatorUpdate{PubKeyHex: "0xAA"}.InternalTransaction()
+	assertO.Equal(poset.TransactionType_PEER_REMOVE, tx.Type)
+	assertO.Equal("0xAA", tx.Peer.PubKeyHex)
+}
+
+// counterApp counts transactions which hold the next value of the counter
+type counterApp struct {
+	count  uint64
+	failed int
+	calls  []string
+	// updates are returned by EndBlock
+	updates []ValidatorUpdate
+}
+
+func counterTx(n uint64) []byte {
+	tx := make([]byte, 8)
+	binary.BigEndian.PutUint64(tx, n)
+	return tx
+}
+
+func (a *counterApp) BeginBlock(req RequestBeginBlock) ResponseBeginBlock {
+	a.calls = append(a.calls, fmt.Sprintf("begin %d", req.Height))
+	return ResponseBeginBlock{}
+}
+
+func (a *counterApp) Query(req RequestQuery) ResponseQuery {
+	if string(req.Data) != "count" {
+		return ResponseQuery{Code: 1, Log: "unknown query"}
+	}
+	return ResponseQuery{Code: CodeTypeOK, Value: counterTx(a.count)}
+}
+
+func (a *counterApp) CheckTx(tx []byte) ResponseCheckTx {
+	if len(tx) != 8 {
+		return ResponseCheckTx{Code: 1, Log: "not a counter value"}
+	}
+	return ResponseCheckTx{Code: CodeTypeOK}
+}
+
+func (a *counterApp) DeliverTx(tx []byte) ResponseDeliverTx {
+	if len(tx) != 8 || binary.BigEndian.Uint64(tx) != a.count {
+		a.failed++
+		return ResponseDeliverTx{Code: 1, Log: "invalid nonce"}
+	}
+	a.count++
+	return ResponseDeliverTx{Code: CodeTypeOK}
+}
+
+func (a *counterApp) EndBlock(req RequestEndBlock) ResponseEndBlock {
+	a.calls = append(a.calls, fmt.Sprintf("end %d", req.Height))
+	return ResponseEndBlock{ValidatorUpdates: a.updates}
+}
+
+func (a *counterApp) Commit() ResponseCommit {
+	a.calls = append(a.calls, "commit")
+	return ResponseCommit{Data: counterTx(a.count)}
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+)
+
+// NodeEventType tells what happened to the node
+type NodeEventType string
+
+const (
+	// NodeStateChanged is sent when the node goes Gossiping, CatchingUp,
+	// Stop or Shutdown
+	NodeStateChanged NodeEventType = "state"
+	// PeerAdded is sent when a peer joins the participants
+	PeerAdded NodeEventType = "peer_added"
+	// PeerRemoved is sent when a peer leaves the participants
+	PeerRemoved NodeEventType = "peer_removed"
+	// ForkDetected is sent when a participant signed two events at the same
+	// index
+	ForkDetected NodeEventType = "fork"
+	// TxExpired is sent when transactions waited in the pool for longer than
+	// their TTL without being put in an event, and were dropped
+	TxExpired NodeEventType = "tx_expired"
+)
+
+// NodeEvent is an event of the node, beyond commits, the application may
+// react to, e.g. by pausing withdrawals while the node is catching up
+type NodeEvent struct {
+	Type NodeEventType
+	Time time.Time
+	// State is the new state of the node
+	State string `json:",omitempty"`
+	// Peer is the added or removed peer
+	Peer *peers.Peer `json:",omitempty"`
+	// Creator signed the Events at Index, the first one being the known one
+	Creator string   `json:",omitempty"`
+	Index   int64    `json:",omitempty"`
+	Events  []string `json:",omitempty"`
+	// Txs are the hashes of the expired transactions
+	Txs []string `json:",omitempty"`
+}
+
+// NodeEventSubscriber is implemented by AppProxies whose application
+// subscribes to node events. The node never blocks on the channel: events
+// are dropped when it is full, or nil.
+type NodeEventSubscriber interface {
+	NodeEventCh() chan<- NodeEvent
+}
+
+// nodeEventBuffer is the capacity of the node event channels of the proxies
+const nodeEventBuffer = 100
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/utils"
+)
+
+// eventHandler is an abciHandler which collects the node events
+type eventHandler struct {
+	*abciHandler
+	events chan NodeEvent
+}
+
+func (h *eventHandler) NodeEventHandler(event NodeEvent) {
+	h.events <- event
+}
+
+func TestNodeEvents(t *testing.T) {
+	logger := common.NewTestLogger(t)
+	gold := []NodeEvent{
+		{Type: NodeStateChanged, Time: time.Unix(1, 0).UTC(), State: "CatchingUp"},
+		{Type: PeerAdded, Time: time.Unix(2, 0).UTC(), Peer: peers.NewPeer("0xAA", "127.0.0.1:1337")},
+		{Type: ForkDetected, Time: time.Unix(3, 0).UTC(), Creator: "0xAA", Index: 7, Events: []string{"0x01", "0x02"}},
+	}
+	check := func(p AppProxy, handler *eventHandler, t *testing.T) {
+		assertO := assert.New(t)
+		subscriber, ok := p.(NodeEventSubscriber)
+		if !assertO.True(ok) || !assertO.NotNil(subscriber.NodeEventCh()) {
+			return
+		}
+		for _, event := range gold {
+			subscriber.NodeEventCh() <- event
+		}
+		for _, event := range gold {
+			select {
+			case got := <-handler.events:
+				assertO.Equal(event, got)
+			case <-time.After(socketTimeout):
+				assertO.Fail("time is over")
+				return
+			}
+		}
+	}
+
+	t.Run("inmem", func(t *testing.T) {
+		handler := &eventHandler{&abciHandler{app: &counterApp{}, logger: logger}, make(chan NodeEvent, len(gold))}
+		check(Chain(NewInmemAppProxy(handler, logger), Metrics()), handler, t)
+
+		// no channel when the app does not subscribe
+		p := NewABCIAppProxy(&counterApp{}, logger)
+		assert.Nil(t, p.NodeEventCh())
+	})
+
+	t.Run("socket", func(t *testing.T) {
+		addr := utils.GetUnusedNetAddr(1, t)
+		s, err := NewSocketAppProxy("tcp", addr[0], socketTimeout, socketHeartbeat, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		handler := &eventHandler{&abciHandler{app: &counterApp{}, logger: logger}, make(chan NodeEvent, len(gold))}
+		c := NewSocketLachesisProxy("tcp", addr[0], handler, socketTimeout, socketHeartbeat, logger)
+		defer c.Close()
+
+		// the events sent before the app connects are dropped
+		if _, err := s.Query([]byte("count")); err != nil {
+			t.Fatal(err)
+		}
+		check(s, handler, t)
+	})
+}
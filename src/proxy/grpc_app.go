@@ -11,12 +11,16 @@ import (
 	"io"
 	"math"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/rs/xid"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy/internal"
@@ -24,6 +28,24 @@ import (
 
 var ErrNoAnswers = errors.New("no answers")
 
+// GrpcProtocolVersion is the version of the messages of the gRPC proxy,
+// which the node and its apps exchange in the headers of the Connect stream.
+// Both ends must speak the same version.
+const GrpcProtocolVersion = 1
+
+// grpcVersionKey is the metadata key of the gRPC protocol version
+const grpcVersionKey = "lachesis-proxy-version"
+
+// grpcVersion returns the protocol version in md, 0 if there is none
+func grpcVersion(md metadata.MD) int {
+	values := md.Get(grpcVersionKey)
+	if len(values) == 0 {
+		return 0
+	}
+	version, _ := strconv.Atoi(values[0])
+	return version
+}
+
 type ClientStream internal.LachesisNode_ConnectServer
 
 //GrpcAppProxy implements the AppProxy interface
@@ -95,6 +117,10 @@ func (p *GrpcAppProxy) Close() error {
 
 // Connect implements gRPC-server interface: LachesisNodeServer
 func (p *GrpcAppProxy) Connect(stream internal.LachesisNode_ConnectServer) error {
+	if err := p.checkVersion(stream); err != nil {
+		p.logger.WithError(err).Error("client refused")
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
 	// save client's stream for writing
 	p.newClients <- stream
 	p.logger.Debugf("client connected")
@@ -120,6 +146,21 @@ func (p *GrpcAppProxy) Connect(stream internal.LachesisNode_ConnectServer) error
 	}
 }
 
+// checkVersion sends our protocol version in the headers of stream and
+// checks the one of the client
+func (p *GrpcAppProxy) checkVersion(stream internal.LachesisNode_ConnectServer) error {
+	header := metadata.Pairs(grpcVersionKey, strconv.Itoa(GrpcProtocolVersion))
+	if err := stream.SendHeader(header); err != nil {
+		return err
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	version := grpcVersion(md)
+	if version != GrpcProtocolVersion {
+		return &VersionError{Protocol: "grpc", Node: GrpcProtocolVersion, App: version}
+	}
+	return nil
+}
+
 func (p *GrpcAppProxy) sendEvents4clients() {
 	var (
 		err       error
@@ -161,21 +202,22 @@ func (p *GrpcAppProxy) SubmitInternalCh() chan poset.InternalTransaction {
 	return nil
 }
 
-// CommitBlock implements AppProxy interface method
-func (p *GrpcAppProxy) CommitBlock(block poset.Block) ([]byte, error) {
+// CommitBlock implements AppProxy interface method. The protocol only
+// carries the state hash, gRPC apps do not report receipts.
+func (p *GrpcAppProxy) CommitBlock(block poset.Block) (CommitResult, error) {
 	data, err := block.ProtoMarshal()
 	if err != nil {
-		return nil, err
+		return CommitResult{}, err
 	}
 	answer, ok := <-p.pushBlock(data)
 	if !ok {
-		return nil, ErrNoAnswers
+		return CommitResult{}, ErrNoAnswers
 	}
 	errMsg := answer.GetError()
 	if errMsg != "" {
-		return nil, errors.New(errMsg)
+		return CommitResult{}, errors.New(errMsg)
 	}
-	return answer.GetData(), nil
+	return CommitResult{StateHash: answer.GetData()}, nil
 }
 
 // GetSnapshot implements AppProxy interface method
@@ -191,17 +233,23 @@ func (p *GrpcAppProxy) GetSnapshot(blockIndex int64) ([]byte, error) {
 	return answer.GetData(), nil
 }
 
+// Query implements AppProxy interface method. The protocol does not carry
+// queries yet.
+func (p *GrpcAppProxy) Query(query []byte) ([]byte, error) {
+	return nil, ErrNoQuery
+}
+
 // Restore implements AppProxy interface method
-func (p *GrpcAppProxy) Restore(snapshot []byte) error {
+func (p *GrpcAppProxy) Restore(snapshot []byte) ([]byte, error) {
 	answer, ok := <-p.pushRestore(snapshot)
 	if !ok {
-		return ErrNoAnswers
+		return nil, ErrNoAnswers
 	}
 	errMsg := answer.GetError()
 	if errMsg != "" {
-		return errors.New(errMsg)
+		return nil, errors.New(errMsg)
 	}
-	return nil
+	return answer.GetData(), nil
 }
 
 /*
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"io"
 	"math"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/xid"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy/internal"
@@ -122,7 +124,7 @@ func (p *GrpcLachesisProxy) sendToServer(data *internal.ToServer) (err error) {
 		p.logger.Warnf("send to server err: %s", err)
 
 		err = p.reConnect()
-		if err == ErrConnShutdown {
+		if err == ErrConnShutdown || IsVersionError(err) {
 			return
 		}
 	}
@@ -137,7 +139,7 @@ func (p *GrpcLachesisProxy) recvFromServer() (data *internal.ToClient, err error
 		p.logger.Warnf("recv from server err: %s", err)
 
 		err = p.reConnect()
-		if err == ErrConnShutdown {
+		if err == ErrConnShutdown || IsVersionError(err) {
 			return
 		}
 	}
@@ -174,12 +176,20 @@ func (p *GrpcLachesisProxy) reConnect() (err error) {
 	}
 
 	var stream internal.LachesisNode_ConnectClient
+	ctx := metadata.AppendToOutgoingContext(context.TODO(), grpcVersionKey, strconv.Itoa(GrpcProtocolVersion))
 	stream, err = p.client.Connect(
-		context.TODO(),
+		ctx,
 		grpc.MaxCallRecvMsgSize(math.MaxInt32),
 		grpc.MaxCallSendMsgSize(math.MaxInt32))
+	if err == nil {
+		err = checkGrpcVersion(stream)
+	}
 	if err != nil {
-		p.logger.Warnf("rpc Connect() err: %s", err)
+		if IsVersionError(err) {
+			p.logger.WithError(err).Error("rpc Connect() refused")
+		} else {
+			p.logger.Warnf("rpc Connect() err: %s", err)
+		}
 		p.reconnectTicket <- connectTime
 		return
 	}
@@ -189,6 +199,20 @@ func (p *GrpcLachesisProxy) reConnect() (err error) {
 	return
 }
 
+// checkGrpcVersion checks the protocol version in the headers the node
+// sends first
+func checkGrpcVersion(stream internal.LachesisNode_ConnectClient) error {
+	header, err := stream.Header()
+	if err != nil {
+		return err
+	}
+	version := grpcVersion(header)
+	if version != GrpcProtocolVersion {
+		return &VersionError{Protocol: "grpc", Node: version, App: GrpcProtocolVersion}
+	}
+	return nil
+}
+
 func (p *GrpcLachesisProxy) listenEvents() {
 	var (
 		event *internal.ToClient
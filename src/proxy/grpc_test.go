@@ -1,13 +1,21 @@
 package proxy
 
 import (
+	"context"
+	"net"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/Fantom-foundation/go-lachesis/src/common"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy/internal"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy/proto"
 	"github.com/Fantom-foundation/go-lachesis/src/utils"
 )
@@ -64,7 +72,7 @@ func TestGrpcCalls(t *testing.T) {
 
 		answ, err := s.CommitBlock(block)
 		if assertO.NoError(err) {
-			assertO.Equal(gold, answ)
+			assertO.Equal(gold, answ.StateHash)
 		}
 	})
 
@@ -109,8 +117,10 @@ func TestGrpcCalls(t *testing.T) {
 			}
 		}()
 
-		err := s.Restore(gold)
-		assertO.NoError(err)
+		stateHash, err := s.Restore(gold)
+		if assertO.NoError(err) {
+			assertO.Equal(gold, stateHash)
+		}
 	})
 
 	err = c.Close()
@@ -169,6 +179,68 @@ func TestGrpcReConnection(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// otherVersionNode is a gRPC node speaking another protocol version
+type otherVersionNode struct{}
+
+func (otherVersionNode) Connect(stream internal.LachesisNode_ConnectServer) error {
+	header := metadata.Pairs(grpcVersionKey, strconv.Itoa(GrpcProtocolVersion+1))
+	if err := stream.SendHeader(header); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestGrpcVersion(t *testing.T) {
+	const timeout = 1 * time.Second
+	addr := utils.GetUnusedNetAddr(2, t)
+	logger := common.NewTestLogger(t)
+
+	t.Run("node refuses", func(t *testing.T) {
+		assertO := assert.New(t)
+		s, err := NewGrpcAppProxy(addr[0], timeout, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		conn, err := grpc.Dial(addr[0], grpc.WithInsecure())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		ctx := metadata.AppendToOutgoingContext(context.Background(), grpcVersionKey, strconv.Itoa(GrpcProtocolVersion+1))
+		stream, err := internal.NewLachesisNodeClient(conn).Connect(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = stream.Recv()
+		assertO.Equal(codes.FailedPrecondition, status.Code(err))
+		assertO.Contains(status.Convert(err).Message(), "incompatible grpc proxy protocol")
+	})
+
+	t.Run("app refuses", func(t *testing.T) {
+		assertO := assert.New(t)
+		listener, err := net.Listen("tcp", addr[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := grpc.NewServer()
+		internal.RegisterLachesisNodeServer(server, otherVersionNode{})
+		go server.Serve(listener)
+		defer server.Stop()
+
+		c, err := NewGrpcLachesisProxy(addr[1], logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		err = c.SubmitTx([]byte("tx"))
+		assertO.Equal(&VersionError{Protocol: "grpc", Node: GrpcProtocolVersion + 1, App: GrpcProtocolVersion}, err)
+	})
+}
+
 /*
 func TestGrpcMaxMsgSize(t *testing.T) {
 	const (
@@ -230,7 +302,7 @@ func TestGrpcMaxMsgSize(t *testing.T) {
 
 		answer, err := s.CommitBlock(block)
 		if assert.NoError(err) {
-			assert.Equal(hash, answer)
+			assert.Equal(hash, answer.StateHash)
 		}
 	})
 
@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"io"
+
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
 )
 
@@ -26,3 +28,51 @@ type ProxyHandler interface {
 	//state
 	RestoreHandler(snapshot []byte) (stateHash []byte, err error)
 }
+
+// CommitReceiptsHandler is optionally implemented by a ProxyHandler which
+// reports the result of each transaction it applies. It is then called
+// instead of CommitHandler.
+type CommitReceiptsHandler interface {
+	//CommitReceiptsHandler is called when Lachesis commits a block. It returns
+	//the state hash and one receipt per transaction of the block
+	CommitReceiptsHandler(block poset.Block) (CommitResult, error)
+}
+
+// QueryHandler is optionally implemented by a ProxyHandler which answers
+// queries, see AppProxy.Query
+type QueryHandler interface {
+	//QueryHandler is called with the raw query and returns the raw response
+	QueryHandler(query []byte) (response []byte, err error)
+}
+
+// CheckTxHandler is optionally implemented by a ProxyHandler to validate the
+// transactions submitted to Lachesis, see TxChecker
+type CheckTxHandler interface {
+	//CheckTxHandler returns an error if the transaction is invalid and should
+	//not be added to the transaction pool
+	CheckTxHandler(tx []byte) error
+}
+
+// TxPriorityHandler is optionally implemented by a ProxyHandler to rank
+// the transactions submitted to Lachesis, see TxPrioritizer
+type TxPriorityHandler interface {
+	//TxPriorityHandler returns the priority of the transaction, 0 by default
+	TxPriorityHandler(tx []byte) int
+}
+
+// RestoreStreamHandler is optionally implemented by a ProxyHandler which
+// restores its state from a reader, see StreamRestorer. It is then called
+// instead of RestoreHandler for the snapshots fetched in chunks.
+type RestoreStreamHandler interface {
+	//RestoreStreamHandler restores the application from the snapshot read
+	//from r and returns the state hash
+	RestoreStreamHandler(r io.Reader) (stateHash []byte, err error)
+}
+
+// NodeEventHandler is optionally implemented by a ProxyHandler which reacts
+// to the events of the node, see NodeEventSubscriber
+type NodeEventHandler interface {
+	//NodeEventHandler is called with the events of the node, in order, from
+	//a goroutine of its own
+	NodeEventHandler(event NodeEvent)
+}
@@ -1,6 +1,9 @@
 package proxy
 
 import (
+	"io"
+	"io/ioutil"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/Fantom-foundation/go-lachesis/src/peers"
@@ -12,7 +15,9 @@ type InmemAppProxy struct {
 	logger           *logrus.Logger
 	handler          ProxyHandler
 	submitCh         chan []byte
+	keyedSubmitCh    chan KeyedTx
 	submitInternalCh chan poset.InternalTransaction
+	nodeEventCh      chan NodeEvent
 }
 
 // NewInmemAppProxy instantiates an InmemProxy from a set of handlers
@@ -22,12 +27,22 @@ func NewInmemAppProxy(handler ProxyHandler, logger *logrus.Logger) *InmemAppProx
 		logger.Level = logrus.DebugLevel
 	}
 
-	return &InmemAppProxy{
+	p := &InmemAppProxy{
 		logger:           logger,
 		handler:          handler,
 		submitCh:         make(chan []byte),
+		keyedSubmitCh:    make(chan KeyedTx),
 		submitInternalCh: make(chan poset.InternalTransaction),
 	}
+	if eventHandler, ok := handler.(NodeEventHandler); ok {
+		p.nodeEventCh = make(chan NodeEvent, nodeEventBuffer)
+		go func() {
+			for event := range p.nodeEventCh {
+				eventHandler.NodeEventHandler(event)
+			}
+		}()
+	}
+	return p
 }
 
 /*
@@ -39,6 +54,11 @@ func (p *InmemAppProxy) SubmitCh() chan []byte {
 	return p.submitCh
 }
 
+// KeyedSubmitCh implements KeyedSubmitter
+func (p *InmemAppProxy) KeyedSubmitCh() chan KeyedTx {
+	return p.keyedSubmitCh
+}
+
 // ProposePeerAdd propose to add a peer to the rest of the network
 func (p *InmemAppProxy) ProposePeerAdd(peer peers.Peer) {
 	p.submitInternalCh <- poset.NewInternalTransaction(poset.TransactionType_PEER_ADD, peer)
@@ -55,15 +75,22 @@ func (p *InmemAppProxy) SubmitInternalCh() chan poset.InternalTransaction {
 }
 
 // CommitBlock implements AppProxy interface method, calls handler
-func (p *InmemAppProxy) CommitBlock(block poset.Block) ([]byte, error) {
-	stateHash, err := p.handler.CommitHandler(block)
+func (p *InmemAppProxy) CommitBlock(block poset.Block) (CommitResult, error) {
+	var res CommitResult
+	var err error
+	if handler, ok := p.handler.(CommitReceiptsHandler); ok {
+		res, err = handler.CommitReceiptsHandler(block)
+	} else {
+		res.StateHash, err = p.handler.CommitHandler(block)
+	}
 	p.logger.WithFields(logrus.Fields{
 		"round_received": block.RoundReceived(),
 		"txs":            len(block.Transactions()),
-		"state_hash":     stateHash,
+		"receipts":       len(res.Receipts),
+		"state_hash":     res.StateHash,
 		"err":            err,
 	}).Debug("InmemAppProxy.CommitBlock")
-	return stateHash, err
+	return res, err
 }
 
 // GetSnapshot implements AppProxy interface method, calls handler
@@ -78,19 +105,73 @@ func (p *InmemAppProxy) GetSnapshot(blockIndex int64) ([]byte, error) {
 }
 
 // Restore implements AppProxy interface method, calls handler
-func (p *InmemAppProxy) Restore(snapshot []byte) error {
+func (p *InmemAppProxy) Restore(snapshot []byte) ([]byte, error) {
 	stateHash, err := p.handler.RestoreHandler(snapshot)
 	p.logger.WithFields(logrus.Fields{
 		"state_hash": stateHash,
 		"err":        err,
 	}).Debug("InmemAppProxy.Restore")
-	return err
+	return stateHash, err
+}
+
+// RestoreFrom implements StreamRestorer, calls the handler if it is a
+// RestoreStreamHandler, reads the whole snapshot for RestoreHandler otherwise
+func (p *InmemAppProxy) RestoreFrom(r io.Reader) ([]byte, error) {
+	handler, ok := p.handler.(RestoreStreamHandler)
+	if !ok {
+		snapshot, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return p.Restore(snapshot)
+	}
+	stateHash, err := handler.RestoreStreamHandler(r)
+	p.logger.WithFields(logrus.Fields{
+		"state_hash": stateHash,
+		"err":        err,
+	}).Debug("InmemAppProxy.RestoreFrom")
+	return stateHash, err
+}
+
+// Query implements AppProxy interface method, calls the handler if it is a
+// QueryHandler
+func (p *InmemAppProxy) Query(query []byte) ([]byte, error) {
+	handler, ok := p.handler.(QueryHandler)
+	if !ok {
+		return nil, ErrNoQuery
+	}
+	return handler.QueryHandler(query)
+}
+
+// CheckTx implements TxChecker, calls the handler if it is a CheckTxHandler
+func (p *InmemAppProxy) CheckTx(tx []byte) error {
+	checker, ok := p.handler.(CheckTxHandler)
+	if !ok {
+		return nil
+	}
+	return checker.CheckTxHandler(tx)
+}
+
+// NodeEventCh implements NodeEventSubscriber. It is nil unless the handler
+// is a NodeEventHandler.
+func (p *InmemAppProxy) NodeEventCh() chan<- NodeEvent {
+	return p.nodeEventCh
 }
 
 /*
  * staff:
  */
 
+// TxPriority implements TxPrioritizer, calls the handler if it is a
+// TxPriorityHandler
+func (p *InmemAppProxy) TxPriority(tx []byte) int {
+	prioritizer, ok := p.handler.(TxPriorityHandler)
+	if !ok {
+		return 0
+	}
+	return prioritizer.TxPriorityHandler(tx)
+}
+
 // SubmitTx is called by the App to submit a transaction to Lachesis
 func (p *InmemAppProxy) SubmitTx(tx []byte) {
 	//have to make a copy, or the tx will be garbage collected and weird stuff
@@ -99,3 +180,10 @@ func (p *InmemAppProxy) SubmitTx(tx []byte) {
 	copy(t, tx)
 	p.submitCh <- t
 }
+
+// SubmitKeyedTx is SubmitTx with an idempotency key, see KeyedTx
+func (p *InmemAppProxy) SubmitKeyedTx(key string, tx []byte) {
+	t := make([]byte, len(tx))
+	copy(t, tx)
+	p.keyedSubmitCh <- KeyedTx{Key: key, Tx: t}
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
@@ -46,9 +47,9 @@ func TestInmemAppCalls(t *testing.T) {
 	t.Run("#2 Commit block", func(t *testing.T) {
 		assertO := assert.New(t)
 
-		stateHash, err := proxy.CommitBlock(block)
+		res, err := proxy.CommitBlock(block)
 		if assertO.NoError(err) {
-			assertO.EqualValues(goldStateHash(), stateHash)
+			assertO.EqualValues(goldStateHash(), res.StateHash)
 			assertO.EqualValues(transactions, proxy.transactions)
 		}
 	})
@@ -62,11 +63,27 @@ func TestInmemAppCalls(t *testing.T) {
 		}
 	})
 
-	t.Run("#4 Restore snapshot", func(t *testing.T) {
+	t.Run("#4 Query", func(t *testing.T) {
+		_, err := proxy.Query([]byte("state"))
+		assert.Equal(t, ErrNoQuery, err)
+	})
+
+	t.Run("#5 Restore snapshot", func(t *testing.T) {
 		assertO := assert.New(t)
 
-		err := proxy.Restore(goldSnapshot())
-		assertO.NoError(err)
+		stateHash, err := proxy.Restore(goldSnapshot())
+		if assertO.NoError(err) {
+			assertO.EqualValues(goldStateHash(), stateHash)
+		}
+	})
+
+	t.Run("#6 Restore snapshot from a reader", func(t *testing.T) {
+		assertO := assert.New(t)
+
+		stateHash, err := proxy.RestoreFrom(bytes.NewReader(goldSnapshot()))
+		if assertO.NoError(err) {
+			assertO.EqualValues(goldStateHash(), stateHash)
+		}
 	})
 }
 
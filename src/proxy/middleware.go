@@ -0,0 +1,299 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// Middleware wraps an AppProxy to add a cross-cutting concern, such as
+// metrics, logging, transaction filtering or rate limiting, whatever the
+// application. Middlewares embed Wrapper and override the calls they
+// intercept.
+type Middleware func(AppProxy) AppProxy
+
+// Chain wraps p with the middlewares. The first one is the outermost, the
+// first to see the calls of the node.
+func Chain(p AppProxy, middlewares ...Middleware) AppProxy {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		p = middlewares[i](p)
+	}
+	return p
+}
+
+// Wrapper is an AppProxy which forwards every call to the wrapped one,
+// including CheckTx, TxPriority, NodeEventCh, KeyedSubmitCh and RestoreFrom
+// when it is a TxChecker, a TxPrioritizer, a NodeEventSubscriber, a
+// KeyedSubmitter or a StreamRestorer
+type Wrapper struct {
+	AppProxy
+}
+
+// CheckTx implements TxChecker
+func (w Wrapper) CheckTx(tx []byte) error {
+	if checker, ok := w.AppProxy.(TxChecker); ok {
+		return checker.CheckTx(tx)
+	}
+	return nil
+}
+
+// TxPriority implements TxPrioritizer
+func (w Wrapper) TxPriority(tx []byte) int {
+	if prioritizer, ok := w.AppProxy.(TxPrioritizer); ok {
+		return prioritizer.TxPriority(tx)
+	}
+	return 0
+}
+
+// NodeEventCh implements NodeEventSubscriber
+func (w Wrapper) NodeEventCh() chan<- NodeEvent {
+	if subscriber, ok := w.AppProxy.(NodeEventSubscriber); ok {
+		return subscriber.NodeEventCh()
+	}
+	return nil
+}
+
+// KeyedSubmitCh implements KeyedSubmitter
+func (w Wrapper) KeyedSubmitCh() chan KeyedTx {
+	if submitter, ok := w.AppProxy.(KeyedSubmitter); ok {
+		return submitter.KeyedSubmitCh()
+	}
+	return nil
+}
+
+// RestoreFrom implements StreamRestorer, it reads the whole snapshot for
+// Restore when the wrapped AppProxy is not a StreamRestorer
+func (w Wrapper) RestoreFrom(r io.Reader) ([]byte, error) {
+	if restorer, ok := w.AppProxy.(StreamRestorer); ok {
+		return restorer.RestoreFrom(r)
+	}
+	snapshot, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return w.AppProxy.Restore(snapshot)
+}
+
+// The metrics of the Metrics middleware, served by the /metrics endpoint of
+// the service
+var (
+	proxyCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "lachesis",
+		Subsystem: "proxy",
+		Name:      "call_duration_seconds",
+		Help:      "Duration of the calls to the application",
+		// 100µs to 100s
+		Buckets: prometheus.ExponentialBuckets(1e-4, 4, 11),
+	}, []string{"call", "result"})
+
+	proxyTransactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lachesis",
+		Subsystem: "proxy",
+		Name:      "transactions_total",
+		Help:      "Transactions submitted by the application, accepted or rejected",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(proxyCallDuration, proxyTransactions)
+}
+
+func callResult(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+type metricsProxy struct {
+	Wrapper
+}
+
+// Metrics records the duration and the outcome of the calls to the
+// application, and counts the transactions it submits
+func Metrics() Middleware {
+	return func(p AppProxy) AppProxy {
+		return &metricsProxy{Wrapper{p}}
+	}
+}
+
+func observeCall(call string, start time.Time, err error) {
+	proxyCallDuration.WithLabelValues(call, callResult(err)).Observe(time.Since(start).Seconds())
+}
+
+func (p *metricsProxy) CheckTx(tx []byte) error {
+	err := p.Wrapper.CheckTx(tx)
+	if err != nil {
+		proxyTransactions.WithLabelValues("rejected").Inc()
+	} else {
+		proxyTransactions.WithLabelValues("accepted").Inc()
+	}
+	return err
+}
+
+func (p *metricsProxy) CommitBlock(block poset.Block) (res CommitResult, err error) {
+	defer func(start time.Time) { observeCall("CommitBlock", start, err) }(time.Now())
+	return p.AppProxy.CommitBlock(block)
+}
+
+func (p *metricsProxy) GetSnapshot(blockIndex int64) (snapshot []byte, err error) {
+	defer func(start time.Time) { observeCall("GetSnapshot", start, err) }(time.Now())
+	return p.AppProxy.GetSnapshot(blockIndex)
+}
+
+func (p *metricsProxy) Restore(snapshot []byte) (stateHash []byte, err error) {
+	defer func(start time.Time) { observeCall("Restore", start, err) }(time.Now())
+	return p.AppProxy.Restore(snapshot)
+}
+
+func (p *metricsProxy) RestoreFrom(r io.Reader) (stateHash []byte, err error) {
+	defer func(start time.Time) { observeCall("Restore", start, err) }(time.Now())
+	return p.Wrapper.RestoreFrom(r)
+}
+
+func (p *metricsProxy) Query(query []byte) (response []byte, err error) {
+	defer func(start time.Time) { observeCall("Query", start, err) }(time.Now())
+	return p.AppProxy.Query(query)
+}
+
+type loggingProxy struct {
+	Wrapper
+	logger *logrus.Entry
+}
+
+// Logging logs the calls to the application with their duration, at debug
+// level, or at error level when they fail
+func Logging(logger *logrus.Logger) Middleware {
+	return func(p AppProxy) AppProxy {
+		return &loggingProxy{Wrapper{p}, logger.WithField("component", "proxy")}
+	}
+}
+
+func (p *loggingProxy) log(call string, start time.Time, err error, fields logrus.Fields) {
+	entry := p.logger.WithFields(fields).WithField("duration", time.Since(start))
+	if err != nil {
+		entry.WithError(err).Error(call)
+		return
+	}
+	entry.Debug(call)
+}
+
+func (p *loggingProxy) CommitBlock(block poset.Block) (res CommitResult, err error) {
+	defer func(start time.Time) {
+		p.log("CommitBlock", start, err, logrus.Fields{
+			"block": block.Index(),
+			"txs":   len(block.Transactions()),
+		})
+	}(time.Now())
+	return p.AppProxy.CommitBlock(block)
+}
+
+func (p *loggingProxy) GetSnapshot(blockIndex int64) (snapshot []byte, err error) {
+	defer func(start time.Time) {
+		p.log("GetSnapshot", start, err, logrus.Fields{"block": blockIndex})
+	}(time.Now())
+	return p.AppProxy.GetSnapshot(blockIndex)
+}
+
+func (p *loggingProxy) Restore(snapshot []byte) (stateHash []byte, err error) {
+	defer func(start time.Time) {
+		p.log("Restore", start, err, logrus.Fields{"size": len(snapshot)})
+	}(time.Now())
+	return p.AppProxy.Restore(snapshot)
+}
+
+func (p *loggingProxy) RestoreFrom(r io.Reader) (stateHash []byte, err error) {
+	defer func(start time.Time) {
+		p.log("RestoreFrom", start, err, nil)
+	}(time.Now())
+	return p.Wrapper.RestoreFrom(r)
+}
+
+func (p *loggingProxy) Query(query []byte) (response []byte, err error) {
+	defer func(start time.Time) {
+		if err == ErrNoQuery {
+			err = nil
+		}
+		p.log("Query", start, err, logrus.Fields{"size": len(query)})
+	}(time.Now())
+	return p.AppProxy.Query(query)
+}
+
+type filterProxy struct {
+	Wrapper
+	filter func(tx []byte) error
+}
+
+// TxFilter rejects the submitted transactions for which filter returns an
+// error, before the application checks them
+func TxFilter(filter func(tx []byte) error) Middleware {
+	return func(p AppProxy) AppProxy {
+		return &filterProxy{Wrapper{p}, filter}
+	}
+}
+
+func (p *filterProxy) CheckTx(tx []byte) error {
+	if err := p.filter(tx); err != nil {
+		return err
+	}
+	return p.Wrapper.CheckTx(tx)
+}
+
+// ErrRateLimited rejects the transactions submitted past the rate of the
+// RateLimit middleware
+var ErrRateLimited = errors.New("transaction rate limit exceeded")
+
+type rateLimitProxy struct {
+	Wrapper
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// RateLimit rejects the transactions the application submits past rate
+// per second, allowing bursts of burst transactions
+func RateLimit(rate float64, burst int) Middleware {
+	return func(p AppProxy) AppProxy {
+		return &rateLimitProxy{
+			Wrapper: Wrapper{p},
+			rate:    rate,
+			burst:   float64(burst),
+			tokens:  float64(burst),
+			last:    time.Now(),
+		}
+	}
+}
+
+func (p *rateLimitProxy) CheckTx(tx []byte) error {
+	if !p.take() {
+		return ErrRateLimited
+	}
+	return p.Wrapper.CheckTx(tx)
+}
+
+// take removes a token from the bucket, which refills at rate
+func (p *rateLimitProxy) take() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	p.tokens += now.Sub(p.last).Seconds() * p.rate
+	if p.tokens > p.burst {
+		p.tokens = p.burst
+	}
+	p.last = now
+	if p.tokens < 1 {
+		return false
+	}
+	p.tokens--
+	return true
+}
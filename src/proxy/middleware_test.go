@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestMiddleware(t *testing.T) {
+	assertO := assert.New(t)
+	logger := common.NewTestLogger(t)
+
+	var order []string
+	trace := func(name string) Middleware {
+		return TxFilter(func(tx []byte) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	errTooLong := errors.New("too long")
+
+	p := Chain(NewABCIAppProxy(&counterApp{}, logger),
+		trace("outer"),
+		Metrics(),
+		Logging(logger),
+		RateLimit(0, 2),
+		TxFilter(func(tx []byte) error {
+			if len(tx) > 8 {
+				return errTooLong
+			}
+			return nil
+		}),
+		trace("inner"),
+	)
+	checker, ok := p.(TxChecker)
+	if !assertO.True(ok) {
+		return
+	}
+
+	// the filter, then the app, reject
+	assertO.Equal(errTooLong, checker.CheckTx([]byte("123456789")))
+	assertO.Error(checker.CheckTx([]byte("1234")))
+	assertO.Equal([]string{"outer", "outer", "inner"}, order)
+
+	// the burst is spent, the rate is 0
+	assertO.Equal(ErrRateLimited, checker.CheckTx(counterTx(0)))
+
+	// the other calls go through
+	res, err := p.CommitBlock(poset.NewBlock(0, 1, []byte{}, [][]byte{counterTx(0)}))
+	if assertO.NoError(err) {
+		assertO.Equal(counterTx(1), res.StateHash)
+	}
+	count, err := p.Query([]byte("count"))
+	if assertO.NoError(err) {
+		assertO.Equal(counterTx(1), count)
+	}
+}
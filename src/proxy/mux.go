@@ -0,0 +1,308 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/crypto"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// CodeNoNamespace is the code of the receipts of the transactions which
+// start with no registered namespace. The apps must not use it.
+const CodeNoNamespace uint32 = math.MaxUint32 - 1
+
+// MuxAppProxy serves several applications with one node. Each application
+// is registered with a namespace, and receives the committed transactions
+// which start with it, without it. The transactions it submits are
+// prefixed with it.
+//
+// Every application receives every block, with its transactions only, so
+// that their block indexes stay the same. The state hash of a block is the
+// hash of the namespaces and state hashes of the applications, in namespace
+// order; snapshots hold the snapshot of every application.
+type MuxAppProxy struct {
+	logger           *logrus.Logger
+	submitCh         chan []byte
+	submitInternalCh chan poset.InternalTransaction
+	nodeEventCh      chan NodeEvent
+
+	lock sync.RWMutex
+	apps map[string]AppProxy
+	// namespaces are sorted
+	namespaces []string
+}
+
+// NewMuxAppProxy creates a MuxAppProxy with no application
+func NewMuxAppProxy(logger *logrus.Logger) *MuxAppProxy {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	p := &MuxAppProxy{
+		logger:           logger,
+		submitCh:         make(chan []byte),
+		submitInternalCh: make(chan poset.InternalTransaction),
+		nodeEventCh:      make(chan NodeEvent, nodeEventBuffer),
+		apps:             make(map[string]AppProxy),
+	}
+	go p.dispatchNodeEvents()
+	return p
+}
+
+// Register serves app the transactions starting with namespace. It must be
+// called before the node starts; a namespace may not be the prefix of
+// another one.
+func (p *MuxAppProxy) Register(namespace string, app AppProxy) error {
+	if namespace == "" {
+		return fmt.Errorf("empty namespace")
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, other := range p.namespaces {
+		if strings.HasPrefix(namespace, other) || strings.HasPrefix(other, namespace) {
+			return fmt.Errorf("namespace %q overlaps namespace %q", namespace, other)
+		}
+	}
+	p.apps[namespace] = app
+	p.namespaces = append(p.namespaces, namespace)
+	sort.Strings(p.namespaces)
+
+	go p.forwardTxs(namespace, app)
+	go func() {
+		for tx := range app.SubmitInternalCh() {
+			p.submitInternalCh <- tx
+		}
+	}()
+	return nil
+}
+
+// forwardTxs submits the transactions of app, prefixed with namespace
+func (p *MuxAppProxy) forwardTxs(namespace string, app AppProxy) {
+	for tx := range app.SubmitCh() {
+		p.submitCh <- append([]byte(namespace), tx...)
+	}
+}
+
+// dispatchNodeEvents sends the node events to the applications which
+// subscribe to them, without blocking on any
+func (p *MuxAppProxy) dispatchNodeEvents() {
+	for event := range p.nodeEventCh {
+		p.lock.RLock()
+		for _, app := range p.apps {
+			subscriber, ok := app.(NodeEventSubscriber)
+			if !ok {
+				continue
+			}
+			if ch := subscriber.NodeEventCh(); ch != nil {
+				select {
+				case ch <- event:
+				default:
+				}
+			}
+		}
+		p.lock.RUnlock()
+	}
+}
+
+// route returns the namespace tx starts with, false if none
+func (p *MuxAppProxy) route(tx []byte) (string, bool) {
+	for _, namespace := range p.namespaces {
+		if bytes.HasPrefix(tx, []byte(namespace)) {
+			return namespace, true
+		}
+	}
+	return "", false
+}
+
+/*
+ * AppProxy implementation
+ */
+
+// SubmitCh implements AppProxy interface method
+func (p *MuxAppProxy) SubmitCh() chan []byte {
+	return p.submitCh
+}
+
+// SubmitInternalCh implements AppProxy interface method
+func (p *MuxAppProxy) SubmitInternalCh() chan poset.InternalTransaction {
+	return p.submitInternalCh
+}
+
+// NodeEventCh implements NodeEventSubscriber
+func (p *MuxAppProxy) NodeEventCh() chan<- NodeEvent {
+	return p.nodeEventCh
+}
+
+// CheckTx implements TxChecker. It refuses the transactions which start
+// with no namespace, and passes the others to their application.
+func (p *MuxAppProxy) CheckTx(tx []byte) error {
+	p.lock.RLock()
+	namespace, ok := p.route(tx)
+	app := p.apps[namespace]
+	p.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("no application for the transaction")
+	}
+	if checker, ok := app.(TxChecker); ok {
+		return checker.CheckTx(tx[len(namespace):])
+	}
+	return nil
+}
+
+// TxPriority implements TxPrioritizer with the priority the application of
+// the transaction gives it
+func (p *MuxAppProxy) TxPriority(tx []byte) int {
+	p.lock.RLock()
+	namespace, ok := p.route(tx)
+	app := p.apps[namespace]
+	p.lock.RUnlock()
+	if !ok {
+		return 0
+	}
+	if prioritizer, ok := app.(TxPrioritizer); ok {
+		return prioritizer.TxPriority(tx[len(namespace):])
+	}
+	return 0
+}
+
+// CommitBlock implements AppProxy interface method. It commits the block to
+// the applications in namespace order, and stops at the first error.
+func (p *MuxAppProxy) CommitBlock(block poset.Block) (CommitResult, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	txs := block.Transactions()
+	receipts := make([]poset.Receipt, len(txs))
+	routed := make(map[string][]int)
+	withReceipts := false
+	for i, tx := range txs {
+		namespace, ok := p.route(tx)
+		if !ok {
+			receipts[i] = poset.Receipt{Code: CodeNoNamespace, Log: "no application for the transaction"}
+			withReceipts = true
+			continue
+		}
+		routed[namespace] = append(routed[namespace], i)
+	}
+
+	var res CommitResult
+	hashes := make(map[string][]byte, len(p.apps))
+	for _, namespace := range p.namespaces {
+		appTxs := make([][]byte, len(routed[namespace]))
+		for j, i := range routed[namespace] {
+			appTxs[j] = txs[i][len(namespace):]
+		}
+		appRes, err := p.apps[namespace].CommitBlock(subBlock(block, appTxs))
+		if err != nil {
+			return CommitResult{}, fmt.Errorf("%s: %v", namespace, err)
+		}
+		hashes[namespace] = appRes.StateHash
+		if len(appRes.Receipts) == len(appTxs) {
+			for j, i := range routed[namespace] {
+				receipts[i] = appRes.Receipts[j]
+			}
+			withReceipts = withReceipts || appRes.Receipts != nil
+		}
+		res.ValidatorUpdates = append(res.ValidatorUpdates, appRes.ValidatorUpdates...)
+	}
+	res.StateHash = p.stateHash(hashes)
+	if withReceipts {
+		res.Receipts = receipts
+	}
+	return res, nil
+}
+
+// GetSnapshot implements AppProxy interface method. The snapshot is the
+// JSON object of the snapshots of the applications by namespace.
+func (p *MuxAppProxy) GetSnapshot(blockIndex int64) ([]byte, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	snapshots := make(map[string][]byte, len(p.apps))
+	for namespace, app := range p.apps {
+		snapshot, err := app.GetSnapshot(blockIndex)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", namespace, err)
+		}
+		snapshots[namespace] = snapshot
+	}
+	return json.Marshal(snapshots)
+}
+
+// Restore implements AppProxy interface method
+func (p *MuxAppProxy) Restore(snapshot []byte) ([]byte, error) {
+	var snapshots map[string][]byte
+	if err := json.Unmarshal(snapshot, &snapshots); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %v", err)
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	hashes := make(map[string][]byte, len(p.apps))
+	for namespace, app := range p.apps {
+		appSnapshot, ok := snapshots[namespace]
+		if !ok {
+			return nil, fmt.Errorf("no snapshot for %s", namespace)
+		}
+		stateHash, err := app.Restore(appSnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", namespace, err)
+		}
+		hashes[namespace] = stateHash
+	}
+	return p.stateHash(hashes), nil
+}
+
+// Query implements AppProxy interface method. The query starts with the
+// namespace of the application to ask.
+func (p *MuxAppProxy) Query(query []byte) ([]byte, error) {
+	p.lock.RLock()
+	namespace, ok := p.route(query)
+	app := p.apps[namespace]
+	p.lock.RUnlock()
+	if !ok {
+		return nil, ErrNoQuery
+	}
+	return app.Query(query[len(namespace):])
+}
+
+/*
+ * staff:
+ */
+
+// stateHash combines the state hashes of the applications, each one
+// prefixed with its namespace and their lengths
+func (p *MuxAppProxy) stateHash(hashes map[string][]byte) []byte {
+	data := make([][]byte, 0, len(p.namespaces))
+	for _, namespace := range p.namespaces {
+		hash := hashes[namespace]
+		entry := make([]byte, 8, 8+len(namespace)+len(hash))
+		binary.BigEndian.PutUint32(entry, uint32(len(namespace)))
+		binary.BigEndian.PutUint32(entry[4:], uint32(len(hash)))
+		entry = append(entry, namespace...)
+		data = append(data, append(entry, hash...))
+	}
+	return crypto.Keccak256(data...)
+}
+
+// subBlock returns a copy of block with other transactions
+func subBlock(block poset.Block, txs [][]byte) poset.Block {
+	sub := poset.NewBlock(block.Index(), block.RoundReceived(), block.FrameHash, txs)
+	sub.Body.Random = block.Body.Random
+	sub.Body.GenesisHash = block.Body.GenesisHash
+	sub.Body.EventsRoot = block.Body.EventsRoot
+	sub.CreatedTime = block.CreatedTime
+	sub.Signatures = block.Signatures
+	return sub
+}
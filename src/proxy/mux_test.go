@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+func TestMuxAppProxy(t *testing.T) {
+	assertO := assert.New(t)
+	bank, names := NewTestProxy(t), NewTestProxy(t)
+
+	mux := NewMuxAppProxy(common.NewTestLogger(t))
+	assertO.NoError(mux.Register("bank/", bank))
+	assertO.NoError(mux.Register("names/", names))
+	assertO.Error(mux.Register("bank/eu/", NewTestProxy(t)), "overlapping namespace")
+
+	// the transactions go to their application, without the namespace
+	block := poset.NewBlock(0, 1, []byte("framehash"), [][]byte{
+		[]byte("bank/pay"),
+		[]byte("names/claim"),
+		[]byte("other"),
+		[]byte("bank/refund"),
+	})
+	res, err := mux.CommitBlock(block)
+	if assertO.NoError(err) {
+		assertO.Equal([][]byte{[]byte("pay"), []byte("refund")}, bank.transactions)
+		assertO.Equal([][]byte{[]byte("claim")}, names.transactions)
+		assertO.Equal(mux.stateHash(map[string][]byte{"bank/": goldStateHash(), "names/": goldStateHash()}), res.StateHash)
+		if assertO.Len(res.Receipts, 4) {
+			assertO.Equal(CodeNoNamespace, res.Receipts[2].Code)
+			assertO.Equal(CodeTypeOK, res.Receipts[0].Code)
+		}
+	}
+	assertO.Error(mux.CheckTx([]byte("other")))
+	assertO.NoError(mux.CheckTx([]byte("names/claim")))
+
+	// the snapshot holds the snapshots of both applications
+	snapshot, err := mux.GetSnapshot(0)
+	if assertO.NoError(err) {
+		var snapshots map[string][]byte
+		assertO.NoError(json.Unmarshal(snapshot, &snapshots))
+		assertO.Equal(map[string][]byte{"bank/": goldSnapshot(), "names/": goldSnapshot()}, snapshots)
+		stateHash, err := mux.Restore(snapshot)
+		if assertO.NoError(err) {
+			assertO.Equal(res.StateHash, stateHash)
+		}
+	}
+
+	// the submitted transactions get the namespace of their application
+	go names.SubmitTx([]byte("claim"))
+	select {
+	case tx := <-mux.SubmitCh():
+		assertO.Equal([]byte("names/claim"), tx)
+	case <-time.After(time.Second):
+		assertO.Fail("time is over")
+	}
+}
@@ -1,6 +1,10 @@
 package proxy
 
 import (
+	"errors"
+	"io"
+
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
 	"github.com/Fantom-foundation/go-lachesis/src/proxy/proto"
 )
@@ -10,9 +14,92 @@ import (
 type AppProxy interface {
 	SubmitCh() chan []byte
 	SubmitInternalCh() chan poset.InternalTransaction
-	CommitBlock(block poset.Block) ([]byte, error)
+	CommitBlock(block poset.Block) (CommitResult, error)
 	GetSnapshot(blockIndex int64) ([]byte, error)
-	Restore(snapshot []byte) error
+	Restore(snapshot []byte) (stateHash []byte, err error)
+	// Query reads the state of the application, the meaning of the query
+	// and the response is up to it. It returns ErrNoQuery if the
+	// application does not answer queries.
+	Query(query []byte) ([]byte, error)
+}
+
+// ErrNoQuery is returned by AppProxies whose application does not answer
+// queries
+var ErrNoQuery = errors.New("application does not answer queries")
+
+// IsDisconnected tells whether err is returned by a call which failed
+// because the application is not connected to its proxy, as opposed to an
+// error of the application
+func IsDisconnected(err error) bool {
+	return err == ErrSocketDisconnected || err == ErrNoAnswers
+}
+
+// CommitResult is the answer of the application to CommitBlock
+type CommitResult struct {
+	// StateHash is the hash of the state after applying the block
+	StateHash []byte
+	// Receipts are the results of the transactions of the block, in order,
+	// if the application reports them
+	Receipts []poset.Receipt
+	// ValidatorUpdates change the validator set, like those of ABCI's
+	// EndBlock. The node submits them as internal transactions.
+	ValidatorUpdates []ValidatorUpdate `json:",omitempty"`
+}
+
+// ValidatorUpdate adds a validator, changes its stake, or removes it when
+// Power is 0
+type ValidatorUpdate struct {
+	PubKeyHex string
+	NetAddr   string
+	Power     uint64
+}
+
+// InternalTransaction returns the internal transaction which carries the
+// update: a PEER_REMOVE when Power is 0, a PEER_ADD whose Amount is the
+// stake otherwise
+func (u ValidatorUpdate) InternalTransaction() poset.InternalTransaction {
+	peer := peers.NewPeer(u.PubKeyHex, u.NetAddr)
+	if u.Power == 0 {
+		return poset.NewInternalTransaction(poset.TransactionType_PEER_REMOVE, *peer)
+	}
+	tx := poset.NewInternalTransaction(poset.TransactionType_PEER_ADD, *peer)
+	tx.Amount = u.Power
+	return tx
+}
+
+// TxChecker is implemented by AppProxies whose application validates
+// transactions before they enter the transaction pool, like ABCI's CheckTx.
+// Rejected transactions are dropped and never gossiped.
+type TxChecker interface {
+	CheckTx(tx []byte) error
+}
+
+// TxPrioritizer is implemented by AppProxies whose application ranks the
+// transactions it submits: those of a higher priority are put in events
+// first, and evicted from a full pool last
+type TxPrioritizer interface {
+	TxPriority(tx []byte) int
+}
+
+// StreamRestorer is implemented by AppProxies which restore the application
+// from a snapshot read from r, so that the chunks of a snapshot fetched
+// during fast sync need not be held in memory all at once
+type StreamRestorer interface {
+	RestoreFrom(r io.Reader) (stateHash []byte, err error)
+}
+
+// KeyedTx is a transaction submitted with an idempotency key: the node
+// drops the submissions of a key it already took within its window, so that
+// an app which retries after a timeout does not get it committed twice
+type KeyedTx struct {
+	Key string
+	Tx  []byte
+}
+
+// KeyedSubmitter is implemented by AppProxies whose application may submit
+// transactions with an idempotency key
+type KeyedSubmitter interface {
+	KeyedSubmitCh() chan KeyedTx
 }
 
 // LachesisProxy provides an interface for the application to
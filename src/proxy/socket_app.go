@@ -0,0 +1,279 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// SocketAppProxy implements the AppProxy interface for an app running in
+// another process, connected over a unix or TCP socket. The app connects
+// with a SocketLachesisProxy; a new connection replaces the previous one.
+type SocketAppProxy struct {
+	logger    *logrus.Logger
+	listener  net.Listener
+	timeout   time.Duration
+	heartbeat time.Duration
+
+	slot     *socketSlot
+	shutdown chan struct{}
+	// wg tracks the goroutines serving connections, none is started once
+	// closing is set
+	wg      sync.WaitGroup
+	wgLock  sync.Mutex
+	closing bool
+
+	submitCh         chan []byte
+	keyedSubmitCh    chan KeyedTx
+	submitInternalCh chan poset.InternalTransaction
+	nodeEventCh      chan NodeEvent
+}
+
+// NewSocketAppProxy listens for the app on network ("unix" or "tcp") and
+// addr. Calls to the app wait at most timeout for it to be connected and
+// then to answer. The connection is dropped when the app sends nothing,
+// not even the pings it sends every heartbeat, for 3 heartbeats.
+func NewSocketAppProxy(network, addr string, timeout, heartbeat time.Duration, logger *logrus.Logger) (*SocketAppProxy, error) {
+	listener, err := ListenSocket(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	p := newSocketAppProxy(timeout, heartbeat, logger)
+	p.listener = listener
+	p.track()
+	go p.accept()
+	return p, nil
+}
+
+func newSocketAppProxy(timeout, heartbeat time.Duration, logger *logrus.Logger) *SocketAppProxy {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	p := &SocketAppProxy{
+		logger:           logger,
+		timeout:          timeout,
+		heartbeat:        heartbeat,
+		slot:             newSocketSlot(),
+		shutdown:         make(chan struct{}),
+		submitCh:         make(chan []byte),
+		keyedSubmitCh:    make(chan KeyedTx),
+		submitInternalCh: make(chan poset.InternalTransaction),
+		nodeEventCh:      make(chan NodeEvent, nodeEventBuffer),
+	}
+	p.track()
+	go p.forwardNodeEvents()
+	return p
+}
+
+// Addr returns the address the proxy listens on
+func (p *SocketAppProxy) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// Close stops listening and drops the app connection
+func (p *SocketAppProxy) Close() error {
+	p.wgLock.Lock()
+	p.closing = true
+	p.wgLock.Unlock()
+
+	close(p.shutdown)
+	var err error
+	if p.listener != nil {
+		err = p.listener.Close()
+	}
+	if conn := p.slot.set(nil); conn != nil {
+		conn.close()
+	}
+	p.wg.Wait()
+	return err
+}
+
+// track counts a new goroutine in wg, unless the proxy is closing
+func (p *SocketAppProxy) track() bool {
+	p.wgLock.Lock()
+	defer p.wgLock.Unlock()
+	if p.closing {
+		return false
+	}
+	p.wg.Add(1)
+	return true
+}
+
+func (p *SocketAppProxy) accept() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			select {
+			case <-p.shutdown:
+				return
+			default:
+			}
+			p.logger.WithError(err).Error("socket proxy accept")
+			time.Sleep(p.heartbeat)
+			continue
+		}
+		if !p.track() {
+			conn.Close()
+			return
+		}
+		go p.serve(newStreamTransport(conn, 3*p.heartbeat), conn.RemoteAddr().String())
+	}
+}
+
+// serve runs the connection of an app until it is lost. The caller tracks
+// it in wg.
+func (p *SocketAppProxy) serve(transport frameTransport, remote string) {
+	defer p.wg.Done()
+	logger := p.logger.WithField("app", remote)
+	if err := socketHandshake(transport, false); err != nil {
+		logger.WithError(err).Error("socket proxy handshake")
+		transport.Close()
+		return
+	}
+
+	conn := newSocketConn(transport, p.heartbeat)
+	if prev := p.slot.set(conn); prev != nil {
+		logger.Warn("app connection replaces the previous one")
+		prev.close()
+	}
+	select {
+	case <-p.shutdown:
+		conn.close()
+	default:
+	}
+	logger.Debug("app connected")
+
+	err := conn.serve(func(f socketFrame) {
+		switch f.Type {
+		case frameTx:
+			select {
+			case p.submitCh <- f.Payload:
+				conn.answer(f, nil, nil)
+			case <-conn.closed:
+			case <-p.shutdown:
+			}
+		case frameKeyedTx:
+			var tx KeyedTx
+			if err := json.Unmarshal(f.Payload, &tx); err != nil {
+				conn.answer(f, nil, err)
+				return
+			}
+			select {
+			case p.keyedSubmitCh <- tx:
+				conn.answer(f, nil, nil)
+			case <-conn.closed:
+			case <-p.shutdown:
+			}
+		default:
+			conn.answer(f, nil, errUnexpectedFrame(f))
+		}
+	})
+	p.slot.clear(conn)
+	logger.WithError(err).Debug("app disconnected")
+}
+
+// forwardNodeEvents sends the node events to the app, dropping those which
+// come while it is not connected
+func (p *SocketAppProxy) forwardNodeEvents() {
+	defer p.wg.Done()
+	for {
+		select {
+		case event := <-p.nodeEventCh:
+			conn := p.slot.current()
+			if conn == nil {
+				continue
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				p.logger.WithError(err).Error("socket proxy node event")
+				continue
+			}
+			if err := conn.send(socketFrame{Type: frameEvent, Payload: payload}); err != nil {
+				p.logger.WithError(err).Debug("socket proxy node event")
+			}
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+func (p *SocketAppProxy) call(typ byte, payload []byte) ([]byte, error) {
+	conn, err := p.slot.get(p.timeout, p.shutdown)
+	if err != nil {
+		return nil, err
+	}
+	return conn.call(typ, payload, p.timeout)
+}
+
+/*
+ * inmem interface: AppProxy implementation
+ */
+
+// SubmitCh implements AppProxy interface method
+func (p *SocketAppProxy) SubmitCh() chan []byte {
+	return p.submitCh
+}
+
+// KeyedSubmitCh implements KeyedSubmitter
+func (p *SocketAppProxy) KeyedSubmitCh() chan KeyedTx {
+	return p.keyedSubmitCh
+}
+
+// SubmitInternalCh implements AppProxy interface method. The protocol does
+// not carry internal transactions.
+func (p *SocketAppProxy) SubmitInternalCh() chan poset.InternalTransaction {
+	return p.submitInternalCh
+}
+
+// NodeEventCh implements NodeEventSubscriber. The events are sent to the
+// app, which passes them to its handler if it is a NodeEventHandler.
+func (p *SocketAppProxy) NodeEventCh() chan<- NodeEvent {
+	return p.nodeEventCh
+}
+
+// CommitBlock implements AppProxy interface method
+func (p *SocketAppProxy) CommitBlock(block poset.Block) (CommitResult, error) {
+	data, err := block.ProtoMarshal()
+	if err != nil {
+		return CommitResult{}, err
+	}
+	answer, err := p.call(frameCommit, data)
+	if err != nil {
+		return CommitResult{}, err
+	}
+	var res CommitResult
+	if len(answer) > 0 {
+		err = json.Unmarshal(answer, &res)
+	}
+	return res, err
+}
+
+// GetSnapshot implements AppProxy interface method
+func (p *SocketAppProxy) GetSnapshot(blockIndex int64) ([]byte, error) {
+	index := make([]byte, 8)
+	binary.BigEndian.PutUint64(index, uint64(blockIndex))
+	return p.call(frameSnapshot, index)
+}
+
+// Restore implements AppProxy interface method
+func (p *SocketAppProxy) Restore(snapshot []byte) ([]byte, error) {
+	return p.call(frameRestore, snapshot)
+}
+
+// Query implements AppProxy interface method
+func (p *SocketAppProxy) Query(query []byte) ([]byte, error) {
+	answer, err := p.call(frameQuery, query)
+	if err != nil && err.Error() == ErrNoQuery.Error() {
+		return nil, ErrNoQuery
+	}
+	return answer, err
+}
@@ -0,0 +1,455 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SocketProtocolVersion is the version of the socket proxy protocol. Both
+// ends of a connection must speak the same version.
+const SocketProtocolVersion = 1
+
+// DefaultSocketHeartbeat is the time between the pings of the ends of a
+// socket proxy connection
+const DefaultSocketHeartbeat = time.Second
+
+// MaxSocketFrameSize is the largest frame, snapshots included, a socket
+// proxy sends or accepts
+const MaxSocketFrameSize = 1 << 30
+
+// Frames are length-prefixed:
+//
+//	uint32 length of the rest of the frame, big endian
+//	uint8  type
+//	uint64 id, big endian
+//	[]byte payload
+//
+// A request and its frameResult or frameError answer share the same id.
+const socketFrameHeader = 1 + 8
+
+const (
+	frameHello byte = iota + 1
+	framePing
+	framePong
+	frameResult
+	frameError
+	frameTx
+	frameCommit
+	frameSnapshot
+	frameRestore
+	frameQuery
+	// frameEvent carries a NodeEvent in JSON and is not answered
+	frameEvent
+	// frameKeyedTx carries a KeyedTx in JSON
+	frameKeyedTx
+)
+
+var (
+	// ErrSocketDisconnected is returned by socket proxy calls made while,
+	// or interrupted because, the other end is not connected
+	ErrSocketDisconnected = errors.New("socket proxy is not connected")
+	// ErrSocketTimeout is returned by socket proxy calls which were not
+	// answered in time
+	ErrSocketTimeout = errors.New("socket proxy call timed out")
+)
+
+type socketFrame struct {
+	Type    byte
+	ID      uint64
+	Payload []byte
+}
+
+func errUnexpectedFrame(f socketFrame) error {
+	return fmt.Errorf("unexpected frame type %d", f.Type)
+}
+
+// socketHello is the payload of the frameHello of both ends of the
+// handshake
+type socketHello struct {
+	Version int
+}
+
+// SplitSocketAddr returns the network and address to listen on or dial for
+// a socket proxy address: "unix:///path/to/socket" is a unix socket,
+// anything else a TCP address.
+func SplitSocketAddr(addr string) (network, address string) {
+	if strings.HasPrefix(addr, "unix://") {
+		return "unix", strings.TrimPrefix(addr, "unix://")
+	}
+	return "tcp", addr
+}
+
+// ListenSocket listens on network, removing the stale socket file a killed
+// node leaves behind for unix sockets
+func ListenSocket(network, addr string) (net.Listener, error) {
+	if network == "unix" {
+		if info, err := os.Stat(addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return net.Listen(network, addr)
+}
+
+func writeSocketFrame(w io.Writer, f socketFrame) error {
+	if len(f.Payload) > MaxSocketFrameSize-socketFrameHeader {
+		return fmt.Errorf("frame of %d bytes exceeds the %d bytes limit", len(f.Payload), MaxSocketFrameSize)
+	}
+	buf := make([]byte, 4+socketFrameHeader+len(f.Payload))
+	binary.BigEndian.PutUint32(buf, uint32(socketFrameHeader+len(f.Payload)))
+	buf[4] = f.Type
+	binary.BigEndian.PutUint64(buf[5:], f.ID)
+	copy(buf[4+socketFrameHeader:], f.Payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+func readSocketFrame(r io.Reader) (socketFrame, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return socketFrame{}, err
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size < socketFrameHeader || size > MaxSocketFrameSize {
+		return socketFrame{}, fmt.Errorf("invalid frame size %d", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return socketFrame{}, err
+	}
+	return socketFrame{
+		Type:    buf[0],
+		ID:      binary.BigEndian.Uint64(buf[1:socketFrameHeader]),
+		Payload: buf[socketFrameHeader:],
+	}, nil
+}
+
+// socketHandshake sends our frameHello and checks the one of the other end.
+// The node answers the hello of the app, so the app writes first. Both ends
+// return a VersionError if they do not speak the same version.
+func socketHandshake(t frameTransport, first bool) error {
+	hello, err := json.Marshal(socketHello{Version: SocketProtocolVersion})
+	if err != nil {
+		return err
+	}
+	if first {
+		if err := t.writeFrame(socketFrame{Type: frameHello, Payload: hello}); err != nil {
+			return err
+		}
+	}
+	f, err := t.readFrame()
+	if err != nil {
+		return err
+	}
+	switch f.Type {
+	case frameHello:
+	case frameError:
+		if err := parseVersionError(string(f.Payload)); err != nil {
+			return err
+		}
+		return fmt.Errorf("handshake refused: %s", f.Payload)
+	default:
+		return fmt.Errorf("%v during handshake", errUnexpectedFrame(f))
+	}
+	var other socketHello
+	if err := json.Unmarshal(f.Payload, &other); err != nil {
+		return fmt.Errorf("invalid hello: %v", err)
+	}
+	if other.Version != SocketProtocolVersion {
+		err := &VersionError{Protocol: "socket", Node: other.Version, App: SocketProtocolVersion}
+		if !first {
+			err.Node, err.App = SocketProtocolVersion, other.Version
+			t.writeFrame(socketFrame{Type: frameError, Payload: []byte(err.Error())})
+		}
+		return err
+	}
+	if !first {
+		return t.writeFrame(socketFrame{Type: frameHello, Payload: hello})
+	}
+	return nil
+}
+
+// frameTransport carries the frames of a socket proxy connection. Reads and
+// writes fail when they make no progress for a while.
+type frameTransport interface {
+	readFrame() (socketFrame, error)
+	writeFrame(f socketFrame) error
+	Close() error
+}
+
+// streamTransport sends length-prefixed frames over a unix or TCP
+// connection
+type streamTransport struct {
+	conn idleConn
+}
+
+func newStreamTransport(conn net.Conn, timeout time.Duration) streamTransport {
+	return streamTransport{conn: idleConn{Conn: conn, timeout: timeout}}
+}
+
+func (t streamTransport) readFrame() (socketFrame, error) {
+	return readSocketFrame(t.conn)
+}
+
+func (t streamTransport) writeFrame(f socketFrame) error {
+	return writeSocketFrame(t.conn, f)
+}
+
+func (t streamTransport) Close() error {
+	return t.conn.Close()
+}
+
+// idleConn refreshes the deadlines of conn before every read and write, so
+// a large frame only fails if the transfer stalls
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+// socketWriteChunk is the most written at once under a single deadline
+const socketWriteChunk = 64 << 10
+
+func (c idleConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c idleConn) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		end := written + socketWriteChunk
+		if end > len(b) {
+			end = len(b)
+		}
+		if err := c.Conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+			return written, err
+		}
+		n, err := c.Conn.Write(b[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// socketConn is a connection between a node and an app which went through
+// the handshake. Either end sends requests and matches the answers by id.
+// Both ends ping every heartbeat and drop the connection when nothing was
+// read, or no write progressed, for 3 heartbeats.
+type socketConn struct {
+	transport frameTransport
+	heartbeat time.Duration
+
+	writeLock sync.Mutex
+	nextID    uint64
+
+	pendingLock sync.Mutex
+	pending     map[uint64]chan socketFrame
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSocketConn(transport frameTransport, heartbeat time.Duration) *socketConn {
+	return &socketConn{
+		transport: transport,
+		heartbeat: heartbeat,
+		pending:   make(map[uint64]chan socketFrame),
+		closed:    make(chan struct{}),
+	}
+}
+
+func (c *socketConn) send(f socketFrame) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.transport.writeFrame(f)
+}
+
+// call sends a request and waits for its answer. A frameError answer is
+// returned as an error.
+func (c *socketConn) call(typ byte, payload []byte, timeout time.Duration) ([]byte, error) {
+	id := atomic.AddUint64(&c.nextID, 1)
+	answer := make(chan socketFrame, 1)
+	c.pendingLock.Lock()
+	c.pending[id] = answer
+	c.pendingLock.Unlock()
+	defer func() {
+		c.pendingLock.Lock()
+		delete(c.pending, id)
+		c.pendingLock.Unlock()
+	}()
+
+	if err := c.send(socketFrame{Type: typ, ID: id, Payload: payload}); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case f := <-answer:
+		if f.Type == frameError {
+			return nil, errors.New(string(f.Payload))
+		}
+		return f.Payload, nil
+	case <-c.closed:
+		return nil, ErrSocketDisconnected
+	case <-timer.C:
+		return nil, ErrSocketTimeout
+	}
+}
+
+// answer sends the result of request, or the error
+func (c *socketConn) answer(request socketFrame, result []byte, err error) error {
+	if err != nil {
+		return c.send(socketFrame{Type: frameError, ID: request.ID, Payload: []byte(err.Error())})
+	}
+	return c.send(socketFrame{Type: frameResult, ID: request.ID, Payload: result})
+}
+
+// serve reads the connection until it fails, answering pings, routing
+// answers to their calls and passing requests to handle, in order
+func (c *socketConn) serve(handle func(socketFrame)) error {
+	defer c.close()
+	go c.keepAlive()
+	for {
+		f, err := c.transport.readFrame()
+		if err != nil {
+			return err
+		}
+		switch f.Type {
+		case framePing:
+			if err := c.send(socketFrame{Type: framePong, ID: f.ID}); err != nil {
+				return err
+			}
+		case framePong:
+		case frameResult, frameError:
+			c.pendingLock.Lock()
+			if answer, ok := c.pending[f.ID]; ok {
+				select {
+				case answer <- f:
+				default:
+				}
+			}
+			c.pendingLock.Unlock()
+		default:
+			handle(f)
+		}
+	}
+}
+
+func (c *socketConn) keepAlive() {
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.send(socketFrame{Type: framePing}); err != nil {
+				c.close()
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *socketConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.transport.Close()
+	})
+}
+
+// socketSlot holds the current connection of a socket proxy, which calls
+// wait for when there is none
+type socketSlot struct {
+	lock  sync.Mutex
+	conn  *socketConn
+	ready chan struct{}
+	err   error
+}
+
+func newSocketSlot() *socketSlot {
+	return &socketSlot{ready: make(chan struct{})}
+}
+
+// set makes conn the current connection and returns the previous one
+func (s *socketSlot) set(conn *socketConn) *socketConn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	prev := s.conn
+	s.conn = conn
+	switch {
+	case prev == nil && conn != nil:
+		close(s.ready)
+	case prev != nil && conn == nil:
+		s.ready = make(chan struct{})
+	}
+	return prev
+}
+
+// clear forgets conn if it is still the current connection
+func (s *socketSlot) clear(conn *socketConn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn == conn {
+		s.conn = nil
+		s.ready = make(chan struct{})
+	}
+}
+
+// current returns the current connection, nil if there is none
+func (s *socketSlot) current() *socketConn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn
+}
+
+// fail makes the calls waiting for a connection, and the next ones, return
+// err at once: there will be none
+func (s *socketSlot) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+	if s.conn == nil {
+		close(s.ready)
+	}
+}
+
+// get returns the current connection, waiting at most timeout for one
+func (s *socketSlot) get(timeout time.Duration, shutdown chan struct{}) (*socketConn, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.lock.Lock()
+		conn, ready, err := s.conn, s.ready, s.err
+		s.lock.Unlock()
+		if conn != nil {
+			return conn, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ready:
+		case <-timer.C:
+			return nil, ErrSocketDisconnected
+		case <-shutdown:
+			return nil, ErrSocketDisconnected
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// socketMaxReconnectDelay caps the delay between two connection attempts,
+// which doubles from one heartbeat after each failure
+const socketMaxReconnectDelay = 30 * time.Second
+
+// SocketLachesisProxy connects an app to the SocketAppProxy of a node and
+// calls its handler for the blocks, snapshots, restores, queries and node
+// events of the node, in the order they come. It reconnects whenever the
+// connection is lost, unless the node speaks another protocol version:
+// SubmitTx then returns the VersionError.
+type SocketLachesisProxy struct {
+	logger    *logrus.Logger
+	network   string
+	addr      string
+	handler   ProxyHandler
+	timeout   time.Duration
+	heartbeat time.Duration
+
+	slot     *socketSlot
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewSocketLachesisProxy connects to the node listening on network ("unix"
+// or "tcp") and addr, in the background. SubmitTx waits at most timeout for
+// the connection and then for the node to accept the transaction.
+func NewSocketLachesisProxy(network, addr string, handler ProxyHandler, timeout, heartbeat time.Duration, logger *logrus.Logger) *SocketLachesisProxy {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	p := &SocketLachesisProxy{
+		logger:    logger,
+		network:   network,
+		addr:      addr,
+		handler:   handler,
+		timeout:   timeout,
+		heartbeat: heartbeat,
+		slot:      newSocketSlot(),
+		shutdown:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Close drops the connection and stops reconnecting
+func (p *SocketLachesisProxy) Close() error {
+	close(p.shutdown)
+	if conn := p.slot.set(nil); conn != nil {
+		conn.close()
+	}
+	<-p.done
+	return nil
+}
+
+// SubmitTx sends a transaction to the node
+func (p *SocketLachesisProxy) SubmitTx(tx []byte) error {
+	conn, err := p.slot.get(p.timeout, p.shutdown)
+	if err != nil {
+		return err
+	}
+	_, err = conn.call(frameTx, tx, p.timeout)
+	return err
+}
+
+// SubmitKeyedTx sends a transaction to the node with an idempotency key, see
+// KeyedTx. A node too old to take keys answers with an error.
+func (p *SocketLachesisProxy) SubmitKeyedTx(key string, tx []byte) error {
+	payload, err := json.Marshal(KeyedTx{Key: key, Tx: tx})
+	if err != nil {
+		return err
+	}
+	conn, err := p.slot.get(p.timeout, p.shutdown)
+	if err != nil {
+		return err
+	}
+	_, err = conn.call(frameKeyedTx, payload, p.timeout)
+	return err
+}
+
+func (p *SocketLachesisProxy) run() {
+	defer close(p.done)
+	delay := p.heartbeat
+	for {
+		err := p.connect()
+		if IsVersionError(err) {
+			p.logger.WithError(err).Error("socket proxy refused, not reconnecting")
+			p.slot.fail(err)
+			return
+		}
+		if err != nil {
+			p.logger.WithError(err).Warnf("socket proxy connection failed, retrying in %v", delay)
+		} else {
+			delay = p.heartbeat
+		}
+		select {
+		case <-p.shutdown:
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > socketMaxReconnectDelay {
+			delay = socketMaxReconnectDelay
+		}
+	}
+}
+
+// connect serves one connection to the node until it is lost
+func (p *SocketLachesisProxy) connect() error {
+	netConn, err := net.DialTimeout(p.network, p.addr, p.timeout)
+	if err != nil {
+		return err
+	}
+	transport := newStreamTransport(netConn, 3*p.heartbeat)
+	if err := socketHandshake(transport, true); err != nil {
+		transport.Close()
+		return err
+	}
+
+	conn := newSocketConn(transport, p.heartbeat)
+	p.slot.set(conn)
+	select {
+	case <-p.shutdown:
+		conn.close()
+	default:
+	}
+	p.logger.Debug("connected to lachesis")
+
+	err = conn.serve(func(f socketFrame) {
+		if f.Type == frameEvent {
+			p.handleNodeEvent(f)
+			return
+		}
+		res, err := p.handle(f)
+		if err := conn.answer(f, res, err); err != nil {
+			p.logger.WithError(err).Debug("socket proxy answer")
+		}
+	})
+	p.slot.clear(conn)
+	p.logger.WithError(err).Warn("disconnected from lachesis")
+	return nil
+}
+
+func (p *SocketLachesisProxy) handleNodeEvent(f socketFrame) {
+	handler, ok := p.handler.(NodeEventHandler)
+	if !ok {
+		return
+	}
+	var event NodeEvent
+	if err := json.Unmarshal(f.Payload, &event); err != nil {
+		p.logger.WithError(err).Error("socket proxy node event")
+		return
+	}
+	handler.NodeEventHandler(event)
+}
+
+func (p *SocketLachesisProxy) handle(f socketFrame) ([]byte, error) {
+	switch f.Type {
+	case frameCommit:
+		var block poset.Block
+		if err := block.ProtoUnmarshal(f.Payload); err != nil {
+			return nil, err
+		}
+		var res CommitResult
+		var err error
+		if handler, ok := p.handler.(CommitReceiptsHandler); ok {
+			res, err = handler.CommitReceiptsHandler(block)
+		} else {
+			res.StateHash, err = p.handler.CommitHandler(block)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(res)
+	case frameSnapshot:
+		if len(f.Payload) != 8 {
+			return nil, fmt.Errorf("invalid snapshot request of %d bytes", len(f.Payload))
+		}
+		return p.handler.SnapshotHandler(int64(binary.BigEndian.Uint64(f.Payload)))
+	case frameRestore:
+		return p.handler.RestoreHandler(f.Payload)
+	case frameQuery:
+		handler, ok := p.handler.(QueryHandler)
+		if !ok {
+			return nil, ErrNoQuery
+		}
+		return handler.QueryHandler(f.Payload)
+	default:
+		return nil, errUnexpectedFrame(f)
+	}
+}
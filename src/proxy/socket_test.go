@@ -0,0 +1,221 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/utils"
+)
+
+const (
+	socketTimeout   = 1 * time.Second
+	socketHeartbeat = 50 * time.Millisecond
+)
+
+func TestSocketCalls(t *testing.T) {
+	addr := utils.GetUnusedNetAddr(1, t)
+	t.Run("tcp", func(t *testing.T) {
+		testSocketCalls("tcp", addr[0], t)
+	})
+	t.Run("unix", func(t *testing.T) {
+		testSocketCalls("unix", filepath.Join(t.TempDir(), "lachesis.sock"), t)
+	})
+}
+
+func testSocketCalls(network, addr string, t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	s, err := NewSocketAppProxy(network, addr, socketTimeout, socketHeartbeat, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	app := &counterApp{}
+	c := NewSocketLachesisProxy(network, addr, &abciHandler{app: app, logger: logger},
+		socketTimeout, socketHeartbeat, logger)
+	defer c.Close()
+
+	t.Run("#1 Send tx", func(t *testing.T) {
+		assertO := assert.New(t)
+		gold := []byte("123456")
+
+		go func() {
+			select {
+			case tx := <-s.SubmitCh():
+				assertO.Equal(gold, tx)
+			case <-time.After(socketTimeout):
+				assertO.Fail("time is over")
+			}
+		}()
+		assertO.NoError(c.SubmitTx(gold))
+	})
+
+	t.Run("#2 Commit block", func(t *testing.T) {
+		assertO := assert.New(t)
+		block := poset.NewBlock(0, 1, []byte{}, [][]byte{counterTx(0), counterTx(7)})
+
+		res, err := s.CommitBlock(block)
+		if assertO.NoError(err) {
+			assertO.Equal(counterTx(1), res.StateHash)
+			if assertO.Len(res.Receipts, 2) {
+				assertO.Equal(CodeTypeOK, res.Receipts[0].Code)
+				assertO.Equal("invalid nonce", res.Receipts[1].Log)
+			}
+		}
+	})
+
+	t.Run("#3 Query", func(t *testing.T) {
+		assertO := assert.New(t)
+
+		count, err := s.Query([]byte("count"))
+		if assertO.NoError(err) {
+			assertO.Equal(counterTx(1), count)
+		}
+		_, err = s.Query([]byte("balance"))
+		assertO.Error(err)
+	})
+
+	t.Run("#4 Snapshot errors", func(t *testing.T) {
+		assertO := assert.New(t)
+
+		_, err := s.GetSnapshot(0)
+		assertO.EqualError(err, "ABCI application does not support snapshots")
+		_, err = s.Restore([]byte("snapshot"))
+		assertO.EqualError(err, "ABCI application does not support snapshots")
+	})
+
+	t.Run("#5 Send keyed tx", func(t *testing.T) {
+		assertO := assert.New(t)
+		gold := KeyedTx{Key: "transfer-42", Tx: []byte("123456")}
+
+		go func() {
+			select {
+			case tx := <-s.KeyedSubmitCh():
+				assertO.Equal(gold, tx)
+			case <-time.After(socketTimeout):
+				assertO.Fail("time is over")
+			}
+		}()
+		assertO.NoError(c.SubmitKeyedTx(gold.Key, gold.Tx))
+	})
+}
+
+func TestSocketReconnect(t *testing.T) {
+	assertO := assert.New(t)
+	addr := filepath.Join(t.TempDir(), "lachesis.sock")
+	logger := common.NewTestLogger(t)
+
+	s, err := NewSocketAppProxy("unix", addr, socketTimeout, socketHeartbeat, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewSocketLachesisProxy("unix", addr, &abciHandler{app: &counterApp{}, logger: logger},
+		socketTimeout, socketHeartbeat, logger)
+	defer c.Close()
+
+	_, err = s.Query([]byte("count"))
+	assertO.NoError(err)
+
+	// the node restarts, the app connects to the new one
+	assertO.NoError(s.Close())
+	_, err = s.Query([]byte("count"))
+	assertO.Equal(ErrSocketDisconnected, err)
+
+	s, err = NewSocketAppProxy("unix", addr, socketTimeout, socketHeartbeat, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	_, err = s.Query([]byte("count"))
+	assertO.NoError(err)
+}
+
+func TestSocketHandshake(t *testing.T) {
+	assertO := assert.New(t)
+	addr := utils.GetUnusedNetAddr(1, t)
+
+	s, err := NewSocketAppProxy("tcp", addr[0], socketTimeout, socketHeartbeat, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// an app speaking another version is refused
+	conn, err := net.Dial("tcp", addr[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	hello, _ := json.Marshal(socketHello{Version: SocketProtocolVersion + 1})
+	assertO.NoError(writeSocketFrame(conn, socketFrame{Type: frameHello, Payload: hello}))
+	f, err := readSocketFrame(conn)
+	if assertO.NoError(err) {
+		assertO.Equal(frameError, f.Type)
+		assertO.Equal(&VersionError{Protocol: "socket", Node: SocketProtocolVersion, App: SocketProtocolVersion + 1},
+			parseVersionError(string(f.Payload)))
+	}
+
+	// an app which stops sending pings is dropped
+	conn, err = net.Dial("tcp", addr[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	assertO.NoError(socketHandshake(newStreamTransport(conn, socketTimeout), true))
+	start := time.Now()
+	for {
+		f, err := readSocketFrame(conn)
+		if err != nil {
+			break
+		}
+		assertO.Equal(framePing, f.Type)
+	}
+	assertO.True(time.Since(start) < socketTimeout, "silent app not dropped")
+}
+
+func TestSocketVersion(t *testing.T) {
+	assertO := assert.New(t)
+	addr := utils.GetUnusedNetAddr(1, t)
+
+	// a node speaking another version
+	listener, err := net.Listen("tcp", addr[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err := readSocketFrame(conn); err != nil {
+			return
+		}
+		hello, _ := json.Marshal(socketHello{Version: SocketProtocolVersion + 1})
+		writeSocketFrame(conn, socketFrame{Type: frameHello, Payload: hello})
+		readSocketFrame(conn)
+	}()
+
+	c := NewSocketLachesisProxy("tcp", addr[0], &abciHandler{app: &counterApp{}, logger: common.NewTestLogger(t)},
+		socketTimeout, socketHeartbeat, common.NewTestLogger(t))
+	defer c.Close()
+
+	// the app does not wait for a connection which will not come
+	start := time.Now()
+	err = c.SubmitTx([]byte("tx"))
+	for err == ErrSocketDisconnected && time.Since(start) < 5*socketTimeout {
+		err = c.SubmitTx([]byte("tx"))
+	}
+	assertO.Equal(&VersionError{Protocol: "socket", Node: SocketProtocolVersion + 1, App: SocketProtocolVersion}, err)
+}
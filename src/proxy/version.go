@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"fmt"
+)
+
+// versionErrorFormat is the message of a VersionError, which the node also
+// sends to the socket apps it refuses
+const versionErrorFormat = "incompatible %s proxy protocol: node speaks version %d, app speaks version %d, upgrade both together"
+
+// VersionError is returned on both ends of a proxy connection when the node
+// and the app do not speak the same protocol version. The connection is not
+// retried: one end must be upgraded.
+type VersionError struct {
+	// Protocol is "socket" or "grpc"
+	Protocol string
+	Node     int
+	App      int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf(versionErrorFormat, e.Protocol, e.Node, e.App)
+}
+
+// IsVersionError tells whether err is a VersionError
+func IsVersionError(err error) bool {
+	_, ok := err.(*VersionError)
+	return ok
+}
+
+// parseVersionError reads back the VersionError in msg, nil if it is
+// another error
+func parseVersionError(msg string) *VersionError {
+	var e VersionError
+	_, err := fmt.Sscanf(msg, "incompatible %s proxy protocol: node speaks version %d, app speaks version %d,", &e.Protocol, &e.Node, &e.App)
+	if err != nil {
+		return nil
+	}
+	return &e
+}
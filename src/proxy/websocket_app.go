@@ -0,0 +1,198 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// WebSocketMessage is the JSON message of the WebSocket proxy protocol, the
+// socket protocol with one message per frame. Only the fields of its Type
+// are set.
+type WebSocketMessage struct {
+	Type      string          `json:"type"`
+	ID        uint64          `json:"id,omitempty"`
+	Version   int             `json:"version,omitempty"`
+	Data      []byte          `json:"data,omitempty"`
+	Index     int64           `json:"index,omitempty"`
+	Block     *poset.Block    `json:"block,omitempty"`
+	StateHash []byte          `json:"stateHash,omitempty"`
+	Receipts  []poset.Receipt `json:"receipts,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Event     *NodeEvent      `json:"event,omitempty"`
+	// Key is the idempotency key of a keyedTx, whose Data is the transaction
+	Key string `json:"key,omitempty"`
+	// ValidatorUpdates may come with the answer to a commit
+	ValidatorUpdates []ValidatorUpdate `json:"validatorUpdates,omitempty"`
+}
+
+var webSocketTypes = map[byte]string{
+	frameHello:    "hello",
+	framePing:     "ping",
+	framePong:     "pong",
+	frameResult:   "result",
+	frameError:    "error",
+	frameTx:       "tx",
+	frameCommit:   "commit",
+	frameSnapshot: "snapshot",
+	frameRestore:  "restore",
+	frameQuery:    "query",
+	frameEvent:    "event",
+	frameKeyedTx:  "keyedTx",
+}
+
+// webSocketTransport maps the frames of the socket protocol to
+// WebSocketMessages
+type webSocketTransport struct {
+	ws      *websocket.Conn
+	timeout time.Duration
+}
+
+func (t webSocketTransport) readFrame() (socketFrame, error) {
+	if err := t.ws.SetReadDeadline(time.Now().Add(t.timeout)); err != nil {
+		return socketFrame{}, err
+	}
+	var msg WebSocketMessage
+	if err := websocket.JSON.Receive(t.ws, &msg); err != nil {
+		return socketFrame{}, err
+	}
+	f := socketFrame{ID: msg.ID, Payload: msg.Data}
+	for typ, name := range webSocketTypes {
+		if name == msg.Type {
+			f.Type = typ
+		}
+	}
+	switch f.Type {
+	case frameHello:
+		payload, err := json.Marshal(socketHello{Version: msg.Version})
+		if err != nil {
+			return socketFrame{}, err
+		}
+		f.Payload = payload
+	case frameError:
+		f.Payload = []byte(msg.Error)
+	case frameKeyedTx:
+		payload, err := json.Marshal(KeyedTx{Key: msg.Key, Tx: msg.Data})
+		if err != nil {
+			return socketFrame{}, err
+		}
+		f.Payload = payload
+	case frameResult:
+		// the answer to a commit
+		if msg.StateHash != nil || msg.Receipts != nil || msg.ValidatorUpdates != nil {
+			payload, err := json.Marshal(CommitResult{
+				StateHash:        msg.StateHash,
+				Receipts:         msg.Receipts,
+				ValidatorUpdates: msg.ValidatorUpdates,
+			})
+			if err != nil {
+				return socketFrame{}, err
+			}
+			f.Payload = payload
+		}
+	}
+	return f, nil
+}
+
+func (t webSocketTransport) writeFrame(f socketFrame) error {
+	msg := WebSocketMessage{Type: webSocketTypes[f.Type], ID: f.ID}
+	switch f.Type {
+	case frameHello:
+		var hello socketHello
+		if err := json.Unmarshal(f.Payload, &hello); err != nil {
+			return err
+		}
+		msg.Version = hello.Version
+	case frameError:
+		msg.Error = string(f.Payload)
+	case frameCommit:
+		msg.Block = new(poset.Block)
+		if err := msg.Block.ProtoUnmarshal(f.Payload); err != nil {
+			return err
+		}
+	case frameSnapshot:
+		msg.Index = int64(binary.BigEndian.Uint64(f.Payload))
+	case frameEvent:
+		msg.Event = new(NodeEvent)
+		if err := json.Unmarshal(f.Payload, msg.Event); err != nil {
+			return err
+		}
+	default:
+		msg.Data = f.Payload
+	}
+	if err := t.ws.SetWriteDeadline(time.Now().Add(t.timeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(t.ws, msg)
+}
+
+func (t webSocketTransport) Close() error {
+	return t.ws.Close()
+}
+
+// WebSocketAppProxy implements the AppProxy interface for an app connected
+// over WebSocket, such as a web page or a serverless function. It speaks
+// the protocol of SocketAppProxy with JSON WebSocketMessages. Any origin is
+// accepted, so it should only be reachable by the app.
+type WebSocketAppProxy struct {
+	*SocketAppProxy
+	listener net.Listener
+	server   *http.Server
+}
+
+// NewWebSocketAppProxy serves the WebSocket endpoint at the root of an HTTP
+// server listening on bindAddr. timeout and heartbeat are as with
+// NewSocketAppProxy.
+func NewWebSocketAppProxy(bindAddr string, timeout, heartbeat time.Duration, logger *logrus.Logger) (*WebSocketAppProxy, error) {
+	listener, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, err
+	}
+	p := &WebSocketAppProxy{
+		SocketAppProxy: newSocketAppProxy(timeout, heartbeat, logger),
+		listener:       listener,
+	}
+	p.server = &http.Server{Handler: p}
+	go func() {
+		if err := p.server.Serve(listener); err != http.ErrServerClosed {
+			p.logger.WithError(err).Error("WebSocket proxy server")
+		}
+	}()
+	return p, nil
+}
+
+// Addr returns the address the proxy listens on
+func (p *WebSocketAppProxy) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// ServeHTTP upgrades the request to the WebSocket connection of the app
+func (p *WebSocketAppProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server := websocket.Server{
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			if !p.track() {
+				return
+			}
+			ws.MaxPayloadBytes = MaxSocketFrameSize
+			p.serve(webSocketTransport{ws: ws, timeout: 3 * p.heartbeat}, r.RemoteAddr)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// Close stops the HTTP server and drops the app connection
+func (p *WebSocketAppProxy) Close() error {
+	err := p.server.Close()
+	if err2 := p.SocketAppProxy.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/utils"
+)
+
+func TestWebSocketCalls(t *testing.T) {
+	addr := utils.GetUnusedNetAddr(1, t)
+
+	s, err := NewWebSocketAppProxy(addr[0], socketTimeout, socketHeartbeat, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ws, err := websocket.Dial("ws://"+addr[0]+"/", "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	// the app of a browser, answering in JSON
+	var msg WebSocketMessage
+	assert.NoError(t, websocket.JSON.Send(ws, WebSocketMessage{Type: "hello", Version: SocketProtocolVersion}))
+	if assert.NoError(t, websocket.JSON.Receive(ws, &msg)) {
+		assert.Equal(t, "hello", msg.Type)
+	}
+	txAnswers := make(chan WebSocketMessage, 1)
+	go func() {
+		for {
+			var msg WebSocketMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				return
+			}
+			answer := WebSocketMessage{Type: "result", ID: msg.ID}
+			switch msg.Type {
+			case "ping":
+				answer.Type = "pong"
+			case "commit":
+				answer.StateHash = []byte("hash")
+				answer.Receipts = make([]poset.Receipt, len(msg.Block.Transactions()))
+			case "query":
+				answer.Data = append([]byte("answer to "), msg.Data...)
+			case "snapshot":
+				answer.Type = "error"
+				answer.Error = fmt.Sprintf("no snapshot of block %d", msg.Index)
+			case "result", "error":
+				txAnswers <- msg
+				continue
+			}
+			websocket.JSON.Send(ws, answer)
+		}
+	}()
+
+	t.Run("#1 Send tx", func(t *testing.T) {
+		assertO := assert.New(t)
+		gold := []byte("123456")
+
+		assertO.NoError(websocket.JSON.Send(ws, WebSocketMessage{Type: "tx", ID: 1, Data: gold}))
+		select {
+		case tx := <-s.SubmitCh():
+			assertO.Equal(gold, tx)
+		case <-time.After(socketTimeout):
+			assertO.Fail("time is over")
+		}
+		select {
+		case answer := <-txAnswers:
+			assertO.Equal(WebSocketMessage{Type: "result", ID: 1}, answer)
+		case <-time.After(socketTimeout):
+			assertO.Fail("time is over")
+		}
+	})
+
+	t.Run("#2 Commit block", func(t *testing.T) {
+		assertO := assert.New(t)
+		block := poset.NewBlock(0, 1, []byte{}, [][]byte{[]byte("tx 1"), []byte("tx 2")})
+
+		res, err := s.CommitBlock(block)
+		if assertO.NoError(err) {
+			assertO.Equal([]byte("hash"), res.StateHash)
+			assertO.Len(res.Receipts, 2)
+		}
+	})
+
+	t.Run("#3 Query and snapshot", func(t *testing.T) {
+		assertO := assert.New(t)
+
+		res, err := s.Query([]byte("count"))
+		if assertO.NoError(err) {
+			assertO.Equal([]byte("answer to count"), res)
+		}
+		_, err = s.GetSnapshot(7)
+		assertO.EqualError(err, "no snapshot of block 7")
+	})
+
+	t.Run("#4 Heartbeat", func(t *testing.T) {
+		// the app answers pings, so it is still connected
+		time.Sleep(5 * socketHeartbeat)
+		_, err := s.Query([]byte("count"))
+		assert.NoError(t, err)
+	})
+}
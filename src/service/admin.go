@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+	"github.com/sirupsen/logrus"
+)
+
+// adminPost serves h to the POST requests only, the admin actions changing
+// the node
+func adminPost(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "admin actions are POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// PauseGossip stops the node from initiating gossips
+func (s *Service) PauseGossip(w http.ResponseWriter, r *http.Request) {
+	s.node.PauseGossip()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResumeGossip lets the node initiate gossips again
+func (s *Service) ResumeGossip(w http.ResponseWriter, r *http.Request) {
+	s.node.ResumeGossip()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Suspend stops the node from creating events and initiating gossips
+func (s *Service) Suspend(w http.ResponseWriter, r *http.Request) {
+	s.node.Suspend()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Resume lets a suspended node create events and gossip again
+func (s *Service) Resume(w http.ResponseWriter, r *http.Request) {
+	s.node.Resume()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FastForward makes the node catch up with a peer
+func (s *Service) FastForward(w http.ResponseWriter, r *http.Request) {
+	if err := s.node.FastForward(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// LogLevel returns the log level of the node, and sets it to the level
+// parameter of a POST
+func (s *Service) LogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		level, err := logrus.ParseLevel(r.FormValue("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.logger.SetLevel(level)
+		s.logger.WithField("level", level.String()).Info("Log level changed")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.logger.GetLevel().String()); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
+// nodeConfig is the reloadable config of the node, under the names of the
+// run flags
+type nodeConfig struct {
+	Heartbeat string `json:"heartbeat"`
+	SyncLimit int64  `json:"sync-limit"`
+	CacheSize int    `json:"cache-size"`
+	Log       string `json:"log"`
+}
+
+// Config returns the reloadable config of the node, and reloads the
+// heartbeat, sync-limit, cache-size and log parameters of a POST
+func (s *Service) Config(w http.ResponseWriter, r *http.Request) {
+	res := s.node.ReloadableConfig()
+	if r.Method == http.MethodPost {
+		var c node.ReloadConfig
+		var err error
+		if v := r.FormValue("heartbeat"); v != "" {
+			c.HeartbeatTimeout, err = time.ParseDuration(v)
+		}
+		if v := r.FormValue("sync-limit"); v != "" && err == nil {
+			c.SyncLimit, err = strconv.ParseInt(v, 10, 64)
+		}
+		if v := r.FormValue("cache-size"); v != "" && err == nil {
+			c.CacheSize, err = strconv.Atoi(v)
+		}
+		c.LogLevel = r.FormValue("log")
+		if err == nil {
+			res, err = s.node.Reload(c)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(nodeConfig{
+		Heartbeat: res.HeartbeatTimeout.String(),
+		SyncLimit: res.SyncLimit,
+		CacheSize: res.CacheSize,
+		Log:       res.LogLevel,
+	}); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
+// faultsConfig are the faults injected by the node, under the names of the
+// /admin/faults parameters
+type faultsConfig struct {
+	Drop       float64 `json:"drop"`
+	Delay      string  `json:"delay"`
+	Duplicate  float64 `json:"duplicate"`
+	Reorder    float64 `json:"reorder"`
+	StoreWrite float64 `json:"store-write"`
+	Peer       string  `json:"peer"`
+}
+
+// Faults returns the faults the node injects, and sets them to the drop,
+// delay, duplicate, reorder, store-write and peer parameters of a POST. It
+// fails unless the node runs with fault injection.
+func (s *Service) Faults(w http.ResponseWriter, r *http.Request) {
+	res, err := s.node.GetFaults()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if r.Method == http.MethodPost {
+		var f node.Faults
+		rates := map[string]*float64{
+			"drop":        &f.Drop,
+			"duplicate":   &f.Duplicate,
+			"reorder":     &f.Reorder,
+			"store-write": &f.StoreWrite,
+		}
+		for name, rate := range rates {
+			if v := r.FormValue(name); v != "" && err == nil {
+				*rate, err = strconv.ParseFloat(v, 64)
+			}
+		}
+		if v := r.FormValue("delay"); v != "" && err == nil {
+			f.Delay, err = time.ParseDuration(v)
+		}
+		f.Peer = r.FormValue("peer")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.node.SetFaults(f); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		res = f
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(faultsConfig{
+		Drop:       res.Drop,
+		Delay:      res.Delay.String(),
+		Duplicate:  res.Duplicate,
+		Reorder:    res.Reorder,
+		StoreWrite: res.StoreWrite,
+		Peer:       res.Peer,
+	}); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
+// CompactStore compacts the database of the node, and answers once done
+func (s *Service) CompactStore(w http.ResponseWriter, r *http.Request) {
+	if err := s.node.CompactStore(); err != nil {
+		s.logger.WithError(err).Error("Compacting store")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("Compacted store")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShutdownNode shuts the node down gracefully, once it answered
+func (s *Service) ShutdownNode(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Shutdown requested")
+	w.WriteHeader(http.StatusAccepted)
+	go s.node.Shutdown()
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errUnauthorized is returned to the clients of the protected endpoints
+// without valid credentials
+var errUnauthorized = errors.New("unauthorized")
+
+// EnableAuth protects the admin, profiling and transaction submission
+// endpoints: their requests must carry an "Authorization: Bearer" header with
+// the token, or with a JWT signed with HS256 by the secret. An empty token or
+// secret is ignored, and without either the endpoints are open.
+func (s *Service) EnableAuth(token, jwtSecret string) {
+	s.token = token
+	s.jwtSecret = []byte(jwtSecret)
+}
+
+// authEnabled tells whether the protected endpoints need credentials
+func (s *Service) authEnabled() bool {
+	return s.token != "" || len(s.jwtSecret) > 0
+}
+
+// authorized checks the credentials of r
+func (s *Service) authorized(r *http.Request) bool {
+	if !s.authEnabled() {
+		return true
+	}
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+	credential := strings.TrimSpace(header[len("Bearer "):])
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(s.token)) == 1 {
+		return true
+	}
+	return len(s.jwtSecret) > 0 && validJWT(credential, s.jwtSecret, time.Now()) == nil
+}
+
+// requireAuth serves h to the authorized clients only. The CORS preflight
+// requests, which browsers send without credentials, go through.
+func (s *Service) requireAuth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions && !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lachesis"`)
+			http.Error(w, errUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// validJWT checks that token is a JWT signed with HS256 by secret, and that
+// it is valid at now if it has an expiry or a not-before time
+func validJWT(token string, secret []byte, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "HS256" {
+		return errors.New("unsupported JWT algorithm " + header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.New("invalid JWT signature")
+	}
+
+	var claims struct {
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.Exp != nil && now.Unix() >= *claims.Exp {
+		return errors.New("expired JWT")
+	}
+	if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return errors.New("JWT not valid yet")
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testToken     = "token"
+	testJWTSecret = "secret"
+)
+
+// signJWT signs the claims with HS256 by secret
+func signJWT(claims, secret string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// testService is a service with credentials and no node
+func testService() *Service {
+	s := NewService("", nil, logrus.New())
+	s.EnableAuth(testToken, testJWTSecret)
+	return s
+}
+
+// testHandler serves, like the routes of s would, a protected route at /tx
+// and an open one at /stats, which both answer 200
+func testHandler(s *Service) http.Handler {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux := http.NewServeMux()
+	s.handleRoutes(mux, []route{
+		{path: "/tx", method: "POST", protected: true, cors: true, handler: ok},
+		{path: "/stats", method: "GET", cors: true, handler: ok},
+	})
+	return mux
+}
+
+func serve(h http.Handler, method, path, authorization string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.Header.Set("Origin", "http://example.com")
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuth(t *testing.T) {
+	h := testHandler(testService())
+
+	now := time.Now().Unix()
+	valid := signJWT(`{"exp":`+strconv.FormatInt(now+60, 10)+`}`, testJWTSecret)
+	expired := signJWT(`{"exp":`+strconv.FormatInt(now-60, 10)+`}`, testJWTSecret)
+	future := signJWT(`{"nbf":`+strconv.FormatInt(now+60, 10)+`}`, testJWTSecret)
+
+	cases := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"no credentials", "", http.StatusUnauthorized},
+		{"token", "Bearer " + testToken, http.StatusOK},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"token without Bearer", testToken, http.StatusUnauthorized},
+		{"JWT", "Bearer " + valid, http.StatusOK},
+		{"JWT without claims", "Bearer " + signJWT(`{}`, testJWTSecret), http.StatusOK},
+		{"JWT of another secret", "Bearer " + signJWT(`{}`, "other"), http.StatusUnauthorized},
+		{"expired JWT", "Bearer " + expired, http.StatusUnauthorized},
+		{"JWT not valid yet", "Bearer " + future, http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		w := serve(h, "POST", "/tx", c.authorization)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d", c.name, c.status, w.Code)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected a WWW-Authenticate header", c.name)
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("%s: expected the CORS headers", c.name)
+		}
+	}
+
+	// the open routes need no credentials
+	if w := serve(h, "GET", "/stats", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the open route to answer 200, got %d", w.Code)
+	}
+}
+
+func TestAuthPreflight(t *testing.T) {
+	h := testHandler(testService())
+
+	w := serve(h, "OPTIONS", "/tx", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the preflight of a protected route to answer 200, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("expected the CORS headers in the preflight answer")
+	}
+	if w.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Fatal("expected the allowed methods in the preflight answer")
+	}
+}
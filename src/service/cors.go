@@ -0,0 +1,54 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultCORSOrigins and DefaultCORSMethods let browsers call the service
+// from any page
+var (
+	DefaultCORSOrigins = []string{"*"}
+	DefaultCORSMethods = []string{"GET", "POST", "OPTIONS"}
+)
+
+// SetCORS sets the origins, "*" for any, and the methods the browsers may
+// call the service with. No origin disables the CORS headers, so that
+// browsers refuse the cross-origin calls.
+func (s *Service) SetCORS(origins, methods []string) {
+	s.corsOrigins = origins
+	s.corsMethods = strings.Join(methods, ", ")
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin of the request
+// origin, empty if it is not allowed
+func (s *Service) allowedOrigin(origin string) string {
+	for _, allowed := range s.corsOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// cors sets the CORS headers of h, and answers the preflight requests
+func (s *Service) cors(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := s.allowedOrigin(r.Header.Get("Origin")); origin != "" {
+			if origin != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", s.corsMethods)
+			w.Header().Set("Access-Control-Allow-Headers",
+				"Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+		}
+		if r.Method == http.MethodOptions {
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+)
+
+// Bounds of the limit parameter of /creator/{pubkey}/events
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 500
+)
+
+// eventsPage is a page of /creator/{pubkey}/events. Next is the from
+// parameter of the next page, empty on the last one.
+type eventsPage struct {
+	Events []node.EventInfo
+	Next   string `json:",omitempty"`
+}
+
+// GetBlockEvents returns the details of the events a block is made of
+func (s *Service) GetBlockEvents(w http.ResponseWriter, r *http.Request, param string) {
+	blockIndex, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Parsing block_index parameter %s", param)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.node.GetBlockEvents(blockIndex)
+	if common.Is(err, common.KeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving events of block %d", blockIndex)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(events); err != nil {
+		s.logger.WithError(err).Errorf("Failed to encode events of block %d", blockIndex)
+	}
+}
+
+// GetCreatorEvents returns the details of the events of a creator, from
+// the index in the from parameter, at most limit of them
+func (s *Service) GetCreatorEvents(w http.ResponseWriter, r *http.Request) {
+	param := r.URL.Path[len("/creator/"):]
+	if !strings.HasSuffix(param, "/events") {
+		http.NotFound(w, r)
+		return
+	}
+	// public keys are upper case hex, see peers.Peer.PubKeyHex
+	creator := strings.ToUpper(strings.TrimSuffix(param, "/events"))
+	creator = "0x" + strings.TrimPrefix(creator, "0X")
+
+	query := r.URL.Query()
+	var from int64
+	if param := query.Get("from"); param != "" {
+		var err error
+		if from, err = strconv.ParseInt(param, 10, 64); err != nil || from < 0 {
+			http.Error(w, "invalid from parameter "+param, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultEventsLimit
+	if param := query.Get("limit"); param != "" {
+		var err error
+		if limit, err = strconv.Atoi(param); err != nil || limit <= 0 || limit > maxEventsLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxEventsLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, err := s.node.GetCreatorEvents(creator, from, limit)
+	if common.Is(err, common.KeyNotFound) || common.Is(err, common.UnknownParticipant) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving events of creator %s", creator)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := eventsPage{Events: events}
+	if len(events) == limit {
+		page.Next = strconv.FormatInt(from+int64(limit), 10)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		s.logger.WithError(err).Errorf("Failed to encode events of creator %s", creator)
+	}
+}
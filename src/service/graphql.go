@@ -0,0 +1,319 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common/hexutil"
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+	"github.com/Fantom-foundation/go-lachesis/src/peers"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// graphqlSchema is the schema of the /graphql endpoint, over the DAG and
+// the blocks of the node. Hashes, keys and transactions are 0x prefixed hex.
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	event(hash: String!): Event
+	round(index: Int!): Round
+	block(index: Int!): Block
+	lastRound: Int!
+	participants: [Participant!]!
+}
+
+type Event {
+	hash: String!
+	creator: Participant
+	index: Int!
+	round: Int!
+	roundReceived: Int!
+	lamportTimestamp: Int!
+	selfParent: Event
+	otherParent: Event
+	parents: [Event!]!
+	transactions: [String!]!
+}
+
+type Round {
+	index: Int!
+	events: [Event!]!
+	clothos: [Event!]!
+	atropos: [Event!]!
+}
+
+type Block {
+	index: Int!
+	roundReceived: Int!
+	stateHash: String!
+	frameHash: String!
+	transactions: [String!]!
+}
+
+type Participant {
+	id: String!
+	pubKey: String!
+	netAddr: String!
+	height: Int!
+}
+`
+
+// The bounds of a /graphql query. Events link to their parents, so a query
+// could otherwise walk the whole DAG.
+const (
+	// graphqlMaxDepth bounds the nesting of the fields of a query
+	graphqlMaxDepth = 10
+	// graphqlMaxParallelism bounds the resolvers of a query run at once
+	graphqlMaxParallelism = 10
+	// graphqlMaxItems cuts the lists a field returns
+	graphqlMaxItems = 1000
+	// maxGraphQLSize bounds the size of the body of a /graphql request
+	maxGraphQLSize = 1 << 16
+)
+
+// newGraphQLHandler serves the GraphQL queries over n
+func newGraphQLHandler(n *node.Node) http.Handler {
+	schema := graphql.MustParseSchema(graphqlSchema, &gqlQuery{node: n},
+		graphql.MaxDepth(graphqlMaxDepth),
+		graphql.MaxParallelism(graphqlMaxParallelism))
+	handler := &relay.Handler{Schema: schema}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxGraphQLSize)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+type gqlQuery struct {
+	node *node.Node
+}
+
+func (q *gqlQuery) Event(args struct{ Hash string }) (*gqlEvent, error) {
+	var hash poset.EventHash
+	if err := hash.Parse(args.Hash); err != nil {
+		return nil, err
+	}
+	return q.event(hash), nil
+}
+
+// event returns the event, nil if the node does not know it
+func (q *gqlQuery) event(hash poset.EventHash) *gqlEvent {
+	event, err := q.node.GetEventBlock(hash)
+	if err != nil {
+		return nil
+	}
+	return &gqlEvent{q: q, event: event}
+}
+
+func (q *gqlQuery) Round(args struct{ Index int32 }) *gqlRound {
+	round, err := q.node.GetRound(int64(args.Index))
+	if err != nil {
+		return nil
+	}
+	return &gqlRound{q: q, index: args.Index, round: round}
+}
+
+func (q *gqlQuery) Block(args struct{ Index int32 }) *gqlBlock {
+	block, err := q.node.GetBlock(int64(args.Index))
+	if err != nil {
+		return nil
+	}
+	return &gqlBlock{block: block}
+}
+
+func (q *gqlQuery) LastRound() int32 {
+	return int32(q.node.GetLastRound())
+}
+
+func (q *gqlQuery) Participants() ([]*gqlParticipant, error) {
+	participants, err := q.node.GetParticipants()
+	if err != nil {
+		return nil, err
+	}
+	var res []*gqlParticipant
+	for _, peer := range participants.ToPeerSlice() {
+		if len(res) == graphqlMaxItems {
+			break
+		}
+		res = append(res, &gqlParticipant{peer: peer})
+	}
+	return res, nil
+}
+
+// participant returns the participant of the public key, nil if it is not
+// one
+func (q *gqlQuery) participant(pubKey string) *gqlParticipant {
+	participants, err := q.node.GetParticipants()
+	if err != nil {
+		return nil
+	}
+	peer, ok := participants.ReadByPubKey(pubKey)
+	if !ok {
+		return nil
+	}
+	return &gqlParticipant{peer: &peer}
+}
+
+type gqlEvent struct {
+	q     *gqlQuery
+	event poset.Event
+}
+
+func (e *gqlEvent) Hash() string {
+	hash := e.event.Hash()
+	return hash.String()
+}
+
+func (e *gqlEvent) Creator() *gqlParticipant {
+	return e.q.participant(e.event.GetCreator())
+}
+
+func (e *gqlEvent) Index() int32 {
+	return int32(e.event.Index())
+}
+
+func (e *gqlEvent) Round() int32 {
+	return int32(e.event.GetRound())
+}
+
+func (e *gqlEvent) RoundReceived() int32 {
+	return int32(e.event.GetRoundReceived())
+}
+
+func (e *gqlEvent) LamportTimestamp() int32 {
+	return int32(e.event.GetLamportTimestamp())
+}
+
+func (e *gqlEvent) SelfParent() *gqlEvent {
+	return e.q.event(e.event.SelfParent())
+}
+
+func (e *gqlEvent) OtherParent() *gqlEvent {
+	return e.q.event(e.event.OtherParent())
+}
+
+// Parents returns the known parents, self parent first
+func (e *gqlEvent) Parents() []*gqlEvent {
+	var parents []*gqlEvent
+	for _, parent := range []*gqlEvent{e.SelfParent(), e.OtherParent()} {
+		if parent != nil {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+func (e *gqlEvent) Transactions() []string {
+	return hexTransactions(e.event.Transactions())
+}
+
+type gqlRound struct {
+	q     *gqlQuery
+	index int32
+	round poset.RoundCreated
+}
+
+func (r *gqlRound) Index() int32 {
+	return r.index
+}
+
+// events returns the events of the round which pass filter, in hash order,
+// up to graphqlMaxItems
+func (r *gqlRound) events(filter func(*poset.RoundEvent) bool) []*gqlEvent {
+	hashes := make([]string, 0, len(r.round.Message.Events))
+	for hash, roundEvent := range r.round.Message.Events {
+		if filter(roundEvent) {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+
+	var events []*gqlEvent
+	for _, raw := range hashes {
+		var hash poset.EventHash
+		if err := hash.Parse(raw); err != nil {
+			continue
+		}
+		if len(events) == graphqlMaxItems {
+			break
+		}
+		if event := r.q.event(hash); event != nil {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (r *gqlRound) Events() []*gqlEvent {
+	return r.events(func(*poset.RoundEvent) bool { return true })
+}
+
+func (r *gqlRound) Clothos() []*gqlEvent {
+	return r.events(func(e *poset.RoundEvent) bool { return e.Clotho })
+}
+
+func (r *gqlRound) Atropos() []*gqlEvent {
+	return r.events(func(e *poset.RoundEvent) bool { return e.Atropos == poset.Trilean_TRUE })
+}
+
+type gqlBlock struct {
+	block poset.Block
+}
+
+func (b *gqlBlock) Index() int32 {
+	return int32(b.block.Index())
+}
+
+func (b *gqlBlock) RoundReceived() int32 {
+	return int32(b.block.RoundReceived())
+}
+
+func (b *gqlBlock) StateHash() string {
+	return hexutil.Encode(b.block.StateHash)
+}
+
+func (b *gqlBlock) FrameHash() string {
+	return hexutil.Encode(b.block.FrameHash)
+}
+
+func (b *gqlBlock) Transactions() []string {
+	return hexTransactions(b.block.Transactions())
+}
+
+type gqlParticipant struct {
+	peer *peers.Peer
+}
+
+func (p *gqlParticipant) ID() string {
+	return strconv.FormatUint(p.peer.ID, 10)
+}
+
+func (p *gqlParticipant) PubKey() string {
+	return p.peer.PubKeyHex
+}
+
+func (p *gqlParticipant) NetAddr() string {
+	return p.peer.NetAddr
+}
+
+func (p *gqlParticipant) Height() int32 {
+	return int32(p.peer.Height)
+}
+
+// hexTransactions encodes the first graphqlMaxItems transactions
+func hexTransactions(txs [][]byte) []string {
+	if len(txs) > graphqlMaxItems {
+		txs = txs[:graphqlMaxItems]
+	}
+	res := make([]string, len(txs))
+	for i, tx := range txs {
+		res[i] = hexutil.Encode(tx)
+	}
+	return res
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"net/http"
+)
+
+// Healthz answers as long as the process is up, for liveness probes
+func (s *Service) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write([]byte("ok\n")); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
+// Readyz answers 200 while the node can serve its clients, 503 with the
+// reason otherwise, for readiness probes and load balancers
+func (s *Service) Readyz(w http.ResponseWriter, r *http.Request) {
+	if err := s.node.Ready(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.Healthz(w, r)
+}
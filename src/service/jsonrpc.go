@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common/hexutil"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// maxRPCSize bounds the size of the body of a /rpc request
+const maxRPCSize = 1 << 20
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+	rpcUnauthorized   = -32001
+)
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string
+	ID      json.RawMessage
+	Result  interface{}
+	Error   *rpcError
+}
+
+// MarshalJSON sets either the result, even if empty, or the error
+func (r rpcResponse) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Error   *rpcError       `json:"error"`
+		}{r.JSONRPC, r.ID, r.Error})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  interface{}     `json:"result"`
+	}{r.JSONRPC, r.ID, r.Result})
+}
+
+// rpcMethod answers the params of a call, or returns an *rpcError
+type rpcMethod func(s *Service, params []json.RawMessage) (interface{}, error)
+
+var rpcMethods = map[string]rpcMethod{
+	"lachesis_submitTx": rpcSubmitTx,
+	"lachesis_getBlock": rpcGetBlock,
+	"lachesis_getStats": rpcGetStats,
+	"lachesis_getPeers": rpcGetPeers,
+}
+
+// rpcProtected are the methods which need credentials, see EnableAuth
+var rpcProtected = map[string]bool{
+	"lachesis_submitTx": true,
+}
+
+// JSONRPC serves the JSON-RPC 2.0 API: single and batch calls, POSTed
+func (s *Service) JSONRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC calls are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRPCSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	authorized := s.authorized(r)
+	var res interface{}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			res = rpcFailure(nil, rpcParseError, err.Error())
+		} else if len(batch) == 0 {
+			res = rpcFailure(nil, rpcInvalidRequest, "empty batch")
+		} else {
+			var answers []rpcResponse
+			for _, call := range batch {
+				if answer, ok := s.rpcCall(call, authorized); ok {
+					answers = append(answers, answer)
+				}
+			}
+			if len(answers) == 0 {
+				// a batch of notifications is not answered
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			res = answers
+		}
+	} else {
+		answer, ok := s.rpcCall(body, authorized)
+		if !ok {
+			// a notification is not answered
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		res = answer
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		s.logger.WithError(err).Debug("Writing JSON-RPC response")
+	}
+}
+
+// rpcCall answers a call, and returns false for a notification, a call
+// without id. The protected methods are only called if authorized.
+func (s *Service) rpcCall(data []byte, authorized bool) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return rpcFailure(nil, rpcParseError, err.Error()), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcFailure(req.ID, rpcInvalidRequest, "not a JSON-RPC 2.0 request"), true
+	}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcFailure(req.ID, rpcMethodNotFound, "method "+req.Method+" not found"), req.ID != nil
+	}
+	if rpcProtected[req.Method] && !authorized {
+		return rpcFailure(req.ID, rpcUnauthorized, errUnauthorized.Error()), req.ID != nil
+	}
+	result, err := method(s, req.Params)
+	if req.ID == nil {
+		return rpcResponse{}, false
+	}
+	if err != nil {
+		if e, ok := err.(*rpcError); ok {
+			return rpcFailure(req.ID, e.Code, e.Message), true
+		}
+		return rpcFailure(req.ID, rpcServerError, err.Error()), true
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+}
+
+func rpcFailure(id json.RawMessage, code int, message string) rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// rpcParams decodes params into the values pointed to by args
+func rpcParams(params []json.RawMessage, args ...interface{}) error {
+	if len(params) != len(args) {
+		return &rpcError{rpcInvalidParams, fmt.Sprintf("expected %d params, got %d", len(args), len(params))}
+	}
+	for i, param := range params {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return &rpcError{rpcInvalidParams, fmt.Sprintf("param %d: %v", i, err)}
+		}
+	}
+	return nil
+}
+
+// rpcSubmitTx submits the 0x prefixed hex transaction and returns its hash
+func rpcSubmitTx(s *Service, params []json.RawMessage) (interface{}, error) {
+	var tx hexutil.Bytes
+	if err := rpcParams(params, &tx); err != nil {
+		return nil, err
+	}
+	if err := s.node.SubmitTx(tx); err != nil {
+		return nil, err
+	}
+	return poset.TxHash(tx), nil
+}
+
+// rpcGetBlock returns the block at the index
+func rpcGetBlock(s *Service, params []json.RawMessage) (interface{}, error) {
+	var index int64
+	if err := rpcParams(params, &index); err != nil {
+		return nil, err
+	}
+	return s.node.GetBlock(index)
+}
+
+func rpcGetStats(s *Service, params []json.RawMessage) (interface{}, error) {
+	if err := rpcParams(params); err != nil {
+		return nil, err
+	}
+	return s.node.GetStats(), nil
+}
+
+func rpcGetPeers(s *Service, params []json.RawMessage) (interface{}, error) {
+	if err := rpcParams(params); err != nil {
+		return nil, err
+	}
+	participants, err := s.node.GetParticipants()
+	if err != nil {
+		return nil, err
+	}
+	return participants.ToPeerSlice(), nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Fantom-foundation/go-lachesis/src/version"
+)
+
+// openAPIDocument returns the OpenAPI 3 document of the routes
+func openAPIDocument(routes []route) ([]byte, error) {
+	paths := make(map[string]map[string]interface{})
+	for _, r := range routes {
+		op := map[string]interface{}{
+			"summary": r.summary,
+			"responses": map[string]interface{}{
+				"200": map[string]string{"description": "OK"},
+			},
+		}
+		if len(r.params) > 0 {
+			var params []interface{}
+			for _, p := range r.params {
+				schema := "string"
+				if p.integer {
+					schema = "integer"
+				}
+				params = append(params, map[string]interface{}{
+					"name":        p.name,
+					"in":          p.in,
+					"description": p.summary,
+					"required":    p.in == "path",
+					"schema":      map[string]string{"type": schema},
+				})
+			}
+			op["parameters"] = params
+		}
+		if r.protected {
+			op["security"] = []interface{}{map[string][]string{"bearer": {}}}
+			op["responses"].(map[string]interface{})["401"] = map[string]string{"description": "Unauthorized"}
+		}
+		if paths[r.path] == nil {
+			paths[r.path] = make(map[string]interface{})
+		}
+		paths[r.path][strings.ToLower(r.method)] = op
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]string{
+			"title":   "Lachesis",
+			"version": version.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]string{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}, "", "  ")
+}
+
+// OpenAPI returns the OpenAPI document of the API
+func (s *Service) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(s.openAPI); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
+// swaggerUI renders /openapi.json with the Swagger UI of a CDN
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Lachesis API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUI serves the Swagger UI of the API
+func (s *Service) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(swaggerUI)); err != nil {
+		s.logger.Debug(err)
+	}
+}
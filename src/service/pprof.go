@@ -0,0 +1,21 @@
+package service
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// EnablePprof serves the net/http/pprof profiles under /debug/pprof/, behind
+// the credentials of EnableAuth and the rate limits
+func (s *Service) EnablePprof() {
+	s.pprof = true
+}
+
+func (s *Service) handlePprof(mux *http.ServeMux) {
+	// pprof.Index serves the named profiles too, e.g. /debug/pprof/heap
+	mux.HandleFunc("/debug/pprof/", s.rateLimit(s.requireAuth(pprof.Index)))
+	mux.HandleFunc("/debug/pprof/cmdline", s.rateLimit(s.requireAuth(pprof.Cmdline)))
+	mux.HandleFunc("/debug/pprof/profile", s.rateLimit(s.requireAuth(pprof.Profile)))
+	mux.HandleFunc("/debug/pprof/symbol", s.rateLimit(s.requireAuth(pprof.Symbol)))
+	mux.HandleFunc("/debug/pprof/trace", s.rateLimit(s.requireAuth(pprof.Trace)))
+}
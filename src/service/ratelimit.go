@@ -0,0 +1,119 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultRateBurst and DefaultGlobalRateBurst are the bursts of requests
+// over the rate limits a client IP, and all the clients, may make
+const (
+	DefaultRateBurst       = 20
+	DefaultGlobalRateBurst = 200
+)
+
+// rateLimiterIdle is the time after which the limiter of a client IP which
+// made no request is dropped
+const rateLimiterIdle = 3 * time.Minute
+
+// rateLimiter limits the requests per second of every client IP, and of all
+// of them together. A zero rate disables a limit.
+type rateLimiter struct {
+	global *rate.Limiter
+
+	ipRate  rate.Limit
+	ipBurst int
+
+	lock      sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(ipQPS float64, ipBurst int, globalQPS float64, globalBurst int) *rateLimiter {
+	l := &rateLimiter{
+		ipRate:    rate.Limit(ipQPS),
+		ipBurst:   ipBurst,
+		clients:   make(map[string]*clientLimiter),
+		lastSweep: time.Now(),
+	}
+	if globalQPS > 0 {
+		l.global = rate.NewLimiter(rate.Limit(globalQPS), globalBurst)
+	}
+	return l
+}
+
+// allow tells whether a request of the client IP may be served now
+func (l *rateLimiter) allow(ip string) bool {
+	if l.ipRate > 0 && !l.client(ip).Allow() {
+		return false
+	}
+	return l.global == nil || l.global.Allow()
+}
+
+// client returns the limiter of the client IP, and drops the idle ones
+func (l *rateLimiter) client(ip string) *rate.Limiter {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now()
+	if now.Sub(l.lastSweep) > rateLimiterIdle {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > rateLimiterIdle {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(l.ipRate, l.ipBurst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+// SetRateLimits limits the requests per second, and their bursts, which
+// every client IP and all the clients together may make. The requests which
+// carry valid credentials are not limited, see EnableAuth. A zero rate
+// disables a limit.
+func (s *Service) SetRateLimits(ipQPS float64, ipBurst int, globalQPS float64, globalBurst int) {
+	if ipQPS <= 0 && globalQPS <= 0 {
+		s.limiter = nil
+		return
+	}
+	s.limiter = newRateLimiter(ipQPS, ipBurst, globalQPS, globalBurst)
+}
+
+// rateLimit answers 429 to the requests over the rate limits instead of
+// serving them with h. Authenticated requests are served at once; without
+// credentials configured, no request is.
+func (s *Service) rateLimit(h http.HandlerFunc) http.HandlerFunc {
+	if s.limiter == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.authEnabled() && s.authorized(r) {
+			h(w, r)
+			return
+		}
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		if !s.limiter.allow(ip) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		h(w, r)
+	}
+}
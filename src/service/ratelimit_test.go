@@ -0,0 +1,56 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	s := testService()
+	s.SetRateLimits(0.001, 2, 0, 0)
+	h := testHandler(s)
+
+	for i := 0; i < 2; i++ {
+		if w := serve(h, "GET", "/stats", ""); w.Code != http.StatusOK {
+			t.Fatalf("expected the burst to be served, got %d", w.Code)
+		}
+	}
+	w := serve(h, "GET", "/stats", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected a Retry-After header")
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("expected the CORS headers in the 429 answer")
+	}
+
+	// the unauthenticated requests of the protected routes are limited too
+	if w := serve(h, "POST", "/tx", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for a request without credentials, got %d", w.Code)
+	}
+	// and the authenticated ones are not
+	if w := serve(h, "POST", "/tx", "Bearer "+testToken); w.Code != http.StatusOK {
+		t.Fatalf("expected an authenticated request to be served, got %d", w.Code)
+	}
+}
+
+func TestGlobalRateLimit(t *testing.T) {
+	s := testService()
+	s.SetRateLimits(0, 0, 0.001, 1)
+	h := testHandler(s)
+
+	if w := serve(h, "GET", "/stats", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected the burst to be served, got %d", w.Code)
+	}
+	// from another client IP
+	r := httptest.NewRequest("GET", "/stats", nil)
+	r.RemoteAddr = "192.0.2.2:1234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the global limit, got %d", w.Code)
+	}
+}
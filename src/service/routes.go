@@ -0,0 +1,184 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// route is an endpoint of the API. The mux and the OpenAPI document are both
+// built from the routes.
+type route struct {
+	// path is the OpenAPI path, the mux pattern ends before its first
+	// parameter
+	path    string
+	method  string
+	summary string
+	params  []routeParam
+	// protected routes need the credentials of EnableAuth
+	protected bool
+	// cors routes may be called by browsers from other origins, see SetCORS
+	cors    bool
+	handler http.HandlerFunc
+}
+
+// routeParam is a path or query parameter of a route
+type routeParam struct {
+	name    string
+	in      string
+	integer bool
+	summary string
+}
+
+func pathParam(name, summary string, integer bool) routeParam {
+	return routeParam{name: name, in: "path", integer: integer, summary: summary}
+}
+
+func queryParam(name, summary string, integer bool) routeParam {
+	return routeParam{name: name, in: "query", integer: integer, summary: summary}
+}
+
+// pattern is the mux pattern of the route
+func (r route) pattern() string {
+	if i := strings.Index(r.path, "{"); i >= 0 {
+		return r.path[:i]
+	}
+	return r.path
+}
+
+// routes are the endpoints the service serves
+func (s *Service) routes() []route {
+	blockIndex := pathParam("index", "index of the block", true)
+	roundIndex := pathParam("index", "index of the round", true)
+	participant := pathParam("participant", "0x prefixed hex public key of the participant", false)
+
+	routes := []route{
+		{path: "/healthz", method: "GET", summary: "Liveness of the process", handler: s.Healthz},
+		{path: "/readyz", method: "GET", summary: "Readiness of the node, 503 with the reason if it is not ready", handler: s.Readyz},
+		{path: "/stats", method: "GET", summary: "Stats of the node", cors: true, handler: s.GetStats},
+		{path: "/stats/history", method: "GET", summary: "Samples of the key stats of the last hour, every 5 seconds", cors: true, handler: s.GetStatsHistory},
+		{path: "/participants/", method: "GET", summary: "Participants of the network", cors: true, handler: s.GetParticipants},
+		{path: "/peers", method: "GET", summary: "Peers of the node, with their heights and connection health", cors: true, handler: s.GetPeers},
+		{path: "/event/{hash}", method: "GET", summary: "Event, with its round, consensus timestamp and parents",
+			params: []routeParam{pathParam("hash", "0x prefixed hex hash of the event", false)}, cors: true, handler: s.GetEventBlock},
+		{path: "/lasteventfrom/{participant}", method: "GET", summary: "Hash of the last event of a participant",
+			params: []routeParam{participant}, cors: true, handler: s.GetLastEventFrom},
+		{path: "/events/", method: "GET", summary: "Index of the last known event of every participant", cors: true, handler: s.GetKnownEvents},
+		{path: "/consensusevents/", method: "GET", summary: "Hashes of the events which reached consensus", cors: true, handler: s.GetConsensusEvents},
+		{path: "/round/{index}", method: "GET", summary: "Round, with its witnesses, fame decisions, events and block",
+			params: []routeParam{roundIndex}, cors: true, handler: s.GetRound},
+		{path: "/round/{index}/witnesses", method: "GET", summary: "Clotho status of a round",
+			params: []routeParam{roundIndex}, cors: true, handler: s.GetRound},
+		{path: "/lastround/", method: "GET", summary: "Index of the last round", cors: true, handler: s.GetLastRound},
+		{path: "/roundclothos/{index}", method: "GET", summary: "Hashes of the clothos of a round",
+			params: []routeParam{roundIndex}, cors: true, handler: s.GetRoundClothos},
+		{path: "/roundevents/{index}", method: "GET", summary: "Number of events of a round",
+			params: []routeParam{roundIndex}, cors: true, handler: s.GetRoundEvents},
+		{path: "/root/{participant}", method: "GET", summary: "Root of a participant",
+			params: []routeParam{participant}, cors: true, handler: s.GetRoot},
+		{path: "/block/{index}", method: "GET", summary: "Block",
+			params: []routeParam{blockIndex}, cors: true, handler: s.GetBlock},
+		{path: "/block/{index}/receipts", method: "GET", summary: "Results of the transactions of a block",
+			params: []routeParam{blockIndex}, cors: true, handler: s.GetBlock},
+		{path: "/block/{index}/events", method: "GET", summary: "Events a block is made of, with their details",
+			params: []routeParam{blockIndex}, cors: true, handler: s.GetBlock},
+		{path: "/blocks", method: "GET", summary: "Page of blocks",
+			params: []routeParam{
+				queryParam("from", "index of the first block, 0 by default", true),
+				queryParam("limit", "number of blocks, 100 by default and at most 1000", true),
+			}, cors: true, handler: s.GetBlocks},
+		{path: "/blocks/stream", method: "GET", summary: "Server-sent events stream of the committed blocks", cors: true, handler: s.StreamBlocks},
+		{path: "/creator/{participant}/events", method: "GET", summary: "Page of the events of a creator, with their details",
+			params: []routeParam{
+				participant,
+				queryParam("from", "index of the first event, 0 by default", true),
+				queryParam("limit", "number of events, 100 by default and at most 500", true),
+			}, cors: true, handler: s.GetCreatorEvents},
+		{path: "/random/{index}", method: "GET", summary: "Random beacon value of a block",
+			params: []routeParam{blockIndex}, cors: true, handler: s.GetBlockRandom},
+		{path: "/tx", method: "POST", summary: "Submit a raw transaction, or a base64 one in JSON, and get its hash",
+			params:    []routeParam{queryParam("priority", "priority hint, the higher priorities are put in events first", true)},
+			protected: true, cors: true, handler: s.SubmitTx},
+		{path: "/tx/{hash}", method: "GET", summary: "Status of a transaction: pending, or committed with its block and position",
+			params: []routeParam{pathParam("hash", "0x prefixed hex hash of the transaction", false)}, cors: true, handler: s.GetTxStatus},
+		{path: "/query", method: "POST", summary: "Query of the app, forwarded raw", cors: true, handler: s.Query},
+		{path: "/ws", method: "GET", summary: "WebSocket of the new blocks, events and transaction confirmations", handler: s.Subscribe},
+		{path: "/rpc", method: "POST", summary: "JSON-RPC 2.0 calls, lachesis_submitTx needs credentials", cors: true, handler: s.JSONRPC},
+		{path: "/graphql", method: "POST", summary: "GraphQL query over the events, rounds, blocks and participants",
+			cors: true, handler: newGraphQLHandler(s.node).ServeHTTP},
+		{path: "/metrics", method: "GET", summary: "Metrics in the Prometheus text format", handler: promhttp.Handler().ServeHTTP},
+		{path: "/openapi.json", method: "GET", summary: "OpenAPI document of the API", cors: true, handler: s.OpenAPI},
+		{path: "/swagger/", method: "GET", summary: "Swagger UI of the API", handler: s.SwaggerUI},
+	}
+	if s.admin {
+		routes = append(routes,
+			route{path: "/admin/backup", method: "GET", summary: "Backup of the store",
+				protected: true, handler: s.Backup},
+			route{path: "/admin/gossip/pause", method: "POST", summary: "Stop initiating gossips",
+				protected: true, handler: adminPost(s.PauseGossip)},
+			route{path: "/admin/gossip/resume", method: "POST", summary: "Initiate gossips again",
+				protected: true, handler: adminPost(s.ResumeGossip)},
+			route{path: "/admin/suspend", method: "POST", summary: "Stop creating events and initiating gossips",
+				protected: true, handler: adminPost(s.Suspend)},
+			route{path: "/admin/resume", method: "POST", summary: "Create events and gossip again",
+				protected: true, handler: adminPost(s.Resume)},
+			route{path: "/admin/fastforward", method: "POST", summary: "Catch up with a peer",
+				protected: true, handler: adminPost(s.FastForward)},
+			route{path: "/admin/loglevel", method: "GET", summary: "Log level",
+				protected: true, handler: s.LogLevel},
+			route{path: "/admin/loglevel", method: "POST", summary: "Set the log level",
+				params:    []routeParam{queryParam("level", "debug, info, warn, error, fatal or panic", false)},
+				protected: true, handler: s.LogLevel},
+			route{path: "/admin/config", method: "GET", summary: "Reloadable config of the node",
+				protected: true, handler: s.Config},
+			route{path: "/admin/config", method: "POST", summary: "Reload the config of the node",
+				params: []routeParam{
+					queryParam("heartbeat", "time between gossips, e.g. 500ms", false),
+					queryParam("sync-limit", "max number of events for sync", true),
+					queryParam("cache-size", "number of items in LRU caches", true),
+					queryParam("log", "debug, info, warn, error, fatal or panic", false),
+				}, protected: true, handler: s.Config},
+			route{path: "/admin/faults", method: "GET", summary: "Faults the node injects, with fault-injection",
+				protected: true, handler: s.Faults},
+			route{path: "/admin/faults", method: "POST", summary: "Set the faults the node injects, with fault-injection",
+				params: []routeParam{
+					queryParam("drop", "share of the RPCs dropped, 0 to 1", false),
+					queryParam("delay", "time every RPC is held back, e.g. 50ms", false),
+					queryParam("duplicate", "share of the RPCs sent twice, 0 to 1", false),
+					queryParam("reorder", "share of the RPCs held back up to 100ms more, 0 to 1", false),
+					queryParam("store-write", "share of the store writes failed, 0 to 1", false),
+					queryParam("peer", "address of the only peer whose RPCs get faults", false),
+				}, protected: true, handler: s.Faults},
+			route{path: "/admin/compact", method: "POST", summary: "Compact the store",
+				protected: true, handler: adminPost(s.CompactStore)},
+			route{path: "/admin/shutdown", method: "POST", summary: "Shut the node down",
+				protected: true, handler: adminPost(s.ShutdownNode)},
+		)
+	}
+	return routes
+}
+
+// handleRoutes registers the routes on mux, once per pattern: the routes of
+// a pattern share its handler. The CORS headers are set first, so that the
+// browsers read the errors too, then the routes are rate limited, and the
+// protected ones need credentials.
+func (s *Service) handleRoutes(mux *http.ServeMux, routes []route) {
+	registered := make(map[string]bool)
+	for _, r := range routes {
+		pattern := r.pattern()
+		if registered[pattern] {
+			continue
+		}
+		registered[pattern] = true
+		handler := r.handler
+		if r.protected {
+			handler = s.requireAuth(handler)
+		}
+		handler = s.rateLimit(handler)
+		if r.cors {
+			handler = s.cors(handler)
+		}
+		mux.Handle(pattern, handler)
+	}
+}
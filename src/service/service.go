@@ -1,12 +1,21 @@
 package service
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/Fantom-foundation/go-lachesis/src/common"
 	"github.com/Fantom-foundation/go-lachesis/src/node"
 	"github.com/Fantom-foundation/go-lachesis/src/poset"
+	"github.com/Fantom-foundation/go-lachesis/src/proxy"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +25,21 @@ type Service struct {
 	node        *node.Node
 	graph       *node.Graph
 	logger      *logrus.Logger
+	admin       bool
+	token       string
+	jwtSecret   []byte
+	pprof       bool
+	corsOrigins []string
+	corsMethods string
+	tls         *tls.Config
+	limiter     *rateLimiter
+	openAPI     []byte
+
+	serverLock sync.Mutex
+	server     *http.Server
+	// done is closed by Shutdown, to end the streams
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 // NewService creates a new http API service
@@ -25,52 +49,92 @@ func NewService(bindAddress string, n *node.Node, logger *logrus.Logger) *Servic
 		node:        n,
 		graph:       node.NewGraph(n),
 		logger:      logger,
+		corsOrigins: DefaultCORSOrigins,
+		corsMethods: strings.Join(DefaultCORSMethods, ", "),
+		done:        make(chan struct{}),
 	}
 
 	return &service
 }
 
-// Serve serves the API
-func (s *Service) Serve() {
-	s.logger.WithField("bind_address", s.bindAddress).Debug("Service serving")
-	mux := http.NewServeMux()
-	mux.Handle("/stats", corsHandler(s.GetStats))
-	mux.Handle("/participants/", corsHandler(s.GetParticipants))
-	mux.Handle("/event/", corsHandler(s.GetEventBlock))
-	mux.Handle("/lasteventfrom/", corsHandler(s.GetLastEventFrom))
-	mux.Handle("/events/", corsHandler(s.GetKnownEvents))
-	mux.Handle("/consensusevents/", corsHandler(s.GetConsensusEvents))
-	mux.Handle("/round/", corsHandler(s.GetRound))
-	mux.Handle("/lastround/", corsHandler(s.GetLastRound))
-	mux.Handle("/roundclothos/", corsHandler(s.GetRoundClothos))
-	mux.Handle("/roundevents/", corsHandler(s.GetRoundEvents))
-	mux.Handle("/root/", corsHandler(s.GetRoot))
-	mux.Handle("/block/", corsHandler(s.GetBlock))
-	err := http.ListenAndServe(s.bindAddress, mux)
+// EnableAdmin serves the /admin/ endpoints, which must not be reachable by
+// untrusted clients
+func (s *Service) EnableAdmin() {
+	s.admin = true
+}
+
+// Handler returns the handler of the API, on a mux of its own so that
+// several services can live in one process
+func (s *Service) Handler() http.Handler {
+	routes := s.routes()
+	doc, err := openAPIDocument(routes)
 	if err != nil {
-		s.logger.WithField("error", err).Error("Service failed")
+		s.logger.WithError(err).Error("Building OpenAPI document")
 	}
+	s.openAPI = doc
+
+	mux := http.NewServeMux()
+	s.handleRoutes(mux, routes)
+	if s.pprof {
+		s.handlePprof(mux)
+	}
+	return mux
 }
 
-func corsHandler(h http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
-		if r.Method == "OPTIONS" {
-			/*w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")*/
+// Start listens on the bind address, a TCP address or a unix socket as
+// unix:///path/to/socket, and serves the API in the background, until
+// Shutdown. A service which was shut down cannot start again.
+func (s *Service) Start() error {
+	s.serverLock.Lock()
+	defer s.serverLock.Unlock()
+	if s.server != nil {
+		return fmt.Errorf("service already serving on %s", s.bindAddress)
+	}
+	select {
+	case <-s.done:
+		return errors.New("service is shut down")
+	default:
+	}
+
+	ln, err := proxy.ListenSocket(proxy.SplitSocketAddr(s.bindAddress))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:   s.Handler(),
+		TLSConfig: s.tls,
+	}
+	s.server = server
+	s.logger.WithField("bind_address", ln.Addr().String()).Debug("Service serving")
+
+	go func() {
+		var err error
+		if s.tls != nil {
+			// the certificate is in TLSConfig
+			err = server.ServeTLS(ln, "", "")
 		} else {
-			/*w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")*/
-			h.ServeHTTP(w, r)
+			err = server.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.WithField("error", err).Error("Service failed")
 		}
+	}()
+	return nil
+}
+
+// Shutdown stops the service: it ends the streams, stops listening and
+// waits for the requests in flight until ctx is done
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.doneOnce.Do(func() { close(s.done) })
+
+	s.serverLock.Lock()
+	server := s.server
+	s.server = nil
+	s.serverLock.Unlock()
+	if server == nil {
+		return nil
 	}
+	return server.Shutdown(ctx)
 }
 
 // GetStats returns all the node processing stats
@@ -85,6 +149,15 @@ func (s *Service) GetStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetStatsHistory returns the samples of the key stats of the last hour,
+// oldest first
+func (s *Service) GetStatsHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.node.GetStatsHistory()); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
 // GetParticipants returns all the known participants
 func (s *Service) GetParticipants(w http.ResponseWriter, r *http.Request) {
 	participants, err := s.node.GetParticipants()
@@ -99,20 +172,36 @@ func (s *Service) GetParticipants(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetEventBlock returns a specific event block by id
+// GetPeers returns the peers the node gossips with, and how healthy the
+// connections to them are
+func (s *Service) GetPeers(w http.ResponseWriter, r *http.Request) {
+	peers := s.node.GetPeers()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(peers); err != nil {
+		s.logger.Debug(err)
+	}
+}
+
+// GetEventBlock returns a specific event block by id, with its round,
+// consensus timestamp and parents
 func (s *Service) GetEventBlock(w http.ResponseWriter, r *http.Request) {
 	param := r.URL.Path[len("/event/"):]
 
 	var hash poset.EventHash
 	err := hash.Parse(param)
 	if err != nil {
-		s.logger.WithError(err).Errorf("Parsing event hash %s", param)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Debugf("Parsing event hash %s", param)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	event, err := s.node.GetEventBlock(hash)
+	event, err := s.node.GetEventInfo(hash)
 	if err != nil {
+		if common.Is(err, common.KeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		s.logger.WithError(err).Errorf("Retrieving event %s", param)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -160,18 +249,27 @@ func (s *Service) GetConsensusEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetRound returns a round for the given index
+// GetRound returns a round for the given index, with its witnesses, fame
+// decisions, events and block
 func (s *Service) GetRound(w http.ResponseWriter, r *http.Request) {
 	param := r.URL.Path[len("/round/"):]
+	if strings.HasSuffix(param, "/witnesses") {
+		s.GetRoundWitnesses(w, r)
+		return
+	}
 	roundIndex, err := strconv.ParseInt(param, 10, 64)
 	if err != nil {
-		s.logger.WithError(err).Errorf("Parsing roundIndex parameter %s", param)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Debugf("Parsing roundIndex parameter %s", param)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	round, err := s.node.GetRound(roundIndex)
+	round, err := s.node.GetRoundInfo(roundIndex)
 	if err != nil {
+		if common.Is(err, common.KeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		s.logger.WithError(err).Errorf("Retrieving round %d", roundIndex)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -183,6 +281,29 @@ func (s *Service) GetRound(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetRoundWitnesses returns the clotho/atropos status of a round
+func (s *Service) GetRoundWitnesses(w http.ResponseWriter, r *http.Request) {
+	param := strings.TrimSuffix(r.URL.Path[len("/round/"):], "/witnesses")
+	roundIndex, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Parsing roundIndex parameter %s", param)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status, err := s.node.GetRoundWitnesses(roundIndex)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving round %d witnesses", roundIndex)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.WithError(err).Errorf("Failed to encode round witnesses: %v", status)
+	}
+}
+
 // GetLastRound returns the last known round
 func (s *Service) GetLastRound(w http.ResponseWriter, r *http.Request) {
 	lastRound := s.node.GetLastRound()
@@ -245,9 +366,18 @@ func (s *Service) GetRoot(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetBlock returns a specific block based on index
+// GetBlock returns a specific block based on index, or its receipts at
+// /block/{index}/receipts
 func (s *Service) GetBlock(w http.ResponseWriter, r *http.Request) {
 	param := r.URL.Path[len("/block/"):]
+	if strings.HasSuffix(param, "/receipts") {
+		s.GetBlockReceipts(w, r, strings.TrimSuffix(param, "/receipts"))
+		return
+	}
+	if strings.HasSuffix(param, "/events") {
+		s.GetBlockEvents(w, r, strings.TrimSuffix(param, "/events"))
+		return
+	}
 	blockIndex, err := strconv.ParseInt(param, 10, 64)
 	if err != nil {
 		s.logger.WithError(err).Errorf("Parsing block_index parameter %s", param)
@@ -267,3 +397,157 @@ func (s *Service) GetBlock(w http.ResponseWriter, r *http.Request) {
 		s.logger.WithError(err).Errorf("Failed to encode block: %v", block)
 	}
 }
+
+// Bounds of the limit parameter of /blocks
+const (
+	defaultBlocksLimit = 100
+	maxBlocksLimit     = 1000
+)
+
+// blocksPage is a page of /blocks. Next is the from parameter of the next
+// page, empty on the last one.
+type blocksPage struct {
+	Blocks []poset.Block
+	Next   string `json:",omitempty"`
+}
+
+// GetBlocks returns the blocks from the index in the from parameter, at
+// most limit of them
+func (s *Service) GetBlocks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var from int64
+	if param := query.Get("from"); param != "" {
+		var err error
+		if from, err = strconv.ParseInt(param, 10, 64); err != nil || from < 0 {
+			http.Error(w, "invalid from parameter "+param, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultBlocksLimit
+	if param := query.Get("limit"); param != "" {
+		var err error
+		if limit, err = strconv.Atoi(param); err != nil || limit <= 0 || limit > maxBlocksLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxBlocksLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	blocks, more, err := s.node.GetBlocks(from, limit)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving blocks from %d", from)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page := blocksPage{Blocks: blocks}
+	if blocks == nil {
+		page.Blocks = []poset.Block{}
+	}
+	if more {
+		page.Next = strconv.FormatInt(blocks[len(blocks)-1].Index()+1, 10)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		s.logger.WithError(err).Errorf("Failed to encode blocks from %d", from)
+	}
+}
+
+// GetBlockReceipts returns the results of the transactions of a block
+func (s *Service) GetBlockReceipts(w http.ResponseWriter, r *http.Request, param string) {
+	blockIndex, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Parsing block_index parameter %s", param)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	receipts, err := s.node.GetReceipts(blockIndex)
+	if common.Is(err, common.KeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving receipts of block %d", blockIndex)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(receipts); err != nil {
+		s.logger.WithError(err).Errorf("Failed to encode receipts of block %d", blockIndex)
+	}
+}
+
+// GetBlockRandom returns the random beacon value of a specific block
+func (s *Service) GetBlockRandom(w http.ResponseWriter, r *http.Request) {
+	param := r.URL.Path[len("/random/"):]
+	blockIndex, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Parsing block_index parameter %s", param)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	block, err := s.node.GetBlock(blockIndex)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving block %d", blockIndex)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	random := block.RandomHex()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(random); err != nil {
+		s.logger.WithError(err).Errorf("Failed to encode block random: %s", random)
+	}
+}
+
+// maxQuerySize bounds the size of the body of a /query request
+const maxQuerySize = 1 << 20
+
+// Query forwards the body of the request to the app as a query and returns
+// its response, both raw bytes
+func (s *Service) Query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "queries are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	query, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxQuerySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	res, err := s.node.Query(query)
+	if err == proxy.ErrNoQuery {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		s.logger.WithError(err).Debug("Querying app")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := w.Write(res); err != nil {
+		s.logger.WithError(err).Debug("Writing query response")
+	}
+}
+
+// Backup streams a consistent backup of the store, taken while the node runs
+func (s *Service) Backup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=lachesis.backup")
+	n, err := s.node.Backup(w)
+	if err != nil {
+		s.logger.WithError(err).Error("Backing up store")
+		// the status can only be set if nothing was sent yet
+		if n == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	s.logger.WithField("bytes", n).Info("Backed up store")
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+)
+
+// sseKeepAlive is the time between the comments which keep idle /blocks/stream
+// connections open through proxies
+const sseKeepAlive = 15 * time.Second
+
+// StreamBlocks serves /blocks/stream, a server-sent events stream of the
+// blocks the node commits, for the clients which do not speak WebSocket
+func (s *Service) StreamBlocks(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	notes, unsubscribe := s.node.Subscribe(wsBuffer)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case note := <-notes:
+			if note.Type != node.FeedBlock {
+				continue
+			}
+			data, err := json.Marshal(note.Block)
+			if err != nil {
+				s.logger.WithError(err).Debug("Encoding streamed block")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: block\nid: %d\ndata: %s\n\n", note.Block.Index(), data); err != nil {
+				s.logger.WithError(err).Debug("Streaming block")
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
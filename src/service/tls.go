@@ -0,0 +1,19 @@
+package service
+
+import (
+	"crypto/tls"
+)
+
+// EnableTLS serves the API over HTTPS with the PEM encoded certificate and
+// key of the files
+func (s *Service) EnableTLS(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	s.tls = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Fantom-foundation/go-lachesis/src/common"
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// maxTxSize bounds the size of the body of a /tx request
+const maxTxSize = 1 << 20
+
+// txRequest is the JSON body of a /tx request, the transaction is base64
+// encoded
+type txRequest struct {
+	Tx       []byte `json:"tx"`
+	Priority *int   `json:"priority,omitempty"`
+	Key      string `json:"key,omitempty"`
+}
+
+// txResponse is the answer to a /tx request
+type txResponse struct {
+	Hash string `json:"hash"`
+}
+
+// SubmitTx adds the transaction of the request body to the transaction pool
+// and returns its hash. The body is the raw transaction, or a txRequest when
+// its content type is application/json. The priority parameter, or field,
+// is a hint which overrides the priority the app gives the transaction. A
+// full pool answers 503. The Idempotency-Key header, or the key field, is
+// an idempotency key: the retries of a submission are answered 200 with the
+// hash of the transaction first submitted with it, and dropped.
+func (s *Service) SubmitTx(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "transactions are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxTxSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	tx := body
+	key := r.Header.Get("Idempotency-Key")
+	var priority *int
+	if v := r.URL.Query().Get("priority"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid priority %q", v), http.StatusBadRequest)
+			return
+		}
+		priority = &p
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req txRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tx = req.Tx
+		if req.Priority != nil {
+			priority = req.Priority
+		}
+		if req.Key != "" {
+			key = req.Key
+		}
+	}
+	if len(tx) == 0 {
+		http.Error(w, "empty transaction", http.StatusBadRequest)
+		return
+	}
+
+	hash, submitted := poset.TxHash(tx), true
+	switch {
+	case key != "" && priority != nil:
+		hash, submitted, err = s.node.SubmitKeyedPriorityTx(key, tx, *priority)
+	case key != "":
+		hash, submitted, err = s.node.SubmitKeyedTx(key, tx)
+	case priority != nil:
+		err = s.node.SubmitPriorityTx(tx, *priority)
+	default:
+		err = s.node.SubmitTx(tx)
+	}
+	if err != nil {
+		s.logger.WithError(err).Debug("Submitting transaction")
+		if err == node.ErrPoolFull {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if submitted {
+		w.WriteHeader(http.StatusAccepted)
+	} else {
+		// a retry of a submission already taken
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := json.NewEncoder(w).Encode(txResponse{Hash: hash}); err != nil {
+		s.logger.WithError(err).Debug("Writing transaction hash")
+	}
+}
+
+// GetTxStatus returns whether the transaction of the hash is pending or
+// committed, and its block and position once it is committed
+func (s *Service) GetTxStatus(w http.ResponseWriter, r *http.Request) {
+	param := r.URL.Path[len("/tx/"):]
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(param), "0x"))
+	if err != nil || len(raw) != common.HashLength {
+		http.Error(w, fmt.Sprintf("invalid transaction hash %q", param), http.StatusBadRequest)
+		return
+	}
+
+	status, err := s.node.GetTxStatus(common.BytesToHash(raw).Hex())
+	if err != nil {
+		if common.Is(err, common.KeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		s.logger.WithError(err).Errorf("Retrieving transaction %s", param)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.Debug(err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/Fantom-foundation/go-lachesis/src/node"
+	"github.com/Fantom-foundation/go-lachesis/src/poset"
+)
+
+// Topics of the /ws subscriptions
+const (
+	topicNewBlock    = "newBlock"
+	topicNewEvent    = "newEvent"
+	topicTxConfirmed = "txConfirmed:"
+)
+
+// wsBuffer is the number of notifications a /ws client may lag behind
+// before it misses some
+const wsBuffer = 256
+
+// wsWriteTimeout is the time a /ws client has to accept a message
+const wsWriteTimeout = 10 * time.Second
+
+// wsRequest is sent by the /ws clients to subscribe to, or unsubscribe
+// from, a topic: newBlock, newEvent or txConfirmed:{hash}
+type wsRequest struct {
+	Subscribe   string `json:"subscribe,omitempty"`
+	Unsubscribe string `json:"unsubscribe,omitempty"`
+}
+
+// wsMessage is sent to the /ws clients for the topics they subscribed to.
+// A txConfirmed subscription ends with its message, which holds the block
+// index of the transaction.
+type wsMessage struct {
+	Topic string       `json:"topic"`
+	Block *poset.Block `json:"block,omitempty"`
+	Event *poset.Event `json:"event,omitempty"`
+	Index int64        `json:"index,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+// wsTopics are the topics of a /ws client
+type wsTopics struct {
+	lock   sync.Mutex
+	topics map[string]bool
+}
+
+func (t *wsTopics) update(req wsRequest) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if topic := req.Subscribe; topic != "" {
+		if topic != topicNewBlock && topic != topicNewEvent &&
+			(!strings.HasPrefix(topic, topicTxConfirmed) || len(topic) == len(topicTxConfirmed)) {
+			return "unknown topic " + topic
+		}
+		t.topics[topic] = true
+	}
+	delete(t.topics, req.Unsubscribe)
+	return ""
+}
+
+// messages returns the messages of note for the topics, and ends the
+// txConfirmed subscriptions it answers
+func (t *wsTopics) messages(note node.Notification) []wsMessage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch note.Type {
+	case node.FeedEvent:
+		if t.topics[topicNewEvent] {
+			return []wsMessage{{Topic: topicNewEvent, Event: note.Event}}
+		}
+	case node.FeedBlock:
+		var msgs []wsMessage
+		if t.topics[topicNewBlock] {
+			msgs = append(msgs, wsMessage{Topic: topicNewBlock, Block: note.Block})
+		}
+		for _, tx := range note.Block.Transactions() {
+			topic := topicTxConfirmed + poset.TxHash(tx)
+			if t.topics[topic] {
+				delete(t.topics, topic)
+				msgs = append(msgs, wsMessage{Topic: topic, Index: note.Block.Index()})
+			}
+		}
+		return msgs
+	}
+	return nil
+}
+
+// Subscribe serves the /ws WebSocket, which pushes the new blocks, the new
+// events and the confirmations of transactions to its clients
+func (s *Service) Subscribe(w http.ResponseWriter, r *http.Request) {
+	server := websocket.Server{
+		// like the other endpoints, /ws serves any origin
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   s.serveWS,
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (s *Service) serveWS(ws *websocket.Conn) {
+	defer ws.Close()
+	notes, unsubscribe := s.node.Subscribe(wsBuffer)
+	defer unsubscribe()
+
+	topics := &wsTopics{topics: make(map[string]bool)}
+	replies := make(chan wsMessage, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var req wsRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			if e := topics.update(req); e != "" {
+				select {
+				case replies <- wsMessage{Topic: req.Subscribe, Error: e}:
+				default:
+				}
+			}
+		}
+	}()
+
+	send := func(msg wsMessage) bool {
+		if err := ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+			return false
+		}
+		if err := websocket.JSON.Send(ws, msg); err != nil {
+			s.logger.WithError(err).Debug("Sending to ws client")
+			return false
+		}
+		return true
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case <-s.done:
+			return
+		case msg := <-replies:
+			if !send(msg) {
+				return
+			}
+		case note := <-notes:
+			for _, msg := range topics.messages(note) {
+				if !send(msg) {
+					return
+				}
+			}
+		}
+	}
+}